@@ -9,6 +9,8 @@ MON_SECRET_CLUSTER_NAME_KEYNAME=cluster-name
 MON_SECRET_FSID_KEYNAME=fsid
 MON_SECRET_ADMIN_KEYRING_KEYNAME=admin-secret
 MON_SECRET_MON_KEYRING_KEYNAME=mon-secret
+MON_SECRET_USERNAME_KEYNAME=ceph-username
+MON_SECRET_USER_KEYRING_KEYNAME=ceph-secret
 MON_ENDPOINT_CONFIGMAP_NAME=rook-ceph-mon-endpoints
 ROOK_EXTERNAL_CLUSTER_NAME=$NAMESPACE
 ROOK_EXTERNAL_MAX_MON_ID=2
@@ -28,8 +30,13 @@ function checkEnvVars() {
         echo "Please populate the environment variable ROOK_EXTERNAL_FSID"
         exit 1
     fi
-    if [ -z "$ROOK_EXTERNAL_ADMIN_SECRET" ]; then
-        echo "Please populate the environment variable ROOK_EXTERNAL_ADMIN_SECRET"
+    if [ -n "$ROOK_EXTERNAL_USERNAME" ]; then
+        if [ -z "$ROOK_EXTERNAL_USER_SECRET" ]; then
+            echo "Please populate the environment variable ROOK_EXTERNAL_USER_SECRET with the key of $ROOK_EXTERNAL_USERNAME"
+            exit 1
+        fi
+    elif [ -z "$ROOK_EXTERNAL_ADMIN_SECRET" ]; then
+        echo "Please populate the environment variable ROOK_EXTERNAL_ADMIN_SECRET or ROOK_EXTERNAL_USERNAME and ROOK_EXTERNAL_USER_SECRET"
         exit 1
     fi
     if [ -z "$ROOK_EXTERNAL_CEPH_MON_DATA" ]; then
@@ -39,6 +46,15 @@ function checkEnvVars() {
 }
 
 function importSecret() {
+    local user_args=()
+    # the admin key is left out of the secret when connecting with a restricted user without it
+    if [ -n "$ROOK_EXTERNAL_ADMIN_SECRET" ]; then
+        user_args=(--from-literal="$MON_SECRET_ADMIN_KEYRING_KEYNAME"="$ROOK_EXTERNAL_ADMIN_SECRET")
+    fi
+    if [ -n "$ROOK_EXTERNAL_USERNAME" ]; then
+        user_args+=(--from-literal="$MON_SECRET_USERNAME_KEYNAME"="$ROOK_EXTERNAL_USERNAME" \
+        --from-literal="$MON_SECRET_USER_KEYRING_KEYNAME"="$ROOK_EXTERNAL_USER_SECRET")
+    fi
     kubectl -n "$NAMESPACE" \
     create \
     secret \
@@ -46,8 +62,8 @@ function importSecret() {
     "$MON_SECRET_NAME" \
     --from-literal="$MON_SECRET_CLUSTER_NAME_KEYNAME"="$ROOK_EXTERNAL_CLUSTER_NAME" \
     --from-literal="$MON_SECRET_FSID_KEYNAME"="$ROOK_EXTERNAL_FSID" \
-    --from-literal="$MON_SECRET_ADMIN_KEYRING_KEYNAME"="$ROOK_EXTERNAL_ADMIN_SECRET" \
-    --from-literal="$MON_SECRET_MON_KEYRING_KEYNAME"="$ROOK_EXTERNAL_MONITOR_SECRET"
+    --from-literal="$MON_SECRET_MON_KEYRING_KEYNAME"="$ROOK_EXTERNAL_MONITOR_SECRET" \
+    "${user_args[@]}"
 }
 
 function importConfigMap() {
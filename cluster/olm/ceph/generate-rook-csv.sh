@@ -94,6 +94,11 @@ CEPH_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/ceph_crd.yaml"
 CEPH_BLOCK_POOLS_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephblockpools.crd.yaml"
 CEPH_OBJECT_STORE_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephobjectstores.crd.yaml"
 CEPH_OBJECT_STORE_USERS_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephobjectstoreusers.crd.yaml"
+CEPH_OBJECT_REALMS_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephobjectrealms.crd.yaml"
+CEPH_OBJECT_ZONEGROUPS_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephobjectzonegroups.crd.yaml"
+CEPH_OBJECT_ZONES_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephobjectzones.crd.yaml"
+CEPH_BUCKET_TOPICS_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephbuckettopics.crd.yaml"
+CEPH_BUCKET_NOTIFICATIONS_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephbucketnotifications.crd.yaml"
 CEPH_FILESYSTEMS_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephfilesystems.crd.yaml"
 CEPH_NFS_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/rookcephnfses.crd.yaml"
 
@@ -191,6 +196,11 @@ function generate_crds_yaml() {
     sed -n '/^# OLM: BEGIN CEPH CRD$/,/# OLM: END CEPH CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_CRD_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH OBJECT STORE CRD$/,/# OLM: END CEPH OBJECT STORE CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_OBJECT_STORE_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH OBJECT STORE USERS CRD$/,/# OLM: END CEPH OBJECT STORE USERS CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_OBJECT_STORE_USERS_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH OBJECT REALM CRD$/,/# OLM: END CEPH OBJECT REALM CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_OBJECT_REALMS_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH OBJECT ZONEGROUP CRD$/,/# OLM: END CEPH OBJECT ZONEGROUP CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_OBJECT_ZONEGROUPS_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH OBJECT ZONE CRD$/,/# OLM: END CEPH OBJECT ZONE CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_OBJECT_ZONES_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH BUCKET TOPIC CRD$/,/# OLM: END CEPH BUCKET TOPIC CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_BUCKET_TOPICS_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH BUCKET NOTIFICATION CRD$/,/# OLM: END CEPH BUCKET NOTIFICATION CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_BUCKET_NOTIFICATIONS_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH BLOCK POOL CRD$/,/# OLM: END CEPH BLOCK POOL CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_BLOCK_POOLS_CRD_YAML_FILE"
      sed -n '/^# OLM: BEGIN CEPH NFS CRD$/,/# OLM: END CEPH NFS CRD$/p' "$COMMON_YAML_FILE" > "$CEPH_NFS_CRD_YAML_FILE"
 
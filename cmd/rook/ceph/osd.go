@@ -76,6 +76,8 @@ var (
 	pvcBackedOSD            bool
 	lvPath                  string
 	lvBackedPV              bool
+	cvMode                  string
+	blockPath               string
 )
 
 func addOSDFlags(command *cobra.Command) {
@@ -91,6 +93,8 @@ func addOSDFlags(command *cobra.Command) {
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
 	provisionCmd.Flags().BoolVar(&cfg.pvcBacked, "pvc-backed-osd", false, "true to specify a block mode pvc is backing the OSD")
+	provisionCmd.Flags().IntVar(&cfg.storeConfig.TestDeviceSizeMB, "test-device-size", 0,
+		"size (MB) of the sparse file in the data dir backing a loop device for the OSDs, for testing only")
 	// flags for generating the osd config
 	osdConfigCmd.Flags().IntVar(&osdID, "osd-id", -1, "osd id for which to generate config")
 	osdConfigCmd.Flags().BoolVar(&osdIsDevice, "is-device", false, "whether the osd is a device")
@@ -106,6 +110,8 @@ func addOSDFlags(command *cobra.Command) {
 	osdStartCmd.Flags().BoolVar(&pvcBackedOSD, "pvc-backed-osd", false, "Whether the OSD backing store in PVC or not")
 	osdStartCmd.Flags().StringVar(&lvPath, "lv-path", "", "LV path for the OSD created by ceph volume")
 	osdStartCmd.Flags().BoolVar(&lvBackedPV, "lv-backed-pv", false, "Whether the PV located on LV")
+	osdStartCmd.Flags().StringVar(&cvMode, "cv-mode", "", "the mode of ceph-volume the OSD was prepared with (lvm or raw)")
+	osdStartCmd.Flags().StringVar(&blockPath, "block-path", "", "the block device of the OSD prepared in raw mode")
 
 	// add the subcommands to the parent osd command
 	osdCmd.AddCommand(osdConfigCmd,
@@ -126,6 +132,11 @@ func addOSDConfigFlags(command *cobra.Command) {
 	command.Flags().StringVar(&cfg.storeConfig.StoreType, "osd-store", "", "type of backing OSD store to use (bluestore or filestore)")
 	command.Flags().IntVar(&cfg.storeConfig.OSDsPerDevice, "osds-per-device", 1, "the number of OSDs per device")
 	command.Flags().BoolVar(&cfg.storeConfig.EncryptedDevice, "encrypted-device", false, "whether to encrypt the OSD with dmcrypt")
+	command.Flags().StringVar(&cfg.storeConfig.DeviceClass, "osd-crush-device-class", "", "the crush device class of the OSDs, unless overridden by a device")
+	command.Flags().StringVar(&cfg.storeConfig.CompressionMode, "osd-compression-mode", "", "the bluestore compression mode of the OSDs (none, passive, aggressive or force)")
+	command.Flags().StringVar(&cfg.storeConfig.CompressionAlgorithm, "osd-compression-algorithm", "", "the bluestore compression algorithm of the OSDs (snappy, zlib, zstd or lz4)")
+	command.Flags().IntVar(&cfg.storeConfig.CompressionMinBlobSize, "osd-compression-min-blob-size", 0, "the minimum size (bytes) of the chunks compressed by bluestore")
+	command.Flags().StringVar(&cfg.storeConfig.CephVolumeMode, "ceph-volume-mode", "", "the mode of ceph-volume to prepare the OSD on a PVC (lvm or raw)")
 }
 
 func init() {
@@ -155,7 +166,7 @@ func startOSD(cmd *cobra.Command, args []string) error {
 	context := createContext()
 
 	// Run OSD start sequence
-	err := osddaemon.StartOSD(context, osdStoreType, osdStringID, osdUUID, lvPath, pvcBackedOSD, lvBackedPV, args)
+	err := osddaemon.StartOSD(context, osdStoreType, osdStringID, osdUUID, lvPath, cvMode, blockPath, pvcBackedOSD, lvBackedPV, args)
 	if err != nil {
 		rook.TerminateFatal(err)
 	}
@@ -21,9 +21,11 @@ import (
 )
 
 const (
-	KeyMon       rook.KeyType = "mon"
-	KeyMgr       rook.KeyType = "mgr"
-	KeyOSD       rook.KeyType = "osd"
-	KeyRBDMirror rook.KeyType = "rbdmirror"
-	KeyRGWMirror rook.KeyType = "rgw"
+	KeyMon        rook.KeyType = "mon"
+	KeyMgr        rook.KeyType = "mgr"
+	KeyOSD        rook.KeyType = "osd"
+	KeyPrepareOSD rook.KeyType = "prepareosd"
+	KeyMDS        rook.KeyType = "mds"
+	KeyRBDMirror  rook.KeyType = "rbdmirror"
+	KeyRGWMirror  rook.KeyType = "rgw"
 )
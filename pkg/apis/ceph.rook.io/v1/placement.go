@@ -38,3 +38,15 @@ func GetOSDPlacement(p rook.PlacementSpec) rook.Placement {
 func GetRBDMirrorPlacement(p rook.PlacementSpec) rook.Placement {
 	return p.All().Merge(p[KeyRBDMirror])
 }
+
+// GetMDSPlacement returns the placement for the MDS daemons. The placement of a filesystem
+// overrides the attributes of this placement.
+func GetMDSPlacement(p rook.PlacementSpec) rook.Placement {
+	return p.All().Merge(p[KeyMDS])
+}
+
+// GetRGWPlacement returns the placement for the RGW daemons. The placement of an object store
+// overrides the attributes of this placement.
+func GetRGWPlacement(p rook.PlacementSpec) rook.Placement {
+	return p.All().Merge(p[KeyRGWMirror])
+}
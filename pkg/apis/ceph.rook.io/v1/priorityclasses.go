@@ -44,6 +44,15 @@ func GetOSDPriorityClassName(p rook.PriorityClassNamesSpec) string {
 	return p[KeyOSD]
 }
 
+// GetPrepareOSDPriorityClassName returns the priority class name for the OSD prepare jobs. The jobs have the
+// priority class of the OSDs unless they have their own.
+func GetPrepareOSDPriorityClassName(p rook.PriorityClassNamesSpec) string {
+	if _, ok := p[KeyPrepareOSD]; !ok {
+		return GetOSDPriorityClassName(p)
+	}
+	return p[KeyPrepareOSD]
+}
+
 // GetRBDMirrorPriorityClassName returns the priority class name for the RBD Mirrors
 func GetRBDMirrorPriorityClassName(p rook.PriorityClassNamesSpec) string {
 	if _, ok := p[KeyRBDMirror]; !ok {
@@ -67,6 +67,16 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephObjectStoreList{},
 		&CephObjectStoreUser{},
 		&CephObjectStoreUserList{},
+		&CephObjectRealm{},
+		&CephObjectRealmList{},
+		&CephObjectZoneGroup{},
+		&CephObjectZoneGroupList{},
+		&CephObjectZone{},
+		&CephObjectZoneList{},
+		&CephBucketTopic{},
+		&CephBucketTopicList{},
+		&CephBucketNotification{},
+		&CephBucketNotificationList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
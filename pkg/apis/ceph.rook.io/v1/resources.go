@@ -36,32 +36,32 @@ const (
 	ResourcesKeyCrashCollector = "crashcollector"
 )
 
-// GetMgrResources returns the placement for the MGR service
+// GetMgrResources returns the resources for the MGR service
 func GetMgrResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyMgr]
 }
 
-// GetMonResources returns the placement for the monitors
+// GetMonResources returns the resources for the monitors
 func GetMonResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyMon]
 }
 
-// GetOSDResources returns the placement for the OSDs
+// GetOSDResources returns the resources for the OSDs
 func GetOSDResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyOSD]
 }
 
-// GetPrepareOSDResources returns the placement for the OSDs prepare job
+// GetPrepareOSDResources returns the resources for the OSDs prepare job
 func GetPrepareOSDResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyPrepareOSD]
 }
 
-// GetRBDMirrorResources returns the placement for the RBD Mirrors
+// GetRBDMirrorResources returns the resources for the RBD Mirrors
 func GetRBDMirrorResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyRBDMirror]
 }
 
-// GetCrashCollectorResources returns the placement for the crash daemon
+// GetCrashCollectorResources returns the resources for the crash daemon
 func GetCrashCollectorResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyCrashCollector]
 }
@@ -113,3 +113,37 @@ storage:
 
 	assert.Equal(t, expectedSpec, clusterSpec)
 }
+
+func TestClusterSpecResources(t *testing.T) {
+	specYaml := []byte(`
+resources:
+  mon:
+    requests:
+      cpu: "100m"
+      memory: "256Mi"
+  osd:
+    limits:
+      memory: "4Gi"
+    requests:
+      cpu: "1"
+      memory: "4Gi"`)
+
+	rawJSON, err := yaml.YAMLToJSON(specYaml)
+	assert.Nil(t, err)
+	var clusterSpec ClusterSpec
+	err = json.Unmarshal(rawJSON, &clusterSpec)
+	assert.Nil(t, err)
+
+	// each daemon gets its own resources
+	mon := GetMonResources(clusterSpec.Resources)
+	assert.Equal(t, "256Mi", mon.Requests.Memory().String())
+	assert.Equal(t, "100m", mon.Requests.Cpu().String())
+	assert.Empty(t, mon.Limits)
+	osd := GetOSDResources(clusterSpec.Resources)
+	assert.Equal(t, "4Gi", osd.Limits.Memory().String())
+	assert.Equal(t, "1", osd.Requests.Cpu().String())
+
+	// the daemons without resources are not restricted
+	assert.Empty(t, GetMgrResources(clusterSpec.Resources).Requests)
+	assert.Empty(t, GetRBDMirrorResources(clusterSpec.Resources).Limits)
+}
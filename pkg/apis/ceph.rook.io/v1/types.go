@@ -19,6 +19,7 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	rook "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
@@ -101,6 +102,18 @@ type ClusterSpec struct {
 
 	// Remove the OSD that is out and safe to remove only if this option is true
 	RemoveOSDsIfOutAndSafeToRemove bool `json:"removeOSDsIfOutAndSafeToRemove"`
+
+	// Security represents the security settings of the cluster
+	Security SecuritySpec `json:"security,omitempty"`
+
+	// Scrub sets when and how often the OSDs scrub the placement groups
+	Scrub *ScrubSpec `json:"scrub,omitempty"`
+
+	// FullRatios sets the ratios of the capacity of the OSDs from which the cluster is considered full
+	FullRatios *FullRatiosSpec `json:"fullRatios,omitempty"`
+
+	// OSDWeightIn brings the new OSDs in at a CRUSH weight of 0 and increases their weight step by step
+	OSDWeightIn *OSDWeightInSpec `json:"osdWeightIn,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -122,6 +135,49 @@ type DashboardSpec struct {
 	Port int `json:"port,omitempty"`
 	// Whether SSL should be used
 	SSL bool `json:"ssl,omitempty"`
+	// The ClusterIP to assign to the dashboard service. If empty, Kubernetes allocates the IP.
+	ClusterIP string `json:"clusterIP,omitempty"`
+	// ServiceType is the type of the dashboard service: ClusterIP (the default), NodePort or LoadBalancer
+	ServiceType string `json:"serviceType,omitempty"`
+	// NodePort is the node port of a NodePort or LoadBalancer dashboard service. If zero, Kubernetes allocates the port.
+	NodePort int `json:"nodePort,omitempty"`
+	// LoadBalancerSourceRanges are the CIDRs of the clients allowed to reach a LoadBalancer dashboard service
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// Whether to publish the dashboard admin credentials in a well-known secret for other controllers to consume
+	PublishCredentials bool `json:"publishCredentials,omitempty"`
+	// Whether to enable the dashboard features of the Ceph daemons deployed in the cluster (e.g. rgw when an object store is running)
+	AutoDetectFeatures bool `json:"autoDetectFeatures,omitempty"`
+	// Features to explicitly enable (true) or disable (false) in the dashboard. These settings take precedence over
+	// the detected features.
+	Features map[string]bool `json:"features,omitempty"`
+	// CertificateSecretName is the name of a TLS secret with the certificate (tls.crt) and private key (tls.key)
+	// for the dashboard. If empty, a self-signed certificate is generated when SSL is enabled.
+	CertificateSecretName string `json:"certificateSecretName,omitempty"`
+	// Ingress configures an ingress to reach the dashboard from outside the cluster
+	Ingress DashboardIngressSpec `json:"ingress,omitempty"`
+	// Users are additional dashboard users created by the operator. The password of each user is generated
+	// and stored in a secret.
+	Users []DashboardUserSpec `json:"users,omitempty"`
+}
+
+// DashboardUserSpec represents a dashboard user with its roles
+type DashboardUserSpec struct {
+	// Name is the username to log in to the dashboard
+	Name string `json:"name"`
+	// Roles are the dashboard roles granted to the user, for example read-only or block-manager
+	Roles []string `json:"roles,omitempty"`
+}
+
+// DashboardIngressSpec represents the settings of the ingress created for the dashboard
+type DashboardIngressSpec struct {
+	// Whether to create an ingress for the dashboard service
+	Enabled bool `json:"enabled,omitempty"`
+	// Host is the host name the dashboard is reachable at. If empty, the ingress rule applies to all hosts.
+	Host string `json:"host,omitempty"`
+	// TLSSecretName is the name of the secret with the TLS certificate the ingress serves for the host
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	// Annotations to set on the ingress, for example to configure the ingress controller
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // MonitoringSpec represents the settings for Prometheus based Ceph monitoring
@@ -133,12 +189,17 @@ type MonitoringSpec struct {
 	// The namespace where the prometheus rules and alerts should be created.
 	// If empty, the same namespace as the cluster will be used.
 	RulesNamespace string `json:"rulesNamespace,omitempty"`
+
+	// Interval at which prometheus scrapes the mgr metrics endpoint, e.g. "30s".
+	// If empty, the interval from the service monitor template will be used.
+	Interval string `json:"interval,omitempty"`
 }
 
 type ClusterStatus struct {
 	State      ClusterState `json:"state,omitempty"`
 	Message    string       `json:"message,omitempty"`
 	CephStatus *CephStatus  `json:"ceph,omitempty"`
+	OSDs       []OSDStatus  `json:"osds,omitempty"`
 }
 
 type CephStatus struct {
@@ -154,6 +215,17 @@ type CephHealthMessage struct {
 	Message  string `json:"message"`
 }
 
+// OSDStatus represents the status of an OSD and of the deployment running it
+type OSDStatus struct {
+	ID int `json:"id"`
+	// Deployment is the name of the deployment of the OSD, if the OSD is running in the cluster
+	Deployment string `json:"deployment,omitempty"`
+	// Node is the node the pod of the OSD is scheduled on
+	Node string `json:"node,omitempty"`
+	Up   bool   `json:"up"`
+	In   bool   `json:"in"`
+}
+
 type ClusterState string
 
 const (
@@ -171,17 +243,163 @@ type MonSpec struct {
 	Count                int                       `json:"count,omitempty"`
 	AllowMultiplePerNode bool                      `json:"allowMultiplePerNode,omitempty"`
 	VolumeClaimTemplate  *v1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	// OutTimeout is the time a mon can be out of quorum before it is failed over, e.g. "10m". Defaults to
+	// the timeout of the operator.
+	OutTimeout string `json:"outTimeout,omitempty"`
+	// HealthCheckInterval is the interval between the checks of the mon quorum, e.g. "45s". Defaults to the
+	// interval of the operator.
+	HealthCheckInterval string `json:"healthCheckInterval,omitempty"`
+	// StretchCluster spreads the mons across two data zones and an arbiter zone and enables the stretch mode
+	StretchCluster *StretchClusterSpec `json:"stretchCluster,omitempty"`
+	// FailureDomainLabel is the node label of the failure domain the mons are spread across, e.g. the zone label.
+	// Two mons are not scheduled in the same failure domain unless AllowMultiplePerFailureDomain is set.
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+	// AllowMultiplePerFailureDomain only prefers to spread the mons across the failure domains
+	AllowMultiplePerFailureDomain bool `json:"allowMultiplePerFailureDomain,omitempty"`
+	// PublishEndpoints publishes the mon endpoints for the clients of the cluster in other namespaces
+	PublishEndpoints *MonPublishEndpointsSpec `json:"publishEndpoints,omitempty"`
+	// Compaction compacts the stores of the mons, which grow large during long recovery events
+	Compaction *MonCompactionSpec `json:"compaction,omitempty"`
+	// Config sets options of the mons in the centralized config of the cluster
+	Config *MonConfigSpec `json:"config,omitempty"`
+}
+
+// MonConfigSpec represents the options of the mons set in the centralized config of the cluster
+type MonConfigSpec struct {
+	// ClockDriftAllowed is the clock drift between the mons allowed before the cluster health is in warning,
+	// e.g. "100ms". This is mon_clock_drift_allowed in ceph.
+	ClockDriftAllowed string `json:"clockDriftAllowed,omitempty"`
+	// ElectionTimeout is the time the mons wait for all the mons to acknowledge an election, e.g. "10s". This
+	// is mon_election_timeout in ceph.
+	ElectionTimeout string `json:"electionTimeout,omitempty"`
+}
+
+// MonCompactionSpec represents when the stores of the mons are compacted
+type MonCompactionSpec struct {
+	// OnStart compacts the store of a mon each time the mon starts
+	OnStart bool `json:"onStart,omitempty"`
+	// Interval is the interval between the compactions of the stores of the mons in quorum, e.g. "24h". The
+	// stores are not compacted periodically if the interval is not set.
+	Interval string `json:"interval,omitempty"`
+}
+
+// MonPublishEndpointsSpec represents where the mon endpoints of the cluster are published
+type MonPublishEndpointsSpec struct {
+	// Namespaces are the other namespaces the mon endpoints are published in
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ClientKeyring also publishes a secret with the keyring of a client that can read the mon map
+	ClientKeyring bool `json:"clientKeyring,omitempty"`
+}
+
+// StretchClusterSpec represents the zones of a stretch cluster. The zones are the values of the zone label
+// of the nodes, which is also the zone of the osds in the crush map.
+type StretchClusterSpec struct {
+	// SubFailureDomain is the crush bucket type the two replicas in a data zone are spread across. Defaults to host.
+	SubFailureDomain string `json:"subFailureDomain,omitempty"`
+	// Zones are the two data zones and the arbiter zone
+	Zones []StretchClusterZoneSpec `json:"zones,omitempty"`
+}
+
+// StretchClusterZoneSpec represents a zone of a stretch cluster
+type StretchClusterZoneSpec struct {
+	// Name is the value of the zone label of the nodes in the zone
+	Name string `json:"name"`
+	// Arbiter is true for the zone that only runs the tiebreaker mon
+	Arbiter bool `json:"arbiter,omitempty"`
 }
 
 // MgrSpec represents options to configure a ceph mgr
 type MgrSpec struct {
+	// Count is the number of mgrs to start. One mgr is active and the others are standbys. Defaults to 1.
+	Count   int      `json:"count,omitempty"`
 	Modules []Module `json:"modules,omitempty"`
+	// MetricsClusterIP is the ClusterIP to assign to the metrics service. If empty, Kubernetes allocates the IP.
+	MetricsClusterIP string `json:"metricsClusterIP,omitempty"`
+	// PublicNetwork is the CIDR of the network the mgrs bind to for client traffic on multi-homed hosts
+	PublicNetwork string `json:"publicNetwork,omitempty"`
+	// ClusterNetwork is the CIDR of the network the mgrs use for the internal cluster traffic
+	ClusterNetwork string `json:"clusterNetwork,omitempty"`
+	// DeploymentLabels are additional labels to set on the mgr deployments
+	DeploymentLabels map[string]string `json:"deploymentLabels,omitempty"`
+	// ExcludeFromAutoscaling annotates the mgr deployments so autoscaling tools do not target them
+	ExcludeFromAutoscaling bool `json:"excludeFromAutoscaling,omitempty"`
+	// CapsMismatchPolicy is the action taken when an existing mgr key has different caps than expected:
+	// "update" (default), "warn" or "ignore"
+	CapsMismatchPolicy string `json:"capsMismatchPolicy,omitempty"`
+	// ReconcileInterval is how often the mgrs are reconciled once they are settled, e.g. "10m". The mgrs
+	// are reconciled sooner while they are not ready or their orchestration failed. Defaults to 10 minutes.
+	ReconcileInterval string `json:"reconcileInterval,omitempty"`
+	// ServiceAccountToken mounts a projected service account token in the mgr pods instead of the
+	// auto-mounted token
+	ServiceAccountToken *ProjectedServiceAccountTokenSpec `json:"serviceAccountToken,omitempty"`
+	// PrometheusModule configures the stats exported by the mgr prometheus module
+	PrometheusModule PrometheusModuleSpec `json:"prometheusModule,omitempty"`
+	// AllowMultiplePerNode allows more than one mgr on the same node. If false, the mgrs are required to run
+	// in different topology domains, otherwise they are only preferred to.
+	AllowMultiplePerNode bool `json:"allowMultiplePerNode,omitempty"`
+	// AntiAffinityTopologyKey is the node label the mgrs are spread across. Defaults to the node hostname.
+	AntiAffinityTopologyKey string `json:"antiAffinityTopologyKey,omitempty"`
+	// LivenessProbe overrides the liveness probe of the mgr daemon container
+	LivenessProbe *ProbeSpec `json:"livenessProbe,omitempty"`
+	// ReadinessProbe overrides the readiness probe of the mgr daemon container
+	ReadinessProbe *ProbeSpec `json:"readinessProbe,omitempty"`
+	// Labels are additional labels to set on the mgr pods and services
+	Labels map[string]string `json:"labels,omitempty"`
+	// ServiceAnnotations are additional annotations to set on the mgr metrics and dashboard services
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+	// KeyGeneration is the generation of the mgr keys. Increasing it rotates the cephx keys of the mgrs and
+	// restarts the mgr pods with the new keys. The keys of the other daemons are not rotated.
+	KeyGeneration int `json:"keyGeneration,omitempty"`
+	// ActiveFailover configures the failover of an active mgr that stops responding
+	ActiveFailover MgrFailoverSpec `json:"activeFailover,omitempty"`
+}
+
+// MgrFailoverSpec represents the settings to detect a hung active mgr and fail over to a standby
+type MgrFailoverSpec struct {
+	// Enabled checks that the metrics endpoint of the active mgr responds, and fails over to a standby
+	// when it does not
+	Enabled bool `json:"enabled,omitempty"`
+	// FailureThreshold is the number of consecutive failed checks before the active mgr is failed over. Defaults to 4.
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// TimeoutSeconds is the time the metrics endpoint has to respond to a check. Defaults to 5.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// ProbeSpec represents the overrides of a daemon container probe
+type ProbeSpec struct {
+	// Disabled removes the probe from the container
+	Disabled bool `json:"disabled,omitempty"`
+	// Probe replaces the settings of the default probe. The default handler is kept if none is given.
+	Probe *v1.Probe `json:"probe,omitempty"`
+}
+
+// PrometheusModuleSpec represents the settings of the mgr prometheus module
+type PrometheusModuleSpec struct {
+	// RBDStatsPools are the pools for which the per-image RBD stats are exported, each given as "<pool>"
+	// or "<pool>/<namespace>". The per-image stats are disabled if no pools are given.
+	RBDStatsPools []string `json:"rbdStatsPools,omitempty"`
+	// RBDStatsPoolsRefreshInterval is the interval in seconds at which the pools are scanned for new images.
+	// If zero, the Ceph default is used.
+	RBDStatsPoolsRefreshInterval int `json:"rbdStatsPoolsRefreshInterval,omitempty"`
+	// Port is the port the prometheus module serves the metrics on. Defaults to 9283.
+	Port int `json:"port,omitempty"`
+}
+
+// ProjectedServiceAccountTokenSpec represents the settings of a projected service account token
+type ProjectedServiceAccountTokenSpec struct {
+	// Audience is the intended audience of the token. If empty, the token is for the API server.
+	Audience string `json:"audience,omitempty"`
+	// ExpirationSeconds is the requested lifetime of the token. It must be at least 10 minutes
+	// and defaults to 1 hour.
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
 }
 
 // Module represents mgr modules that the user wants to enable or disable
 type Module struct {
 	Name    string `json:"name,omitempty"`
 	Enabled bool   `json:"enabled"`
+	// Settings are the module options to set when the module is enabled, applied as "mgr/<name>/<key>"
+	Settings map[string]string `json:"settings,omitempty"`
 }
 
 // ExternalSpec represents the options supported by an external cluster
@@ -189,6 +407,60 @@ type ExternalSpec struct {
 	Enable bool `json:"enable"`
 }
 
+// SecuritySpec represents the security settings of the cluster
+type SecuritySpec struct {
+	// KeyManagementService is the key management service where the dm-crypt keys of the encrypted OSDs are stored
+	KeyManagementService KeyManagementServiceSpec `json:"kms,omitempty"`
+}
+
+// KeyManagementServiceSpec represents the settings to connect to a key management service
+type KeyManagementServiceSpec struct {
+	// Provider is the type of the key management service. Only "vault" is supported.
+	Provider string `json:"provider,omitempty"`
+	// ConnectionDetails are the settings to connect to the key management service, such as VAULT_ADDR
+	ConnectionDetails map[string]string `json:"connectionDetails,omitempty"`
+	// TokenSecretName is the name of the secret with the token to authenticate to the key management service
+	TokenSecretName string `json:"tokenSecretName,omitempty"`
+}
+
+// ScrubSpec represents the scrub options of the OSDs set in the centralized config of the cluster
+type ScrubSpec struct {
+	// BeginHour is the hour of the day (0-23) from which the OSDs are allowed to scrub. This is
+	// osd_scrub_begin_hour in ceph.
+	BeginHour *int `json:"beginHour,omitempty"`
+	// EndHour is the hour of the day (0-23) until which the OSDs are allowed to scrub. This is
+	// osd_scrub_end_hour in ceph.
+	EndHour *int `json:"endHour,omitempty"`
+	// MaxScrubs is the maximum number of scrubs running at the same time on an OSD. This is osd_max_scrubs in ceph.
+	MaxScrubs int `json:"maxScrubs,omitempty"`
+	// DeepScrubInterval is the interval between the deep scrubs of a placement group, e.g. "168h". This is
+	// osd_deep_scrub_interval in ceph.
+	DeepScrubInterval string `json:"deepScrubInterval,omitempty"`
+}
+
+// FullRatiosSpec represents the full ratios of the OSDs set in the OSD map of the cluster. A ratio that is not set
+// keeps its current value.
+type FullRatiosSpec struct {
+	// Full is the ratio of the capacity of an OSD from which the cluster stops accepting writes.
+	// This is set with "ceph osd set-full-ratio".
+	Full *float64 `json:"full,omitempty"`
+	// BackfillFull is the ratio of the capacity of an OSD from which no data is backfilled to the OSD.
+	// This is set with "ceph osd set-backfillfull-ratio".
+	BackfillFull *float64 `json:"backfillFull,omitempty"`
+	// NearFull is the ratio of the capacity of an OSD from which the cluster health is in warning.
+	// This is set with "ceph osd set-nearfull-ratio".
+	NearFull *float64 `json:"nearFull,omitempty"`
+}
+
+// OSDWeightInSpec represents how the CRUSH weight of the new OSDs is increased up to the size of the OSDs in TiB
+type OSDWeightInSpec struct {
+	// Step is the fraction of the target CRUSH weight of a new OSD added at each step, e.g. 0.25. Defaults to 0.1.
+	Step float64 `json:"step,omitempty"`
+	// Interval is the minimum time between two steps, e.g. "30m". A step is only taken once all the placement
+	// groups are active+clean.
+	Interval string `json:"interval,omitempty"`
+}
+
 type RBDMirroringSpec struct {
 	Workers int `json:"workers"`
 }
@@ -231,6 +503,8 @@ type PoolSpec struct {
 
 type Status struct {
 	Phase string `json:"phase,omitempty"`
+	// The reason of a failure
+	Message string `json:"message,omitempty"`
 }
 
 // ReplicationSpec represents the spec for replication in a pool
@@ -338,6 +612,42 @@ type ObjectStoreSpec struct {
 
 	// The rgw pod info
 	Gateway GatewaySpec `json:"gateway"`
+
+	// The multisite zone of the object store. The store creates its own realm, zone group and zone if not set.
+	Zone ZoneSpec `json:"zone,omitempty"`
+
+	// The authentication of the users of the gateways with an external identity service
+	Auth AuthSpec `json:"auth,omitempty"`
+}
+
+// ZoneSpec represents the multisite zone of an object store
+type ZoneSpec struct {
+	// The name of the CephObjectZone the store belongs to
+	Name string `json:"name"`
+}
+
+// AuthSpec represents the external identity services the gateways authenticate the users with
+type AuthSpec struct {
+	// The OpenStack Keystone service of the users of the S3 and Swift APIs
+	Keystone *KeystoneSpec `json:"keystone,omitempty"`
+}
+
+// KeystoneSpec represents the settings of the gateways to authenticate the users with OpenStack Keystone
+type KeystoneSpec struct {
+	// The URL of the Keystone API
+	URL string `json:"url"`
+
+	// The name of the secret with the credentials of the service user of the gateways in Keystone
+	ServiceUserSecretName string `json:"serviceUserSecretName"`
+
+	// The roles of the Keystone users the gateways accept
+	AcceptedRoles []string `json:"acceptedRoles"`
+
+	// Whether the users are created in their own tenant of the gateways: "true", "false", "s3" or "swift"
+	ImplicitTenants string `json:"implicitTenants,omitempty"`
+
+	// The maximum number of Keystone tokens the gateways cache. The default of the gateways is used if not set.
+	TokenCacheSize *int `json:"tokenCacheSize,omitempty"`
 }
 
 // +genclient
@@ -365,6 +675,309 @@ type ObjectStoreUserSpec struct {
 	Store string `json:"store,omitempty"`
 	//The display name for the ceph users
 	DisplayName string `json:"displayName,omitempty"`
+	// The quotas of the user
+	Quotas *ObjectUserQuotaSpec `json:"quotas,omitempty"`
+	// The admin capabilities of the user
+	Capabilities *ObjectUserCapSpec `json:"capabilities,omitempty"`
+}
+
+// ObjectUserQuotaSpec represents the quotas of an object store user. The limits that are not set are unlimited.
+type ObjectUserQuotaSpec struct {
+	// The maximum number of buckets the user can own
+	MaxBuckets *int `json:"maxBuckets,omitempty"`
+	// The maximum size of the objects of the user across all its buckets
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// The maximum number of objects of the user across all its buckets
+	MaxObjects *int64 `json:"maxObjects,omitempty"`
+	// The limits of each bucket of the user
+	Bucket *ObjectBucketQuotaSpec `json:"bucket,omitempty"`
+}
+
+// ObjectBucketQuotaSpec represents the limits of each bucket of an object store user
+type ObjectBucketQuotaSpec struct {
+	// The maximum size of the objects of the bucket
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// The maximum number of objects of the bucket
+	MaxObjects *int64 `json:"maxObjects,omitempty"`
+}
+
+// ObjectUserCapSpec represents the admin capabilities of an object store user. Each capability is one of "read",
+// "write" or "*" for both.
+type ObjectUserCapSpec struct {
+	// The admin capability on the users
+	Users string `json:"users,omitempty"`
+	// The admin capability on the buckets
+	Buckets string `json:"buckets,omitempty"`
+	// The admin capability on the metadata
+	Metadata string `json:"metadata,omitempty"`
+	// The admin capability on the usage
+	Usage string `json:"usage,omitempty"`
+	// The admin capability on the zone
+	Zone string `json:"zone,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephObjectRealm represents a realm of the object stores in multisite
+type CephObjectRealm struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectRealmSpec `json:"spec"`
+	Status            *Status         `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephObjectRealmList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephObjectRealm `json:"items"`
+}
+
+// ObjectRealmSpec represents the spec of a realm
+type ObjectRealmSpec struct {
+	// The settings to pull the realm from the master zone of another cluster. The realm is created in this cluster if not set.
+	Pull *PullSpec `json:"pull,omitempty"`
+}
+
+// PullSpec represents the master zone that a realm is pulled from
+type PullSpec struct {
+	// The endpoint of the gateways of the master zone
+	Endpoint string `json:"endpoint"`
+
+	// The name of the secret with the "access-key" and "secret-key" of the system user of the realm
+	SecretName string `json:"secretName"`
+
+	// The name of the secret with the "ca.crt" of the CA of the certificate of the endpoint, if it is not signed by a
+	// public CA
+	CASecretName string `json:"caSecretName,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephObjectZoneGroup represents a zone group of a realm in multisite
+type CephObjectZoneGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectZoneGroupSpec `json:"spec"`
+	Status            *Status             `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephObjectZoneGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephObjectZoneGroup `json:"items"`
+}
+
+// ObjectZoneGroupSpec represents the spec of a zone group
+type ObjectZoneGroupSpec struct {
+	// The name of the CephObjectRealm of the zone group
+	Realm string `json:"realm"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephObjectZone represents a zone of a zone group in multisite
+type CephObjectZone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectZoneSpec    `json:"spec"`
+	Status            *ObjectZoneStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephObjectZoneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephObjectZone `json:"items"`
+}
+
+// ObjectZoneSpec represents the spec of a zone
+type ObjectZoneSpec struct {
+	// The name of the CephObjectZoneGroup of the zone
+	ZoneGroup string `json:"zoneGroup"`
+
+	// The metadata pool settings of the zone
+	MetadataPool PoolSpec `json:"metadataPool"`
+
+	// The data pool settings of the zone
+	DataPool PoolSpec `json:"dataPool"`
+
+	// Preserve the pools of the zone on its deletion
+	PreservePoolsOnDelete bool `json:"preservePoolsOnDelete"`
+
+	// The endpoints of the zone for the other zones, when the services of the object stores of the zone are not
+	// reachable from the other clusters
+	CustomEndpoints []string `json:"customEndpoints,omitempty"`
+}
+
+// ObjectZoneStatus represents the status of a zone
+type ObjectZoneStatus struct {
+	Phase string `json:"phase,omitempty"`
+
+	// The sync of the zone with the other zones of its zone group
+	SyncStatus *ZoneSyncStatus `json:"syncStatus,omitempty"`
+}
+
+// ZoneSyncStatus represents the sync of a zone with the other zones of its zone group
+type ZoneSyncStatus struct {
+	// The state of the metadata sync from the master zone, empty in the master zone
+	MetadataSync string `json:"metadataSync,omitempty"`
+
+	// The state of the data sync from each of the other zones
+	DataSync map[string]string `json:"dataSync,omitempty"`
+
+	// The time the sync was last checked
+	LastChecked string `json:"lastChecked,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBucketTopic represents a topic of an object store that the notifications of the buckets are sent to
+type CephBucketTopic struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              BucketTopicSpec    `json:"spec"`
+	Status            *BucketTopicStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBucketTopicList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBucketTopic `json:"items"`
+}
+
+// BucketTopicSpec represents the spec of a bucket topic
+type BucketTopicSpec struct {
+	// The object store the topic is created in
+	Store string `json:"store"`
+
+	// The opaque data added to the notifications of the topic
+	OpaqueData string `json:"opaqueData,omitempty"`
+
+	// The endpoint the notifications of the topic are pushed to. Only one of the endpoints can be set.
+	Endpoint TopicEndpointSpec `json:"endpoint"`
+}
+
+// TopicEndpointSpec represents the endpoint of a bucket topic
+type TopicEndpointSpec struct {
+	// An HTTP endpoint
+	HTTP *HTTPEndpointSpec `json:"http,omitempty"`
+
+	// An AMQP endpoint
+	AMQP *AMQPEndpointSpec `json:"amqp,omitempty"`
+
+	// A Kafka endpoint
+	Kafka *KafkaEndpointSpec `json:"kafka,omitempty"`
+}
+
+// HTTPEndpointSpec represents an HTTP endpoint of a bucket topic
+type HTTPEndpointSpec struct {
+	// The URI of the endpoint, with the http or https scheme
+	URI string `json:"uri"`
+
+	// Whether the certificate of an https endpoint is not verified
+	DisableVerifySSL bool `json:"disableVerifySSL,omitempty"`
+}
+
+// AMQPEndpointSpec represents an AMQP endpoint of a bucket topic
+type AMQPEndpointSpec struct {
+	// The URI of the endpoint, with the amqp or amqps scheme
+	URI string `json:"uri"`
+
+	// The exchange of the notifications
+	Exchange string `json:"exchange"`
+
+	// The ack level of the notifications: "none", "broker" or "routable". The default of the gateways is used if not set.
+	AckLevel string `json:"ackLevel,omitempty"`
+
+	// Whether the certificate of an amqps endpoint is not verified
+	DisableVerifySSL bool `json:"disableVerifySSL,omitempty"`
+}
+
+// KafkaEndpointSpec represents a Kafka endpoint of a bucket topic
+type KafkaEndpointSpec struct {
+	// The URI of the endpoint, with the kafka scheme
+	URI string `json:"uri"`
+
+	// Whether the endpoint is connected to with ssl
+	UseSSL bool `json:"useSSL,omitempty"`
+
+	// The ack level of the notifications: "none" or "broker". The default of the gateways is used if not set.
+	AckLevel string `json:"ackLevel,omitempty"`
+}
+
+// BucketTopicStatus represents the status of a bucket topic
+type BucketTopicStatus struct {
+	Phase string `json:"phase,omitempty"`
+
+	// The ARN of the topic, which the notifications of the buckets refer to
+	ARN string `json:"arn,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBucketNotification represents a notification of the events of a bucket to a bucket topic
+type CephBucketNotification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              BucketNotificationSpec    `json:"spec"`
+	Status            *BucketNotificationStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBucketNotificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBucketNotification `json:"items"`
+}
+
+// BucketNotificationSpec represents the spec of a bucket notification
+type BucketNotificationSpec struct {
+	// The name of the CephBucketTopic the events are sent to. The bucket is in the object store of the topic.
+	Topic string `json:"topic"`
+
+	// The name of the bucket
+	Bucket string `json:"bucket"`
+
+	// The events of the notification, e.g. "s3:ObjectCreated:*". All the events are notified if not set.
+	Events []string `json:"events,omitempty"`
+
+	// The filter of the keys of the objects of the events
+	Filter *NotificationFilterSpec `json:"filter,omitempty"`
+}
+
+// BucketNotificationStatus represents the status of a bucket notification
+type BucketNotificationStatus struct {
+	Phase string `json:"phase,omitempty"`
+
+	// The object store the notification was created in, from which it is removed even when its topic is gone
+	Store string `json:"store,omitempty"`
+}
+
+// NotificationFilterSpec represents the filter of the keys of the objects of a bucket notification
+type NotificationFilterSpec struct {
+	// The prefix of the keys
+	Prefix string `json:"prefix,omitempty"`
+
+	// The suffix of the keys
+	Suffix string `json:"suffix,omitempty"`
 }
 
 type GatewaySpec struct {
@@ -394,6 +1007,10 @@ type GatewaySpec struct {
 
 	// PriorityClassName sets priority classes on the rgw pods
 	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ExternalRgwEndpoints are the addresses of the existing gateways of an external cluster. If set, no gateways are
+	// deployed and the service of the store points to the external gateways.
+	ExternalRgwEndpoints []v1.EndpointAddress `json:"externalRgwEndpoints,omitempty"`
 }
 
 // +genclient
@@ -26,6 +26,118 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AMQPEndpointSpec) DeepCopyInto(out *AMQPEndpointSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AMQPEndpointSpec.
+func (in *AMQPEndpointSpec) DeepCopy() *AMQPEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AMQPEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSpec) DeepCopyInto(out *AuthSpec) {
+	*out = *in
+	if in.Keystone != nil {
+		in, out := &in.Keystone, &out.Keystone
+		*out = new(KeystoneSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSpec.
+func (in *AuthSpec) DeepCopy() *AuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BucketNotificationSpec) DeepCopyInto(out *BucketNotificationSpec) {
+	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(NotificationFilterSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketNotificationSpec.
+func (in *BucketNotificationSpec) DeepCopy() *BucketNotificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BucketNotificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BucketNotificationStatus) DeepCopyInto(out *BucketNotificationStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketNotificationStatus.
+func (in *BucketNotificationStatus) DeepCopy() *BucketNotificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BucketNotificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BucketTopicSpec) DeepCopyInto(out *BucketTopicSpec) {
+	*out = *in
+	in.Endpoint.DeepCopyInto(&out.Endpoint)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketTopicSpec.
+func (in *BucketTopicSpec) DeepCopy() *BucketTopicSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BucketTopicSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BucketTopicStatus) DeepCopyInto(out *BucketTopicStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketTopicStatus.
+func (in *BucketTopicStatus) DeepCopy() *BucketTopicStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BucketTopicStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephBlockPool) DeepCopyInto(out *CephBlockPool) {
 	*out = *in
@@ -91,6 +203,136 @@ func (in *CephBlockPoolList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketNotification) DeepCopyInto(out *CephBucketNotification) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(BucketNotificationStatus)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketNotification.
+func (in *CephBucketNotification) DeepCopy() *CephBucketNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketNotification) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketNotificationList) DeepCopyInto(out *CephBucketNotificationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBucketNotification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketNotificationList.
+func (in *CephBucketNotificationList) DeepCopy() *CephBucketNotificationList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketNotificationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketNotificationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketTopic) DeepCopyInto(out *CephBucketTopic) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(BucketTopicStatus)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketTopic.
+func (in *CephBucketTopic) DeepCopy() *CephBucketTopic {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketTopic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketTopic) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBucketTopicList) DeepCopyInto(out *CephBucketTopicList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBucketTopic, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBucketTopicList.
+func (in *CephBucketTopicList) DeepCopy() *CephBucketTopicList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBucketTopicList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBucketTopicList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephClient) DeepCopyInto(out *CephClient) {
 	*out = *in
@@ -358,6 +600,71 @@ func (in *CephNFSList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephObjectRealm) DeepCopyInto(out *CephObjectRealm) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(Status)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectRealm.
+func (in *CephObjectRealm) DeepCopy() *CephObjectRealm {
+	if in == nil {
+		return nil
+	}
+	out := new(CephObjectRealm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectRealm) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephObjectRealmList) DeepCopyInto(out *CephObjectRealmList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephObjectRealm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectRealmList.
+func (in *CephObjectRealmList) DeepCopy() *CephObjectRealmList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephObjectRealmList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectRealmList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephObjectStore) DeepCopyInto(out *CephObjectStore) {
 	*out = *in
@@ -428,7 +735,7 @@ func (in *CephObjectStoreUser) DeepCopyInto(out *CephObjectStoreUser) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
 		*out = new(Status)
@@ -489,24 +796,154 @@ func (in *CephObjectStoreUserList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CephStatus) DeepCopyInto(out *CephStatus) {
+func (in *CephObjectZone) DeepCopyInto(out *CephObjectZone) {
 	*out = *in
-	if in.Details != nil {
-		in, out := &in.Details, &out.Details
-		*out = make(map[string]CephHealthMessage, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(ObjectZoneStatus)
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephStatus.
-func (in *CephStatus) DeepCopy() *CephStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZone.
+func (in *CephObjectZone) DeepCopy() *CephObjectZone {
 	if in == nil {
 		return nil
 	}
-	out := new(CephStatus)
+	out := new(CephObjectZone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectZone) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephObjectZoneGroup) DeepCopyInto(out *CephObjectZoneGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(Status)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneGroup.
+func (in *CephObjectZoneGroup) DeepCopy() *CephObjectZoneGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(CephObjectZoneGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectZoneGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephObjectZoneGroupList) DeepCopyInto(out *CephObjectZoneGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephObjectZoneGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneGroupList.
+func (in *CephObjectZoneGroupList) DeepCopy() *CephObjectZoneGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephObjectZoneGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectZoneGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephObjectZoneList) DeepCopyInto(out *CephObjectZoneList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephObjectZone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectZoneList.
+func (in *CephObjectZoneList) DeepCopy() *CephObjectZoneList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephObjectZoneList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephObjectZoneList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephStatus) DeepCopyInto(out *CephStatus) {
+	*out = *in
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make(map[string]CephHealthMessage, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephStatus.
+func (in *CephStatus) DeepCopy() *CephStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -597,10 +1034,26 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	out.DisruptionManagement = in.DisruptionManagement
 	in.Mon.DeepCopyInto(&out.Mon)
 	out.RBDMirroring = in.RBDMirroring
-	out.Dashboard = in.Dashboard
+	in.Dashboard.DeepCopyInto(&out.Dashboard)
 	out.Monitoring = in.Monitoring
 	out.External = in.External
 	in.Mgr.DeepCopyInto(&out.Mgr)
+	in.Security.DeepCopyInto(&out.Security)
+	if in.Scrub != nil {
+		in, out := &in.Scrub, &out.Scrub
+		*out = new(ScrubSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FullRatios != nil {
+		in, out := &in.FullRatios, &out.FullRatios
+		*out = new(FullRatiosSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OSDWeightIn != nil {
+		in, out := &in.OSDWeightIn, &out.OSDWeightIn
+		*out = new(OSDWeightInSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -622,6 +1075,11 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 		*out = new(CephStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OSDs != nil {
+		in, out := &in.OSDs, &out.OSDs
+		*out = make([]OSDStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -635,9 +1093,52 @@ func (in *ClusterStatus) DeepCopy() *ClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DashboardIngressSpec) DeepCopyInto(out *DashboardIngressSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardIngressSpec.
+func (in *DashboardIngressSpec) DeepCopy() *DashboardIngressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DashboardIngressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DashboardSpec) DeepCopyInto(out *DashboardSpec) {
 	*out = *in
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Ingress.DeepCopyInto(&out.Ingress)
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]DashboardUserSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -651,6 +1152,27 @@ func (in *DashboardSpec) DeepCopy() *DashboardSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DashboardUserSpec) DeepCopyInto(out *DashboardUserSpec) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardUserSpec.
+func (in *DashboardUserSpec) DeepCopy() *DashboardUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DashboardUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DisruptionManagementSpec) DeepCopyInto(out *DisruptionManagementSpec) {
 	*out = *in
@@ -722,6 +1244,37 @@ func (in *FilesystemSpec) DeepCopy() *FilesystemSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FullRatiosSpec) DeepCopyInto(out *FullRatiosSpec) {
+	*out = *in
+	if in.Full != nil {
+		in, out := &in.Full, &out.Full
+		*out = new(float64)
+		**out = **in
+	}
+	if in.BackfillFull != nil {
+		in, out := &in.BackfillFull, &out.BackfillFull
+		*out = new(float64)
+		**out = **in
+	}
+	if in.NearFull != nil {
+		in, out := &in.NearFull, &out.NearFull
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FullRatiosSpec.
+func (in *FullRatiosSpec) DeepCopy() *FullRatiosSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FullRatiosSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GaneshaRADOSSpec) DeepCopyInto(out *GaneshaRADOSSpec) {
 	*out = *in
@@ -775,6 +1328,13 @@ func (in *GatewaySpec) DeepCopyInto(out *GatewaySpec) {
 		}
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.ExternalRgwEndpoints != nil {
+		in, out := &in.ExternalRgwEndpoints, &out.ExternalRgwEndpoints
+		*out = make([]corev1.EndpointAddress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -789,130 +1349,347 @@ func (in *GatewaySpec) DeepCopy() *GatewaySpec {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetadataServerSpec) DeepCopyInto(out *MetadataServerSpec) {
+func (in *HTTPEndpointSpec) DeepCopyInto(out *HTTPEndpointSpec) {
 	*out = *in
-	in.Placement.DeepCopyInto(&out.Placement)
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(v1alpha2.Annotations, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	in.Resources.DeepCopyInto(&out.Resources)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetadataServerSpec.
-func (in *MetadataServerSpec) DeepCopy() *MetadataServerSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPEndpointSpec.
+func (in *HTTPEndpointSpec) DeepCopy() *HTTPEndpointSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MetadataServerSpec)
+	out := new(HTTPEndpointSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MgrSpec) DeepCopyInto(out *MgrSpec) {
+func (in *KafkaEndpointSpec) DeepCopyInto(out *KafkaEndpointSpec) {
 	*out = *in
-	if in.Modules != nil {
-		in, out := &in.Modules, &out.Modules
-		*out = make([]Module, len(*in))
-		copy(*out, *in)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MgrSpec.
-func (in *MgrSpec) DeepCopy() *MgrSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaEndpointSpec.
+func (in *KafkaEndpointSpec) DeepCopy() *KafkaEndpointSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MgrSpec)
+	out := new(KafkaEndpointSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Module) DeepCopyInto(out *Module) {
+func (in *KeyManagementServiceSpec) DeepCopyInto(out *KeyManagementServiceSpec) {
 	*out = *in
+	if in.ConnectionDetails != nil {
+		in, out := &in.ConnectionDetails, &out.ConnectionDetails
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Module.
-func (in *Module) DeepCopy() *Module {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyManagementServiceSpec.
+func (in *KeyManagementServiceSpec) DeepCopy() *KeyManagementServiceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(Module)
+	out := new(KeyManagementServiceSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MonSpec) DeepCopyInto(out *MonSpec) {
+func (in *KeystoneSpec) DeepCopyInto(out *KeystoneSpec) {
 	*out = *in
-	if in.VolumeClaimTemplate != nil {
-		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
-		*out = new(corev1.PersistentVolumeClaim)
-		(*in).DeepCopyInto(*out)
+	if in.AcceptedRoles != nil {
+		in, out := &in.AcceptedRoles, &out.AcceptedRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TokenCacheSize != nil {
+		in, out := &in.TokenCacheSize, &out.TokenCacheSize
+		*out = new(int)
+		**out = **in
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonSpec.
-func (in *MonSpec) DeepCopy() *MonSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoneSpec.
+func (in *KeystoneSpec) DeepCopy() *KeystoneSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MonSpec)
+	out := new(KeystoneSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+func (in *MetadataServerSpec) DeepCopyInto(out *MetadataServerSpec) {
 	*out = *in
+	in.Placement.DeepCopyInto(&out.Placement)
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(v1alpha2.Annotations, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
-func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetadataServerSpec.
+func (in *MetadataServerSpec) DeepCopy() *MetadataServerSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MonitoringSpec)
+	out := new(MetadataServerSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NFSGaneshaSpec) DeepCopyInto(out *NFSGaneshaSpec) {
+func (in *MgrFailoverSpec) DeepCopyInto(out *MgrFailoverSpec) {
 	*out = *in
-	out.RADOS = in.RADOS
-	in.Server.DeepCopyInto(&out.Server)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NFSGaneshaSpec.
-func (in *NFSGaneshaSpec) DeepCopy() *NFSGaneshaSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MgrFailoverSpec.
+func (in *MgrFailoverSpec) DeepCopy() *MgrFailoverSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(NFSGaneshaSpec)
+	out := new(MgrFailoverSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+func (in *MgrSpec) DeepCopyInto(out *MgrSpec) {
 	*out = *in
-	in.NetworkSpec.DeepCopyInto(&out.NetworkSpec)
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
+	if in.Modules != nil {
+		in, out := &in.Modules, &out.Modules
+		*out = make([]Module, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeploymentLabels != nil {
+		in, out := &in.DeploymentLabels, &out.DeploymentLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ServiceAccountToken != nil {
+		in, out := &in.ServiceAccountToken, &out.ServiceAccountToken
+		*out = new(ProjectedServiceAccountTokenSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.PrometheusModule.DeepCopyInto(&out.PrometheusModule)
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(ProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(ProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ServiceAnnotations != nil {
+		in, out := &in.ServiceAnnotations, &out.ServiceAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.ActiveFailover = in.ActiveFailover
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MgrSpec.
+func (in *MgrSpec) DeepCopy() *MgrSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MgrSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Module) DeepCopyInto(out *Module) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Module.
+func (in *Module) DeepCopy() *Module {
+	if in == nil {
+		return nil
+	}
+	out := new(Module)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonCompactionSpec) DeepCopyInto(out *MonCompactionSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonCompactionSpec.
+func (in *MonCompactionSpec) DeepCopy() *MonCompactionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonCompactionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonConfigSpec) DeepCopyInto(out *MonConfigSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonConfigSpec.
+func (in *MonConfigSpec) DeepCopy() *MonConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonPublishEndpointsSpec) DeepCopyInto(out *MonPublishEndpointsSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonPublishEndpointsSpec.
+func (in *MonPublishEndpointsSpec) DeepCopy() *MonPublishEndpointsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonPublishEndpointsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonSpec) DeepCopyInto(out *MonSpec) {
+	*out = *in
+	if in.VolumeClaimTemplate != nil {
+		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
+		*out = new(corev1.PersistentVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StretchCluster != nil {
+		in, out := &in.StretchCluster, &out.StretchCluster
+		*out = new(StretchClusterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PublishEndpoints != nil {
+		in, out := &in.PublishEndpoints, &out.PublishEndpoints
+		*out = new(MonPublishEndpointsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Compaction != nil {
+		in, out := &in.Compaction, &out.Compaction
+		*out = new(MonCompactionSpec)
+		**out = **in
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(MonConfigSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonSpec.
+func (in *MonSpec) DeepCopy() *MonSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NFSGaneshaSpec) DeepCopyInto(out *NFSGaneshaSpec) {
+	*out = *in
+	out.RADOS = in.RADOS
+	in.Server.DeepCopyInto(&out.Server)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NFSGaneshaSpec.
+func (in *NFSGaneshaSpec) DeepCopy() *NFSGaneshaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NFSGaneshaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+	*out = *in
+	in.NetworkSpec.DeepCopyInto(&out.NetworkSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
 func (in *NetworkSpec) DeepCopy() *NetworkSpec {
 	if in == nil {
 		return nil
@@ -922,12 +1699,109 @@ func (in *NetworkSpec) DeepCopy() *NetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationFilterSpec) DeepCopyInto(out *NotificationFilterSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationFilterSpec.
+func (in *NotificationFilterSpec) DeepCopy() *NotificationFilterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationFilterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDStatus) DeepCopyInto(out *OSDStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDStatus.
+func (in *OSDStatus) DeepCopy() *OSDStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDWeightInSpec) DeepCopyInto(out *OSDWeightInSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDWeightInSpec.
+func (in *OSDWeightInSpec) DeepCopy() *OSDWeightInSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDWeightInSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectBucketQuotaSpec) DeepCopyInto(out *ObjectBucketQuotaSpec) {
+	*out = *in
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxObjects != nil {
+		in, out := &in.MaxObjects, &out.MaxObjects
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectBucketQuotaSpec.
+func (in *ObjectBucketQuotaSpec) DeepCopy() *ObjectBucketQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectBucketQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectRealmSpec) DeepCopyInto(out *ObjectRealmSpec) {
+	*out = *in
+	if in.Pull != nil {
+		in, out := &in.Pull, &out.Pull
+		*out = new(PullSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectRealmSpec.
+func (in *ObjectRealmSpec) DeepCopy() *ObjectRealmSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectRealmSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectStoreSpec) DeepCopyInto(out *ObjectStoreSpec) {
 	*out = *in
 	out.MetadataPool = in.MetadataPool
 	out.DataPool = in.DataPool
 	in.Gateway.DeepCopyInto(&out.Gateway)
+	out.Zone = in.Zone
+	in.Auth.DeepCopyInto(&out.Auth)
 	return
 }
 
@@ -944,6 +1818,16 @@ func (in *ObjectStoreSpec) DeepCopy() *ObjectStoreSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectStoreUserSpec) DeepCopyInto(out *ObjectStoreUserSpec) {
 	*out = *in
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(ObjectUserQuotaSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = new(ObjectUserCapSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -957,6 +1841,118 @@ func (in *ObjectStoreUserSpec) DeepCopy() *ObjectStoreUserSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectUserCapSpec) DeepCopyInto(out *ObjectUserCapSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectUserCapSpec.
+func (in *ObjectUserCapSpec) DeepCopy() *ObjectUserCapSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectUserCapSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectUserQuotaSpec) DeepCopyInto(out *ObjectUserQuotaSpec) {
+	*out = *in
+	if in.MaxBuckets != nil {
+		in, out := &in.MaxBuckets, &out.MaxBuckets
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxObjects != nil {
+		in, out := &in.MaxObjects, &out.MaxObjects
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Bucket != nil {
+		in, out := &in.Bucket, &out.Bucket
+		*out = new(ObjectBucketQuotaSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectUserQuotaSpec.
+func (in *ObjectUserQuotaSpec) DeepCopy() *ObjectUserQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectUserQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectZoneGroupSpec) DeepCopyInto(out *ObjectZoneGroupSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectZoneGroupSpec.
+func (in *ObjectZoneGroupSpec) DeepCopy() *ObjectZoneGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectZoneGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectZoneSpec) DeepCopyInto(out *ObjectZoneSpec) {
+	*out = *in
+	out.MetadataPool = in.MetadataPool
+	out.DataPool = in.DataPool
+	if in.CustomEndpoints != nil {
+		in, out := &in.CustomEndpoints, &out.CustomEndpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectZoneSpec.
+func (in *ObjectZoneSpec) DeepCopy() *ObjectZoneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectZoneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectZoneStatus) DeepCopyInto(out *ObjectZoneStatus) {
+	*out = *in
+	if in.SyncStatus != nil {
+		in, out := &in.SyncStatus, &out.SyncStatus
+		*out = new(ZoneSyncStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectZoneStatus.
+func (in *ObjectZoneStatus) DeepCopy() *ObjectZoneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectZoneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PoolSpec) DeepCopyInto(out *PoolSpec) {
 	*out = *in
@@ -975,6 +1971,85 @@ func (in *PoolSpec) DeepCopy() *PoolSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeSpec) DeepCopyInto(out *ProbeSpec) {
+	*out = *in
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeSpec.
+func (in *ProbeSpec) DeepCopy() *ProbeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectedServiceAccountTokenSpec) DeepCopyInto(out *ProjectedServiceAccountTokenSpec) {
+	*out = *in
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectedServiceAccountTokenSpec.
+func (in *ProjectedServiceAccountTokenSpec) DeepCopy() *ProjectedServiceAccountTokenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectedServiceAccountTokenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusModuleSpec) DeepCopyInto(out *PrometheusModuleSpec) {
+	*out = *in
+	if in.RBDStatsPools != nil {
+		in, out := &in.RBDStatsPools, &out.RBDStatsPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusModuleSpec.
+func (in *PrometheusModuleSpec) DeepCopy() *PrometheusModuleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusModuleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PullSpec) DeepCopyInto(out *PullSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullSpec.
+func (in *PullSpec) DeepCopy() *PullSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PullSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RBDMirroringSpec) DeepCopyInto(out *RBDMirroringSpec) {
 	*out = *in
@@ -1007,6 +2082,49 @@ func (in *ReplicatedSpec) DeepCopy() *ReplicatedSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScrubSpec) DeepCopyInto(out *ScrubSpec) {
+	*out = *in
+	if in.BeginHour != nil {
+		in, out := &in.BeginHour, &out.BeginHour
+		*out = new(int)
+		**out = **in
+	}
+	if in.EndHour != nil {
+		in, out := &in.EndHour, &out.EndHour
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScrubSpec.
+func (in *ScrubSpec) DeepCopy() *ScrubSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScrubSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecuritySpec) DeepCopyInto(out *SecuritySpec) {
+	*out = *in
+	in.KeyManagementService.DeepCopyInto(&out.KeyManagementService)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecuritySpec.
+func (in *SecuritySpec) DeepCopy() *SecuritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecuritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Status) DeepCopyInto(out *Status) {
 	*out = *in
@@ -1022,3 +2140,110 @@ func (in *Status) DeepCopy() *Status {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StretchClusterSpec) DeepCopyInto(out *StretchClusterSpec) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]StretchClusterZoneSpec, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StretchClusterSpec.
+func (in *StretchClusterSpec) DeepCopy() *StretchClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StretchClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StretchClusterZoneSpec) DeepCopyInto(out *StretchClusterZoneSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StretchClusterZoneSpec.
+func (in *StretchClusterZoneSpec) DeepCopy() *StretchClusterZoneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StretchClusterZoneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicEndpointSpec) DeepCopyInto(out *TopicEndpointSpec) {
+	*out = *in
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPEndpointSpec)
+		**out = **in
+	}
+	if in.AMQP != nil {
+		in, out := &in.AMQP, &out.AMQP
+		*out = new(AMQPEndpointSpec)
+		**out = **in
+	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaEndpointSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicEndpointSpec.
+func (in *TopicEndpointSpec) DeepCopy() *TopicEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneSpec) DeepCopyInto(out *ZoneSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneSpec.
+func (in *ZoneSpec) DeepCopy() *ZoneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneSyncStatus) DeepCopyInto(out *ZoneSyncStatus) {
+	*out = *in
+	if in.DataSync != nil {
+		in, out := &in.DataSync, &out.DataSync
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneSyncStatus.
+func (in *ZoneSyncStatus) DeepCopy() *ZoneSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
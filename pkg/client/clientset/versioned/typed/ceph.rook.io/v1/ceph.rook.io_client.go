@@ -28,12 +28,17 @@ import (
 type CephV1Interface interface {
 	RESTClient() rest.Interface
 	CephBlockPoolsGetter
+	CephBucketNotificationsGetter
+	CephBucketTopicsGetter
 	CephClientsGetter
 	CephClustersGetter
 	CephFilesystemsGetter
 	CephNFSesGetter
+	CephObjectRealmsGetter
 	CephObjectStoresGetter
 	CephObjectStoreUsersGetter
+	CephObjectZonesGetter
+	CephObjectZoneGroupsGetter
 }
 
 // CephV1Client is used to interact with features provided by the ceph.rook.io group.
@@ -45,6 +50,14 @@ func (c *CephV1Client) CephBlockPools(namespace string) CephBlockPoolInterface {
 	return newCephBlockPools(c, namespace)
 }
 
+func (c *CephV1Client) CephBucketNotifications(namespace string) CephBucketNotificationInterface {
+	return newCephBucketNotifications(c, namespace)
+}
+
+func (c *CephV1Client) CephBucketTopics(namespace string) CephBucketTopicInterface {
+	return newCephBucketTopics(c, namespace)
+}
+
 func (c *CephV1Client) CephClients(namespace string) CephClientInterface {
 	return newCephClients(c, namespace)
 }
@@ -61,6 +74,10 @@ func (c *CephV1Client) CephNFSes(namespace string) CephNFSInterface {
 	return newCephNFSes(c, namespace)
 }
 
+func (c *CephV1Client) CephObjectRealms(namespace string) CephObjectRealmInterface {
+	return newCephObjectRealms(c, namespace)
+}
+
 func (c *CephV1Client) CephObjectStores(namespace string) CephObjectStoreInterface {
 	return newCephObjectStores(c, namespace)
 }
@@ -69,6 +86,14 @@ func (c *CephV1Client) CephObjectStoreUsers(namespace string) CephObjectStoreUse
 	return newCephObjectStoreUsers(c, namespace)
 }
 
+func (c *CephV1Client) CephObjectZones(namespace string) CephObjectZoneInterface {
+	return newCephObjectZones(c, namespace)
+}
+
+func (c *CephV1Client) CephObjectZoneGroups(namespace string) CephObjectZoneGroupInterface {
+	return newCephObjectZoneGroups(c, namespace)
+}
+
 // NewForConfig creates a new CephV1Client for the given config.
 func NewForConfig(c *rest.Config) (*CephV1Client, error) {
 	config := *c
@@ -32,6 +32,14 @@ func (c *FakeCephV1) CephBlockPools(namespace string) v1.CephBlockPoolInterface
 	return &FakeCephBlockPools{c, namespace}
 }
 
+func (c *FakeCephV1) CephBucketNotifications(namespace string) v1.CephBucketNotificationInterface {
+	return &FakeCephBucketNotifications{c, namespace}
+}
+
+func (c *FakeCephV1) CephBucketTopics(namespace string) v1.CephBucketTopicInterface {
+	return &FakeCephBucketTopics{c, namespace}
+}
+
 func (c *FakeCephV1) CephClients(namespace string) v1.CephClientInterface {
 	return &FakeCephClients{c, namespace}
 }
@@ -48,6 +56,10 @@ func (c *FakeCephV1) CephNFSes(namespace string) v1.CephNFSInterface {
 	return &FakeCephNFSes{c, namespace}
 }
 
+func (c *FakeCephV1) CephObjectRealms(namespace string) v1.CephObjectRealmInterface {
+	return &FakeCephObjectRealms{c, namespace}
+}
+
 func (c *FakeCephV1) CephObjectStores(namespace string) v1.CephObjectStoreInterface {
 	return &FakeCephObjectStores{c, namespace}
 }
@@ -56,6 +68,14 @@ func (c *FakeCephV1) CephObjectStoreUsers(namespace string) v1.CephObjectStoreUs
 	return &FakeCephObjectStoreUsers{c, namespace}
 }
 
+func (c *FakeCephV1) CephObjectZones(namespace string) v1.CephObjectZoneInterface {
+	return &FakeCephObjectZones{c, namespace}
+}
+
+func (c *FakeCephV1) CephObjectZoneGroups(namespace string) v1.CephObjectZoneGroupInterface {
+	return &FakeCephObjectZoneGroups{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCephV1) RESTClient() rest.Interface {
@@ -20,6 +20,10 @@ package v1
 
 type CephBlockPoolExpansion interface{}
 
+type CephBucketNotificationExpansion interface{}
+
+type CephBucketTopicExpansion interface{}
+
 type CephClientExpansion interface{}
 
 type CephClusterExpansion interface{}
@@ -28,6 +32,12 @@ type CephFilesystemExpansion interface{}
 
 type CephNFSExpansion interface{}
 
+type CephObjectRealmExpansion interface{}
+
 type CephObjectStoreExpansion interface{}
 
 type CephObjectStoreUserExpansion interface{}
+
+type CephObjectZoneExpansion interface{}
+
+type CephObjectZoneGroupExpansion interface{}
@@ -26,6 +26,22 @@ type CephBlockPoolListerExpansion interface{}
 // CephBlockPoolNamespaceLister.
 type CephBlockPoolNamespaceListerExpansion interface{}
 
+// CephBucketNotificationListerExpansion allows custom methods to be added to
+// CephBucketNotificationLister.
+type CephBucketNotificationListerExpansion interface{}
+
+// CephBucketNotificationNamespaceListerExpansion allows custom methods to be added to
+// CephBucketNotificationNamespaceLister.
+type CephBucketNotificationNamespaceListerExpansion interface{}
+
+// CephBucketTopicListerExpansion allows custom methods to be added to
+// CephBucketTopicLister.
+type CephBucketTopicListerExpansion interface{}
+
+// CephBucketTopicNamespaceListerExpansion allows custom methods to be added to
+// CephBucketTopicNamespaceLister.
+type CephBucketTopicNamespaceListerExpansion interface{}
+
 // CephClientListerExpansion allows custom methods to be added to
 // CephClientLister.
 type CephClientListerExpansion interface{}
@@ -58,6 +74,14 @@ type CephNFSListerExpansion interface{}
 // CephNFSNamespaceLister.
 type CephNFSNamespaceListerExpansion interface{}
 
+// CephObjectRealmListerExpansion allows custom methods to be added to
+// CephObjectRealmLister.
+type CephObjectRealmListerExpansion interface{}
+
+// CephObjectRealmNamespaceListerExpansion allows custom methods to be added to
+// CephObjectRealmNamespaceLister.
+type CephObjectRealmNamespaceListerExpansion interface{}
+
 // CephObjectStoreListerExpansion allows custom methods to be added to
 // CephObjectStoreLister.
 type CephObjectStoreListerExpansion interface{}
@@ -73,3 +97,19 @@ type CephObjectStoreUserListerExpansion interface{}
 // CephObjectStoreUserNamespaceListerExpansion allows custom methods to be added to
 // CephObjectStoreUserNamespaceLister.
 type CephObjectStoreUserNamespaceListerExpansion interface{}
+
+// CephObjectZoneListerExpansion allows custom methods to be added to
+// CephObjectZoneLister.
+type CephObjectZoneListerExpansion interface{}
+
+// CephObjectZoneNamespaceListerExpansion allows custom methods to be added to
+// CephObjectZoneNamespaceLister.
+type CephObjectZoneNamespaceListerExpansion interface{}
+
+// CephObjectZoneGroupListerExpansion allows custom methods to be added to
+// CephObjectZoneGroupLister.
+type CephObjectZoneGroupListerExpansion interface{}
+
+// CephObjectZoneGroupNamespaceListerExpansion allows custom methods to be added to
+// CephObjectZoneGroupNamespaceLister.
+type CephObjectZoneGroupNamespaceListerExpansion interface{}
@@ -30,6 +30,7 @@ import (
 	"github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -106,14 +107,6 @@ func TestAttach(t *testing.T) {
 	clusterNamespace := "testCluster"
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
-	cm := &v1.ConfigMap{
-		Data: map[string]string{
-			"data": "rook-ceph-mon0=10.0.0.1:6789,rook-ceph-mon1=10.0.0.2:6789,rook-ceph-mon2=10.0.0.3:6789",
-		},
-	}
-	cm.Name = "rook-ceph-mon-endpoints"
-	clientset.CoreV1().ConfigMaps(clusterNamespace).Create(cm)
-
 	runCount := 1
 
 	executor := &exectest.MockExecutor{
@@ -154,7 +147,17 @@ func TestAttach(t *testing.T) {
 			called:   0,
 		},
 	}
-	mon.CreateOrLoadClusterInfo(context, clusterNamespace, &metav1.OwnerReference{})
+	// the cluster identity is created before the mon endpoints exist, like when the cluster is created
+	_, _, _, err := mon.CreateOrLoadClusterInfo(context, clusterNamespace, &metav1.OwnerReference{})
+	require.NoError(t, err)
+	cm := &v1.ConfigMap{
+		Data: map[string]string{
+			"data": "rook-ceph-mon0=10.0.0.1:6789,rook-ceph-mon1=10.0.0.2:6789,rook-ceph-mon2=10.0.0.3:6789",
+		},
+	}
+	cm.Name = "rook-ceph-mon-endpoints"
+	_, err = clientset.CoreV1().ConfigMaps(clusterNamespace).Create(cm)
+	require.NoError(t, err)
 
 	devicePath, err := vm.Attach("image1", "testpool", "admin", "never-gonna-give-you-up", clusterNamespace)
 	assert.Equal(t, "/dev/rbd3", devicePath)
@@ -191,14 +194,6 @@ func TestDetach(t *testing.T) {
 	clusterNamespace := "testCluster"
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
-	cm := &v1.ConfigMap{
-		Data: map[string]string{
-			"data": "rook-ceph-mon0=10.0.0.1:6789,rook-ceph-mon1=10.0.0.2:6789,rook-ceph-mon2=10.0.0.3:6789",
-		},
-	}
-	cm.Name = "rook-ceph-mon-endpoints"
-	clientset.CoreV1().ConfigMaps(clusterNamespace).Create(cm)
-
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
 			if strings.Contains(command, "ceph-authtool") {
@@ -232,8 +227,19 @@ func TestDetach(t *testing.T) {
 			called:   0,
 		},
 	}
-	mon.CreateOrLoadClusterInfo(context, clusterNamespace, &metav1.OwnerReference{})
-	err := vm.Detach("image1", "testpool", "admin", "", clusterNamespace, false)
+	// the cluster identity is created before the mon endpoints exist, like when the cluster is created
+	_, _, _, err := mon.CreateOrLoadClusterInfo(context, clusterNamespace, &metav1.OwnerReference{})
+	require.NoError(t, err)
+	cm := &v1.ConfigMap{
+		Data: map[string]string{
+			"data": "rook-ceph-mon0=10.0.0.1:6789,rook-ceph-mon1=10.0.0.2:6789,rook-ceph-mon2=10.0.0.3:6789",
+		},
+	}
+	cm.Name = "rook-ceph-mon-endpoints"
+	_, err = clientset.CoreV1().ConfigMaps(clusterNamespace).Create(cm)
+	require.NoError(t, err)
+
+	err = vm.Detach("image1", "testpool", "admin", "", clusterNamespace, false)
 	assert.Nil(t, err)
 }
 
@@ -242,14 +248,6 @@ func TestDetachCustomKeyring(t *testing.T) {
 	clusterNamespace := "testCluster"
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
-	cm := &v1.ConfigMap{
-		Data: map[string]string{
-			"data": "rook-ceph-mon0=10.0.0.1:6789,rook-ceph-mon1=10.0.0.2:6789,rook-ceph-mon2=10.0.0.3:6789",
-		},
-	}
-	cm.Name = "rook-ceph-mon-endpoints"
-	clientset.CoreV1().ConfigMaps(clusterNamespace).Create(cm)
-
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
 			if strings.Contains(command, "ceph-authtool") {
@@ -283,8 +281,19 @@ func TestDetachCustomKeyring(t *testing.T) {
 			called:   0,
 		},
 	}
-	mon.CreateOrLoadClusterInfo(context, clusterNamespace, &metav1.OwnerReference{})
-	err := vm.Detach("image1", "testpool", "user1", "", clusterNamespace, false)
+	// the cluster identity is created before the mon endpoints exist, like when the cluster is created
+	_, _, _, err := mon.CreateOrLoadClusterInfo(context, clusterNamespace, &metav1.OwnerReference{})
+	require.NoError(t, err)
+	cm := &v1.ConfigMap{
+		Data: map[string]string{
+			"data": "rook-ceph-mon0=10.0.0.1:6789,rook-ceph-mon1=10.0.0.2:6789,rook-ceph-mon2=10.0.0.3:6789",
+		},
+	}
+	cm.Name = "rook-ceph-mon-endpoints"
+	_, err = clientset.CoreV1().ConfigMaps(clusterNamespace).Create(cm)
+	require.NoError(t, err)
+
+	err = vm.Detach("image1", "testpool", "user1", "", clusterNamespace, false)
 	assert.Nil(t, err)
 }
 
@@ -19,6 +19,7 @@ package client
 import (
 	"fmt"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -44,6 +45,41 @@ const (
 	cephConnectionTimeout = "15" // in seconds
 )
 
+var (
+	clusterUsernames     = map[string]string{}
+	clusterUsernamesLock sync.Mutex
+)
+
+// SetClusterUsername sets the user the ceph commands connect as to the cluster. The admin user is used
+// unless another user is set, for example a restricted user of an external cluster.
+func SetClusterUsername(clusterName, username string) {
+	clusterUsernamesLock.Lock()
+	defer clusterUsernamesLock.Unlock()
+	if username == "" || username == AdminUsername {
+		delete(clusterUsernames, clusterName)
+		return
+	}
+	clusterUsernames[clusterName] = username
+}
+
+// ClearClusterUsername forgets the user the ceph commands connect as to the cluster when the cluster
+// is deleted
+func ClearClusterUsername(clusterName string) {
+	clusterUsernamesLock.Lock()
+	defer clusterUsernamesLock.Unlock()
+	delete(clusterUsernames, clusterName)
+}
+
+// GetClusterUsername returns the user the ceph commands connect as to the cluster
+func GetClusterUsername(clusterName string) string {
+	clusterUsernamesLock.Lock()
+	defer clusterUsernamesLock.Unlock()
+	if username, ok := clusterUsernames[clusterName]; ok {
+		return username
+	}
+	return AdminUsername
+}
+
 // CephConfFilePath returns the location to the cluster's config file in the operator container.
 func CephConfFilePath(configDir, clusterName string) string {
 	confFile := fmt.Sprintf("%s.config", clusterName)
@@ -75,12 +111,16 @@ func FinalizeCephCommandArgs(command string, args []string, configDir, clusterNa
 	}
 
 	// Append the args to find the config and keyring
-	keyringFile := fmt.Sprintf("%s.keyring", AdminUsername)
+	username := GetClusterUsername(clusterName)
+	keyringFile := fmt.Sprintf("%s.keyring", username)
 	configArgs := []string{
 		fmt.Sprintf("--cluster=%s", clusterName),
 		fmt.Sprintf("--conf=%s", CephConfFilePath(configDir, clusterName)),
 		fmt.Sprintf("--keyring=%s", path.Join(configDir, clusterName, keyringFile)),
 	}
+	if username != AdminUsername {
+		configArgs = append(configArgs, fmt.Sprintf("--name=%s", username))
+	}
 	return command, append(args, configArgs...)
 }
 
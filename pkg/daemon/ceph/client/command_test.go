@@ -41,6 +41,32 @@ func TestFinalizeCephCommandArgs(t *testing.T) {
 	assert.Exactly(t, expectedArgs, args)
 }
 
+func TestFinalizeCephCommandArgsExternalUser(t *testing.T) {
+	RunAllCephCommandsInToolbox = false
+	SetClusterUsername("rook", "client.healthchecker")
+	defer ClearClusterUsername("rook")
+	expectedArgs := []string{
+		"quorum_status",
+		"--connect-timeout=15",
+		"--cluster=rook",
+		"--conf=/var/lib/rook/rook-ceph/rook/rook.config",
+		"--keyring=/var/lib/rook/rook-ceph/rook/client.healthchecker.keyring",
+		"--name=client.healthchecker",
+	}
+
+	_, args := FinalizeCephCommandArgs("ceph", []string{"quorum_status"}, "/var/lib/rook/rook-ceph", "rook")
+	assert.Exactly(t, expectedArgs, args)
+
+	// the admin user is used again when the user is reset
+	SetClusterUsername("rook", "")
+	assert.Equal(t, AdminUsername, GetClusterUsername("rook"))
+
+	// the user is forgotten when the cluster is deleted
+	SetClusterUsername("rook", "client.healthchecker")
+	ClearClusterUsername("rook")
+	assert.Equal(t, AdminUsername, GetClusterUsername("rook"))
+}
+
 func TestFinalizeRadosGWAdminCommandArgs(t *testing.T) {
 	RunAllCephCommandsInToolbox = false
 	clusterName := "rook"
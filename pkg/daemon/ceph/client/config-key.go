@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// GetConfigKey gets a value from the config-key store of the mons. It returns whether the key was found.
+func GetConfigKey(context *clusterd.Context, clusterName, key string) (string, bool, error) {
+	// the keys with the prefix are dumped so a missing key is not reported as a failure
+	// the values may be secrets, so the command is only logged at the debug level and the output is never logged
+	cmd := NewCephCommand(context, clusterName, []string{"config-key", "dump", key})
+	cmd.Debug = true
+	buf, err := cmd.Run()
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to get config key %s", key)
+	}
+	var keys map[string]string
+	if err := json.Unmarshal(buf, &keys); err != nil {
+		return "", false, errors.Wrapf(err, "failed to unmarshal config key %s", key)
+	}
+	val, ok := keys[key]
+	return val, ok, nil
+}
+
+// SetConfigKey stores a value in the config-key store of the mons. The value may be a secret such as an
+// encryption key, so it is passed in a temp file instead of on the command line.
+func SetConfigKey(context *clusterd.Context, clusterName, key, val string) error {
+	file, err := ioutil.TempFile("", "config-key")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the value file of config key %s", key)
+	}
+	defer func() {
+		if err := os.Remove(file.Name()); err != nil {
+			logger.Warningf("failed to remove the value file of config key %s. %v", key, err)
+		}
+	}()
+	_, err = file.WriteString(val)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the value file of config key %s", key)
+	}
+
+	cmd := NewCephCommand(context, clusterName, []string{"config-key", "set", key, "-i", file.Name()})
+	cmd.Debug = true
+	if _, err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to set config key %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigKey(t *testing.T) {
+	store := map[string]string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		// the values are secrets, so the commands are only logged at the debug level
+		assert.True(t, debug)
+		switch {
+		case args[0] == "config-key" && args[1] == "dump":
+			if val, ok := store[args[2]]; ok {
+				return `{"` + args[2] + `":"` + val + `"}`, nil
+			}
+			return `{}`, nil
+		case args[0] == "config-key" && args[1] == "set":
+			// the value is not passed on the command line
+			require.Equal(t, "-i", args[3])
+			val, err := ioutil.ReadFile(args[4])
+			require.NoError(t, err)
+			store[args[2]] = string(val)
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// a missing key is not a failure
+	_, found, err := GetConfigKey(context, "rook", "dm-crypt/osd/myuuid/luks")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	err = SetConfigKey(context, "rook", "dm-crypt/osd/myuuid/luks", "mykey")
+	assert.NoError(t, err)
+	val, found, err := GetConfigKey(context, "rook", "dm-crypt/osd/myuuid/luks")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "mykey", val)
+}
@@ -18,6 +18,9 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -93,8 +96,85 @@ func GetCrushMap(context *clusterd.Context, clusterName string) (CrushMap, error
 	return c, nil
 }
 
+// CreateStretchCrushRule creates a crush rule that places two replicas in each of two buckets of the failure
+// domain type, on different buckets of the sub failure domain type. The rule is not modified if it already exists.
+func CreateStretchCrushRule(context *clusterd.Context, clusterName, ruleName, failureDomain, subFailureDomain string) error {
+	crushMap, err := GetCrushMap(context, clusterName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get crush map")
+	}
+	ruleID := 0
+	for _, rule := range crushMap.Rules {
+		if rule.Name == ruleName {
+			logger.Infof("crush rule %q already exists", ruleName)
+			return nil
+		}
+		if rule.ID >= ruleID {
+			ruleID = rule.ID + 1
+		}
+	}
+
+	// the rule is added to the decompiled crush map, which is compiled and set back in the cluster
+	dir, err := ioutil.TempDir("", "crushmap")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a temp dir for the crush map")
+	}
+	defer os.RemoveAll(dir)
+	compiledPath := path.Join(dir, "crushmap")
+	decompiledPath := path.Join(dir, "crushmap.txt")
+
+	cmd := NewCephCommand(context, clusterName, []string{"osd", "getcrushmap", "-o", compiledPath})
+	cmd.JsonOutput = false
+	cmd.OutputFile = false
+	if _, err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to get the compiled crush map")
+	}
+	if _, err := context.Executor.ExecuteCommandWithOutput(false, "", CrushTool, "--decompile", compiledPath, "--outfn", decompiledPath); err != nil {
+		return errors.Wrapf(err, "failed to decompile the crush map")
+	}
+
+	f, err := os.OpenFile(decompiledPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open the decompiled crush map")
+	}
+	_, err = f.WriteString(stretchCrushRule(ruleName, ruleID, failureDomain, subFailureDomain))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to add crush rule %q to the decompiled crush map", ruleName)
+	}
+
+	if _, err := context.Executor.ExecuteCommandWithOutput(false, "", CrushTool, "--compile", decompiledPath, "--outfn", compiledPath); err != nil {
+		return errors.Wrapf(err, "failed to compile the crush map")
+	}
+	cmd = NewCephCommand(context, clusterName, []string{"osd", "setcrushmap", "-i", compiledPath})
+	cmd.JsonOutput = false
+	cmd.OutputFile = false
+	if _, err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to set the crush map with rule %q", ruleName)
+	}
+	logger.Infof("created crush rule %q", ruleName)
+	return nil
+}
+
+func stretchCrushRule(ruleName string, ruleID int, failureDomain, subFailureDomain string) string {
+	return fmt.Sprintf(`
+rule %s {
+	id %d
+	type replicated
+	min_size 1
+	max_size 10
+	step take default
+	step choose firstn 0 type %s
+	step chooseleaf firstn 2 type %s
+	step emit
+}
+`, ruleName, ruleID, failureDomain, subFailureDomain)
+}
+
 func CrushReweight(context *clusterd.Context, clusterName string, id int, weight float64) (string, error) {
-	args := []string{"osd", "crush", "reweight", fmt.Sprintf("osd.%d", id), fmt.Sprintf("%.1f", weight)}
+	args := []string{"osd", "crush", "reweight", fmt.Sprintf("osd.%d", id), fmt.Sprintf("%.5f", weight)}
 	buf, err := NewCephCommand(context, clusterName, args).Run()
 
 	return string(buf), err
@@ -17,6 +17,7 @@ package client
 
 import (
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -280,3 +281,38 @@ func TestCrushName(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateStretchCrushRule(t *testing.T) {
+	commands := []string{}
+	rule := ""
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return testCrushMap, nil
+		}
+		return "", errors.Errorf("unexpected ceph command '%v'", args)
+	}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		commands = append(commands, fmt.Sprintf("%s %s", command, args[0]))
+		if command == CrushTool && args[0] == "--compile" {
+			b, err := ioutil.ReadFile(args[1])
+			assert.NoError(t, err)
+			rule = string(b)
+		}
+		return "", nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the rule is added to the crush map with the next id
+	err := CreateStretchCrushRule(context, "rook", "stretch_rule", "zone", "host")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ceph osd", "crushtool --decompile", "crushtool --compile", "ceph osd"}, commands)
+	assert.Contains(t, rule, "rule stretch_rule {\n\tid 2\n")
+	assert.Contains(t, rule, "step choose firstn 0 type zone\n\tstep chooseleaf firstn 2 type host\n")
+
+	// the rule already exists
+	commands = []string{}
+	err = CreateStretchCrushRule(context, "rook", "replicated_ruleset", "zone", "host")
+	assert.NoError(t, err)
+	assert.Empty(t, commands)
+}
@@ -83,6 +83,54 @@ func MgrSetConfig(context *clusterd.Context, clusterName, mgrName string, cephVe
 	return hasChanged, nil
 }
 
+// SetBalancerMode sets the mode of the balancer module, e.g. "upmap" or "crush-compat"
+func SetBalancerMode(context *clusterd.Context, clusterName, mode string) error {
+	args := []string{"balancer", "mode", mode}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to set balancer mode %q", mode)
+	}
+	return nil
+}
+
+// SetBalancerActive turns the automatic balancing of the balancer module on or off
+func SetBalancerActive(context *clusterd.Context, clusterName string, active bool) error {
+	action := "off"
+	if active {
+		action = "on"
+	}
+	args := []string{"balancer", action}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to turn the balancer %s", action)
+	}
+	return nil
+}
+
+// SetTelemetry opts in or out of sending the telemetry reports of the telemetry module. The license of the
+// reported data must be accepted to opt in on the Ceph versions that require it.
+func SetTelemetry(context *clusterd.Context, clusterName string, on bool, license string) error {
+	action := "off"
+	if on {
+		action = "on"
+	}
+	args := []string{"telemetry", action}
+	if on && license != "" {
+		args = append(args, "--license", license)
+	}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to turn the telemetry %s", action)
+	}
+	return nil
+}
+
+// MgrFail marks a mgr daemon as failed so that a standby takes over if it is the active mgr
+func MgrFail(context *clusterd.Context, clusterName, name string) error {
+	args := []string{"mgr", "fail", name}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to fail mgr %q", name)
+	}
+	return nil
+}
+
 func enableModule(context *clusterd.Context, clusterName, name string, force bool, action string) error {
 	args := []string{"mgr", "module", action, name}
 	if force {
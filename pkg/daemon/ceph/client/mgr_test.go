@@ -88,3 +88,69 @@ func TestEnableModule(t *testing.T) {
 	err = enableModule(&clusterd.Context{Executor: executor}, "clusterName", "pg_autoscaler", false, "invalidCommandArgs")
 	assert.Error(t, err)
 }
+
+func TestBalancer(t *testing.T) {
+	var lastArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "balancer" {
+			lastArgs = args
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, SetBalancerMode(context, "clusterName", "upmap"))
+	assert.Equal(t, []string{"balancer", "mode", "upmap"}, lastArgs[:3])
+
+	assert.NoError(t, SetBalancerActive(context, "clusterName", true))
+	assert.Equal(t, []string{"balancer", "on"}, lastArgs[:2])
+
+	assert.NoError(t, SetBalancerActive(context, "clusterName", false))
+	assert.Equal(t, []string{"balancer", "off"}, lastArgs[:2])
+}
+
+func TestTelemetry(t *testing.T) {
+	var lastArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "telemetry" {
+			lastArgs = args
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, SetTelemetry(context, "clusterName", true, ""))
+	assert.Equal(t, []string{"telemetry", "on"}, lastArgs[:2])
+	assert.NotContains(t, lastArgs, "--license")
+
+	assert.NoError(t, SetTelemetry(context, "clusterName", true, "sharing-1-0"))
+	assert.Equal(t, []string{"telemetry", "on", "--license", "sharing-1-0"}, lastArgs[:4])
+
+	// the license only applies to opting in
+	assert.NoError(t, SetTelemetry(context, "clusterName", false, "sharing-1-0"))
+	assert.Equal(t, []string{"telemetry", "off"}, lastArgs[:2])
+	assert.NotContains(t, lastArgs, "--license")
+}
+
+func TestMgrFail(t *testing.T) {
+	var lastArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "mgr" && args[1] == "fail" {
+			lastArgs = args
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, MgrFail(context, "clusterName", "a"))
+	assert.Equal(t, []string{"mgr", "fail", "a"}, lastArgs[:3])
+}
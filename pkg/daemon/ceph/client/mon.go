@@ -17,6 +17,7 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
@@ -27,7 +28,8 @@ import (
 type MonStatusResponse struct {
 	Quorum []int `json:"quorum"`
 	MonMap struct {
-		Mons []MonMapEntry `json:"mons"`
+		Mons        []MonMapEntry `json:"mons"`
+		StretchMode bool          `json:"stretch_mode"`
 	} `json:"monmap"`
 }
 
@@ -108,3 +110,43 @@ func GetMonTimeStatus(context *clusterd.Context, clusterName string) (*MonTimeSt
 
 	return &timeStatus, nil
 }
+
+// SetMonLocation sets the crush location of a mon, which is the bucket of the given type the mon runs in
+func SetMonLocation(context *clusterd.Context, clusterName, name, bucketType, bucket string) error {
+	args := []string{"mon", "set_location", name, fmt.Sprintf("%s=%s", bucketType, bucket)}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to set the location of mon %q", name)
+	}
+	return nil
+}
+
+// SetMonElectionStrategy sets the strategy the mons use to elect the leader
+func SetMonElectionStrategy(context *clusterd.Context, clusterName, strategy string) error {
+	args := []string{"mon", "set", "election_strategy", strategy}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to set the mon election strategy to %q", strategy)
+	}
+	return nil
+}
+
+// EnableStretchMode enables the stretch mode of the cluster. The data is split between the two buckets of the
+// given type according to the crush rule, and the tiebreaker mon decides which bucket stays in the quorum if
+// the connection between them is lost.
+func EnableStretchMode(context *clusterd.Context, clusterName, tiebreaker, ruleName, bucketType string) error {
+	args := []string{"mon", "enable_stretch_mode", tiebreaker, ruleName, bucketType}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to enable the stretch mode")
+	}
+	return nil
+}
+
+// CompactMonStore compacts the store of a mon
+func CompactMonStore(context *clusterd.Context, clusterName, name string) error {
+	args := []string{"tell", fmt.Sprintf("mon.%s", name), "compact"}
+	cmd := NewCephCommand(context, clusterName, args)
+	cmd.JsonOutput = false
+	if _, err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to compact the store of mon %q", name)
+	}
+	return nil
+}
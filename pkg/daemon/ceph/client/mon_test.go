@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -66,3 +68,39 @@ func TestCephArgs(t *testing.T) {
 	assert.Equal(t, 2, len(args))
 	assert.Equal(t, "myarg", args[0])
 }
+
+func TestStretchModeCommands(t *testing.T) {
+	commands := [][]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			// drop the connection, config and format args
+			commands = append(commands, args[:len(args)-6])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, SetMonLocation(context, "rook", "a", "zone", "us-east-2b"))
+	assert.NoError(t, SetMonElectionStrategy(context, "rook", "connectivity"))
+	assert.NoError(t, EnableStretchMode(context, "rook", "e", "stretch_rule", "zone"))
+	assert.Equal(t, [][]string{
+		{"mon", "set_location", "a", "zone=us-east-2b"},
+		{"mon", "set", "election_strategy", "connectivity"},
+		{"mon", "enable_stretch_mode", "e", "stretch_rule", "zone"},
+	}, commands)
+}
+
+func TestCompactMonStore(t *testing.T) {
+	var compactArgs []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			compactArgs = args
+			return "compacted rocksdb in 0.5 seconds", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, CompactMonStore(context, "rook", "b"))
+	assert.Equal(t, []string{"tell", "mon.b", "compact"}, compactArgs[:3])
+	assert.Equal(t, []string{"--format", "plain"}, compactArgs[len(compactArgs)-2:])
+}
@@ -17,6 +17,7 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -61,12 +62,16 @@ type OSDPerfStats struct {
 
 type OSDDump struct {
 	OSDs []struct {
-		OSD json.Number `json:"osd"`
-		Up  json.Number `json:"up"`
-		In  json.Number `json:"in"`
+		OSD  json.Number `json:"osd"`
+		UUID string      `json:"uuid"`
+		Up   json.Number `json:"up"`
+		In   json.Number `json:"in"`
 	} `json:"osds"`
-	Flags          string              `json:"flags"`
-	CrushNodeFlags map[string][]string `json:"crush_node_flags"`
+	Flags             string              `json:"flags"`
+	CrushNodeFlags    map[string][]string `json:"crush_node_flags"`
+	FullRatio         float64             `json:"full_ratio"`
+	BackfillFullRatio float64             `json:"backfillfull_ratio"`
+	NearFullRatio     float64             `json:"nearfull_ratio"`
 }
 
 // IsFlagSet checks if an OSD flag is set
@@ -248,6 +253,33 @@ func GetOSDDump(context *clusterd.Context, clusterName string) (*OSDDump, error)
 	return &osdDump, nil
 }
 
+// SetFlag sets the osd flag on the cluster, such as noout
+func SetFlag(context *clusterd.Context, clusterName, flag string) error {
+	args := []string{"osd", "set", flag}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to set flag %s", flag)
+	}
+	return nil
+}
+
+// UnsetFlag unsets the osd flag on the cluster
+func UnsetFlag(context *clusterd.Context, clusterName, flag string) error {
+	args := []string{"osd", "unset", flag}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to unset flag %s", flag)
+	}
+	return nil
+}
+
+// SetRatio sets a full ratio of the osd map, where the ratio name is full, backfillfull or nearfull
+func SetRatio(context *clusterd.Context, clusterName, ratioName string, ratio float64) error {
+	args := []string{"osd", fmt.Sprintf("set-%s-ratio", ratioName), strconv.FormatFloat(ratio, 'f', -1, 64)}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to set the %s ratio to %v", ratioName, ratio)
+	}
+	return nil
+}
+
 func OSDOut(context *clusterd.Context, clusterName string, osdID int) (string, error) {
 	args := []string{"osd", "out", strconv.Itoa(osdID)}
 	buf, err := NewCephCommand(context, clusterName, args).Run()
@@ -18,6 +18,7 @@ package client
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 	"time"
 
@@ -181,6 +182,27 @@ func OkToStop(context *clusterd.Context, namespace, deployment, daemonType, daem
 	return nil
 }
 
+// OSDsOkToStop determines if the osds can be stopped at the same time without making any pg inactive
+func OSDsOkToStop(context *clusterd.Context, clusterName string, osdIDs []int) error {
+	if osdDoNothing(context, clusterName) {
+		return nil
+	}
+	return osdsOkToStop(context, clusterName, osdIDs)
+}
+
+func osdsOkToStop(context *clusterd.Context, clusterName string, osdIDs []int) error {
+	args := []string{"osd", "ok-to-stop"}
+	for _, id := range osdIDs {
+		args = append(args, strconv.Itoa(id))
+	}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "osds %v cannot be stopped. %s", osdIDs, string(buf))
+	}
+	logger.Debugf("osds %v are ok to be stopped. %s", osdIDs, string(buf))
+	return nil
+}
+
 // OkToContinue determines if it's ok to continue an upgrade
 func OkToContinue(context *clusterd.Context, namespace, deployment, daemonType, daemonName string) error {
 	// the mon case is handled directly in the deployment where the mon checks for quorum
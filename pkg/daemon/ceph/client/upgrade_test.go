@@ -18,6 +18,7 @@ package client
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -120,6 +121,34 @@ func TestOkToStopDaemon(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestOSDsOkToStop(t *testing.T) {
+	// the ids of the osds of each ok-to-stop command
+	checked := [][]string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "osd" && args[1] == "ok-to-stop" {
+			// all the osds are checked with a single command
+			assert.True(t, strings.HasPrefix(args[4], "--"))
+			checked = append(checked, args[2:4])
+			if args[2] == "1" && args[3] == "2" {
+				return "", nil
+			}
+			return "", errors.New("osds are not ok to stop")
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, osdsOkToStop(context, "rook-ceph", []int{1, 2}))
+	assert.Error(t, osdsOkToStop(context, "rook-ceph", []int{1, 3}))
+	assert.Equal(t, [][]string{{"1", "2"}, {"1", "3"}}, checked)
+
+	// nothing is checked if the versions of the osds are not known
+	assert.NoError(t, OSDsOkToStop(context, "rook-ceph", []int{1, 3}))
+	assert.Equal(t, 2, len(checked))
+}
+
 func TestOkToContinue(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
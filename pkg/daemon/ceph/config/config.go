@@ -39,6 +39,8 @@ var logger = capnslog.NewPackageLogger("github.com/rook/rook", "cephconfig")
 const (
 	// DefaultKeyringFile is the default name of the file where Ceph stores its keyring info
 	DefaultKeyringFile = "keyring"
+	// Msgr1port is the default listening port of the messenger v1 protocol
+	Msgr1port = 6789
 	// Msgr2port is the listening port of the messenger v2 protocol
 	Msgr2port   = 3300
 	msgr1Prefix = "v1:"
@@ -123,13 +125,21 @@ func GenerateAdminConnectionConfig(context *clusterd.Context, cluster *ClusterIn
 // some subset of settings.
 func GenerateAdminConnectionConfigWithSettings(context *clusterd.Context, cluster *ClusterInfo, settings *CephConfig) (string, error) {
 	root := path.Join(context.ConfigDir, cluster.Name)
-	keyringPath := path.Join(root, fmt.Sprintf("%s.keyring", client.AdminUsername))
-	err := writeKeyring(AdminKeyring(cluster), keyringPath)
+	username := client.AdminUsername
+	keyring := AdminKeyring(cluster)
+	if cluster.ExternalCred.Username != "" {
+		// connect to the external cluster as the restricted user
+		username = cluster.ExternalCred.Username
+		keyring = ExternalKeyring(cluster)
+	}
+	keyringPath := path.Join(root, fmt.Sprintf("%s.keyring", username))
+	err := writeKeyring(keyring, keyringPath)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to write keyring to %s", root)
 	}
+	client.SetClusterUsername(cluster.Name, username)
 
-	filePath, err := GenerateConfigFile(context, cluster, root, client.AdminUsername, keyringPath, settings, nil)
+	filePath, err := GenerateConfigFile(context, cluster, root, username, keyringPath, settings, nil)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to write config to %s", root)
 	}
@@ -201,6 +211,7 @@ func CreateDefaultCephConfig(context *clusterd.Context, cluster *ClusterInfo) (*
 		currentMonPort := cephutil.GetPortFromEndpoint(monitor.Endpoint)
 
 		monPorts := [2]string{strconv.Itoa(int(Msgr2port)), strconv.Itoa(int(currentMonPort))}
+		msgr2Endpoint := net.JoinHostPort(monIP, monPorts[0])
 		msgr1Endpoint := net.JoinHostPort(monIP, monPorts[1])
 
 		// Mimic daemons like OSD won't be able to parse this, so only the operator should get this config
@@ -212,7 +223,11 @@ func CreateDefaultCephConfig(context *clusterd.Context, cluster *ClusterInfo) (*
 		// 		failed to fetch mon config (--no-mon-config to skip)
 		// The operator always fails this test since it does not have the env var 'ROOK_CEPH_VERSION'
 		podName := os.Getenv("POD_NAME")
-		if cluster.CephVersion.IsAtLeastNautilus() {
+		if cluster.CephVersion.IsAtLeastNautilus() && currentMonPort == Msgr1port {
+			// The mons listen on both the v2 and v1 ports
+			monHosts[i] = "[" + msgr2Prefix + msgr2Endpoint + "," + msgr1Prefix + msgr1Endpoint + "]"
+		} else if cluster.CephVersion.IsAtLeastNautilus() {
+			// The mons were created on a non-default port before Nautilus and only listen on v1
 			monHosts[i] = msgr1Prefix + msgr1Endpoint
 		} else if podName != "" && strings.Contains(podName, "operator") {
 			// This is an operator and its version is always based on Nautilus
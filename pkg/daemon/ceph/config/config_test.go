@@ -26,6 +26,7 @@ import (
 	"github.com/coreos/pkg/capnslog"
 	"github.com/go-ini/ini"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/stretchr/testify/assert"
 )
@@ -74,6 +75,18 @@ func TestCreateDefaultCephConfig(t *testing.T) {
 	assert.Equal(t, "10.1.1.0/24", cephConfig.PublicNetwork)
 	assert.Equal(t, "10.1.2.2", cephConfig.ClusterAddr)
 	assert.Equal(t, "10.1.2.0/24", cephConfig.ClusterNetwork)
+
+	// the v2 and v1 addresses of the mons are used on nautilus
+	clusterInfo.CephVersion = cephver.Nautilus
+	cephConfig, err = CreateDefaultCephConfig(context, clusterInfo)
+	assert.NoError(t, err)
+	verifyConfig(t, cephConfig, clusterInfo, 10)
+
+	// only the v1 address is used for the mons running on a non-default port
+	clusterInfo.Monitors = map[string]*MonInfo{"node0": {Name: "mon0", Endpoint: "10.0.0.1:6790"}}
+	cephConfig, err = CreateDefaultCephConfig(context, clusterInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1:10.0.0.1:6790", cephConfig.MonHost)
 }
 
 func TestGenerateConfigFile(t *testing.T) {
@@ -151,6 +164,50 @@ func verifyConfig(t *testing.T, cephConfig *CephConfig, cluster *ClusterInfo, lo
 	assert.Equal(t, loggingLevel, cephConfig.DebugLogBluestoreLevel)
 }
 
+func TestGenerateAdminConnectionConfigExternalUser(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "TestGenerateAdminConnectionConfigExternalUser")
+	if err != nil {
+		t.Fatalf("failed to create temp config dir: %+v", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	context := &clusterd.Context{ConfigDir: configDir}
+	clusterInfo := &ClusterInfo{
+		FSID:          "myfsid",
+		MonitorSecret: "monsecret",
+		AdminSecret:   "adminsecret",
+		Name:          "foo-cluster",
+		Monitors: map[string]*MonInfo{
+			"node0": {Name: "mon0", Endpoint: "10.0.0.1:6789"},
+		},
+		CephVersion:  cephver.Nautilus,
+		ExternalCred: ExternalCred{Username: "client.healthchecker", Secret: "restrictedsecret"},
+	}
+	defer client.SetClusterUsername("foo-cluster", "")
+
+	// the keyring and the client section are written for the restricted user
+	configFilePath, err := GenerateAdminConnectionConfig(context, clusterInfo)
+	assert.Nil(t, err)
+	keyringPath := filepath.Join(configDir, "foo-cluster", "client.healthchecker.keyring")
+	keyring, err := ioutil.ReadFile(keyringPath)
+	assert.Nil(t, err)
+	assert.Contains(t, string(keyring), "[client.healthchecker]")
+	assert.Contains(t, string(keyring), "key = restrictedsecret")
+	actualConf, err := ini.Load(configFilePath)
+	assert.Nil(t, err)
+	verifyConfigValue(t, actualConf, "client.healthchecker", "keyring", keyringPath)
+	assert.Equal(t, "client.healthchecker", client.GetClusterUsername("foo-cluster"))
+
+	// the admin keyring is written when no restricted user is set
+	clusterInfo.ExternalCred = ExternalCred{}
+	_, err = GenerateAdminConnectionConfig(context, clusterInfo)
+	assert.Nil(t, err)
+	keyring, err = ioutil.ReadFile(filepath.Join(configDir, "foo-cluster", "client.admin.keyring"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(keyring), "key = adminsecret")
+	assert.Equal(t, client.AdminUsername, client.GetClusterUsername("foo-cluster"))
+}
+
 func verifyConfigValue(t *testing.T, actualConf *ini.File, section, key, expectedVal string) {
 	s, err := actualConf.GetSection(section)
 	if !assert.Nil(t, err) {
@@ -34,6 +34,15 @@ type ClusterInfo struct {
 	Name          string
 	Monitors      map[string]*MonInfo
 	CephVersion   cephver.CephVersion
+	// ExternalCred is the user the operator connects as to an external cluster when the admin keyring is not
+	// available. The admin secret is used when the username is empty.
+	ExternalCred ExternalCred
+}
+
+// ExternalCred is a user and its key to connect to an external cluster
+type ExternalCred struct {
+	Username string
+	Secret   string
 }
 
 // MonInfo is a collection of information about a Ceph mon.
@@ -46,8 +55,9 @@ type MonInfo struct {
 // in. This method exists less out of necessity than the desire to be explicit about the lifecycle
 // of the ClusterInfo struct during startup, specifically that it is expected to exist after the
 // Rook operator has started up or connected to the first components of the Ceph cluster.
+// The admin secret is not required when connecting to an external cluster as a restricted user.
 func (c *ClusterInfo) IsInitialized() bool {
-	if c == nil || c.FSID == "" || c.MonitorSecret == "" || c.AdminSecret == "" {
+	if c == nil || c.FSID == "" || c.MonitorSecret == "" || (c.AdminSecret == "" && c.ExternalCred.Username == "") {
 		logger.Errorf("clusterInfo: %+v", c)
 		return false
 	}
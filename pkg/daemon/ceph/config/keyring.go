@@ -38,6 +38,13 @@ const (
 	caps osd = "allow *"
 	caps mgr = "allow *"
 `
+
+	// externalKeyringTemplate is a string template of the keyring of the user connecting to an external
+	// cluster. The caps of the user are managed in the external cluster.
+	externalKeyringTemplate = `
+[%s]
+	key = %s
+`
 )
 
 // AdminKeyring returns the filled-out admin keyring
@@ -45,6 +52,11 @@ func AdminKeyring(c *ClusterInfo) string {
 	return fmt.Sprintf(AdminKeyringTemplate, c.AdminSecret)
 }
 
+// ExternalKeyring returns the filled-out keyring of the user connecting to an external cluster
+func ExternalKeyring(c *ClusterInfo) string {
+	return fmt.Sprintf(externalKeyringTemplate, c.ExternalCred.Username, c.ExternalCred.Secret)
+}
+
 // WriteKeyring calls the generate contents function with auth key as an argument then saves the
 // output of the generateContents function to disk at the keyring path
 // TODO: Kludgey; can keyring files be generated w/ go-ini package or using the '-o' option to
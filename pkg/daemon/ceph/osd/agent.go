@@ -162,11 +162,9 @@ func (a *OsdAgent) configureDevices(context *clusterd.Context, devices *DeviceOs
 		return osds, nil
 	}
 
-	// Detect OSDs provisioned already with legacy rook
-	// If ceph-volume is not supported, go ahead and configure the osds natively with rook
-
-	// compute an OSD layout scheme that will optimize performance
-	scheme, cvDevices, err := a.getPartitionPerfScheme(context, devices, cvSupported)
+	// Detect OSDs provisioned already with legacy rook. The legacy partitions are only kept for the existing osds,
+	// the new devices are always configured with ceph-volume.
+	scheme, cvDevices, err := a.getPartitionPerfScheme(context, devices, true)
 	logger.Debugf("partition scheme: %+v. %v", scheme, err)
 	if err != nil {
 		return osds, errors.Wrapf(err, "failed to get OSD partition scheme")
@@ -199,6 +197,9 @@ func (a *OsdAgent) configureDevices(context *clusterd.Context, devices *DeviceOs
 	logger.Infof("%d/%d pre-ceph-volume osd devices succeeded on this node", succeeded, nonCVTotal)
 
 	if !cvSupported {
+		if len(cvDevices.Entries) > 0 {
+			logger.Warningf("skipping the new devices %v. a ceph image with ceph-volume is required to configure new osds on devices", cvDevices.Entries)
+		}
 		return osds, nil
 	}
 
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+const (
+	compressionModeOption        = "bluestore_compression_mode"
+	compressionAlgorithmOption   = "bluestore_compression_algorithm"
+	compressionMinBlobSizeOption = "bluestore_compression_min_blob_size"
+	// compressionConfigKey is the prefix of the keys of the config-key store of the mons with the compression
+	// settings set by rook for each osd
+	compressionConfigKey = "rook/osd-compression"
+)
+
+var (
+	compressionModes      = []string{"none", "passive", "aggressive", "force"}
+	compressionAlgorithms = []string{"snappy", "zlib", "zstd", "lz4"}
+)
+
+// validateCompression checks the bluestore compression settings of the store config
+func validateCompression(storeConfig config.StoreConfig) error {
+	if storeConfig.CompressionMode != "" && !contains(compressionModes, storeConfig.CompressionMode) {
+		return errors.Errorf("invalid compression mode %q. it must be one of %v", storeConfig.CompressionMode, compressionModes)
+	}
+	if storeConfig.CompressionAlgorithm != "" && !contains(compressionAlgorithms, storeConfig.CompressionAlgorithm) {
+		return errors.Errorf("invalid compression algorithm %q. it must be one of %v", storeConfig.CompressionAlgorithm, compressionAlgorithms)
+	}
+	if storeConfig.CompressionMinBlobSize < 0 {
+		return errors.Errorf("invalid compression min blob size %d. it must not be negative", storeConfig.CompressionMinBlobSize)
+	}
+	return nil
+}
+
+// applyCompression sets the bluestore compression settings of the store config in the config of the mons for each
+// bluestore osd. The settings that are not specified are removed if rook had set them, so the osds fall back to the
+// settings of the cluster. The settings of an osd that were set by the user are not removed.
+func applyCompression(context *clusterd.Context, clusterName string, storeConfig config.StoreConfig, osds []oposd.OSDInfo) error {
+	minBlobSize := ""
+	if storeConfig.CompressionMinBlobSize != 0 {
+		minBlobSize = strconv.Itoa(storeConfig.CompressionMinBlobSize)
+	}
+
+	store := opconfig.GetMonStore(context, clusterName)
+	for _, osd := range osds {
+		if osd.IsFileStore {
+			continue
+		}
+		who := fmt.Sprintf("osd.%d", osd.ID)
+		options := []opconfig.Option{
+			{Who: who, Option: compressionModeOption, Value: storeConfig.CompressionMode},
+			{Who: who, Option: compressionAlgorithmOption, Value: storeConfig.CompressionAlgorithm},
+			{Who: who, Option: compressionMinBlobSizeOption, Value: minBlobSize},
+		}
+		key := fmt.Sprintf("%s/%s", compressionConfigKey, who)
+		previous, err := getCompressionOptions(context, clusterName, key)
+		if err != nil {
+			return err
+		}
+		current := map[string]bool{}
+		for _, option := range options {
+			if option.Value == "" {
+				if !previous[option.Option] {
+					continue
+				}
+				if err := store.Delete(option.Who, option.Option); err != nil {
+					return errors.Wrapf(err, "failed to remove %q of %s", option.Option, who)
+				}
+				continue
+			}
+			if err := store.Set(option.Who, option.Option, option.Value); err != nil {
+				return errors.Wrapf(err, "failed to set %q of %s to %q", option.Option, who, option.Value)
+			}
+			current[option.Option] = true
+		}
+		if !reflect.DeepEqual(previous, current) {
+			val, err := json.Marshal(current)
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal the compression settings of %s", who)
+			}
+			if err := client.SetConfigKey(context, clusterName, key, string(val)); err != nil {
+				return errors.Wrapf(err, "failed to save the compression settings of %s", who)
+			}
+		}
+		logger.Infof("bluestore compression of %s is mode=%q algorithm=%q min blob size=%d", who,
+			storeConfig.CompressionMode, storeConfig.CompressionAlgorithm, storeConfig.CompressionMinBlobSize)
+	}
+	return nil
+}
+
+// getCompressionOptions returns the compression options of an osd that were set by rook
+func getCompressionOptions(context *clusterd.Context, clusterName, key string) (map[string]bool, error) {
+	options := map[string]bool{}
+	val, found, err := client.GetConfigKey(context, clusterName, key)
+	if err != nil || !found {
+		return options, err
+	}
+	if err := json.Unmarshal([]byte(val), &options); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the compression settings %s", key)
+	}
+	return options, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCompression(t *testing.T) {
+	assert.NoError(t, validateCompression(config.StoreConfig{}))
+	assert.NoError(t, validateCompression(config.StoreConfig{CompressionMode: "aggressive", CompressionAlgorithm: "zstd", CompressionMinBlobSize: 65536}))
+	assert.Error(t, validateCompression(config.StoreConfig{CompressionMode: "always"}))
+	assert.Error(t, validateCompression(config.StoreConfig{CompressionAlgorithm: "gzip"}))
+	assert.Error(t, validateCompression(config.StoreConfig{CompressionMinBlobSize: -1}))
+}
+
+func TestApplyCompression(t *testing.T) {
+	store := map[string]string{}
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "config-key" && args[1] == "dump":
+			keys := map[string]string{}
+			if val, ok := store[args[2]]; ok {
+				keys[args[2]] = val
+			}
+			output, _ := json.Marshal(keys)
+			return string(output), nil
+		case args[0] == "config-key" && args[1] == "set" && args[3] == "-i":
+			val, err := ioutil.ReadFile(args[4])
+			if err != nil {
+				return "", err
+			}
+			store[args[2]] = string(val)
+			return "", nil
+		case args[0] == "config":
+			var cmd []string
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--") {
+					break
+				}
+				cmd = append(cmd, arg)
+			}
+			commands = append(commands, strings.Join(cmd, " "))
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	osds := []oposd.OSDInfo{{ID: 0}, {ID: 1, IsFileStore: true}}
+
+	// the settings of the cluster or set by the user are not removed
+	require.NoError(t, applyCompression(context, "rook-ceph", config.StoreConfig{}, osds))
+	assert.Nil(t, commands)
+
+	// the settings are set for the bluestore osds
+	storeConfig := config.StoreConfig{CompressionMode: "aggressive", CompressionAlgorithm: "snappy", CompressionMinBlobSize: 131072}
+	require.NoError(t, applyCompression(context, "rook-ceph", storeConfig, osds))
+	assert.Equal(t, []string{
+		"config set osd.0 bluestore_compression_mode aggressive",
+		"config set osd.0 bluestore_compression_algorithm snappy",
+		"config set osd.0 bluestore_compression_min_blob_size 131072",
+	}, commands)
+
+	// the settings that are not specified are removed
+	commands = nil
+	require.NoError(t, applyCompression(context, "rook-ceph", config.StoreConfig{CompressionMode: "passive"}, osds))
+	assert.Equal(t, []string{
+		"config set osd.0 bluestore_compression_mode passive",
+		"config rm osd.0 bluestore_compression_algorithm",
+		"config rm osd.0 bluestore_compression_min_blob_size",
+	}, commands)
+
+	// the settings are removed only once
+	commands = nil
+	require.NoError(t, applyCompression(context, "rook-ceph", config.StoreConfig{}, osds))
+	assert.Equal(t, []string{"config rm osd.0 bluestore_compression_mode"}, commands)
+	commands = nil
+	require.NoError(t, applyCompression(context, "rook-ceph", config.StoreConfig{}, osds))
+	assert.Nil(t, commands)
+}
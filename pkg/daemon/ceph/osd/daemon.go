@@ -30,6 +30,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
@@ -39,10 +40,13 @@ import (
 
 var (
 	logger = capnslog.NewPackageLogger("github.com/rook/rook", "cephosd")
+
+	// sysBlockDir is the directory of the block devices in sysfs, where the paths of a multipath device are listed
+	sysBlockDir = "/sys/block"
 )
 
 // StartOSD starts an OSD on a device that was provisioned by ceph-volume
-func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID, lvPath string, pvcBackedOSD, lvBackedPV bool, cephArgs []string) error {
+func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID, lvPath, cvMode, blockPath string, pvcBackedOSD, lvBackedPV bool, cephArgs []string) error {
 
 	// ensure the config mount point exists
 	configDir := fmt.Sprintf("/var/lib/ceph/osd/ceph-%s", osdID)
@@ -51,6 +55,10 @@ func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID, lvPath string,
 		logger.Errorf("failed to create config dir %q. %v", configDir, err)
 	}
 
+	if cvMode == config.CephVolumeRawMode {
+		return startRawOSD(context, osdID, osdUUID, blockPath, cephArgs)
+	}
+
 	// Update LVM config at runtime
 	if err := updateLVMConfig(context, pvcBackedOSD, lvBackedPV); err != nil {
 		return errors.Wrapf(err, "failed to update lvm configuration file") // fail return here as validation provided by ceph-volume
@@ -94,6 +102,24 @@ func StartOSD(context *clusterd.Context, osdType, osdID, osdUUID, lvPath string,
 	return nil
 }
 
+// startRawOSD activates and runs an OSD prepared with the raw mode of ceph-volume, which has no lvm volumes to
+// activate or release
+func startRawOSD(context *clusterd.Context, osdID, osdUUID, blockPath string, cephArgs []string) error {
+	if blockPath == "" {
+		return errors.Errorf("the block device of raw osd %q is not set", osdID)
+	}
+	if err := context.Executor.ExecuteCommand(false, "", "stdbuf", "-oL", "ceph-volume", "raw", "activate", "--device", blockPath,
+		"--osd-id", osdID, "--osd-uuid", osdUUID, "--no-systemd"); err != nil {
+		return errors.Wrapf(err, "failed to activate raw osd %q on %q", osdID, blockPath)
+	}
+
+	// run the ceph-osd daemon
+	if err := context.Executor.ExecuteCommand(false, "", "ceph-osd", cephArgs...); err != nil {
+		return errors.Wrapf(err, "failed to start osd or shutting down")
+	}
+	return nil
+}
+
 func handleTerminate(context *clusterd.Context, lvPath, volumeGroupName string) error {
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc, syscall.SIGTERM)
@@ -158,6 +184,10 @@ func RunFilestoreOnDevice(context *clusterd.Context, mountSourcePath, mountPath
 
 // Provision provisions an OSD
 func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation string) error {
+	if err := validateCompression(agent.storeConfig); err != nil {
+		return errors.Wrapf(err, "failed to validate the compression settings")
+	}
+
 	// set the initial orchestration status
 	status := oposd.OrchestrationStatus{Status: oposd.OrchestrationStatusComputingDiff}
 	if err := oposd.UpdateNodeStatus(agent.kv, agent.nodeName, status); err != nil {
@@ -206,6 +236,19 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation string)
 		if err != nil {
 			return errors.Wrapf(err, "failed initial hardware discovery")
 		}
+
+		if agent.storeConfig.TestDeviceSizeMB > 0 {
+			// the loop devices are not discovered, so the test device is only used in the test mode
+			testDevice, err := createTestDevice(context, agent.storeConfig.TestDeviceSizeMB)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create the test device")
+			}
+			logger.Infof("using the test device %q for the osds", testDevice.Name)
+			rawDevices = append(rawDevices, testDevice)
+			if len(agent.devices) != 1 || agent.devices[0].Name != "all" {
+				agent.devices = append(agent.devices, DesiredDevice{Name: testDevice.Name, OSDsPerDevice: agent.storeConfig.OSDsPerDevice})
+			}
+		}
 	}
 
 	context.Devices = rawDevices
@@ -213,7 +256,8 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation string)
 	logger.Infof("creating and starting the osds")
 
 	// determine the set of devices that can/should be used for OSDs.
-	devices, err := getAvailableDevices(context, agent.devices, agent.metadataDevice, agent.pvcBacked)
+	rawMode := agent.pvcBacked && agent.storeConfig.CephVolumeMode == config.CephVolumeRawMode
+	devices, err := getAvailableDevices(context, agent.devices, agent.metadataDevice, agent.pvcBacked, rawMode)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get available devices")
 	}
@@ -270,6 +314,10 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation string)
 
 	osds := append(deviceOSDs, dirOSDs...)
 
+	if err := applyCompression(context, agent.cluster.Name, agent.storeConfig, osds); err != nil {
+		return errors.Wrapf(err, "failed to apply the compression settings of the osds")
+	}
+
 	// orchestration is completed, update the status
 	status = oposd.OrchestrationStatus{OSDs: osds, Status: oposd.OrchestrationStatusCompleted, PvcBackedOSD: agent.pvcBacked}
 	if err := oposd.UpdateNodeStatus(agent.kv, agent.nodeName, status); err != nil {
@@ -279,13 +327,65 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation string)
 	return nil
 }
 
-func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevice, metadataDevice string, pvcBacked bool) (*DeviceOsdMapping, error) {
+// getMultipathMembers returns the names of the paths of the multipath devices. lsblk only reports one of the paths
+// as the parent of a multipath device, so all the paths are listed from sysfs.
+func getMultipathMembers(devices []*sys.LocalDisk) map[string]bool {
+	members := map[string]bool{}
+	for _, device := range devices {
+		if device.Type != sys.MultiPath {
+			continue
+		}
+		if device.Parent != "" {
+			members[device.Parent] = true
+		}
+		paths, err := ioutil.ReadDir(filepath.Join(sysBlockDir, device.Name, "slaves"))
+		if err != nil {
+			logger.Warningf("failed to list the paths of multipath device %q. %v", device.Name, err)
+			continue
+		}
+		for _, path := range paths {
+			members[path.Name()] = true
+		}
+	}
+	return members
+}
+
+func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevice, metadataDevice string, pvcBacked, rawMode bool) (*DeviceOsdMapping, error) {
 
 	available := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}}
+	exclusions := discoverDaemon.GetDeviceExclusions()
+	useAllDevices := len(desiredDevices) == 1 && desiredDevices[0].Name == "all"
+
+	// the paths of a multipath device are only consumed through the multipath device
+	multipathMembers := getMultipathMembers(context.Devices)
+
 	for _, device := range context.Devices {
-		if device.Type == sys.PartType {
+		if discoverDaemon.IsExcludedDevice(device, exclusions) {
 			continue
 		}
+		if multipathMembers[device.Name] || device.Filesystem == sys.MultipathMemberFS {
+			logger.Infof("skipping device %q that is a path of a multipath device", device.Name)
+			continue
+		}
+		isPartition := device.Type == sys.PartType
+		if isPartition && pvcBacked && rawMode {
+			// the partition of a pvc is consumed directly in raw mode
+			logger.Infof("using the partition %q of the pvc in raw mode", device.Name)
+		} else if isPartition {
+			if useAllDevices || pvcBacked {
+				// the partitions of the disk of the os must not be consumed when all the devices are used
+				logger.Infof("skipping partition %q that is not selected explicitly by name", device.Name)
+				continue
+			}
+			label, err := sys.GetPartitionLabel(device.Name, context.Executor)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get the label of partition %q", device.Name)
+			}
+			if strings.HasPrefix(label, "ROOK-OSD") {
+				logger.Infof("skipping partition %q of a legacy rook osd", device.Name)
+				continue
+			}
+		}
 		partCount, ownPartitions, fs, err := sys.CheckIfDeviceAvailable(context.Executor, device.Name, pvcBacked)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get device %q info", device.Name)
@@ -301,7 +401,7 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevi
 		if metadataDevice != "" && metadataDevice == device.Name {
 			// current device is desired as the metadata device
 			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Metadata: []int{}}
-		} else if len(desiredDevices) == 1 && desiredDevices[0].Name == "all" {
+		} else if useAllDevices {
 			// user has specified all devices, use the current one for data
 			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID}
 		} else if len(desiredDevices) > 0 {
@@ -309,6 +409,11 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevi
 			var err error
 			var matchedDevice DesiredDevice
 			for _, desiredDevice := range desiredDevices {
+				if isPartition && !(pvcBacked && rawMode) && (desiredDevice.IsFilter || desiredDevice.IsDevicePathFilter) {
+					// a filter of disks such as "^sd." would also match their partitions
+					logger.Infof("partition %q is only selected by its name, not by the filter %q", device.Name, desiredDevice.Name)
+					continue
+				}
 				if desiredDevice.IsFilter {
 					// the desired devices is a regular expression
 					matched, err = regexp.Match(desiredDevice.Name, []byte(device.Name))
@@ -343,7 +448,7 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices []DesiredDevi
 			if err == nil && matched {
 				// the current device matches the user specifies filter/list, use it for data
 				logger.Infof("device %q is selected by the device filter/name %q", device.Name, matchedDevice.Name)
-				deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Config: matchedDevice, PersistentDevicePaths: strings.Fields(device.DevLinks)}
+				deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Config: matchedDevice, PersistentDevicePaths: strings.Fields(device.DevLinks), IsPartition: isPartition}
 			} else {
 				logger.Infof("skipping device %q that does not match the device filter/list (%v). %v", device.Name, desiredDevices, err)
 			}
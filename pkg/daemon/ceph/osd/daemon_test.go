@@ -18,6 +18,7 @@ package osd
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -28,6 +29,7 @@ import (
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/rook/rook/pkg/util/sys"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const udevFSOutput = `
@@ -198,64 +200,109 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 		{Name: "nvme01", DevLinks: "/dev/disk/by-id/nvme-0246 /dev/disk/by-path/pci-0:2:4:6-nvme-1"},
 		{Name: "rda"},
 		{Name: "rdb"},
+		{Name: "sdb1", Type: sys.PartType, Parent: "sdb"},
+		{Name: "dm-0", Type: sys.MultiPath, Parent: "sde", DevLinks: "/dev/mapper/mpatha"},
+		{Name: "sde"},
+		{Name: "sdf", Filesystem: sys.MultipathMemberFS},
 	}
 
 	// select all devices, including nvme01 for metadata
 	pvcBackedOSD := false
-	mapping, err := getAvailableDevices(context, []DesiredDevice{{Name: "all"}}, "nvme01", pvcBackedOSD)
+	mapping, err := getAvailableDevices(context, []DesiredDevice{{Name: "all"}}, "nvme01", pvcBackedOSD, false)
 	assert.Nil(t, err)
-	assert.Equal(t, 5, len(mapping.Entries))
+	assert.Equal(t, 6, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 	assert.Equal(t, -1, mapping.Entries["rda"].Data)
 	assert.Equal(t, -1, mapping.Entries["rdb"].Data)
 	assert.Equal(t, -1, mapping.Entries["nvme01"].Data)
+	assert.Equal(t, -1, mapping.Entries["dm-0"].Data)
 	assert.NotNil(t, mapping.Entries["nvme01"].Metadata)
 	assert.Equal(t, 0, len(mapping.Entries["nvme01"].Metadata))
 
 	// select no devices both using and not using a filter
-	mapping, err = getAvailableDevices(context, nil, "", pvcBackedOSD)
+	mapping, err = getAvailableDevices(context, nil, "", pvcBackedOSD, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(mapping.Entries))
 
-	mapping, err = getAvailableDevices(context, nil, "", pvcBackedOSD)
+	mapping, err = getAvailableDevices(context, nil, "", pvcBackedOSD, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(mapping.Entries))
 
 	// select the sd* devices
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^sd.$", IsFilter: true}}, "", pvcBackedOSD)
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^sd.$", IsFilter: true}}, "", pvcBackedOSD, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
 	// select an exact device
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "sdd"}}, "", pvcBackedOSD)
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "sdd"}}, "", pvcBackedOSD, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
 	// select all devices except those that have a prefix of "s"
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^[^s]", IsFilter: true}}, "", pvcBackedOSD)
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^[^s]", IsFilter: true}}, "", pvcBackedOSD, false)
 	assert.Nil(t, err)
-	assert.Equal(t, 3, len(mapping.Entries))
+	assert.Equal(t, 4, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["rda"].Data)
 	assert.Equal(t, -1, mapping.Entries["rdb"].Data)
 	assert.Equal(t, -1, mapping.Entries["nvme01"].Data)
 
 	// select the sd* devices by path names
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^/dev/sd.$", IsDevicePathFilter: true}}, "", pvcBackedOSD)
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^/dev/sd.$", IsDevicePathFilter: true}}, "", pvcBackedOSD, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
 	// select the SCSI devices
-	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^/dev/disk/by-path/.*-scsi-.*", IsDevicePathFilter: true}}, "", pvcBackedOSD)
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^/dev/disk/by-path/.*-scsi-.*", IsDevicePathFilter: true}}, "", pvcBackedOSD, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
+
+	// select a partition by name
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "sdb1"}}, "", pvcBackedOSD, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mapping.Entries))
+	assert.True(t, mapping.Entries["sdb1"].IsPartition)
+
+	// a partition is not selected by a filter of the disks
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "^sd.", IsFilter: true}, {Name: "^/dev/sdb", IsDevicePathFilter: true}}, "", pvcBackedOSD, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mapping.Entries))
+	assert.Nil(t, mapping.Entries["sdb1"])
+
+	// the paths of a multipath device are not selected, only the multipath device
+	mapping, err = getAvailableDevices(context, []DesiredDevice{{Name: "sde"}, {Name: "sdf"}, {Name: "^/dev/mapper/mpath", IsDevicePathFilter: true}}, "", pvcBackedOSD, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mapping.Entries))
+	assert.Equal(t, []string{"/dev/mapper/mpatha"}, mapping.Entries["dm-0"].PersistentDevicePaths)
+}
+
+func TestGetMultipathMembers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sys-block")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func() { sysBlockDir = "/sys/block" }()
+	sysBlockDir = dir
+
+	// lsblk reports a single parent of a multipath device with several paths
+	for _, path := range []string{"sde", "sdg"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "dm-0", "slaves", path), 0755))
+	}
+	devices := []*sys.LocalDisk{
+		{Name: "dm-0", Type: sys.MultiPath, Parent: "sde"},
+		{Name: "dm-1", Type: sys.MultiPath, Parent: "sdh"},
+		{Name: "sde"},
+		{Name: "sdf"},
+		{Name: "sdg"},
+	}
+	members := getMultipathMembers(devices)
+	assert.Equal(t, map[string]bool{"sde": true, "sdg": true, "sdh": true}, members)
 }
 
 func TestGetVolumeGroupName(t *testing.T) {
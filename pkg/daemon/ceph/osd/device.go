@@ -92,6 +92,7 @@ type DeviceOsdIDEntry struct {
 	Config                DesiredDevice // Device specific config options
 	LegacyPartitionsFound bool          // Whether legacy rook partitions were found
 	PersistentDevicePaths []string
+	IsPartition           bool // Whether the device is a partition, which ceph-volume lvm batch does not accept
 }
 
 type devicePartInfo struct {
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+// testDeviceFilePath returns the path of the sparse file of the test mode. The activate init container of the osd
+// pods attaches the file to a loop device again after the host restarts.
+func testDeviceFilePath(context *clusterd.Context) string {
+	return filepath.Join(context.ConfigDir, oposd.TestDeviceFileName)
+}
+
+// createTestDevice creates the sparse file of the test mode with the given size if it does not exist yet, and
+// attaches it to a loop device. The file is only grown if the size is increased, since shrinking it would
+// corrupt the osds on the device.
+func createTestDevice(context *clusterd.Context, sizeMB int) (*sys.LocalDisk, error) {
+	path := testDeviceFilePath(context)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open the test device file %q", path)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the size of the test device file %q", path)
+	}
+	size := int64(sizeMB) * 1024 * 1024
+	if info.Size() < size {
+		logger.Infof("allocating %d MB for the test device file %q", sizeMB, path)
+		if err := file.Truncate(size); err != nil {
+			return nil, errors.Wrapf(err, "failed to allocate the test device file %q", path)
+		}
+	} else if info.Size() > size {
+		logger.Warningf("keeping the test device file %q at %d MB. it cannot be shrunk to %d MB", path, info.Size()/1024/1024, sizeMB)
+		size = info.Size()
+	}
+
+	name, err := attachLoopDevice(context, path)
+	if err != nil {
+		return nil, err
+	}
+	return &sys.LocalDisk{Name: name, Type: sys.LoopType, Size: uint64(size)}, nil
+}
+
+// attachLoopDevice returns the name of the loop device the file is attached to, and attaches the file to a free
+// loop device if it is not attached yet
+func attachLoopDevice(context *clusterd.Context, path string) (string, error) {
+	output, err := context.Executor.ExecuteCommandWithOutput(false, "", "losetup", "--noheadings", "--output", "NAME", "--associated", path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the loop device of %q", path)
+	}
+	device := strings.TrimSpace(output)
+	if device == "" {
+		output, err = context.Executor.ExecuteCommandWithOutput(false, "", "losetup", "--find", "--show", path)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to attach %q to a loop device", path)
+		}
+		device = strings.TrimSpace(output)
+		if device == "" {
+			return "", errors.Errorf("no loop device found for %q", path)
+		}
+		logger.Infof("attached the test device file %q to %q", path, device)
+	}
+	// a file is attached to a single loop device by rook
+	device = strings.Fields(device)[0]
+	return strings.TrimPrefix(device, "/dev/"), nil
+}
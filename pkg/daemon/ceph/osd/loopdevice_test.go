@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestDevice(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "testdevice")
+	require.NoError(t, err)
+	defer os.RemoveAll(configDir)
+	path := filepath.Join(configDir, oposd.TestDeviceFileName)
+
+	attached := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if command == "losetup" && args[len(args)-2] == "--associated" && args[len(args)-1] == path {
+				return attached, nil
+			}
+			if command == "losetup" && args[0] == "--find" && args[2] == path {
+				attached = "/dev/loop3"
+				return attached, nil
+			}
+			return "", errors.Errorf("unexpected command %s %v", command, args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor, ConfigDir: configDir}
+
+	// the sparse file is created and attached to a loop device
+	device, err := createTestDevice(context, 10)
+	require.NoError(t, err)
+	assert.Equal(t, &sys.LocalDisk{Name: "loop3", Type: sys.LoopType, Size: 10 * 1024 * 1024}, device)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10*1024*1024), info.Size())
+
+	// the file is grown but never shrunk, and the same loop device is used
+	device, err = createTestDevice(context, 20)
+	require.NoError(t, err)
+	assert.Equal(t, "loop3", device.Name)
+	device, err = createTestDevice(context, 5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20*1024*1024), device.Size)
+
+	// the file is attached to a loop device again after a restart of the host
+	attached = ""
+	device, err = createTestDevice(context, 20)
+	require.NoError(t, err)
+	assert.Equal(t, "loop3", device.Name)
+}
@@ -58,9 +58,14 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 	var lvBackedPV bool
 
 	var err error
+	rawMode := a.pvcBacked && a.storeConfig.CephVolumeMode == config.CephVolumeRawMode
 	if len(devices.Entries) == 0 {
 		logger.Infof("no new devices to configure. returning devices already configured with ceph-volume.")
-		osds, err = getCephVolumeOSDs(context, a.cluster.Name, a.cluster.FSID, lv, false, lvBackedPV)
+		if rawMode {
+			osds, err = getCephVolumeRawOSDs(context, a.cluster.Name, a.cluster.FSID, a.devices[0].Name)
+		} else {
+			osds, err = getCephVolumeOSDs(context, a.cluster.Name, a.cluster.FSID, lv, false, lvBackedPV)
+		}
 		if err != nil {
 			logger.Infof("failed to get devices already provisioned by ceph-volume. %v", err)
 		}
@@ -71,6 +76,15 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to generate osd keyring")
 	}
+
+	if rawMode {
+		// the raw mode does not use lvm, the osd is prepared on the device or partition of the pvc directly
+		if err := a.initializeBlockPVCRaw(context, devices); err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize devices in raw mode")
+		}
+		return getCephVolumeRawOSDs(context, a.cluster.Name, a.cluster.FSID, a.devices[0].Name)
+	}
+
 	// Update LVM configuration file
 	if a.pvcBacked {
 		for _, device := range devices.Entries {
@@ -127,6 +141,12 @@ func (a *OsdAgent) initializeBlockPVC(context *clusterd.Context, devices *Device
 				"--data",
 				deviceArg,
 			}...)
+			if a.storeConfig.DeviceClass != "" {
+				immediateExecuteArgs = append(immediateExecuteArgs, []string{
+					crushDeviceClassFlag,
+					a.storeConfig.DeviceClass,
+				}...)
+			}
 			// execute ceph-volume with the device
 
 			if op, err := context.Executor.ExecuteCommandWithCombinedOutput(false, "", baseCommand, immediateExecuteArgs...); err != nil {
@@ -150,6 +170,30 @@ func (a *OsdAgent) initializeBlockPVC(context *clusterd.Context, devices *Device
 	return lvpath, nil
 }
 
+// initializeBlockPVCRaw prepares the osd on the device of the pvc with the raw mode of ceph-volume, which suits the
+// pvcs that are partitions or logical volumes where lvm must not be created again
+func (a *OsdAgent) initializeBlockPVCRaw(context *clusterd.Context, devices *DeviceOsdMapping) error {
+	baseCommand := "stdbuf"
+	baseArgs := []string{"-oL", cephVolumeCmd, "raw", "prepare", "--bluestore"}
+	for name, device := range devices.Entries {
+		if device.Data != -1 {
+			logger.Infof("skipping device %s with osd %d already configured", name, device.Data)
+			continue
+		}
+		logger.Infof("configuring new device %s in raw mode", name)
+		args := append(baseArgs, "--data", device.Config.Name)
+		if a.storeConfig.DeviceClass != "" {
+			args = append(args, crushDeviceClassFlag, a.storeConfig.DeviceClass)
+		}
+		op, err := context.Executor.ExecuteCommandWithCombinedOutput(false, "", baseCommand, args...)
+		if err != nil {
+			return errors.Wrapf(err, "failed ceph-volume raw prepare. %s", op) // fail return here as validation provided by ceph-volume
+		}
+		logger.Infof("%v", op)
+	}
+	return nil
+}
+
 func getLVPath(op string) string {
 	tmp := sys.Grep(op, "Volume group")
 	vgtmp := strings.Split(tmp, "\"")
@@ -255,11 +299,29 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 				}
 			}
 
+			// the count of the device overrides the count of the node, even to create a single osd
 			deviceOSDCount := osdsPerDeviceCount
-			if device.Config.OSDsPerDevice > 1 {
+			if device.Config.OSDsPerDevice > 0 {
 				deviceOSDCount = sanitizeOSDsPerDevice(device.Config.OSDsPerDevice)
 			}
 
+			// the device class of the device overrides the class of the node
+			deviceClass := a.storeConfig.DeviceClass
+			if device.Config.DeviceClass != "" {
+				deviceClass = device.Config.DeviceClass
+			}
+
+			if device.IsPartition {
+				if a.metadataDevice != "" || device.Config.MetadataDevice != "" {
+					logger.Warningf("skipping partition %s. a metadata device is not supported for an osd on a partition", name)
+					continue
+				}
+				if err := a.initializePartition(context, storeFlag, deviceArg, deviceClass); err != nil {
+					return err
+				}
+				continue
+			}
+
 			if a.metadataDevice != "" || device.Config.MetadataDevice != "" {
 				// When mixed hdd/ssd devices are given, ceph-volume configures db lv on the ssd.
 				// the device will be configured as a batch at the end of the method
@@ -277,8 +339,8 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 				} else {
 					metadataDevices[md] = make(map[string]string)
 					metadataDevices[md]["osdsperdevice"] = deviceOSDCount
-					if device.Config.DeviceClass != "" {
-						metadataDevices[md]["deviceclass"] = device.Config.DeviceClass
+					if deviceClass != "" {
+						metadataDevices[md]["deviceclass"] = deviceClass
 					}
 					metadataDevices[md]["devices"] = deviceArg
 				}
@@ -305,10 +367,10 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 					deviceArg,
 				}...)
 
-				if device.Config.DeviceClass != "" {
+				if deviceClass != "" {
 					immediateExecuteArgs = append(immediateExecuteArgs, []string{
 						crushDeviceClassFlag,
-						device.Config.DeviceClass,
+						deviceClass,
 					}...)
 				}
 
@@ -406,6 +468,24 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 	return nil
 }
 
+// initializePartition prepares a single osd on a partition. ceph-volume lvm batch does not accept partitions,
+// so the osd is prepared with ceph-volume lvm prepare instead.
+func (a *OsdAgent) initializePartition(context *clusterd.Context, storeFlag, partitionPath, deviceClass string) error {
+	args := []string{"-oL", cephVolumeCmd, "lvm", "prepare", storeFlag, "--data", partitionPath}
+	if a.storeConfig.EncryptedDevice {
+		args = append(args, encryptedFlag)
+	}
+	if deviceClass != "" {
+		args = append(args, crushDeviceClassFlag, deviceClass)
+	}
+
+	logger.Infof("preparing an osd on partition %s", partitionPath)
+	if err := context.Executor.ExecuteCommand(false, "", "stdbuf", args...); err != nil {
+		return errors.Wrapf(err, "failed ceph-volume prepare on partition %q", partitionPath)
+	}
+	return nil
+}
+
 func getDatabaseSize(globalSize int, deviceSize int) int {
 	if deviceSize > 0 {
 		globalSize = deviceSize
@@ -462,6 +542,7 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string, cephfsid s
 		}
 		var osdFSID string
 		isFilestore := false
+		encrypted := false
 		for _, osd := range osdInfo {
 			if osd.Tags.ClusterFSID != cephfsid {
 				logger.Infof("skipping osd%d: %q running on a different ceph cluster %q", id, osd.Tags.OSDFSID, osd.Tags.ClusterFSID)
@@ -471,6 +552,9 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string, cephfsid s
 			if osd.Type == "journal" {
 				isFilestore = true
 			}
+			if osd.Tags.Encrypted == "1" {
+				encrypted = true
+			}
 		}
 		if len(osdFSID) == 0 {
 			logger.Infof("Skipping osd%d as no instances are running on ceph cluster %q", id, cephfsid)
@@ -491,6 +575,7 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string, cephfsid s
 			LVPath:              lv,
 			SkipLVRelease:       skipLVRelease,
 			LVBackedPV:          lvBackedPV,
+			Encrypted:           encrypted,
 		}
 		osds = append(osds, osd)
 	}
@@ -499,6 +584,53 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string, cephfsid s
 	return osds, nil
 }
 
+// getCephVolumeRawOSDs returns the osds prepared with the raw mode of ceph-volume on the device
+func getCephVolumeRawOSDs(context *clusterd.Context, clusterName, cephfsid, device string) ([]oposd.OSDInfo, error) {
+	result, err := context.Executor.ExecuteCommandWithCombinedOutput(false, "", cephVolumeCmd, "raw", "list", device, "--format", "json")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve ceph-volume raw list results")
+	}
+	logger.Debug(result)
+
+	var cephVolumeResult map[string]rawOSDInfo
+	if err := json.Unmarshal([]byte(result), &cephVolumeResult); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal ceph-volume raw list results")
+	}
+
+	var osds []oposd.OSDInfo
+	for _, osdInfo := range cephVolumeResult {
+		if osdInfo.CephFSID != cephfsid {
+			logger.Infof("skipping osd%d: %q running on a different ceph cluster %q", osdInfo.OSDID, osdInfo.OSDUUID, osdInfo.CephFSID)
+			continue
+		}
+		configDir := fmt.Sprintf("/var/lib/rook/osd%d", osdInfo.OSDID)
+		osds = append(osds, oposd.OSDInfo{
+			ID:                  osdInfo.OSDID,
+			DataPath:            configDir,
+			Config:              fmt.Sprintf("%s/%s.config", configDir, clusterName),
+			KeyringPath:         path.Join(configDir, "keyring"),
+			Cluster:             "ceph",
+			UUID:                osdInfo.OSDUUID,
+			CephVolumeInitiated: true,
+			// there is no lvm to release in the raw mode
+			SkipLVRelease: true,
+			CVMode:        config.CephVolumeRawMode,
+			BlockPath:     device,
+		})
+	}
+	logger.Infof("%d ceph-volume raw osd devices configured on this node", len(osds))
+
+	return osds, nil
+}
+
+type rawOSDInfo struct {
+	CephFSID string `json:"ceph_fsid"`
+	Device   string `json:"device"`
+	OSDID    int    `json:"osd_id"`
+	OSDUUID  string `json:"osd_uuid"`
+	Type     string `json:"type"`
+}
+
 type osdInfo struct {
 	Name string  `json:"name"`
 	Path string  `json:"path"`
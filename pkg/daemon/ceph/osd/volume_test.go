@@ -17,10 +17,14 @@ limitations under the License.
 package osd
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -256,6 +260,30 @@ func TestParseCephVolumeResult(t *testing.T) {
 	assert.Nil(t, err)
 	require.NotNil(t, osds)
 	assert.Equal(t, 2, len(osds))
+	for _, osd := range osds {
+		assert.False(t, osd.Encrypted)
+	}
+}
+
+func TestParseCephVolumeResultEncrypted(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithCombinedOutput = func(debug bool, name string, command string, args ...string) (string, error) {
+		logger.Infof("%s %+v", command, args)
+
+		if command == "ceph-volume" {
+			return strings.Replace(cephVolumeTestResult, `"ceph.encrypted": "0"`, `"ceph.encrypted": "1"`, -1), nil
+		}
+
+		return "", errors.Errorf("unknown command %s %s", command, args)
+	}
+
+	context := &clusterd.Context{Executor: executor}
+	osds, err := getCephVolumeOSDs(context, "rook", "4bfe8b72-5e69-4330-b6c0-4d914db8ab89", "", false, false)
+	assert.Nil(t, err)
+	require.Equal(t, 2, len(osds))
+	for _, osd := range osds {
+		assert.True(t, osd.Encrypted)
+	}
 }
 
 func TestCephVolumeResultMultiClusterSingleOSD(t *testing.T) {
@@ -311,3 +339,189 @@ func TestGetDatabaseSize(t *testing.T) {
 	assert.Equal(t, 0, getDatabaseSize(0, 0))
 	assert.Equal(t, 2048, getDatabaseSize(4096, 2048))
 }
+
+func TestInitializeDevicesWithMetadataDevice(t *testing.T) {
+	batchArgs := [][]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			if args[len(args)-1] != "--report" {
+				batchArgs = append(batchArgs, args)
+			}
+			return nil
+		},
+		MockExecuteCommandWithCombinedOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			return `{"changed": true, "vg": {"devices": "/dev/nvme0n1"}}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{
+		cluster:        &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 5}},
+		metadataDevice: "nvme0n1",
+		storeConfig:    config.StoreConfig{StoreType: config.Bluestore, DatabaseSizeMB: 2048},
+	}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sda": {Data: -1},
+		"sdb": {Data: -1},
+	}}
+
+	// the data devices are batched with the db on the metadata device
+	require.NoError(t, agent.initializeDevices(context, devices))
+	require.Equal(t, 1, len(batchArgs))
+	args := strings.Join(batchArgs[0], " ")
+	assert.Contains(t, args, "ceph-volume lvm batch --prepare --bluestore --yes --osds-per-device 1 --block-db-size 2147483648")
+	assert.Contains(t, args, "/dev/sda")
+	assert.Contains(t, args, "/dev/sdb")
+	assert.True(t, strings.HasSuffix(args, "--db-devices /dev/nvme0n1"))
+
+	// the devices sharing the metadata device must request the same db size
+	batchArgs = [][]string{}
+	devices.Entries["sdb"].Config.DatabaseSizeMB = 4096
+	assert.Error(t, agent.initializeDevices(context, devices))
+	assert.Empty(t, batchArgs)
+}
+
+func TestInitializeDevicesWithDeviceClass(t *testing.T) {
+	execArgs := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			if args[len(args)-1] != "--report" {
+				execArgs[args[len(args)-1]] = strings.Join(args, " ")
+			}
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{
+		cluster:     &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 5}},
+		storeConfig: config.StoreConfig{StoreType: config.Bluestore, DeviceClass: "hdd-archive"},
+	}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sda": {Data: -1},
+		"sdb": {Data: -1, Config: DesiredDevice{DeviceClass: "nvme-cache"}},
+	}}
+
+	// the class of the device overrides the class of the node
+	require.NoError(t, agent.initializeDevices(context, devices))
+	assert.Contains(t, execArgs["hdd-archive"], "/dev/sda --crush-device-class hdd-archive")
+	assert.Contains(t, execArgs["nvme-cache"], "/dev/sdb --crush-device-class nvme-cache")
+}
+
+func TestInitializeDevicesWithOSDsPerDevice(t *testing.T) {
+	execArgs := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			if args[len(args)-1] != "--report" {
+				execArgs[args[len(args)-1]] = strings.Join(args, " ")
+			}
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{
+		cluster:     &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 5}},
+		storeConfig: config.StoreConfig{StoreType: config.Bluestore, OSDsPerDevice: 4},
+	}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"nvme0n1": {Data: -1},
+		"nvme1n1": {Data: -1, Config: DesiredDevice{OSDsPerDevice: 1}},
+	}}
+
+	// the count of the device overrides the count of the node
+	require.NoError(t, agent.initializeDevices(context, devices))
+	assert.Equal(t, "-oL ceph-volume lvm batch --prepare --bluestore --yes --osds-per-device 4 /dev/nvme0n1", execArgs["/dev/nvme0n1"])
+	assert.Equal(t, "-oL ceph-volume lvm batch --prepare --bluestore --yes --osds-per-device 1 /dev/nvme1n1", execArgs["/dev/nvme1n1"])
+}
+
+func TestInitializeDevicesWithPartition(t *testing.T) {
+	execArgs := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			if args[len(args)-1] != "--report" {
+				execArgs[args[len(args)-1]] = strings.Join(args, " ")
+			}
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{
+		cluster:     &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 5}},
+		storeConfig: config.StoreConfig{StoreType: config.Bluestore, OSDsPerDevice: 2},
+	}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sda":  {Data: -1},
+		"sdb1": {Data: -1, IsPartition: true, Config: DesiredDevice{DeviceClass: "ssd"}},
+	}}
+
+	// a single osd is prepared on the partition instead of batching it
+	require.NoError(t, agent.initializeDevices(context, devices))
+	assert.Equal(t, "-oL ceph-volume lvm batch --prepare --bluestore --yes --osds-per-device 2 /dev/sda", execArgs["/dev/sda"])
+	assert.Equal(t, "-oL ceph-volume lvm prepare --bluestore --data /dev/sdb1 --crush-device-class ssd", execArgs["ssd"])
+
+	// a partition is not prepared with a metadata device
+	execArgs = map[string]string{}
+	devices.Entries["sda"].Data = 0
+	devices.Entries["sdb1"].Config.MetadataDevice = "nvme0n1"
+	require.NoError(t, agent.initializeDevices(context, devices))
+	assert.Empty(t, execArgs)
+}
+
+func TestInitializeBlockPVCRaw(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithCombinedOutput = func(debug bool, name string, command string, args ...string) (string, error) {
+		logger.Infof("%s %+v", command, args)
+		commands = append(commands, strings.Join(args, " "))
+		return "", nil
+	}
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{
+		pvcBacked:   true,
+		storeConfig: config.StoreConfig{DeviceClass: "ssd", CephVolumeMode: config.CephVolumeRawMode},
+	}
+
+	// only the new device is prepared
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{"/mnt/set1-0-data-abc": {Data: -1, Config: DesiredDevice{Name: "/mnt/set1-0-data-abc"}}}}
+	require.NoError(t, agent.initializeBlockPVCRaw(context, devices))
+	assert.Equal(t, []string{"-oL ceph-volume raw prepare --bluestore --data /mnt/set1-0-data-abc --crush-device-class ssd"}, commands)
+
+	commands = nil
+	devices.Entries["/mnt/set1-0-data-abc"].Data = 3
+	require.NoError(t, agent.initializeBlockPVCRaw(context, devices))
+	assert.Nil(t, commands)
+}
+
+func TestParseCephVolumeRawResult(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithCombinedOutput = func(debug bool, name string, command string, args ...string) (string, error) {
+		logger.Infof("%s %+v", command, args)
+		if command == "ceph-volume" && args[0] == "raw" && args[1] == "list" && args[2] == "/mnt/set1-0-data-abc" {
+			return `{
+    "dbe407e0-c1cb-495e-b30a-02e01de6c8ae": {
+        "ceph_fsid": "451267e6-883f-4936-8dff-080d781c67d5",
+        "device": "/mnt/set1-0-data-abc",
+        "osd_id": 2,
+        "osd_uuid": "dbe407e0-c1cb-495e-b30a-02e01de6c8ae",
+        "type": "bluestore"
+    },
+    "4bfe8b72-5e69-4330-b6c0-4d914db8ab89": {
+        "ceph_fsid": "other-cluster",
+        "device": "/mnt/set1-0-data-abc",
+        "osd_id": 0,
+        "osd_uuid": "4bfe8b72-5e69-4330-b6c0-4d914db8ab89",
+        "type": "bluestore"
+    }
+}`, nil
+		}
+		return "", errors.Errorf("unknown command %s %s", command, args)
+	}
+
+	context := &clusterd.Context{Executor: executor}
+	osds, err := getCephVolumeRawOSDs(context, "rook", "451267e6-883f-4936-8dff-080d781c67d5", "/mnt/set1-0-data-abc")
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(osds))
+	assert.Equal(t, 2, osds[0].ID)
+	assert.Equal(t, "dbe407e0-c1cb-495e-b30a-02e01de6c8ae", osds[0].UUID)
+	assert.Equal(t, config.CephVolumeRawMode, osds[0].CVMode)
+	assert.Equal(t, "/mnt/set1-0-data-abc", osds[0].BlockPath)
+	assert.True(t, osds[0].SkipLVRelease)
+}
@@ -41,7 +41,12 @@ import (
 )
 
 const (
-	discoverDaemonUdev = "DISCOVER_DAEMON_UDEV_BLACKLIST"
+	// UdevBlacklistEnvVar is the comma separated list of the regular expressions of the udev events that are
+	// ignored by the discover daemon
+	UdevBlacklistEnvVar = "DISCOVER_DAEMON_UDEV_BLACKLIST"
+	// DeviceExclusionsEnvVar is the comma separated list of the regular expressions of the devices that are never
+	// reported or consumed, such as "^/dev/dm-[0-9]+$,^/dev/disk/by-path/.*-fc-.*"
+	DeviceExclusionsEnvVar = "DISCOVER_DEVICE_EXCLUSIONS"
 )
 
 var (
@@ -189,7 +194,7 @@ func udevBlockMonitor(c chan string, period time.Duration) {
 	// get discoverDaemonUdevBlacklist from the enviornment variable
 	// if user doesnt provide any regex; generate the default regex
 	// else use the regex provided by user
-	discoverUdev := os.Getenv(discoverDaemonUdev)
+	discoverUdev := os.Getenv(UdevBlacklistEnvVar)
 	if discoverUdev == "" {
 		discoverUdev = "(?i)dm-[0-9]+,(?i)rbd[0-9]+,(?i)nbd[0-9]+"
 	}
@@ -222,6 +227,38 @@ func udevBlockMonitor(c chan string, period time.Duration) {
 	}
 }
 
+// GetDeviceExclusions returns the regular expressions of the excluded devices. The invalid expressions are skipped.
+func GetDeviceExclusions() []*regexp.Regexp {
+	exclusions := []*regexp.Regexp{}
+	for _, expr := range strings.Split(os.Getenv(DeviceExclusionsEnvVar), ",") {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		exclusion, err := regexp.Compile(expr)
+		if err != nil {
+			logger.Warningf("skipping invalid device exclusion %q. %v", expr, err)
+			continue
+		}
+		exclusions = append(exclusions, exclusion)
+	}
+	return exclusions
+}
+
+// IsExcludedDevice returns whether the path of the device or one of its links matches an exclusion
+func IsExcludedDevice(device *sys.LocalDisk, exclusions []*regexp.Regexp) bool {
+	pathnames := append([]string{path.Join("/dev", device.Name)}, strings.Fields(device.DevLinks)...)
+	for _, exclusion := range exclusions {
+		for _, pathname := range pathnames {
+			if exclusion.MatchString(pathname) {
+				logger.Infof("device %q is excluded by %q", device.Name, exclusion.String())
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func ignoreDevice(dev sys.LocalDisk) bool {
 	return strings.Contains(strings.ToUpper(dev.DevLinks), "USB")
 }
@@ -404,11 +441,12 @@ func probeDevices(context *clusterd.Context) ([]sys.LocalDisk, error) {
 		}
 	}
 
+	exclusions := GetDeviceExclusions()
 	for _, device := range localDevices {
 		if device == nil {
 			continue
 		}
-		if device.Type == sys.PartType {
+		if IsExcludedDevice(device, exclusions) {
 			continue
 		}
 
@@ -19,6 +19,7 @@ package discover
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
@@ -76,9 +77,11 @@ func TestProbeDevices(t *testing.T) {
 		output := ""
 		switch name {
 		case "lsblk all":
-			output = "testa"
+			output = "testa\ntesta1"
 		case "lsblk /dev/testa":
 			output = `SIZE="249510756352" ROTA="1" RO="0" TYPE="disk" PKNAME=""`
+		case "lsblk /dev/testa1":
+			output = `SIZE="1073741824" ROTA="1" RO="0" TYPE="part" PKNAME="testa"`
 		case "get filesystem type for /dev/testa":
 			output = udevOutput
 		case "get parent for device testa":
@@ -101,11 +104,36 @@ testa2   centos_host13-home
 
 	context := &clusterd.Context{Executor: executor}
 
+	// the partitions are reported with the disks
 	devices, err := probeDevices(context)
 	assert.Nil(t, err)
-	assert.Equal(t, 1, len(devices))
+	assert.Equal(t, 2, len(devices))
 	assert.Equal(t, "ext2", devices[0].Filesystem)
+	assert.Equal(t, "testa1", devices[1].Name)
+	assert.Equal(t, sys.PartType, devices[1].Type)
+	assert.Equal(t, "testa", devices[1].Parent)
+	assert.False(t, devices[1].Empty)
+
+	// excluded devices are not reported
+	os.Setenv(DeviceExclusionsEnvVar, "^/dev/testa$")
+	defer os.Unsetenv(DeviceExclusionsEnvVar)
+	devices, err = probeDevices(context)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(devices))
+	assert.Equal(t, "testa1", devices[0].Name)
+}
 
+func TestIsExcludedDevice(t *testing.T) {
+	os.Setenv(DeviceExclusionsEnvVar, "^/dev/dm-[0-9]+$, ,(invalid,^/dev/disk/by-path/.*-fc-.*")
+	defer os.Unsetenv(DeviceExclusionsEnvVar)
+	exclusions := GetDeviceExclusions()
+	assert.Equal(t, 2, len(exclusions))
+
+	assert.True(t, IsExcludedDevice(&sys.LocalDisk{Name: "dm-1"}, exclusions))
+	assert.False(t, IsExcludedDevice(&sys.LocalDisk{Name: "dm-1p1"}, exclusions))
+	assert.True(t, IsExcludedDevice(&sys.LocalDisk{Name: "sdb", DevLinks: "/dev/disk/by-id/wwn-0x5000c500a0 /dev/disk/by-path/pci-0000:05:00.0-fc-0x500a098-lun-0"}, exclusions))
+	assert.False(t, IsExcludedDevice(&sys.LocalDisk{Name: "sdc", DevLinks: "/dev/disk/by-path/pci-0000:00:1f.2-ata-1"}, exclusions))
+	assert.False(t, IsExcludedDevice(&sys.LocalDisk{Name: "dm-1"}, nil))
 }
 
 func TestMatchUdevMonitorFiltering(t *testing.T) {
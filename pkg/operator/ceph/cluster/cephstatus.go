@@ -18,6 +18,7 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -25,6 +26,10 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -104,6 +109,13 @@ func (c *cephStatusChecker) updateCephStatus(status *client.CephStatus) error {
 
 	// translate the ceph status struct to the crd status
 	cluster.Status.CephStatus = toCustomResourceStatus(cluster.Status, status)
+	osds, err := c.getOSDStatus()
+	if err != nil {
+		// keep the last status of the osds
+		logger.Warningf("failed to get the status of the osds. %v", err)
+	} else {
+		cluster.Status.OSDs = osds
+	}
 	if _, err := c.context.RookClientset.CephV1().CephClusters(c.namespace).Update(cluster); err != nil {
 		return errors.Wrapf(err, "failed to update cluster %s status", c.namespace)
 	}
@@ -135,6 +147,63 @@ func toCustomResourceStatus(currentStatus cephv1.ClusterStatus, newStatus *clien
 	return s
 }
 
+// getOSDStatus returns the status of each osd of the osd map with the deployment and the node running it
+func (c *cephStatusChecker) getOSDStatus() ([]cephv1.OSDStatus, error) {
+	osdDump, err := client.GetOSDDump(c.context, c.namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the osd dump")
+	}
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, osd.AppName)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the osd deployments")
+	}
+	pods, err := c.context.Clientset.CoreV1().Pods(c.namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the osd pods")
+	}
+	return toOSDStatus(osdDump, deployments.Items, pods.Items)
+}
+
+// toOSDStatus converts the osds of the osd map and their deployments to the osd status of the CephCluster CR. The
+// node is the one the pod of the osd is scheduled on, since the osds on pvcs are not bound to a node by a selector.
+func toOSDStatus(osdDump *client.OSDDump, deployments []apps.Deployment, pods []v1.Pod) ([]cephv1.OSDStatus, error) {
+	osdDeployments := map[string]*apps.Deployment{}
+	for i := range deployments {
+		osdDeployments[deployments[i].Labels[osd.OsdIdLabelKey]] = &deployments[i]
+	}
+	osdNodes := map[string]string{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		// a pod that is terminating is only reported until the new pod of the osd is scheduled
+		id := pod.Labels[osd.OsdIdLabelKey]
+		if _, ok := osdNodes[id]; !ok || pod.DeletionTimestamp == nil {
+			osdNodes[id] = pod.Spec.NodeName
+		}
+	}
+
+	status := []cephv1.OSDStatus{}
+	for _, o := range osdDump.OSDs {
+		id, err := o.OSD.Int64()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the osd id %q", o.OSD)
+		}
+		up, in, err := osdDump.StatusByID(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the status of osd %d", id)
+		}
+		s := cephv1.OSDStatus{ID: int(id), Up: up == 1, In: in == 1}
+		if d, ok := osdDeployments[o.OSD.String()]; ok {
+			s.Deployment = d.Name
+			s.Node = osdNodes[o.OSD.String()]
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
 func formatTime(t time.Time) string {
 	return t.Format(time.RFC3339)
 }
@@ -18,12 +18,17 @@ limitations under the License.
 package cluster
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestCephStatus(t *testing.T) {
@@ -88,3 +93,29 @@ func TestCephStatus(t *testing.T) {
 	assert.Equal(t, pgAvailMsg.Summary.Message, aggregateStatus.Details["PG_AVAILABILITY"].Message)
 	assert.Equal(t, pgAvailMsg.Severity, aggregateStatus.Details["PG_AVAILABILITY"].Severity)
 }
+
+func TestOSDStatus(t *testing.T) {
+	var osdDump client.OSDDump
+	assert.NoError(t, json.Unmarshal([]byte(`{"osds":[{"osd":0,"up":1,"in":1},{"osd":1,"up":0,"in":1},{"osd":2,"up":0,"in":0}]}`), &osdDump))
+	deployments := []apps.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0", Labels: map[string]string{osd.OsdIdLabelKey: "0"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1", Labels: map[string]string{osd.OsdIdLabelKey: "1"}}},
+	}
+	now := metav1.Now()
+	pods := []v1.Pod{
+		// the osd on a pvc does not have a node selector, the node is the one its pod is scheduled on
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0-abc", Labels: map[string]string{osd.OsdIdLabelKey: "0"}}, Spec: v1.PodSpec{NodeName: "node0"}},
+		// the new pod is reported instead of the terminating pod
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1-new", Labels: map[string]string{osd.OsdIdLabelKey: "1"}}, Spec: v1.PodSpec{NodeName: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1-old", Labels: map[string]string{osd.OsdIdLabelKey: "1"}, DeletionTimestamp: &now}, Spec: v1.PodSpec{NodeName: "node2"}},
+	}
+
+	status, err := toOSDStatus(&osdDump, deployments, pods)
+	assert.NoError(t, err)
+	assert.Equal(t, []cephv1.OSDStatus{
+		{ID: 0, Deployment: "rook-ceph-osd-0", Node: "node0", Up: true, In: true},
+		{ID: 1, Deployment: "rook-ceph-osd-1", Node: "node1", Up: false, In: true},
+		// the osd without a deployment is still reported
+		{ID: 2, Up: false, In: false},
+	}, status)
+}
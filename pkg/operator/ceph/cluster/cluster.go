@@ -57,6 +57,8 @@ type cluster struct {
 	Spec                 *cephv1.ClusterSpec
 	crdName              string
 	mons                 *mon.Cluster
+	mgrs                 *mgr.Cluster
+	mgrReconcilePending  bool
 	initCompleted        bool
 	stopCh               chan struct{}
 	ownerRef             metav1.OwnerReference
@@ -65,6 +67,9 @@ type cluster struct {
 	orchMux              sync.Mutex
 	childControllers     []childController
 	isUpgrade            bool
+	// maintenanceTask is the task running on the cluster outside of the orchestration, such as the restore of
+	// the mon quorum. The updates of the cluster are not orchestrated while the task is running.
+	maintenanceTask string
 }
 
 // ChildController is implemented by CRs that are owned by the CephCluster
@@ -238,6 +243,16 @@ func (c *cluster) createInstance(rookImage string, cephVersion cephver.CephVersi
 	return err
 }
 
+// newOSDCluster makes the manager of the osds of the cluster
+func (c *cluster) newOSDCluster(rookImage string, spec *cephv1.ClusterSpec) *osd.Cluster {
+	osds := osd.New(c.Info, c.context, c.Namespace, rookImage, spec.CephVersion, spec.Storage, spec.DataDirHostPath,
+		cephv1.GetOSDPlacement(spec.Placement), cephv1.GetOSDAnnotations(spec.Annotations), spec.Network, spec.Security.KeyManagementService,
+		cephv1.GetOSDResources(spec.Resources), cephv1.GetPrepareOSDResources(spec.Resources), cephv1.GetOSDPriorityClassName(spec.PriorityClassNames),
+		cephv1.GetPrepareOSDPriorityClassName(spec.PriorityClassNames), c.ownerRef, c.isUpgrade, c.Spec.SkipUpgradeChecks)
+	osds.WeightInNewOSDs = spec.OSDWeightIn != nil
+	return osds
+}
+
 func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVersion, spec *cephv1.ClusterSpec) error {
 	// Create a configmap for overriding ceph config settings
 	// These settings should only be modified by a user after they are initialized
@@ -283,20 +298,40 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 			spec.CephVersion, cephv1.GetMgrPlacement(spec.Placement), cephv1.GetMgrAnnotations(c.Spec.Annotations),
 			spec.Network, spec.Dashboard, spec.Monitoring, spec.Mgr, cephv1.GetMgrResources(spec.Resources),
 			cephv1.GetMgrPriorityClassName(spec.PriorityClassNames), c.ownerRef, c.Spec.DataDirHostPath, c.isUpgrade, c.Spec.SkipUpgradeChecks)
+		c.setMgrs(mgrs)
 		err = mgrs.Start()
+		c.scheduleMgrReconcile()
 		if err != nil {
 			return errors.Wrapf(err, "failed to start the ceph mgr")
 		}
 
+		// Set the scrub options of the osds before they are started
+		err = osd.SetScrubConfigOptions(c.context, c.Namespace, spec.Scrub)
+		if err != nil {
+			return errors.Wrapf(err, "failed to configure the scrubbing of the osds")
+		}
+		err = osd.SetFullRatios(c.context, c.Info.Name, spec.FullRatios)
+		if err != nil {
+			return errors.Wrapf(err, "failed to set the full ratios of the osds")
+		}
+		err = osd.SetWeightInConfig(c.context, c.Namespace, spec.OSDWeightIn)
+		if err != nil {
+			return errors.Wrapf(err, "failed to configure the weight-in of the new osds")
+		}
+
 		// Start the OSDs
-		osds := osd.New(c.Info, c.context, c.Namespace, rookImage, spec.CephVersion, spec.Storage, spec.DataDirHostPath,
-			cephv1.GetOSDPlacement(spec.Placement), cephv1.GetOSDAnnotations(spec.Annotations), spec.Network,
-			cephv1.GetOSDResources(spec.Resources), cephv1.GetPrepareOSDResources(spec.Resources), cephv1.GetOSDPriorityClassName(spec.PriorityClassNames), c.ownerRef, c.isUpgrade, c.Spec.SkipUpgradeChecks)
+		osds := c.newOSDCluster(rookImage, spec)
 		err = osds.Start()
 		if err != nil {
 			return errors.Wrapf(err, "failed to start the osds")
 		}
 
+		// Enable the stretch mode once the osds of each zone are in the crush map
+		err = c.mons.ConfigureStretchMode()
+		if err != nil {
+			return errors.Wrapf(err, "failed to configure the stretch cluster")
+		}
+
 		// Start the rbd mirroring daemon(s)
 		rbdmirror := rbd.New(c.Info, c.context, c.Namespace, rookImage, spec.CephVersion, cephv1.GetRBDMirrorPlacement(spec.Placement),
 			cephv1.GetRBDMirrorAnnotations(spec.Annotations), spec.Network, spec.RBDMirroring,
@@ -349,6 +384,84 @@ func clusterChanged(oldCluster, newCluster cephv1.ClusterSpec, clusterRef *clust
 	return false, ""
 }
 
+// setMgrs keeps the mgrs of the latest orchestration, with the result of the previous orchestration of the mgrs
+func (c *cluster) setMgrs(mgrs *mgr.Cluster) {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	if c.mgrs != nil {
+		mgrs.InheritStartResult(c.mgrs)
+	}
+	c.mgrs = mgrs
+}
+
+// mgrStartResult returns the result of the last orchestration of the mgrs, or false if the mgrs were never started
+func (c *cluster) mgrStartResult() (mgr.StartResult, bool) {
+	c.orchMux.Lock()
+	mgrs := c.mgrs
+	c.orchMux.Unlock()
+	if mgrs == nil {
+		return mgr.StartResult{}, false
+	}
+	return mgrs.LastStartResult(), true
+}
+
+// scheduleMgrReconcile starts the mgrs again after the requeue suggested by their last orchestration, which is
+// their reconcile interval once they are settled. Only one reconcile is pending at a time.
+func (c *cluster) scheduleMgrReconcile() {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	if c.mgrs == nil || c.mgrReconcilePending {
+		return
+	}
+	requeue := c.mgrs.LastStartResult().SuggestedRequeue
+	if requeue <= 0 {
+		return
+	}
+	c.mgrReconcilePending = true
+	go func() {
+		select {
+		case <-c.stopCh:
+			return
+		case <-time.After(requeue):
+		}
+		c.orchMux.Lock()
+		c.mgrReconcilePending = false
+		mgrs := c.mgrs
+		c.orchMux.Unlock()
+
+		logger.Debugf("reconciling the mgrs of cluster %q", c.Namespace)
+		if err := mgrs.Start(); err != nil {
+			logger.Errorf("failed to reconcile the mgrs of cluster %q. %v", c.Namespace, err)
+		}
+		c.scheduleMgrReconcile()
+	}()
+}
+
+// startMaintenance marks the task as running on the cluster. It returns false if another task is already running.
+func (c *cluster) startMaintenance(task string) bool {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	if c.maintenanceTask != "" {
+		return false
+	}
+	c.maintenanceTask = task
+	return true
+}
+
+// finishMaintenance marks the running task as completed
+func (c *cluster) finishMaintenance() {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	c.maintenanceTask = ""
+}
+
+// runningMaintenance returns the task running on the cluster, or an empty string if no task is running
+func (c *cluster) runningMaintenance() string {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	return c.maintenanceTask
+}
+
 func (c *cluster) setOrchestrationNeeded() {
 	c.orchMux.Lock()
 	c.orchestrationNeeded = true
@@ -456,7 +569,9 @@ func (c *cluster) postMonStartupActions() error {
 				// If length is one, this clearly indicates that all the mons are running the same version
 				// We are doing this because 'ceph version' might return the Ceph version that a majority of mons has but not all of them
 				// so instead of trying to active msgr2 when mons are not ready, we activate it when we believe that's the right time
-				client.EnableMessenger2(c.context, c.Namespace)
+				if err := client.EnableMessenger2(c.context, c.Namespace); err != nil {
+					return errors.Wrapf(err, "failed to enable the msgr2 protocol")
+				}
 			}
 		}
 	}
@@ -154,3 +154,17 @@ func testSpec() cluster {
 	}
 	return cluster{Spec: &cephv1.ClusterSpec{}, context: context}
 }
+
+func TestMaintenanceTask(t *testing.T) {
+	c := &cluster{}
+	assert.Equal(t, "", c.runningMaintenance())
+
+	// only one task runs at a time
+	assert.True(t, c.startMaintenance("restoring the mon quorum"))
+	assert.False(t, c.startMaintenance("purging osds"))
+	assert.Equal(t, "restoring the mon quorum", c.runningMaintenance())
+
+	c.finishMaintenance()
+	assert.Equal(t, "", c.runningMaintenance())
+	assert.True(t, c.startMaintenance("purging osds"))
+}
@@ -36,6 +36,7 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
 	cephclient "github.com/rook/rook/pkg/operator/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/config"
@@ -43,6 +44,8 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/nfs"
 	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
+	"github.com/rook/rook/pkg/operator/ceph/object/multisite"
+	"github.com/rook/rook/pkg/operator/ceph/object/notification"
 	objectuser "github.com/rook/rook/pkg/operator/ceph/object/user"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
@@ -194,6 +197,16 @@ func (c *ClusterController) GetClusterCount() int {
 	return len(c.clusterMap)
 }
 
+// GetMgrStartResult returns the result of the last orchestration of the mgrs of the cluster in the namespace, for
+// the health of the operator. It returns false if the mgrs of the cluster were never started.
+func (c *ClusterController) GetMgrStartResult(namespace string) (mgr.StartResult, bool) {
+	cluster, ok := c.clusterMap[namespace]
+	if !ok {
+		return mgr.StartResult{}, false
+	}
+	return cluster.mgrStartResult()
+}
+
 // ************************************************************************************************
 // Add event functions
 // ************************************************************************************************
@@ -266,6 +279,13 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	c.csiConfigMutex.Unlock()
 
 	c.initializeCluster(cluster, clusterObj)
+
+	// A purge that was requested before the operator restarted is resumed since the annotation is only removed
+	// once the OSDs are purged
+	if purgeOSDs := clusterObj.Annotations[osd.PurgeOSDsAnnotation]; purgeOSDs != "" {
+		logger.Infof("resuming the purge of osds %q of cluster %q", purgeOSDs, cluster.Namespace)
+		c.startPurgeOSDs(clusterObj, cluster, purgeOSDs)
+	}
 }
 
 func (c *ClusterController) configureExternalCephCluster(namespace, name string, cluster *cluster) error {
@@ -333,9 +353,6 @@ func (c *ClusterController) configureLocalCephCluster(namespace, name string, cl
 		logger.Warningf("mon count should be at least 1, will use default value of %d", mon.DefaultMonCount)
 		cluster.Spec.Mon.Count = mon.DefaultMonCount
 	}
-	if cluster.Spec.Mon.Count%2 == 0 {
-		logger.Warningf("mon count is even (given: %d), should be uneven, continuing", cluster.Spec.Mon.Count)
-	}
 
 	if c.devicesInUse && cluster.Spec.Storage.AnyUseAllDevices() {
 		c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, cephv1.ClusterStateError, "using all devices in more than one namespace is not supported")
@@ -431,6 +448,15 @@ func (c *ClusterController) initializeCluster(cluster *cluster, clusterObj *ceph
 	objectStoreUserController := objectuser.NewObjectStoreUserController(c.context, cluster.Spec, cluster.Namespace, cluster.ownerRef)
 	objectStoreUserController.StartWatch(cluster.stopCh)
 
+	// Start object multisite CRD watchers
+	multisite.NewRealmController(c.context, cluster.Spec, cluster.Namespace).StartWatch(cluster.stopCh)
+	multisite.NewZoneGroupController(c.context, cluster.Spec, cluster.Namespace).StartWatch(cluster.stopCh)
+	multisite.NewZoneController(c.context, cluster.Spec, cluster.Namespace).StartWatch(cluster.stopCh)
+
+	// Start bucket topic and notification CRD watchers
+	notification.NewTopicController(c.context, cluster.Spec, cluster.Namespace).StartWatch(cluster.stopCh)
+	notification.NewNotificationController(c.context, cluster.Spec, cluster.Namespace).StartWatch(cluster.stopCh)
+
 	// Start the object bucket provisioner
 	bucketProvisioner := bucket.NewProvisioner(c.context, cluster.Namespace)
 	// note: the error return below is ignored and is expected to be removed from the
@@ -463,8 +489,14 @@ func (c *ClusterController) initializeCluster(cluster *cluster, clusterObj *ceph
 
 	if !cluster.Spec.External.Enable {
 		// Start the osd health checker only if running OSDs in the local ceph cluster
-		c.osdChecker = osd.NewMonitor(c.context, cluster.Namespace, cluster.Spec.RemoveOSDsIfOutAndSafeToRemove, cluster.Info.CephVersion)
+		c.osdChecker = osd.NewMonitor(c.context, cluster.Namespace, cluster.Spec.RemoveOSDsIfOutAndSafeToRemove, cluster.Info.CephVersion,
+			cluster.Spec.Security.KeyManagementService, cluster.ownerRef)
+		c.osdChecker.UpdateWeightIn(cluster.Spec.OSDWeightIn)
 		go c.osdChecker.Start(cluster.stopCh)
+
+		// Start the checker that labels the active mgr
+		activeMgrChecker := mgr.NewActiveMgrChecker(c.context, cluster.Namespace, func() *cephv1.ClusterSpec { return cluster.Spec })
+		go activeMgrChecker.Check(cluster.stopCh)
 	}
 
 	// Start the ceph status checker
@@ -500,6 +532,12 @@ func (c *ClusterController) onK8sNodeUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
+	// the osds of the node are put into maintenance, or started again, when the annotation changes
+	if osd.IsNodeInMaintenance(newNode) != osd.IsNodeInMaintenance(oldNode) {
+		c.updateNodeMaintenance(newNode)
+		return
+	}
+
 	newNodeSchedulable := k8sutil.GetNodeSchedulable(*newNode)
 	oldNodeSchedulable := k8sutil.GetNodeSchedulable(*oldNode)
 
@@ -533,6 +571,31 @@ func (c *ClusterController) onK8sNodeUpdate(oldObj, newObj interface{}) {
 	}
 }
 
+// updateNodeMaintenance puts the osds of the node into maintenance, or starts them again, in all the clusters
+func (c *ClusterController) updateNodeMaintenance(node *v1.Node) {
+	hostName := node.Labels[v1.LabelHostname]
+	if hostName == "" {
+		hostName = node.Name
+	}
+	inMaintenance := osd.IsNodeInMaintenance(node)
+	for _, cluster := range c.clusterMap {
+		if !cluster.initialized() {
+			logger.Infof("cluster %q is not ready. skipping the maintenance of node %q", cluster.Namespace, hostName)
+			continue
+		}
+		osds := cluster.newOSDCluster(c.rookImage, cluster.Spec)
+		var err error
+		if inMaintenance {
+			err = osds.StartNodeMaintenance(hostName)
+		} else {
+			err = osds.StopNodeMaintenance(hostName)
+		}
+		if err != nil {
+			logger.Errorf("failed to update the maintenance of node %q in cluster %q. %v", hostName, cluster.Namespace, err)
+		}
+	}
+}
+
 func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 	oldClust, err := getClusterObject(oldObj)
 	if err != nil {
@@ -573,6 +636,31 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
+	// The cluster is orchestrated with the latest spec once the running task completes
+	if task := cluster.runningMaintenance(); task != "" {
+		logger.Infof("not orchestrating the update of cluster %q while %s", newClust.Namespace, task)
+		cluster.Spec = &newClust.Spec
+		return
+	}
+
+	// The mon quorum is restored before any other orchestration since the orchestration requires the quorum.
+	// The restore waits for the mons, so it runs outside of the handler of the CR events.
+	restoreMon := newClust.Annotations[mon.RestoreQuorumAnnotation]
+	if restoreMon != "" && restoreMon != oldClust.Annotations[mon.RestoreQuorumAnnotation] {
+		if cluster.startMaintenance(fmt.Sprintf("restoring the mon quorum from mon %q", restoreMon)) {
+			go c.restoreMonQuorum(newClust, cluster, restoreMon)
+		}
+		return
+	}
+
+	// The OSDs are purged before the orchestration so the purged OSDs are not started again. The purge waits for
+	// the data to be rebalanced, so it runs outside of the handler of the CR events.
+	purgeOSDs := newClust.Annotations[osd.PurgeOSDsAnnotation]
+	if purgeOSDs != "" && purgeOSDs != oldClust.Annotations[osd.PurgeOSDsAnnotation] {
+		c.startPurgeOSDs(newClust, cluster, purgeOSDs)
+		return
+	}
+
 	// If the cluster was never initialized during the OnAdd() method due to a failure, we must
 	// treat the cluster as if it was just created.
 	if !cluster.initialized() {
@@ -593,6 +681,13 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 		logger.Infof("removeOSDsIfOutAndSafeToRemove is set to %t", newClust.Spec.RemoveOSDsIfOutAndSafeToRemove)
 		c.osdChecker.Update(newClust.Spec.RemoveOSDsIfOutAndSafeToRemove)
 	}
+	if !reflect.DeepEqual(oldClust.Spec.OSDWeightIn, newClust.Spec.OSDWeightIn) {
+		logger.Infof("the osd weight-in is set to %+v", newClust.Spec.OSDWeightIn)
+		c.osdChecker.UpdateWeightIn(newClust.Spec.OSDWeightIn)
+	}
+	if !reflect.DeepEqual(oldClust.Spec.Security.KeyManagementService, newClust.Spec.Security.KeyManagementService) {
+		c.osdChecker.UpdateKMS(newClust.Spec.Security.KeyManagementService)
+	}
 
 	logger.Debugf("old cluster: %+v", oldClust.Spec)
 	logger.Debugf("new cluster: %+v", newClust.Spec)
@@ -675,6 +770,99 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 	}
 }
 
+// restoreMonQuorum restores the mon quorum from the mon requested with the annotation of the cluster CR, then
+// orchestrates the cluster to create the missing mons. The annotation is removed once the quorum is restored.
+func (c *ClusterController) restoreMonQuorum(clust *cephv1.CephCluster, cluster *cluster, name string) {
+	logger.Infof("restoring the mon quorum of cluster %q from mon %q", clust.Namespace, name)
+	err := cluster.mons.RestoreQuorum(name)
+	cluster.finishMaintenance()
+	if err != nil {
+		c.updateClusterStatus(clust.Namespace, clust.Name, cephv1.ClusterStateError,
+			fmt.Sprintf("failed to restore the mon quorum from mon %q. %v", name, err))
+		return
+	}
+	c.removeAnnotations(clust, mon.RestoreQuorumAnnotation)
+
+	if !cluster.initialized() {
+		c.initializeCluster(cluster, clust)
+		return
+	}
+	c.updateLatestSpec(clust, cluster)
+}
+
+// updateLatestSpec orchestrates the latest spec of the cluster after a maintenance task, since the updates of the
+// cluster CR are not orchestrated while the task runs
+func (c *ClusterController) updateLatestSpec(clust *cephv1.CephCluster, cluster *cluster) {
+	done, _ := c.handleUpdate(clust.Name, cluster)
+	if done {
+		return
+	}
+	err := wait.Poll(updateClusterInterval, updateClusterTimeout, func() (bool, error) {
+		return c.handleUpdate(clust.Name, cluster)
+	})
+	if err != nil {
+		c.updateClusterStatus(clust.Namespace, clust.Name, cephv1.ClusterStateError,
+			fmt.Sprintf("giving up trying to update cluster in namespace %q after %q. %v", cluster.Namespace, updateClusterTimeout, err))
+	}
+}
+
+// startPurgeOSDs purges the OSDs in the background unless another maintenance task is running
+func (c *ClusterController) startPurgeOSDs(clust *cephv1.CephCluster, cluster *cluster, value string) {
+	if cluster.startMaintenance(fmt.Sprintf("purging osds %q", value)) {
+		go c.purgeOSDs(clust, cluster, value)
+	}
+}
+
+// purgeOSDs removes the OSDs requested with the annotation of the cluster CR. The annotations are removed once
+// the OSDs are purged. The progress is reported in the status of the cluster CR.
+func (c *ClusterController) purgeOSDs(clust *cephv1.CephCluster, cluster *cluster, value string) {
+	err := c.runPurgeOSDs(clust, cluster, value)
+	cluster.finishMaintenance()
+	if err != nil {
+		c.updateClusterStatus(clust.Namespace, clust.Name, cephv1.ClusterStateError, err.Error())
+		return
+	}
+	c.removeAnnotations(clust, osd.PurgeOSDsAnnotation, osd.ForcePurgeOSDsAnnotation)
+	c.updateLatestSpec(clust, cluster)
+}
+
+func (c *ClusterController) runPurgeOSDs(clust *cephv1.CephCluster, cluster *cluster, value string) error {
+	if !cluster.initialized() {
+		return errors.Errorf("cannot purge osds %q of cluster %q that is not initialized", value, clust.Namespace)
+	}
+	ids, err := osd.ParseOSDIDs(value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the annotation %q", osd.PurgeOSDsAnnotation)
+	}
+	force := clust.Annotations[osd.ForcePurgeOSDsAnnotation] == "true"
+
+	osds := cluster.newOSDCluster(c.rookImage, cluster.Spec)
+	for i, id := range ids {
+		c.updateClusterStatus(clust.Namespace, clust.Name, cephv1.ClusterStateUpdating,
+			fmt.Sprintf("purging osd.%d (%d of %d), waiting for its data to be rebalanced", id, i+1, len(ids)))
+		if err := osds.PurgeOSD(id, force); err != nil {
+			return errors.Wrapf(err, "failed to purge osd.%d", id)
+		}
+	}
+	return nil
+}
+
+// removeAnnotations removes the annotations of a request from the cluster CR, so the request is not handled again
+// when the same annotation is set later
+func (c *ClusterController) removeAnnotations(clust *cephv1.CephCluster, annotations ...string) {
+	latest, err := c.context.RookClientset.CephV1().CephClusters(clust.Namespace).Get(clust.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to remove the annotations %v. %v", clust.Name, annotations, err)
+		return
+	}
+	for _, annotation := range annotations {
+		delete(latest.Annotations, annotation)
+	}
+	if _, err := c.context.RookClientset.CephV1().CephClusters(clust.Namespace).Update(latest); err != nil {
+		logger.Warningf("failed to remove the annotations %v from cluster %q. %v", annotations, clust.Name, err)
+	}
+}
+
 func (c *ClusterController) detectAndValidateCephVersion(cluster *cluster, image string) (*cephver.CephVersion, bool, error) {
 	version, err := cluster.detectCephVersion(c.rookImage, image, detectCephVersionTimeout)
 	if err != nil {
@@ -744,7 +932,8 @@ func (c *ClusterController) onDeviceCMUpdate(oldObj, newObj interface{}) {
 			logger.Infof("Cluster %s is not ready. Skipping orchestration on device change", cluster.Namespace)
 			continue
 		}
-		if len(cluster.Spec.Storage.StorageClassDeviceSets) > 0 {
+		storage := cluster.Spec.Storage
+		if len(storage.StorageClassDeviceSets) > 0 && !storage.UseAllNodes && len(storage.Nodes) == 0 {
 			logger.Info("skip orchestration on device config map update for OSDs on PVC")
 			continue
 		}
@@ -781,6 +970,12 @@ func (c *ClusterController) onDelete(obj interface{}) {
 		logger.Errorf("failed to delete cluster. %v", err)
 	}
 
+	// the mon endpoints published outside of the cluster namespace are not garbage collected with the cluster
+	if err := mon.RemovePublishedEndpoints(c.context.Clientset, clust.Namespace); err != nil {
+		logger.Errorf("failed to remove the published mon endpoints. %v", err)
+	}
+	client.ClearClusterUsername(clust.Namespace)
+
 	// Note that this lock is held through the callback process, as this deletes CSI resources, but we must lock in
 	// this scope as the clusterMap is authoritative on cluster count. If we ever add additional callback functions,
 	// we should tighten this lock.
@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// mgrRoleLabel is set on the mgr pods with the role of the mgr daemon reported by ceph
+	mgrRoleLabel   = "mgr_role"
+	mgrRoleActive  = "active"
+	mgrRoleStandby = "standby"
+
+	defaultFailoverThreshold = 4
+	defaultFailoverTimeout   = 5
+)
+
+var (
+	// ActiveCheckInterval is the interval to check which mgr is active
+	ActiveCheckInterval = 15 * time.Second
+
+	// probeMetrics checks that the metrics endpoint of a mgr responds
+	probeMetrics = func(url string, timeout time.Duration) error {
+		httpClient := &http.Client{Timeout: timeout}
+		resp, err := httpClient.Get(url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}
+)
+
+// ActiveMgrChecker labels the pod of the active mgr so that it can be told apart from the standbys. If the
+// active failover is enabled, it also fails over the active mgr when it stops responding.
+type ActiveMgrChecker struct {
+	context   *clusterd.Context
+	namespace string
+	// clusterSpec returns the current cluster spec, which is replaced when the cluster CR is updated
+	clusterSpec func() *cephv1.ClusterSpec
+	// the active mgr that failed the latest checks, and the number of consecutive failed checks
+	failingMgr string
+	failures   int
+}
+
+// NewActiveMgrChecker creates a new ActiveMgrChecker object
+func NewActiveMgrChecker(context *clusterd.Context, namespace string, clusterSpec func() *cephv1.ClusterSpec) *ActiveMgrChecker {
+	return &ActiveMgrChecker{
+		context:     context,
+		namespace:   namespace,
+		clusterSpec: clusterSpec,
+	}
+}
+
+// Check periodically updates the role label of the mgr pods
+func (a *ActiveMgrChecker) Check(stopCh chan struct{}) {
+	// the services only select the active mgr, so check immediately before starting the loop
+	if err := updateMgrRoleLabels(a.context, a.namespace); err != nil {
+		logger.Warningf("failed to update the role of the mgr pods. %v", err)
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping the active mgr checker in namespace %s", a.namespace)
+			return
+
+		case <-time.After(ActiveCheckInterval):
+			logger.Debugf("checking the active mgr")
+			if err := updateMgrRoleLabels(a.context, a.namespace); err != nil {
+				logger.Warningf("failed to update the role of the mgr pods. %v", err)
+			}
+			if err := a.checkActiveMgrResponding(); err != nil {
+				logger.Warningf("failed to check that the active mgr is responding. %v", err)
+			}
+		}
+	}
+}
+
+// updateMgrRoleLabels sets the role label of each mgr pod to active or standby depending on the
+// active mgr in the mgr map. All the pods are labeled as standby if there is no active mgr.
+func updateMgrRoleLabels(context *clusterd.Context, namespace string) error {
+	status, err := client.Status(context, namespace, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to get ceph status")
+	}
+	activeName := ""
+	if isMgrActive(status) {
+		activeName = status.MgrMap.ActiveName
+	}
+
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)
+	pods, err := context.Clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list mgr pods")
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		role := mgrRoleStandby
+		if activeName != "" && pod.Labels["mgr"] == activeName {
+			role = mgrRoleActive
+		}
+		if pod.Labels[mgrRoleLabel] == role {
+			continue
+		}
+		logger.Infof("mgr pod %q is %s", pod.Name, role)
+		pod.Labels[mgrRoleLabel] = role
+		if _, err := context.Clientset.CoreV1().Pods(namespace).Update(pod); err != nil {
+			return errors.Wrapf(err, "failed to update the role of mgr pod %q", pod.Name)
+		}
+	}
+	return nil
+}
+
+// checkActiveMgrResponding probes the metrics endpoint of the active mgr. After the configured number of
+// consecutive failed probes, the mgr is marked as failed and its pod is deleted so that a standby takes over
+// and the hung daemon is restarted.
+func (a *ActiveMgrChecker) checkActiveMgrResponding() error {
+	mgrSpec := a.clusterSpec().Mgr
+	failover := mgrSpec.ActiveFailover
+	if !failover.Enabled {
+		a.failingMgr, a.failures = "", 0
+		return nil
+	}
+
+	status, err := client.Status(a.context, a.namespace, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to get ceph status")
+	}
+	if !isMgrActive(status) {
+		// there is no active mgr to fail over
+		a.failingMgr, a.failures = "", 0
+		return nil
+	}
+	activeName := status.MgrMap.ActiveName
+
+	selector := fmt.Sprintf("%s=%s,mgr=%s", k8sutil.AppAttr, AppName, activeName)
+	pods, err := a.context.Clientset.CoreV1().Pods(a.namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the pods of mgr %q", activeName)
+	}
+	if len(pods.Items) != 1 || pods.Items[0].Status.PodIP == "" {
+		logger.Debugf("the pod of the active mgr %q is not running", activeName)
+		return nil
+	}
+	pod := pods.Items[0]
+
+	timeout := failover.TimeoutSeconds
+	if timeout == 0 {
+		timeout = defaultFailoverTimeout
+	}
+	url := fmt.Sprintf("http://%s:%d/", pod.Status.PodIP, getMetricsPort(mgrSpec))
+	probeErr := probeMetrics(url, time.Duration(timeout)*time.Second)
+	if probeErr == nil {
+		a.failingMgr, a.failures = "", 0
+		return nil
+	}
+
+	if a.failingMgr != activeName {
+		a.failingMgr, a.failures = activeName, 0
+	}
+	a.failures++
+	threshold := failover.FailureThreshold
+	if threshold == 0 {
+		threshold = defaultFailoverThreshold
+	}
+	logger.Warningf("active mgr %q is not responding (%d/%d). %v", activeName, a.failures, threshold, probeErr)
+	if a.failures < threshold {
+		return nil
+	}
+
+	logger.Infof("failing over the active mgr %q", activeName)
+	if err := client.MgrFail(a.context, a.namespace, activeName); err != nil {
+		return errors.Wrapf(err, "failed to fail over the active mgr %q", activeName)
+	}
+	if err := a.context.Clientset.CoreV1().Pods(a.namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the pod %q of the active mgr", pod.Name)
+	}
+	a.failingMgr, a.failures = "", 0
+	return nil
+}
+
+// validateActiveFailover checks the thresholds to detect a hung active mgr
+func validateActiveFailover(failover cephv1.MgrFailoverSpec) error {
+	if failover.FailureThreshold < 0 {
+		return errors.Errorf("invalid failure threshold %d", failover.FailureThreshold)
+	}
+	if failover.TimeoutSeconds < 0 {
+		return errors.Errorf("invalid timeout %d", failover.TimeoutSeconds)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateMgrRoleLabels(t *testing.T) {
+	activeName := "a"
+	available := true
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "status" {
+				return fmt.Sprintf(`{"mgrmap":{"available":%t,"active_name":"%s"}}`, available, activeName), nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(1)}
+	c := &Cluster{Namespace: "ns"}
+	for _, id := range []string{"a", "b"} {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph-mgr-" + id,
+			Namespace: "ns",
+			Labels:    c.getPodLabels(id),
+		}}
+		_, err := context.Clientset.CoreV1().Pods("ns").Create(pod)
+		require.NoError(t, err)
+	}
+	// pods of other apps are not labeled
+	other := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns", Labels: map[string]string{"app": "other", "mgr": "a"}}}
+	_, err := context.Clientset.CoreV1().Pods("ns").Create(other)
+	require.NoError(t, err)
+
+	roles := func() map[string]string {
+		pods, err := context.Clientset.CoreV1().Pods("ns").List(metav1.ListOptions{})
+		require.NoError(t, err)
+		result := map[string]string{}
+		for _, pod := range pods.Items {
+			result[pod.Name] = pod.Labels[mgrRoleLabel]
+		}
+		return result
+	}
+
+	// the active mgr is labeled
+	assert.NoError(t, updateMgrRoleLabels(context, "ns"))
+	assert.Equal(t, map[string]string{"rook-ceph-mgr-a": "active", "rook-ceph-mgr-b": "standby", "other": ""}, roles())
+
+	// the labels follow a failover
+	activeName = "b"
+	assert.NoError(t, updateMgrRoleLabels(context, "ns"))
+	assert.Equal(t, map[string]string{"rook-ceph-mgr-a": "standby", "rook-ceph-mgr-b": "active", "other": ""}, roles())
+
+	// all the mgrs are standby if none is available
+	available = false
+	assert.NoError(t, updateMgrRoleLabels(context, "ns"))
+	assert.Equal(t, map[string]string{"rook-ceph-mgr-a": "standby", "rook-ceph-mgr-b": "standby", "other": ""}, roles())
+}
+
+func TestActiveMgrFailover(t *testing.T) {
+	defer func(probe func(string, time.Duration) error) { probeMetrics = probe }(probeMetrics)
+	responding := true
+	probed := []string{}
+	probeMetrics = func(url string, timeout time.Duration) error {
+		probed = append(probed, url)
+		assert.Equal(t, 5*time.Second, timeout)
+		if !responding {
+			return errors.New("timeout")
+		}
+		return nil
+	}
+	failed := []string{}
+	activeName := "a"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			switch args[0] {
+			case "status":
+				return fmt.Sprintf(`{"mgrmap":{"available":true,"active_name":"%s"}}`, activeName), nil
+			case "mgr":
+				if args[1] == "fail" {
+					failed = append(failed, args[2])
+				}
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(1)}
+	c := &Cluster{Namespace: "ns"}
+	for i, id := range []string{"a", "b"} {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mgr-" + id, Namespace: "ns", Labels: c.getPodLabels(id)},
+			Status:     v1.PodStatus{PodIP: fmt.Sprintf("10.0.0.%d", i+1)},
+		}
+		_, err := context.Clientset.CoreV1().Pods("ns").Create(pod)
+		require.NoError(t, err)
+	}
+	spec := &cephv1.ClusterSpec{}
+	checker := NewActiveMgrChecker(context, "ns", func() *cephv1.ClusterSpec { return spec })
+
+	// nothing is checked if the failover is disabled
+	assert.NoError(t, checker.checkActiveMgrResponding())
+	assert.Empty(t, probed)
+
+	// the metrics endpoint of the active mgr is probed
+	spec.Mgr.ActiveFailover = cephv1.MgrFailoverSpec{Enabled: true, FailureThreshold: 2}
+	assert.NoError(t, checker.checkActiveMgrResponding())
+	assert.Equal(t, []string{"http://10.0.0.1:9283/"}, probed)
+
+	// the mgr is failed over after the consecutive failures reach the threshold
+	responding = false
+	assert.NoError(t, checker.checkActiveMgrResponding())
+	assert.Empty(t, failed)
+	assert.NoError(t, checker.checkActiveMgrResponding())
+	assert.Equal(t, []string{"a"}, failed)
+	_, err := context.Clientset.CoreV1().Pods("ns").Get("rook-ceph-mgr-a", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = context.Clientset.CoreV1().Pods("ns").Get("rook-ceph-mgr-b", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// a successful probe of the new active mgr resets the failures
+	checker.failingMgr, checker.failures = "a", 1
+	activeName = "b"
+	responding = true
+	assert.NoError(t, checker.checkActiveMgrResponding())
+	assert.Equal(t, "http://10.0.0.2:9283/", probed[len(probed)-1])
+	assert.Equal(t, 0, checker.failures)
+
+	// invalid thresholds
+	assert.NoError(t, validateActiveFailover(spec.Mgr.ActiveFailover))
+	assert.Error(t, validateActiveFailover(cephv1.MgrFailoverSpec{FailureThreshold: -1}))
+	assert.Error(t, validateActiveFailover(cephv1.MgrFailoverSpec{TimeoutSeconds: -1}))
+
+	// the checker sees the spec of an updated cluster
+	probes := len(probed)
+	spec = &cephv1.ClusterSpec{}
+	assert.NoError(t, checker.checkActiveMgrResponding())
+	assert.Equal(t, probes, len(probed))
+}
@@ -19,10 +19,12 @@ package mgr
 import (
 	"fmt"
 
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	apps "k8s.io/api/apps/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -30,10 +32,14 @@ const (
 	keyringTemplate = `
 [mgr.%s]
 	key = %s
-	caps mon = "allow *"
-	caps mds = "allow *"
-	caps osd = "allow *"
+	caps mon = "%s"
+	caps mds = "%s"
+	caps osd = "%s"
 `
+	// the caps recommended for the mgr by the ceph docs
+	monCaps = "allow profile mgr"
+	mdsCaps = "allow *"
+	osdCaps = "allow *"
 )
 
 // mgrConfig for a single mgr
@@ -56,29 +62,79 @@ func (c *Cluster) dashboardPort() int {
 	return c.dashboard.Port
 }
 
+func (c *Cluster) metricsPort() int {
+	return getMetricsPort(c.mgrSpec)
+}
+
+func getMetricsPort(mgrSpec cephv1.MgrSpec) int {
+	if mgrSpec.PrometheusModule.Port == 0 {
+		return defaultMetricsPort
+	}
+	return mgrSpec.PrometheusModule.Port
+}
+
 func (c *Cluster) generateKeyring(m *mgrConfig) (string, error) {
 	user := fmt.Sprintf("mgr.%s", m.DaemonID)
-	/* TODO: the access string here does not match the access from the keyring template. should they match? */
-	access := []string{"mon", "allow *", "mds", "allow *", "osd", "allow *"}
+	access := []string{"mon", monCaps, "mds", mdsCaps, "osd", osdCaps}
 	s := keyring.GetSecretStore(c.context, c.Namespace, &c.ownerRef)
 
-	key, err := s.GenerateKey(user, access)
+	key, generation, err := c.generateKey(s, user, access, m.ResourceName)
 	if err != nil {
 		return "", err
 	}
+	// the key of an existing user is returned even if the caps are different
+	if err := s.VerifyCaps(user, access, c.capsMismatchPolicy()); err != nil {
+		logger.Warningf("failed to verify the caps of %q. %v", user, err)
+	}
 
 	// Delete legacy key store for upgrade from Rook v0.9.x to v1.0.x
 	err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Delete(m.ResourceName, &metav1.DeleteOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
+		if kerrors.IsNotFound(err) {
 			logger.Debugf("legacy mgr key %q is already removed", m.ResourceName)
 		} else {
 			logger.Warningf("legacy mgr key %q could not be removed. %v", m.ResourceName, err)
 		}
 	}
 
-	keyring := fmt.Sprintf(keyringTemplate, m.DaemonID, key)
-	return keyring, s.CreateOrUpdate(m.ResourceName, keyring)
+	keyring := fmt.Sprintf(keyringTemplate, m.DaemonID, key, monCaps, mdsCaps, osdCaps)
+	return keyring, s.CreateOrUpdateWithGeneration(m.ResourceName, keyring, generation)
+}
+
+// generateKey returns the key of the mgr and the generation of the key. The key is rotated if the key
+// generation in the mgr spec is greater than the generation of the key stored in the keyring secret.
+func (c *Cluster) generateKey(s *keyring.SecretStore, user string, access []string, resourceName string) (string, int, error) {
+	desired := c.mgrSpec.KeyGeneration
+	stored, exists, err := s.KeyGeneration(resourceName)
+	if err != nil {
+		return "", 0, err
+	}
+	if !exists {
+		// a new key is already at the desired generation
+		key, err := s.GenerateKey(user, access)
+		return key, desired, err
+	}
+
+	if desired > stored {
+		logger.Infof("key generation of %q increased from %d to %d", user, stored, desired)
+		key, err := s.RotateKey(user, access)
+		if err != nil {
+			return "", 0, errors.Wrapf(err, "failed to rotate the key of %q", user)
+		}
+		return key, desired, nil
+	}
+	if desired < stored {
+		logger.Warningf("key generation %d of %q is lower than the current generation %d. keeping the current key", desired, user, stored)
+	}
+	key, err := s.GenerateKey(user, access)
+	return key, stored, err
+}
+
+func (c *Cluster) capsMismatchPolicy() keyring.CapsMismatchPolicy {
+	if c.mgrSpec.CapsMismatchPolicy == "" {
+		return keyring.CapsMismatchUpdate
+	}
+	return keyring.CapsMismatchPolicy(c.mgrSpec.CapsMismatchPolicy)
 }
 
 func (c *Cluster) associateKeyring(existingKeyring string, d *apps.Deployment) error {
@@ -19,19 +19,25 @@ package mgr
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"os"
 	"os/exec"
+	"reflect"
 	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -39,6 +45,7 @@ const (
 	dashboardPortHTTPS             = 8443
 	dashboardPortHTTP              = 7000
 	dashboardUsername              = "admin"
+	dashboardAdminRole             = "administrator"
 	dashboardPasswordName          = "rook-ceph-dashboard-password"
 	passwordLength                 = 10
 	passwordKeyName                = "password"
@@ -46,8 +53,33 @@ const (
 	invalidArgErrorCode            = int(syscall.EINVAL)
 )
 
+const (
+	// DashboardCredentialsName is the name of the secret where the dashboard credentials are published
+	DashboardCredentialsName = "rook-ceph-dashboard-credentials"
+	// DashboardCredentialsUsernameKey is the key of the dashboard username in the published credentials secret
+	DashboardCredentialsUsernameKey = "username"
+	// DashboardCredentialsPasswordKey is the key of the dashboard password in the published credentials secret
+	DashboardCredentialsPasswordKey = "password"
+	// DashboardUserSecretPrefix is the prefix of the name of the secrets with the credentials of the dashboard users
+	DashboardUserSecretPrefix = "rook-ceph-dashboard-user-"
+	// dashboardUserLabel is set on the secrets of the dashboard users with the name of the user
+	dashboardUserLabel = "rook-ceph-dashboard-user"
+)
+
 var (
 	dashboardInitWaitTime = 5 * time.Second
+
+	// the first releases that read the dashboard passwords from a file given with -i
+	passwordFileNautilus = cephver.CephVersion{Major: 14, Minor: 2, Extra: 12}
+	passwordFileOctopus  = cephver.CephVersion{Major: 15, Minor: 2, Extra: 5}
+
+	// dashboardFeatureApps maps the dashboard features to the app of the daemons that provide them
+	dashboardFeatureApps = map[string]string{
+		"rgw":       "rook-ceph-rgw",
+		"cephfs":    "rook-ceph-mds",
+		"nfs":       "rook-ceph-nfs",
+		"mirroring": "rook-ceph-rbd-mirror",
+	}
 )
 
 func init() {
@@ -67,9 +99,18 @@ func (c *Cluster) configureDashboardService() error {
 			if err != nil {
 				return errors.Wrapf(err, "failed to get dashboard service")
 			}
-			if original.Spec.Ports[0].Port != int32(c.dashboardPort()) {
-				logger.Infof("dashboard port changed. updating service")
-				original.Spec.Ports[0].Port = int32(c.dashboardPort())
+			// the cluster IP is immutable, the update must keep the IP already assigned to the service
+			warnIfClusterIPChanged(original, dashboardService.Spec.ClusterIP)
+			// the port and its name change with the port and ssl settings
+			desiredPort := dashboardService.Spec.Ports[0]
+			metadataChanged := syncServiceMetadata(original, dashboardService)
+			selectorChanged := !reflect.DeepEqual(original.Spec.Selector, dashboardService.Spec.Selector)
+			typeChanged := syncDashboardServiceType(original, dashboardService)
+			if original.Spec.Ports[0].Port != desiredPort.Port || original.Spec.Ports[0].Name != desiredPort.Name || metadataChanged || selectorChanged || typeChanged {
+				logger.Infof("dashboard service changed. updating service")
+				original.Spec.Selector = dashboardService.Spec.Selector
+				original.Spec.Ports[0].Port = desiredPort.Port
+				original.Spec.Ports[0].Name = desiredPort.Name
 				if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Update(original); err != nil {
 					return errors.Wrapf(err, "failed to update dashboard mgr service")
 				}
@@ -88,6 +129,65 @@ func (c *Cluster) configureDashboardService() error {
 	return nil
 }
 
+// syncDashboardServiceType updates the type of the existing dashboard service and the settings that depend
+// on the type. It returns whether the service changed. A node port allocated by Kubernetes is kept unless
+// another port is requested or the service is changed to ClusterIP.
+func syncDashboardServiceType(existing, desired *v1.Service) bool {
+	changed := false
+	if existing.Spec.Type != desired.Spec.Type {
+		existing.Spec.Type = desired.Spec.Type
+		changed = true
+	}
+	nodePort := existing.Spec.Ports[0].NodePort
+	if desired.Spec.Type == v1.ServiceTypeClusterIP {
+		nodePort = 0
+	} else if desired.Spec.Ports[0].NodePort != 0 {
+		nodePort = desired.Spec.Ports[0].NodePort
+	}
+	if existing.Spec.Ports[0].NodePort != nodePort {
+		existing.Spec.Ports[0].NodePort = nodePort
+		changed = true
+	}
+	if !reflect.DeepEqual(existing.Spec.LoadBalancerSourceRanges, desired.Spec.LoadBalancerSourceRanges) {
+		existing.Spec.LoadBalancerSourceRanges = desired.Spec.LoadBalancerSourceRanges
+		changed = true
+	}
+	return changed
+}
+
+// configureDashboardIngress creates or updates the ingress for the dashboard service, or deletes it
+// when the ingress or the dashboard is disabled
+func (c *Cluster) configureDashboardIngress() error {
+	ingress := c.makeDashboardIngress(c.makeDashboardService(AppName))
+	ingresses := c.context.Clientset.NetworkingV1beta1().Ingresses(c.Namespace)
+	if !c.dashboard.Enabled || !c.dashboard.Ingress.Enabled {
+		err := ingresses.Delete(ingress.Name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete dashboard ingress")
+		}
+		return nil
+	}
+
+	if _, err := ingresses.Create(ingress); err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create dashboard ingress")
+		}
+		existing, err := ingresses.Get(ingress.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get dashboard ingress")
+		}
+		existing.Annotations = ingress.Annotations
+		existing.Spec = ingress.Spec
+		if _, err := ingresses.Update(existing); err != nil {
+			return errors.Wrapf(err, "failed to update dashboard ingress")
+		}
+		logger.Infof("dashboard ingress updated")
+		return nil
+	}
+	logger.Infof("dashboard ingress created")
+	return nil
+}
+
 // Ceph docs about the dashboard module: http://docs.ceph.com/docs/nautilus/mgr/dashboard/
 func (c *Cluster) configureDashboardModules() error {
 	if c.dashboard.Enabled {
@@ -98,6 +198,9 @@ func (c *Cluster) configureDashboardModules() error {
 		if err := client.MgrDisableModule(c.context, c.Namespace, dashboardModuleName); err != nil {
 			logger.Errorf("failed to disable mgr dashboard module. %v", err)
 		}
+		if err := c.unpublishDashboardCredentials(); err != nil {
+			logger.Errorf("failed to remove the published dashboard credentials. %v", err)
+		}
 		return nil
 	}
 
@@ -106,6 +209,14 @@ func (c *Cluster) configureDashboardModules() error {
 		return errors.Wrapf(err, "failed to initialize dashboard")
 	}
 
+	if err := c.reconcilePublishedCredentials(); err != nil {
+		return errors.Wrapf(err, "failed to publish dashboard credentials")
+	}
+
+	if err := c.reconcileDashboardUsers(); err != nil {
+		return errors.Wrapf(err, "failed to configure the dashboard users")
+	}
+
 	for _, daemonID := range c.getDaemonIDs() {
 		changed, err := c.configureDashboardModuleSettings(daemonID)
 		if err != nil {
@@ -115,6 +226,10 @@ func (c *Cluster) configureDashboardModules() error {
 			hasChanged = true
 		}
 	}
+	if err := c.configureDashboardFeatures(); err != nil {
+		logger.Errorf("failed to configure dashboard features. %v", err)
+	}
+
 	if hasChanged {
 		logger.Infof("dashboard config has changed. restarting the dashboard module.")
 		return c.restartDashboard()
@@ -159,7 +274,61 @@ func (c *Cluster) configureDashboardModuleSettings(daemonID string) (bool, error
 	return hasChanged, nil
 }
 
+// getDashboardFeatures returns the dashboard features that must be enabled or disabled. Features are detected
+// from the daemons running in the cluster if requested, then overridden by the features from the spec.
+func (c *Cluster) getDashboardFeatures() (map[string]bool, error) {
+	features := map[string]bool{}
+	if c.dashboard.AutoDetectFeatures {
+		for feature, app := range dashboardFeatureApps {
+			selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, app)
+			deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list %q deployments", app)
+			}
+			features[feature] = len(deployments.Items) > 0
+		}
+	}
+	for feature, enabled := range c.dashboard.Features {
+		features[feature] = enabled
+	}
+	return features, nil
+}
+
+// Ceph docs about the dashboard features: https://docs.ceph.com/docs/octopus/mgr/dashboard/#enabling-or-disabling-features
+func (c *Cluster) configureDashboardFeatures() error {
+	if !c.dashboard.AutoDetectFeatures && len(c.dashboard.Features) == 0 {
+		return nil
+	}
+	if !c.clusterInfo.CephVersion.IsAtLeastOctopus() {
+		logger.Infof("skipping dashboard features configuration on releases older than octopus")
+		return nil
+	}
+
+	features, err := c.getDashboardFeatures()
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine the dashboard features")
+	}
+	for feature, enabled := range features {
+		action := "disable"
+		if enabled {
+			action = "enable"
+		}
+		args := []string{"dashboard", "feature", action, feature}
+		if _, err := client.NewCephCommand(c.context, c.Namespace, args).Run(); err != nil {
+			return errors.Wrapf(err, "failed to %s dashboard feature %q", action, feature)
+		}
+		logger.Infof("dashboard feature %q %sd", feature, action)
+	}
+	return nil
+}
+
 func (c *Cluster) initializeSecureDashboard() (bool, error) {
+	if !c.clusterInfo.CephVersion.IsAtLeastMimic() {
+		// the luminous dashboard is read-only and has neither ssl nor a login
+		logger.Infof("skipping the dashboard ssl and login configuration on releases older than mimic")
+		return false, nil
+	}
+
 	// we need to wait a short period after enabling the module before we can call the `ceph dashboard` commands.
 	time.Sleep(dashboardInitWaitTime)
 
@@ -169,12 +338,22 @@ func (c *Cluster) initializeSecureDashboard() (bool, error) {
 	}
 
 	if c.dashboard.SSL {
-		alreadyCreated, err := c.createSelfSignedCert()
-		if err != nil {
-			return false, errors.Wrapf(err, "failed to create a self signed cert for the ceph dashboard")
-		}
-		if alreadyCreated {
-			return false, nil
+		if c.dashboard.CertificateSecretName != "" {
+			changed, err := c.configureDashboardCert()
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to configure the certificate for the ceph dashboard")
+			}
+			if !changed {
+				return false, nil
+			}
+		} else {
+			alreadyCreated, err := c.createSelfSignedCert()
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to create a self signed cert for the ceph dashboard")
+			}
+			if alreadyCreated {
+				return false, nil
+			}
 		}
 	}
 
@@ -185,6 +364,53 @@ func (c *Cluster) initializeSecureDashboard() (bool, error) {
 	return true, nil
 }
 
+// configureDashboardCert stores the certificate and key from the certificate secret where the dashboard
+// loads them. It returns whether the certificate or key has changed.
+func (c *Cluster) configureDashboardCert() (bool, error) {
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(c.dashboard.CertificateSecretName, metav1.GetOptions{})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get dashboard certificate secret %q", c.dashboard.CertificateSecretName)
+	}
+	cert, ok := secret.Data[v1.TLSCertKey]
+	if !ok || len(cert) == 0 {
+		return false, errors.Errorf("dashboard certificate secret %q has no %q", secret.Name, v1.TLSCertKey)
+	}
+	key, ok := secret.Data[v1.TLSPrivateKeyKey]
+	if !ok || len(key) == 0 {
+		return false, errors.Errorf("dashboard certificate secret %q has no %q", secret.Name, v1.TLSPrivateKeyKey)
+	}
+
+	certChanged, err := c.setDashboardConfigKey("mgr/dashboard/crt", string(cert))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to set the dashboard certificate")
+	}
+	keyChanged, err := c.setDashboardConfigKey("mgr/dashboard/key", string(key))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to set the dashboard certificate key")
+	}
+	if certChanged || keyChanged {
+		logger.Infof("dashboard certificate from secret %q configured", secret.Name)
+		return true, nil
+	}
+	return false, nil
+}
+
+// setDashboardConfigKey stores a value in the config-key store where the dashboard loads it. The value may be the
+// private key of the certificate, so it is never passed on the command line. It returns whether the value has changed.
+func (c *Cluster) setDashboardConfigKey(key, val string) (bool, error) {
+	prevVal, found, err := client.GetConfigKey(c.context, c.Namespace, key)
+	if err != nil {
+		return false, err
+	}
+	if found && prevVal == val {
+		return false, nil
+	}
+	if err := client.SetConfigKey(c.context, c.Namespace, key, val); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (c *Cluster) createSelfSignedCert() (bool, error) {
 	// create a self-signed cert for the https connections required in mimic
 	args := []string{"dashboard", "create-self-signed-cert"}
@@ -227,11 +453,21 @@ func getExitCode(err error) (int, bool) {
 }
 
 func (c *Cluster) setLoginCredentials(password string) error {
+	if c.clusterInfo.CephVersion.IsAtLeastOctopus() {
+		// set-login-credentials is deprecated in octopus. the admin user is managed like the other users.
+		admin := cephv1.DashboardUserSpec{Name: dashboardUsername, Roles: []string{dashboardAdminRole}}
+		return c.configureDashboardUser(admin, password, true)
+	}
+
 	// Set the login credentials. Write the command/args to the debug log so we don't write the password by default to the log.
-	logger.Infof("Running command: ceph dashboard set-login-credentials admin *******")
+	logger.Infof("setting the dashboard login credentials of user %q", dashboardUsername)
 	// retry a few times in the case that the mgr module is not ready to accept commands
 	_, err := client.ExecuteCephCommandWithRetry(func() ([]byte, error) {
-		args := []string{"dashboard", "set-login-credentials", dashboardUsername, password}
+		args, cleanup, err := c.dashboardPasswordArgs([]string{"dashboard", "set-login-credentials", dashboardUsername}, password)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
 		cmd := client.NewCephCommand(c.context, c.Namespace, args)
 		cmd.Debug = true
 		return cmd.RunWithTimeout(client.CmdExecuteTimeout)
@@ -246,6 +482,9 @@ func (c *Cluster) getOrGenerateDashboardPassword() (string, error) {
 	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(dashboardPasswordName, metav1.GetOptions{})
 	if err == nil {
 		logger.Infof("the dashboard secret was already generated")
+		if err := c.addDashboardUsername(secret); err != nil {
+			logger.Warningf("failed to add the username to the dashboard secret. %v", err)
+		}
 		return decodeSecret(secret)
 	}
 	if !kerrors.IsNotFound(err) {
@@ -257,7 +496,8 @@ func (c *Cluster) getOrGenerateDashboardPassword() (string, error) {
 
 	// Store the keyring in a secret
 	secrets := map[string][]byte{
-		passwordKeyName: []byte(password),
+		DashboardCredentialsUsernameKey: []byte(dashboardUsername),
+		passwordKeyName:                 []byte(password),
 	}
 	secret = &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -276,6 +516,236 @@ func (c *Cluster) getOrGenerateDashboardPassword() (string, error) {
 	return password, nil
 }
 
+// addDashboardUsername adds the username to a dashboard secret generated before the username was stored with the password
+func (c *Cluster) addDashboardUsername(secret *v1.Secret) error {
+	if _, ok := secret.Data[DashboardCredentialsUsernameKey]; ok {
+		return nil
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[DashboardCredentialsUsernameKey] = []byte(dashboardUsername)
+	if _, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(secret); err != nil {
+		return errors.Wrapf(err, "failed to update dashboard secret")
+	}
+	return nil
+}
+
+// reconcilePublishedCredentials keeps the published credentials secret in sync with the dashboard login settings
+func (c *Cluster) reconcilePublishedCredentials() error {
+	if !c.dashboard.PublishCredentials {
+		return c.unpublishDashboardCredentials()
+	}
+
+	password, err := c.getOrGenerateDashboardPassword()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the dashboard password")
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DashboardCredentialsName,
+			Namespace: c.Namespace,
+		},
+		Data: map[string][]byte{
+			DashboardCredentialsUsernameKey: []byte(dashboardUsername),
+			DashboardCredentialsPasswordKey: []byte(password),
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(&secret.ObjectMeta, &c.ownerRef)
+
+	_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Create(secret)
+	if err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create secret %q", DashboardCredentialsName)
+		}
+		if _, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(secret); err != nil {
+			return errors.Wrapf(err, "failed to update secret %q", DashboardCredentialsName)
+		}
+	}
+	logger.Infof("dashboard credentials published in secret %q", DashboardCredentialsName)
+	return nil
+}
+
+func (c *Cluster) unpublishDashboardCredentials() error {
+	err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Delete(DashboardCredentialsName, &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete secret %q", DashboardCredentialsName)
+	}
+	return nil
+}
+
+// validateDashboardUsers checks that the dashboard users have a unique name that can be used in the name of
+// their secret, and at least one role
+func (c *Cluster) validateDashboardUsers() error {
+	names := map[string]bool{}
+	for _, user := range c.dashboard.Users {
+		if user.Name == dashboardUsername {
+			return errors.Errorf("dashboard user %q is reserved for the operator", user.Name)
+		}
+		if errs := validation.IsDNS1123Label(user.Name); len(errs) > 0 {
+			return errors.Errorf("invalid dashboard user name %q. %v", user.Name, errs)
+		}
+		if names[user.Name] {
+			return errors.Errorf("dashboard user %q is defined more than once", user.Name)
+		}
+		names[user.Name] = true
+		if len(user.Roles) == 0 {
+			return errors.Errorf("dashboard user %q has no roles", user.Name)
+		}
+	}
+	return nil
+}
+
+// reconcileDashboardUsers creates the dashboard users of the spec with their roles, and deletes the users that
+// were created by the operator but are no longer in the spec
+func (c *Cluster) reconcileDashboardUsers() error {
+	desired := map[string]bool{}
+	for _, user := range c.dashboard.Users {
+		desired[user.Name] = true
+		password, generated, err := c.getOrGenerateDashboardUserPassword(user.Name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the password of dashboard user %q", user.Name)
+		}
+		if err := c.configureDashboardUser(user, password, generated); err != nil {
+			return errors.Wrapf(err, "failed to configure dashboard user %q", user.Name)
+		}
+	}
+
+	secrets, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).List(metav1.ListOptions{LabelSelector: dashboardUserLabel})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the secrets of the dashboard users")
+	}
+	for _, secret := range secrets.Items {
+		name := secret.Labels[dashboardUserLabel]
+		if desired[name] {
+			continue
+		}
+		logger.Infof("removing dashboard user %q", name)
+		args := []string{"dashboard", "ac-user-delete", name}
+		if _, err := client.NewCephCommand(c.context, c.Namespace, args).Run(); err != nil {
+			if code, ok := c.exitCode(err); !ok || code != int(syscall.ENOENT) {
+				return errors.Wrapf(err, "failed to delete dashboard user %q", name)
+			}
+		}
+		if err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Delete(secret.Name, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete secret %q", secret.Name)
+		}
+	}
+	return nil
+}
+
+// configureDashboardUser creates the dashboard user if it does not exist and sets its roles. The password of an
+// existing user is only reset if requested, e.g. when a new password was generated after its secret was deleted.
+func (c *Cluster) configureDashboardUser(user cephv1.DashboardUserSpec, password string, resetPassword bool) error {
+	var passwordArgs []string
+	args := []string{"dashboard", "ac-user-show", user.Name}
+	if _, err := client.NewCephCommand(c.context, c.Namespace, args).Run(); err != nil {
+		if code, ok := c.exitCode(err); !ok || code != int(syscall.ENOENT) {
+			return errors.Wrapf(err, "failed to get dashboard user")
+		}
+		passwordArgs = []string{"dashboard", "ac-user-create", user.Name}
+	} else if resetPassword {
+		passwordArgs = []string{"dashboard", "ac-user-set-password", user.Name}
+	}
+	if passwordArgs != nil {
+		logger.Infof("setting the password of dashboard user %q", user.Name)
+		args, cleanup, err := c.dashboardPasswordArgs(passwordArgs, password)
+		if err != nil {
+			return err
+		}
+		cmd := client.NewCephCommand(c.context, c.Namespace, args)
+		cmd.Debug = true
+		_, err = cmd.Run()
+		cleanup()
+		if err != nil {
+			return errors.Wrapf(err, "failed to set the password of the dashboard user")
+		}
+	}
+
+	args = append([]string{"dashboard", "ac-user-set-roles", user.Name}, user.Roles...)
+	if _, err := client.NewCephCommand(c.context, c.Namespace, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to set the roles of the dashboard user")
+	}
+	return nil
+}
+
+// dashboardPasswordArgs adds the password to the args of a dashboard command. If the ceph version reads the
+// password from a file, the password is written to a temp file given with -i so that it is not visible on the
+// command line. The returned func removes the temp file after the command ran.
+func (c *Cluster) dashboardPasswordArgs(args []string, password string) ([]string, func(), error) {
+	if !c.readsDashboardPasswordFile() {
+		return append(args, password), func() {}, nil
+	}
+	file, err := ioutil.TempFile("", "dashboard-password")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to create the dashboard password file")
+	}
+	cleanup := func() {
+		if err := os.Remove(file.Name()); err != nil {
+			logger.Warningf("failed to remove the dashboard password file. %v", err)
+		}
+	}
+	_, err = file.WriteString(password)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, errors.Wrapf(err, "failed to write the dashboard password file")
+	}
+	return append(args, "-i", file.Name()), cleanup, nil
+}
+
+// readsDashboardPasswordFile returns whether the dashboard commands of the ceph version read the password
+// from a file. Older releases only accept the password on the command line.
+func (c *Cluster) readsDashboardPasswordFile() bool {
+	version := c.clusterInfo.CephVersion
+	if version.IsAtLeastPacific() {
+		return true
+	}
+	if version.IsAtLeastOctopus() {
+		return version.IsAtLeast(passwordFileOctopus)
+	}
+	return version.IsAtLeast(passwordFileNautilus)
+}
+
+// getOrGenerateDashboardUserPassword returns the password stored in the secret of the dashboard user. If the
+// secret does not exist, a password is generated and stored in a new secret.
+func (c *Cluster) getOrGenerateDashboardUserPassword(name string) (string, bool, error) {
+	secretName := DashboardUserSecretPrefix + name
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(secretName, metav1.GetOptions{})
+	if err == nil {
+		password, err := decodeSecret(secret)
+		return password, false, err
+	}
+	if !kerrors.IsNotFound(err) {
+		return "", false, errors.Wrapf(err, "failed to get secret %q", secretName)
+	}
+
+	password := generatePassword(passwordLength)
+	secret = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: c.Namespace,
+			Labels:    map[string]string{dashboardUserLabel: name},
+		},
+		Data: map[string][]byte{
+			DashboardCredentialsUsernameKey: []byte(name),
+			DashboardCredentialsPasswordKey: []byte(password),
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(&secret.ObjectMeta, &c.ownerRef)
+
+	if _, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Create(secret); err != nil {
+		return "", false, errors.Wrapf(err, "failed to save secret %q", secretName)
+	}
+	logger.Infof("generated the password of dashboard user %q in secret %q", name, secretName)
+	return password, true, nil
+}
+
 func generatePassword(length int) string {
 	const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	passwd := make([]byte, length)
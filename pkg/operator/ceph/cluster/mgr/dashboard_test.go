@@ -16,6 +16,11 @@ limitations under the License.
 package mgr
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -28,6 +33,8 @@ import (
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -57,7 +64,8 @@ func TestGetOrGeneratePassword(t *testing.T) {
 
 	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(dashboardPasswordName, metav1.GetOptions{})
 	assert.Nil(t, err)
-	assert.Equal(t, 1, len(secret.Data))
+	assert.Equal(t, 2, len(secret.Data))
+	assert.Equal(t, dashboardUsername, string(secret.Data[DashboardCredentialsUsernameKey]))
 	passwordFromSecret, err := decodeSecret(secret)
 	assert.Equal(t, password, passwordFromSecret)
 
@@ -65,6 +73,17 @@ func TestGetOrGeneratePassword(t *testing.T) {
 	retrievedPassword, err := c.getOrGenerateDashboardPassword()
 	assert.Nil(t, err)
 	assert.Equal(t, password, retrievedPassword)
+
+	// The username is added to a secret that only has the password
+	delete(secret.Data, DashboardCredentialsUsernameKey)
+	_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(secret)
+	require.Nil(t, err)
+	retrievedPassword, err = c.getOrGenerateDashboardPassword()
+	assert.Nil(t, err)
+	assert.Equal(t, password, retrievedPassword)
+	secret, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(dashboardPasswordName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, dashboardUsername, string(secret.Data[DashboardCredentialsUsernameKey]))
 }
 
 func TestStartSecureDashboard(t *testing.T) {
@@ -139,3 +158,530 @@ func TestStartSecureDashboard(t *testing.T) {
 	assert.True(t, kerrors.IsNotFound(err))
 	assert.Nil(t, svc)
 }
+
+func TestDashboardCertificateSecret(t *testing.T) {
+	configKeys := map[string]string{}
+	configKeySets := 0
+	selfSignedCerts := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("command: %s %v", command, args)
+			// the private key of the certificate must never be on the command line where it would be logged
+			for _, arg := range args {
+				assert.NotContains(t, arg, "mykey")
+			}
+			if args[0] == "config-key" && args[1] == "dump" {
+				assert.True(t, debug)
+				keys := map[string]string{}
+				if val, ok := configKeys[args[2]]; ok {
+					keys[args[2]] = val
+				}
+				output, _ := json.Marshal(keys)
+				return string(output), nil
+			}
+			if args[0] == "config-key" && args[1] == "set" {
+				assert.True(t, debug)
+				require.Equal(t, "-i", args[3])
+				val, err := ioutil.ReadFile(args[4])
+				require.NoError(t, err)
+				configKeySets++
+				configKeys[args[2]] = string(val)
+			}
+			if args[0] == "dashboard" && args[1] == "create-self-signed-cert" {
+				selfSignedCerts++
+			}
+			return "", nil
+		},
+	}
+	executor.MockExecuteCommandWithOutputFileTimeout = func(debug bool, timeout time.Duration, actionName string, command, outfileArg string, arg ...string) (string, error) {
+		return executor.MockExecuteCommandWithOutputFile(debug, actionName, command, outfileArg, arg...)
+	}
+	c := &Cluster{clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}, context: &clusterd.Context{Clientset: test.New(3), Executor: executor},
+		Namespace: "myns", exitCode: getExitCode,
+		dashboard: cephv1.DashboardSpec{Enabled: true, SSL: true, CertificateSecretName: "dashboard-tls"}}
+	dashboardInitWaitTime = 0
+
+	// the certificate secret does not exist
+	assert.Error(t, c.configureDashboardModules())
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard-tls", Namespace: c.Namespace},
+		Data:       map[string][]byte{v1.TLSCertKey: []byte("mycert"), v1.TLSPrivateKeyKey: []byte("mykey")},
+		Type:       v1.SecretTypeTLS,
+	}
+	_, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Create(secret)
+	require.NoError(t, err)
+
+	// the certificate from the secret is used instead of a self-signed cert
+	assert.NoError(t, c.configureDashboardModules())
+	assert.Equal(t, map[string]string{"mgr/dashboard/crt": "mycert", "mgr/dashboard/key": "mykey"}, configKeys)
+	assert.Equal(t, 2, configKeySets)
+	assert.Equal(t, 0, selfSignedCerts)
+
+	// the certificate is not set again if it has not changed
+	assert.NoError(t, c.configureDashboardModules())
+	assert.Equal(t, 2, configKeySets)
+
+	// a renewed certificate is applied
+	secret.Data[v1.TLSCertKey] = []byte("renewedcert")
+	_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(secret)
+	require.NoError(t, err)
+	assert.NoError(t, c.configureDashboardModules())
+	assert.Equal(t, "renewedcert", configKeys["mgr/dashboard/crt"])
+	assert.Equal(t, 3, configKeySets)
+
+	// the secret must contain the key
+	delete(secret.Data, v1.TLSPrivateKeyKey)
+	_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(secret)
+	require.NoError(t, err)
+	assert.Error(t, c.configureDashboardModules())
+}
+
+func TestDashboardServiceClusterIP(t *testing.T) {
+	c := &Cluster{context: &clusterd.Context{Clientset: test.New(3)}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true, ClusterIP: "10.0.0.11"}}
+
+	err := c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.11", svc.Spec.ClusterIP)
+
+	// changing the port updates the service, but the immutable cluster IP is kept
+	c.dashboard.ClusterIP = "10.0.0.12"
+	c.dashboard.Port = 8080
+	err = c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.11", svc.Spec.ClusterIP)
+	assert.Equal(t, int32(8080), svc.Spec.Ports[0].Port)
+}
+
+func TestDashboardServicePort(t *testing.T) {
+	c := &Cluster{context: &clusterd.Context{Clientset: test.New(3)}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true}}
+
+	err := c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(dashboardPortHTTP), svc.Spec.Ports[0].Port)
+	assert.Equal(t, "dashboard", svc.Spec.Ports[0].Name)
+
+	// enabling ssl updates the default port and the port name
+	c.dashboard.SSL = true
+	err = c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(dashboardPortHTTPS), svc.Spec.Ports[0].Port)
+	assert.Equal(t, "https-dashboard", svc.Spec.Ports[0].Name)
+
+	// the name is updated even if the port is the same
+	c.dashboard.Port = dashboardPortHTTPS
+	c.dashboard.SSL = false
+	err = c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(dashboardPortHTTPS), svc.Spec.Ports[0].Port)
+	assert.Equal(t, "dashboard", svc.Spec.Ports[0].Name)
+
+	// the selector of a service that selects all the mgrs is updated to the active mgr
+	svc.Spec.Selector = map[string]string{"app": AppName, "rook_cluster": "myns"}
+	_, err = c.context.Clientset.CoreV1().Services(c.Namespace).Update(svc)
+	assert.NoError(t, err)
+	err = c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": AppName, "rook_cluster": "myns", "mgr_role": "active"}, svc.Spec.Selector)
+}
+
+func TestDashboardServiceType(t *testing.T) {
+	c := &Cluster{context: &clusterd.Context{Clientset: test.New(3)}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true, ServiceType: "NodePort", NodePort: 30443}}
+	assert.NoError(t, c.validateDashboardService())
+
+	err := c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ServiceTypeNodePort, svc.Spec.Type)
+	assert.Equal(t, int32(30443), svc.Spec.Ports[0].NodePort)
+
+	// the node port allocated to the service is kept if none is requested
+	c.dashboard = cephv1.DashboardSpec{Enabled: true, ServiceType: "LoadBalancer", LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}
+	assert.NoError(t, c.validateDashboardService())
+	err = c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ServiceTypeLoadBalancer, svc.Spec.Type)
+	assert.Equal(t, int32(30443), svc.Spec.Ports[0].NodePort)
+	assert.Equal(t, []string{"10.0.0.0/8"}, svc.Spec.LoadBalancerSourceRanges)
+
+	// the node port and source ranges are removed with the default service type
+	c.dashboard = cephv1.DashboardSpec{Enabled: true}
+	err = c.configureDashboardService()
+	assert.NoError(t, err)
+	svc, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ServiceTypeClusterIP, svc.Spec.Type)
+	assert.Equal(t, int32(0), svc.Spec.Ports[0].NodePort)
+	assert.Nil(t, svc.Spec.LoadBalancerSourceRanges)
+
+	// invalid settings
+	c.dashboard = cephv1.DashboardSpec{ServiceType: "ExternalName"}
+	assert.Error(t, c.validateDashboardService())
+	c.dashboard = cephv1.DashboardSpec{NodePort: 30443}
+	assert.Error(t, c.validateDashboardService())
+	c.dashboard = cephv1.DashboardSpec{ServiceType: "NodePort", LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}
+	assert.Error(t, c.validateDashboardService())
+	c.dashboard = cephv1.DashboardSpec{ServiceType: "LoadBalancer", LoadBalancerSourceRanges: []string{"10.0.0.0"}}
+	assert.Error(t, c.validateDashboardService())
+}
+
+func TestDashboardIngress(t *testing.T) {
+	c := &Cluster{context: &clusterd.Context{Clientset: test.New(3)}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true, SSL: true, UrlPrefix: "/ceph-dashboard", Ingress: cephv1.DashboardIngressSpec{
+			Enabled:       true,
+			Host:          "rook-ceph.example.com",
+			TLSSecretName: "rook-ceph-tls",
+			Annotations:   map[string]string{"kubernetes.io/ingress.class": "nginx"},
+		}}}
+
+	// the ingress is created
+	err := c.configureDashboardIngress()
+	assert.NoError(t, err)
+	ingress, err := c.context.Clientset.NetworkingV1beta1().Ingresses(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", ingress.Annotations["kubernetes.io/ingress.class"])
+	require.Equal(t, 1, len(ingress.Spec.Rules))
+	rule := ingress.Spec.Rules[0]
+	assert.Equal(t, "rook-ceph.example.com", rule.Host)
+	require.Equal(t, 1, len(rule.HTTP.Paths))
+	assert.Equal(t, "/ceph-dashboard", rule.HTTP.Paths[0].Path)
+	assert.Equal(t, "rook-ceph-mgr-dashboard", rule.HTTP.Paths[0].Backend.ServiceName)
+	assert.Equal(t, dashboardPortHTTPS, rule.HTTP.Paths[0].Backend.ServicePort.IntValue())
+	require.Equal(t, 1, len(ingress.Spec.TLS))
+	assert.Equal(t, "rook-ceph-tls", ingress.Spec.TLS[0].SecretName)
+	assert.Equal(t, []string{"rook-ceph.example.com"}, ingress.Spec.TLS[0].Hosts)
+
+	// the ingress is updated
+	c.dashboard.UrlPrefix = ""
+	c.dashboard.Ingress.Host = ""
+	c.dashboard.Ingress.TLSSecretName = ""
+	err = c.configureDashboardIngress()
+	assert.NoError(t, err)
+	ingress, err = c.context.Clientset.NetworkingV1beta1().Ingresses(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "", ingress.Spec.Rules[0].Host)
+	assert.Equal(t, "/", ingress.Spec.Rules[0].HTTP.Paths[0].Path)
+	assert.Equal(t, 0, len(ingress.Spec.TLS))
+
+	// the ingress is removed when it is disabled
+	c.dashboard.Ingress.Enabled = false
+	err = c.configureDashboardIngress()
+	assert.NoError(t, err)
+	_, err = c.context.Clientset.NetworkingV1beta1().Ingresses(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// there is nothing to remove when the dashboard is disabled
+	c.dashboard.Enabled = false
+	c.dashboard.Ingress.Enabled = true
+	err = c.configureDashboardIngress()
+	assert.NoError(t, err)
+}
+
+func TestPublishDashboardCredentials(t *testing.T) {
+	c := &Cluster{context: &clusterd.Context{Clientset: test.New(3)}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true, PublishCredentials: true}}
+
+	err := c.reconcilePublishedCredentials()
+	assert.NoError(t, err)
+	password, err := c.getOrGenerateDashboardPassword()
+	assert.NoError(t, err)
+
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(DashboardCredentialsName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(secret.Data))
+	assert.Equal(t, dashboardUsername, string(secret.Data[DashboardCredentialsUsernameKey]))
+	assert.Equal(t, password, string(secret.Data[DashboardCredentialsPasswordKey]))
+
+	// a new password is synced to the published secret
+	generated, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(dashboardPasswordName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	generated.Data[passwordKeyName] = []byte("newpassword")
+	_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(generated)
+	assert.NoError(t, err)
+	err = c.reconcilePublishedCredentials()
+	assert.NoError(t, err)
+	secret, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(DashboardCredentialsName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "newpassword", string(secret.Data[DashboardCredentialsPasswordKey]))
+
+	// the secret is removed when the credentials are no longer published
+	c.dashboard.PublishCredentials = false
+	err = c.reconcilePublishedCredentials()
+	assert.NoError(t, err)
+	_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(DashboardCredentialsName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestDashboardFeatures(t *testing.T) {
+	enabled := []string{}
+	disabled := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("command: %s %v", command, args)
+			if args[0] == "dashboard" && args[1] == "feature" {
+				if args[2] == "enable" {
+					enabled = append(enabled, args[3])
+				} else if args[2] == "disable" {
+					disabled = append(disabled, args[3])
+				}
+			}
+			return "", nil
+		},
+	}
+	clientset := test.New(3)
+	c := &Cluster{clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Octopus},
+		context: &clusterd.Context{Clientset: clientset, Executor: executor}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true}}
+
+	// nothing to configure by default
+	assert.NoError(t, c.configureDashboardFeatures())
+	assert.Equal(t, 0, len(enabled)+len(disabled))
+
+	// an rgw is running in the cluster
+	rgw := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-rgw-store-a", Namespace: c.Namespace,
+		Labels: map[string]string{"app": "rook-ceph-rgw"}}}
+	_, err := clientset.AppsV1().Deployments(c.Namespace).Create(rgw)
+	require.NoError(t, err)
+
+	c.dashboard.AutoDetectFeatures = true
+	assert.NoError(t, c.configureDashboardFeatures())
+	assert.ElementsMatch(t, []string{"rgw"}, enabled)
+	assert.ElementsMatch(t, []string{"cephfs", "nfs", "mirroring"}, disabled)
+
+	// explicit settings take precedence over the detected features
+	enabled = []string{}
+	disabled = []string{}
+	c.dashboard.Features = map[string]bool{"rgw": false, "cephfs": true}
+	assert.NoError(t, c.configureDashboardFeatures())
+	assert.ElementsMatch(t, []string{"cephfs"}, enabled)
+	assert.ElementsMatch(t, []string{"rgw", "nfs", "mirroring"}, disabled)
+
+	// the features are not configured before octopus
+	enabled = []string{}
+	disabled = []string{}
+	c.clusterInfo.CephVersion = cephver.Nautilus
+	assert.NoError(t, c.configureDashboardFeatures())
+	assert.Equal(t, 0, len(enabled)+len(disabled))
+}
+
+func TestDashboardUsers(t *testing.T) {
+	users := map[string][]string{}
+	passwords := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] != "dashboard" {
+				return "", nil
+			}
+			switch args[1] {
+			case "ac-user-show", "ac-user-delete":
+				if _, ok := users[args[2]]; !ok {
+					return "", errors.New("user not found")
+				}
+				if args[1] == "ac-user-delete" {
+					delete(users, args[2])
+				}
+			case "ac-user-create":
+				users[args[2]] = []string{}
+				passwords[args[2]] = readPasswordArg(t, args)
+			case "ac-user-set-password":
+				passwords[args[2]] = readPasswordArg(t, args)
+			case "ac-user-set-roles":
+				// the roles are followed by the flags of the ceph command
+				roles := []string{}
+				for _, arg := range args[3:] {
+					if strings.HasPrefix(arg, "--") {
+						break
+					}
+					roles = append(roles, arg)
+				}
+				users[args[2]] = roles
+			}
+			return "", nil
+		},
+	}
+	clientset := test.New(3)
+	c := &Cluster{clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 15, Minor: 2, Extra: 5}},
+		context: &clusterd.Context{Clientset: clientset, Executor: executor}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true, Users: []cephv1.DashboardUserSpec{
+			{Name: "viewer", Roles: []string{"read-only"}},
+			{Name: "storage", Roles: []string{"block-manager", "pool-manager"}},
+		}}}
+	c.exitCode = func(err error) (int, bool) {
+		return int(syscall.ENOENT), true
+	}
+	assert.NoError(t, c.validateDashboardUsers())
+
+	// the users are created with the password stored in their secret
+	assert.NoError(t, c.reconcileDashboardUsers())
+	assert.Equal(t, map[string][]string{"viewer": {"read-only"}, "storage": {"block-manager", "pool-manager"}}, users)
+	secret, err := clientset.CoreV1().Secrets(c.Namespace).Get("rook-ceph-dashboard-user-viewer", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "viewer", string(secret.Data[DashboardCredentialsUsernameKey]))
+	assert.Equal(t, passwords["viewer"], string(secret.Data[DashboardCredentialsPasswordKey]))
+	assert.Equal(t, passwordLength, len(passwords["viewer"]))
+
+	// the password is kept and the roles are updated
+	viewerPassword := passwords["viewer"]
+	c.dashboard.Users[0].Roles = []string{"read-only", "rgw-manager"}
+	assert.NoError(t, c.reconcileDashboardUsers())
+	assert.Equal(t, []string{"read-only", "rgw-manager"}, users["viewer"])
+	assert.Equal(t, viewerPassword, passwords["viewer"])
+
+	// a new password is set when the secret is deleted
+	assert.NoError(t, clientset.CoreV1().Secrets(c.Namespace).Delete("rook-ceph-dashboard-user-viewer", &metav1.DeleteOptions{}))
+	assert.NoError(t, c.reconcileDashboardUsers())
+	secret, err = clientset.CoreV1().Secrets(c.Namespace).Get("rook-ceph-dashboard-user-viewer", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, passwords["viewer"], string(secret.Data[DashboardCredentialsPasswordKey]))
+
+	// the password is passed on the command line to the releases that do not read it from a file
+	c.clusterInfo.CephVersion = cephver.CephVersion{Major: 15, Minor: 2, Extra: 4}
+	assert.NoError(t, clientset.CoreV1().Secrets(c.Namespace).Delete("rook-ceph-dashboard-user-viewer", &metav1.DeleteOptions{}))
+	assert.NoError(t, c.reconcileDashboardUsers())
+	secret, err = clientset.CoreV1().Secrets(c.Namespace).Get("rook-ceph-dashboard-user-viewer", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, passwords["viewer"], string(secret.Data[DashboardCredentialsPasswordKey]))
+
+	// a user removed from the spec is deleted with its secret
+	c.dashboard.Users = c.dashboard.Users[:1]
+	assert.NoError(t, c.reconcileDashboardUsers())
+	assert.Equal(t, 1, len(users))
+	assert.NotContains(t, users, "storage")
+	_, err = clientset.CoreV1().Secrets(c.Namespace).Get("rook-ceph-dashboard-user-storage", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// invalid users
+	c.dashboard.Users = []cephv1.DashboardUserSpec{{Name: "admin", Roles: []string{"read-only"}}}
+	assert.Error(t, c.validateDashboardUsers())
+	c.dashboard.Users = []cephv1.DashboardUserSpec{{Name: "Viewer_1", Roles: []string{"read-only"}}}
+	assert.Error(t, c.validateDashboardUsers())
+	c.dashboard.Users = []cephv1.DashboardUserSpec{{Name: "viewer"}}
+	assert.Error(t, c.validateDashboardUsers())
+	c.dashboard.Users = []cephv1.DashboardUserSpec{{Name: "viewer", Roles: []string{"read-only"}}, {Name: "viewer", Roles: []string{"read-only"}}}
+	assert.Error(t, c.validateDashboardUsers())
+}
+
+// readPasswordArg returns the password of a dashboard command, which is either read from the file given with -i
+// or passed on the command line
+func readPasswordArg(t *testing.T, args []string) string {
+	if args[3] != "-i" {
+		return args[3]
+	}
+	password, err := ioutil.ReadFile(args[4])
+	require.NoError(t, err)
+	return string(password)
+}
+
+func TestDashboardPasswordFile(t *testing.T) {
+	c := &Cluster{clusterInfo: &cephconfig.ClusterInfo{}}
+	for _, version := range []cephver.CephVersion{{Major: 14, Minor: 2, Extra: 11}, {Major: 15, Minor: 2, Extra: 4}} {
+		c.clusterInfo.CephVersion = version
+		args, cleanup, err := c.dashboardPasswordArgs([]string{"dashboard", "ac-user-create", "viewer"}, "secret")
+		assert.NoError(t, err)
+		cleanup()
+		assert.Equal(t, []string{"dashboard", "ac-user-create", "viewer", "secret"}, args)
+	}
+
+	for _, version := range []cephver.CephVersion{{Major: 14, Minor: 2, Extra: 12}, {Major: 15, Minor: 2, Extra: 5}, cephver.Pacific} {
+		c.clusterInfo.CephVersion = version
+		args, cleanup, err := c.dashboardPasswordArgs([]string{"dashboard", "ac-user-create", "viewer"}, "secret")
+		assert.NoError(t, err)
+		require.Equal(t, 5, len(args))
+		assert.Equal(t, "-i", args[3])
+		assert.Equal(t, "secret", readPasswordArg(t, args))
+
+		// the password file is removed after the command ran
+		cleanup()
+		_, err = os.Stat(args[4])
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestDashboardLoginCredentials(t *testing.T) {
+	commands := []string{}
+	password := ""
+	adminExists := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] != "dashboard" {
+				return "", nil
+			}
+			commands = append(commands, args[1])
+			if args[1] == "set-login-credentials" || args[1] == "ac-user-create" || args[1] == "ac-user-set-password" {
+				assert.Equal(t, "admin", args[2])
+				password = readPasswordArg(t, args)
+			}
+			if args[1] == "ac-user-show" && !adminExists {
+				return "", errors.New("user not found")
+			}
+			return "", nil
+		},
+	}
+	executor.MockExecuteCommandWithOutputFileTimeout = func(debug bool, timeout time.Duration, actionName string, command, outfileArg string, arg ...string) (string, error) {
+		return executor.MockExecuteCommandWithOutputFile(debug, actionName, command, outfileArg, arg...)
+	}
+	c := &Cluster{clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus},
+		context: &clusterd.Context{Clientset: test.New(3), Executor: executor}, Namespace: "myns",
+		dashboard: cephv1.DashboardSpec{Enabled: true}}
+	c.exitCode = func(err error) (int, bool) {
+		return int(syscall.ENOENT), true
+	}
+	dashboardInitWaitTime = 0
+
+	// the login credentials are set before octopus
+	changed, err := c.initializeSecureDashboard()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"set-login-credentials"}, commands)
+	assert.Equal(t, passwordLength, len(password))
+
+	// the password is read from a file on the releases that support it
+	commands = []string{}
+	password = ""
+	c.clusterInfo.CephVersion = cephver.CephVersion{Major: 14, Minor: 2, Extra: 12}
+	_, err = c.initializeSecureDashboard()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"set-login-credentials"}, commands)
+	assert.Equal(t, passwordLength, len(password))
+
+	// the admin user is created with the administrator role on octopus
+	commands = []string{}
+	c.clusterInfo.CephVersion = cephver.Octopus
+	_, err = c.initializeSecureDashboard()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ac-user-show", "ac-user-create", "ac-user-set-roles"}, commands)
+
+	// the password of an existing admin user is reset
+	commands = []string{}
+	adminExists = true
+	_, err = c.initializeSecureDashboard()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ac-user-show", "ac-user-set-password", "ac-user-set-roles"}, commands)
+	assert.Equal(t, passwordLength, len(password))
+
+	// the luminous dashboard has no login
+	commands = []string{}
+	c.clusterInfo.CephVersion = cephver.Luminous
+	changed, err = c.initializeSecureDashboard()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, commands)
+}
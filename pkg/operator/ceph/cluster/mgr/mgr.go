@@ -19,12 +19,18 @@ package mgr
 
 import (
 	"fmt"
+	"net"
+	"os"
 	"path"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
@@ -33,11 +39,14 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -52,11 +61,24 @@ const (
 	prometheusModuleName   = "prometheus"
 	crashModuleName        = "crash"
 	pgautoscalerModuleName = "pg_autoscaler"
-	metricsPort            = 9283
+	balancerModuleName     = "balancer"
+	balancerModeSetting    = "mode"
+	telemetryModuleName    = "telemetry"
+	telemetryLicense       = "sharing-1-0"
+	defaultMetricsPort     = 9283
 	monitoringPath         = "/etc/ceph-monitoring/"
 	serviceMonitorFile     = "service-monitor.yaml"
 	// minimum amount of memory in MB to run the pod
 	cephMgrPodMinimumMemory uint64 = 512
+	// the reason of the event reported when the mgr resources are invalid
+	invalidResourcesReason = "InvalidMgrResources"
+	// the resources requested by default
+	defaultCPURequest    = "500m"
+	defaultMemoryRequest = "512Mi"
+	// the reconcile interval suggested when none is configured
+	defaultReconcileInterval = 10 * time.Minute
+	// the requeue delay suggested while the mgrs are not ready or the orchestration failed
+	unsettledRequeueDelay = 30 * time.Second
 )
 
 // Cluster represents the Rook and environment configuration settings needed to set up Ceph mgrs.
@@ -82,6 +104,26 @@ type Cluster struct {
 	isUpgrade         bool
 	skipUpgradeChecks bool
 	appliedHttpBind   bool
+	lastStart         StartResult
+	lastStartMux      sync.RWMutex
+	// ReconcileInterval is how often the mgrs should be reconciled once they are settled. It is a hint
+	// for the controllers that schedule the orchestration and defaults to 10 minutes.
+	ReconcileInterval time.Duration
+}
+
+// StartResult is the outcome of a call to Start, used to report the health of the mgr orchestration
+type StartResult struct {
+	// Time is when the orchestration completed. It is zero if Start has not completed yet.
+	Time time.Time
+	// Succeeded is true if the orchestration completed without error
+	Succeeded bool
+	// Err is the error that failed the orchestration, if any
+	Err error
+	// LastSuccess is when the last successful orchestration completed
+	LastSuccess time.Time
+	// SuggestedRequeue is the suggested delay before the next orchestration. It is shorter than the
+	// reconcile interval when the orchestration failed or the mgrs are not ready yet.
+	SuggestedRequeue time.Duration
 }
 
 // New creates an instance of the mgr
@@ -103,7 +145,7 @@ func New(
 	isUpgrade bool,
 	skipUpgradeChecks bool,
 ) *Cluster {
-	return &Cluster{
+	c := &Cluster{
 		clusterInfo:       clusterInfo,
 		context:           context,
 		Namespace:         namespace,
@@ -112,6 +154,7 @@ func New(
 		rookVersion:       rookVersion,
 		cephVersion:       cephVersion,
 		Replicas:          1,
+		ReconcileInterval: defaultReconcileInterval,
 		dataDir:           k8sutil.DataDir,
 		dashboard:         dashboard,
 		monitoringSpec:    monitoringSpec,
@@ -125,31 +168,161 @@ func New(
 		isUpgrade:         isUpgrade,
 		skipUpgradeChecks: skipUpgradeChecks,
 	}
+	if mgrSpec.Count > 0 {
+		c.Replicas = mgrSpec.Count
+	}
+	if mgrSpec.ReconcileInterval != "" {
+		interval, err := time.ParseDuration(mgrSpec.ReconcileInterval)
+		if err != nil || interval <= 0 {
+			logger.Warningf("invalid mgr reconcile interval %q, using %s. %v", mgrSpec.ReconcileInterval, defaultReconcileInterval.String(), err)
+		} else {
+			c.ReconcileInterval = interval
+		}
+	}
+	if len(resources.Limits) == 0 && len(resources.Requests) == 0 {
+		c.resources = defaultResources()
+	}
+	return c
+}
+
+// defaultResources returns the resources requested by the mgrs when no resources are set in the cluster
+// CR. Only requests are set so that the mgrs of large clusters are not killed for exceeding a limit.
+func defaultResources() v1.ResourceRequirements {
+	return v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(defaultCPURequest),
+			v1.ResourceMemory: resource.MustParse(defaultMemoryRequest),
+		},
+	}
 }
 
 var updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait
 
+var (
+	// startLocks ensures only one orchestration at a time is updating the mgr resources of a cluster
+	startLocks    = map[string]*sync.Mutex{}
+	startLocksMux sync.Mutex
+)
+
+// getStartLock returns the lock that serializes calls to Start for the cluster in the given namespace
+func getStartLock(namespace string) *sync.Mutex {
+	startLocksMux.Lock()
+	defer startLocksMux.Unlock()
+	lock, ok := startLocks[namespace]
+	if !ok {
+		lock = &sync.Mutex{}
+		startLocks[namespace] = lock
+	}
+	return lock
+}
+
 func (c *Cluster) getDaemonIDs() []string {
 	var daemonIDs []string
 	for i := 0; i < c.Replicas; i++ {
-		if i >= 2 {
-			logger.Errorf("cannot have more than 2 mgrs")
-			break
-		}
 		daemonIDs = append(daemonIDs, k8sutil.IndexToName(i))
 	}
 	return daemonIDs
 }
 
+// removeExtraMgrs deletes the mgr deployments that are not expected anymore after the number of mgrs is lowered
+func (c *Cluster) removeExtraMgrs(daemonIDs []string) error {
+	expected := map[string]bool{}
+	for _, daemonID := range daemonIDs {
+		expected[daemonID] = true
+	}
+
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)
+	deployments, err := k8sutil.GetDeployments(c.context.Clientset, c.Namespace, selector)
+	if err != nil {
+		return errors.Wrap(err, "failed to list mgr deployments")
+	}
+	for _, d := range deployments.Items {
+		daemonID, ok := d.Labels[string(config.MgrType)]
+		if !ok || expected[daemonID] {
+			continue
+		}
+		logger.Infof("removing extra mgr deployment %q", d.Name)
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, c.Namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to remove extra mgr deployment %q", d.Name)
+		}
+		// the keyring and the key are not used anymore
+		if err := keyring.GetSecretStore(c.context, c.Namespace, &c.ownerRef).Delete(d.Name); err != nil {
+			return errors.Wrapf(err, "failed to remove the keyring of extra mgr %q", daemonID)
+		}
+		user := fmt.Sprintf("mgr.%s", daemonID)
+		if err := client.AuthDelete(c.context, c.Namespace, user); err != nil {
+			return errors.Wrapf(err, "failed to remove the key of extra mgr %q", daemonID)
+		}
+	}
+	return nil
+}
+
 // Start begins the process of running a cluster of Ceph mgrs.
 func (c *Cluster) Start() error {
+	// Overlapping reconciles for the same cluster must wait for the running one to complete
+	// so they don't race to create or update the same resources
+	lock := getStartLock(c.Namespace)
+	lock.Lock()
+	defer lock.Unlock()
+
+	err := c.start()
+	c.recordStartResult(err, c.suggestRequeue(err))
+	return err
+}
+
+func (c *Cluster) start() error {
 	// Validate pod's memory if specified
 	err := opspec.CheckPodMemory(c.resources, cephMgrPodMinimumMemory)
 	if err != nil {
+		// report the reason the mgrs are not deployed on the cluster CR
+		message := fmt.Sprintf("the mgr resources are invalid. %v", err)
+		if eventErr := k8sutil.CreateEvent(c.context.Clientset, c.Namespace, &c.ownerRef, v1.EventTypeWarning, invalidResourcesReason, message); eventErr != nil {
+			logger.Warningf("failed to report the invalid mgr resources. %v", eventErr)
+		}
 		return errors.Wrap(err, "error checking pod memory")
 	}
+	if err := c.validateServiceClusterIPs(); err != nil {
+		return errors.Wrap(err, "invalid mgr service settings")
+	}
+	if err := c.validateDashboardService(); err != nil {
+		return errors.Wrap(err, "invalid dashboard service settings")
+	}
+	if err := c.validateDashboardUsers(); err != nil {
+		return errors.Wrap(err, "invalid dashboard users")
+	}
+	if err := c.validateNetworkBinding(); err != nil {
+		return errors.Wrap(err, "invalid mgr network settings")
+	}
+	if err := keyring.ValidateCapsMismatchPolicy(c.mgrSpec.CapsMismatchPolicy); err != nil {
+		return errors.Wrap(err, "invalid mgr keyring settings")
+	}
+	if c.mgrSpec.KeyGeneration < 0 {
+		return errors.Errorf("invalid mgr key generation %d", c.mgrSpec.KeyGeneration)
+	}
+	if err := validateActiveFailover(c.mgrSpec.ActiveFailover); err != nil {
+		return errors.Wrap(err, "invalid mgr active failover settings")
+	}
+	if err := c.validateServiceAccountToken(); err != nil {
+		return errors.Wrap(err, "invalid mgr service account token settings")
+	}
+	if err := c.validatePrometheusModule(); err != nil {
+		return errors.Wrap(err, "invalid mgr prometheus module settings")
+	}
+	if err := c.validateMonitoring(); err != nil {
+		return errors.Wrap(err, "invalid monitoring settings")
+	}
+	if err := c.validatePriorityClass(); err != nil {
+		return errors.Wrap(err, "invalid mgr priority class")
+	}
 
 	logger.Infof("start running mgr")
+	if err := c.createServiceAccount(); err != nil {
+		return errors.Wrap(err, "failed to create the mgr service account")
+	}
+	if err := c.configureNetworkBinding(); err != nil {
+		return errors.Wrap(err, "failed to configure the mgr network binding")
+	}
+
 	daemonIDs := c.getDaemonIDs()
 	for _, daemonID := range daemonIDs {
 		resourceName := fmt.Sprintf("%s-%s", AppName, daemonID)
@@ -173,26 +346,7 @@ func (c *Cluster) Start() error {
 			if !kerrors.IsAlreadyExists(err) {
 				return errors.Wrapf(err, "failed to create mgr deployment %s", resourceName)
 			}
-			logger.Infof("deployment for mgr %s already exists. updating if needed", resourceName)
-
-			// Always invoke ceph version before an upgrade so we are sure to be up-to-date
-			daemon := string(config.MgrType)
-			var cephVersionToUse cephver.CephVersion
-
-			// If this is not a Ceph upgrade there is no need to check the ceph version
-			if c.isUpgrade {
-				currentCephVersion, err := client.LeastUptodateDaemonVersion(c.context, c.clusterInfo.Name, daemon)
-				if err != nil {
-					logger.Warningf("failed to retrieve current ceph %q version. %v", daemon, err)
-					logger.Debug("could not detect ceph version during update, this is likely an initial bootstrap, proceeding with c.clusterInfo.CephVersion")
-					cephVersionToUse = c.clusterInfo.CephVersion
-				} else {
-					logger.Debugf("current cluster version for mgrs before upgrading is: %+v", currentCephVersion)
-					cephVersionToUse = currentCephVersion
-				}
-			}
-
-			if err := updateDeploymentAndWait(c.context, d, c.Namespace, daemon, mgrConfig.DaemonID, cephVersionToUse, c.isUpgrade, c.skipUpgradeChecks); err != nil {
+			if err := c.updateDeployment(d, mgrConfig.DaemonID); err != nil {
 				logger.Errorf("failed to update mgr deployment %q. %v", resourceName, err)
 			}
 		}
@@ -205,9 +359,21 @@ func (c *Cluster) Start() error {
 		}
 	}
 
+	if err := c.removeExtraMgrs(daemonIDs); err != nil {
+		logger.Errorf("failed to remove extra mgrs. %v", err)
+	}
+
+	// the services only select the active mgr, so label it without waiting for the active mgr checker
+	if err := updateMgrRoleLabels(c.context, c.Namespace); err != nil {
+		logger.Warningf("failed to update the role of the mgr pods. %v", err)
+	}
+
 	if err := c.configureDashboardService(); err != nil {
 		logger.Errorf("failed to enable dashboard. %v", err)
 	}
+	if err := c.configureDashboardIngress(); err != nil {
+		logger.Errorf("failed to configure dashboard ingress. %v", err)
+	}
 
 	// configure the mgr modules
 	c.configureModules(daemonIDs)
@@ -219,6 +385,21 @@ func (c *Cluster) Start() error {
 			return errors.Wrapf(err, "failed to create mgr service")
 		}
 		logger.Infof("mgr metrics service already exists")
+		if existing, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get(service.Name, metav1.GetOptions{}); err != nil {
+			logger.Warningf("failed to get mgr metrics service. %v", err)
+		} else {
+			warnIfClusterIPChanged(existing, service.Spec.ClusterIP)
+			metadataChanged := syncServiceMetadata(existing, service)
+			selectorChanged := !reflect.DeepEqual(existing.Spec.Selector, service.Spec.Selector)
+			if existing.Spec.Ports[0].Port != service.Spec.Ports[0].Port || metadataChanged || selectorChanged {
+				logger.Infof("mgr metrics service changed. updating service")
+				existing.Spec.Ports[0].Port = service.Spec.Ports[0].Port
+				existing.Spec.Selector = service.Spec.Selector
+				if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Update(existing); err != nil {
+					return errors.Wrapf(err, "failed to update mgr service")
+				}
+			}
+		}
 	} else {
 		logger.Infof("mgr metrics service started")
 	}
@@ -252,6 +433,150 @@ func (c *Cluster) Start() error {
 	return nil
 }
 
+// updateDeployment updates an existing mgr deployment if the desired deployment changed, for example after
+// the image, resources or placement were changed in the cluster CR. The deployments are always updated
+// during an upgrade.
+func (c *Cluster) updateDeployment(d *apps.Deployment, daemonID string) error {
+	existing, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(d.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get mgr deployment %q", d.GetName())
+	}
+	if !c.isUpgrade && !k8sutil.DeploymentChanged(existing, d) {
+		logger.Debugf("mgr deployment %q is up to date", d.GetName())
+		return nil
+	}
+	logger.Infof("mgr deployment %q changed. updating", d.GetName())
+
+	// Always invoke ceph version before an upgrade so we are sure to be up-to-date
+	daemon := string(config.MgrType)
+	var cephVersionToUse cephver.CephVersion
+
+	// If this is not a Ceph upgrade there is no need to check the ceph version
+	if c.isUpgrade {
+		currentCephVersion, err := client.LeastUptodateDaemonVersion(c.context, c.clusterInfo.Name, daemon)
+		if err != nil {
+			logger.Warningf("failed to retrieve current ceph %q version. %v", daemon, err)
+			logger.Debug("could not detect ceph version during update, this is likely an initial bootstrap, proceeding with c.clusterInfo.CephVersion")
+			cephVersionToUse = c.clusterInfo.CephVersion
+		} else {
+			logger.Debugf("current cluster version for mgrs before upgrading is: %+v", currentCephVersion)
+			cephVersionToUse = currentCephVersion
+		}
+	}
+
+	return updateDeploymentAndWait(c.context, d, c.Namespace, daemon, daemonID, cephVersionToUse, c.isUpgrade, c.skipUpgradeChecks)
+}
+
+func (c *Cluster) recordStartResult(err error, requeue time.Duration) {
+	c.lastStartMux.Lock()
+	defer c.lastStartMux.Unlock()
+	c.lastStart.Time = time.Now()
+	c.lastStart.Succeeded = err == nil
+	c.lastStart.Err = err
+	c.lastStart.SuggestedRequeue = requeue
+	if err == nil {
+		c.lastStart.LastSuccess = c.lastStart.Time
+	}
+	logger.Infof("mgr orchestration succeeded: %t. suggested requeue in %s", c.lastStart.Succeeded, requeue.String())
+}
+
+// ReconcileIntervalHint returns how often the mgrs should be reconciled once they are settled
+func (c *Cluster) ReconcileIntervalHint() time.Duration {
+	if c.ReconcileInterval <= 0 {
+		return defaultReconcileInterval
+	}
+	return c.ReconcileInterval
+}
+
+// suggestRequeue returns the delay before the next orchestration. The mgrs are reconciled again sooner
+// if the orchestration failed or any of the mgr deployments is not ready, but never later than the
+// reconcile interval.
+func (c *Cluster) suggestRequeue(startErr error) time.Duration {
+	interval := c.ReconcileIntervalHint()
+	if interval < unsettledRequeueDelay {
+		return interval
+	}
+	if startErr != nil {
+		return unsettledRequeueDelay
+	}
+	if !c.mgrsReady() {
+		logger.Infof("not all the mgrs are ready")
+		return unsettledRequeueDelay
+	}
+	return interval
+}
+
+// mgrsReady returns whether all the expected mgr deployments have a ready pod
+func (c *Cluster) mgrsReady() bool {
+	for _, daemonID := range c.getDaemonIDs() {
+		resourceName := fmt.Sprintf("%s-%s", AppName, daemonID)
+		d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName, metav1.GetOptions{})
+		if err != nil {
+			logger.Debugf("failed to get mgr deployment %q. %v", resourceName, err)
+			return false
+		}
+		if d.Status.ReadyReplicas < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// InheritStartResult keeps the result of the last orchestration of the mgrs by a previous instance, since a new
+// instance is made for each orchestration of the cluster
+func (c *Cluster) InheritStartResult(previous *Cluster) {
+	result := previous.LastStartResult()
+	c.lastStartMux.Lock()
+	defer c.lastStartMux.Unlock()
+	c.lastStart = result
+}
+
+// LastStartResult returns the time and outcome of the last call to Start. It is safe to call while
+// an orchestration is in progress, for example from an operator health endpoint.
+func (c *Cluster) LastStartResult() StartResult {
+	c.lastStartMux.RLock()
+	defer c.lastStartMux.RUnlock()
+	return c.lastStart
+}
+
+func (c *Cluster) networkBindingSettings() []config.Option {
+	return []config.Option{
+		{Who: "mgr", Option: "public_network", Value: c.mgrSpec.PublicNetwork},
+		{Who: "mgr", Option: "cluster_network", Value: c.mgrSpec.ClusterNetwork},
+	}
+}
+
+func (c *Cluster) validateNetworkBinding() error {
+	for _, setting := range c.networkBindingSettings() {
+		if setting.Value == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(setting.Value); err != nil {
+			return errors.Wrapf(err, "invalid %s %q", setting.Option, setting.Value)
+		}
+	}
+	return nil
+}
+
+// configureNetworkBinding sets the networks the mgrs bind to. On multi-homed hosts, and especially with host
+// networking, the mgr could otherwise bind to an interface that is not on the public network.
+func (c *Cluster) configureNetworkBinding() error {
+	monStore := config.GetMonStore(c.context, c.Namespace)
+	for _, setting := range c.networkBindingSettings() {
+		if setting.Value == "" {
+			// clear a network that was previously set in the spec
+			if err := monStore.Delete(setting.Who, setting.Option); err != nil {
+				return errors.Wrapf(err, "failed to clear %s", setting.Option)
+			}
+			continue
+		}
+		if err := monStore.Set(setting.Who, setting.Option, setting.Value); err != nil {
+			return errors.Wrapf(err, "failed to set %s to %q", setting.Option, setting.Value)
+		}
+	}
+	return nil
+}
+
 func (c *Cluster) configureModules(daemonIDs []string) {
 	// Configure the modules asynchronously so we can complete all the configuration much sooner.
 	var wg sync.WaitGroup
@@ -284,9 +609,107 @@ func startModuleConfiguration(wg *sync.WaitGroup, description string, configureM
 
 // Ceph docs about the prometheus module: http://docs.ceph.com/docs/master/mgr/prometheus/
 func (c *Cluster) enablePrometheusModule() error {
+	portChanged, err := c.configureMetricsPort()
+	if err != nil {
+		return errors.Wrapf(err, "failed to configure the metrics port")
+	}
 	if err := client.MgrEnableModule(c.context, c.Namespace, prometheusModuleName, true); err != nil {
 		return errors.Wrapf(err, "failed to enable mgr prometheus module")
 	}
+	if portChanged {
+		// the module only binds to the port when it starts
+		logger.Infof("metrics port changed to %d. restarting the prometheus module", c.metricsPort())
+		if err := client.MgrDisableModule(c.context, c.Namespace, prometheusModuleName); err != nil {
+			return errors.Wrapf(err, "failed to disable mgr prometheus module")
+		}
+		if err := client.MgrEnableModule(c.context, c.Namespace, prometheusModuleName, true); err != nil {
+			return errors.Wrapf(err, "failed to enable mgr prometheus module")
+		}
+	}
+	if err := c.configurePrometheusModule(); err != nil {
+		return errors.Wrapf(err, "failed to configure mgr prometheus module")
+	}
+	return nil
+}
+
+// configureMetricsPort sets the port of the prometheus module on each mgr. The default port is also
+// set explicitly so that it is not reported as a change when the option is read back.
+// It returns whether the port has changed.
+func (c *Cluster) configureMetricsPort() (bool, error) {
+	port := strconv.Itoa(c.metricsPort())
+	hasChanged := false
+	for _, daemonID := range c.getDaemonIDs() {
+		changed, err := client.MgrSetConfig(c.context, c.Namespace, daemonID, c.clusterInfo.CephVersion, "mgr/prometheus/server_port", port, false)
+		if err != nil {
+			return false, err
+		}
+		if changed {
+			hasChanged = true
+		}
+	}
+	return hasChanged, nil
+}
+
+func (c *Cluster) prometheusModuleSettings() []config.Option {
+	spec := c.mgrSpec.PrometheusModule
+	interval := ""
+	if spec.RBDStatsPoolsRefreshInterval > 0 {
+		interval = strconv.Itoa(spec.RBDStatsPoolsRefreshInterval)
+	}
+	return []config.Option{
+		{Who: "mgr", Option: "mgr/prometheus/rbd_stats_pools", Value: strings.Join(spec.RBDStatsPools, ",")},
+		{Who: "mgr", Option: "mgr/prometheus/rbd_stats_pools_refresh_interval", Value: interval},
+	}
+}
+
+func (c *Cluster) validatePrometheusModule() error {
+	spec := c.mgrSpec.PrometheusModule
+	for _, pool := range spec.RBDStatsPools {
+		if strings.ContainsAny(pool, ", ") {
+			return errors.Errorf("invalid rbd stats pool %q. the pool must not contain commas or spaces", pool)
+		}
+		parts := strings.Split(pool, "/")
+		if len(parts) > 2 {
+			return errors.Errorf("invalid rbd stats pool %q. expected <pool> or <pool>/<namespace>", pool)
+		}
+		for _, part := range parts {
+			if part == "" {
+				return errors.Errorf("invalid rbd stats pool %q. expected <pool> or <pool>/<namespace>", pool)
+			}
+		}
+	}
+	if spec.RBDStatsPoolsRefreshInterval < 0 {
+		return errors.Errorf("invalid rbd stats pools refresh interval %d", spec.RBDStatsPoolsRefreshInterval)
+	}
+	if spec.Port < 0 || spec.Port > 65535 {
+		return errors.Errorf("invalid metrics port %d", spec.Port)
+	}
+	return nil
+}
+
+// configurePrometheusModule sets the RBD stats exported by the prometheus module. The per-image stats can be
+// expensive on clusters with many images, so they are only enabled for the pools in the spec.
+func (c *Cluster) configurePrometheusModule() error {
+	if !c.clusterInfo.CephVersion.IsAtLeastNautilus() {
+		if len(c.mgrSpec.PrometheusModule.RBDStatsPools) > 0 {
+			logger.Warningf("rbd stats pools are only supported on nautilus or newer, not configuring them")
+		}
+		return nil
+	}
+
+	monStore := config.GetMonStore(c.context, c.Namespace)
+	for _, setting := range c.prometheusModuleSettings() {
+		if setting.Value == "" {
+			// clear a setting that was previously set in the spec
+			if err := monStore.Delete(setting.Who, setting.Option); err != nil {
+				return errors.Wrapf(err, "failed to clear %s", setting.Option)
+			}
+			continue
+		}
+		if err := monStore.Set(setting.Who, setting.Option, setting.Value); err != nil {
+			return errors.Wrapf(err, "failed to set %s to %q", setting.Option, setting.Value)
+		}
+	}
 	return nil
 }
 
@@ -330,13 +753,101 @@ func (c *Cluster) configureMgrModules() error {
 					return errors.Wrapf(err, "failed to set minimal number PGs per (in) osd before we warn the admin to")
 				}
 			}
+			if module.Name == balancerModuleName {
+				if err := c.configureBalancer(module); err != nil {
+					return errors.Wrapf(err, "failed to configure the balancer")
+				}
+			}
+
+			if err := c.configureModuleSettings(module); err != nil {
+				return errors.Wrapf(err, "failed to configure mgr module %s", module.Name)
+			}
+			// the telemetry module only sends reports after opting in, once its settings such as the contact are set
+			if module.Name == telemetryModuleName {
+				if err := client.SetTelemetry(c.context, c.Namespace, true, c.telemetryLicenseToAccept()); err != nil {
+					return errors.Wrapf(err, "failed to opt in to the telemetry")
+				}
+			}
 		} else {
+			// the balancer is always on starting with nautilus, only the balancing can be stopped
+			if module.Name == balancerModuleName && c.clusterInfo.CephVersion.IsAtLeastNautilus() {
+				if err := client.SetBalancerActive(c.context, c.Namespace, false); err != nil {
+					return errors.Wrapf(err, "failed to disable the balancer")
+				}
+				continue
+			}
+			// the telemetry module is always on starting with octopus, only the reports can be stopped
+			if module.Name == telemetryModuleName && c.clusterInfo.CephVersion.IsAtLeastOctopus() {
+				if err := client.SetTelemetry(c.context, c.Namespace, false, ""); err != nil {
+					return errors.Wrapf(err, "failed to opt out of the telemetry")
+				}
+				continue
+			}
 			if err := client.MgrDisableModule(c.context, c.Namespace, module.Name); err != nil {
 				return errors.Wrapf(err, "failed to disable mgr module %s", module.Name)
 			}
+
+			// New pools must not be created with autoscaling when the autoscaler is disabled
+			if module.Name == pgautoscalerModuleName && c.clusterInfo.CephVersion.IsAtLeastNautilus() {
+				monStore := config.GetMonStore(c.context, c.Namespace)
+				if err := monStore.Delete("global", "osd_pool_default_pg_autoscale_mode"); err != nil {
+					return errors.Wrapf(err, "failed to reset pg autoscale mode for newly created pools")
+				}
+				if err := monStore.Delete("global", "mon_pg_warn_min_per_osd"); err != nil {
+					return errors.Wrapf(err, "failed to reset minimal number PGs per (in) osd before we warn the admin to")
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// telemetryLicenseToAccept returns the license of the telemetry data that must be accepted to opt in
+func (c *Cluster) telemetryLicenseToAccept() string {
+	if c.clusterInfo.CephVersion.IsAtLeastOctopus() {
+		return telemetryLicense
+	}
+	return ""
+}
+
+// configureBalancer sets the mode of the balancer from the module settings and turns on the balancing
+// Ceph docs about the balancer module: https://docs.ceph.com/docs/master/rados/operations/balancer/
+func (c *Cluster) configureBalancer(module cephv1.Module) error {
+	if mode, ok := module.Settings[balancerModeSetting]; ok {
+		if mode != "none" && mode != "crush-compat" && mode != "upmap" {
+			return errors.Errorf("invalid balancer mode %q. expected crush-compat, upmap or none", mode)
+		}
+		if err := client.SetBalancerMode(c.context, c.Namespace, mode); err != nil {
+			return err
 		}
 	}
+	return client.SetBalancerActive(c.context, c.Namespace, true)
+}
 
+// configureModuleSettings applies the settings of a module from the spec as "mgr/<module>/<key>" options
+func (c *Cluster) configureModuleSettings(module cephv1.Module) error {
+	keys := make([]string, 0, len(module.Settings))
+	for key := range module.Settings {
+		if key == "" || strings.ContainsAny(key, "/ ") {
+			return errors.Errorf("invalid setting %q for mgr module %s", key, module.Name)
+		}
+		keys = append(keys, key)
+	}
+	// apply the settings in a consistent order
+	sort.Strings(keys)
+
+	monStore := config.GetMonStore(c.context, c.Namespace)
+	for _, key := range keys {
+		if module.Name == balancerModuleName && key == balancerModeSetting {
+			// already applied when configuring the balancer
+			continue
+		}
+		option := fmt.Sprintf("mgr/%s/%s", module.Name, key)
+		if err := monStore.Set("mgr", option, module.Settings[key]); err != nil {
+			return errors.Wrapf(err, "failed to set %s", option)
+		}
+	}
 	return nil
 }
 
@@ -376,18 +887,85 @@ func (c *Cluster) enableServiceMonitor(service *v1.Service) error {
 	k8sutil.SetOwnerRef(&serviceMonitor.ObjectMeta, &c.ownerRef)
 	serviceMonitor.Spec.NamespaceSelector.MatchNames = []string{namespace}
 	serviceMonitor.Spec.Selector.MatchLabels = service.GetLabels()
+	c.applyServiceMonitorEndpoints(serviceMonitor, service)
 	if _, err := k8sutil.CreateOrUpdateServiceMonitor(serviceMonitor); err != nil {
 		return errors.Wrapf(err, "service monitor could not be enabled")
 	}
 	return nil
 }
 
+// validatePriorityClass returns an error if the priority class of the mgr pods does not exist. Otherwise
+// the deployments are created but the pods are rejected by the API server.
+func (c *Cluster) validatePriorityClass() error {
+	if c.priorityClassName == "" {
+		return nil
+	}
+	_, err := c.context.Clientset.SchedulingV1().PriorityClasses().Get(c.priorityClassName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return errors.Errorf("priority class %q not found", c.priorityClassName)
+		}
+		// the operator may not be allowed to read the priority classes
+		logger.Warningf("failed to verify the mgr priority class %q. %v", c.priorityClassName, err)
+	}
+	return nil
+}
+
+// applyServiceMonitorEndpoints points the servicemonitor at the metrics port of the service and
+// sets the scrape interval from the monitoring spec
+func (c *Cluster) applyServiceMonitorEndpoints(serviceMonitor *monitoringv1.ServiceMonitor, service *v1.Service) {
+	if len(serviceMonitor.Spec.Endpoints) == 0 {
+		serviceMonitor.Spec.Endpoints = []monitoringv1.Endpoint{{Path: "/metrics"}}
+	}
+	for i := range serviceMonitor.Spec.Endpoints {
+		for _, port := range service.Spec.Ports {
+			if port.Port == int32(c.metricsPort()) {
+				serviceMonitor.Spec.Endpoints[i].Port = port.Name
+			}
+		}
+		if c.monitoringSpec.Interval != "" {
+			serviceMonitor.Spec.Endpoints[i].Interval = c.monitoringSpec.Interval
+		}
+	}
+}
+
+func (c *Cluster) validateMonitoring() error {
+	if c.monitoringSpec.Interval == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(c.monitoringSpec.Interval)
+	if err != nil {
+		return errors.Wrapf(err, "invalid scrape interval %q", c.monitoringSpec.Interval)
+	}
+	if interval <= 0 {
+		return errors.Errorf("invalid scrape interval %q. the interval must be greater than zero", c.monitoringSpec.Interval)
+	}
+	return nil
+}
+
+// findPrometheusRuleFile returns the name and path of the rules for the given ceph major version.
+// If there are no rules for that version, the rules of the most recent older version are used so
+// that alerting keeps working on ceph releases newer than the rules shipped with the operator.
+func findPrometheusRuleFile(dir, name string, major int) (string, string, error) {
+	for version := major; version >= cephver.Nautilus.Major; version-- {
+		versionedName := strings.Replace(name, "VERSION", strconv.Itoa(version), 1)
+		ruleFile := path.Join(dir, versionedName+".yaml")
+		if _, err := os.Stat(ruleFile); err == nil {
+			if version != major {
+				logger.Infof("no prometheus rules found for ceph version %d. using the rules for version %d", major, version)
+			}
+			return versionedName, ruleFile, nil
+		}
+	}
+	return "", "", errors.Errorf("no prometheus rules found for ceph version %d in %q", major, dir)
+}
+
 // deploy prometheusRule that adds alerting and/or recording rules to the cluster
 func (c *Cluster) deployPrometheusRule(name, namespace string) error {
-	version := strconv.Itoa(c.clusterInfo.CephVersion.Major)
-	name = strings.Replace(name, "VERSION", version, 1)
-	prometheusRuleFile := name + ".yaml"
-	prometheusRuleFile = path.Join(monitoringPath, prometheusRuleFile)
+	name, prometheusRuleFile, err := findPrometheusRuleFile(monitoringPath, name, c.clusterInfo.CephVersion.Major)
+	if err != nil {
+		return errors.Wrapf(err, "prometheus rule could not be deployed")
+	}
 	prometheusRule, err := k8sutil.GetPrometheusRule(prometheusRuleFile)
 	if err != nil {
 		return errors.Wrapf(err, "prometheus rule could not be deployed")
@@ -20,13 +20,20 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
+	"sync"
 	"testing"
+	"time"
 
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	cephkeyring "github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -34,7 +41,9 @@ import (
 	"github.com/stretchr/testify/require"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -42,8 +51,12 @@ func TestStartMGR(t *testing.T) {
 	var deploymentsUpdated *[]*apps.Deployment
 	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
 
+	authDeleted := []string{}
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "del" {
+				authDeleted = append(authDeleted, args[2])
+			}
 			return "{\"key\":\"mysecurekey\"}", nil
 		},
 	}
@@ -76,13 +89,25 @@ func TestStartMGR(t *testing.T) {
 	)
 	defer os.RemoveAll(c.dataDir)
 
-	// start a basic service
+	// the priority class must exist
 	err := c.Start()
+	assert.Error(t, err)
+	priorityClass := &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "my-priority-class"}, Value: 1000}
+	_, err = context.Clientset.SchedulingV1().PriorityClasses().Create(priorityClass)
+	require.NoError(t, err)
+
+	// start a basic service
+	err = c.Start()
 	assert.Nil(t, err)
 	validateStart(t, c)
 	assert.ElementsMatch(t, []string{}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
+	// the deployment is not updated if the spec did not change
+	err = c.Start()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+
 	c.dashboard.UrlPrefix = "/test"
 	c.dashboard.Port = 12345
 	err = c.Start()
@@ -99,16 +124,142 @@ func TestStartMGR(t *testing.T) {
 	validateStart(t, c)
 	assert.ElementsMatch(t, []string{"rook-ceph-mgr-a"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(deployments.Items))
+
+	// the extra mgrs are removed when the replicas are lowered
+	c.Replicas = 1
+	err = c.Start()
+	assert.Nil(t, err)
+	validateStart(t, c)
+	assert.ElementsMatch(t, []string{"rook-ceph-mgr-a"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	deployments, err = c.context.Clientset.AppsV1().Deployments(c.Namespace).List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(deployments.Items))
+	assert.Equal(t, "rook-ceph-mgr-a", deployments.Items[0].Name)
+	// the keyrings and keys of the extra mgrs are removed
+	assert.ElementsMatch(t, []string{"mgr.b", "mgr.c"}, authDeleted)
+	for _, id := range []string{"b", "c"} {
+		_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Get("rook-ceph-mgr-"+id+"-keyring", metav1.GetOptions{})
+		assert.True(t, errors.IsNotFound(err))
+	}
+	_, err = c.context.Clientset.CoreV1().Secrets(c.Namespace).Get("rook-ceph-mgr-a-keyring", metav1.GetOptions{})
+	assert.NoError(t, err)
 }
 
-func validateStart(t *testing.T, c *Cluster) {
-	mgrNames := []string{"a", "b"}
-	for i := 0; i < c.Replicas; i++ {
-		if i == 2 {
-			break
+func TestStartSerialized(t *testing.T) {
+	defer func(update func(*clusterd.Context, *apps.Deployment, string, string, string, cephver.CephVersion, bool, bool) error) {
+		updateDeploymentAndWait = update
+	}(updateDeploymentAndWait)
+
+	// track how many updates are in flight at the same time
+	var mux sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+	updates := 0
+	updateDeploymentAndWait = func(context *clusterd.Context, deployment *apps.Deployment, namespace, daemonType, daemonName string, cephVersion cephver.CephVersion, isUpgrade, skipUpgradeChecks bool) error {
+		mux.Lock()
+		inFlight++
+		updates++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
 		}
+		mux.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		// the deployment is left outdated so that the next start updates it again
+		assert.NoError(t, markDeploymentsOutdated(context, namespace))
+		mux.Lock()
+		inFlight--
+		mux.Unlock()
+		return nil
+	}
+
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return "{\"key\":\"mysecurekey\"}", nil
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Executor:  executor,
+		ConfigDir: configDir,
+		Clientset: testop.New(3)}
+	c := New(
+		&cephconfig.ClusterInfo{FSID: "myfsid"},
+		context,
+		"serialized-ns",
+		"myversion",
+		cephv1.CephVersionSpec{},
+		rookalpha.Placement{},
+		rookalpha.Annotations{},
+		cephv1.NetworkSpec{},
+		cephv1.DashboardSpec{},
+		cephv1.MonitoringSpec{},
+		cephv1.MgrSpec{},
+		v1.ResourceRequirements{},
+		"",
+		metav1.OwnerReference{},
+		"/var/lib/rook/",
+		false,
+		false,
+	)
+	defer os.RemoveAll(c.dataDir)
+
+	// a start must wait while another orchestration holds the lock for the cluster
+	lock := getStartLock(c.Namespace)
+	lock.Lock()
+	done := make(chan error)
+	go func() {
+		done <- c.Start()
+	}()
+	select {
+	case <-done:
+		assert.Fail(t, "start completed while the cluster was locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	lock.Unlock()
+	assert.NoError(t, <-done)
+
+	// concurrent starts run one at a time, each of them updating the outdated deployment
+	require.NoError(t, markDeploymentsOutdated(context, c.Namespace))
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Start())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 5, updates)
+	assert.Equal(t, 1, maxInFlight)
+
+	// clusters in other namespaces have their own lock
+	assert.True(t, getStartLock(c.Namespace) != getStartLock("other-ns"))
+	assert.True(t, getStartLock(c.Namespace) == getStartLock("serialized-ns"))
+}
+
+// markDeploymentsOutdated changes the spec hash of the deployments so that they are updated by the next start
+func markDeploymentsOutdated(context *clusterd.Context, namespace string) error {
+	deployments, err := context.Clientset.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		d.Annotations[k8sutil.SpecHashAnnotation] = "outdated"
+		if _, err := context.Clientset.AppsV1().Deployments(namespace).Update(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateStart(t *testing.T, c *Cluster) {
+	for i, daemonName := range c.getDaemonIDs() {
 		logger.Infof("Looking for cephmgr replica %d", i)
-		daemonName := mgrNames[i]
 		d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(fmt.Sprintf("rook-ceph-mgr-%s", daemonName), metav1.GetOptions{})
 		assert.Nil(t, err)
 		assert.Equal(t, map[string]string{"my": "annotation"}, d.Spec.Template.Annotations)
@@ -137,6 +288,7 @@ func TestConfigureModules(t *testing.T) {
 	modulesEnabled := 0
 	modulesDisabled := 0
 	configSettings := map[string]string{}
+	configRemoved := []string{}
 	lastModuleConfigured := ""
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
@@ -155,6 +307,9 @@ func TestConfigureModules(t *testing.T) {
 					configSettings[args[3]] = args[4]
 				}
 			}
+			if args[0] == "config" && args[1] == "rm" && args[2] == "global" {
+				configRemoved = append(configRemoved, args[3])
+			}
 			return "", nil //return "{\"key\":\"mysecurekey\"}", nil
 		},
 	}
@@ -208,12 +363,625 @@ func TestConfigureModules(t *testing.T) {
 	assert.Equal(t, 1, modulesDisabled)
 	assert.Equal(t, "pg_autoscaler", lastModuleConfigured)
 	assert.Equal(t, 0, len(configSettings))
+	// new pools are not autoscaled anymore
+	assert.Equal(t, []string{"osd_pool_default_pg_autoscale_mode", "mon_pg_warn_min_per_osd"}, configRemoved)
+}
+
+func TestConfigureModuleSettings(t *testing.T) {
+	modulesEnabled := []string{}
+	modulesDisabled := []string{}
+	configSettings := map[string]string{}
+	telemetryCommands := [][]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "mgr" && args[1] == "module" && args[2] == "enable" {
+				modulesEnabled = append(modulesEnabled, args[3])
+			}
+			if args[0] == "mgr" && args[1] == "module" && args[2] == "disable" {
+				modulesDisabled = append(modulesDisabled, args[3])
+			}
+			if args[0] == "telemetry" {
+				// the settings are applied before opting in
+				assert.Equal(t, "admin@example.com", configSettings["mgr/telemetry/contact"])
+				if args[1] == "on" && args[2] == "--license" {
+					telemetryCommands = append(telemetryCommands, args[:4])
+				} else {
+					telemetryCommands = append(telemetryCommands, args[:2])
+				}
+			}
+			if args[0] == "config" && args[1] == "set" && args[2] == "mgr" {
+				configSettings[args[3]] = args[4]
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus},
+		context:     &clusterd.Context{Executor: executor, Clientset: testop.New(3)},
+		Namespace:   "ns",
+	}
+
+	// the settings are applied to the enabled modules
+	c.mgrSpec.Modules = []cephv1.Module{
+		{Name: "telemetry", Enabled: true, Settings: map[string]string{"contact": "admin@example.com", "interval": "72"}},
+		{Name: "insights", Enabled: true},
+	}
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, []string{"telemetry", "insights"}, modulesEnabled)
+	assert.Equal(t, map[string]string{
+		"mgr/telemetry/contact":  "admin@example.com",
+		"mgr/telemetry/interval": "72",
+	}, configSettings)
+	assert.Equal(t, [][]string{{"telemetry", "on"}}, telemetryCommands)
+
+	// the settings of a disabled module are not applied
+	configSettings = map[string]string{}
+	telemetryCommands = [][]string{}
+	c.mgrSpec.Modules = []cephv1.Module{
+		{Name: "telemetry", Enabled: false, Settings: map[string]string{"contact": "admin@example.com"}},
+	}
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, 0, len(configSettings))
+	assert.Equal(t, []string{"telemetry"}, modulesDisabled)
+	assert.Equal(t, 0, len(telemetryCommands))
+
+	// the telemetry license is accepted and the always-on module is not disabled starting with octopus
+	c.clusterInfo.CephVersion = cephver.Octopus
+	c.mgrSpec.Modules = []cephv1.Module{
+		{Name: "telemetry", Enabled: true, Settings: map[string]string{"contact": "admin@example.com"}},
+	}
+	assert.NoError(t, c.configureMgrModules())
+	c.mgrSpec.Modules[0].Enabled = false
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, [][]string{{"telemetry", "on", "--license", "sharing-1-0"}, {"telemetry", "off"}}, telemetryCommands)
+	assert.Equal(t, []string{"telemetry"}, modulesDisabled)
+	c.clusterInfo.CephVersion = cephver.Nautilus
+
+	// invalid setting names are rejected
+	for _, key := range []string{"", "a/b", "a b"} {
+		c.mgrSpec.Modules = []cephv1.Module{
+			{Name: "telemetry", Enabled: true, Settings: map[string]string{key: "value"}},
+		}
+		assert.Error(t, c.configureMgrModules(), key)
+	}
+}
+
+func TestConfigureBalancer(t *testing.T) {
+	balancerCommands := [][]string{}
+	modulesDisabled := 0
+	configSettings := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "balancer" {
+				if args[1] == "mode" {
+					balancerCommands = append(balancerCommands, args[:3])
+				} else {
+					balancerCommands = append(balancerCommands, args[:2])
+				}
+			}
+			if args[0] == "mgr" && args[1] == "module" && args[2] == "disable" {
+				modulesDisabled++
+			}
+			if args[0] == "config" && args[1] == "set" && args[2] == "mgr" {
+				configSettings[args[3]] = args[4]
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus},
+		context:     &clusterd.Context{Executor: executor, Clientset: testop.New(3)},
+		Namespace:   "ns",
+	}
+
+	// the mode is set before the balancing is turned on
+	c.mgrSpec.Modules = []cephv1.Module{
+		{Name: "balancer", Enabled: true, Settings: map[string]string{"mode": "upmap", "sleep_interval": "120"}},
+	}
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, [][]string{{"balancer", "mode", "upmap"}, {"balancer", "on"}}, balancerCommands)
+	assert.Equal(t, map[string]string{"mgr/balancer/sleep_interval": "120"}, configSettings)
+
+	// the balancing is turned off, the always-on module is not disabled
+	balancerCommands = [][]string{}
+	c.mgrSpec.Modules[0].Enabled = false
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, [][]string{{"balancer", "off"}}, balancerCommands)
+	assert.Equal(t, 0, modulesDisabled)
+
+	// the module is disabled before nautilus
+	balancerCommands = [][]string{}
+	c.clusterInfo.CephVersion = cephver.Mimic
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, 0, len(balancerCommands))
+	assert.Equal(t, 1, modulesDisabled)
+
+	// invalid modes are rejected
+	c.mgrSpec.Modules = []cephv1.Module{
+		{Name: "balancer", Enabled: true, Settings: map[string]string{"mode": "random"}},
+	}
+	assert.Error(t, c.configureMgrModules())
 }
 
 func TestMgrDaemons(t *testing.T) {
 	c := &Cluster{Replicas: 3}
 	daemons := c.getDaemonIDs()
-	require.Equal(t, 2, len(daemons))
-	assert.Equal(t, "a", daemons[0])
-	assert.Equal(t, "b", daemons[1])
+	assert.Equal(t, []string{"a", "b", "c"}, daemons)
+
+	c.Replicas = 1
+	assert.Equal(t, []string{"a"}, c.getDaemonIDs())
+
+	// the replicas come from the mgr count in the spec
+	c = New(&cephconfig.ClusterInfo{}, &clusterd.Context{}, "ns", "myversion", cephv1.CephVersionSpec{}, rookalpha.Placement{},
+		rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.DashboardSpec{}, cephv1.MonitoringSpec{}, cephv1.MgrSpec{Count: 5},
+		v1.ResourceRequirements{}, "", metav1.OwnerReference{}, "/var/lib/rook/", false, false)
+	assert.Equal(t, 5, c.Replicas)
+	assert.Equal(t, 5, len(c.getDaemonIDs()))
+}
+
+func TestConfigureNetworkBinding(t *testing.T) {
+	configSettings := map[string]string{}
+	configRemoved := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "config" && args[2] == "mgr" {
+				if args[1] == "set" {
+					configSettings[args[3]] = args[4]
+				}
+				if args[1] == "rm" {
+					configRemoved = append(configRemoved, args[3])
+				}
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		context:   &clusterd.Context{Executor: executor, Clientset: testop.New(1)},
+		Namespace: "ns",
+		mgrSpec:   cephv1.MgrSpec{PublicNetwork: "192.168.0.0/24", ClusterNetwork: "10.1.0.0/16"},
+	}
+
+	assert.NoError(t, c.validateNetworkBinding())
+	assert.NoError(t, c.configureNetworkBinding())
+	assert.Equal(t, map[string]string{"public_network": "192.168.0.0/24", "cluster_network": "10.1.0.0/16"}, configSettings)
+	assert.Equal(t, 0, len(configRemoved))
+
+	// networks that are not in the spec are cleared
+	configSettings = map[string]string{}
+	c.mgrSpec.ClusterNetwork = ""
+	assert.NoError(t, c.validateNetworkBinding())
+	assert.NoError(t, c.configureNetworkBinding())
+	assert.Equal(t, map[string]string{"public_network": "192.168.0.0/24"}, configSettings)
+	assert.Equal(t, []string{"cluster_network"}, configRemoved)
+
+	// invalid CIDRs are rejected
+	c.mgrSpec.PublicNetwork = "192.168.0.1"
+	assert.Error(t, c.validateNetworkBinding())
+	c.mgrSpec.PublicNetwork = ""
+	c.mgrSpec.ClusterNetwork = "10.1.0.0/99"
+	assert.Error(t, c.validateNetworkBinding())
+}
+
+func TestConfigurePrometheusModule(t *testing.T) {
+	configSettings := map[string]string{}
+	configRemoved := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "config" && args[2] == "mgr" {
+				if args[1] == "set" {
+					configSettings[args[3]] = args[4]
+				}
+				if args[1] == "rm" {
+					configRemoved = append(configRemoved, args[3])
+				}
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus},
+		context:     &clusterd.Context{Executor: executor, Clientset: testop.New(1)},
+		Namespace:   "ns",
+		mgrSpec: cephv1.MgrSpec{PrometheusModule: cephv1.PrometheusModuleSpec{
+			RBDStatsPools:                []string{"replicapool", "ecpool/ns1"},
+			RBDStatsPoolsRefreshInterval: 600,
+		}},
+	}
+
+	// enable the rbd stats for the pools
+	assert.NoError(t, c.validatePrometheusModule())
+	assert.NoError(t, c.configurePrometheusModule())
+	assert.Equal(t, map[string]string{
+		"mgr/prometheus/rbd_stats_pools":                  "replicapool,ecpool/ns1",
+		"mgr/prometheus/rbd_stats_pools_refresh_interval": "600",
+	}, configSettings)
+	assert.Equal(t, 0, len(configRemoved))
+
+	// disable the rbd stats
+	configSettings = map[string]string{}
+	c.mgrSpec.PrometheusModule = cephv1.PrometheusModuleSpec{}
+	assert.NoError(t, c.validatePrometheusModule())
+	assert.NoError(t, c.configurePrometheusModule())
+	assert.Equal(t, 0, len(configSettings))
+	assert.Equal(t, []string{"mgr/prometheus/rbd_stats_pools", "mgr/prometheus/rbd_stats_pools_refresh_interval"}, configRemoved)
+
+	// the settings are not supported before nautilus
+	configRemoved = []string{}
+	c.clusterInfo.CephVersion = cephver.Mimic
+	c.mgrSpec.PrometheusModule.RBDStatsPools = []string{"replicapool"}
+	assert.NoError(t, c.configurePrometheusModule())
+	assert.Equal(t, 0, len(configSettings))
+	assert.Equal(t, 0, len(configRemoved))
+
+	// invalid pools are rejected
+	for _, pool := range []string{"", "a,b", "a b", "/ns", "pool/", "a/b/c"} {
+		c.mgrSpec.PrometheusModule.RBDStatsPools = []string{pool}
+		assert.Error(t, c.validatePrometheusModule(), pool)
+	}
+	c.mgrSpec.PrometheusModule = cephv1.PrometheusModuleSpec{RBDStatsPoolsRefreshInterval: -1}
+	assert.Error(t, c.validatePrometheusModule())
+}
+
+func TestConfigureMetricsPort(t *testing.T) {
+	configSettings := map[string]string{}
+	modulesDisabled := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "config" && args[3] == "mgr/prometheus/server_port" {
+				switch args[1] {
+				case "get":
+					return configSettings[args[2]], nil
+				case "set":
+					configSettings[args[2]] = args[4]
+				}
+			}
+			if args[0] == "mgr" && args[1] == "module" && args[2] == "disable" {
+				modulesDisabled++
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Nautilus},
+		context:     &clusterd.Context{Executor: executor, Clientset: testop.New(1)},
+		Namespace:   "ns",
+		Replicas:    2,
+		mgrSpec:     cephv1.MgrSpec{PrometheusModule: cephv1.PrometheusModuleSpec{Port: 9300}},
+	}
+
+	// the port is set on each mgr and the module is restarted
+	assert.NoError(t, c.validatePrometheusModule())
+	assert.NoError(t, c.enablePrometheusModule())
+	assert.Equal(t, map[string]string{"mgr.a": "9300", "mgr.b": "9300"}, configSettings)
+	assert.Equal(t, 1, modulesDisabled)
+
+	// the module is not restarted if the port did not change
+	assert.NoError(t, c.enablePrometheusModule())
+	assert.Equal(t, 1, modulesDisabled)
+
+	// the port is used by the metrics service and the mgr container
+	service := c.makeMetricsService(AppName)
+	assert.Equal(t, int32(9300), service.Spec.Ports[0].Port)
+	container := c.makeMgrDaemonContainer(&mgrConfig{DaemonID: "a", ResourceName: "rook-ceph-mgr-a",
+		DataPathMap: config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "ns", "/var/lib/rook/")})
+	assert.Equal(t, int32(9300), container.Ports[1].ContainerPort)
+
+	// the default port is restored
+	c.mgrSpec.PrometheusModule.Port = 0
+	assert.NoError(t, c.enablePrometheusModule())
+	assert.Equal(t, map[string]string{"mgr.a": "9283", "mgr.b": "9283"}, configSettings)
+	assert.Equal(t, 2, modulesDisabled)
+	assert.Equal(t, int32(defaultMetricsPort), c.makeMetricsService(AppName).Spec.Ports[0].Port)
+
+	// invalid ports are rejected
+	c.mgrSpec.PrometheusModule.Port = 70000
+	assert.Error(t, c.validatePrometheusModule())
+}
+
+func TestServiceMonitorEndpoints(t *testing.T) {
+	c := &Cluster{Namespace: "ns", monitoringSpec: cephv1.MonitoringSpec{Enabled: true}}
+	service := c.makeMetricsService(AppName)
+
+	// the endpoint from the template is kept when no interval is set
+	serviceMonitor := &monitoringv1.ServiceMonitor{Spec: monitoringv1.ServiceMonitorSpec{
+		Endpoints: []monitoringv1.Endpoint{{Port: "metrics", Path: "/metrics", Interval: "5s"}},
+	}}
+	assert.NoError(t, c.validateMonitoring())
+	c.applyServiceMonitorEndpoints(serviceMonitor, service)
+	require.Equal(t, 1, len(serviceMonitor.Spec.Endpoints))
+	assert.Equal(t, "http-metrics", serviceMonitor.Spec.Endpoints[0].Port)
+	assert.Equal(t, "/metrics", serviceMonitor.Spec.Endpoints[0].Path)
+	assert.Equal(t, "5s", serviceMonitor.Spec.Endpoints[0].Interval)
+
+	// the interval from the spec overrides the template
+	c.monitoringSpec.Interval = "30s"
+	assert.NoError(t, c.validateMonitoring())
+	c.applyServiceMonitorEndpoints(serviceMonitor, service)
+	assert.Equal(t, "30s", serviceMonitor.Spec.Endpoints[0].Interval)
+
+	// an endpoint is added if the template has none
+	serviceMonitor = &monitoringv1.ServiceMonitor{}
+	c.applyServiceMonitorEndpoints(serviceMonitor, service)
+	require.Equal(t, 1, len(serviceMonitor.Spec.Endpoints))
+	assert.Equal(t, "http-metrics", serviceMonitor.Spec.Endpoints[0].Port)
+	assert.Equal(t, "30s", serviceMonitor.Spec.Endpoints[0].Interval)
+
+	// invalid intervals are rejected
+	for _, interval := range []string{"30", "abc", "0s", "-5s"} {
+		c.monitoringSpec.Interval = interval
+		assert.Error(t, c.validateMonitoring(), interval)
+	}
+}
+
+func TestFindPrometheusRuleFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "prometheus-ceph-v14-rules.yaml"), []byte{}, 0644))
+
+	// the rules for the running version are used
+	name, file, err := findPrometheusRuleFile(dir, prometheusRuleName, 14)
+	assert.NoError(t, err)
+	assert.Equal(t, "prometheus-ceph-v14-rules", name)
+	assert.Equal(t, path.Join(dir, "prometheus-ceph-v14-rules.yaml"), file)
+
+	// newer versions fall back to the most recent rules
+	name, file, err = findPrometheusRuleFile(dir, prometheusRuleName, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, "prometheus-ceph-v14-rules", name)
+	assert.Equal(t, path.Join(dir, "prometheus-ceph-v14-rules.yaml"), file)
+
+	// there are no rules for versions before nautilus
+	_, _, err = findPrometheusRuleFile(dir, prometheusRuleName, 13)
+	assert.Error(t, err)
+}
+
+func TestLastStartResult(t *testing.T) {
+	updateDeploymentAndWait, _ = testopk8s.UpdateDeploymentAndWaitStub()
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return "{\"key\":\"mysecurekey\"}", nil
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{FSID: "myfsid"},
+		context:     &clusterd.Context{Executor: executor, ConfigDir: configDir, Clientset: testop.New(1)},
+		Namespace:   "ns",
+		Replicas:    1,
+		exitCode:    getExitCode,
+	}
+
+	// nothing is reported before the first start
+	result := c.LastStartResult()
+	assert.True(t, result.Time.IsZero())
+	assert.False(t, result.Succeeded)
+
+	// a successful start
+	before := time.Now()
+	assert.NoError(t, c.Start())
+	result = c.LastStartResult()
+	assert.True(t, result.Succeeded)
+	assert.NoError(t, result.Err)
+	assert.False(t, result.Time.Before(before))
+	assert.Equal(t, result.Time, result.LastSuccess)
+	lastSuccess := result.LastSuccess
+
+	// a failed start keeps the time of the last success
+	c.mgrSpec.PublicNetwork = "invalid"
+	assert.Error(t, c.Start())
+	result = c.LastStartResult()
+	assert.False(t, result.Succeeded)
+	assert.Error(t, result.Err)
+	assert.True(t, result.Time.After(lastSuccess) || result.Time.Equal(lastSuccess))
+	assert.Equal(t, lastSuccess, result.LastSuccess)
+}
+
+func TestSuggestedRequeue(t *testing.T) {
+	updateDeploymentAndWait, _ = testopk8s.UpdateDeploymentAndWaitStub()
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return "{\"key\":\"mysecurekey\"}", nil
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	c := &Cluster{
+		clusterInfo:       &cephconfig.ClusterInfo{FSID: "myfsid"},
+		context:           &clusterd.Context{Executor: executor, ConfigDir: configDir, Clientset: testop.New(1)},
+		Namespace:         "ns",
+		Replicas:          1,
+		exitCode:          getExitCode,
+		ReconcileInterval: 5 * time.Minute,
+	}
+
+	// the mgr is not ready after it is created
+	assert.NoError(t, c.Start())
+	assert.Equal(t, unsettledRequeueDelay, c.LastStartResult().SuggestedRequeue)
+
+	// the mgr is ready
+	d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get("rook-ceph-mgr-a", metav1.GetOptions{})
+	require.NoError(t, err)
+	d.Status.ReadyReplicas = 1
+	_, err = c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(d)
+	require.NoError(t, err)
+	assert.NoError(t, c.Start())
+	assert.Equal(t, 5*time.Minute, c.LastStartResult().SuggestedRequeue)
+
+	// the orchestration failed
+	c.mgrSpec.PublicNetwork = "invalid"
+	assert.Error(t, c.Start())
+	assert.Equal(t, unsettledRequeueDelay, c.LastStartResult().SuggestedRequeue)
+	c.mgrSpec.PublicNetwork = ""
+
+	// the requeue is never later than the reconcile interval
+	c.ReconcileInterval = 10 * time.Second
+	assert.Equal(t, 10*time.Second, c.suggestRequeue(errors.NewBadRequest("failed")))
+
+	// the default interval is used if none is configured
+	c.ReconcileInterval = 0
+	assert.Equal(t, defaultReconcileInterval, c.ReconcileIntervalHint())
+	assert.Equal(t, defaultReconcileInterval, c.suggestRequeue(nil))
+}
+
+func TestReconcileIntervalFromSpec(t *testing.T) {
+	newCluster := func(interval string) *Cluster {
+		return New(&cephconfig.ClusterInfo{FSID: "myfsid"}, &clusterd.Context{}, "ns", "myversion", cephv1.CephVersionSpec{},
+			rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.DashboardSpec{}, cephv1.MonitoringSpec{},
+			cephv1.MgrSpec{ReconcileInterval: interval}, v1.ResourceRequirements{}, "", metav1.OwnerReference{}, "/var/lib/rook/", false, false)
+	}
+	assert.Equal(t, defaultReconcileInterval, newCluster("").ReconcileIntervalHint())
+	assert.Equal(t, 3*time.Minute, newCluster("3m").ReconcileIntervalHint())
+
+	// an invalid interval falls back to the default
+	assert.Equal(t, defaultReconcileInterval, newCluster("soon").ReconcileIntervalHint())
+	assert.Equal(t, defaultReconcileInterval, newCluster("-1m").ReconcileIntervalHint())
+
+	// the result of the last start is kept by the next instance
+	previous := newCluster("")
+	previous.recordStartResult(nil, time.Minute)
+	next := newCluster("")
+	next.InheritStartResult(previous)
+	assert.Equal(t, previous.LastStartResult(), next.LastStartResult())
+}
+
+func TestGenerateKeyringVerifiesCaps(t *testing.T) {
+	capsUpdated := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "auth" && args[1] == "get" {
+				// the existing key was created with different caps
+				return `[{"entity":"mgr.a","key":"mysecurekey","caps":{"mon":"allow r"}}]`, nil
+			}
+			if args[0] == "auth" && args[1] == "caps" {
+				assert.Equal(t, "mgr.a", args[2])
+				assert.Equal(t, []string{"mon", "allow profile mgr", "mds", "allow *", "osd", "allow *"}, args[3:9])
+				capsUpdated = true
+			}
+			return "{\"key\":\"mysecurekey\"}", nil
+		},
+	}
+	c := &Cluster{
+		context:   &clusterd.Context{Executor: executor, Clientset: testop.New(1)},
+		Namespace: "ns",
+	}
+	mgrConfig := &mgrConfig{DaemonID: "a", ResourceName: "rook-ceph-mgr-a"}
+
+	keyring, err := c.generateKeyring(mgrConfig)
+	assert.NoError(t, err)
+	assert.True(t, capsUpdated)
+	assert.Contains(t, keyring, `caps mon = "allow profile mgr"`)
+	assert.Contains(t, keyring, `caps osd = "allow *"`)
+
+	// the caps are only reported with the warn policy
+	capsUpdated = false
+	c.mgrSpec.CapsMismatchPolicy = "warn"
+	_, err = c.generateKeyring(mgrConfig)
+	assert.NoError(t, err)
+	assert.False(t, capsUpdated)
+}
+
+func TestGenerateKeyringRotatesKey(t *testing.T) {
+	key := "firstkey"
+	deleted := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "del" {
+				assert.Equal(t, "mgr.a", args[2])
+				deleted++
+				key = fmt.Sprintf("rotatedkey%d", deleted)
+				return "", nil
+			}
+			if args[0] == "auth" && args[1] == "get" {
+				return `[{"entity":"mgr.a","key":"key","caps":{"mon":"allow profile mgr","mds":"allow *","osd":"allow *"}}]`, nil
+			}
+			return fmt.Sprintf(`{"key":"%s"}`, key), nil
+		},
+	}
+	c := &Cluster{
+		context:   &clusterd.Context{Executor: executor, Clientset: testop.New(1)},
+		Namespace: "ns",
+	}
+	mgrConfig := &mgrConfig{DaemonID: "a", ResourceName: "rook-ceph-mgr-a"}
+	s := cephkeyring.GetSecretStore(c.context, c.Namespace, &c.ownerRef)
+	assertGeneration := func(expected int) {
+		generation, exists, err := s.KeyGeneration(mgrConfig.ResourceName)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, expected, generation)
+	}
+
+	// the first key is not rotated
+	keyring, err := c.generateKeyring(mgrConfig)
+	assert.NoError(t, err)
+	assert.Contains(t, keyring, "key = firstkey")
+	assertGeneration(0)
+	assert.Equal(t, 0, deleted)
+
+	// the key is rotated when the generation is increased
+	c.mgrSpec.KeyGeneration = 1
+	keyring, err = c.generateKeyring(mgrConfig)
+	assert.NoError(t, err)
+	assert.Contains(t, keyring, "key = rotatedkey1")
+	assertGeneration(1)
+	assert.Equal(t, 1, deleted)
+
+	// the key is only rotated once
+	keyring, err = c.generateKeyring(mgrConfig)
+	assert.NoError(t, err)
+	assert.Contains(t, keyring, "key = rotatedkey1")
+	assert.Equal(t, 1, deleted)
+
+	// the generation is not decreased
+	c.mgrSpec.KeyGeneration = 0
+	_, err = c.generateKeyring(mgrConfig)
+	assert.NoError(t, err)
+	assertGeneration(1)
+	assert.Equal(t, 1, deleted)
+
+	// the pods are restarted with the new key
+	c.mgrSpec.KeyGeneration = 2
+	podMeta := metav1.ObjectMeta{}
+	c.applyKeyGeneration(&podMeta)
+	assert.Equal(t, map[string]string{cephkeyring.KeyGenerationAnnotation: "2"}, podMeta.Annotations)
+}
+
+func TestMgrResources(t *testing.T) {
+	newCluster := func(resources v1.ResourceRequirements) *Cluster {
+		return New(&cephconfig.ClusterInfo{FSID: "myfsid"}, &clusterd.Context{Clientset: testop.New(1)}, "ns", "myversion",
+			cephv1.CephVersionSpec{}, rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.DashboardSpec{},
+			cephv1.MonitoringSpec{}, cephv1.MgrSpec{}, resources, "", metav1.OwnerReference{Kind: "CephCluster", Name: "rook-ceph"},
+			"/var/lib/rook/", false, false)
+	}
+
+	// the recommended resources are requested if none are set
+	c := newCluster(v1.ResourceRequirements{})
+	assert.Equal(t, "500m", c.resources.Requests.Cpu().String())
+	assert.Equal(t, "512Mi", c.resources.Requests.Memory().String())
+	assert.Equal(t, 0, len(c.resources.Limits))
+
+	// the resources that are set are not changed
+	resources := v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}
+	c = newCluster(resources)
+	assert.Equal(t, resources, c.resources)
+
+	// a memory limit below the minimum is reported on the cluster CR
+	c = newCluster(v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("256Mi")}})
+	assert.Error(t, c.Start())
+	events, err := c.context.Clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(events.Items))
+	assert.Equal(t, v1.EventTypeWarning, events.Items[0].Type)
+	assert.Equal(t, invalidResourcesReason, events.Items[0].Reason)
+	assert.Equal(t, "rook-ceph", events.Items[0].InvolvedObject.Name)
+	deployments, err := c.context.Clientset.AppsV1().Deployments("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(deployments.Items))
 }
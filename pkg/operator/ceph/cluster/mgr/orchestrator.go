@@ -22,6 +22,10 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -68,3 +72,30 @@ func (c *Cluster) setRookOrchestratorBackend() error {
 
 	return nil
 }
+
+// createServiceAccount creates the service account of the mgr pods if it does not exist. The rook module
+// talks to the Kubernetes API with this service account. The roles of the service account are not created
+// by the operator and must be bound as in common.yaml.
+func (c *Cluster) createServiceAccount() error {
+	serviceAccounts := c.context.Clientset.CoreV1().ServiceAccounts(c.Namespace)
+	_, err := serviceAccounts.Get(serviceAccountName, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get service account %q", serviceAccountName)
+	}
+
+	serviceAccount := &v1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceAccountName,
+			Namespace: c.Namespace,
+		},
+	}
+	k8sutil.SetOwnerRef(&serviceAccount.ObjectMeta, &c.ownerRef)
+	if _, err := serviceAccounts.Create(serviceAccount); err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create service account %q", serviceAccountName)
+	}
+	logger.Infof("created service account %q. the roles of the mgr must be bound to it for the rook module to manage the cluster", serviceAccountName)
+	return nil
+}
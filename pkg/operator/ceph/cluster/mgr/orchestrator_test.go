@@ -23,8 +23,10 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestOrchestratorModules(t *testing.T) {
@@ -103,3 +105,22 @@ func TestOrchestratorModules(t *testing.T) {
 	assert.True(t, rookModuleEnabled)
 	assert.True(t, rookBackendSet)
 }
+
+func TestCreateServiceAccount(t *testing.T) {
+	c := &Cluster{context: &clusterd.Context{Clientset: testop.New(1)}, Namespace: "ns"}
+
+	// the service account is created if it does not exist
+	assert.NoError(t, c.createServiceAccount())
+	serviceAccount, err := c.context.Clientset.CoreV1().ServiceAccounts("ns").Get(serviceAccountName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-mgr", serviceAccount.Name)
+
+	// an existing service account is kept
+	serviceAccount.Labels = map[string]string{"created-by": "admin"}
+	_, err = c.context.Clientset.CoreV1().ServiceAccounts("ns").Update(serviceAccount)
+	assert.NoError(t, err)
+	assert.NoError(t, c.createServiceAccount())
+	serviceAccount, err = c.context.Clientset.CoreV1().ServiceAccounts("ns").Get(serviceAccountName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", serviceAccount.Labels["created-by"])
+}
@@ -18,10 +18,12 @@ package mgr
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
 
+	"github.com/pkg/errors"
 	rookcephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
@@ -33,12 +35,24 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
 	podIPEnvVar = "ROOK_POD_IP"
+	// excludeFromAutoscalingAnnotation marks the mgr deployments that autoscaling tools must not target
+	excludeFromAutoscalingAnnotation = "rook.io/exclude-from-autoscaling"
+	// the projected token is mounted where the service account token is expected by the kubernetes clients
+	serviceAccountTokenVolumeName = "rook-ceph-mgr-token"
+	serviceAccountTokenMountPath  = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountTokenPath       = "token"
+	// the config map published in every namespace with the CA bundle of the API server
+	serviceAccountCAConfigMap = "kube-root-ca.crt"
+	serviceAccountCAKey       = "ca.crt"
+	// the minimum lifetime of a projected token accepted by the API server
+	minServiceAccountTokenExpiration int64 = 600
 )
 
 func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) *apps.Deployment {
@@ -86,7 +100,11 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) *apps.Deployment {
 	}
 	c.annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	c.applyPrometheusAnnotations(&podSpec.ObjectMeta)
+	c.applyCustomLabels(&podSpec.ObjectMeta)
+	c.applyKeyGeneration(&podSpec.ObjectMeta)
 	c.placement.ApplyToPodSpec(&podSpec.Spec)
+	c.applyPodAntiAffinity(&podSpec.Spec)
+	c.applyServiceAccountToken(&podSpec.Spec)
 
 	replicas := int32(1)
 
@@ -107,12 +125,185 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) *apps.Deployment {
 			},
 		},
 	}
+	c.applyDeploymentMetadata(&d.ObjectMeta)
 	k8sutil.AddRookVersionLabelToDeployment(d)
 	opspec.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, d)
 	k8sutil.SetOwnerRef(&d.ObjectMeta, &c.ownerRef)
+	k8sutil.ApplySpecHash(d)
 	return d
 }
 
+// applyDeploymentMetadata adds the labels and annotations from the mgr spec to the deployment. The labels
+// that Rook sets on the deployment can not be overridden.
+func (c *Cluster) applyDeploymentMetadata(objectMeta *metav1.ObjectMeta) {
+	for key, value := range c.mgrSpec.DeploymentLabels {
+		if _, ok := objectMeta.Labels[key]; ok {
+			logger.Warningf("cannot override the mgr deployment label %q", key)
+			continue
+		}
+		objectMeta.Labels[key] = value
+	}
+	if c.mgrSpec.ExcludeFromAutoscaling {
+		if objectMeta.Annotations == nil {
+			objectMeta.Annotations = map[string]string{}
+		}
+		objectMeta.Annotations[excludeFromAutoscalingAnnotation] = "true"
+	}
+}
+
+// applyCustomLabels adds the labels from the mgr spec. The labels that Rook sets can not be overridden.
+func (c *Cluster) applyCustomLabels(objectMeta *metav1.ObjectMeta) {
+	for key, value := range c.mgrSpec.Labels {
+		if _, ok := objectMeta.Labels[key]; ok {
+			logger.Warningf("cannot override the mgr label %q on %q", key, objectMeta.Name)
+			continue
+		}
+		objectMeta.Labels[key] = value
+	}
+}
+
+// applyKeyGeneration annotates the mgr pods with the key generation so the pods are restarted with the new
+// keys when the keys are rotated
+func (c *Cluster) applyKeyGeneration(objectMeta *metav1.ObjectMeta) {
+	if c.mgrSpec.KeyGeneration == 0 {
+		return
+	}
+	if objectMeta.Annotations == nil {
+		objectMeta.Annotations = map[string]string{}
+	}
+	objectMeta.Annotations[keyring.KeyGenerationAnnotation] = strconv.Itoa(c.mgrSpec.KeyGeneration)
+}
+
+// applyServiceMetadata adds the labels and annotations from the mgr spec to a service
+func (c *Cluster) applyServiceMetadata(objectMeta *metav1.ObjectMeta) {
+	c.applyCustomLabels(objectMeta)
+	if len(c.mgrSpec.ServiceAnnotations) == 0 {
+		return
+	}
+	if objectMeta.Annotations == nil {
+		objectMeta.Annotations = map[string]string{}
+	}
+	for key, value := range c.mgrSpec.ServiceAnnotations {
+		objectMeta.Annotations[key] = value
+	}
+}
+
+// syncServiceMetadata copies the labels and annotations of the desired service to the existing service.
+// Labels and annotations added to the existing service by others are kept. It returns whether the
+// existing service was changed.
+func syncServiceMetadata(existing, desired *v1.Service) bool {
+	changed := false
+	if existing.Labels == nil {
+		existing.Labels = map[string]string{}
+	}
+	for key, value := range desired.Labels {
+		if existing.Labels[key] != value {
+			existing.Labels[key] = value
+			changed = true
+		}
+	}
+	if existing.Annotations == nil {
+		existing.Annotations = map[string]string{}
+	}
+	for key, value := range desired.Annotations {
+		if existing.Annotations[key] != value {
+			existing.Annotations[key] = value
+			changed = true
+		}
+	}
+	return changed
+}
+
+// applyPodAntiAffinity spreads the mgrs across nodes so a standby is available if the node of the active mgr
+// fails. Pod anti-affinity in the mgr placement overrides the default rules.
+func (c *Cluster) applyPodAntiAffinity(pod *v1.PodSpec) {
+	if c.Replicas < 2 || c.placement.PodAntiAffinity != nil {
+		return
+	}
+
+	topologyKey := c.mgrSpec.AntiAffinityTopologyKey
+	if topologyKey == "" {
+		topologyKey = v1.LabelHostname
+	}
+
+	// label selector for mgrs used in anti-affinity rules
+	mgrAntiAffinity := v1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				k8sutil.AppAttr: AppName,
+			},
+		},
+		TopologyKey: topologyKey,
+	}
+
+	// ApplyToPodSpec ensures that pod.Affinity is non-nil
+	if pod.Affinity.PodAntiAffinity == nil {
+		pod.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+	}
+	paa := pod.Affinity.PodAntiAffinity
+
+	// mgrs on the host network would conflict on their ports if they were co-located
+	if c.Network.IsHost() || !c.mgrSpec.AllowMultiplePerNode {
+		paa.RequiredDuringSchedulingIgnoredDuringExecution =
+			append(paa.RequiredDuringSchedulingIgnoredDuringExecution, mgrAntiAffinity)
+	} else {
+		paa.PreferredDuringSchedulingIgnoredDuringExecution =
+			append(paa.PreferredDuringSchedulingIgnoredDuringExecution, v1.WeightedPodAffinityTerm{
+				Weight:          50,
+				PodAffinityTerm: mgrAntiAffinity,
+			})
+	}
+}
+
+// applyServiceAccountToken mounts a projected service account token in the mgr containers if requested. The
+// token replaces the auto-mounted one, so auto-mounting is disabled and the CA and namespace are projected
+// next to the token like in the auto-mounted volume.
+func (c *Cluster) applyServiceAccountToken(podSpec *v1.PodSpec) {
+	tokenSpec := c.mgrSpec.ServiceAccountToken
+	if tokenSpec == nil {
+		return
+	}
+
+	automount := false
+	podSpec.AutomountServiceAccountToken = &automount
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: serviceAccountTokenVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Projected: &v1.ProjectedVolumeSource{
+				Sources: []v1.VolumeProjection{
+					{
+						ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+							Audience:          tokenSpec.Audience,
+							ExpirationSeconds: tokenSpec.ExpirationSeconds,
+							Path:              serviceAccountTokenPath,
+						},
+					},
+					{
+						ConfigMap: &v1.ConfigMapProjection{
+							LocalObjectReference: v1.LocalObjectReference{Name: serviceAccountCAConfigMap},
+							Items:                []v1.KeyToPath{{Key: serviceAccountCAKey, Path: serviceAccountCAKey}},
+						},
+					},
+					{
+						DownwardAPI: &v1.DownwardAPIProjection{
+							Items: []v1.DownwardAPIVolumeFile{
+								{Path: "namespace", FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.namespace"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, v1.VolumeMount{
+			Name:      serviceAccountTokenVolumeName,
+			MountPath: serviceAccountTokenMountPath,
+			ReadOnly:  true,
+		})
+	}
+}
+
 func (c *Cluster) needHTTPBindFix() bool {
 	needed := true
 
@@ -238,7 +429,7 @@ func (c *Cluster) makeMgrDaemonContainer(mgrConfig *mgrConfig) v1.Container {
 			},
 			{
 				Name:          "http-metrics",
-				ContainerPort: int32(metricsPort),
+				ContainerPort: int32(c.metricsPort()),
 				Protocol:      v1.ProtocolTCP,
 			},
 			{
@@ -251,16 +442,9 @@ func (c *Cluster) makeMgrDaemonContainer(mgrConfig *mgrConfig) v1.Container {
 			opspec.DaemonEnvVars(c.cephVersion.Image),
 			c.cephMgrOrchestratorModuleEnvs()...,
 		),
-		Resources: c.resources,
-		LivenessProbe: &v1.Probe{
-			Handler: v1.Handler{
-				HTTPGet: &v1.HTTPGetAction{
-					Path: "/",
-					Port: intstr.FromInt(metricsPort),
-				},
-			},
-			InitialDelaySeconds: 60,
-		},
+		Resources:       c.resources,
+		LivenessProbe:   applyProbeOverride(c.makeMetricsProbe(60), c.mgrSpec.LivenessProbe),
+		ReadinessProbe:  applyProbeOverride(c.makeMetricsProbe(10), c.mgrSpec.ReadinessProbe),
 		SecurityContext: mon.PodSecurityContext(),
 	}
 
@@ -275,33 +459,76 @@ func (c *Cluster) makeMgrDaemonContainer(mgrConfig *mgrConfig) v1.Container {
 	return container
 }
 
-func (c *Cluster) makeMetricsService(name string) *v1.Service {
+// makeMetricsProbe returns a probe against the metrics endpoint, which is served by both the active
+// and the standby mgrs
+func (c *Cluster) makeMetricsProbe(initialDelaySeconds int32) *v1.Probe {
+	return &v1.Probe{
+		Handler: v1.Handler{
+			HTTPGet: &v1.HTTPGetAction{
+				Path: "/",
+				Port: intstr.FromInt(c.metricsPort()),
+			},
+		},
+		InitialDelaySeconds: initialDelaySeconds,
+	}
+}
+
+// applyProbeOverride returns the probe with the overrides from the cluster CRD. The probe is removed
+// if it is disabled, and the default handler is kept if the override does not set one.
+func applyProbeOverride(probe *v1.Probe, override *rookcephv1.ProbeSpec) *v1.Probe {
+	if override == nil {
+		return probe
+	}
+	if override.Disabled {
+		return nil
+	}
+	if override.Probe == nil {
+		return probe
+	}
+	custom := override.Probe.DeepCopy()
+	if custom.Handler == (v1.Handler{}) {
+		custom.Handler = probe.Handler
+	}
+	return custom
+}
+
+// activeMgrSelector selects the pod of the active mgr, which is labeled by the active mgr checker.
+// The standby mgrs do not serve the metrics or the dashboard.
+func (c *Cluster) activeMgrSelector() map[string]string {
 	labels := opspec.AppLabels(AppName, c.Namespace)
+	labels[mgrRoleLabel] = mgrRoleActive
+	return labels
+}
+
+func (c *Cluster) makeMetricsService(name string) *v1.Service {
+	labels := c.activeMgrSelector()
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: c.Namespace,
-			Labels:    labels,
+			Labels:    opspec.AppLabels(AppName, c.Namespace),
 		},
 		Spec: v1.ServiceSpec{
-			Selector: labels,
-			Type:     v1.ServiceTypeClusterIP,
+			Selector:  labels,
+			Type:      v1.ServiceTypeClusterIP,
+			ClusterIP: c.mgrSpec.MetricsClusterIP,
 			Ports: []v1.ServicePort{
 				{
 					Name:     "http-metrics",
-					Port:     int32(metricsPort),
+					Port:     int32(c.metricsPort()),
 					Protocol: v1.ProtocolTCP,
 				},
 			},
 		},
 	}
 
+	c.applyServiceMetadata(&svc.ObjectMeta)
 	k8sutil.SetOwnerRef(&svc.ObjectMeta, &c.ownerRef)
 	return svc
 }
 
 func (c *Cluster) makeDashboardService(name string) *v1.Service {
-	labels := opspec.AppLabels(AppName, c.Namespace)
+	labels := c.activeMgrSelector()
 	portName := "https-dashboard"
 	if !c.dashboard.SSL {
 		portName = "dashboard"
@@ -310,11 +537,12 @@ func (c *Cluster) makeDashboardService(name string) *v1.Service {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-dashboard", name),
 			Namespace: c.Namespace,
-			Labels:    labels,
+			Labels:    opspec.AppLabels(AppName, c.Namespace),
 		},
 		Spec: v1.ServiceSpec{
-			Selector: labels,
-			Type:     v1.ServiceTypeClusterIP,
+			Selector:  labels,
+			Type:      c.dashboardServiceType(),
+			ClusterIP: c.dashboard.ClusterIP,
 			Ports: []v1.ServicePort{
 				{
 					Name:     portName,
@@ -324,10 +552,131 @@ func (c *Cluster) makeDashboardService(name string) *v1.Service {
 			},
 		},
 	}
+	if svc.Spec.Type != v1.ServiceTypeClusterIP {
+		svc.Spec.Ports[0].NodePort = int32(c.dashboard.NodePort)
+	}
+	if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
+		svc.Spec.LoadBalancerSourceRanges = c.dashboard.LoadBalancerSourceRanges
+	}
+	c.applyServiceMetadata(&svc.ObjectMeta)
 	k8sutil.SetOwnerRef(&svc.ObjectMeta, &c.ownerRef)
 	return svc
 }
 
+func (c *Cluster) makeDashboardIngress(service *v1.Service) *networking.Ingress {
+	spec := c.dashboard.Ingress
+	path := c.dashboard.UrlPrefix
+	if path == "" {
+		path = "/"
+	}
+	ingress := &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        service.Name,
+			Namespace:   c.Namespace,
+			Labels:      opspec.AppLabels(AppName, c.Namespace),
+			Annotations: spec.Annotations,
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{
+				{
+					Host: spec.Host,
+					IngressRuleValue: networking.IngressRuleValue{
+						HTTP: &networking.HTTPIngressRuleValue{
+							Paths: []networking.HTTPIngressPath{
+								{
+									Path: path,
+									Backend: networking.IngressBackend{
+										ServiceName: service.Name,
+										ServicePort: intstr.FromInt(int(service.Spec.Ports[0].Port)),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if spec.TLSSecretName != "" {
+		tls := networking.IngressTLS{SecretName: spec.TLSSecretName}
+		if spec.Host != "" {
+			tls.Hosts = []string{spec.Host}
+		}
+		ingress.Spec.TLS = []networking.IngressTLS{tls}
+	}
+	k8sutil.SetOwnerRef(&ingress.ObjectMeta, &c.ownerRef)
+	return ingress
+}
+
+// validateServiceClusterIPs checks that the cluster IPs requested for the mgr services are valid IP addresses
+func (c *Cluster) validateServiceClusterIPs() error {
+	if ip := c.mgrSpec.MetricsClusterIP; ip != "" && net.ParseIP(ip) == nil {
+		return errors.Errorf("invalid cluster IP %q for the metrics service", ip)
+	}
+	if ip := c.dashboard.ClusterIP; ip != "" && net.ParseIP(ip) == nil {
+		return errors.Errorf("invalid cluster IP %q for the dashboard service", ip)
+	}
+	return nil
+}
+
+func (c *Cluster) dashboardServiceType() v1.ServiceType {
+	if c.dashboard.ServiceType == "" {
+		return v1.ServiceTypeClusterIP
+	}
+	return v1.ServiceType(c.dashboard.ServiceType)
+}
+
+// validateDashboardService checks the type of the dashboard service and the settings that only apply to
+// some of the types
+func (c *Cluster) validateDashboardService() error {
+	serviceType := c.dashboardServiceType()
+	switch serviceType {
+	case v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+	default:
+		return errors.Errorf("invalid dashboard service type %q. expected ClusterIP, NodePort or LoadBalancer", serviceType)
+	}
+	if c.dashboard.NodePort != 0 {
+		if serviceType == v1.ServiceTypeClusterIP {
+			return errors.Errorf("the dashboard node port requires a NodePort or LoadBalancer service")
+		}
+		if c.dashboard.NodePort < 0 || c.dashboard.NodePort > 65535 {
+			return errors.Errorf("invalid dashboard node port %d", c.dashboard.NodePort)
+		}
+	}
+	if len(c.dashboard.LoadBalancerSourceRanges) > 0 && serviceType != v1.ServiceTypeLoadBalancer {
+		return errors.Errorf("the dashboard load balancer source ranges require a LoadBalancer service")
+	}
+	for _, cidr := range c.dashboard.LoadBalancerSourceRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return errors.Errorf("invalid dashboard load balancer source range %q", cidr)
+		}
+	}
+	return nil
+}
+
+// validateServiceAccountToken checks that the lifetime requested for the projected service account token is
+// accepted by the API server
+func (c *Cluster) validateServiceAccountToken() error {
+	tokenSpec := c.mgrSpec.ServiceAccountToken
+	if tokenSpec == nil || tokenSpec.ExpirationSeconds == nil {
+		return nil
+	}
+	if *tokenSpec.ExpirationSeconds < minServiceAccountTokenExpiration {
+		return errors.Errorf("service account token expiration of %d seconds is less than the minimum of %d seconds",
+			*tokenSpec.ExpirationSeconds, minServiceAccountTokenExpiration)
+	}
+	return nil
+}
+
+// warnIfClusterIPChanged logs a warning if the cluster IP requested for a service differs from the IP that the
+// existing service was assigned. The cluster IP of a service is immutable, so the existing IP is always kept.
+func warnIfClusterIPChanged(existing *v1.Service, requestedIP string) {
+	if requestedIP != "" && existing.Spec.ClusterIP != requestedIP {
+		logger.Warningf("the cluster IP of service %q cannot be changed from %q to %q. delete the service to apply the new cluster IP",
+			existing.Name, existing.Spec.ClusterIP, requestedIP)
+	}
+}
+
 func (c *Cluster) getPodLabels(daemonName string) map[string]string {
 	labels := opspec.PodLabels(AppName, c.Namespace, "mgr", daemonName)
 	// leave "instance" key for legacy usage
@@ -339,7 +688,7 @@ func (c *Cluster) applyPrometheusAnnotations(objectMeta *metav1.ObjectMeta) erro
 	if len(c.annotations) == 0 {
 		t := rookalpha.Annotations{
 			"prometheus.io/scrape": "true",
-			"prometheus.io/port":   strconv.Itoa(metricsPort),
+			"prometheus.io/port":   strconv.Itoa(c.metricsPort()),
 		}
 
 		t.ApplyToObjectMeta(objectMeta)
@@ -26,11 +26,14 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	optest "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestPodSpec(t *testing.T) {
@@ -244,7 +247,8 @@ func TestApplyPrometheusAnnotations(t *testing.T) {
 
 	// Test without annotations
 	c.applyPrometheusAnnotations(&d.ObjectMeta)
-	assert.Equal(t, 2, len(d.ObjectMeta.Annotations))
+	// the prometheus annotations are added to the spec hash
+	assert.Equal(t, 3, len(d.ObjectMeta.Annotations))
 
 	// Test with existing annotations
 	// applyPrometheusAnnotations() shouldn't do anything
@@ -258,5 +262,304 @@ func TestApplyPrometheusAnnotations(t *testing.T) {
 
 	c.applyPrometheusAnnotations(&d.ObjectMeta)
 	assert.Equal(t, 1, len(c.annotations))
-	assert.Equal(t, 0, len(d.ObjectMeta.Annotations))
+	assert.Equal(t, 1, len(d.ObjectMeta.Annotations))
+	assert.Contains(t, d.ObjectMeta.Annotations, k8sutil.SpecHashAnnotation)
+}
+
+func TestServiceClusterIP(t *testing.T) {
+	c := &Cluster{
+		Namespace: "ns",
+		mgrSpec:   cephv1.MgrSpec{MetricsClusterIP: "10.0.0.10"},
+		dashboard: cephv1.DashboardSpec{Enabled: true, ClusterIP: "10.0.0.11"},
+	}
+	assert.NoError(t, c.validateServiceClusterIPs())
+
+	s := c.makeMetricsService(AppName)
+	assert.Equal(t, "10.0.0.10", s.Spec.ClusterIP)
+	s = c.makeDashboardService(AppName)
+	assert.Equal(t, "10.0.0.11", s.Spec.ClusterIP)
+
+	// no cluster IP is requested by default
+	c.mgrSpec.MetricsClusterIP = ""
+	c.dashboard.ClusterIP = ""
+	assert.NoError(t, c.validateServiceClusterIPs())
+	assert.Equal(t, "", c.makeMetricsService(AppName).Spec.ClusterIP)
+	assert.Equal(t, "", c.makeDashboardService(AppName).Spec.ClusterIP)
+
+	// invalid IPs are rejected
+	c.mgrSpec.MetricsClusterIP = "10.0.0"
+	assert.Error(t, c.validateServiceClusterIPs())
+	c.mgrSpec.MetricsClusterIP = ""
+	c.dashboard.ClusterIP = "not-an-ip"
+	assert.Error(t, c.validateServiceClusterIPs())
+}
+
+func TestDeploymentLabelsAndAnnotations(t *testing.T) {
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{FSID: "myfsid"},
+		Namespace:   "ns",
+		mgrSpec: cephv1.MgrSpec{
+			DeploymentLabels:       map[string]string{"team": "storage", "app": "overridden"},
+			ExcludeFromAutoscaling: true,
+		},
+	}
+	mgrTestConfig := mgrConfig{
+		DaemonID:     "a",
+		ResourceName: "rook-ceph-mgr-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook/"),
+	}
+
+	d := c.makeDeployment(&mgrTestConfig)
+	assert.Equal(t, "storage", d.Labels["team"])
+	// the labels set by rook are not overridden
+	assert.Equal(t, AppName, d.Labels["app"])
+	assert.Equal(t, "true", d.Annotations[excludeFromAutoscalingAnnotation])
+	// the labels only apply to the deployment
+	_, ok := d.Spec.Selector.MatchLabels["team"]
+	assert.False(t, ok)
+	_, ok = d.Spec.Template.Labels["team"]
+	assert.False(t, ok)
+
+	c.mgrSpec = cephv1.MgrSpec{}
+	d = c.makeDeployment(&mgrTestConfig)
+	_, ok = d.Labels["team"]
+	assert.False(t, ok)
+	_, ok = d.Annotations[excludeFromAutoscalingAnnotation]
+	assert.False(t, ok)
+}
+
+func TestDeploymentChanged(t *testing.T) {
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{FSID: "myfsid"},
+		Namespace:   "ns",
+	}
+	mgrTestConfig := mgrConfig{
+		DaemonID:     "a",
+		ResourceName: "rook-ceph-mgr-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook/"),
+	}
+
+	existing := c.makeDeployment(&mgrTestConfig)
+	assert.False(t, k8sutil.DeploymentChanged(existing, c.makeDeployment(&mgrTestConfig)))
+
+	// the image changed
+	c.cephVersion.Image = "ceph/ceph:v14.2.5"
+	assert.True(t, k8sutil.DeploymentChanged(existing, c.makeDeployment(&mgrTestConfig)))
+
+	// the resources changed
+	c.cephVersion.Image = ""
+	c.resources = v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}
+	assert.True(t, k8sutil.DeploymentChanged(existing, c.makeDeployment(&mgrTestConfig)))
+
+	// the placement changed
+	c.resources = v1.ResourceRequirements{}
+	c.placement = rookalpha.Placement{Tolerations: []v1.Toleration{{Key: "storage", Operator: v1.TolerationOpExists}}}
+	assert.True(t, k8sutil.DeploymentChanged(existing, c.makeDeployment(&mgrTestConfig)))
+
+	// a deployment created before the hash was recorded is updated
+	c.placement = rookalpha.Placement{}
+	delete(existing.Annotations, k8sutil.SpecHashAnnotation)
+	assert.True(t, k8sutil.DeploymentChanged(existing, c.makeDeployment(&mgrTestConfig)))
+}
+
+func TestMgrLabelsAndServiceAnnotations(t *testing.T) {
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{FSID: "myfsid"},
+		Namespace:   "ns",
+		mgrSpec: cephv1.MgrSpec{
+			Labels:             map[string]string{"cost-center": "storage", "app": "overridden"},
+			ServiceAnnotations: map[string]string{"sidecar.istio.io/inject": "false"},
+		},
+	}
+	mgrTestConfig := mgrConfig{
+		DaemonID:     "a",
+		ResourceName: "rook-ceph-mgr-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook/"),
+	}
+
+	// the labels are set on the pods, but not in the deployment selector
+	d := c.makeDeployment(&mgrTestConfig)
+	assert.Equal(t, "storage", d.Spec.Template.Labels["cost-center"])
+	assert.Equal(t, AppName, d.Spec.Template.Labels["app"])
+	_, ok := d.Spec.Selector.MatchLabels["cost-center"]
+	assert.False(t, ok)
+
+	// the labels and annotations are set on the services, but not in the service selectors
+	for _, service := range []*v1.Service{c.makeMetricsService(AppName), c.makeDashboardService(AppName)} {
+		assert.Equal(t, "storage", service.Labels["cost-center"], service.Name)
+		assert.Equal(t, AppName, service.Labels["app"], service.Name)
+		assert.Equal(t, "false", service.Annotations["sidecar.istio.io/inject"], service.Name)
+		assert.Equal(t, map[string]string{"app": AppName, "rook_cluster": "ns", "mgr_role": "active"}, service.Spec.Selector, service.Name)
+	}
+
+	// the metadata is added to an existing service and the metadata added by others is kept
+	existing := &v1.Service{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"other": "label"}}}
+	assert.True(t, syncServiceMetadata(existing, c.makeMetricsService(AppName)))
+	assert.Equal(t, "label", existing.Labels["other"])
+	assert.Equal(t, "storage", existing.Labels["cost-center"])
+	assert.Equal(t, "false", existing.Annotations["sidecar.istio.io/inject"])
+	assert.False(t, syncServiceMetadata(existing, c.makeMetricsService(AppName)))
+}
+
+func TestProjectedServiceAccountToken(t *testing.T) {
+	expiration := int64(3600)
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{FSID: "myfsid"},
+		Namespace:   "ns",
+		mgrSpec: cephv1.MgrSpec{
+			ServiceAccountToken: &cephv1.ProjectedServiceAccountTokenSpec{Audience: "vault", ExpirationSeconds: &expiration},
+		},
+	}
+	mgrTestConfig := mgrConfig{
+		DaemonID:     "a",
+		ResourceName: "rook-ceph-mgr-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook/"),
+	}
+	assert.NoError(t, c.validateServiceAccountToken())
+
+	d := c.makeDeployment(&mgrTestConfig)
+	podSpec := d.Spec.Template.Spec
+	require.NotNil(t, podSpec.AutomountServiceAccountToken)
+	assert.False(t, *podSpec.AutomountServiceAccountToken)
+
+	var tokenVolume *v1.Volume
+	for i, volume := range podSpec.Volumes {
+		if volume.Name == serviceAccountTokenVolumeName {
+			tokenVolume = &podSpec.Volumes[i]
+		}
+	}
+	require.NotNil(t, tokenVolume)
+	require.NotNil(t, tokenVolume.Projected)
+	token := tokenVolume.Projected.Sources[0].ServiceAccountToken
+	require.NotNil(t, token)
+	assert.Equal(t, "vault", token.Audience)
+	assert.Equal(t, int64(3600), *token.ExpirationSeconds)
+	assert.Equal(t, serviceAccountTokenPath, token.Path)
+	require.Equal(t, 3, len(tokenVolume.Projected.Sources))
+	ca := tokenVolume.Projected.Sources[1].ConfigMap
+	require.NotNil(t, ca)
+	assert.Equal(t, "kube-root-ca.crt", ca.Name)
+	assert.Equal(t, []v1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}}, ca.Items)
+	namespace := tokenVolume.Projected.Sources[2].DownwardAPI
+	require.NotNil(t, namespace)
+	assert.Equal(t, "namespace", namespace.Items[0].Path)
+	assert.Equal(t, "metadata.namespace", namespace.Items[0].FieldRef.FieldPath)
+
+	mounted := false
+	for _, mount := range podSpec.Containers[0].VolumeMounts {
+		if mount.Name == serviceAccountTokenVolumeName {
+			mounted = true
+			assert.Equal(t, serviceAccountTokenMountPath, mount.MountPath)
+			assert.True(t, mount.ReadOnly)
+		}
+	}
+	assert.True(t, mounted)
+
+	// the lifetime of the token is too short
+	expiration = 60
+	assert.Error(t, c.validateServiceAccountToken())
+
+	// the token is auto-mounted by default
+	c.mgrSpec = cephv1.MgrSpec{}
+	d = c.makeDeployment(&mgrTestConfig)
+	assert.Nil(t, d.Spec.Template.Spec.AutomountServiceAccountToken)
+	for _, volume := range d.Spec.Template.Spec.Volumes {
+		assert.NotEqual(t, serviceAccountTokenVolumeName, volume.Name)
+	}
+}
+
+func TestMgrPodAntiAffinity(t *testing.T) {
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{FSID: "myfsid"},
+		Namespace:   "ns",
+		Replicas:    2,
+	}
+	mgrTestConfig := mgrConfig{
+		DaemonID:     "a",
+		ResourceName: "rook-ceph-mgr-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook/"),
+	}
+
+	// the mgrs are required to run on different nodes by default
+	d := c.makeDeployment(&mgrTestConfig)
+	paa := d.Spec.Template.Spec.Affinity.PodAntiAffinity
+	require.NotNil(t, paa)
+	require.Equal(t, 1, len(paa.RequiredDuringSchedulingIgnoredDuringExecution))
+	assert.Equal(t, 0, len(paa.PreferredDuringSchedulingIgnoredDuringExecution))
+	term := paa.RequiredDuringSchedulingIgnoredDuringExecution[0]
+	assert.Equal(t, v1.LabelHostname, term.TopologyKey)
+	assert.Equal(t, map[string]string{"app": AppName}, term.LabelSelector.MatchLabels)
+
+	// multiple mgrs per node are only discouraged, across the configured failure domain
+	c.mgrSpec.AllowMultiplePerNode = true
+	c.mgrSpec.AntiAffinityTopologyKey = "failure-domain.beta.kubernetes.io/zone"
+	d = c.makeDeployment(&mgrTestConfig)
+	paa = d.Spec.Template.Spec.Affinity.PodAntiAffinity
+	assert.Equal(t, 0, len(paa.RequiredDuringSchedulingIgnoredDuringExecution))
+	require.Equal(t, 1, len(paa.PreferredDuringSchedulingIgnoredDuringExecution))
+	assert.Equal(t, "failure-domain.beta.kubernetes.io/zone", paa.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey)
+
+	// host networking always requires the mgrs on different nodes
+	c.Network.HostNetwork = true
+	d = c.makeDeployment(&mgrTestConfig)
+	assert.Equal(t, 1, len(d.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution))
+	c.Network.HostNetwork = false
+
+	// the anti-affinity from the placement overrides the default
+	placementTerm := v1.PodAffinityTerm{TopologyKey: "rack"}
+	c.placement = rookalpha.Placement{PodAntiAffinity: &v1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{placementTerm},
+	}}
+	d = c.makeDeployment(&mgrTestConfig)
+	paa = d.Spec.Template.Spec.Affinity.PodAntiAffinity
+	assert.Equal(t, []v1.PodAffinityTerm{placementTerm}, paa.RequiredDuringSchedulingIgnoredDuringExecution)
+	assert.Equal(t, 0, len(paa.PreferredDuringSchedulingIgnoredDuringExecution))
+
+	// no anti-affinity for a single mgr
+	c.placement = rookalpha.Placement{}
+	c.Replicas = 1
+	d = c.makeDeployment(&mgrTestConfig)
+	assert.Nil(t, d.Spec.Template.Spec.Affinity.PodAntiAffinity)
+}
+
+func TestMgrProbes(t *testing.T) {
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{FSID: "myfsid"},
+		Namespace:   "ns",
+	}
+	mgrTestConfig := mgrConfig{
+		DaemonID:     "a",
+		ResourceName: "rook-ceph-mgr-a",
+		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook/"),
+	}
+
+	// both probes check the metrics endpoint by default
+	container := c.makeMgrDaemonContainer(&mgrTestConfig)
+	require.NotNil(t, container.LivenessProbe)
+	require.NotNil(t, container.ReadinessProbe)
+	assert.Equal(t, intstr.FromInt(defaultMetricsPort), container.LivenessProbe.HTTPGet.Port)
+	assert.Equal(t, int32(60), container.LivenessProbe.InitialDelaySeconds)
+	assert.Equal(t, intstr.FromInt(defaultMetricsPort), container.ReadinessProbe.HTTPGet.Port)
+	assert.Equal(t, int32(10), container.ReadinessProbe.InitialDelaySeconds)
+
+	// the probe settings are overridden and the default handler is kept
+	c.mgrSpec.LivenessProbe = &cephv1.ProbeSpec{Probe: &v1.Probe{InitialDelaySeconds: 120, FailureThreshold: 5}}
+	container = c.makeMgrDaemonContainer(&mgrTestConfig)
+	require.NotNil(t, container.LivenessProbe.HTTPGet)
+	assert.Equal(t, intstr.FromInt(defaultMetricsPort), container.LivenessProbe.HTTPGet.Port)
+	assert.Equal(t, int32(120), container.LivenessProbe.InitialDelaySeconds)
+	assert.Equal(t, int32(5), container.LivenessProbe.FailureThreshold)
+
+	// a custom handler replaces the default one
+	c.mgrSpec.LivenessProbe.Probe.Handler = v1.Handler{TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(6800)}}
+	container = c.makeMgrDaemonContainer(&mgrTestConfig)
+	assert.Nil(t, container.LivenessProbe.HTTPGet)
+	assert.Equal(t, intstr.FromInt(6800), container.LivenessProbe.TCPSocket.Port)
+
+	// the probes can be disabled
+	c.mgrSpec.LivenessProbe = &cephv1.ProbeSpec{Disabled: true}
+	c.mgrSpec.ReadinessProbe = &cephv1.ProbeSpec{Disabled: true}
+	container = c.makeMgrDaemonContainer(&mgrTestConfig)
+	assert.Nil(t, container.LivenessProbe)
+	assert.Nil(t, container.ReadinessProbe)
 }
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+)
+
+var (
+	// the number of times to check for an active mgr after a mgr deployment is updated
+	upgradeFailoverRetries = 30
+	// the time to wait between each check for an active mgr
+	upgradeFailoverInterval = 5 * time.Second
+)
+
+// Upgrade updates the mgrs to the target Ceph version one deployment at a time. The version of the
+// target image must already have been detected. Before any deployment is updated the cluster must be
+// HEALTH_OK with an available active mgr, unless upgrade checks are skipped. After each deployment is
+// updated, an active mgr must be available again before moving on to the next one. The upgrade is
+// complete when `ceph versions` reports that all the mgrs are running the target version.
+func (c *Cluster) Upgrade(target cephv1.CephVersionSpec) error {
+	lock := getStartLock(c.Namespace)
+	lock.Lock()
+	defer lock.Unlock()
+
+	targetVersion, ok := cephver.GetImageVersion(target.Image)
+	if !ok {
+		return errors.Errorf("the ceph version of image %q is unknown. it must be detected before upgrading the mgrs", target.Image)
+	}
+
+	daemon := string(config.MgrType)
+	currentVersion, err := client.LeastUptodateDaemonVersion(c.context, c.clusterInfo.Name, daemon)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve the current ceph %q version", daemon)
+	}
+
+	if c.skipUpgradeChecks {
+		logger.Warningf("not performing mgr upgrade pre-checks because skipUpgradeChecks is %t", c.skipUpgradeChecks)
+	} else if err := c.checkUpgradeReady(); err != nil {
+		return errors.Wrapf(err, "mgr upgrade to ceph version %s blocked", targetVersion.String())
+	}
+
+	logger.Infof("upgrading the mgrs from ceph version %s to %s", currentVersion.String(), targetVersion.String())
+	c.cephVersion = target
+	c.clusterInfo.CephVersion = *targetVersion
+	c.isUpgrade = true
+
+	for _, daemonID := range c.getDaemonIDs() {
+		resourceName := fmt.Sprintf("%s-%s", AppName, daemonID)
+		mgrConfig := &mgrConfig{
+			DaemonID:     daemonID,
+			ResourceName: resourceName,
+			DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, daemonID, c.Namespace, c.dataDirHostPath),
+		}
+
+		d := c.makeDeployment(mgrConfig)
+		logger.Infof("upgrading mgr deployment %q", resourceName)
+		if err := updateDeploymentAndWait(c.context, d, c.Namespace, daemon, daemonID, currentVersion, c.isUpgrade, c.skipUpgradeChecks); err != nil {
+			return errors.Wrapf(err, "failed to upgrade mgr deployment %q", resourceName)
+		}
+
+		if err := c.waitForActiveMgr(); err != nil {
+			return errors.Wrapf(err, "no active mgr after upgrading mgr deployment %q", resourceName)
+		}
+	}
+
+	if err := c.verifyMgrVersion(*targetVersion); err != nil {
+		return errors.Wrap(err, "failed to confirm the mgr upgrade")
+	}
+	logger.Infof("successfully upgraded the mgrs to ceph version %s", targetVersion.String())
+	return nil
+}
+
+// checkUpgradeReady returns an error if the cluster health is not OK or there is no active mgr
+func (c *Cluster) checkUpgradeReady() error {
+	status, err := client.Status(c.context, c.Namespace, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to get ceph status")
+	}
+	if status.Health.Status != client.CephHealthOK {
+		return errors.Errorf("cluster health is %q", status.Health.Status)
+	}
+	if !isMgrActive(status) {
+		return errors.New("there is no active mgr")
+	}
+	return nil
+}
+
+// waitForActiveMgr waits for a mgr to be reported as active after a failover
+func (c *Cluster) waitForActiveMgr() error {
+	for i := 0; i < upgradeFailoverRetries; i++ {
+		status, err := client.Status(c.context, c.Namespace, false)
+		if err != nil {
+			logger.Warningf("failed to get ceph status while waiting for an active mgr. %v", err)
+		} else if isMgrActive(status) {
+			logger.Infof("mgr %q is active", status.MgrMap.ActiveName)
+			return nil
+		}
+		if i < upgradeFailoverRetries-1 {
+			time.Sleep(upgradeFailoverInterval)
+		}
+	}
+	return errors.Errorf("timed out waiting for an active mgr after %d checks", upgradeFailoverRetries)
+}
+
+// verifyMgrVersion returns an error if any mgr is not running the expected version
+func (c *Cluster) verifyMgrVersion(expected cephver.CephVersion) error {
+	versions, err := client.GetAllCephDaemonVersions(c.context, c.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get ceph daemon versions")
+	}
+	if len(versions.Mgr) == 0 {
+		return errors.New("no mgr versions reported")
+	}
+	for v := range versions.Mgr {
+		running, err := cephver.ExtractCephVersion(v)
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract the ceph version from %q", v)
+		}
+		if !cephver.IsIdentical(*running, expected) {
+			return errors.Errorf("mgrs are running version %s, expected %s", running.String(), expected.String())
+		}
+	}
+	return nil
+}
+
+func isMgrActive(status client.CephStatus) bool {
+	return status.MgrMap.Available && status.MgrMap.ActiveName != ""
+}
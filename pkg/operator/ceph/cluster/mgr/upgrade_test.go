@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	oldMgrVersion = "ceph version 14.2.4 (75f4de193b3ea58512f204623e6c5a16e6c1e1ba) nautilus (stable)"
+	newMgrVersion = "ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)"
+	upgradeImage  = "ceph/ceph:v14.2.5"
+)
+
+func newUpgradeTestCluster(executor *exectest.MockExecutor) *Cluster {
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(3)}
+	c := New(
+		&cephconfig.ClusterInfo{FSID: "myfsid", CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 4}},
+		context,
+		"ns",
+		"myversion",
+		cephv1.CephVersionSpec{Image: "ceph/ceph:v14.2.4"},
+		rookalpha.Placement{},
+		rookalpha.Annotations{},
+		cephv1.NetworkSpec{},
+		cephv1.DashboardSpec{},
+		cephv1.MonitoringSpec{},
+		cephv1.MgrSpec{},
+		v1.ResourceRequirements{},
+		"",
+		metav1.OwnerReference{},
+		"/var/lib/rook/",
+		false,
+		false,
+	)
+	c.Replicas = 2
+	return c
+}
+
+func mgrStatusResponse(health string, available bool) string {
+	return fmt.Sprintf(`{"health":{"status":"%s"},"mgrmap":{"available":%t,"active_name":"a"}}`, health, available)
+}
+
+func mgrVersionsResponse(version string) string {
+	return fmt.Sprintf(`{"mgr":{"%s":2}}`, version)
+}
+
+func TestUpgradeBlocked(t *testing.T) {
+	var deploymentsUpdated *[]*apps.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+	cephver.RegisterImageVersion(upgradeImage, cephver.CephVersion{Major: 14, Minor: 2, Extra: 5})
+
+	health := "HEALTH_WARN"
+	available := true
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			switch args[0] {
+			case "status":
+				return mgrStatusResponse(health, available), nil
+			case "versions":
+				return mgrVersionsResponse(oldMgrVersion), nil
+			}
+			return "", nil
+		},
+	}
+	c := newUpgradeTestCluster(executor)
+
+	// the cluster is not healthy
+	err := c.Upgrade(cephv1.CephVersionSpec{Image: upgradeImage})
+	assert.Error(t, err)
+	assert.Empty(t, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	assert.Equal(t, "ceph/ceph:v14.2.4", c.cephVersion.Image)
+
+	// the cluster is healthy but there is no active mgr
+	health = "HEALTH_OK"
+	available = false
+	err = c.Upgrade(cephv1.CephVersionSpec{Image: upgradeImage})
+	assert.Error(t, err)
+	assert.Empty(t, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+
+	// the version of the image has not been detected
+	available = true
+	err = c.Upgrade(cephv1.CephVersionSpec{Image: "ceph/ceph:unknown"})
+	assert.Error(t, err)
+	assert.Empty(t, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+}
+
+func TestUpgradeStaged(t *testing.T) {
+	var deploymentsUpdated *[]*apps.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+	cephver.RegisterImageVersion(upgradeImage, cephver.CephVersion{Major: 14, Minor: 2, Extra: 5})
+	defer func(retries int, interval time.Duration) {
+		upgradeFailoverRetries = retries
+		upgradeFailoverInterval = interval
+	}(upgradeFailoverRetries, upgradeFailoverInterval)
+	upgradeFailoverInterval = time.Millisecond
+
+	// record the number of mgrs updated each time the active mgr is checked
+	statusChecks := []int{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			updated := len(testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+			switch args[0] {
+			case "status":
+				statusChecks = append(statusChecks, updated)
+				return mgrStatusResponse("HEALTH_OK", true), nil
+			case "versions":
+				if updated == 2 {
+					return mgrVersionsResponse(newMgrVersion), nil
+				}
+				return mgrVersionsResponse(oldMgrVersion), nil
+			}
+			return "", nil
+		},
+	}
+	c := newUpgradeTestCluster(executor)
+
+	err := c.Upgrade(cephv1.CephVersionSpec{Image: upgradeImage})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rook-ceph-mgr-a", "rook-ceph-mgr-b"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	for _, d := range *deploymentsUpdated {
+		assert.Equal(t, upgradeImage, d.Spec.Template.Spec.Containers[0].Image)
+	}
+	// the pre-check, then a failover check after each deployment is updated
+	assert.Equal(t, []int{0, 1, 2}, statusChecks)
+
+	// the mgr does not come back after the first deployment is updated
+	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
+	upgradeFailoverRetries = 2
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+		updated := len(testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+		if args[0] == "status" {
+			return mgrStatusResponse("HEALTH_OK", updated == 0), nil
+		}
+		return mgrVersionsResponse(oldMgrVersion), nil
+	}
+	err = c.Upgrade(cephv1.CephVersionSpec{Image: upgradeImage})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"rook-ceph-mgr-a"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+
+	// the mgrs do not report the new version after the upgrade
+	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+		if args[0] == "status" {
+			return mgrStatusResponse("HEALTH_OK", true), nil
+		}
+		return mgrVersionsResponse(oldMgrVersion), nil
+	}
+	err = c.Upgrade(cephv1.CephVersionSpec{Image: upgradeImage})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"rook-ceph-mgr-a", "rook-ceph-mgr-b"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+}
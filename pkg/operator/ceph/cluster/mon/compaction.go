@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+// compactionInterval returns the interval between the compactions of the mon stores, or zero if the stores
+// are not compacted periodically
+func (c *Cluster) compactionInterval() (time.Duration, error) {
+	if c.spec.Mon.Compaction == nil || c.spec.Mon.Compaction.Interval == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(c.spec.Mon.Compaction.Interval)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid mon compaction interval %q", c.spec.Mon.Compaction.Interval)
+	}
+	if interval <= 0 {
+		return 0, errors.Errorf("invalid mon compaction interval %q. it must be positive", c.spec.Mon.Compaction.Interval)
+	}
+	return interval, nil
+}
+
+// compactStoresIfDue compacts the stores of the mons in quorum when the compaction interval has passed since
+// the last compaction. The first compaction happens one interval after the operator started.
+func (c *Cluster) compactStoresIfDue() error {
+	if c.spec.External.Enable {
+		return nil
+	}
+	interval, err := c.compactionInterval()
+	if err != nil || interval == 0 {
+		return err
+	}
+	if c.lastCompaction.IsZero() {
+		c.lastCompaction = time.Now()
+		return nil
+	}
+	if time.Since(c.lastCompaction) < interval {
+		return nil
+	}
+
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	quorumStatus, err := client.GetMonQuorumStatus(c.context, c.ClusterInfo.Name, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get mon quorum status")
+	}
+	// the mons are compacted one at a time since a mon is slow to respond while its store is compacted
+	for _, mon := range quorumStatus.MonMap.Mons {
+		if !monInQuorum(mon, quorumStatus.Quorum) {
+			logger.Infof("not compacting the store of mon %q that is out of quorum", mon.Name)
+			continue
+		}
+		logger.Infof("compacting the store of mon %q", mon.Name)
+		if err := client.CompactMonStore(c.context, c.ClusterInfo.Name, mon.Name); err != nil {
+			return err
+		}
+	}
+
+	c.lastCompaction = time.Now()
+	logger.Infof("compacted the mon stores. the next compaction is in %s", interval.String())
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestCompactStores(t *testing.T) {
+	quorumResponse := client.MonStatusResponse{Quorum: []int{0, 1}}
+	for i, name := range []string{"a", "b", "c"} {
+		quorumResponse.MonMap.Mons = append(quorumResponse.MonMap.Mons, client.MonMapEntry{Name: name, Rank: i})
+	}
+	serialized, _ := json.Marshal(quorumResponse)
+	compacted := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "tell" && args[2] == "compact" {
+				compacted = append(compacted, args[1])
+				return "", nil
+			}
+			return string(serialized), nil
+		},
+	}
+	c := newCluster(&clusterd.Context{Clientset: test.New(1), Executor: executor}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	c.ClusterInfo = test.CreateConfigDir(3)
+
+	// the stores are not compacted by default
+	assert.NoError(t, c.compactStoresIfDue())
+	assert.True(t, c.lastCompaction.IsZero())
+
+	// the first compaction is one interval after the operator started
+	c.spec.Mon.Compaction = &cephv1.MonCompactionSpec{Interval: "24h"}
+	assert.NoError(t, c.compactStoresIfDue())
+	assert.Empty(t, compacted)
+	assert.False(t, c.lastCompaction.IsZero())
+	assert.NoError(t, c.compactStoresIfDue())
+	assert.Empty(t, compacted)
+
+	// the mons in quorum are compacted when the interval has passed
+	c.lastCompaction = time.Now().Add(-25 * time.Hour)
+	assert.NoError(t, c.compactStoresIfDue())
+	assert.Equal(t, []string{"mon.a", "mon.b"}, compacted)
+	assert.True(t, time.Since(c.lastCompaction) < time.Hour)
+
+	// invalid intervals
+	for _, invalid := range []string{"one day", "0h", "-24h"} {
+		c.spec.Mon.Compaction.Interval = invalid
+		_, err := c.compactionInterval()
+		assert.Error(t, err)
+	}
+}
+
+func TestCompactOnStart(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	c.ClusterInfo = test.CreateConfigDir(1)
+	m := testGenMonConfig("a")
+
+	container := c.makeMonDaemonContainer(m)
+	assert.NotContains(t, container.Args, "--mon-compact-on-start=true")
+
+	c.spec.Mon.Compaction = &cephv1.MonCompactionSpec{OnStart: true}
+	container = c.makeMonDaemonContainer(m)
+	assert.Contains(t, container.Args, "--mon-compact-on-start=true")
+}
@@ -92,6 +92,11 @@ func CreateOrLoadClusterInfo(context *clusterd.Context, namespace string, ownerR
 		if ownerRef == nil {
 			return nil, maxMonID, monMapping, errors.New("not expected to create new cluster info and did not find existing secret")
 		}
+		// the mons of an existing cluster would never accept a new identity, so the identity is only generated
+		// for a new cluster
+		if err := validateNoExistingMons(context.Clientset, namespace); err != nil {
+			return nil, maxMonID, monMapping, err
+		}
 
 		clusterInfo, err = createNamedClusterInfo(context, namespace)
 		if err != nil {
@@ -108,6 +113,13 @@ func CreateOrLoadClusterInfo(context *clusterd.Context, namespace string, ownerR
 			FSID:          string(secrets.Data[fsidSecretName]),
 			MonitorSecret: string(secrets.Data[monSecretName]),
 			AdminSecret:   string(secrets.Data[adminSecretName]),
+			ExternalCred: cephconfig.ExternalCred{
+				Username: string(secrets.Data[externalUsernameSecretName]),
+				Secret:   string(secrets.Data[externalUserSecretName]),
+			},
+		}
+		if err := validateClusterInfoSecret(clusterInfo); err != nil {
+			return nil, maxMonID, monMapping, err
 		}
 		logger.Debugf("found existing monitor secrets for cluster %s", clusterInfo.Name)
 	}
@@ -121,6 +133,54 @@ func CreateOrLoadClusterInfo(context *clusterd.Context, namespace string, ownerR
 	return clusterInfo, maxMonID, monMapping, nil
 }
 
+// validateNoExistingMons returns an error if the mon endpoints or the mon deployments of a cluster exist while
+// the mon secret is missing
+func validateNoExistingMons(clientset kubernetes.Interface, namespace string) error {
+	mons, _, _, err := loadMonConfig(clientset, namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check for existing mons")
+	}
+	if len(mons) > 0 {
+		return errors.Errorf("refusing to create a new cluster identity since the secret %q is missing but the mons %q exist. restore the secret to recover the cluster", AppName, FlattenMonEndpoints(mons))
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", AppName)})
+	if err != nil {
+		return errors.Wrapf(err, "failed to check for existing mon deployments")
+	}
+	if len(deployments.Items) > 0 {
+		return errors.Errorf("refusing to create a new cluster identity since the secret %q is missing but %d mon deployments exist. restore the secret to recover the cluster", AppName, len(deployments.Items))
+	}
+	return nil
+}
+
+// validateClusterInfoSecret returns an error if the identity of the cluster loaded from the mon secret is
+// incomplete
+func validateClusterInfoSecret(clusterInfo *cephconfig.ClusterInfo) error {
+	missing := []string{}
+	if clusterInfo.Name == "" {
+		missing = append(missing, clusterSecretName)
+	}
+	if clusterInfo.FSID == "" {
+		missing = append(missing, fsidSecretName)
+	}
+	if clusterInfo.MonitorSecret == "" {
+		missing = append(missing, monSecretName)
+	}
+	if clusterInfo.ExternalCred.Username != "" {
+		// the admin secret is not imported when connecting to an external cluster as a restricted user
+		if clusterInfo.ExternalCred.Secret == "" {
+			missing = append(missing, externalUserSecretName)
+		}
+	} else if clusterInfo.AdminSecret == "" {
+		missing = append(missing, adminSecretName)
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("the secret %q is missing the keys %v", AppName, missing)
+	}
+	return nil
+}
+
 // WriteConnectionConfig save monitor connection config to disk
 func WriteConnectionConfig(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo) error {
 	// write the latest config to the config dir
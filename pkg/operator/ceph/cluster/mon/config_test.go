@@ -28,6 +28,9 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -66,3 +69,105 @@ func TestCreateClusterSecrets(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, adminSecret, string(secret.Data["admin-secret"]))
 }
+
+func TestLoadExternalClusterInfo(t *testing.T) {
+	clientset := test.New(1)
+	context := &clusterd.Context{Clientset: clientset}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: AppName, Namespace: "ns"},
+		Data: map[string][]byte{
+			clusterSecretName: []byte("ns"),
+			fsidSecretName:    []byte("12345"),
+			monSecretName:     []byte("mon-secret"),
+			adminSecretName:   []byte("admin-secret"),
+		},
+	}
+	_, err := clientset.CoreV1().Secrets("ns").Create(secret)
+	require.NoError(t, err)
+
+	// the admin is used when no restricted user is imported
+	info, _, _, err := LoadClusterInfo(context, "ns")
+	require.NoError(t, err)
+	assert.Equal(t, "", info.ExternalCred.Username)
+
+	// the restricted user is loaded with the cluster info
+	secret.Data[externalUsernameSecretName] = []byte("client.healthchecker")
+	secret.Data[externalUserSecretName] = []byte("restrictedsecret")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	require.NoError(t, err)
+	info, _, _, err = LoadClusterInfo(context, "ns")
+	require.NoError(t, err)
+	assert.True(t, info.IsInitialized())
+	assert.Equal(t, "client.healthchecker", info.ExternalCred.Username)
+	assert.Equal(t, "restrictedsecret", info.ExternalCred.Secret)
+
+	// the admin secret is not required with the restricted user
+	delete(secret.Data, adminSecretName)
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	require.NoError(t, err)
+	info, _, _, err = LoadClusterInfo(context, "ns")
+	require.NoError(t, err)
+	assert.True(t, info.IsInitialized())
+	assert.Equal(t, "", info.AdminSecret)
+
+	// the key of the restricted user is required
+	delete(secret.Data, externalUserSecretName)
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	require.NoError(t, err)
+	_, _, _, err = LoadClusterInfo(context, "ns")
+	assert.Error(t, err)
+}
+
+func TestLoadClusterInfoWithoutSecret(t *testing.T) {
+	clientset := test.New(1)
+	context := &clusterd.Context{Clientset: clientset}
+	ownerRef := &metav1.OwnerReference{}
+
+	// a new identity is not generated if the mon endpoints exist
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: EndpointConfigMapName, Namespace: "ns"},
+		Data:       map[string]string{EndpointDataKey: "a=1.2.3.1:6789"},
+	}
+	_, err := clientset.CoreV1().ConfigMaps("ns").Create(cm)
+	require.NoError(t, err)
+	_, _, _, err = CreateOrLoadClusterInfo(context, "ns", ownerRef)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "a=1.2.3.1:6789")
+
+	// a new identity is not generated if the mon deployments exist
+	require.NoError(t, clientset.CoreV1().ConfigMaps("ns").Delete(EndpointConfigMapName, &metav1.DeleteOptions{}))
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: "ns", Labels: map[string]string{"app": AppName}}}
+	_, err = clientset.AppsV1().Deployments("ns").Create(d)
+	require.NoError(t, err)
+	_, _, _, err = CreateOrLoadClusterInfo(context, "ns", ownerRef)
+	assert.Error(t, err)
+	_, err = clientset.CoreV1().Secrets("ns").Get(AppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the identity is not loaded from an incomplete secret
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: AppName, Namespace: "ns"},
+		Data: map[string][]byte{
+			clusterSecretName: []byte("ns"),
+			fsidSecretName:    []byte("12345"),
+		},
+	}
+	_, err = clientset.CoreV1().Secrets("ns").Create(secret)
+	require.NoError(t, err)
+	_, _, _, err = CreateOrLoadClusterInfo(context, "ns", ownerRef)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "mon-secret admin-secret")
+
+	// the identity is rebuilt from the secret and the mon endpoints
+	secret.Data[monSecretName] = []byte("monsecret")
+	secret.Data[adminSecretName] = []byte("adminsecret")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	require.NoError(t, err)
+	_, err = clientset.CoreV1().ConfigMaps("ns").Create(cm)
+	require.NoError(t, err)
+	info, _, _, err := CreateOrLoadClusterInfo(context, "ns", ownerRef)
+	require.NoError(t, err)
+	assert.Equal(t, "12345", info.FSID)
+	assert.Equal(t, "adminsecret", info.AdminSecret)
+	assert.Equal(t, "1.2.3.1:6789", info.Monitors["a"].Endpoint)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package mon
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephutil "github.com/rook/rook/pkg/daemon/ceph/util"
 	cephspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -65,12 +68,15 @@ func (hc *HealthChecker) Check(stopCh chan struct{}) {
 			logger.Infof("Stopping monitoring of mons in namespace %s", hc.monCluster.Namespace)
 			return
 
-		case <-time.After(HealthCheckInterval):
+		case <-time.After(hc.monCluster.checkInterval()):
 			logger.Debugf("checking health of mons")
 			err := hc.monCluster.checkHealth()
 			if err != nil {
 				logger.Warningf("failed to check mon health. %v", err)
 			}
+			if err := hc.monCluster.compactStoresIfDue(); err != nil {
+				logger.Warningf("failed to compact the mon stores. %v", err)
+			}
 		}
 	}
 }
@@ -96,6 +102,10 @@ func (c *Cluster) checkHealth() error {
 	// We need to complete a health check with a consistent value.
 	desiredMonCount := c.spec.Mon.Count
 	logger.Debugf("targeting the mon count %d", desiredMonCount)
+	outTimeout, err := c.monOutTimeout()
+	if err != nil {
+		return err
+	}
 
 	// Source of truth of which mons should exist is our *clusterInfo*
 	monsNotFound := map[string]interface{}{}
@@ -147,7 +157,7 @@ func (c *Cluster) checkHealth() error {
 
 			// when the timeout for the mon has been reached, continue to the
 			// normal failover/delete mon pod part of the code
-			if time.Since(c.monTimeoutList[mon.Name]) <= MonOutTimeout {
+			if time.Since(c.monTimeoutList[mon.Name]) <= outTimeout {
 				logger.Warningf("mon %s not found in quorum, waiting for timeout before failover", mon.Name)
 				continue
 			}
@@ -184,9 +194,120 @@ func (c *Cluster) checkHealth() error {
 		}
 	}
 
+	// move the mons that no longer meet the placement of the CRD once all the mons are healthy
+	if allMonsInQuorum && len(quorumStatus.MonMap.Mons) == desiredMonCount {
+		name, err := c.findMonViolatingPlacement()
+		if err != nil {
+			return errors.Wrapf(err, "failed to check the placement of the mons")
+		}
+		if name != "" {
+			logger.Infof("mon %q does not meet the mon placement anymore, mon will be failed over", name)
+			// only move one mon per health check. the next mon is moved after the new mon is in quorum.
+			return c.failoverMon(name)
+		}
+	}
+
 	return nil
 }
 
+// findMonViolatingPlacement returns the name of a mon running on a node that does not meet the mon placement
+// anymore, or an empty string if all the mons meet the placement
+func (c *Cluster) findMonViolatingPlacement() (string, error) {
+	names := []string{}
+	for name := range c.ClusterInfo.Monitors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		nodeName, err := c.getMonNodeName(name)
+		if err != nil {
+			return "", err
+		}
+		if nodeName == "" {
+			continue
+		}
+		node, err := c.context.Clientset.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				// the mon is failed over when it is out of quorum
+				continue
+			}
+			return "", errors.Wrapf(err, "failed to get node %q of mon %q", nodeName, name)
+		}
+		zone, err := c.getMonZone(name)
+		if err != nil {
+			return "", err
+		}
+		// the taints of nodes that are not ready are ignored since the mons out of quorum are already failed over
+		valid, err := k8sutil.NodeMeetsPlacementTerms(*node, c.getMonPlacement(&monConfig{DaemonName: name, Zone: zone}), true)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to check the placement of mon %q", name)
+		}
+		if !valid {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// getMonNodeName returns the name of the node the pod of the mon is running on, or an empty string if the pod
+// is not scheduled
+func (c *Cluster) getMonNodeName(name string) (string, error) {
+	selector := fmt.Sprintf("app=%s,mon=%s", AppName, name)
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the pods of mon %q", name)
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			return pod.Spec.NodeName, nil
+		}
+	}
+	return "", nil
+}
+
+// monOutTimeout returns the time a mon can be out of quorum before it is failed over
+func (c *Cluster) monOutTimeout() (time.Duration, error) {
+	if c.spec.Mon.OutTimeout == "" {
+		return MonOutTimeout, nil
+	}
+	timeout, err := time.ParseDuration(c.spec.Mon.OutTimeout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid mon out timeout %q", c.spec.Mon.OutTimeout)
+	}
+	if timeout < 0 {
+		return 0, errors.Errorf("invalid mon out timeout %q. it must not be negative", c.spec.Mon.OutTimeout)
+	}
+	return timeout, nil
+}
+
+// healthCheckInterval returns the interval between the checks of the mon quorum
+func (c *Cluster) healthCheckInterval() (time.Duration, error) {
+	if c.spec.Mon.HealthCheckInterval == "" {
+		return HealthCheckInterval, nil
+	}
+	interval, err := time.ParseDuration(c.spec.Mon.HealthCheckInterval)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid mon health check interval %q", c.spec.Mon.HealthCheckInterval)
+	}
+	if interval <= 0 {
+		return 0, errors.Errorf("invalid mon health check interval %q. it must be positive", c.spec.Mon.HealthCheckInterval)
+	}
+	return interval, nil
+}
+
+// checkInterval returns the interval until the next health check. The interval of the operator is used if
+// the interval of the cluster is not valid.
+func (c *Cluster) checkInterval() time.Duration {
+	interval, err := c.healthCheckInterval()
+	if err != nil {
+		logger.Warningf("using the default mon health check interval %s. %v", HealthCheckInterval.String(), err)
+		return HealthCheckInterval
+	}
+	return interval
+}
+
 // failMon compares the monCount against desiredMonCount
 func (c *Cluster) failMon(monCount, desiredMonCount int, name string) {
 	if monCount > desiredMonCount {
@@ -207,6 +328,13 @@ func (c *Cluster) failoverMon(name string) error {
 
 	// Start a new monitor
 	m := c.newMonConfig(c.maxMonID + 1)
+
+	// In a stretch cluster the new mon replaces the failed mon in the same zone
+	zone, err := c.getMonZone(name)
+	if err != nil {
+		return err
+	}
+	m.Zone = zone
 	logger.Infof("starting new mon: %+v", m)
 
 	mConf := []*monConfig{m}
@@ -246,9 +374,29 @@ func (c *Cluster) failoverMon(name string) error {
 func (c *Cluster) removeMon(daemonName string) error {
 	logger.Infof("ensuring removal of unhealthy monitor %s", daemonName)
 
-	resourceName := resourceName(daemonName)
-
 	// Remove the mon pod if it is still there
+	if err := c.removeMonDeployment(daemonName); err != nil {
+		return err
+	}
+
+	// Remove the bad monitor from quorum
+	if err := removeMonitorFromQuorum(c.context, c.ClusterInfo.Name, daemonName); err != nil {
+		return errors.Wrapf(err, "failed to remove mon %s from quorum", daemonName)
+	}
+
+	if err := c.removeMonResources(daemonName); err != nil {
+		return err
+	}
+
+	if err := c.saveMonConfig(); err != nil {
+		return errors.Wrapf(err, "failed to save mon config after failing over mon %s", daemonName)
+	}
+
+	return nil
+}
+
+func (c *Cluster) removeMonDeployment(daemonName string) error {
+	resourceName := resourceName(daemonName)
 	var gracePeriod int64
 	propagation := metav1.DeletePropagationForeground
 	options := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod, PropagationPolicy: &propagation}
@@ -259,11 +407,14 @@ func (c *Cluster) removeMon(daemonName string) error {
 			return errors.Wrapf(err, "failed to remove dead mon deployment %s", resourceName)
 		}
 	}
+	return nil
+}
+
+// removeMonResources removes the mon from the mon config and removes its service and pvc. The mon must already
+// be out of the monmap.
+func (c *Cluster) removeMonResources(daemonName string) error {
+	resourceName := resourceName(daemonName)
 
-	// Remove the bad monitor from quorum
-	if err := removeMonitorFromQuorum(c.context, c.ClusterInfo.Name, daemonName); err != nil {
-		return errors.Wrapf(err, "failed to remove mon %s from quorum", daemonName)
-	}
 	delete(c.ClusterInfo.Monitors, daemonName)
 	// check if a mapping exists for the mon
 	if _, ok := c.mapping.Node[daemonName]; ok {
@@ -271,6 +422,9 @@ func (c *Cluster) removeMon(daemonName string) error {
 	}
 
 	// Remove the service endpoint
+	var gracePeriod int64
+	propagation := metav1.DeletePropagationForeground
+	options := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod, PropagationPolicy: &propagation}
 	if err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(resourceName, options); err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Infof("dead mon service %s was already gone", resourceName)
@@ -279,8 +433,13 @@ func (c *Cluster) removeMon(daemonName string) error {
 		}
 	}
 
-	if err := c.saveMonConfig(); err != nil {
-		return errors.Wrapf(err, "failed to save mon config after failing over mon %s", daemonName)
+	// Remove the pvc of the mon data. it is never reattached since the new mons get a new id.
+	if err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(resourceName, &metav1.DeleteOptions{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debugf("dead mon pvc %s was already gone", resourceName)
+		} else {
+			return errors.Wrapf(err, "failed to remove dead mon pvc %s", resourceName)
+		}
 	}
 
 	return nil
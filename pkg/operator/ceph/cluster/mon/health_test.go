@@ -17,13 +17,16 @@ limitations under the License.
 package mon
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
@@ -33,6 +36,8 @@ import (
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -58,6 +63,7 @@ func TestCheckHealth(t *testing.T) {
 	setCommonMonProperties(c, 1, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
 	logger.Infof("initial mons: %v", c.ClusterInfo.Monitors)
 	c.waitForStart = false
+	c.monInQuorum = func(name string) bool { return false }
 	defer os.RemoveAll(c.context.ConfigDir)
 
 	c.mapping.Node["f"] = &NodeInfo{
@@ -112,6 +118,7 @@ func TestCheckHealthNotFound(t *testing.T) {
 	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
 	setCommonMonProperties(c, 2, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
 	c.waitForStart = false
+	c.monInQuorum = func(name string) bool { return false }
 	defer os.RemoveAll(c.context.ConfigDir)
 
 	c.mapping.Node["a"] = &NodeInfo{
@@ -169,6 +176,7 @@ func TestAddRemoveMons(t *testing.T) {
 	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 5, AllowMultiplePerNode: true}, "myversion")
 	c.maxMonID = 0 // "a" is max mon id
 	c.waitForStart = false
+	c.monInQuorum = func(name string) bool { return false }
 	defer os.RemoveAll(c.context.ConfigDir)
 
 	// checking the health will increase the mons as desired all in one go
@@ -284,3 +292,183 @@ func TestAddOrRemoveExternalMonitor(t *testing.T) {
 	// ClusterInfo should now have 2 monitors
 	assert.Equal(t, 2, len(c.ClusterInfo.Monitors))
 }
+
+func TestRemoveMonPVC(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return "", nil
+		},
+	}
+	clientset := test.New(1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3}, "myversion")
+	c.spec.Mon.VolumeClaimTemplate = &v1.PersistentVolumeClaim{}
+
+	pvc, err := c.makeDeploymentPVC(c.newMonConfig(1))
+	assert.NoError(t, err)
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Create(pvc)
+	assert.NoError(t, err)
+
+	// the pvc of the mon data is removed with the mon
+	assert.NoError(t, c.removeMon("b"))
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Get("rook-ceph-mon-b", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the mon is removed if it has no pvc
+	assert.NoError(t, c.removeMon("c"))
+}
+
+func TestMonOutTimeout(t *testing.T) {
+	quorumResponse := client.MonStatusResponse{Quorum: []int{0, 1}}
+	for i, name := range []string{"a", "b", "c"} {
+		quorumResponse.MonMap.Mons = append(quorumResponse.MonMap.Mons, client.MonMapEntry{Name: name, Rank: i})
+	}
+	serialized, _ := json.Marshal(quorumResponse)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return string(serialized), nil
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: test.New(1),
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	// one more mon than desired so the mon out of quorum is removed without starting a new one
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 2}, "myversion")
+
+	timeout, err := c.monOutTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, MonOutTimeout, timeout)
+
+	// the mon is kept until it has been out of quorum for the timeout of the cluster
+	c.spec.Mon.OutTimeout = "1h"
+	assert.NoError(t, c.checkHealth())
+	assert.Contains(t, c.ClusterInfo.Monitors, "c")
+	assert.Contains(t, c.monTimeoutList, "c")
+
+	c.monTimeoutList["c"] = time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, c.checkHealth())
+	assert.NotContains(t, c.ClusterInfo.Monitors, "c")
+
+	// invalid timeouts
+	c.spec.Mon.OutTimeout = "ten minutes"
+	_, err = c.monOutTimeout()
+	assert.Error(t, err)
+	assert.Error(t, c.checkHealth())
+	c.spec.Mon.OutTimeout = "-10m"
+	_, err = c.monOutTimeout()
+	assert.Error(t, err)
+}
+
+func TestHealthCheckInterval(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+
+	// the interval of the operator is the default
+	interval, err := c.healthCheckInterval()
+	assert.NoError(t, err)
+	assert.Equal(t, HealthCheckInterval, interval)
+
+	c.spec.Mon.HealthCheckInterval = "2m"
+	interval, err = c.healthCheckInterval()
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, interval)
+	assert.Equal(t, 2*time.Minute, c.checkInterval())
+
+	// invalid intervals fall back to the interval of the operator in the health loop
+	for _, invalid := range []string{"two minutes", "0s", "-1m"} {
+		c.spec.Mon.HealthCheckInterval = invalid
+		_, err = c.healthCheckInterval()
+		assert.Error(t, err)
+		assert.Equal(t, HealthCheckInterval, c.checkInterval())
+	}
+}
+
+func TestMigrateMonViolatingPlacement(t *testing.T) {
+	updateDeploymentAndWait, _ = testopk8s.UpdateDeploymentAndWaitStub()
+	var monQuorumResponse string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return monQuorumResponse, nil
+		},
+	}
+	clientset := test.New(3)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+	c.maxMonID = 2
+	c.waitForStart = false
+	c.monInQuorum = func(name string) bool { return false }
+	scheduleMonitor = func(c *Cluster, mon *monConfig) (SchedulingResult, error) {
+		node, _ := clientset.CoreV1().Nodes().Get("node1", metav1.GetOptions{})
+		return SchedulingResult{Node: node}, nil
+	}
+
+	for name, nodeName := range map[string]string{"a": "node0", "b": "node2", "c": "node1"} {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName(name), Namespace: "ns", Labels: map[string]string{"app": AppName, "mon": name}},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+		}
+		_, err := clientset.CoreV1().Pods("ns").Create(pod)
+		assert.Nil(t, err)
+	}
+	for _, nodeName := range []string{"node0", "node1"} {
+		node, _ := clientset.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		node.Labels = map[string]string{"role": "mon"}
+		_, err := clientset.CoreV1().Nodes().Update(node)
+		assert.Nil(t, err)
+	}
+
+	// all the mons meet the placement by default
+	name, err := c.findMonViolatingPlacement()
+	assert.Nil(t, err)
+	assert.Equal(t, "", name)
+
+	// the mon on the node without the label does not meet the new placement
+	c.spec.Placement = map[rookalpha.KeyType]rookalpha.Placement{
+		cephv1.KeyMon: {NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{
+					MatchExpressions: []v1.NodeSelectorRequirement{{Key: "role", Operator: v1.NodeSelectorOpIn, Values: []string{"mon"}}},
+				}},
+			},
+		}},
+	}
+	name, err = c.findMonViolatingPlacement()
+	assert.Nil(t, err)
+	assert.Equal(t, "b", name)
+
+	// the mon is not moved while a mon is out of quorum
+	outOfQuorum := client.MonStatusResponse{Quorum: []int{0, 1}}
+	for i, name := range []string{"a", "b", "c"} {
+		outOfQuorum.MonMap.Mons = append(outOfQuorum.MonMap.Mons, client.MonMapEntry{Name: name, Rank: i})
+	}
+	serialized, _ := json.Marshal(outOfQuorum)
+	monQuorumResponse = string(serialized)
+	assert.Nil(t, c.checkHealth())
+	assert.Equal(t, 3, len(c.ClusterInfo.Monitors))
+	assert.NotNil(t, c.ClusterInfo.Monitors["b"])
+
+	// the mon is failed over when all the mons are in quorum
+	monQuorumResponse = clienttest.MonInQuorumResponseFromMons(c.ClusterInfo.Monitors)
+	assert.Nil(t, c.checkHealth())
+	assert.Equal(t, 3, len(c.ClusterInfo.Monitors))
+	assert.Nil(t, c.ClusterInfo.Monitors["b"])
+	assert.NotNil(t, c.ClusterInfo.Monitors["d"])
+}
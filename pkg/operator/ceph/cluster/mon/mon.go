@@ -45,6 +45,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 const (
@@ -66,6 +67,9 @@ const (
 	monSecretName     = "mon-secret"
 	adminSecretName   = "admin-secret"
 	clusterSecretName = "cluster-name"
+	// the optional restricted user to connect to an external cluster instead of the admin
+	externalUsernameSecretName = "ceph-username"
+	externalUserSecretName     = "ceph-secret"
 
 	// DefaultMonCount Default mon count for a cluster
 	DefaultMonCount = 3
@@ -73,8 +77,8 @@ const (
 	MaxMonCount = 9
 
 	// DefaultMsgr1Port is the default port Ceph mons use to communicate amongst themselves prior
-	// to Ceph Nautilus.
-	DefaultMsgr1Port int32 = 6789
+	// to Ceph Nautilus. It is defined with the mon hosts of the ceph config, which cannot import this package.
+	DefaultMsgr1Port int32 = cephconfig.Msgr1port
 	// DefaultMsgr2Port is the listening port of the messenger v2 protocol introduced in Ceph
 	// Nautilus. In Nautilus and a few Ceph releases after, Ceph can use both v1 and v2 protocols.
 	DefaultMsgr2Port int32 = 3300
@@ -120,6 +124,9 @@ type Cluster struct {
 	ownerRef            metav1.OwnerReference
 	csiConfigMutex      *sync.Mutex
 	isUpgrade           bool
+	lastCompaction      time.Time
+	// monInQuorum returns whether a mon is in quorum, and is overridden by the tests
+	monInQuorum func(name string) bool
 }
 
 // monConfig for a single monitor
@@ -135,6 +142,8 @@ type monConfig struct {
 	// DataPathMap is the mapping relationship between mon data stored on the host and mon data
 	// stored in containers.
 	DataPathMap *config.DataPathMap
+	// Zone is the zone of a stretch cluster the mon is assigned to
+	Zone string
 }
 
 // Mapping is mon node and port mapping
@@ -157,7 +166,7 @@ type SchedulingResult struct {
 
 // New creates an instance of a mon cluster
 func New(context *clusterd.Context, namespace, dataDirHostPath string, network cephv1.NetworkSpec, ownerRef metav1.OwnerReference, csiConfigMutex *sync.Mutex, isUpgrade bool) *Cluster {
-	return &Cluster{
+	c := &Cluster{
 		context:             context,
 		dataDirHostPath:     dataDirHostPath,
 		Namespace:           namespace,
@@ -174,6 +183,8 @@ func New(context *clusterd.Context, namespace, dataDirHostPath string, network c
 		csiConfigMutex: csiConfigMutex,
 		isUpgrade:      isUpgrade,
 	}
+	c.monInQuorum = c.getMonInQuorum
+	return c
 }
 
 // Start begins the process of running a cluster of Ceph mons.
@@ -193,6 +204,28 @@ func (c *Cluster) Start(clusterInfo *cephconfig.ClusterInfo, rookVersion string,
 		return nil, errors.Errorf("refusing to deploy %d monitors on the same host since hostNetwork is %+v and allowMultiplePerNode is %t. only one monitor per node is allowed", c.spec.Mon.Count, c.Network, c.spec.Mon.AllowMultiplePerNode)
 	}
 
+	if err := validateMonCount(c.spec.Mon.Count); err != nil {
+		return nil, errors.Wrap(err, "invalid mon settings")
+	}
+	if _, err := c.monOutTimeout(); err != nil {
+		return nil, errors.Wrap(err, "invalid mon settings")
+	}
+	if _, err := c.healthCheckInterval(); err != nil {
+		return nil, errors.Wrap(err, "invalid mon settings")
+	}
+	if _, err := c.compactionInterval(); err != nil {
+		return nil, errors.Wrap(err, "invalid mon settings")
+	}
+	if _, err := c.monConfigOptions(); err != nil {
+		return nil, errors.Wrap(err, "invalid mon settings")
+	}
+	if err := c.validateStretchCluster(cephVersion); err != nil {
+		return nil, errors.Wrap(err, "invalid stretch cluster settings")
+	}
+	if err := c.validatePriorityClass(); err != nil {
+		return nil, errors.Wrap(err, "invalid mon priority class")
+	}
+
 	// Validate pod's memory if specified
 	err := opspec.CheckPodMemory(cephv1.GetMonResources(c.spec.Resources), cephMonPodMinimumMemory)
 	if err != nil {
@@ -209,13 +242,59 @@ func (c *Cluster) Start(clusterInfo *cephconfig.ClusterInfo, rookVersion string,
 	logger.Infof("targeting the mon count %d", c.spec.Mon.Count)
 
 	// create the mons for a new cluster or ensure mons are running in an existing cluster
-	return c.ClusterInfo, c.startMons(c.spec.Mon.Count)
+	if err := c.startMons(c.spec.Mon.Count); err != nil {
+		return c.ClusterInfo, err
+	}
+
+	// the client keyring published with the mon endpoints is created once the mons are running
+	if err := c.publishClientKeyring(); err != nil {
+		return c.ClusterInfo, errors.Wrapf(err, "failed to publish the client keyring")
+	}
+	return c.ClusterInfo, nil
+}
+
+// validateMonCount checks that the mon count is odd and within the supported range. An even count is rejected
+// since it does not improve the tolerance to failures over the odd count below it.
+func validateMonCount(count int) error {
+	if count < 1 || count > MaxMonCount {
+		return errors.Errorf("mon count %d is not between 1 and %d", count, MaxMonCount)
+	}
+	if count%2 == 0 {
+		return errors.Errorf("mon count %d is even. the count must be odd to keep a majority of the mons in quorum", count)
+	}
+	if count == 1 {
+		logger.Warningf("running a single mon is only recommended for test clusters since the cluster is unavailable if the mon is lost")
+	}
+	return nil
+}
+
+// validatePriorityClass returns an error if the priority class of the mon pods does not exist. Otherwise
+// the deployments are created but the pods are rejected by the API server while waiting for quorum.
+func (c *Cluster) validatePriorityClass() error {
+	priorityClassName := cephv1.GetMonPriorityClassName(c.spec.PriorityClassNames)
+	if priorityClassName == "" {
+		return nil
+	}
+	_, err := c.context.Clientset.SchedulingV1().PriorityClasses().Get(priorityClassName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return errors.Errorf("priority class %q not found", priorityClassName)
+		}
+		// the operator may not be allowed to read the priority classes
+		logger.Warningf("failed to verify the mon priority class %q. %v", priorityClassName, err)
+	}
+	return nil
 }
 
 func (c *Cluster) startMons(targetCount int) error {
 	// init the mon config
 	existingCount, mons := c.initMonConfig(targetCount)
 
+	// Assign the mons of a stretch cluster to zones
+	if err := c.assignStretchZones(mons); err != nil {
+		return errors.Wrapf(err, "failed to assign mons to stretch zones")
+	}
+
 	// Assign the mons to nodes
 	if err := c.assignMons(mons); err != nil {
 		return errors.Wrapf(err, "failed to assign pods to mons")
@@ -277,6 +356,11 @@ func (c *Cluster) startMons(targetCount int) error {
 		}
 	}
 
+	// the mon options of the spec are set in the config database once the mons are in quorum
+	if err := c.setMonConfigOptions(); err != nil {
+		return err
+	}
+
 	logger.Debugf("mon endpoints used are: %s", FlattenMonEndpoints(c.ClusterInfo.Monitors))
 	return nil
 }
@@ -423,7 +507,7 @@ func realScheduleMonitor(c *Cluster, mon *monConfig) (SchedulingResult, error) {
 	d.Spec.Template.Spec.Containers[0].Args = []string{"--", "sleep", "3600"}
 
 	// setup affinity settings for pod scheduling
-	p := cephv1.GetMonPlacement(c.spec.Placement)
+	p := c.getMonPlacement(mon)
 	c.setPodPlacement(&d.Spec.Template.Spec, p, nil)
 
 	// setup storage on the canary since scheduling will be affected when
@@ -510,6 +594,10 @@ func realScheduleMonitor(c *Cluster, mon *monConfig) (SchedulingResult, error) {
 
 		pod := pods.Items[0]
 		if pod.Spec.NodeName == "" {
+			// the mon is not assigned if no node can host the canary with the placement and resources of the mon
+			if reason, unschedulable := canaryUnschedulable(pod); unschedulable {
+				return result, errors.Errorf("sched-mon: canary pod %s cannot be scheduled. %s", pod.Name, reason)
+			}
 			logger.Debugf("sched-mon: monitor %s canary pod %s not yet scheduled", d.Name, pod.Name)
 			time.Sleep(time.Second * canaryRetryDelaySeconds)
 			continue
@@ -529,6 +617,17 @@ func realScheduleMonitor(c *Cluster, mon *monConfig) (SchedulingResult, error) {
 	return result, errors.New("sched-mon: canary pod scheduling failed retries")
 }
 
+// canaryUnschedulable returns whether the scheduler reported that no node can host the canary pod, and the
+// reason reported by the scheduler
+func canaryUnschedulable(pod v1.Pod) (string, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable {
+			return condition.Message, true
+		}
+	}
+	return "", false
+}
+
 func (c *Cluster) initMonIPs(mons []*monConfig) error {
 	for _, m := range mons {
 		if c.Network.IsHost() {
@@ -639,6 +738,7 @@ func (c *Cluster) startDeployments(mons []*monConfig, requireAllInQuorum bool) e
 	// 1) New clusters where we are starting one deployment at a time. We only need to check for quorum once when we add a new mon.
 	// 2) Clusters being restored where no mon deployments are running. We need to start all the deployments before checking quorum.
 	onlyCheckQuorumOnce := false
+	existingDeployments := map[string]bool{}
 	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", AppName)})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
@@ -647,14 +747,22 @@ func (c *Cluster) startDeployments(mons []*monConfig, requireAllInQuorum bool) e
 		} else {
 			logger.Warningf("failed to list mon deployments. attempting to continue. %v", err)
 		}
-	} else if len(deployments.Items) < len(mons) {
-		logger.Infof("%d of %d expected mon deployments exist. creating new deployment(s).", len(deployments.Items), len(mons))
-		onlyCheckQuorumOnce = true
+	} else {
+		for _, d := range deployments.Items {
+			existingDeployments[d.Name] = true
+		}
+		if len(deployments.Items) < len(mons) {
+			logger.Infof("%d of %d expected mon deployments exist. creating new deployment(s).", len(deployments.Items), len(mons))
+			onlyCheckQuorumOnce = true
+		}
 	}
 
 	// Ensure each of the mons have been created. If already created, it will be a no-op.
 	for i := 0; i < len(mons); i++ {
 		node, _ := c.mapping.Node[mons[i].DaemonName]
+		// a mon in quorum must rejoin quorum after its deployment is updated before the next mon is updated, so
+		// the update of the mons never takes more than one mon out of quorum
+		rollingUpdate := existingDeployments[mons[i].ResourceName] && c.monInQuorum(mons[i].DaemonName)
 		err := c.startMon(mons[i], node)
 		if err != nil {
 			if c.isUpgrade {
@@ -668,6 +776,11 @@ func (c *Cluster) startDeployments(mons []*monConfig, requireAllInQuorum bool) e
 			// if they aren't all up.
 			logger.Errorf("attempting to continue after failing to start mon %q. %v", mons[i].DaemonName, err)
 		}
+		if rollingUpdate {
+			if err := c.waitForMonToRejoinQuorum(mons[i].DaemonName); err != nil {
+				return errors.Wrapf(err, "failed to update mon %q. not updating the next mons", mons[i].DaemonName)
+			}
+		}
 
 		// For the initial deployment (first creation) it's expected to not have all the monitors in quorum
 		// However, in an event of an update, it's crucial to proceed monitors by monitors
@@ -716,6 +829,29 @@ func (c *Cluster) waitForMonsToJoin(mons []*monConfig, requireAllInQuorum bool)
 	return nil
 }
 
+// getMonInQuorum returns whether the mon is currently in quorum
+func (c *Cluster) getMonInQuorum(name string) bool {
+	quorumStatus, err := client.GetMonQuorumStatus(c.context, c.ClusterInfo.Name, false)
+	if err != nil {
+		logger.Debugf("failed to get quorum_status. %v", err)
+		return false
+	}
+	return monFoundInQuorum(name, quorumStatus)
+}
+
+// waitForMonToRejoinQuorum waits for an updated mon to be back in quorum
+func (c *Cluster) waitForMonToRejoinQuorum(name string) error {
+	logger.Infof("waiting for the updated mon %q to rejoin quorum", name)
+	err := wait.Poll(c.monPodRetryInterval, c.monPodTimeout, func() (bool, error) {
+		return c.monInQuorum(name), nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for mon %q to rejoin quorum", name)
+	}
+	logger.Infof("mon %q is back in quorum", name)
+	return nil
+}
+
 func (c *Cluster) saveMonConfig() error {
 	configMap := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -769,6 +905,10 @@ func (c *Cluster) saveMonConfig() error {
 		return errors.Wrapf(err, "failed to update csi cluster config")
 	}
 
+	if err := c.publishMonEndpoints(); err != nil {
+		return errors.Wrapf(err, "failed to publish the mon endpoints")
+	}
+
 	return nil
 }
 
@@ -858,12 +998,17 @@ func (c *Cluster) startMon(m *monConfig, node *NodeInfo) error {
 		opspec.AddVolumeMountSubPath(&d.Spec.Template.Spec, "ceph-daemon-data")
 		logger.Debugf("adding pvc volume source %s to mon deployment %s", pvcName, d.Name)
 	} else {
+		var existingVolumes []v1.Volume
+		if deploymentExists {
+			existingVolumes = existingDeployment.Spec.Template.Spec.Volumes
+		}
+		c.setMonDataHostPath(m, node, existingVolumes)
 		d.Spec.Template.Spec.Volumes = append(d.Spec.Template.Spec.Volumes, opspec.DaemonVolumesDataHostPath(m.DataPathMap)...)
 		logger.Debugf("adding host path volume source to mon deployment %s", d.Name)
 	}
 
 	// placement settings from the CRD
-	p := cephv1.GetMonPlacement(c.spec.Placement)
+	p := c.getMonPlacement(m)
 
 	if deploymentExists {
 		// the existing deployment may have a node selector. if the cluster
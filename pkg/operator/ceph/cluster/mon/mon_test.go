@@ -33,6 +33,7 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rook "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
@@ -41,7 +42,9 @@ import (
 	"github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -124,7 +127,8 @@ func newCluster(context *clusterd.Context, namespace string, network cephv1.Netw
 		mapping: &Mapping{
 			Node: map[string]*NodeInfo{},
 		},
-		ownerRef: metav1.OwnerReference{},
+		ownerRef:    metav1.OwnerReference{},
+		monInQuorum: func(name string) bool { return false },
 	}
 }
 
@@ -223,6 +227,43 @@ func validateStart(t *testing.T, c *Cluster) {
 	assert.Nil(t, err)
 }
 
+func TestValidateMonCount(t *testing.T) {
+	assert.NoError(t, validateMonCount(1))
+	assert.NoError(t, validateMonCount(3))
+	assert.NoError(t, validateMonCount(MaxMonCount))
+	assert.Error(t, validateMonCount(2))
+	assert.Error(t, validateMonCount(4))
+	assert.Error(t, validateMonCount(0))
+	assert.Error(t, validateMonCount(-1))
+	assert.Error(t, validateMonCount(MaxMonCount+1))
+
+	// the mons are not started with an invalid count
+	namespace := "ns"
+	context := newTestStartCluster(namespace)
+	c := newCluster(context, namespace, cephv1.NetworkSpec{}, true, v1.ResourceRequirements{})
+	c.spec.Mon.Count = MaxMonCount + 2
+	_, err := c.Start(c.ClusterInfo, c.rookVersion, cephver.Mimic, c.spec, false)
+	assert.Error(t, err)
+}
+
+func TestValidatePriorityClass(t *testing.T) {
+	clientset := test.New(1)
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	assert.NoError(t, c.validatePriorityClass())
+
+	// the priority class of the mons must exist
+	c.spec.PriorityClassNames = map[rook.KeyType]string{cephv1.KeyMon: "mon-priority-class", cephv1.KeyOSD: "osd-priority-class"}
+	assert.Error(t, c.validatePriorityClass())
+	priorityClass := &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "mon-priority-class"}, Value: 2000}
+	_, err := clientset.SchedulingV1().PriorityClasses().Create(priorityClass)
+	assert.NoError(t, err)
+	assert.NoError(t, c.validatePriorityClass())
+
+	// the mons fall back to the priority class of all the daemons
+	c.spec.PriorityClassNames = map[rook.KeyType]string{"all": "default-priority-class"}
+	assert.Error(t, c.validatePriorityClass())
+}
+
 func TestSaveMonEndpoints(t *testing.T) {
 	clientset := test.New(1)
 	configDir, _ := ioutil.TempDir("", "")
@@ -339,3 +380,97 @@ func TestMonFoundInQuorum(t *testing.T) {
 	assert.True(t, monFoundInQuorum("c", response))
 	assert.False(t, monFoundInQuorum("d", response))
 }
+
+func TestScheduleMonitorCanary(t *testing.T) {
+	clientset := test.New(1)
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	c.ClusterInfo = test.CreateConfigDir(1)
+	m := testGenMonConfig("a")
+
+	// the mon is not assigned if no node can host the canary
+	canary := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a-canary-123", Namespace: "ns", Labels: c.getLabels("a")},
+		Status: v1.PodStatus{Conditions: []v1.PodCondition{{
+			Type:    v1.PodScheduled,
+			Status:  v1.ConditionFalse,
+			Reason:  v1.PodReasonUnschedulable,
+			Message: "0/1 nodes are available: 1 Insufficient memory.",
+		}}},
+	}
+	_, err := clientset.CoreV1().Pods("ns").Create(canary)
+	assert.NoError(t, err)
+	result, err := realScheduleMonitor(c, m)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Insufficient memory")
+	assert.Nil(t, result.Node)
+	assert.Equal(t, "rook-ceph-mon-a-canary", result.CanaryDeployment)
+
+	// the mon is assigned to the node of the canary
+	assert.NoError(t, k8sutil.DeleteDeployment(clientset, "ns", result.CanaryDeployment))
+	canary.Status = v1.PodStatus{}
+	canary.Spec.NodeName = "node0"
+	_, err = clientset.CoreV1().Pods("ns").Update(canary)
+	assert.NoError(t, err)
+	result, err = realScheduleMonitor(c, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "node0", result.Node.Name)
+}
+
+func TestRollingMonUpdate(t *testing.T) {
+	defer func(update func(*clusterd.Context, *apps.Deployment, string, string, string, cephver.CephVersion, bool, bool) error) {
+		updateDeploymentAndWait = update
+	}(updateDeploymentAndWait)
+	updated := []string{}
+	outOfQuorum := map[string]bool{}
+	rejoins := true
+	updateDeploymentAndWait = func(context *clusterd.Context, d *apps.Deployment, namespace, daemonType, daemonName string, cephVersion cephver.CephVersion, isUpgrade, skipUpgradeChecks bool) error {
+		updated = append(updated, daemonName)
+		if !rejoins {
+			outOfQuorum[daemonName] = true
+		}
+		return nil
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "versions" {
+				return `{"mon":{"ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)":3}}`, nil
+			}
+			mons := map[string]*cephconfig.MonInfo{}
+			for _, name := range []string{"a", "b", "c"} {
+				if !outOfQuorum[name] {
+					mons[name] = &cephconfig.MonInfo{}
+				}
+			}
+			return clienttest.MonInQuorumResponseFromMons(mons), nil
+		},
+	}
+	clientset := test.New(1)
+	c := newCluster(&clusterd.Context{Clientset: clientset, Executor: executor}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3}, "myversion")
+	c.waitForStart = true
+	c.monInQuorum = c.getMonInQuorum
+	mons := []*monConfig{}
+	for _, name := range []string{"a", "b", "c"} {
+		m := testGenMonConfig(name)
+		mons = append(mons, m)
+		_, err := clientset.AppsV1().Deployments("ns").Create(c.makeDeployment(m))
+		assert.NoError(t, err)
+	}
+
+	// the mons are updated one at a time after each mon rejoins quorum
+	assert.NoError(t, c.startDeployments(mons, false))
+	assert.Equal(t, []string{"a", "b", "c"}, updated)
+
+	// a mon that was already out of quorum is updated without waiting for it
+	updated = []string{}
+	outOfQuorum["b"] = true
+	assert.NoError(t, c.startDeployments(mons, false))
+	assert.Equal(t, []string{"a", "b", "c"}, updated)
+
+	// the update stops at the first mon in quorum that does not rejoin quorum
+	updated = []string{}
+	outOfQuorum = map[string]bool{}
+	rejoins = false
+	assert.Error(t, c.startDeployments(mons, false))
+	assert.Equal(t, []string{"a"}, updated)
+}
@@ -17,10 +17,16 @@ limitations under the License.
 package mon
 
 import (
+	"path"
+
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 )
 
+// MonDataDirHostPathKey is the key of the node config in the storage spec that overrides the dataDirHostPath
+// under which the data of the mons running on the node is stored
+const MonDataDirHostPathKey = "monDataDirHostPath"
+
 // NodeUsage is a mapping between a Node and computed metadata about the node
 // that is used in monitor pod scheduling.
 type NodeUsage struct {
@@ -63,3 +69,32 @@ func getNodeInfoFromNode(n v1.Node) (*NodeInfo, error) {
 	}
 	return nr, nil
 }
+
+// monDataDirHostPath returns the host path under which the mon stores its data on the node, or an empty string
+// if the node does not override the dataDirHostPath of the cluster
+func (c *Cluster) monDataDirHostPath(node *NodeInfo) string {
+	if node == nil {
+		return ""
+	}
+	for _, n := range c.spec.Storage.Nodes {
+		if n.Name == node.Name || (node.Hostname != "" && n.Name == node.Hostname) {
+			return n.Config[MonDataDirHostPathKey]
+		}
+	}
+	return ""
+}
+
+// setMonDataHostPath sets the host path of the mon data. The host path of an existing deployment is never
+// changed so the mon keeps its store, otherwise the host path of the node is used if it is set.
+func (c *Cluster) setMonDataHostPath(m *monConfig, node *NodeInfo, existing []v1.Volume) {
+	for _, vol := range existing {
+		if vol.Name == "ceph-daemon-data" && vol.HostPath != nil {
+			m.DataPathMap.HostDataDir = vol.HostPath.Path
+			return
+		}
+	}
+	if hostPath := c.monDataDirHostPath(node); hostPath != "" {
+		m.DataPathMap.HostDataDir = path.Join(hostPath, dataDirRelativeHostPath(m.DaemonName))
+		logger.Infof("mon %q stores its data under the host path %q of node %q", m.DaemonName, m.DataPathMap.HostDataDir, node.Name)
+	}
+}
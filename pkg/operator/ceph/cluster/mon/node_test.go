@@ -25,8 +25,10 @@ import (
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -216,3 +218,44 @@ func TestGetNodeInfoFromNode(t *testing.T) {
 
 	assert.Equal(t, "1.1.1.1", info.Address)
 }
+
+func TestMonDataDirHostPathOfNode(t *testing.T) {
+	var deploymentsUpdated *[]*apps.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+	clientset := test.New(1)
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "/var/lib/rook", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+	c.spec.Storage.Nodes = []rookalpha.Node{
+		{Name: "node1"},
+		{Name: "myhost", Config: map[string]string{MonDataDirHostPathKey: "/mnt/fast"}},
+	}
+	node := &NodeInfo{Name: "node0", Hostname: "myhost", Address: "1.1.1.1"}
+
+	dataHostPath := func(d *apps.Deployment) string {
+		for _, vol := range d.Spec.Template.Spec.Volumes {
+			if vol.Name == "ceph-daemon-data" {
+				return vol.HostPath.Path
+			}
+		}
+		return ""
+	}
+	hostPath := func(name string) string {
+		d, err := clientset.AppsV1().Deployments("ns").Get(resourceName(name), metav1.GetOptions{})
+		assert.Nil(t, err)
+		return dataHostPath(d)
+	}
+
+	// the mon on a node without a host path uses the dataDirHostPath of the cluster
+	assert.Nil(t, c.startMon(testGenMonConfig("a"), &NodeInfo{Name: "node1", Address: "1.1.1.2"}))
+	assert.Equal(t, "/var/lib/rook/mon-a/data", hostPath("a"))
+
+	// the host path of the node overrides the dataDirHostPath of the cluster
+	assert.Nil(t, c.startMon(testGenMonConfig("b"), node))
+	assert.Equal(t, "/mnt/fast/mon-b/data", hostPath("b"))
+
+	// the host path of an existing mon is not changed
+	c.spec.Storage.Nodes[1].Config[MonDataDirHostPathKey] = "/mnt/other"
+	assert.Nil(t, c.startMon(testGenMonConfig("b"), node))
+	assert.Equal(t, 1, len(*deploymentsUpdated))
+	assert.Equal(t, "/mnt/fast/mon-b/data", dataHostPath((*deploymentsUpdated)[0]))
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// PublishedEndpointsName is the name of the config map with the mon endpoints for the clients of the
+	// cluster, and of the secret with the client keyring
+	PublishedEndpointsName = "rook-ceph-mon-endpoints-published"
+	// PublishedFSIDKey is the key of the fsid in the published config map
+	PublishedFSIDKey = "fsid"
+	// PublishedMonHostKey is the key of the "mon_host" config value in the published config map
+	PublishedMonHostKey = "mon_host"
+	// PublishedMonMembersKey is the key of the "mon_initial_members" config value in the published config map
+	PublishedMonMembersKey = "mon_initial_members"
+	// PublishedKeyringKey is the key of the client keyring in the published secret
+	PublishedKeyringKey = "keyring"
+
+	// publishedNamespacesAnnotation lists the published namespaces on the config map in the namespace of the
+	// cluster, so that the objects can be removed from the namespaces no longer published without listing the
+	// objects of all the namespaces
+	publishedNamespacesAnnotation = "ceph.rook.io/published-namespaces"
+
+	publishedClientName      = "client.rook-ceph-mon-endpoints"
+	publishedKeyringTemplate = `
+[client.rook-ceph-mon-endpoints]
+	key = %s
+	caps mon = "allow r"
+`
+)
+
+func (c *Cluster) publishedNamespaces() []string {
+	namespaces := []string{c.Namespace}
+	if c.spec.Mon.PublishEndpoints != nil {
+		for _, namespace := range c.spec.Mon.PublishEndpoints.Namespaces {
+			if namespace != c.Namespace {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	}
+	return namespaces
+}
+
+func (c *Cluster) publishedObjectMeta(namespace string) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Name:      PublishedEndpointsName,
+		Namespace: namespace,
+		Labels: map[string]string{
+			k8sutil.AppAttr:     PublishedEndpointsName,
+			k8sutil.ClusterAttr: c.Namespace,
+		},
+	}
+	// the owner of the cluster can only be set on the objects in the namespace of the cluster
+	if namespace == c.Namespace {
+		k8sutil.SetOwnerRef(&meta, &c.ownerRef)
+	}
+	return meta
+}
+
+// getPublishedNamespaces returns the namespaces the endpoints of the cluster are published in, as recorded on the
+// config map in the namespace of the cluster
+func getPublishedNamespaces(clientset kubernetes.Interface, clusterNamespace string) ([]string, error) {
+	configMap, err := clientset.CoreV1().ConfigMaps(clusterNamespace).Get(PublishedEndpointsName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the published mon endpoints")
+	}
+	value := configMap.Annotations[publishedNamespacesAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+	return strings.Split(value, ","), nil
+}
+
+// publishMonEndpoints maintains the config map with the current mon endpoints in the namespace of the cluster
+// and in the namespaces the endpoints are published in. The config maps and client keyrings are removed from
+// the namespaces that are no longer published.
+func (c *Cluster) publishMonEndpoints() error {
+	previous, err := getPublishedNamespaces(c.context.Clientset, c.Namespace)
+	if err != nil {
+		return err
+	}
+
+	monHost, monMembers := config.MonHostAndMembers(c.ClusterInfo)
+	data := map[string]string{
+		PublishedFSIDKey:       c.ClusterInfo.FSID,
+		PublishedMonHostKey:    monHost,
+		PublishedMonMembersKey: monMembers,
+	}
+
+	namespaces := c.publishedNamespaces()
+	published := map[string]bool{}
+	for _, namespace := range namespaces {
+		published[namespace] = true
+		configMap := &v1.ConfigMap{ObjectMeta: c.publishedObjectMeta(namespace), Data: data}
+		if namespace == c.Namespace {
+			configMap.Annotations = map[string]string{publishedNamespacesAnnotation: strings.Join(namespaces, ",")}
+		}
+		if _, err := c.context.Clientset.CoreV1().ConfigMaps(namespace).Create(configMap); err != nil {
+			if !kerrors.IsAlreadyExists(err) {
+				return errors.Wrapf(err, "failed to create the published mon endpoints in namespace %q", namespace)
+			}
+			if _, err := c.context.Clientset.CoreV1().ConfigMaps(namespace).Update(configMap); err != nil {
+				return errors.Wrapf(err, "failed to update the published mon endpoints in namespace %q", namespace)
+			}
+		}
+	}
+
+	for _, namespace := range previous {
+		if published[namespace] {
+			continue
+		}
+		if err := removePublishedConfigMap(c.context.Clientset, namespace); err != nil {
+			return err
+		}
+		if err := removePublishedSecret(c.context.Clientset, namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishClientKeyring maintains the secret with the keyring of a client that can read the mon map next to
+// the published mon endpoints. The key is created in ceph, so the mons must be running.
+func (c *Cluster) publishClientKeyring() error {
+	if c.spec.Mon.PublishEndpoints == nil || !c.spec.Mon.PublishEndpoints.ClientKeyring {
+		for _, namespace := range c.publishedNamespaces() {
+			if err := removePublishedSecret(c.context.Clientset, namespace); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	key, err := client.AuthGetOrCreateKey(c.context, c.ClusterInfo.Name, publishedClientName, []string{"mon", "allow r"})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the key of %q", publishedClientName)
+	}
+	data := map[string][]byte{PublishedKeyringKey: []byte(fmt.Sprintf(publishedKeyringTemplate, key))}
+
+	for _, namespace := range c.publishedNamespaces() {
+		secret := &v1.Secret{ObjectMeta: c.publishedObjectMeta(namespace), Data: data, Type: k8sutil.RookType}
+		if _, err := c.context.Clientset.CoreV1().Secrets(namespace).Create(secret); err != nil {
+			if !kerrors.IsAlreadyExists(err) {
+				return errors.Wrapf(err, "failed to create the published client keyring in namespace %q", namespace)
+			}
+			if _, err := c.context.Clientset.CoreV1().Secrets(namespace).Update(secret); err != nil {
+				return errors.Wrapf(err, "failed to update the published client keyring in namespace %q", namespace)
+			}
+		}
+	}
+	return nil
+}
+
+// RemovePublishedEndpoints removes the published mon endpoints and client keyrings of a deleted cluster from
+// the published namespaces. The objects outside of the namespace of the cluster are not owned by the cluster.
+func RemovePublishedEndpoints(clientset kubernetes.Interface, clusterNamespace string) error {
+	namespaces, err := getPublishedNamespaces(clientset, clusterNamespace)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		if namespace == clusterNamespace {
+			continue
+		}
+		if err := removePublishedConfigMap(clientset, namespace); err != nil {
+			return err
+		}
+		if err := removePublishedSecret(clientset, namespace); err != nil {
+			return err
+		}
+	}
+
+	// the config map in the namespace of the cluster lists the published namespaces, so it is removed last
+	if err := removePublishedSecret(clientset, clusterNamespace); err != nil {
+		return err
+	}
+	return removePublishedConfigMap(clientset, clusterNamespace)
+}
+
+// removePublishedConfigMap removes the published mon endpoints from a namespace
+func removePublishedConfigMap(clientset kubernetes.Interface, namespace string) error {
+	err := clientset.CoreV1().ConfigMaps(namespace).Delete(PublishedEndpointsName, &metav1.DeleteOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to remove the published mon endpoints from namespace %q", namespace)
+	}
+	logger.Infof("removed the published mon endpoints from namespace %q", namespace)
+	return nil
+}
+
+// removePublishedSecret removes the published client keyring from a namespace
+func removePublishedSecret(clientset kubernetes.Interface, namespace string) error {
+	err := clientset.CoreV1().Secrets(namespace).Delete(PublishedEndpointsName, &metav1.DeleteOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to remove the published client keyring from namespace %q", namespace)
+	}
+	logger.Infof("removed the published client keyring from namespace %q", namespace)
+	return nil
+}
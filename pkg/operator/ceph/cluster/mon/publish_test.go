@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPublishMonEndpoints(t *testing.T) {
+	clientset := test.New(1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: configDir}, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 1, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+
+	// the endpoints are always published in the namespace of the cluster
+	require.NoError(t, c.saveMonConfig())
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get(PublishedEndpointsName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "12345", cm.Data[PublishedFSIDKey])
+	assert.Equal(t, "1.2.3.1:6789", cm.Data[PublishedMonHostKey])
+	assert.Equal(t, "a", cm.Data[PublishedMonMembersKey])
+
+	// the endpoints are updated in the other published namespaces when the mons change
+	c.spec.Mon.PublishEndpoints = &cephv1.MonPublishEndpointsSpec{Namespaces: []string{"app1", "app2"}}
+	c.ClusterInfo.Monitors["a"].Endpoint = "2.3.4.5:6789"
+	require.NoError(t, c.saveMonConfig())
+	for _, namespace := range []string{"ns", "app1", "app2"} {
+		cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(PublishedEndpointsName, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "2.3.4.5:6789", cm.Data[PublishedMonHostKey])
+		assert.Equal(t, "ns", cm.Labels["rook_cluster"])
+	}
+	cm, err = clientset.CoreV1().ConfigMaps("ns").Get(PublishedEndpointsName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "ns,app1,app2", cm.Annotations[publishedNamespacesAnnotation])
+
+	// the endpoints and the keyring are removed from the namespaces that are no longer published
+	_, err = clientset.CoreV1().Secrets("app1").Create(&v1.Secret{ObjectMeta: c.publishedObjectMeta("app1")})
+	require.NoError(t, err)
+	c.spec.Mon.PublishEndpoints.Namespaces = []string{"app2"}
+	require.NoError(t, c.saveMonConfig())
+	_, err = clientset.CoreV1().ConfigMaps("app1").Get(PublishedEndpointsName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.CoreV1().Secrets("app1").Get(PublishedEndpointsName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.CoreV1().ConfigMaps("app2").Get(PublishedEndpointsName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	cm, err = clientset.CoreV1().ConfigMaps("ns").Get(PublishedEndpointsName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "ns,app2", cm.Annotations[publishedNamespacesAnnotation])
+
+	// the endpoints are removed from all the namespaces when the cluster is deleted
+	require.NoError(t, RemovePublishedEndpoints(clientset, "ns"))
+	for _, namespace := range []string{"ns", "app2"} {
+		_, err := clientset.CoreV1().ConfigMaps(namespace).Get(PublishedEndpointsName, metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+	}
+}
+
+func TestPublishClientKeyring(t *testing.T) {
+	clientset := test.New(1)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "get-or-create-key" {
+				assert.Equal(t, []string{publishedClientName, "mon", "allow r"}, args[2:5])
+				return `{"key":"mysecurekey"}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := New(&clusterd.Context{Clientset: clientset, Executor: executor}, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 1, cephv1.MonSpec{Count: 3}, "myversion")
+
+	// the keyring is not published by default
+	require.NoError(t, c.publishClientKeyring())
+	_, err := clientset.CoreV1().Secrets("ns").Get(PublishedEndpointsName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the keyring is published next to the endpoints
+	c.spec.Mon.PublishEndpoints = &cephv1.MonPublishEndpointsSpec{Namespaces: []string{"app1"}, ClientKeyring: true}
+	require.NoError(t, c.publishClientKeyring())
+	for _, namespace := range []string{"ns", "app1"} {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(PublishedEndpointsName, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Contains(t, string(secret.Data[PublishedKeyringKey]), "key = mysecurekey")
+	}
+
+	// the keyring is removed when it is no longer published
+	c.spec.Mon.PublishEndpoints.ClientKeyring = false
+	require.NoError(t, c.publishClientKeyring())
+	for _, namespace := range []string{"ns", "app1"} {
+		_, err := clientset.CoreV1().Secrets(namespace).Get(PublishedEndpointsName, metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+	}
+}
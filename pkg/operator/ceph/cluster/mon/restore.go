@@ -0,0 +1,210 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// RestoreQuorumAnnotation is set on the cluster CR with the name of the healthy mon to restore the mon
+	// quorum from when the mons cannot form quorum anymore
+	RestoreQuorumAnnotation = "ceph.rook.io/restore-mon-quorum"
+
+	restoreQuorumJobName    = "rook-ceph-mon-restore-quorum"
+	restoreQuorumJobTimeout = 10 * time.Minute
+
+	// the script extracts the monmap from the store of the healthy mon, removes all the other mons from the
+	// monmap, and injects the modified monmap back into the store
+	restoreQuorumScript = `
+set -e
+monmap=%[1]s
+%[2]s --extract-monmap=$monmap
+%[3]s --print $monmap
+for mon in $(%[3]s --print $monmap | sed -n 's/^[0-9]*: .* mon\.\([^ ]*\)$/\1/p'); do
+  if [ "$mon" != "%[4]s" ]; then
+    echo "removing mon $mon from the monmap"
+    %[3]s $monmap --rm $mon
+  fi
+done
+%[2]s --inject-monmap=$monmap
+`
+)
+
+var waitForRestoreQuorumJob = k8sutil.WaitForJobCompletion
+
+// RestoreQuorum rebuilds the mon quorum from a single healthy mon. The mon is stopped, the other mons are
+// removed from the monmap in its store, and the mon is restarted alone. The resources of the other mons are
+// removed, and the missing mons are created again by the next orchestration.
+func (c *Cluster) RestoreQuorum(name string) error {
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	if c.ClusterInfo == nil {
+		return errors.New("the mon config is not loaded")
+	}
+	if _, ok := c.ClusterInfo.Monitors[name]; !ok {
+		return errors.Errorf("mon %q is not a mon of the cluster", name)
+	}
+	d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName(name), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of mon %q", name)
+	}
+	job, err := c.makeRestoreQuorumJob(d, name)
+	if err != nil {
+		return err
+	}
+
+	logger.Warningf("restoring the mon quorum from mon %q. all the other mons are removed from the cluster", name)
+
+	// the store of the mon can only be modified while the mon is stopped
+	if err := c.scaleMonDeployment(name, 0); err != nil {
+		return err
+	}
+	if err := c.runRestoreQuorumJob(job); err != nil {
+		// the monmap was not modified, so the mon is started again with its current monmap
+		if err := c.scaleMonDeployment(name, 1); err != nil {
+			logger.Errorf("failed to restart mon %q. %v", name, err)
+		}
+		return errors.Wrapf(err, "failed to restore the monmap of mon %q", name)
+	}
+
+	// the other mons are no longer in the monmap
+	for other := range c.ClusterInfo.Monitors {
+		if other == name {
+			continue
+		}
+		logger.Infof("removing mon %q after restoring the quorum", other)
+		if err := c.removeMonDeployment(other); err != nil {
+			return err
+		}
+		if err := c.removeMonResources(other); err != nil {
+			return err
+		}
+	}
+	if err := c.saveMonConfig(); err != nil {
+		return errors.Wrapf(err, "failed to save mon config after restoring the quorum")
+	}
+
+	// the mon picks up the new mon endpoints when it restarts
+	if err := c.scaleMonDeployment(name, 1); err != nil {
+		return err
+	}
+	if err := c.waitForMonsToJoin([]*monConfig{{DaemonName: name}}, true); err != nil {
+		return errors.Wrapf(err, "failed to wait for mon %q to form quorum", name)
+	}
+
+	logger.Infof("mon quorum restored from mon %q", name)
+	return nil
+}
+
+// makeRestoreQuorumJob makes the job that modifies the monmap in the store of the mon. The job runs the pod of
+// the mon deployment with the same flags so the store and the keyring of the mon are found.
+func (c *Cluster) makeRestoreQuorumJob(d *apps.Deployment, name string) (*batch.Job, error) {
+	podSpec := d.Spec.Template.Spec.DeepCopy()
+	var container *v1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "mon" {
+			container = &podSpec.Containers[i]
+		}
+	}
+	if container == nil {
+		return nil, errors.Errorf("failed to find the mon container in the deployment of mon %q", name)
+	}
+
+	monmap := path.Join("/tmp", monmapFile)
+	script := fmt.Sprintf(restoreQuorumScript, monmap, quoteCommand(cephMonCommand, container.Args), monmaptoolCommand, name)
+	container.Command = []string{"/bin/bash", "-c", script}
+	container.Args = nil
+	container.Ports = nil
+	container.LivenessProbe = nil
+	podSpec.Containers = []v1.Container{*container}
+	podSpec.RestartPolicy = v1.RestartPolicyNever
+
+	labels := map[string]string{
+		k8sutil.AppAttr:     restoreQuorumJobName,
+		k8sutil.ClusterAttr: c.Namespace,
+		"mon":               name,
+	}
+	backoffLimit := int32(0)
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restoreQuorumJobName,
+			Namespace: c.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       *podSpec,
+			},
+		},
+	}
+	k8sutil.SetOwnerRef(&job.ObjectMeta, &c.ownerRef)
+	return job, nil
+}
+
+func (c *Cluster) runRestoreQuorumJob(job *batch.Job) error {
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
+		return errors.Wrapf(err, "failed to start the job %q", job.Name)
+	}
+	if err := waitForRestoreQuorumJob(c.context.Clientset, job, restoreQuorumJobTimeout); err != nil {
+		return errors.Wrapf(err, "failed to complete the job %q", job.Name)
+	}
+	if err := k8sutil.DeleteBatchJob(c.context.Clientset, c.Namespace, job.Name, false); err != nil {
+		logger.Warningf("failed to remove the job %q. %v", job.Name, err)
+	}
+	return nil
+}
+
+// scaleMonDeployment sets the replicas of the mon deployment. When the mon is stopped, the function waits for
+// the pod of the mon to be removed.
+func (c *Cluster) scaleMonDeployment(name string, replicas int32) error {
+	d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName(name), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of mon %q", name)
+	}
+	d.Spec.Replicas = &replicas
+	if _, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(d); err != nil {
+		return errors.Wrapf(err, "failed to scale the deployment of mon %q to %d", name, replicas)
+	}
+	if replicas > 0 {
+		return nil
+	}
+
+	selector := fmt.Sprintf("app=%s,mon=%s", AppName, name)
+	err = wait.Poll(c.monPodRetryInterval, c.monPodTimeout, func() (bool, error) {
+		pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			logger.Infof("failed to list the pods of mon %q, trying again. %v", name, err)
+			return false, nil
+		}
+		return len(pods.Items) == 0, nil
+	})
+	return errors.Wrapf(err, "failed to wait for mon %q to stop", name)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestRestoreQuorum(t *testing.T) {
+	defer func(wait func(kubernetes.Interface, *batch.Job, time.Duration) error) {
+		waitForRestoreQuorumJob = wait
+	}(waitForRestoreQuorumJob)
+	var restoreJob *batch.Job
+	var jobErr error
+	waitForRestoreQuorumJob = func(clientset kubernetes.Interface, job *batch.Job, timeout time.Duration) error {
+		restoreJob = job
+		return jobErr
+	}
+
+	clientset := test.New(1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: configDir}, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3}, "myversion")
+	c.waitForStart = false
+	c.monInQuorum = func(name string) bool { return false }
+	c.monPodRetryInterval = time.Millisecond
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := clientset.AppsV1().Deployments("ns").Create(c.makeDeployment(testGenMonConfig(name)))
+		require.NoError(t, err)
+		_, err = clientset.CoreV1().Services("ns").Create(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: resourceName(name), Namespace: "ns"}})
+		require.NoError(t, err)
+	}
+
+	// the quorum can only be restored from a mon of the cluster
+	assert.Error(t, c.RestoreQuorum("z"))
+
+	// the mon is started again with its monmap if the job fails
+	jobErr = errors.New("failed")
+	assert.Error(t, c.RestoreQuorum("b"))
+	assert.Equal(t, 3, len(c.ClusterInfo.Monitors))
+	d, err := clientset.AppsV1().Deployments("ns").Get(resourceName("b"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+
+	// the other mons are removed from the monmap and from the cluster
+	jobErr = nil
+	require.NoError(t, c.RestoreQuorum("b"))
+	require.NotNil(t, restoreJob)
+	assert.Equal(t, v1.RestartPolicyNever, restoreJob.Spec.Template.Spec.RestartPolicy)
+	container := restoreJob.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "mon", container.Name)
+	assert.Empty(t, container.Args)
+	script := container.Command[2]
+	assert.Contains(t, script, "ceph-mon '--fsid=12345'")
+	assert.Contains(t, script, "'--id=b'")
+	assert.Contains(t, script, "monmap=/tmp/monmap\n")
+	assert.Contains(t, script, "--extract-monmap=$monmap")
+	assert.Contains(t, script, `if [ "$mon" != "b" ]`)
+	assert.Contains(t, script, "--inject-monmap=$monmap")
+
+	assert.Equal(t, 1, len(c.ClusterInfo.Monitors))
+	assert.NotNil(t, c.ClusterInfo.Monitors["b"])
+	for _, name := range []string{"a", "c"} {
+		_, err := clientset.AppsV1().Deployments("ns").Get(resourceName(name), metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+		_, err = clientset.CoreV1().Services("ns").Get(resourceName(name), metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err))
+	}
+	d, err = clientset.AppsV1().Deployments("ns").Get(resourceName("b"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "b=1.2.3.2:6789", cm.Data[EndpointDataKey])
+	_, err = clientset.BatchV1().Jobs("ns").Get(restoreQuorumJobName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+// monConfigOptions returns the options of the mons that are set in the centralized mon config database from
+// the config of the mon spec. The durations of the spec are converted to the seconds expected by ceph.
+func (c *Cluster) monConfigOptions() ([]config.Option, error) {
+	spec := c.spec.Mon.Config
+	if spec == nil {
+		return nil, nil
+	}
+	settings := []struct {
+		option string
+		name   string
+		value  string
+	}{
+		{"mon_clock_drift_allowed", "clock drift allowed", spec.ClockDriftAllowed},
+		{"mon_election_timeout", "election timeout", spec.ElectionTimeout},
+	}
+
+	options := []config.Option{}
+	for _, s := range settings {
+		if s.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid mon %s %q", s.name, s.value)
+		}
+		if d <= 0 {
+			return nil, errors.Errorf("invalid mon %s %q. it must be positive", s.name, s.value)
+		}
+		options = append(options, config.Option{Who: "mon", Option: s.option, Value: strconv.FormatFloat(d.Seconds(), 'f', -1, 64)})
+	}
+	return options, nil
+}
+
+// setMonConfigOptions sets the options of the mon spec in the centralized mon config database. An option that
+// is removed from the spec keeps its last value in the database.
+func (c *Cluster) setMonConfigOptions() error {
+	options, err := c.monConfigOptions()
+	if err != nil || len(options) == 0 {
+		return err
+	}
+	if err := config.GetMonStore(c.context, c.Namespace).SetAll(options...); err != nil {
+		return errors.Wrapf(err, "failed to set the mon config options")
+	}
+	return nil
+}
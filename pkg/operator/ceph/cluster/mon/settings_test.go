@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSetMonConfigOptions(t *testing.T) {
+	configSet := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "config" && args[1] == "set" {
+				configSet = append(configSet, strings.Join(args[2:5], " "))
+			}
+			return "", nil
+		},
+	}
+	c := newCluster(&clusterd.Context{Clientset: test.New(1), Executor: executor}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	c.ClusterInfo = test.CreateConfigDir(3)
+
+	// no options are set by default
+	assert.NoError(t, c.setMonConfigOptions())
+	assert.Empty(t, configSet)
+
+	// the durations are set in seconds
+	c.spec.Mon.Config = &cephv1.MonConfigSpec{ClockDriftAllowed: "250ms"}
+	assert.NoError(t, c.setMonConfigOptions())
+	assert.Equal(t, []string{"mon mon_clock_drift_allowed 0.25"}, configSet)
+
+	configSet = []string{}
+	c.spec.Mon.Config.ElectionTimeout = "1m"
+	assert.NoError(t, c.setMonConfigOptions())
+	assert.Equal(t, []string{"mon mon_clock_drift_allowed 0.25", "mon mon_election_timeout 60"}, configSet)
+
+	// invalid durations
+	for _, invalid := range []string{"0.5", "0s", "-1s"} {
+		c.spec.Mon.Config.ElectionTimeout = invalid
+		_, err := c.monConfigOptions()
+		assert.Error(t, err)
+	}
+}
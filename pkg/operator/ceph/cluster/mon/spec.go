@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -43,6 +44,22 @@ const (
 	cephMonCommand = "ceph-mon"
 
 	monmapFile = "monmap"
+
+	// the script fails if the existing store of the mon belongs to another cluster, for example when the
+	// dataDirHostPath was not cleaned up after a previous cluster was deleted
+	verifyFSIDScript = `
+set -e
+if [ ! -d %[1]s ]; then
+  exit 0
+fi
+monmap=%[2]s
+%[3]s --extract-monmap=$monmap
+fsid=$(%[4]s --print $monmap | sed -n 's/^fsid //p')
+if [ "$fsid" != "%[5]s" ]; then
+  echo "the mon store in %[1]s belongs to the cluster $fsid instead of the cluster %[5]s. remove the store of the deleted cluster from the dataDirHostPath" >&2
+  exit 1
+fi
+`
 )
 
 func (c *Cluster) getLabels(daemonName string) map[string]string {
@@ -62,6 +79,9 @@ func (c *Cluster) makeDeployment(monConfig *monConfig) *apps.Deployment {
 			Labels:    c.getLabels(monConfig.DaemonName),
 		},
 	}
+	if monConfig.Zone != "" {
+		d.Labels[stretchZoneLabel] = monConfig.Zone
+	}
 	k8sutil.AddRookVersionLabelToDeployment(d)
 	cephv1.GetMonAnnotations(c.spec.Annotations).ApplyToObjectMeta(&d.ObjectMeta)
 	opspec.AddCephVersionLabelToDeployment(c.ClusterInfo.CephVersion, d)
@@ -176,6 +196,23 @@ func (c *Cluster) setPodPlacement(pod *v1.PodSpec, p rook.Placement, nodeSelecto
 				PodAffinityTerm: monAntiAffinity,
 			})
 	}
+
+	// spread the mons across the failure domains of the nodes. the rule is required unless multiple mons are
+	// allowed in the same failure domain.
+	if c.spec.Mon.FailureDomainLabel != "" {
+		failureDomainAntiAffinity := monAntiAffinity
+		failureDomainAntiAffinity.TopologyKey = c.spec.Mon.FailureDomainLabel
+		if c.spec.Mon.AllowMultiplePerFailureDomain {
+			paa.PreferredDuringSchedulingIgnoredDuringExecution =
+				append(paa.PreferredDuringSchedulingIgnoredDuringExecution, v1.WeightedPodAffinityTerm{
+					Weight:          50,
+					PodAffinityTerm: failureDomainAntiAffinity,
+				})
+		} else {
+			paa.RequiredDuringSchedulingIgnoredDuringExecution =
+				append(paa.RequiredDuringSchedulingIgnoredDuringExecution, failureDomainAntiAffinity)
+		}
+	}
 }
 
 func (c *Cluster) makeMonPod(monConfig *monConfig) *v1.Pod {
@@ -183,6 +220,7 @@ func (c *Cluster) makeMonPod(monConfig *monConfig) *v1.Pod {
 	podSpec := v1.PodSpec{
 		InitContainers: []v1.Container{
 			c.makeChownInitContainer(monConfig),
+			c.makeVerifyFSIDInitContainer(monConfig),
 			c.makeMonFSInitContainer(monConfig),
 		},
 		Containers: []v1.Container{
@@ -244,6 +282,32 @@ func (c *Cluster) makeChownInitContainer(monConfig *monConfig) v1.Container {
 	)
 }
 
+// makeVerifyFSIDInitContainer makes the container that verifies the existing store of the mon belongs to the
+// cluster before the mon starts
+func (c *Cluster) makeVerifyFSIDInitContainer(monConfig *monConfig) v1.Container {
+	extract := quoteCommand(cephMonCommand, opspec.DaemonFlags(c.ClusterInfo, monConfig.DaemonName))
+	store := path.Join(monConfig.DataPathMap.ContainerDataDir, "store.db")
+	script := fmt.Sprintf(verifyFSIDScript, store, path.Join("/tmp", monmapFile), extract, monmaptoolCommand, c.ClusterInfo.FSID)
+	return v1.Container{
+		Name:            "verify-mon-fsid",
+		Command:         []string{"/bin/bash", "-c", script},
+		Image:           c.spec.CephVersion.Image,
+		VolumeMounts:    opspec.DaemonVolumeMounts(monConfig.DataPathMap, keyringStoreName),
+		SecurityContext: PodSecurityContext(),
+		Env:             opspec.DaemonEnvVars(c.spec.CephVersion.Image),
+		Resources:       cephv1.GetMonResources(c.spec.Resources),
+	}
+}
+
+// quoteCommand returns the command line of a command with its args quoted for a shell script
+func quoteCommand(command string, args []string) string {
+	quoted := []string{command}
+	for _, arg := range args {
+		quoted = append(quoted, fmt.Sprintf("'%s'", arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
 func (c *Cluster) makeMonFSInitContainer(monConfig *monConfig) v1.Container {
 	return v1.Container{
 		Name: "init-mon-fs",
@@ -314,6 +378,10 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 		Resources: cephv1.GetMonResources(c.spec.Resources),
 	}
 
+	if c.spec.Mon.Compaction != nil && c.spec.Mon.Compaction.OnStart {
+		container.Args = append(container.Args, config.NewFlag("mon-compact-on-start", "true"))
+	}
+
 	// If host networking is enabled, we don't need a bind addr that is different from the public addr
 	if !c.Network.IsHost() {
 		// Opposite of the above, --public-bind-addr will *not* still advertise on the previous
@@ -324,7 +392,7 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 
 	// If deploying Nautilus and newer we need a new port of the monitor container
 	if c.ClusterInfo.CephVersion.IsAtLeastNautilus() {
-		addContainerPort(container, "msgr2", 3300)
+		addContainerPort(&container, "msgr2", DefaultMsgr2Port)
 	}
 
 	return container
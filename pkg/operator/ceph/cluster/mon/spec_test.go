@@ -26,6 +26,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	testop "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -153,6 +154,39 @@ func TestDeploymentPVCSpec(t *testing.T) {
 	assert.Equal(t, pvc.Spec.Resources.Requests[v1.ResourceStorage], req)
 }
 
+func TestMsgr2Ports(t *testing.T) {
+	clientset := testop.New(1)
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "/var/lib/rook", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3}, "rook/rook:myversion")
+	portNames := func(mon *monConfig) ([]string, []string) {
+		containerPorts := []string{}
+		for _, port := range c.makeMonDaemonContainer(mon).Ports {
+			containerPorts = append(containerPorts, port.Name)
+		}
+		_, err := c.createService(mon)
+		assert.NoError(t, err)
+		svc, err := clientset.CoreV1().Services("ns").Get(mon.ResourceName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		servicePorts := []string{}
+		for _, port := range svc.Spec.Ports {
+			servicePorts = append(servicePorts, port.Name)
+		}
+		return containerPorts, servicePorts
+	}
+
+	// the msgr2 port is not exposed before nautilus
+	c.ClusterInfo.CephVersion = cephver.Mimic
+	containerPorts, servicePorts := portNames(testGenMonConfig("a"))
+	assert.Equal(t, []string{"client"}, containerPorts)
+	assert.Equal(t, []string{"msgr1"}, servicePorts)
+
+	// the mon container and service expose the msgr2 port on nautilus
+	c.ClusterInfo.CephVersion = cephver.Nautilus
+	containerPorts, servicePorts = portNames(testGenMonConfig("b"))
+	assert.Equal(t, []string{"client", "msgr2"}, containerPorts)
+	assert.Equal(t, []string{"msgr1", "msgr2"}, servicePorts)
+}
+
 func testPodSpecPlacement(t *testing.T, hostNet, allowMulti bool, req, pref int, placement *rook.Placement) {
 	clientset := testop.New(1)
 	c := New(
@@ -224,3 +258,47 @@ func TestPodSpecPlacement(t *testing.T) {
 	p = makePlacement()
 	testPodSpecPlacement(t, false, false, 2, 1, &p)
 }
+
+func TestPodSpecFailureDomainPlacement(t *testing.T) {
+	c := New(&clusterd.Context{Clientset: testop.New(1)}, "ns", "/var/lib/rook", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}, false)
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3}, "rook/rook:myversion")
+	c.spec.Mon.FailureDomainLabel = v1.LabelZoneFailureDomain
+
+	// the mons are required to run in different zones
+	d := c.makeDeployment(testGenMonConfig("a"))
+	c.setPodPlacement(&d.Spec.Template.Spec, rook.Placement{}, nil)
+	paa := d.Spec.Template.Spec.Affinity.PodAntiAffinity
+	assert.Equal(t, 2, len(paa.RequiredDuringSchedulingIgnoredDuringExecution))
+	assert.Equal(t, 0, len(paa.PreferredDuringSchedulingIgnoredDuringExecution))
+	assert.Equal(t, v1.LabelHostname, paa.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey)
+	assert.Equal(t, v1.LabelZoneFailureDomain, paa.RequiredDuringSchedulingIgnoredDuringExecution[1].TopologyKey)
+	assert.Equal(t, map[string]string{"app": AppName}, paa.RequiredDuringSchedulingIgnoredDuringExecution[1].LabelSelector.MatchLabels)
+
+	// the zones are only preferred when multiple mons are allowed in a zone
+	c.spec.Mon.AllowMultiplePerFailureDomain = true
+	d = c.makeDeployment(testGenMonConfig("a"))
+	c.setPodPlacement(&d.Spec.Template.Spec, rook.Placement{}, nil)
+	paa = d.Spec.Template.Spec.Affinity.PodAntiAffinity
+	assert.Equal(t, 1, len(paa.RequiredDuringSchedulingIgnoredDuringExecution))
+	assert.Equal(t, 1, len(paa.PreferredDuringSchedulingIgnoredDuringExecution))
+	assert.Equal(t, v1.LabelZoneFailureDomain, paa.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey)
+}
+
+func TestVerifyFSIDInitContainer(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	setCommonMonProperties(c, 1, cephv1.MonSpec{Count: 3}, "myversion")
+	c.spec.CephVersion = cephv1.CephVersionSpec{Image: "ceph/ceph:myceph"}
+	d := c.makeDeployment(testGenMonConfig("a"))
+
+	// the store is verified before the mkfs of a new store
+	initContainers := d.Spec.Template.Spec.InitContainers
+	assert.Equal(t, 3, len(initContainers))
+	assert.Equal(t, "verify-mon-fsid", initContainers[1].Name)
+	assert.Equal(t, "init-mon-fs", initContainers[2].Name)
+
+	script := initContainers[1].Command[2]
+	assert.Contains(t, script, "if [ ! -d /var/lib/ceph/mon/ceph-a/store.db ]")
+	assert.Contains(t, script, "ceph-mon '--fsid=12345'")
+	assert.Contains(t, script, "--extract-monmap=$monmap")
+	assert.Contains(t, script, `if [ "$fsid" != "12345" ]`)
+}
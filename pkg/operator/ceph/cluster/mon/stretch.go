@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rook "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// stretchZoneLabel is set on the mon deployments with the zone the mon is assigned to
+	stretchZoneLabel = "stretch_zone"
+	// the zone label of the nodes, which replaces the deprecated failure-domain.beta.kubernetes.io/zone label
+	labelZoneStable = "topology.kubernetes.io/zone"
+	// the crush rule created for the pools of a stretch cluster
+	stretchCrushRuleName = "stretch_rule"
+	// the crush bucket type of the stretch zones
+	stretchFailureDomain           = "zone"
+	defaultStretchSubFailureDomain = "host"
+	// two mons in each data zone and one in the arbiter zone
+	stretchMonCount = 5
+)
+
+// validateStretchCluster checks that the stretch cluster has two data zones and an arbiter zone for the mons
+func (c *Cluster) validateStretchCluster(cephVersion cephver.CephVersion) error {
+	stretch := c.spec.Mon.StretchCluster
+	if stretch == nil {
+		return nil
+	}
+	if !cephVersion.IsAtLeastPacific() {
+		return errors.Errorf("stretch clusters require ceph pacific or newer, found %s", cephVersion.String())
+	}
+	if len(stretch.Zones) != 3 {
+		return errors.Errorf("stretch clusters require 3 zones, found %d", len(stretch.Zones))
+	}
+	arbiters := 0
+	names := map[string]bool{}
+	for _, zone := range stretch.Zones {
+		if zone.Name == "" {
+			return errors.New("the name of a stretch zone is required")
+		}
+		if names[zone.Name] {
+			return errors.Errorf("stretch zone %q is listed more than once", zone.Name)
+		}
+		names[zone.Name] = true
+		if zone.Arbiter {
+			arbiters++
+		}
+	}
+	if arbiters != 1 {
+		return errors.Errorf("stretch clusters require 1 arbiter zone, found %d", arbiters)
+	}
+	if c.spec.Mon.Count != stretchMonCount {
+		return errors.Errorf("stretch clusters require %d mons, found %d", stretchMonCount, c.spec.Mon.Count)
+	}
+	zoneFailureDomain := c.spec.Mon.FailureDomainLabel == labelZoneStable || c.spec.Mon.FailureDomainLabel == v1.LabelZoneFailureDomain
+	if zoneFailureDomain && !c.spec.Mon.AllowMultiplePerFailureDomain {
+		return errors.New("stretch clusters run two mons in each data zone, which requires allowMultiplePerFailureDomain")
+	}
+	return nil
+}
+
+// assignStretchZones assigns a zone to each mon of a stretch cluster. The existing mons keep the zone of their
+// deployment, and the new mons are assigned to the zones that are missing the most mons.
+func (c *Cluster) assignStretchZones(mons []*monConfig) error {
+	stretch := c.spec.Mon.StretchCluster
+	if stretch == nil {
+		return nil
+	}
+
+	counts := map[string]int{}
+	for _, m := range mons {
+		if m.Zone == "" {
+			zone, err := c.getMonZone(m.DaemonName)
+			if err != nil {
+				return err
+			}
+			m.Zone = zone
+		}
+		if m.Zone != "" {
+			counts[m.Zone]++
+		}
+	}
+
+	for _, m := range mons {
+		if m.Zone != "" {
+			continue
+		}
+		missing := 0
+		for _, zone := range stretch.Zones {
+			target := 2
+			if zone.Arbiter {
+				target = 1
+			}
+			if target-counts[zone.Name] > missing {
+				missing = target - counts[zone.Name]
+				m.Zone = zone.Name
+			}
+		}
+		if m.Zone == "" {
+			return errors.Errorf("no stretch zone is available for mon %q", m.DaemonName)
+		}
+		counts[m.Zone]++
+		logger.Infof("mon %q assigned to stretch zone %q", m.DaemonName, m.Zone)
+	}
+	return nil
+}
+
+// getMonZone returns the stretch zone of an existing mon, or an empty string if the mon has no deployment
+func (c *Cluster) getMonZone(name string) (string, error) {
+	d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName(name), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to get the deployment of mon %q", name)
+	}
+	return d.Labels[stretchZoneLabel], nil
+}
+
+// getMonPlacement returns the placement of the mon from the CRD. The mons of a stretch cluster are also
+// required to run on the nodes of their zone. The zone is matched with the topology.kubernetes.io/zone label,
+// or with the deprecated beta label on the nodes that are not labeled with the stable label.
+func (c *Cluster) getMonPlacement(m *monConfig) rook.Placement {
+	p := cephv1.GetMonPlacement(c.spec.Placement)
+	if m.Zone == "" {
+		return p
+	}
+
+	stableZone := v1.NodeSelectorRequirement{Key: labelZoneStable, Operator: v1.NodeSelectorOpIn, Values: []string{m.Zone}}
+	betaZone := []v1.NodeSelectorRequirement{
+		{Key: labelZoneStable, Operator: v1.NodeSelectorOpDoesNotExist},
+		{Key: v1.LabelZoneFailureDomain, Operator: v1.NodeSelectorOpIn, Values: []string{m.Zone}},
+	}
+	nodeAffinity := &v1.NodeAffinity{}
+	if p.NodeAffinity != nil {
+		nodeAffinity = p.NodeAffinity.DeepCopy()
+	}
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{}
+	}
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []v1.NodeSelectorTerm{{}}
+	}
+	// the terms are ORed, so the zone must be required by each of them. Each term is split in a term that
+	// matches the stable label and a term that matches the beta label.
+	terms := []v1.NodeSelectorTerm{}
+	for _, term := range required.NodeSelectorTerms {
+		stable := term.DeepCopy()
+		stable.MatchExpressions = append(stable.MatchExpressions, stableZone)
+		beta := term.DeepCopy()
+		beta.MatchExpressions = append(beta.MatchExpressions, betaZone...)
+		terms = append(terms, *stable, *beta)
+	}
+	required.NodeSelectorTerms = terms
+	p.NodeAffinity = nodeAffinity
+	return p
+}
+
+// ConfigureStretchMode enables the stretch mode of a stretch cluster once the mons and osds are running. The
+// location of each mon is set to its zone, the stretch crush rule is created and set on the replicated pools,
+// and the mon in the arbiter zone is set as the tiebreaker.
+func (c *Cluster) ConfigureStretchMode() error {
+	stretch := c.spec.Mon.StretchCluster
+	if stretch == nil {
+		return nil
+	}
+
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	status, err := client.GetMonQuorumStatus(c.context, c.ClusterInfo.Name, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get mon quorum status")
+	}
+	if status.MonMap.StretchMode {
+		logger.Debugf("stretch mode is already enabled")
+		// the pools created since the stretch mode was enabled also need the stretch rule
+		return c.applyStretchCrushRule()
+	}
+
+	arbiterZone := ""
+	for _, zone := range stretch.Zones {
+		if zone.Arbiter {
+			arbiterZone = zone.Name
+		}
+	}
+	tiebreaker := ""
+	for name := range c.ClusterInfo.Monitors {
+		zone, err := c.getMonZone(name)
+		if err != nil {
+			return err
+		}
+		if zone == "" {
+			return errors.Errorf("mon %q is not assigned to a stretch zone", name)
+		}
+		if zone == arbiterZone {
+			tiebreaker = name
+		}
+		if err := client.SetMonLocation(c.context, c.ClusterInfo.Name, name, stretchFailureDomain, client.NormalizeCrushName(zone)); err != nil {
+			return err
+		}
+	}
+	if tiebreaker == "" {
+		return errors.Errorf("no mon is running in the arbiter zone %q", arbiterZone)
+	}
+
+	if err := client.SetMonElectionStrategy(c.context, c.ClusterInfo.Name, "connectivity"); err != nil {
+		return err
+	}
+	subFailureDomain := stretch.SubFailureDomain
+	if subFailureDomain == "" {
+		subFailureDomain = defaultStretchSubFailureDomain
+	}
+	if err := client.CreateStretchCrushRule(c.context, c.ClusterInfo.Name, stretchCrushRuleName, stretchFailureDomain, subFailureDomain); err != nil {
+		return errors.Wrapf(err, "failed to create the stretch crush rule")
+	}
+	if err := c.applyStretchCrushRule(); err != nil {
+		return err
+	}
+	if err := client.EnableStretchMode(c.context, c.ClusterInfo.Name, tiebreaker, stretchCrushRuleName, stretchFailureDomain); err != nil {
+		return err
+	}
+	logger.Infof("stretch mode enabled with tiebreaker mon %q in zone %q", tiebreaker, arbiterZone)
+	return nil
+}
+
+// applyStretchCrushRule sets the stretch crush rule on the replicated pools so that their replicas are split
+// between the two data zones. Erasure coded pools are not supported by the stretch mode and keep their rule.
+func (c *Cluster) applyStretchCrushRule() error {
+	pools, err := client.ListPoolSummaries(c.context, c.ClusterInfo.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the pools of the stretch cluster")
+	}
+	for _, pool := range pools {
+		details, err := client.GetPoolDetails(c.context, c.ClusterInfo.Name, pool.Name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the crush rule of pool %q", pool.Name)
+		}
+		if details.ErasureCodeProfile != "" || details.CrushRule == stretchCrushRuleName {
+			continue
+		}
+		logger.Infof("setting the stretch crush rule on pool %q", pool.Name)
+		if err := client.SetPoolProperty(c.context, c.ClusterInfo.Name, pool.Name, "crush_rule", stretchCrushRuleName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rook "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newStretchSpec() *cephv1.StretchClusterSpec {
+	return &cephv1.StretchClusterSpec{
+		Zones: []cephv1.StretchClusterZoneSpec{
+			{Name: "a"},
+			{Name: "b"},
+			{Name: "arbiter", Arbiter: true},
+		},
+	}
+}
+
+func createMonDeployment(t *testing.T, c *Cluster, name, zone string) {
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      resourceName(name),
+		Namespace: c.Namespace,
+		Labels:    map[string]string{stretchZoneLabel: zone},
+	}}
+	_, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Create(d)
+	require.NoError(t, err)
+}
+
+func TestValidateStretchCluster(t *testing.T) {
+	pacific := cephver.CephVersion{Major: 16}
+	c := newCluster(&clusterd.Context{}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	assert.NoError(t, c.validateStretchCluster(cephver.Nautilus))
+
+	c.spec.Mon.StretchCluster = newStretchSpec()
+	c.spec.Mon.Count = 5
+	assert.NoError(t, c.validateStretchCluster(pacific))
+
+	// pacific is required
+	assert.Error(t, c.validateStretchCluster(cephver.Octopus))
+
+	// the mon count must be 5
+	c.spec.Mon.Count = 3
+	assert.Error(t, c.validateStretchCluster(pacific))
+	c.spec.Mon.Count = 5
+
+	// two mons must be allowed in the same zone
+	c.spec.Mon.FailureDomainLabel = v1.LabelZoneFailureDomain
+	assert.Error(t, c.validateStretchCluster(pacific))
+	c.spec.Mon.AllowMultiplePerFailureDomain = true
+	assert.NoError(t, c.validateStretchCluster(pacific))
+
+	// there must be two data zones and one arbiter zone with unique names
+	c.spec.Mon.StretchCluster.Zones[2].Arbiter = false
+	assert.Error(t, c.validateStretchCluster(pacific))
+	c.spec.Mon.StretchCluster.Zones[2].Arbiter = true
+	c.spec.Mon.StretchCluster.Zones[1].Name = "a"
+	assert.Error(t, c.validateStretchCluster(pacific))
+	c.spec.Mon.StretchCluster.Zones[1].Name = ""
+	assert.Error(t, c.validateStretchCluster(pacific))
+	c.spec.Mon.StretchCluster.Zones = c.spec.Mon.StretchCluster.Zones[1:]
+	assert.Error(t, c.validateStretchCluster(pacific))
+}
+
+func TestAssignStretchZones(t *testing.T) {
+	c := newCluster(&clusterd.Context{Clientset: test.New(1)}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	mons := []*monConfig{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		mons = append(mons, &monConfig{DaemonName: name})
+	}
+
+	// the zones are not assigned if the cluster is not stretched
+	assert.NoError(t, c.assignStretchZones(mons))
+	assert.Equal(t, "", mons[0].Zone)
+
+	// the existing mons keep their zone and the new mons fill the zones missing the most mons
+	c.spec.Mon.StretchCluster = newStretchSpec()
+	createMonDeployment(t, c, "a", "arbiter")
+	createMonDeployment(t, c, "b", "a")
+	assert.NoError(t, c.assignStretchZones(mons))
+	zones := []string{}
+	for _, m := range mons {
+		zones = append(zones, m.Zone)
+	}
+	assert.Equal(t, []string{"arbiter", "a", "b", "a", "b"}, zones)
+
+	// the zone is set on the mon deployment
+	c.ClusterInfo = test.CreateConfigDir(1)
+	m := testGenMonConfig("c")
+	m.Zone = mons[2].Zone
+	d := c.makeDeployment(m)
+	assert.Equal(t, "b", d.Labels[stretchZoneLabel])
+	assert.NotContains(t, d.Spec.Selector.MatchLabels, stretchZoneLabel)
+
+	// all the zones are full
+	mons = append(mons, &monConfig{DaemonName: "f"})
+	assert.Error(t, c.assignStretchZones(mons))
+}
+
+func TestStretchMonPlacement(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+
+	// the placement is not changed outside of a stretch cluster
+	p := c.getMonPlacement(&monConfig{DaemonName: "a"})
+	assert.Nil(t, p.NodeAffinity)
+
+	// the mon is required to run in its zone
+	p = c.getMonPlacement(&monConfig{DaemonName: "a", Zone: "b"})
+	require.NotNil(t, p.NodeAffinity)
+	terms := p.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	require.Equal(t, 2, len(terms))
+	assert.Equal(t, []v1.NodeSelectorRequirement{{Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}}}, terms[0].MatchExpressions)
+	// the nodes without the stable zone label are matched with the beta label
+	assert.Equal(t, []v1.NodeSelectorRequirement{
+		{Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpDoesNotExist},
+		{Key: v1.LabelZoneFailureDomain, Operator: v1.NodeSelectorOpIn, Values: []string{"b"}},
+	}, terms[1].MatchExpressions)
+
+	// the zone is added to the node affinity from the crd without modifying it
+	storageNodes := v1.NodeSelectorRequirement{Key: "role", Operator: v1.NodeSelectorOpIn, Values: []string{"storage"}}
+	c.spec.Placement = rook.PlacementSpec{cephv1.KeyMon: {
+		NodeAffinity: &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{storageNodes}}},
+		}},
+	}}
+	p = c.getMonPlacement(&monConfig{DaemonName: "a", Zone: "b"})
+	terms = p.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	require.Equal(t, 2, len(terms))
+	assert.Equal(t, 2, len(terms[0].MatchExpressions))
+	assert.Equal(t, storageNodes, terms[0].MatchExpressions[0])
+	assert.Equal(t, 3, len(terms[1].MatchExpressions))
+	assert.Equal(t, storageNodes, terms[1].MatchExpressions[0])
+	assert.Equal(t, 1, len(c.spec.Placement[cephv1.KeyMon].NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions))
+}
+
+func TestConfigureStretchMode(t *testing.T) {
+	stretchMode := false
+	commands := []string{}
+	poolRules := map[string]string{"replicapool": "replicapool", "ecpool": "ecpool"}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "quorum_status":
+				if stretchMode {
+					return `{"monmap":{"stretch_mode":true}}`, nil
+				}
+				return `{"monmap":{"stretch_mode":false}}`, nil
+			case args[0] == "osd" && args[1] == "crush" && args[2] == "dump":
+				return `{"rules":[{"rule_id":0,"rule_name":"stretch_rule"}]}`, nil
+			case args[0] == "osd" && args[1] == "lspools":
+				return `[{"poolnum":1,"poolname":"replicapool"},{"poolnum":2,"poolname":"ecpool"}]`, nil
+			case args[0] == "osd" && args[1] == "pool" && args[2] == "get":
+				if args[3] == "ecpool" {
+					return `{"pool":"ecpool","erasure_code_profile":"ecpool"}{"pool":"ecpool","crush_rule":"ecpool"}`, nil
+				}
+				return fmt.Sprintf(`{"pool":"%s","crush_rule":"%s"}`, args[3], poolRules[args[3]]), nil
+			case args[0] == "osd" && args[1] == "pool" && args[2] == "set":
+				assert.Equal(t, "crush_rule", args[4])
+				poolRules[args[3]] = args[5]
+			}
+			commands = append(commands, strings.Join(args[:3], " "))
+			return "", nil
+		},
+	}
+	c := newCluster(&clusterd.Context{Clientset: test.New(1), Executor: executor}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	c.ClusterInfo = test.CreateConfigDir(3)
+
+	// nothing is configured if the cluster is not stretched
+	assert.NoError(t, c.ConfigureStretchMode())
+	assert.Empty(t, commands)
+
+	// all the mons must be assigned to a zone
+	c.spec.Mon.StretchCluster = newStretchSpec()
+	createMonDeployment(t, c, "a", "a")
+	createMonDeployment(t, c, "b", "b")
+	assert.Error(t, c.ConfigureStretchMode())
+
+	// the mon in the arbiter zone is the tiebreaker
+	commands = []string{}
+	createMonDeployment(t, c, "c", "arbiter")
+	assert.NoError(t, c.ConfigureStretchMode())
+	assert.ElementsMatch(t, []string{
+		"mon set_location a",
+		"mon set_location b",
+		"mon set_location c",
+		"mon set election_strategy",
+		"osd pool set",
+		"mon enable_stretch_mode c",
+	}, commands)
+	assert.Equal(t, map[string]string{"replicapool": "stretch_rule", "ecpool": "ecpool"}, poolRules)
+
+	// stretch mode is already enabled
+	commands = []string{}
+	stretchMode = true
+	assert.NoError(t, c.ConfigureStretchMode())
+	assert.Empty(t, commands)
+
+	// a pool created since the stretch mode was enabled is set to the stretch rule
+	poolRules["replicapool"] = "replicapool"
+	assert.NoError(t, c.ConfigureStretchMode())
+	assert.Equal(t, []string{"osd pool set"}, commands)
+	assert.Equal(t, "stretch_rule", poolRules["replicapool"])
+}
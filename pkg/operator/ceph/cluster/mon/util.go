@@ -73,7 +73,7 @@ func addServicePort(service *v1.Service, name string, port int32) {
 }
 
 // addContainerPort adds a port to a container
-func addContainerPort(container v1.Container, name string, port int32) {
+func addContainerPort(container *v1.Container, name string, port int32) {
 	if port == 0 {
 		return
 	}
@@ -37,14 +37,26 @@ func GetConfigStoreName(nodeName string) string {
 }
 
 const (
-	StoreTypeKey       = "storeType"
-	WalSizeMBKey       = "walSizeMB"
-	DatabaseSizeMBKey  = "databaseSizeMB"
-	JournalSizeMBKey   = "journalSizeMB"
-	OSDsPerDeviceKey   = "osdsPerDevice"
-	EncryptedDeviceKey = "encryptedDevice"
-	MetadataDeviceKey  = "metadataDevice"
-	DeviceClassKey     = "deviceClass"
+	StoreTypeKey              = "storeType"
+	WalSizeMBKey              = "walSizeMB"
+	DatabaseSizeMBKey         = "databaseSizeMB"
+	JournalSizeMBKey          = "journalSizeMB"
+	OSDsPerDeviceKey          = "osdsPerDevice"
+	EncryptedDeviceKey        = "encryptedDevice"
+	MetadataDeviceKey         = "metadataDevice"
+	DeviceClassKey            = "deviceClass"
+	TestDeviceSizeMBKey       = "testDeviceSizeMB"
+	CompressionModeKey        = "compressionMode"
+	CompressionAlgorithmKey   = "compressionAlgorithm"
+	CompressionMinBlobSizeKey = "compressionMinBlobSize"
+	CephVolumeModeKey         = "cephVolumeMode"
+)
+
+const (
+	// CephVolumeLVMMode is the default mode of ceph-volume, where the osds are created on lvm volumes
+	CephVolumeLVMMode = "lvm"
+	// CephVolumeRawMode is the mode of ceph-volume where bluestore is created on the device or partition directly
+	CephVolumeRawMode = "raw"
 )
 
 type StoreConfig struct {
@@ -55,6 +67,16 @@ type StoreConfig struct {
 	OSDsPerDevice   int    `json:"osdsPerDevice,omitempty"`
 	EncryptedDevice bool   `json:"encryptedDevice,omitempty"`
 	DeviceClass     string `json:"deviceClass,omitempty"`
+	// TestDeviceSizeMB enables the test mode, where the osds of a node are created on a loop device backed by a
+	// sparse file of this size in the data dir of the host
+	TestDeviceSizeMB int `json:"testDeviceSizeMB,omitempty"`
+	// CompressionMode, CompressionAlgorithm and CompressionMinBlobSize are the bluestore compression settings of the
+	// osds, set in the config of the mons for each osd by the prepare job
+	CompressionMode        string `json:"compressionMode,omitempty"`
+	CompressionAlgorithm   string `json:"compressionAlgorithm,omitempty"`
+	CompressionMinBlobSize int    `json:"compressionMinBlobSize,omitempty"`
+	// CephVolumeMode is the mode of ceph-volume used to prepare the osd on a pvc, "lvm" or "raw"
+	CephVolumeMode string `json:"cephVolumeMode,omitempty"`
 }
 
 func ToStoreConfig(config map[string]string) StoreConfig {
@@ -75,6 +97,16 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 			storeConfig.EncryptedDevice = (v == "true")
 		case DeviceClassKey:
 			storeConfig.DeviceClass = v
+		case TestDeviceSizeMBKey:
+			storeConfig.TestDeviceSizeMB = convertToIntIgnoreErr(v)
+		case CompressionModeKey:
+			storeConfig.CompressionMode = v
+		case CompressionAlgorithmKey:
+			storeConfig.CompressionAlgorithm = v
+		case CompressionMinBlobSizeKey:
+			storeConfig.CompressionMinBlobSize = convertToIntIgnoreErr(v)
+		case CephVolumeModeKey:
+			storeConfig.CephVolumeMode = v
 		}
 	}
 
@@ -27,6 +27,10 @@ import (
 func (c *Cluster) prepareStorageClassDeviceSets(config *provisionConfig) []rookalpha.VolumeSource {
 	volumeSources := []rookalpha.VolumeSource{}
 	for _, storageClassDeviceSet := range c.DesiredStorage.StorageClassDeviceSets {
+		if err := validateStorageClassDeviceSet(storageClassDeviceSet); err != nil {
+			config.addError("cannot use storageClassDeviceSet %s for creating osds %v", storageClassDeviceSet.Name, err)
+			continue
+		}
 		if err := opspec.CheckPodMemory(storageClassDeviceSet.Resources, cephOsdPodMinimumMemory); err != nil {
 			config.addError("cannot use storageClassDeviceSet %s for creating osds %v", storageClassDeviceSet.Name, err)
 			continue
@@ -55,6 +59,28 @@ func (c *Cluster) prepareStorageClassDeviceSets(config *provisionConfig) []rooka
 	return volumeSources
 }
 
+// validateStorageClassDeviceSet checks that the PVCs of the set can be provisioned as block devices for the OSDs
+func validateStorageClassDeviceSet(storageClassDeviceSet rookalpha.StorageClassDeviceSet) error {
+	if storageClassDeviceSet.Name == "" {
+		return errors.New("the storageClassDeviceSet must have a name")
+	}
+	if storageClassDeviceSet.Count < 0 {
+		return errors.Errorf("invalid count %d. it must not be negative", storageClassDeviceSet.Count)
+	}
+	if len(storageClassDeviceSet.VolumeClaimTemplates) == 0 {
+		return errors.New("no volumeClaimTemplates are specified")
+	}
+	for _, template := range storageClassDeviceSet.VolumeClaimTemplates {
+		if template.Spec.VolumeMode == nil || *template.Spec.VolumeMode != v1.PersistentVolumeBlock {
+			return errors.Errorf("the volumeMode of the volumeClaimTemplate %q must be %q", template.GetName(), v1.PersistentVolumeBlock)
+		}
+		if _, ok := template.Spec.Resources.Requests[v1.ResourceStorage]; !ok {
+			return errors.Errorf("the volumeClaimTemplate %q must request the storage size", template.GetName())
+		}
+	}
+	return nil
+}
+
 func (c *Cluster) createStorageClassDeviceSetPVC(storageClassDeviceSet rookalpha.StorageClassDeviceSet, setIndex int) (*v1.PersistentVolumeClaim, error) {
 	if len(storageClassDeviceSet.VolumeClaimTemplates) == 0 {
 		return nil, errors.Errorf("no PVC available for storageClassDeviceSet %s", storageClassDeviceSet.Name)
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateStorageClassDeviceSet(t *testing.T) {
+	block := v1.PersistentVolumeBlock
+	filesystem := v1.PersistentVolumeFilesystem
+	template := v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeMode: &block,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	set := rookalpha.StorageClassDeviceSet{Name: "set1", Count: 3, VolumeClaimTemplates: []v1.PersistentVolumeClaim{template}}
+	assert.NoError(t, validateStorageClassDeviceSet(set))
+
+	invalid := set
+	invalid.Name = ""
+	assert.Error(t, validateStorageClassDeviceSet(invalid))
+
+	invalid = set
+	invalid.Count = -1
+	assert.Error(t, validateStorageClassDeviceSet(invalid))
+
+	invalid = set
+	invalid.VolumeClaimTemplates = nil
+	assert.Error(t, validateStorageClassDeviceSet(invalid))
+
+	// the pvcs must be block devices
+	for _, mode := range []*v1.PersistentVolumeMode{nil, &filesystem} {
+		invalid = set
+		invalid.VolumeClaimTemplates = []v1.PersistentVolumeClaim{*template.DeepCopy()}
+		invalid.VolumeClaimTemplates[0].Spec.VolumeMode = mode
+		assert.Error(t, validateStorageClassDeviceSet(invalid))
+	}
+
+	// the size of the pvcs must be requested
+	invalid = set
+	invalid.VolumeClaimTemplates = []v1.PersistentVolumeClaim{*template.DeepCopy()}
+	invalid.VolumeClaimTemplates[0].Spec.Resources.Requests = nil
+	assert.Error(t, validateStorageClassDeviceSet(invalid))
+
+	// no pvcs are created for an invalid set
+	clientset := fake.NewSimpleClientset()
+	storage := rookalpha.StorageScopeSpec{StorageClassDeviceSets: []rookalpha.StorageClassDeviceSet{invalid}}
+	c := New(&cephconfig.ClusterInfo{CephVersion: cephver.Nautilus}, &clusterd.Context{Clientset: clientset}, "ns", "myversion", cephv1.CephVersionSpec{},
+		storage, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+	config := c.newProvisionConfig()
+	assert.Empty(t, c.prepareStorageClassDeviceSets(config))
+	assert.Equal(t, 1, len(config.errorMessages))
+	pvcs, err := clientset.CoreV1().PersistentVolumeClaims("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, pvcs.Items)
+}
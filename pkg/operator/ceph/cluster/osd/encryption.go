@@ -0,0 +1,229 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	encryptionKeySecretNameFmt = "rook-ceph-osd-%d-encryption-key"
+	// EncryptionKeySecretKey is the key of the dm-crypt key in the encryption key secret of an osd
+	EncryptionKeySecretKey = "dmcrypt-key"
+	// ceph-volume stores the dm-crypt key of an osd under this key in the config-key store of the mons
+	dmcryptConfigKeyFmt = "dm-crypt/osd/%s/luks"
+)
+
+func encryptionKeySecretName(osdID int) string {
+	return fmt.Sprintf(encryptionKeySecretNameFmt, osdID)
+}
+
+// keyStore keeps the copies of the dm-crypt keys of the encrypted osds
+type keyStore interface {
+	// getKey returns the key of the osd and whether it was found
+	getKey(osdID int) (string, bool, error)
+	setKey(osdID int, key string) error
+	deleteKey(osdID int) error
+}
+
+// getKeyStore returns the key management service if one is configured, or the store of the keys in secrets. The
+// store is created once for each orchestration of the osds so the token secret is not read for every osd.
+func (c *Cluster) getKeyStore() (keyStore, error) {
+	if c.keyStore != nil {
+		return c.keyStore, nil
+	}
+	store, err := newKeyStore(c.context, c.Namespace, c.ownerRef, c.kms)
+	if err != nil {
+		return nil, err
+	}
+	c.keyStore = store
+	return c.keyStore, nil
+}
+
+// newKeyStore returns the key management service if one is configured, or the store of the keys in secrets
+func newKeyStore(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference, kms cephv1.KeyManagementServiceSpec) (keyStore, error) {
+	if !kms.IsEnabled() {
+		return &secretKeyStore{context: context, namespace: namespace, ownerRef: ownerRef}, nil
+	}
+	store, err := newVaultKeyStore(context.Clientset, namespace, kms)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// ensureEncryptionKey keeps a copy of the dm-crypt key of an encrypted osd in the key store. ceph-volume stores the
+// key in the config-key store of the mons, where the osd gets it from each time its devices are opened. If the
+// key is missing from the mons, it is restored from the key store so the osd can still be activated when it restarts.
+func (c *Cluster) ensureEncryptionKey(osd OSDInfo) error {
+	if !osd.Encrypted {
+		return nil
+	}
+	if osd.UUID == "" {
+		return errors.Errorf("failed to find the uuid of encrypted osd %d", osd.ID)
+	}
+
+	store, err := c.getKeyStore()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the key store")
+	}
+
+	if err := c.migrateEncryptionKey(store, osd.ID); err != nil {
+		return errors.Wrapf(err, "failed to move the dm-crypt key of osd %d to the kms", osd.ID)
+	}
+
+	configKey := fmt.Sprintf(dmcryptConfigKeyFmt, osd.UUID)
+	monKey, found, err := client.GetConfigKey(c.context, c.clusterInfo.Name, configKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the dm-crypt key of osd %d", osd.ID)
+	}
+	savedKey, saved, err := store.getKey(osd.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the saved dm-crypt key of osd %d", osd.ID)
+	}
+
+	if !found {
+		if !saved {
+			return errors.Errorf("the dm-crypt key of osd %d was not found in the mons or in the key store", osd.ID)
+		}
+		logger.Infof("restoring the dm-crypt key of osd %d from the key store", osd.ID)
+		if err := client.SetConfigKey(c.context, c.clusterInfo.Name, configKey, savedKey); err != nil {
+			return errors.Wrapf(err, "failed to restore the dm-crypt key of osd %d", osd.ID)
+		}
+		return nil
+	}
+
+	if saved {
+		if savedKey == monKey {
+			return nil
+		}
+		logger.Warningf("the saved dm-crypt key of osd %d does not match the key in the mons. updating the saved key", osd.ID)
+	}
+	if err := store.setKey(osd.ID, monKey); err != nil {
+		return errors.Wrapf(err, "failed to save the dm-crypt key of osd %d", osd.ID)
+	}
+	logger.Infof("saved the dm-crypt key of osd %d", osd.ID)
+	return nil
+}
+
+// migrateEncryptionKey moves the copy of the dm-crypt key of an osd that was saved in a secret before the key
+// management service was configured. The secret is removed once the key is in the key management service.
+func (c *Cluster) migrateEncryptionKey(store keyStore, osdID int) error {
+	if !c.kms.IsEnabled() {
+		return nil
+	}
+	secrets := &secretKeyStore{context: c.context, namespace: c.Namespace, ownerRef: c.ownerRef}
+	key, found, err := secrets.getKey(osdID)
+	if err != nil || !found {
+		return err
+	}
+	_, saved, err := store.getKey(osdID)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		if err := store.setKey(osdID, key); err != nil {
+			return err
+		}
+		logger.Infof("moved the dm-crypt key of osd %d from its secret to the kms", osdID)
+	}
+	return secrets.deleteKey(osdID)
+}
+
+// deleteEncryptionKey removes the copy of the dm-crypt key of a removed osd
+func (c *Cluster) deleteEncryptionKey(osdID int) error {
+	store, err := c.getKeyStore()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the key store")
+	}
+	return deleteKeyFromStore(c.context, c.Namespace, c.kms, store, osdID)
+}
+
+// deleteKeyFromStore removes the copy of the dm-crypt key of a removed osd from the key store, and from the secret
+// that may be left from before the kms was configured
+func deleteKeyFromStore(context *clusterd.Context, namespace string, kms cephv1.KeyManagementServiceSpec, store keyStore, osdID int) error {
+	if err := store.deleteKey(osdID); err != nil {
+		return err
+	}
+	if kms.IsEnabled() {
+		return (&secretKeyStore{context: context, namespace: namespace}).deleteKey(osdID)
+	}
+	return nil
+}
+
+// secretKeyStore keeps the dm-crypt key of each osd in a secret
+type secretKeyStore struct {
+	context   *clusterd.Context
+	namespace string
+	ownerRef  metav1.OwnerReference
+}
+
+func (s *secretKeyStore) getKey(osdID int) (string, bool, error) {
+	secret, err := s.context.Clientset.CoreV1().Secrets(s.namespace).Get(encryptionKeySecretName(osdID), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, errors.Wrapf(err, "failed to get the encryption key secret of osd %d", osdID)
+	}
+	key, ok := secret.Data[EncryptionKeySecretKey]
+	return string(key), ok, nil
+}
+
+func (s *secretKeyStore) setKey(osdID int, key string) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      encryptionKeySecretName(osdID),
+			Namespace: s.namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     AppName,
+				k8sutil.ClusterAttr: s.namespace,
+				OsdIdLabelKey:       strconv.Itoa(osdID),
+			},
+		},
+		Data: map[string][]byte{EncryptionKeySecretKey: []byte(key)},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(&secret.ObjectMeta, &s.ownerRef)
+
+	_, err := s.context.Clientset.CoreV1().Secrets(s.namespace).Create(secret)
+	if err != nil && kerrors.IsAlreadyExists(err) {
+		_, err = s.context.Clientset.CoreV1().Secrets(s.namespace).Update(secret)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the encryption key secret of osd %d", osdID)
+	}
+	return nil
+}
+
+func (s *secretKeyStore) deleteKey(osdID int) error {
+	err := s.context.Clientset.CoreV1().Secrets(s.namespace).Delete(encryptionKeySecretName(osdID), &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the encryption key secret of osd %d", osdID)
+	}
+	return nil
+}
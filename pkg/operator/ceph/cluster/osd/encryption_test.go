@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnsureEncryptionKey(t *testing.T) {
+	store := map[string]string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "config-key" && args[1] == "dump":
+			keys := map[string]string{}
+			for key, val := range store {
+				if strings.HasPrefix(key, args[2]) {
+					keys[key] = val
+				}
+			}
+			output, _ := json.Marshal(keys)
+			return string(output), nil
+		case args[0] == "config-key" && args[1] == "set" && args[3] == "-i":
+			val, err := ioutil.ReadFile(args[4])
+			if err != nil {
+				return "", err
+			}
+			store[args[2]] = string(val)
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	clientset := test.New(1)
+	clusterInfo := &cephconfig.ClusterInfo{Name: "rook-ceph", CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, Executor: executor}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+
+	getSecret := func(id int) (*v1.Secret, error) {
+		return clientset.CoreV1().Secrets("ns").Get(encryptionKeySecretName(id), metav1.GetOptions{})
+	}
+	configKey := "dm-crypt/osd/myuuid/luks"
+
+	// nothing is saved for an osd that is not encrypted
+	require.NoError(t, c.ensureEncryptionKey(OSDInfo{ID: 0, UUID: "myuuid"}))
+	_, err := getSecret(0)
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the key must be found somewhere
+	osd := OSDInfo{ID: 1, UUID: "myuuid", Encrypted: true}
+	assert.Error(t, c.ensureEncryptionKey(osd))
+
+	// the key of the mons is saved in the secret
+	store[configKey] = "mykey"
+	require.NoError(t, c.ensureEncryptionKey(osd))
+	secret, err := getSecret(1)
+	require.NoError(t, err)
+	assert.Equal(t, "mykey", string(secret.Data[EncryptionKeySecretKey]))
+	assert.Equal(t, "1", secret.Labels[OsdIdLabelKey])
+
+	// the key is restored in the mons from the secret
+	delete(store, configKey)
+	require.NoError(t, c.ensureEncryptionKey(osd))
+	assert.Equal(t, "mykey", store[configKey])
+
+	// the secret follows the key of the mons
+	store[configKey] = "newkey"
+	require.NoError(t, c.ensureEncryptionKey(osd))
+	secret, err = getSecret(1)
+	require.NoError(t, err)
+	assert.Equal(t, "newkey", string(secret.Data[EncryptionKeySecretKey]))
+
+	// the secret is removed with the osd
+	require.NoError(t, c.deleteEncryptionKey(1))
+	_, err = getSecret(1)
+	assert.True(t, kerrors.IsNotFound(err))
+	assert.NoError(t, c.deleteEncryptionKey(1))
+}
+
+// memKeyStore is a key management service in memory
+type memKeyStore map[int]string
+
+func (m memKeyStore) getKey(osdID int) (string, bool, error) {
+	key, ok := m[osdID]
+	return key, ok, nil
+}
+
+func (m memKeyStore) setKey(osdID int, key string) error {
+	m[osdID] = key
+	return nil
+}
+
+func (m memKeyStore) deleteKey(osdID int) error {
+	delete(m, osdID)
+	return nil
+}
+
+func TestMigrateEncryptionKey(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[0] == "config-key" && args[1] == "dump" {
+			return `{"dm-crypt/osd/myuuid/luks":"mykey"}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	clientset := test.New(1)
+	clusterInfo := &cephconfig.ClusterInfo{Name: "rook-ceph", CephVersion: cephver.Nautilus}
+	kms := cephv1.KeyManagementServiceSpec{Provider: KMSProviderVault}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, Executor: executor}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, kms, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+	kmsStore := memKeyStore{}
+	c.keyStore = kmsStore
+
+	// the key that was saved in a secret before the kms was configured
+	require.NoError(t, (&secretKeyStore{context: c.context, namespace: c.Namespace}).setKey(1, "mykey"))
+
+	// the key is moved to the kms and the secret is removed
+	require.NoError(t, c.ensureEncryptionKey(OSDInfo{ID: 1, UUID: "myuuid", Encrypted: true}))
+	assert.Equal(t, "mykey", kmsStore[1])
+	_, err := clientset.CoreV1().Secrets("ns").Get(encryptionKeySecretName(1), metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the key store is reused
+	store, err := c.getKeyStore()
+	require.NoError(t, err)
+	assert.Equal(t, kmsStore, store)
+}
@@ -17,21 +17,32 @@ limitations under the License.
 package osd
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	upStatus  = 1
-	inStatus  = 1
+	upStatus = 1
+	inStatus = 1
+	// graceTime is how long an osd must be down and out before it is removed
 	graceTime = 60 * time.Minute
+
+	// healthStoreName is the configmap where the osd monitor keeps its state across the restarts of the operator
+	healthStoreName = "rook-ceph-osd-health"
+	downOutSinceKey = "down-out-since"
+	// the uuids of the osds removed by the monitor, whose volumes are still on their devices
+	purgedOSDsKey = "purged-osds"
 )
 
 var (
@@ -44,11 +55,24 @@ type Monitor struct {
 	clusterName                    string
 	removeOSDsIfOUTAndSafeToRemove bool
 	cephVersion                    cephver.CephVersion
+	ownerRef                       metav1.OwnerReference
+	kms                            cephv1.KeyManagementServiceSpec
+	kv                             *k8sutil.ConfigMapKVStore
+	// downOutSince is when each osd was first seen down and out. It is loaded from the health store on the first
+	// check after the operator starts.
+	downOutSince       map[int]time.Time
+	downOutSinceLoaded bool
+	weightIn           *cephv1.OSDWeightInSpec
+	lastWeightInStep   time.Time
+	// weightInFinished is whether the osds were set to their full weight after the weight-in was disabled
+	weightInFinished bool
 }
 
 // NewMonitor instantiates OSD monitoring
-func NewMonitor(context *clusterd.Context, clusterName string, removeOSDsIfOUTAndSafeToRemove bool, cephVersion cephver.CephVersion) *Monitor {
-	return &Monitor{context, clusterName, removeOSDsIfOUTAndSafeToRemove, cephVersion}
+func NewMonitor(context *clusterd.Context, clusterName string, removeOSDsIfOUTAndSafeToRemove bool, cephVersion cephver.CephVersion, kms cephv1.KeyManagementServiceSpec, ownerRef metav1.OwnerReference) *Monitor {
+	return &Monitor{context: context, clusterName: clusterName, removeOSDsIfOUTAndSafeToRemove: removeOSDsIfOUTAndSafeToRemove,
+		cephVersion: cephVersion, ownerRef: ownerRef, kms: kms, kv: k8sutil.NewConfigMapKVStore(clusterName, context.Clientset, ownerRef),
+		downOutSince: map[int]time.Time{}}
 }
 
 // Start runs monitoring logic for osds status at set intervals
@@ -62,6 +86,9 @@ func (m *Monitor) Start(stopCh chan struct{}) {
 			if err != nil {
 				logger.Warningf("failed OSD status check. %v", err)
 			}
+			if err := m.weighInOSDs(); err != nil {
+				logger.Warningf("failed to weigh in the new osds. %v", err)
+			}
 
 		case <-stopCh:
 			logger.Infof("Stopping monitoring of OSDs in namespace %s", m.clusterName)
@@ -75,6 +102,11 @@ func (m *Monitor) Update(removeOSDsIfOUTAndSafeToRemove bool) {
 	m.removeOSDsIfOUTAndSafeToRemove = removeOSDsIfOUTAndSafeToRemove
 }
 
+// UpdateKMS updates the key management service of the dm-crypt keys of the osds
+func (m *Monitor) UpdateKMS(kms cephv1.KeyManagementServiceSpec) {
+	m.kms = kms
+}
+
 // OSDStatus validates osd dump output
 func (m *Monitor) osdStatus() error {
 	osdDump, err := client.GetOSDDump(m.context, m.clusterName)
@@ -83,6 +115,16 @@ func (m *Monitor) osdStatus() error {
 	}
 	logger.Debugf("osd dump %v", osdDump)
 
+	if !m.downOutSinceLoaded {
+		if err := m.loadDownOutSince(); err != nil {
+			return err
+		}
+	}
+	previousDownOutSince := map[int]time.Time{}
+	for id, since := range m.downOutSince {
+		previousDownOutSince[id] = since
+	}
+
 	for _, osdStatus := range osdDump.OSDs {
 		id64, err := osdStatus.OSD.Int64()
 		if err != nil {
@@ -106,43 +148,143 @@ func (m *Monitor) osdStatus() error {
 
 		if in != inStatus {
 			logger.Debugf("osd.%d is marked 'OUT'", id)
-			if m.removeOSDsIfOUTAndSafeToRemove {
-				if err := m.handleOSDMarkedOut(id); err != nil {
-					logger.Errorf("error handling marked out osd osd.%d. %v", id, err)
-				}
+		}
+
+		if status == upStatus || in == inStatus {
+			delete(m.downOutSince, id)
+			continue
+		}
+		if _, ok := m.downOutSince[id]; !ok {
+			m.downOutSince[id] = time.Now()
+		}
+		if m.removeOSDsIfOUTAndSafeToRemove {
+			if err := m.handleOSDMarkedOut(id, osdStatus.UUID); err != nil {
+				logger.Errorf("error handling marked out osd osd.%d. %v", id, err)
 			}
 		}
 	}
 
+	if !reflect.DeepEqual(previousDownOutSince, m.downOutSince) {
+		if err := m.saveDownOutSince(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (m *Monitor) handleOSDMarkedOut(outOSDid int) error {
-	label := fmt.Sprintf("ceph-osd-id=%d", outOSDid)
-	dp, err := k8sutil.GetDeployments(m.context.Clientset, m.clusterName, label)
+// loadDownOutSince restores the time the osds are down and out since, so the grace time does not start again when
+// the operator restarts
+func (m *Monitor) loadDownOutSince() error {
+	val, err := m.kv.GetValue(healthStoreName, downOutSinceKey)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get the time the osds are down and out since")
+	}
+	if err == nil {
+		if err := json.Unmarshal([]byte(val), &m.downOutSince); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal the time the osds are down and out since")
+		}
+	}
+	m.downOutSinceLoaded = true
+	return nil
+}
+
+func (m *Monitor) saveDownOutSince() error {
+	val, err := json.Marshal(m.downOutSince)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the time the osds are down and out since")
+	}
+	if err := m.kv.SetValue(healthStoreName, downOutSinceKey, string(val)); err != nil {
+		return errors.Wrapf(err, "failed to save the time the osds are down and out since")
+	}
+	return nil
+}
+
+// getPurgedOSDs returns the uuids of the osds that were removed by the monitor. The volumes of the osds are still on
+// their devices, so the osds must not be started again when they are found on the devices.
+func getPurgedOSDs(kv *k8sutil.ConfigMapKVStore) (map[string]bool, error) {
+	purged := map[string]bool{}
+	val, err := kv.GetValue(healthStoreName, purgedOSDsKey)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
-			return nil
+			return purged, nil
 		}
+		return nil, errors.Wrapf(err, "failed to get the purged osds")
+	}
+	if err := json.Unmarshal([]byte(val), &purged); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the purged osds")
+	}
+	return purged, nil
+}
+
+func addPurgedOSD(kv *k8sutil.ConfigMapKVStore, uuid string) error {
+	purged, err := getPurgedOSDs(kv)
+	if err != nil {
+		return err
+	}
+	purged[uuid] = true
+	val, err := json.Marshal(purged)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the purged osds")
+	}
+	return kv.SetValue(healthStoreName, purgedOSDsKey, string(val))
+}
+
+// deleteEncryptionKey removes the copy of the dm-crypt key of a removed osd from the same key store as the osds
+func (m *Monitor) deleteEncryptionKey(osdID int) error {
+	store, err := newKeyStore(m.context, m.clusterName, m.ownerRef, m.kms)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the key store")
+	}
+	return deleteKeyFromStore(m.context, m.clusterName, m.kms, store, osdID)
+}
+
+// handleOSDMarkedOut removes an osd that has been down and out for the grace time once its data is safe. The
+// deployment of the osd is deleted and the osd is removed from the crush map, the auth and the osd map.
+func (m *Monitor) handleOSDMarkedOut(outOSDid int, uuid string) error {
+	if time.Since(m.downOutSince[outOSDid]) < graceTime {
+		logger.Debugf("osd.%d is down and out since %s", outOSDid, m.downOutSince[outOSDid])
+		return nil
+	}
+
+	safeToDestroyOSD, err := client.OsdSafeToDestroy(m.context, m.clusterName, outOSDid, m.cephVersion)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if osd.%d is safe to destroy", outOSDid)
+	}
+	if !safeToDestroyOSD {
+		logger.Debugf("osd.%d is down and out but not 'safe-to-destroy' yet", outOSDid)
+		return nil
+	}
+
+	logger.Infof("osd.%d is down and out for more than %s and 'safe-to-destroy'. removing the osd.", outOSDid, graceTime)
+	label := fmt.Sprintf("%s=%d", OsdIdLabelKey, outOSDid)
+	dp, err := k8sutil.GetDeployments(m.context.Clientset, m.clusterName, label)
+	if err != nil {
 		return errors.Wrapf(err, "failed to get osd deployment of osd id %d", outOSDid)
 	}
-	if len(dp.Items) != 0 {
-		safeToDestroyOSD, err := client.OsdSafeToDestroy(m.context, m.clusterName, outOSDid, m.cephVersion)
-		if err != nil {
-			return errors.Wrapf(err, "failed to get osd deployment of osd id %d", outOSDid)
+	for _, d := range dp.Items {
+		if err := k8sutil.DeleteDeployment(m.context.Clientset, d.Namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete osd deployment %s", d.Name)
 		}
+	}
 
-		if safeToDestroyOSD {
-			podCreationTimestamp := dp.Items[0].GetCreationTimestamp()
-			podDeletionTimeStamp := podCreationTimestamp.Add(graceTime)
-			currentTime := time.Now().UTC()
-			if podDeletionTimeStamp.Before(currentTime) {
-				logger.Infof("osd.%d is 'safe-to-destroy'. removing the osd deployment.", outOSDid)
-				if err := k8sutil.DeleteDeployment(m.context.Clientset, dp.Items[0].Namespace, dp.Items[0].Name); err != nil {
-					return errors.Wrapf(err, "failed to delete osd deployment %s", dp.Items[0].Name)
-				}
-			}
+	// the osd is not started again from the volumes left on its device
+	if uuid != "" {
+		if err := addPurgedOSD(m.kv, uuid); err != nil {
+			return errors.Wrapf(err, "failed to record the removal of osd.%d", outOSDid)
 		}
 	}
+	if err := purgeOSD(m.context, m.clusterName, outOSDid); err != nil {
+		return errors.Wrapf(err, "failed to purge osd.%d from the cluster", outOSDid)
+	}
+	delete(m.downOutSince, outOSDid)
+
+	if err := deleteOSDFileSystem(m.context.Clientset, m.clusterName, outOSDid); err != nil {
+		logger.Warningf("failed to delete osd.%d filesystem, it may need to be cleaned up manually. %v", outOSDid, err)
+	}
+	if err := m.deleteEncryptionKey(outOSDid); err != nil {
+		logger.Warningf("failed to delete the encryption key of osd.%d, it may need to be cleaned up manually. %v", outOSDid, err)
+	}
+	logger.Infof("removed osd.%d", outOSDid)
 	return nil
 }
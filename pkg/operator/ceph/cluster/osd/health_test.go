@@ -17,23 +17,33 @@ package osd
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testexec "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOSDStatus(t *testing.T) {
 	cluster := "fake"
 
 	var execCount = 0
+	var commands []string
+	osdDump := `{"OSDs": [{"OSD": 0, "UUID": "osd0-uuid", "Up": 0, "In": 0}]}`
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
 			return "{\"key\":\"mysecurekey\", \"osdid\":3.0}", nil
@@ -43,12 +53,13 @@ func TestOSDStatus(t *testing.T) {
 		logger.Infof("ExecuteCommandWithOutputFile: %s %v", command, args)
 		execCount++
 		if args[1] == "dump" {
-			// Mock executor for OSD Dump command, returning an osd in Down state
-			return `{"OSDs": [{"OSD": 0, "Up": 1, "In": 0}]}`, nil
+			// Mock executor for OSD Dump command, returning an osd in Down and Out state
+			return osdDump, nil
 		} else if args[1] == "safe-to-destroy" {
 			// Mock executor for OSD Dump command, returning an osd in Down state
 			return `{"safe_to_destroy":[0],"active":[],"missing_stats":[],"stored_pgs":[]}`, nil
 		}
+		commands = append(commands, strings.Join(args[:3], " "))
 		return "", nil
 	}
 
@@ -89,17 +100,45 @@ func TestOSDStatus(t *testing.T) {
 	}
 
 	// Initializing an OSD monitoring
-	osdMon := NewMonitor(context, cluster, true, cephVersion)
+	osdMon := NewMonitor(context, cluster, true, cephVersion, cephv1.KeyManagementServiceSpec{}, metav1.OwnerReference{})
 
 	// Run OSD monitoring routine
 	err := osdMon.osdStatus()
 	assert.Nil(t, err)
-	// After creating an OSD, the dump has 1 mocked cmd and safe to destroy has 1 mocked cmd
-	assert.Equal(t, 2, execCount)
+	// The osd was just seen down and out, so only the dump is run
+	assert.Equal(t, 1, execCount)
+	dp, _ = context.Clientset.AppsV1().Deployments(cluster).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%v=%d", OsdIdLabelKey, 0)})
+	assert.Equal(t, 1, len(dp.Items))
+
+	// The time the osd is down and out since is kept when the operator restarts
+	restartedMon := NewMonitor(context, cluster, true, cephVersion, cephv1.KeyManagementServiceSpec{}, metav1.OwnerReference{})
+	assert.Nil(t, restartedMon.loadDownOutSince())
+	assert.Equal(t, 1, len(restartedMon.downOutSince))
+	assert.True(t, osdMon.downOutSince[0].Equal(restartedMon.downOutSince[0]))
+
+	// The osd is removed once it is down and out for the grace time
+	osdMon.downOutSince[0] = time.Now().Add(-graceTime)
+	err = osdMon.osdStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"osd crush rm", "auth del osd.0", "osd rm 0"}, commands)
+	assert.Empty(t, osdMon.downOutSince)
+	purged, err := getPurgedOSDs(osdMon.kv)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"osd0-uuid": true}, purged)
+	restartedMon = NewMonitor(context, cluster, true, cephVersion, cephv1.KeyManagementServiceSpec{}, metav1.OwnerReference{})
+	assert.Nil(t, restartedMon.loadDownOutSince())
+	assert.Empty(t, restartedMon.downOutSince)
 
 	// Check if the osd deployment was deleted
 	dp, _ = context.Clientset.AppsV1().Deployments(cluster).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%v=%d", OsdIdLabelKey, 0)})
 	assert.Equal(t, 0, len(dp.Items))
+
+	// The timer of an osd is reset when it is up again
+	osdDump = `{"OSDs": [{"OSD": 1, "Up": 1, "In": 0}]}`
+	osdMon.downOutSince[1] = time.Now()
+	err = osdMon.osdStatus()
+	assert.Nil(t, err)
+	assert.Empty(t, osdMon.downOutSince)
 }
 
 func TestMonitorStart(t *testing.T) {
@@ -108,8 +147,46 @@ func TestMonitorStart(t *testing.T) {
 	}
 
 	stopCh := make(chan struct{})
-	osdMon := NewMonitor(&clusterd.Context{}, "cluster", true, cephVersion)
+	osdMon := NewMonitor(&clusterd.Context{}, "cluster", true, cephVersion, cephv1.KeyManagementServiceSpec{}, metav1.OwnerReference{})
 	logger.Infof("starting osd monitor")
 	go osdMon.Start(stopCh)
 	close(stopCh)
 }
+
+func TestMonitorDeleteEncryptionKey(t *testing.T) {
+	// the dm-crypt keys of the osds are kept in vault
+	deleted := []string{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	clientset := testexec.New(1)
+	_, err := clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "ns"},
+		Data:       map[string][]byte{KMSTokenSecretKey: []byte("mytoken")},
+	})
+	require.NoError(t, err)
+	// a secret is left from before the kms was configured
+	_, err = clientset.CoreV1().Secrets("ns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: encryptionKeySecretName(3), Namespace: "ns"}})
+	require.NoError(t, err)
+	kms := cephv1.KeyManagementServiceSpec{
+		Provider: KMSProviderVault,
+		ConnectionDetails: map[string]string{
+			VaultAddressKey:     server.URL + "/",
+			VaultBackendPathKey: "rook/",
+			VaultSkipVerifyKey:  "true",
+		},
+		TokenSecretName: "vault-token",
+	}
+	m := NewMonitor(&clusterd.Context{Clientset: clientset}, "ns", true, cephver.Nautilus, kms, metav1.OwnerReference{})
+
+	// the key of a purged osd is removed from vault like when the osd is removed manually
+	require.NoError(t, m.deleteEncryptionKey(3))
+	assert.Equal(t, []string{"/v1/rook/metadata/ns/rook-ceph-osd-3-encryption-key"}, deleted)
+	_, err = clientset.CoreV1().Secrets("ns").Get(encryptionKeySecretName(3), metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// KMSProviderVault is the provider of the HashiCorp Vault key management service
+	KMSProviderVault = "vault"
+	// KMSTokenSecretKey is the key of the token in the token secret of the key management service
+	KMSTokenSecretKey = "token"
+	// KMSCACertSecretKey is the key of the PEM encoded CA certificates in the CA secret of the key management service
+	KMSCACertSecretKey = "cert"
+
+	// VaultAddressKey is the connection detail with the address of vault, such as https://vault.default:8200
+	VaultAddressKey = "VAULT_ADDR"
+	// VaultBackendPathKey is the connection detail with the path of the kv version 2 secrets engine
+	VaultBackendPathKey = "VAULT_BACKEND_PATH"
+	// VaultNamespaceKey is the connection detail with the vault enterprise namespace
+	VaultNamespaceKey = "VAULT_NAMESPACE"
+	// VaultCACertKey is the connection detail with the name of the secret with the CA certificates that signed
+	// the certificate of vault
+	VaultCACertKey = "VAULT_CACERT"
+	// VaultSkipVerifyKey is the connection detail to skip the verification of the certificate of vault if "true"
+	VaultSkipVerifyKey = "VAULT_SKIP_VERIFY"
+
+	defaultVaultBackendPath = "secret"
+	vaultRequestTimeout     = 30 * time.Second
+)
+
+// validateKMS checks the settings of the key management service without connecting to it
+func validateKMS(kms cephv1.KeyManagementServiceSpec) error {
+	if !kms.IsEnabled() {
+		return nil
+	}
+	if kms.Provider != KMSProviderVault {
+		return errors.Errorf("unsupported kms provider %q. only %q is supported", kms.Provider, KMSProviderVault)
+	}
+	if kms.ConnectionDetails[VaultAddressKey] == "" {
+		return errors.Errorf("the kms connection details must have the %s of vault", VaultAddressKey)
+	}
+	if kms.TokenSecretName == "" {
+		return errors.New("the kms token secret name must be set")
+	}
+	if skipVerify := kms.ConnectionDetails[VaultSkipVerifyKey]; skipVerify != "" {
+		if _, err := strconv.ParseBool(skipVerify); err != nil {
+			return errors.Wrapf(err, "invalid kms connection detail %s", VaultSkipVerifyKey)
+		}
+	}
+	return nil
+}
+
+// vaultKeyStore keeps the dm-crypt keys of the osds in the kv version 2 secrets engine of vault. The keys of the
+// osds of a cluster are stored under the namespace of the cluster.
+type vaultKeyStore struct {
+	address        string
+	backendPath    string
+	vaultNamespace string
+	token          string
+	prefix         string
+	client         *http.Client
+}
+
+// vaultSecret is the body of a kv version 2 secret
+type vaultSecret struct {
+	Data map[string]string `json:"data"`
+}
+
+func newVaultKeyStore(clientset kubernetes.Interface, namespace string, kms cephv1.KeyManagementServiceSpec) (*vaultKeyStore, error) {
+	if err := validateKMS(kms); err != nil {
+		return nil, err
+	}
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(kms.TokenSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the kms token secret %q", kms.TokenSecretName)
+	}
+	token := string(secret.Data[KMSTokenSecretKey])
+	if token == "" {
+		return nil, errors.Errorf("the kms token secret %q does not have a %q key", kms.TokenSecretName, KMSTokenSecretKey)
+	}
+
+	tlsConfig, err := vaultTLSConfig(clientset, namespace, kms)
+	if err != nil {
+		return nil, err
+	}
+
+	backendPath := kms.ConnectionDetails[VaultBackendPathKey]
+	if backendPath == "" {
+		backendPath = defaultVaultBackendPath
+	}
+	return &vaultKeyStore{
+		address:        strings.TrimSuffix(kms.ConnectionDetails[VaultAddressKey], "/"),
+		backendPath:    strings.Trim(backendPath, "/"),
+		vaultNamespace: kms.ConnectionDetails[VaultNamespaceKey],
+		token:          token,
+		prefix:         namespace,
+		client: &http.Client{
+			Timeout:   vaultRequestTimeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// vaultTLSConfig returns the settings to verify the certificate of vault. The system CAs are used unless the CA
+// secret is given.
+func vaultTLSConfig(clientset kubernetes.Interface, namespace string, kms cephv1.KeyManagementServiceSpec) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if skipVerify, _ := strconv.ParseBool(kms.ConnectionDetails[VaultSkipVerifyKey]); skipVerify {
+		logger.Warningf("the certificate of vault is not verified since %s is set", VaultSkipVerifyKey)
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	caSecretName := kms.ConnectionDetails[VaultCACertKey]
+	if caSecretName == "" {
+		return tlsConfig, nil
+	}
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(caSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the kms CA secret %q", caSecretName)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(secret.Data[KMSCACertSecretKey]) {
+		return nil, errors.Errorf("the kms CA secret %q does not have PEM encoded certificates under the %q key", caSecretName, KMSCACertSecretKey)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// url returns the url of the key of the osd. The data of the key is under "data" and all its versions are
+// deleted under "metadata".
+func (v *vaultKeyStore) url(kind string, osdID int) string {
+	return fmt.Sprintf("%s/v1/%s/%s/%s/%s", v.address, v.backendPath, kind, v.prefix, encryptionKeySecretName(osdID))
+}
+
+func (v *vaultKeyStore) request(method, url string, body interface{}) (int, []byte, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return 0, nil, errors.Wrapf(err, "failed to marshal the vault request")
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to create the vault request")
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.vaultNamespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.vaultNamespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to send the request to vault")
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to read the response of vault")
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+func (v *vaultKeyStore) getKey(osdID int) (string, bool, error) {
+	status, body, err := v.request(http.MethodGet, v.url("data", osdID), nil)
+	if err != nil {
+		return "", false, err
+	}
+	if status == http.StatusNotFound {
+		return "", false, nil
+	}
+	if status != http.StatusOK {
+		return "", false, errors.Errorf("failed to get the key of osd %d from vault. status %d: %s", osdID, status, string(body))
+	}
+
+	// the secret is wrapped in the data of the response
+	var resp struct {
+		Data vaultSecret `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", false, errors.Wrapf(err, "failed to unmarshal the key of osd %d from vault", osdID)
+	}
+	key, ok := resp.Data.Data[EncryptionKeySecretKey]
+	return key, ok, nil
+}
+
+func (v *vaultKeyStore) setKey(osdID int, key string) error {
+	secret := vaultSecret{Data: map[string]string{EncryptionKeySecretKey: key}}
+	status, body, err := v.request(http.MethodPost, v.url("data", osdID), secret)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNoContent {
+		return errors.Errorf("failed to save the key of osd %d in vault. status %d: %s", osdID, status, string(body))
+	}
+	return nil
+}
+
+func (v *vaultKeyStore) deleteKey(osdID int) error {
+	status, body, err := v.request(http.MethodDelete, v.url("metadata", osdID), nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNoContent && status != http.StatusNotFound {
+		return errors.Errorf("failed to delete the key of osd %d from vault. status %d: %s", osdID, status, string(body))
+	}
+	return nil
+}
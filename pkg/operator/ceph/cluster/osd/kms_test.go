@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateKMS(t *testing.T) {
+	kms := cephv1.KeyManagementServiceSpec{}
+	assert.NoError(t, validateKMS(kms))
+
+	kms.Provider = "other"
+	assert.Error(t, validateKMS(kms))
+
+	kms.Provider = KMSProviderVault
+	assert.Error(t, validateKMS(kms))
+
+	kms.ConnectionDetails = map[string]string{VaultAddressKey: "https://vault:8200"}
+	assert.Error(t, validateKMS(kms))
+
+	kms.TokenSecretName = "vault-token"
+	assert.NoError(t, validateKMS(kms))
+
+	kms.ConnectionDetails[VaultSkipVerifyKey] = "maybe"
+	assert.Error(t, validateKMS(kms))
+
+	kms.ConnectionDetails[VaultSkipVerifyKey] = "true"
+	assert.NoError(t, validateKMS(kms))
+}
+
+func TestVaultKeyStore(t *testing.T) {
+	// the kv version 2 secrets engine of vault
+	secrets := map[string]vaultSecret{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "mytoken" || r.Header.Get("X-Vault-Namespace") != "myvaultns" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/v1/rook/data/"), "/v1/rook/metadata/")
+		switch r.Method {
+		case http.MethodGet:
+			secret, ok := secrets[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]vaultSecret{"data": secret})
+		case http.MethodPost:
+			var secret vaultSecret
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&secret))
+			secrets[name] = secret
+		case http.MethodDelete:
+			assert.True(t, strings.HasPrefix(r.URL.Path, "/v1/rook/metadata/"))
+			delete(secrets, name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	clientset := test.New(1)
+	kms := cephv1.KeyManagementServiceSpec{
+		Provider: KMSProviderVault,
+		ConnectionDetails: map[string]string{
+			VaultAddressKey:     server.URL + "/",
+			VaultBackendPathKey: "rook/",
+			VaultNamespaceKey:   "myvaultns",
+		},
+		TokenSecretName: "vault-token",
+	}
+
+	// the token secret must exist
+	_, err := newVaultKeyStore(clientset, "ns", kms)
+	assert.Error(t, err)
+	_, err = clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "ns"},
+		Data:       map[string][]byte{KMSTokenSecretKey: []byte("mytoken")},
+	})
+	require.NoError(t, err)
+	store, err := newVaultKeyStore(clientset, "ns", kms)
+	require.NoError(t, err)
+
+	// the certificate of vault is not signed by a system CA
+	_, _, err = store.getKey(3)
+	assert.Error(t, err)
+
+	// the certificate is trusted with the CA secret
+	kms.ConnectionDetails[VaultCACertKey] = "vault-ca"
+	_, err = newVaultKeyStore(clientset, "ns", kms)
+	assert.Error(t, err)
+	_, err = clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-ca", Namespace: "ns"},
+		Data: map[string][]byte{
+			KMSCACertSecretKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		},
+	})
+	require.NoError(t, err)
+	store, err = newVaultKeyStore(clientset, "ns", kms)
+	require.NoError(t, err)
+
+	_, found, err := store.getKey(3)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, store.setKey(3, "mykey"))
+	assert.Equal(t, "mykey", secrets["ns/rook-ceph-osd-3-encryption-key"].Data[EncryptionKeySecretKey])
+	key, found, err := store.getKey(3)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "mykey", key)
+
+	require.NoError(t, store.deleteKey(3))
+	assert.Equal(t, 0, len(secrets))
+
+	// the errors of vault are reported
+	store.token = "badtoken"
+	_, _, err = store.getKey(3)
+	assert.Error(t, err)
+	assert.Error(t, store.setKey(3, "mykey"))
+
+	// the certificate is not verified if requested
+	delete(kms.ConnectionDetails, VaultCACertKey)
+	kms.ConnectionDetails[VaultSkipVerifyKey] = "true"
+	store, err = newVaultKeyStore(clientset, "ns", kms)
+	require.NoError(t, err)
+	_, found, err = store.getKey(3)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NodeMaintenanceAnnotation is set to "true" on a node to put its osds into maintenance. The osds of the node are
+	// started again when the annotation is removed.
+	NodeMaintenanceAnnotation = "ceph.rook.io/osd-maintenance"
+)
+
+// IsNodeInMaintenance returns whether the osds of the node are in maintenance
+func IsNodeInMaintenance(node *v1.Node) bool {
+	return node.Annotations[NodeMaintenanceAnnotation] == "true"
+}
+
+// nodeInMaintenance returns whether the node with the given hostname is in maintenance
+func (c *Cluster) nodeInMaintenance(hostName string) bool {
+	nodeName, err := k8sutil.GetNodeNameFromHostname(c.context.Clientset, hostName)
+	if err != nil {
+		logger.Debugf("failed to get the node with hostname %q. %v", hostName, err)
+		return false
+	}
+	node, err := c.context.Clientset.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		logger.Debugf("failed to get node %q. %v", nodeName, err)
+		return false
+	}
+	return IsNodeInMaintenance(node)
+}
+
+// StartNodeMaintenance sets the noout flag on the crush host of the node and stops the osds of the node, so they can
+// be down without their data being rebalanced
+func (c *Cluster) StartNodeMaintenance(hostName string) error {
+	crushHost := client.NormalizeCrushName(hostName)
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the osd flags for the maintenance of node %q", hostName)
+	}
+	if _, err := osdDump.UpdateFlagOnCrushUnit(c.context, true, c.clusterInfo.Name, crushHost, nooutFlag); err != nil {
+		return errors.Wrapf(err, "failed to set the noout flag on host %q", crushHost)
+	}
+
+	logger.Infof("stopping the osds of node %q for the maintenance", hostName)
+	return c.scaleNodeOSDs(hostName, 0)
+}
+
+// StopNodeMaintenance starts the osds of the node again and unsets the noout flag on its crush host
+func (c *Cluster) StopNodeMaintenance(hostName string) error {
+	logger.Infof("starting the osds of node %q after the maintenance", hostName)
+	if err := c.scaleNodeOSDs(hostName, 1); err != nil {
+		return err
+	}
+
+	crushHost := client.NormalizeCrushName(hostName)
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the osd flags after the maintenance of node %q", hostName)
+	}
+	if _, err := osdDump.UpdateFlagOnCrushUnit(c.context, false, c.clusterInfo.Name, crushHost, nooutFlag); err != nil {
+		return errors.Wrapf(err, "failed to unset the noout flag on host %q", crushHost)
+	}
+	return nil
+}
+
+// scaleNodeOSDs sets the number of replicas of the osd deployments of the node
+func (c *Cluster) scaleNodeOSDs(hostName string, replicas int32) error {
+	deployments, err := c.getNodeOSDDeployments(hostName)
+	if err != nil {
+		return err
+	}
+	for i := range deployments {
+		d := &deployments[i]
+		if d.Spec.Replicas != nil && *d.Spec.Replicas == replicas {
+			continue
+		}
+		d.Spec.Replicas = &replicas
+		if _, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(d); err != nil {
+			return errors.Wrapf(err, "failed to scale osd deployment %q to %d", d.Name, replicas)
+		}
+		logger.Infof("scaled osd deployment %q to %d", d.Name, replicas)
+	}
+	return nil
+}
+
+// getNodeOSDDeployments returns the osd deployments that run on the node with the given hostname
+func (c *Cluster) getNodeOSDDeployments(hostName string) ([]apps.Deployment, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	osdDeployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the osd deployments")
+	}
+	var deployments []apps.Deployment
+	for _, d := range osdDeployments.Items {
+		if d.Spec.Template.Spec.NodeSelector[v1.LabelHostname] == hostName {
+			deployments = append(deployments, d)
+		}
+	}
+	return deployments, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeMaintenance(t *testing.T) {
+	crushNodeFlags := `{}`
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "osd" && args[1] == "dump":
+			return `{"osds":[],"flags":"","crush_node_flags":` + crushNodeFlags + `}`, nil
+		case args[0] == "osd" && (args[1] == "set-group" || args[1] == "unset-group"):
+			commands = append(commands, strings.Join(args[:4], " "))
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	clientset := test.New(1)
+	c := New(&cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Nautilus}, &clusterd.Context{Clientset: clientset, Executor: executor}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+
+	replicas := int32(1)
+	for _, osd := range []struct{ name, host string }{{"rook-ceph-osd-0", "node.a"}, {"rook-ceph-osd-1", "node.a"}, {"rook-ceph-osd-2", "node.b"}} {
+		d := &apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: osd.name, Namespace: "ns", Labels: map[string]string{k8sutil.AppAttr: AppName}},
+			Spec: apps.DeploymentSpec{
+				Replicas: &replicas,
+				Template: v1.PodTemplateSpec{Spec: v1.PodSpec{NodeSelector: map[string]string{v1.LabelHostname: osd.host}}},
+			},
+		}
+		_, err := clientset.AppsV1().Deployments("ns").Create(d)
+		require.NoError(t, err)
+	}
+	getReplicas := func(name string) int32 {
+		d, err := clientset.AppsV1().Deployments("ns").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return *d.Spec.Replicas
+	}
+
+	// the osds of the node are stopped with noout set on its crush host
+	require.NoError(t, c.StartNodeMaintenance("node.a"))
+	assert.Equal(t, []string{"osd set-group noout node-a"}, commands)
+	assert.Equal(t, int32(0), getReplicas("rook-ceph-osd-0"))
+	assert.Equal(t, int32(0), getReplicas("rook-ceph-osd-1"))
+	assert.Equal(t, int32(1), getReplicas("rook-ceph-osd-2"))
+
+	// the osds are started again and noout is unset
+	commands = nil
+	crushNodeFlags = `{"node-a":["noout"]}`
+	require.NoError(t, c.StopNodeMaintenance("node.a"))
+	assert.Equal(t, []string{"osd unset-group noout node-a"}, commands)
+	assert.Equal(t, int32(1), getReplicas("rook-ceph-osd-0"))
+	assert.Equal(t, int32(1), getReplicas("rook-ceph-osd-1"))
+
+	// the maintenance is read from the annotation of the node
+	assert.False(t, c.nodeInMaintenance("node0"))
+	node, err := clientset.CoreV1().Nodes().Get("node0", metav1.GetOptions{})
+	require.NoError(t, err)
+	node.Labels = map[string]string{v1.LabelHostname: "node0"}
+	node.Annotations = map[string]string{NodeMaintenanceAnnotation: "true"}
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	require.NoError(t, err)
+	assert.True(t, c.nodeInMaintenance("node0"))
+}
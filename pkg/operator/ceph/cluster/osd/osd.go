@@ -19,6 +19,8 @@ package osd
 
 import (
 	"fmt"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -31,9 +33,9 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
-	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/discover"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	apps "k8s.io/api/apps/v1"
 	batch "k8s.io/api/batch/v1"
@@ -46,6 +48,8 @@ import (
 var (
 	logger                  = capnslog.NewPackageLogger("github.com/rook/rook", "op-osd")
 	updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait
+	// cephVolumeRawModeMinVersion is the first version of ceph with the raw mode of ceph-volume
+	cephVolumeRawModeMinVersion = cephver.CephVersion{Major: 14, Minor: 2, Extra: 8}
 )
 
 const (
@@ -68,25 +72,30 @@ const (
 
 // Cluster keeps track of the OSDs
 type Cluster struct {
-	clusterInfo       *cephconfig.ClusterInfo
-	context           *clusterd.Context
-	Namespace         string
-	placement         rookalpha.Placement
-	annotations       rookalpha.Annotations
-	Keyring           string
-	rookVersion       string
-	cephVersion       cephv1.CephVersionSpec
-	DesiredStorage    rookalpha.StorageScopeSpec // user-defined storage scope spec
-	ValidStorage      rookalpha.StorageScopeSpec // valid subset of `Storage`, computed at runtime
-	dataDirHostPath   string
-	Network           cephv1.NetworkSpec
-	resources         v1.ResourceRequirements
-	prepareResources  v1.ResourceRequirements
-	priorityClassName string
-	ownerRef          metav1.OwnerReference
-	kv                *k8sutil.ConfigMapKVStore
-	isUpgrade         bool
-	skipUpgradeChecks bool
+	clusterInfo              *cephconfig.ClusterInfo
+	context                  *clusterd.Context
+	Namespace                string
+	placement                rookalpha.Placement
+	annotations              rookalpha.Annotations
+	Keyring                  string
+	rookVersion              string
+	cephVersion              cephv1.CephVersionSpec
+	DesiredStorage           rookalpha.StorageScopeSpec // user-defined storage scope spec
+	ValidStorage             rookalpha.StorageScopeSpec // valid subset of `Storage`, computed at runtime
+	dataDirHostPath          string
+	Network                  cephv1.NetworkSpec
+	kms                      cephv1.KeyManagementServiceSpec
+	keyStore                 keyStore
+	resources                v1.ResourceRequirements
+	prepareResources         v1.ResourceRequirements
+	priorityClassName        string
+	preparePriorityClassName string
+	ownerRef                 metav1.OwnerReference
+	kv                       *k8sutil.ConfigMapKVStore
+	isUpgrade                bool
+	skipUpgradeChecks        bool
+	// WeightInNewOSDs is whether the osds started for the first time are weighed in by the osd monitor
+	WeightInNewOSDs bool
 }
 
 // New creates an instance of the OSD manager
@@ -101,31 +110,35 @@ func New(
 	placement rookalpha.Placement,
 	annotations rookalpha.Annotations,
 	network cephv1.NetworkSpec,
+	kms cephv1.KeyManagementServiceSpec,
 	resources v1.ResourceRequirements,
 	prepareResources v1.ResourceRequirements,
 	priorityClassName string,
+	preparePriorityClassName string,
 	ownerRef metav1.OwnerReference,
 	isUpgrade bool,
 	skipUpgradeChecks bool,
 ) *Cluster {
 	return &Cluster{
-		clusterInfo:       clusterInfo,
-		context:           context,
-		Namespace:         namespace,
-		placement:         placement,
-		annotations:       annotations,
-		rookVersion:       rookVersion,
-		cephVersion:       cephVersion,
-		DesiredStorage:    storageSpec,
-		dataDirHostPath:   dataDirHostPath,
-		Network:           network,
-		resources:         resources,
-		prepareResources:  prepareResources,
-		priorityClassName: priorityClassName,
-		ownerRef:          ownerRef,
-		kv:                k8sutil.NewConfigMapKVStore(namespace, context.Clientset, ownerRef),
-		isUpgrade:         isUpgrade,
-		skipUpgradeChecks: skipUpgradeChecks,
+		clusterInfo:              clusterInfo,
+		context:                  context,
+		Namespace:                namespace,
+		placement:                placement,
+		annotations:              annotations,
+		rookVersion:              rookVersion,
+		cephVersion:              cephVersion,
+		DesiredStorage:           storageSpec,
+		dataDirHostPath:          dataDirHostPath,
+		Network:                  network,
+		kms:                      kms,
+		resources:                resources,
+		prepareResources:         prepareResources,
+		priorityClassName:        priorityClassName,
+		preparePriorityClassName: preparePriorityClassName,
+		ownerRef:                 ownerRef,
+		kv:                       k8sutil.NewConfigMapKVStore(namespace, context.Clientset, ownerRef),
+		isUpgrade:                isUpgrade,
+		skipUpgradeChecks:        skipUpgradeChecks,
 	}
 }
 
@@ -147,6 +160,12 @@ type OSDInfo struct {
 	SkipLVRelease bool   `json:"skip-lv-release"`
 	Location      string `json:"location"`
 	LVBackedPV    bool   `json:"lv-backed-pv"`
+	// Encrypted is whether the devices of the OSD were encrypted with dm-crypt by ceph-volume
+	Encrypted bool `json:"encrypted"`
+	// CVMode is the mode of ceph-volume the OSD was prepared with. It is empty for the lvm mode.
+	CVMode string `json:"cv-mode"`
+	// BlockPath is the path of the block device of an OSD prepared in the raw mode of ceph-volume
+	BlockPath string `json:"block-path"`
 }
 
 // OrchestrationStatus represents the status of an OSD orchestration
@@ -183,6 +202,10 @@ func (c *Cluster) Start() error {
 		return errors.Wrap(err, "error checking pod memory")
 	}
 
+	if err := validateKMS(c.kms); err != nil {
+		return errors.Wrap(err, "invalid kms settings")
+	}
+
 	logger.Infof("start running osds in namespace %s", c.Namespace)
 
 	if c.DesiredStorage.UseAllNodes == false && len(c.DesiredStorage.Nodes) == 0 && len(c.DesiredStorage.VolumeSources) == 0 && len(c.DesiredStorage.StorageClassDeviceSets) == 0 {
@@ -197,6 +220,8 @@ func (c *Cluster) Start() error {
 	logger.Infof("start provisioning the osds on nodes, if needed")
 	c.startProvisioningOverNodes(config)
 
+	c.updateOSDDeployments(config)
+
 	if len(config.errorMessages) > 0 {
 		return errors.Errorf("%d failures encountered while running osds in namespace %s: %+v",
 			len(config.errorMessages), c.Namespace, strings.Join(config.errorMessages, "\n"))
@@ -257,12 +282,26 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 	}
 
 	for _, volume := range c.ValidStorage.VolumeSources {
+		volumeConfig := osdconfig.ToStoreConfig(volume.Config)
+		if err := c.validateCephVolumeMode(volumeConfig.CephVolumeMode); err != nil {
+			config.addError("invalid config of pvc %q. %v", volume.PersistentVolumeClaimSource.ClaimName, err)
+			continue
+		}
 		osdProps := osdProperties{
 			crushHostname: volume.PersistentVolumeClaimSource.ClaimName,
 			pvc:           volume.PersistentVolumeClaimSource,
 			resources:     volume.Resources,
 			placement:     volume.Placement,
 			portable:      volume.Portable,
+			// only the device class, the compression and the ceph-volume mode of the config of the device set apply to
+			// the osd on the pvc
+			storeConfig: osdconfig.StoreConfig{
+				DeviceClass:            volumeConfig.DeviceClass,
+				CompressionMode:        volumeConfig.CompressionMode,
+				CompressionAlgorithm:   volumeConfig.CompressionAlgorithm,
+				CompressionMinBlobSize: volumeConfig.CompressionMinBlobSize,
+				CephVolumeMode:         volumeConfig.CephVolumeMode,
+			},
 		}
 
 		// update the orchestration status of this pvc to the starting state
@@ -311,7 +350,7 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 			}
 		}
 
-		if !c.runJob(job, osdProps.crushHostname, config, "provision") {
+		if !c.runProvisionJob(job, osdProps.crushHostname, config) {
 			status := OrchestrationStatus{
 				Status:       OrchestrationStatusCompleted,
 				Message:      fmt.Sprintf("failed to start osd provisioning on pvc %s", osdProps.crushHostname),
@@ -376,6 +415,22 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 			continue
 		}
 
+		if c.nodeInMaintenance(n.Name) {
+			logger.Infof("skipping osd provisioning on node %q that is in maintenance", n.Name)
+			continue
+		}
+
+		if err := validateDeviceFilters(n.Selection); err != nil {
+			config.addError("skipping osd provisioning on node %q. %v", n.Name, err)
+			continue
+		}
+
+		devices, err := c.resolveDeviceFullPaths(n.Name, n.Devices)
+		if err != nil {
+			config.addError("skipping osd provisioning on node %q. %v", n.Name, err)
+			continue
+		}
+
 		// update the orchestration status of this node to the starting state
 		status := OrchestrationStatus{Status: OrchestrationStatusStarting}
 		if err := c.updateOSDStatus(n.Name, status); err != nil {
@@ -388,7 +443,7 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 		metadataDevice := osdconfig.MetadataDevice(n.Config)
 		osdProps := osdProperties{
 			crushHostname:  n.Name,
-			devices:        n.Devices,
+			devices:        devices,
 			selection:      n.Selection,
 			resources:      n.Resources,
 			storeConfig:    storeConfig,
@@ -405,7 +460,7 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 			}
 		}
 
-		if !c.runJob(job, n.Name, config, "provision") {
+		if !c.runProvisionJob(job, n.Name, config) {
 			status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: fmt.Sprintf("failed to start osd provisioning on node %s", n.Name)}
 			if err := c.updateOSDStatus(n.Name, status); err != nil {
 				config.addError("failed to update node %q status. %v", n.Name, err)
@@ -416,6 +471,78 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 	c.completeProvision(config)
 }
 
+// resolveDeviceFullPaths sets the name of the devices given by a persistent path, such as a
+// /dev/disk/by-path link of a disk slot, to the disk the path currently links to on the node. A disk
+// replaced in the same slot is found under the same path, so its osd is prepared without a change to the
+// cluster CR. Devices whose path is not found on the node are skipped.
+func (c *Cluster) resolveDeviceFullPaths(nodeName string, devices []rookalpha.Device) ([]rookalpha.Device, error) {
+	needed := false
+	for _, device := range devices {
+		if device.FullPath != "" {
+			needed = true
+		}
+	}
+	if !needed {
+		return devices, nil
+	}
+
+	allDevices, err := discover.ListDevices(c.context, os.Getenv(k8sutil.PodNamespaceEnvVar), nodeName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the devices discovered on node %q", nodeName)
+	}
+
+	resolved := []rookalpha.Device{}
+	for _, device := range devices {
+		if device.FullPath == "" {
+			resolved = append(resolved, device)
+			continue
+		}
+		// the devices are only listed for the node, which is keyed by its kubernetes node name
+		name := ""
+		for _, disks := range allDevices {
+			for _, disk := range disks {
+				if matchDevLinks(disk.DevLinks, device.FullPath) {
+					name = disk.Name
+				}
+			}
+		}
+		if name == "" {
+			logger.Warningf("device %q was not found on node %q", device.FullPath, nodeName)
+			continue
+		}
+		logger.Debugf("device %q is %q on node %q", device.FullPath, name, nodeName)
+		device.Name = name
+		resolved = append(resolved, device)
+	}
+	return resolved, nil
+}
+
+// matchDevLinks returns whether the path is one of the space-separated links of a device
+func matchDevLinks(devLinks, fullPath string) bool {
+	for _, link := range strings.Fields(devLinks) {
+		if link == fullPath {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDeviceFilters checks that the device filters of the selection are valid regular expressions so an
+// invalid filter is reported before the osds are prepared
+func validateDeviceFilters(selection rookalpha.Selection) error {
+	if selection.DeviceFilter != "" {
+		if _, err := regexp.Compile(selection.DeviceFilter); err != nil {
+			return errors.Wrapf(err, "invalid deviceFilter %q", selection.DeviceFilter)
+		}
+	}
+	if selection.DevicePathFilter != "" {
+		if _, err := regexp.Compile(selection.DevicePathFilter); err != nil {
+			return errors.Wrapf(err, "invalid devicePathFilter %q", selection.DevicePathFilter)
+		}
+	}
+	return nil
+}
+
 func (c *Cluster) runJob(job *batch.Job, nodeName string, config *provisionConfig, action string) bool {
 	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, false); err != nil {
 		if !kerrors.IsAlreadyExists(err) {
@@ -443,9 +570,20 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		return
 	}
 
+	purgedOSDs, err := getPurgedOSDs(c.kv)
+	if err != nil {
+		config.addError("failed to get the purged osds to start the osds on pvc %q. %v", pvcName, err)
+		return
+	}
+
+	var newOSDs []int
 	// start osds
 	for _, osd := range osds {
 		logger.Debugf("start osd %v", osd)
+		if purgedOSDs[osd.UUID] {
+			logger.Warningf("skipping osd %d (uuid %q) on pvc %q that was removed from the cluster. the device must be wiped to be used again", osd.ID, osd.UUID, pvcName)
+			continue
+		}
 
 		// keyring must be generated before deployment creation in order to avoid a race condition resulting
 		// in intermittent failure of first-attempt OSD pods.
@@ -456,6 +594,12 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 			continue
 		}
 
+		// the dm-crypt key must be in the mons before the osd is activated
+		if err := c.ensureEncryptionKey(osd); err != nil {
+			config.addError("failed to save the encryption key for pvc %q, osd %d. %v", osdProps.crushHostname, osd.ID, err)
+			continue
+		}
+
 		dp, err := c.makeDeployment(osdProps, osd, config)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to create deployment for pvc %q: %v", osdProps.crushHostname, err)
@@ -484,29 +628,14 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		}
 
 		if createErr != nil && kerrors.IsAlreadyExists(createErr) {
-			// Always invoke ceph version before an upgrade so we are sure to be up-to-date
-			daemon := string(opconfig.OsdType)
-			var cephVersionToUse cephver.CephVersion
-
-			// If this is not a Ceph upgrade there is no need to check the ceph version
-			if c.isUpgrade {
-				currentCephVersion, err := client.LeastUptodateDaemonVersion(c.context, c.clusterInfo.Name, daemon)
-				if err != nil {
-					logger.Warningf("failed to retrieve current ceph %q version. %v", daemon, err)
-					logger.Debug("could not detect ceph version during update, this is likely an initial bootstrap, proceeding with c.clusterInfo.CephVersion")
-					cephVersionToUse = c.clusterInfo.CephVersion
-				} else {
-					logger.Debugf("current cluster version for osds before upgrading is: %+v", currentCephVersion)
-					cephVersionToUse = currentCephVersion
-				}
-			}
-
-			if err = updateDeploymentAndWait(c.context, dp, c.Namespace, daemon, strconv.Itoa(osd.ID), cephVersionToUse, c.isUpgrade, c.skipUpgradeChecks); err != nil {
-				logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
-			}
+			// the existing osds are restarted one failure domain at a time after all the osds are started
+			c.queueOSDUpdate(config, osd.ID, createdDeployment, dp)
+		} else {
+			newOSDs = append(newOSDs, osd.ID)
 		}
 		logger.Infof("started deployment for osd %d (dir=%t, type=%s)", osd.ID, osd.IsDirectory, storeConfig.StoreType)
 	}
+	c.addWeightInOSDs(newOSDs)
 }
 
 func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig, configMap *v1.ConfigMap, status *OrchestrationStatus) {
@@ -520,6 +649,11 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		logger.Errorf("node %q did not resolve to start osds", nodeName)
 		return
 	}
+	if c.nodeInMaintenance(n.Name) {
+		// the osds are started again when the maintenance of the node is done
+		logger.Infof("not starting the osds on node %q that is in maintenance", n.Name)
+		return
+	}
 	storeConfig := osdconfig.ToStoreConfig(n.Config)
 	metadataDevice := osdconfig.MetadataDevice(n.Config)
 
@@ -532,9 +666,31 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		metadataDevice: metadataDevice,
 	}
 
+	// the osds found on the devices of the node are checked against the osd map since they are adopted again
+	// after the os of the node is reinstalled
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo.Name)
+	if err != nil {
+		logger.Warningf("failed to get the osd map to check the osds of node %q. %v", nodeName, err)
+		osdDump = nil
+	}
+	purgedOSDs, err := getPurgedOSDs(c.kv)
+	if err != nil {
+		config.addError("failed to get the purged osds to start the osds on node %q. %v", n.Name, err)
+		return
+	}
+	var newOSDs []int
+
 	// start osds
 	for _, osd := range osds {
 		logger.Debugf("start osd %v", osd)
+		if !osdInOSDMap(osdDump, osd) {
+			logger.Warningf("skipping osd %d (uuid %q) on node %q that is not in the osd map. the osd was removed from the cluster", osd.ID, osd.UUID, n.Name)
+			continue
+		}
+		if purgedOSDs[osd.UUID] {
+			logger.Warningf("skipping osd %d (uuid %q) on node %q that was removed from the cluster. the device must be wiped to be used again", osd.ID, osd.UUID, n.Name)
+			continue
+		}
 
 		// keyring must be generated before deployment creation in order to avoid a race condition resulting
 		// in intermittent failure of first-attempt OSD pods.
@@ -545,6 +701,12 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 			continue
 		}
 
+		// the dm-crypt key must be in the mons before the osd is activated
+		if err := c.ensureEncryptionKey(osd); err != nil {
+			config.addError("failed to save the encryption key for node %q, osd %d. %v", n.Name, osd.ID, err)
+			continue
+		}
+
 		dp, err := c.makeDeployment(osdProps, osd, config)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to create deployment for node %s: %v", n.Name, err)
@@ -573,29 +735,38 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		}
 
 		if createErr != nil && kerrors.IsAlreadyExists(createErr) {
-			// Always invoke ceph version before an upgrade so we are sure to be up-to-date
-			daemon := string(opconfig.OsdType)
-			var cephVersionToUse cephver.CephVersion
+			// the existing osds are restarted one failure domain at a time after all the osds are started
+			c.queueOSDUpdate(config, osd.ID, createdDeployment, dp)
+		} else {
+			newOSDs = append(newOSDs, osd.ID)
+		}
+		logger.Infof("started deployment for osd %d (dir=%t, type=%s)", osd.ID, osd.IsDirectory, storeConfig.StoreType)
+	}
+	c.addWeightInOSDs(newOSDs)
+}
 
-			// If this is not a Ceph upgrade there is no need to check the ceph version
-			if c.isUpgrade {
-				currentCephVersion, err := client.LeastUptodateDaemonVersion(c.context, c.clusterInfo.Name, daemon)
-				if err != nil {
-					logger.Warningf("failed to retrieve current ceph %q version. %v", daemon, err)
-					logger.Debug("could not detect ceph version during update, this is likely an initial bootstrap, proceeding with c.clusterInfo.CephVersion")
-					cephVersionToUse = c.clusterInfo.CephVersion
-				} else {
-					logger.Debugf("current cluster version for osds before upgrading is: %+v", currentCephVersion)
-					cephVersionToUse = currentCephVersion
-				}
-			}
+// addWeightInOSDs records the new osds to weigh them in if the weight-in is enabled
+func (c *Cluster) addWeightInOSDs(osdIDs []int) {
+	if !c.WeightInNewOSDs {
+		return
+	}
+	if err := addWeightInOSDs(c.context, c.Namespace, osdIDs); err != nil {
+		logger.Warningf("failed to record the new osds %v to weigh them in. %v", osdIDs, err)
+	}
+}
 
-			if err = updateDeploymentAndWait(c.context, dp, c.Namespace, daemon, strconv.Itoa(osd.ID), cephVersionToUse, c.isUpgrade, c.skipUpgradeChecks); err != nil {
-				logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
-			}
+// osdInOSDMap checks that an osd found on the devices of a node is still in the osd map with the same uuid. An osd
+// that was removed from the cluster while its node was reinstalled must not be started again.
+func osdInOSDMap(osdDump *client.OSDDump, osd OSDInfo) bool {
+	if osdDump == nil || !osd.CephVolumeInitiated || osd.UUID == "" {
+		return true
+	}
+	for _, mapOSD := range osdDump.OSDs {
+		if mapOSD.OSD.String() == strconv.Itoa(osd.ID) {
+			return mapOSD.UUID == "" || mapOSD.UUID == osd.UUID
 		}
-		logger.Infof("started deployment for osd %d (dir=%t, type=%s)", osd.ID, osd.IsDirectory, storeConfig.StoreType)
 	}
+	return false
 }
 
 // discover nodes which currently have osds scheduled on them. Return a mapping of
@@ -696,6 +867,20 @@ func (c *Cluster) getPVCHostName(pvcName string) (string, error) {
 	return "", err
 }
 
+// validateCephVolumeMode checks the ceph-volume mode of the config of a device set
+func (c *Cluster) validateCephVolumeMode(mode string) error {
+	switch mode {
+	case "", osdconfig.CephVolumeLVMMode:
+		return nil
+	case osdconfig.CephVolumeRawMode:
+		if !c.clusterInfo.CephVersion.IsAtLeast(cephVolumeRawModeMinVersion) {
+			return errors.Errorf("the raw mode of ceph-volume requires ceph %s or newer", cephVolumeRawModeMinVersion.String())
+		}
+		return nil
+	}
+	return errors.Errorf("invalid ceph-volume mode %q. it must be %q or %q", mode, osdconfig.CephVolumeLVMMode, osdconfig.CephVolumeRawMode)
+}
+
 func getOSDInfo(d *apps.Deployment) ([]OSDInfo, error) {
 	container := d.Spec.Template.Spec.Containers[0]
 	var osd OSDInfo
@@ -713,6 +898,12 @@ func getOSDInfo(d *apps.Deployment) ([]OSDInfo, error) {
 		if envVar.Name == "ROOK_LV_PATH" {
 			osd.LVPath = envVar.Value
 		}
+		if envVar.Name == cvModeVarName {
+			osd.CVMode = envVar.Value
+		}
+		if envVar.Name == blockPathVarName {
+			osd.BlockPath = envVar.Value
+		}
 	}
 
 	for i, a := range container.Args {
@@ -731,7 +922,11 @@ func getOSDInfo(d *apps.Deployment) ([]OSDInfo, error) {
 
 	osd.CephVolumeInitiated = true
 
-	if osd.DataPath == "" || osd.UUID == "" || osd.LVPath == "" {
+	devicePath := osd.LVPath
+	if osd.CVMode == osdconfig.CephVolumeRawMode {
+		devicePath = osd.BlockPath
+	}
+	if osd.DataPath == "" || osd.UUID == "" || devicePath == "" {
 		return []OSDInfo{}, errors.Errorf("failed to get required osdInfo. %+v", osd)
 	}
 
@@ -16,6 +16,7 @@ limitations under the License.
 package osd
 
 import (
+	"encoding/json"
 	"os"
 	"testing"
 
@@ -23,6 +24,7 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
@@ -48,7 +50,7 @@ func TestStart(t *testing.T) {
 		CephVersion: cephver.Nautilus,
 	}
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	// Start the first time
 	err := c.Start()
@@ -133,7 +135,7 @@ func TestAddRemoveNode(t *testing.T) {
 	}
 
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: executor}, "ns-add-remove", "myversion", cephv1.CephVersionSpec{},
-		storageSpec, "/foo", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		storageSpec, "/foo", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	// kick off the start of the orchestration in a goroutine
 	var startErr error
@@ -218,7 +220,7 @@ func TestAddRemoveNode(t *testing.T) {
 	// modify the storage spec to remove the node from the cluster
 	storageSpec.Nodes = []rookalpha.Node{}
 	c = New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: mockExec}, "ns-add-remove", "myversion", cephv1.CephVersionSpec{},
-		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	// reset the orchestration status watcher
 	statusMapWatcher = watch.NewFake()
@@ -270,7 +272,7 @@ func TestDiscoverOSDs(t *testing.T) {
 		Executor: executor,
 	}
 	c := New(clusterInfo, context, "ns", "myversion", cephv1.CephVersionSpec{},
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 	node1 := "n1"
 	node2 := "n2"
 
@@ -361,7 +363,7 @@ func TestAddNodeFailure(t *testing.T) {
 		CephVersion: cephver.Nautilus,
 	}
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns-add-remove", "myversion", cephv1.CephVersionSpec{},
-		storageSpec, "/foo", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		storageSpec, "/foo", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	// kick off the start of the orchestration in a goroutine
 	var startErr error
@@ -381,8 +383,8 @@ func TestAddNodeFailure(t *testing.T) {
 
 func TestGetOSDInfo(t *testing.T) {
 	c := New(&cephconfig.ClusterInfo{}, &clusterd.Context{}, "ns", "myversion", cephv1.CephVersionSpec{},
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{},
-		v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{},
+		v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	node := "n1"
 	location := "root=default host=myhost zone=myzone"
@@ -409,4 +411,72 @@ func TestGetOSDInfo(t *testing.T) {
 	osds2, err := getOSDInfo(d2)
 	assert.Equal(t, 0, len(osds2))
 	assert.NotNil(t, err)
+
+	// an osd in raw mode has a block device instead of a logical volume
+	osd3 := OSDInfo{ID: 4, UUID: "osd-uuid", DataPath: "/rook/path", CephVolumeInitiated: true, CVMode: config.CephVolumeRawMode, BlockPath: "/mnt/pvc"}
+	d3, _ := c.makeDeployment(osdProp, osd3, dataPathMap)
+	osds3, err := getOSDInfo(d3)
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(osds3))
+	assert.Equal(t, config.CephVolumeRawMode, osds3[0].CVMode)
+	assert.Equal(t, "/mnt/pvc", osds3[0].BlockPath)
+}
+
+func TestValidateCephVolumeMode(t *testing.T) {
+	c := &Cluster{clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 7}}}
+	assert.NoError(t, c.validateCephVolumeMode(""))
+	assert.NoError(t, c.validateCephVolumeMode(config.CephVolumeLVMMode))
+	assert.Error(t, c.validateCephVolumeMode("other"))
+
+	// the raw mode requires a recent version of nautilus
+	assert.Error(t, c.validateCephVolumeMode(config.CephVolumeRawMode))
+	c.clusterInfo.CephVersion = cephver.CephVersion{Major: 14, Minor: 2, Extra: 8}
+	assert.NoError(t, c.validateCephVolumeMode(config.CephVolumeRawMode))
+}
+
+func TestOSDInOSDMap(t *testing.T) {
+	var osdDump client.OSDDump
+	require.NoError(t, json.Unmarshal([]byte(`{"osds":[{"osd":1,"uuid":"uuid-1","up":0,"in":1},{"osd":2,"uuid":"uuid-2","up":1,"in":1}]}`), &osdDump))
+
+	// an adopted osd is started when it is still in the osd map with the same uuid
+	assert.True(t, osdInOSDMap(&osdDump, OSDInfo{ID: 1, UUID: "uuid-1", CephVolumeInitiated: true}))
+	// the osd was removed, or its id was reused by another osd
+	assert.False(t, osdInOSDMap(&osdDump, OSDInfo{ID: 3, UUID: "uuid-3", CephVolumeInitiated: true}))
+	assert.False(t, osdInOSDMap(&osdDump, OSDInfo{ID: 2, UUID: "uuid-1", CephVolumeInitiated: true}))
+
+	// the osds are not checked without the osd map or without the uuid of ceph-volume
+	assert.True(t, osdInOSDMap(nil, OSDInfo{ID: 3, UUID: "uuid-3", CephVolumeInitiated: true}))
+	assert.True(t, osdInOSDMap(&osdDump, OSDInfo{ID: 3, IsDirectory: true}))
+}
+
+func TestValidateDeviceFilters(t *testing.T) {
+	assert.NoError(t, validateDeviceFilters(rookalpha.Selection{}))
+	assert.NoError(t, validateDeviceFilters(rookalpha.Selection{DeviceFilter: "^sd[a-d]"}))
+	assert.NoError(t, validateDeviceFilters(rookalpha.Selection{DevicePathFilter: "^/dev/disk/by-path/pci-.*"}))
+
+	assert.Error(t, validateDeviceFilters(rookalpha.Selection{DeviceFilter: "^sd[a-d"}))
+	assert.Error(t, validateDeviceFilters(rookalpha.Selection{DevicePathFilter: "^/dev/disk/by-path/(pci-.*"}))
+}
+
+func TestResolveDeviceFullPaths(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-system")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	require.NoError(t, createDiscoverConfigmap("node1", "rook-system", clientset))
+	clusterInfo := &cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+
+	// the devices given by name are not changed
+	devices := []rookalpha.Device{{Name: "sdy"}}
+	resolved, err := c.resolveDeviceFullPaths("node1", devices)
+	assert.NoError(t, err)
+	assert.Equal(t, devices, resolved)
+
+	// the path of the disk slot is resolved to the disk currently in the slot
+	slot := "/dev/disk/by-path/ip-127.0.0.1:3260-iscsi-iqn.2016-06.world.srv:storage.target01-lun-1"
+	devices = []rookalpha.Device{{Name: "sdy"}, {FullPath: slot, Config: map[string]string{"deviceClass": "ssd"}}, {FullPath: "/dev/disk/by-path/missing"}}
+	resolved, err = c.resolveDeviceFullPaths("node1", devices)
+	assert.NoError(t, err)
+	assert.Equal(t, []rookalpha.Device{{Name: "sdy"}, {Name: "sdx", FullPath: slot, Config: map[string]string{"deviceClass": "ssd"}}}, resolved)
 }
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+type fullRatio struct {
+	name    string
+	current float64
+	desired float64
+}
+
+// fullRatiosToSet returns the full ratios of the spec that differ from the ratios of the osd map. A ratio that is
+// not in the spec keeps its current value, which must still be in order with the ratios of the spec.
+func fullRatiosToSet(spec *cephv1.FullRatiosSpec, osdDump *client.OSDDump) ([]fullRatio, error) {
+	ratios := []fullRatio{
+		{name: "nearfull", current: osdDump.NearFullRatio},
+		{name: "backfillfull", current: osdDump.BackfillFullRatio},
+		{name: "full", current: osdDump.FullRatio},
+	}
+	for i, r := range []*float64{spec.NearFull, spec.BackfillFull, spec.Full} {
+		ratios[i].desired = ratios[i].current
+		if r == nil {
+			continue
+		}
+		if *r <= 0 || *r > 1 {
+			return nil, errors.Errorf("invalid %s ratio %v. it must be greater than 0 and at most 1", ratios[i].name, *r)
+		}
+		ratios[i].desired = *r
+	}
+
+	// the osds must be nearfull before they are backfillfull, and backfillfull before they are full
+	for i := 1; i < len(ratios); i++ {
+		if ratios[i-1].desired >= ratios[i].desired {
+			return nil, errors.Errorf("invalid full ratios. the %s ratio %v must be lower than the %s ratio %v",
+				ratios[i-1].name, ratios[i-1].desired, ratios[i].name, ratios[i].desired)
+		}
+	}
+
+	toSet := []fullRatio{}
+	for _, r := range ratios {
+		// ceph keeps the ratios as floats
+		if float32(r.desired) != float32(r.current) {
+			toSet = append(toSet, r)
+		}
+	}
+	return toSet, nil
+}
+
+// SetFullRatios sets the full ratios of the spec in the osd map of the cluster
+func SetFullRatios(context *clusterd.Context, clusterName string, spec *cephv1.FullRatiosSpec) error {
+	if spec == nil {
+		return nil
+	}
+	osdDump, err := client.GetOSDDump(context, clusterName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the current full ratios")
+	}
+	ratios, err := fullRatiosToSet(spec, osdDump)
+	if err != nil {
+		return err
+	}
+	for _, r := range ratios {
+		logger.Infof("setting the %s ratio to %v", r.name, r.desired)
+		if err := client.SetRatio(context, clusterName, r.name, r.desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
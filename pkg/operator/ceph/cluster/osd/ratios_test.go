@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetFullRatios(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "osd" && args[1] == "dump":
+			return `{"osds":[],"full_ratio":0.949999988079071,"backfillfull_ratio":0.8999999761581421,"nearfull_ratio":0.8500000238418579}`, nil
+		case args[0] == "osd" && strings.HasSuffix(args[1], "-ratio"):
+			commands = append(commands, strings.Join(args[:3], " "))
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	ratio := func(r float64) *float64 { return &r }
+
+	// nothing is set without ratios
+	assert.NoError(t, SetFullRatios(context, "ns", nil))
+	assert.NoError(t, SetFullRatios(context, "ns", &cephv1.FullRatiosSpec{}))
+	assert.Empty(t, commands)
+
+	// the ratios of the osd map are not set again
+	assert.NoError(t, SetFullRatios(context, "ns", &cephv1.FullRatiosSpec{Full: ratio(0.95), NearFull: ratio(0.85)}))
+	assert.Empty(t, commands)
+
+	// only the changed ratios are set
+	assert.NoError(t, SetFullRatios(context, "ns", &cephv1.FullRatiosSpec{Full: ratio(0.97), BackfillFull: ratio(0.92), NearFull: ratio(0.85)}))
+	assert.Equal(t, []string{"osd set-backfillfull-ratio 0.92", "osd set-full-ratio 0.97"}, commands)
+
+	// the ratios must be in order, including the current ratios that are not in the spec
+	commands = nil
+	for _, invalid := range []cephv1.FullRatiosSpec{
+		{Full: ratio(1.1)},
+		{NearFull: ratio(0)},
+		{NearFull: ratio(0.9)},
+		{BackfillFull: ratio(0.95)},
+		{Full: ratio(0.8), BackfillFull: ratio(0.7)},
+	} {
+		spec := invalid
+		assert.Error(t, SetFullRatios(context, "ns", &spec))
+	}
+	assert.Empty(t, commands)
+}
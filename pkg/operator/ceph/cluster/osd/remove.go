@@ -19,6 +19,8 @@ package osd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -34,11 +36,55 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func (c *Cluster) removeOSD(deploymentName string, id int) error {
-	// get a baseline for OSD usage so we can compare usage to it later on to know when migration has started
-	initialUsage, err := client.GetOSDUsage(c.context, c.Namespace)
+const (
+	// PurgeOSDsAnnotation is set on the cluster CR with the comma-separated IDs of the osds to remove from the cluster
+	PurgeOSDsAnnotation = "ceph.rook.io/purge-osds"
+	// ForcePurgeOSDsAnnotation is set to "true" on the cluster CR to purge the osds without waiting for their data
+	// to be rebalanced
+	ForcePurgeOSDsAnnotation = "ceph.rook.io/force-purge-osds"
+)
+
+// ParseOSDIDs parses the IDs of the osds of the purge annotation
+func ParseOSDIDs(value string) ([]int, error) {
+	var ids []int
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		id, err := strconv.Atoi(s)
+		if err != nil || id < 0 {
+			return nil, errors.Errorf("invalid osd id %q", s)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.Errorf("no osd id in %q", value)
+	}
+	return ids, nil
+}
+
+// PurgeOSD removes an osd from the cluster. The osd is marked out and, unless the purge is forced, its data is
+// rebalanced to the other osds before the osd is removed from the crush map, the auth and the osd map. The
+// deployment of the osd is removed, as well as the pvc and the prepare job of an osd on a pvc, so the device set
+// creates a new pvc for a replacement osd.
+func (c *Cluster) PurgeOSD(id int, force bool) error {
+	logger.Infof("purging osd.%d (force=%t)", id, force)
+	deployments, err := k8sutil.GetDeployments(c.context.Clientset, c.Namespace, fmt.Sprintf("%s=%d", OsdIdLabelKey, id))
 	if err != nil {
-		logger.Warningf("failed to get baseline OSD usage, but will still continue")
+		return errors.Wrapf(err, "failed to get the deployment of osd.%d", id)
+	}
+
+	// the usage of a down osd does not change while its data is rebalanced, so only the placement groups are checked
+	var initialUsage *client.OSDUsage
+	if up, err := isOSDUp(c.context, c.Namespace, id); err != nil {
+		logger.Warningf("failed to get the status of osd.%d. %v", id, err)
+	} else if up {
+		// get a baseline for OSD usage so we can compare usage to it later on to know when migration has started
+		initialUsage, err = client.GetOSDUsage(c.context, c.Namespace)
+		if err != nil {
+			logger.Warningf("failed to get baseline OSD usage, but will still continue")
+		}
 	}
 
 	// first reweight the OSD to be 0.0, which will begin the data migration
@@ -63,19 +109,34 @@ func (c *Cluster) removeOSD(deploymentName string, id int) error {
 		}
 
 		// wait for the OSDs data to be migrated
-		if err := waitForRebalance(c.context, c.Namespace, id, initialUsage, c.clusterInfo.CephVersion.IsAtLeastNautilus()); err != nil {
+		if force {
+			logger.Warningf("purging osd.%d without waiting for its data to be rebalanced", id)
+		} else if err := waitForRebalance(c.context, c.Namespace, id, initialUsage, c.clusterInfo.CephVersion.IsAtLeastNautilus()); err != nil {
 			return errors.Wrapf(err, "failed to wait for cluster rebalancing after removing osd.%d", id)
 		}
 	}
 
 	// data is migrated off the osd, we can delete the deployment now
-	if err := k8sutil.DeleteDeployment(c.context.Clientset, c.Namespace, deploymentName); err != nil {
-		return errors.Wrapf(err, "failed to delete deployment %s", deploymentName)
+	for _, d := range deployments.Items {
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, c.Namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete deployment %s", d.Name)
+		}
 	}
 
 	// purge the OSD from the cluster
-	if err := purgeOSD(c.context, c.Namespace, id); err != nil {
-		return errors.Wrapf(err, "failed to purge osd.%d from the cluster", id)
+	if !alreadyPurged {
+		if err := purgeOSD(c.context, c.Namespace, id); err != nil {
+			return errors.Wrapf(err, "failed to purge osd.%d from the cluster", id)
+		}
+	}
+
+	// the device of an osd on a pvc is released with the pvc
+	for _, d := range deployments.Items {
+		if pvcName := d.Labels[OSDOverPVCLabelKey]; pvcName != "" {
+			if err := c.deleteOSDPVC(pvcName); err != nil {
+				return err
+			}
+		}
 	}
 
 	// delete any backups of the OSD filesystem
@@ -83,6 +144,42 @@ func (c *Cluster) removeOSD(deploymentName string, id int) error {
 		logger.Warningf("failed to delete osd.%d filesystem, it may need to be cleaned up manually. %v", id, err)
 	}
 
+	// the dm-crypt key of the osd is not needed anymore
+	if err := c.deleteEncryptionKey(id); err != nil {
+		logger.Warningf("failed to delete the encryption key of osd.%d, it may need to be cleaned up manually. %v", id, err)
+	}
+
+	logger.Infof("purged osd.%d", id)
+	return nil
+}
+
+func isOSDUp(context *clusterd.Context, namespace string, id int) (bool, error) {
+	dump, err := client.GetOSDDump(context, namespace)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get the osd dump")
+	}
+	up, _, err := dump.StatusByID(int64(id))
+	if err != nil {
+		return false, err
+	}
+	return up == 1, nil
+}
+
+// deleteOSDPVC removes the prepare job and the pvc of an osd on a pvc
+func (c *Cluster) deleteOSDPVC(pvcName string) error {
+	jobName := k8sutil.TruncateNodeName(prepareAppNameFmt, pvcName)
+	if _, err := c.context.Clientset.BatchV1().Jobs(c.Namespace).Get(jobName, metav1.GetOptions{}); err == nil {
+		if err := k8sutil.DeleteBatchJob(c.context.Clientset, c.Namespace, jobName, false); err != nil {
+			return errors.Wrapf(err, "failed to delete the prepare job %q", jobName)
+		}
+	} else if !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get the prepare job %q", jobName)
+	}
+	err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(pvcName, &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the pvc %q", pvcName)
+	}
+	logger.Infof("deleted the pvc %q", pvcName)
 	return nil
 }
 
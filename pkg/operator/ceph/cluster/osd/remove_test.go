@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseOSDIDs(t *testing.T) {
+	ids, err := ParseOSDIDs("3")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3}, ids)
+
+	ids, err = ParseOSDIDs("0, 5,12")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 5, 12}, ids)
+
+	for _, value := range []string{"", " , ", "a", "1,b", "-1"} {
+		_, err = ParseOSDIDs(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestPurgeOSD(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		cmd := strings.Join(args[:3], " ")
+		switch {
+		case args[0] == "osd" && args[1] == "dump":
+			// osd.1 is down
+			return `{"osds":[{"osd":1,"up":0,"in":1}]}`, nil
+		case strings.HasPrefix(cmd, "pg dump"):
+			return `{"pg_stats":[]}`, nil
+		case args[0] == "status":
+			return `{"pgmap":{"num_pgs":0}}`, nil
+		case strings.HasPrefix(cmd, "osd crush reweight"), strings.HasPrefix(cmd, "osd out"), strings.HasPrefix(cmd, "osd crush rm"),
+			strings.HasPrefix(cmd, "auth del"), strings.HasPrefix(cmd, "osd rm"):
+			commands = append(commands, cmd)
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	clientset := test.New(1)
+	clusterInfo := &cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Nautilus}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, Executor: executor}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+
+	// the osd on a pvc
+	labels := map[string]string{OsdIdLabelKey: "1", OSDOverPVCLabelKey: "set1-0-data-abc"}
+	_, err := clientset.AppsV1().Deployments("ns").Create(&apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1", Namespace: "ns", Labels: labels}})
+	require.NoError(t, err)
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Create(&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "set1-0-data-abc", Namespace: "ns"}})
+	require.NoError(t, err)
+	_, err = clientset.BatchV1().Jobs("ns").Create(&batch.Job{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-prepare-set1-0-data-abc", Namespace: "ns"}})
+	require.NoError(t, err)
+	_, err = clientset.CoreV1().Secrets("ns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: encryptionKeySecretName(1), Namespace: "ns"}})
+	require.NoError(t, err)
+
+	// the data of the down osd is rebalanced before the osd is removed from the cluster
+	require.NoError(t, c.PurgeOSD(1, false))
+	assert.Equal(t, []string{"osd crush reweight", "osd out 1", "osd crush rm", "auth del osd.1", "osd rm 1"}, commands)
+	_, err = clientset.AppsV1().Deployments("ns").Get("rook-ceph-osd-1", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Get("set1-0-data-abc", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.BatchV1().Jobs("ns").Get("rook-ceph-osd-prepare-set1-0-data-abc", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.CoreV1().Secrets("ns").Get(encryptionKeySecretName(1), metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// an osd without a deployment is still purged from the cluster
+	commands = nil
+	require.NoError(t, c.PurgeOSD(2, true))
+	assert.Equal(t, []string{"osd crush reweight", "osd out 2", "osd crush rm", "auth del osd.2", "osd rm 2"}, commands)
+}
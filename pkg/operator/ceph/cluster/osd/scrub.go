@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+// scrubConfigOptions returns the scrub options of the osds that are set in the centralized mon config database
+// from the scrub spec of the cluster. The deep scrub interval is converted to the seconds expected by ceph.
+func scrubConfigOptions(spec *cephv1.ScrubSpec) ([]config.Option, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	options := []config.Option{}
+	for _, h := range []struct {
+		option string
+		name   string
+		hour   *int
+	}{
+		{"osd_scrub_begin_hour", "begin hour", spec.BeginHour},
+		{"osd_scrub_end_hour", "end hour", spec.EndHour},
+	} {
+		if h.hour == nil {
+			continue
+		}
+		if *h.hour < 0 || *h.hour > 23 {
+			return nil, errors.Errorf("invalid scrub %s %d. it must be between 0 and 23", h.name, *h.hour)
+		}
+		options = append(options, config.Option{Who: "osd", Option: h.option, Value: strconv.Itoa(*h.hour)})
+	}
+
+	if spec.MaxScrubs < 0 {
+		return nil, errors.Errorf("invalid max scrubs %d. it must be positive", spec.MaxScrubs)
+	}
+	if spec.MaxScrubs > 0 {
+		options = append(options, config.Option{Who: "osd", Option: "osd_max_scrubs", Value: strconv.Itoa(spec.MaxScrubs)})
+	}
+
+	if spec.DeepScrubInterval != "" {
+		d, err := time.ParseDuration(spec.DeepScrubInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid deep scrub interval %q", spec.DeepScrubInterval)
+		}
+		if d <= 0 {
+			return nil, errors.Errorf("invalid deep scrub interval %q. it must be positive", spec.DeepScrubInterval)
+		}
+		options = append(options, config.Option{Who: "osd", Option: "osd_deep_scrub_interval", Value: strconv.FormatFloat(d.Seconds(), 'f', -1, 64)})
+	}
+	return options, nil
+}
+
+// SetScrubConfigOptions sets the options of the scrub spec in the centralized mon config database. An option that
+// is removed from the spec keeps its last value in the database.
+func SetScrubConfigOptions(context *clusterd.Context, namespace string, spec *cephv1.ScrubSpec) error {
+	options, err := scrubConfigOptions(spec)
+	if err != nil || len(options) == 0 {
+		return err
+	}
+	if err := config.GetMonStore(context, namespace).SetAll(options...); err != nil {
+		return errors.Wrapf(err, "failed to set the scrub config options")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetScrubConfigOptions(t *testing.T) {
+	configSet := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "config" && args[1] == "set" {
+				configSet = append(configSet, strings.Join(args[2:5], " "))
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: test.New(1), Executor: executor}
+
+	// no options are set by default
+	assert.NoError(t, SetScrubConfigOptions(context, "ns", nil))
+	assert.NoError(t, SetScrubConfigOptions(context, "ns", &cephv1.ScrubSpec{}))
+	assert.Empty(t, configSet)
+
+	// scrubbing is confined to the night and the interval is set in seconds
+	begin, end := 22, 0
+	spec := &cephv1.ScrubSpec{BeginHour: &begin, EndHour: &end, MaxScrubs: 2, DeepScrubInterval: "336h"}
+	assert.NoError(t, SetScrubConfigOptions(context, "ns", spec))
+	assert.Equal(t, []string{"osd osd_scrub_begin_hour 22", "osd osd_scrub_end_hour 0", "osd osd_max_scrubs 2",
+		"osd osd_deep_scrub_interval 1209600"}, configSet)
+
+	// invalid settings
+	hour := 24
+	for _, invalid := range []*cephv1.ScrubSpec{{BeginHour: &hour}, {EndHour: &hour}, {MaxScrubs: -1}, {DeepScrubInterval: "7d"}, {DeepScrubInterval: "0s"}} {
+		_, err := scrubConfigOptions(invalid)
+		assert.Error(t, err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
 	opmon "github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
@@ -37,28 +38,37 @@ import (
 	apps "k8s.io/api/apps/v1"
 	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	dataDirsEnvVarName                  = "ROOK_DATA_DIRECTORIES"
-	osdStoreEnvVarName                  = "ROOK_OSD_STORE"
-	osdDatabaseSizeEnvVarName           = "ROOK_OSD_DATABASE_SIZE"
-	osdWalSizeEnvVarName                = "ROOK_OSD_WAL_SIZE"
-	osdJournalSizeEnvVarName            = "ROOK_OSD_JOURNAL_SIZE"
-	osdsPerDeviceEnvVarName             = "ROOK_OSDS_PER_DEVICE"
-	encryptedDeviceEnvVarName           = "ROOK_ENCRYPTED_DEVICE"
-	osdMetadataDeviceEnvVarName         = "ROOK_METADATA_DEVICE"
-	pvcBackedOSDVarName                 = "ROOK_PVC_BACKED_OSD"
-	lvPathVarName                       = "ROOK_LV_PATH"
-	lvBackedPVVarName                   = "ROOK_LV_BACKED_PV"
-	rookBinariesMountPath               = "/rook"
-	rookBinariesVolumeName              = "rook-binaries"
-	activateOSDVolumeName               = "activate-osd"
-	activateOSDMountPath                = "/var/lib/ceph/osd/ceph-"
-	blockPVCMapperInitContainer         = "blkdevmapper"
-	osdMemoryTargetSafetyFactor float32 = 0.8
+	dataDirsEnvVarName                       = "ROOK_DATA_DIRECTORIES"
+	osdStoreEnvVarName                       = "ROOK_OSD_STORE"
+	osdDatabaseSizeEnvVarName                = "ROOK_OSD_DATABASE_SIZE"
+	osdWalSizeEnvVarName                     = "ROOK_OSD_WAL_SIZE"
+	osdJournalSizeEnvVarName                 = "ROOK_OSD_JOURNAL_SIZE"
+	osdsPerDeviceEnvVarName                  = "ROOK_OSDS_PER_DEVICE"
+	encryptedDeviceEnvVarName                = "ROOK_ENCRYPTED_DEVICE"
+	crushDeviceClassEnvVarName               = "ROOK_OSD_CRUSH_DEVICE_CLASS"
+	testDeviceSizeEnvVarName                 = "ROOK_TEST_DEVICE_SIZE"
+	compressionModeEnvVarName                = "ROOK_OSD_COMPRESSION_MODE"
+	compressionAlgorithmEnvVarName           = "ROOK_OSD_COMPRESSION_ALGORITHM"
+	compressionMinBlobSizeEnvVarName         = "ROOK_OSD_COMPRESSION_MIN_BLOB_SIZE"
+	osdMetadataDeviceEnvVarName              = "ROOK_METADATA_DEVICE"
+	pvcBackedOSDVarName                      = "ROOK_PVC_BACKED_OSD"
+	lvPathVarName                            = "ROOK_LV_PATH"
+	lvBackedPVVarName                        = "ROOK_LV_BACKED_PV"
+	cvModeVarName                            = "ROOK_CV_MODE"
+	blockPathVarName                         = "ROOK_BLOCK_PATH"
+	cephVolumeModeEnvVarName                 = "ROOK_CEPH_VOLUME_MODE"
+	rookBinariesMountPath                    = "/rook"
+	rookBinariesVolumeName                   = "rook-binaries"
+	activateOSDVolumeName                    = "activate-osd"
+	activateOSDMountPath                     = "/var/lib/ceph/osd/ceph-"
+	blockPVCMapperInitContainer              = "blkdevmapper"
+	osdMemoryTargetSafetyFactor      float32 = 0.8
+	osdLivenessProbeInitialDelay             = 45
+	osdLivenessProbeTimeout                  = 5
 	// CephDeviceSetLabelKey is the Rook device set label key
 	CephDeviceSetLabelKey = "ceph.rook.io/DeviceSet"
 	// CephSetIndexLabelKey is the Rook label key index
@@ -76,9 +86,18 @@ set -ex
 OSD_ID=%s
 OSD_UUID=%s
 OSD_STORE_FLAG="%s"
+TEST_DEVICE_FILE=%s
 TMP_DIR=$(mktemp -d)
 OSD_DATA_DIR=/var/lib/ceph/osd/ceph-"$OSD_ID"
 
+# the loop device of the test mode is detached when the host restarts
+if [ -f "$TEST_DEVICE_FILE" ] && [ -z "$(losetup --noheadings --output NAME --associated "$TEST_DEVICE_FILE")" ]; then
+  losetup --find "$TEST_DEVICE_FILE"
+fi
+
+# the volumes are not active when the osd is adopted again after the os of the node was reinstalled
+lvchange --activate y "@ceph.osd_fsid=$OSD_UUID"
+
 # active the osd with ceph-volume
 ceph-volume lvm activate --no-systemd "$OSD_STORE_FLAG" "$OSD_ID" "$OSD_UUID"
 
@@ -101,6 +120,9 @@ rm --recursive --force "$TMP_DIR"
 `
 )
 
+// TestDeviceFileName is the sparse file in the data dir of the host that backs the loop device of the test mode
+const TestDeviceFileName = "osd-test-device.img"
+
 // OSDs on PVC using a certain storage class need to do some tuning
 const (
 	osdRecoverySleep = "0.1"
@@ -254,11 +276,11 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		}
 	}
 
-	// Set osd memory target to the best appropriate value
+	// Set osd memory target below the memory limit of the osd pod. Ceph auto-tunes the caches of bluestore to keep
+	// the memory of the osd under its target, which is 4GiB by default and would get the osd killed by a lower limit.
 	if !osd.IsFileStore {
-		// As of Nautilus Ceph auto-tunes its osd_memory_target on the fly so we don't need to force it
-		if !c.clusterInfo.CephVersion.IsAtLeastNautilus() && !c.resources.Limits.Memory().IsZero() {
-			osdMemoryTargetValue := float32(c.resources.Limits.Memory().Value()) * osdMemoryTargetSafetyFactor
+		if memoryLimit := osdProps.resources.Limits.Memory(); !memoryLimit.IsZero() {
+			osdMemoryTargetValue := float32(memoryLimit.Value()) * osdMemoryTargetSafetyFactor
 			commonArgs = append(commonArgs, fmt.Sprintf("--osd-memory-target=%d", int(osdMemoryTargetValue)))
 		}
 	}
@@ -411,6 +433,10 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		envVars = append(envVars, pvcBackedOSDEnvVar("true"))
 		envVars = append(envVars, lvPathEnvVariable(osd.LVPath))
 		envVars = append(envVars, lvBackedPVEnvVar(strconv.FormatBool(osd.LVBackedPV)))
+		if osd.CVMode == config.CephVolumeRawMode {
+			envVars = append(envVars, v1.EnvVar{Name: cvModeVarName, Value: osd.CVMode})
+			envVars = append(envVars, v1.EnvVar{Name: blockPathVarName, Value: osd.BlockPath})
+		}
 	}
 
 	privileged := true
@@ -422,8 +448,9 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
 	}
 
-	// needed for luksOpen synchronization when devices are encrypted
-	hostIPC := osdProps.storeConfig.EncryptedDevice
+	// needed for luksOpen synchronization when devices are encrypted. the osd might have been encrypted
+	// with a previous config of the node, so the osd info is also checked
+	hostIPC := osdProps.storeConfig.EncryptedDevice || osd.Encrypted
 
 	DNSPolicy := v1.DNSClusterFirst
 	if c.Network.IsHost() {
@@ -508,6 +535,7 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 							Env:             envVars,
 							Resources:       osdProps.resources,
 							SecurityContext: securityContext,
+							LivenessProbe:   makeOSDLivenessProbe(osdID),
 						},
 					},
 					Volumes: volumes,
@@ -540,10 +568,26 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	} else {
 		osdProps.placement.ApplyToPodSpec(&deployment.Spec.Template.Spec)
 	}
+	k8sutil.ApplySpecHash(deployment)
 
 	return deployment, nil
 }
 
+// makeOSDLivenessProbe checks the status of the osd on its admin socket, so an osd that is hung is restarted
+func makeOSDLivenessProbe(osdID string) *v1.Probe {
+	socket := fmt.Sprintf("/var/run/ceph/ceph-osd.%s.asok", osdID)
+	return &v1.Probe{
+		Handler: v1.Handler{
+			Exec: &v1.ExecAction{
+				// the env of the osd is cleared so the ceph cli only talks to the admin socket
+				Command: []string{"env", "-i", "sh", "-c", fmt.Sprintf("ceph --admin-daemon %s status", socket)},
+			},
+		},
+		InitialDelaySeconds: osdLivenessProbeInitialDelay,
+		TimeoutSeconds:      osdLivenessProbeTimeout,
+	}
+}
+
 // To get rook inside the container, the config init container needs to copy "tini" and "rook" binaries into a volume.
 // Get the config flag so rook will copy the binaries and create the volume and mount that will be shared between
 // the init container and the daemon container
@@ -579,6 +623,8 @@ func (c *Cluster) getActivateOSDInitContainer(osdID, osdUUID string, isFilestore
 		{Name: activateOSDVolumeName, MountPath: activateOSDMountPathID},
 		{Name: "devices", MountPath: "/dev"},
 		{Name: k8sutil.ConfigOverrideName, ReadOnly: true, MountPath: opconfig.EtcCephDir},
+		// the sparse file of the test mode is in the data dir of the host
+		{Name: k8sutil.DataDirVolume, MountPath: k8sutil.DataDir},
 	}
 
 	privileged := true
@@ -590,7 +636,7 @@ func (c *Cluster) getActivateOSDInitContainer(osdID, osdUUID string, isFilestore
 		Command: []string{
 			"/bin/bash",
 			"-c",
-			fmt.Sprintf(activateOSDCode, osdID, osdUUID, osdStore),
+			fmt.Sprintf(activateOSDCode, osdID, osdUUID, osdStore, path.Join(k8sutil.DataDir, TestDeviceFileName)),
 		},
 		Name:            "activate-osd",
 		Image:           c.cephVersion.Image,
@@ -651,7 +697,7 @@ func (c *Cluster) provisionPodTemplateSpec(osdProps osdProperties, restart v1.Re
 		RestartPolicy:     restart,
 		Volumes:           volumes,
 		HostNetwork:       c.Network.IsHost(),
-		PriorityClassName: c.priorityClassName,
+		PriorityClassName: c.preparePriorityClassName,
 	}
 	if c.Network.IsHost() {
 		podSpec.DNSPolicy = v1.DNSClusterFirstWithHostNet
@@ -758,6 +804,30 @@ func (c *Cluster) getConfigEnvVars(storeConfig config.StoreConfig, dataDir, node
 		envVars = append(envVars, v1.EnvVar{Name: encryptedDeviceEnvVarName, Value: "true"})
 	}
 
+	if storeConfig.DeviceClass != "" {
+		envVars = append(envVars, v1.EnvVar{Name: crushDeviceClassEnvVarName, Value: storeConfig.DeviceClass})
+	}
+
+	if storeConfig.TestDeviceSizeMB != 0 {
+		envVars = append(envVars, v1.EnvVar{Name: testDeviceSizeEnvVarName, Value: strconv.Itoa(storeConfig.TestDeviceSizeMB)})
+	}
+
+	if storeConfig.CompressionMode != "" {
+		envVars = append(envVars, v1.EnvVar{Name: compressionModeEnvVarName, Value: storeConfig.CompressionMode})
+	}
+
+	if storeConfig.CompressionAlgorithm != "" {
+		envVars = append(envVars, v1.EnvVar{Name: compressionAlgorithmEnvVarName, Value: storeConfig.CompressionAlgorithm})
+	}
+
+	if storeConfig.CompressionMinBlobSize != 0 {
+		envVars = append(envVars, v1.EnvVar{Name: compressionMinBlobSizeEnvVarName, Value: strconv.Itoa(storeConfig.CompressionMinBlobSize)})
+	}
+
+	if storeConfig.CephVolumeMode != "" {
+		envVars = append(envVars, v1.EnvVar{Name: cephVolumeModeEnvVarName, Value: storeConfig.CephVolumeMode})
+	}
+
 	return envVars
 }
 
@@ -779,6 +849,9 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 			if count, ok := device.Config[config.OSDsPerDeviceKey]; ok {
 				logger.Infof("%s osds requested on device %s (node %s)", count, device.Name, osdProps.crushHostname)
 				devSuffix += ":" + count
+			} else if osdProps.storeConfig.OSDsPerDevice > 1 {
+				// the device gets the number of osds of its node
+				devSuffix += ":" + strconv.Itoa(osdProps.storeConfig.OSDsPerDevice)
 			} else {
 				devSuffix += ":1"
 			}
@@ -852,6 +925,11 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 		envVars = append(envVars, dataDirectoriesEnvVar(strings.Join(dirPaths, ",")))
 	}
 
+	// the devices excluded from the discovery are not consumed by the prepare job either
+	if exclusions := os.Getenv(discoverDaemon.DeviceExclusionsEnvVar); exclusions != "" {
+		envVars = append(envVars, v1.EnvVar{Name: discoverDaemon.DeviceExclusionsEnvVar, Value: exclusions})
+	}
+
 	// elevate to be privileged if it is going to mount devices or if running in a restricted environment such as openshift
 	if devMountNeeded || os.Getenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED") == "true" || osdProps.pvc.ClaimName != "" {
 		privileged = true
@@ -873,7 +951,7 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 			RunAsNonRoot:           &runAsNonRoot,
 			ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
 		},
-		Resources: c.osdPrepareResources(osdProps.pvc.ClaimName),
+		Resources: c.osdPrepareResources(),
 	}
 
 	return osdProvisionContainer
@@ -983,6 +1061,18 @@ func getConfigFromContainer(osdContainer v1.Container) map[string]string {
 			cfg[config.JournalSizeMBKey] = envVar.Value
 		case osdMetadataDeviceEnvVarName:
 			cfg[config.MetadataDeviceKey] = envVar.Value
+		case crushDeviceClassEnvVarName:
+			cfg[config.DeviceClassKey] = envVar.Value
+		case testDeviceSizeEnvVarName:
+			cfg[config.TestDeviceSizeMBKey] = envVar.Value
+		case compressionModeEnvVarName:
+			cfg[config.CompressionModeKey] = envVar.Value
+		case compressionAlgorithmEnvVarName:
+			cfg[config.CompressionAlgorithmKey] = envVar.Value
+		case compressionMinBlobSizeEnvVarName:
+			cfg[config.CompressionMinBlobSizeKey] = envVar.Value
+		case cephVolumeModeEnvVarName:
+			cfg[config.CephVolumeModeKey] = envVar.Value
 		}
 	}
 
@@ -1025,24 +1115,10 @@ func (c *Cluster) getOSDLabels(osdID int, failureDomainValue string, portable bo
 	}
 }
 
-func (c *Cluster) osdPrepareResources(osdClaimName string) v1.ResourceRequirements {
-	var cpuLimit, cpuRequest, memoryLimit, memoryRequest int64
-
-	cpuLimit = c.prepareResources.Limits.Cpu().Value()
-	cpuRequest = c.prepareResources.Requests.Cpu().Value()
-	memoryLimit = c.prepareResources.Limits.Memory().Value()
-	memoryRequest = c.prepareResources.Requests.Memory().Value()
-
-	return v1.ResourceRequirements{
-		Limits: v1.ResourceList{
-			v1.ResourceCPU:    *resource.NewMilliQuantity(cpuLimit, resource.DecimalSI),
-			v1.ResourceMemory: *resource.NewQuantity(memoryLimit, resource.BinarySI),
-		},
-		Requests: v1.ResourceList{
-			v1.ResourceCPU:    *resource.NewMilliQuantity(cpuRequest, resource.DecimalSI),
-			v1.ResourceMemory: *resource.NewQuantity(memoryRequest, resource.BinarySI),
-		},
-	}
+// osdPrepareResources returns the resources of the prepare jobs. The jobs have their own resources since
+// the resources of the osds are usually much larger than what is needed to prepare the devices.
+func (c *Cluster) osdPrepareResources() v1.ResourceRequirements {
+	return c.prepareResources
 }
 
 func cephVolumeEnvVar() []v1.EnvVar {
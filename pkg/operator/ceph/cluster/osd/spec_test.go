@@ -18,6 +18,7 @@ limitations under the License.
 package osd
 
 import (
+	"strings"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -87,7 +88,7 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 		CephVersion: cephver.Nautilus,
 	}
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephVersion,
-		storageSpec, dataDir, rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		storageSpec, dataDir, rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	devMountNeeded := deviceName != "" || allDevices
 
@@ -148,6 +149,7 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	assert.Equal(t, cephVersion.Image, cont.Image)
 	assert.Equal(t, 6, len(cont.VolumeMounts))
 	assert.Equal(t, "ceph-osd", cont.Command[0])
+	assert.Equal(t, []string{"env", "-i", "sh", "-c", "ceph --admin-daemon /var/run/ceph/ceph-osd.0.asok status"}, cont.LivenessProbe.Exec.Command)
 }
 
 func verifyEnvVar(t *testing.T, envVars []v1.EnvVar, expectedName, expectedValue string, expectedFound bool) {
@@ -184,7 +186,7 @@ func TestStorageSpecDevicesAndDirectories(t *testing.T) {
 		CephVersion: cephver.Nautilus,
 	}
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
-		storageSpec, "/var/lib/rook", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		storageSpec, "/var/lib/rook", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -233,10 +235,14 @@ func TestStorageSpecConfig(t *testing.T) {
 			{
 				Name: "node1",
 				Config: map[string]string{
-					"databaseSizeMB": "10",
-					"walSizeMB":      "20",
-					"journalSizeMB":  "30",
-					"metadataDevice": "nvme093",
+					"databaseSizeMB":         "10",
+					"walSizeMB":              "20",
+					"journalSizeMB":          "30",
+					"metadataDevice":         "nvme093",
+					"deviceClass":            "nvme-cache",
+					"compressionMode":        "aggressive",
+					"compressionAlgorithm":   "zstd",
+					"compressionMinBlobSize": "65536",
 				},
 				Selection: rookalpha.Selection{
 					Directories: []rookalpha.Directory{{Path: "/rook/storageDir472"}},
@@ -260,7 +266,7 @@ func TestStorageSpecConfig(t *testing.T) {
 		CephVersion: cephver.Nautilus,
 	}
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
-		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
 	storeConfig := config.ToStoreConfig(storageSpec.Nodes[0].Config)
@@ -291,6 +297,10 @@ func TestStorageSpecConfig(t *testing.T) {
 	verifyEnvVar(t, container.Env, "ROOK_OSD_WAL_SIZE", "20", true)
 	verifyEnvVar(t, container.Env, "ROOK_OSD_JOURNAL_SIZE", "30", true)
 	verifyEnvVar(t, container.Env, "ROOK_METADATA_DEVICE", "nvme093", true)
+	verifyEnvVar(t, container.Env, "ROOK_OSD_CRUSH_DEVICE_CLASS", "nvme-cache", true)
+	verifyEnvVar(t, container.Env, "ROOK_OSD_COMPRESSION_MODE", "aggressive", true)
+	verifyEnvVar(t, container.Env, "ROOK_OSD_COMPRESSION_ALGORITHM", "zstd", true)
+	verifyEnvVar(t, container.Env, "ROOK_OSD_COMPRESSION_MIN_BLOB_SIZE", "65536", true)
 
 	// verify that osd config can be discovered from the container and matches the original config from the spec
 	discoveredConfig := getConfigFromContainer(container)
@@ -318,7 +328,7 @@ func TestHostNetwork(t *testing.T) {
 		CephVersion: cephver.Nautilus,
 	}
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
-		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{HostNetwork: true}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		storageSpec, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{HostNetwork: true}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 
 	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -344,6 +354,13 @@ func TestHostNetwork(t *testing.T) {
 	assert.Equal(t, "rook-ceph-osd-0", r.ObjectMeta.Name)
 	assert.Equal(t, true, r.Spec.Template.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, r.Spec.Template.Spec.DNSPolicy)
+	assert.False(t, r.Spec.Template.Spec.HostIPC)
+
+	// an encrypted osd needs the host ipc even when the node does not ask for encryption anymore
+	osd.Encrypted = true
+	r, err = c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.Nil(t, err)
+	assert.True(t, r.Spec.Template.Spec.HostIPC)
 }
 
 func TestOsdOnSDNFlag(t *testing.T) {
@@ -362,28 +379,39 @@ func TestOsdOnSDNFlag(t *testing.T) {
 }
 
 func TestOsdPrepareResources(t *testing.T) {
-	var osdClaimName string
-
 	clientset := fake.NewSimpleClientset()
 	c := New(&cephconfig.ClusterInfo{}, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
+
+	// no resources are set on the prepare jobs by default
+	r := c.osdPrepareResources()
+	assert.Equal(t, 0, len(r.Limits))
+	assert.Equal(t, 0, len(r.Requests))
 
-	// TEST 2: NOT running on PVC and some prepareResources are specificied
+	// some prepareResources are specified
 	rr := v1.ResourceRequirements{
 		Limits: v1.ResourceList{
-			v1.ResourceCPU: *resource.NewQuantity(2000.0, resource.BinarySI),
+			v1.ResourceCPU: resource.MustParse("500m"),
 		},
 		Requests: v1.ResourceList{
-			v1.ResourceMemory: *resource.NewQuantity(250.0, resource.BinarySI),
+			v1.ResourceMemory: resource.MustParse("250Mi"),
 		},
 	}
-
 	c.prepareResources = rr
-	r := c.osdPrepareResources(osdClaimName)
-	assert.Equal(t, "2", r.Limits.Cpu().String(), rr.Limits.Cpu().String())
+	r = c.osdPrepareResources()
+	assert.Equal(t, "500m", r.Limits.Cpu().String())
 	assert.Equal(t, "0", r.Requests.Cpu().String())
 	assert.Equal(t, "0", r.Limits.Memory().String())
-	assert.Equal(t, "250", r.Requests.Memory().String())
+	assert.Equal(t, "250Mi", r.Requests.Memory().String())
+
+	// the prepare pod has the resources and the priority class of the prepare jobs
+	c.preparePriorityClassName = "prepare-priority-class"
+	osdProps := osdProperties{crushHostname: "node", devices: []rookalpha.Device{}}
+	config := &provisionConfig{DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.Namespace, "/var/lib/rook")}
+	pod, err := c.provisionPodTemplateSpec(osdProps, v1.RestartPolicyOnFailure, config)
+	assert.NoError(t, err)
+	assert.Equal(t, "prepare-priority-class", pod.Spec.PriorityClassName)
+	assert.Equal(t, "500m", pod.Spec.Containers[0].Resources.Limits.Cpu().String())
 }
 
 func TestCephVolumeEnvVar(t *testing.T) {
@@ -395,3 +423,101 @@ func TestCephVolumeEnvVar(t *testing.T) {
 	assert.Equal(t, "DM_DISABLE_UDEV", cvEnv[2].Name)
 	assert.Equal(t, "1", cvEnv[1].Value)
 }
+
+func TestOSDMemoryTarget(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephconfig.ClusterInfo{
+		CephVersion: cephver.Nautilus,
+	}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+	dataPathMap := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.Namespace, "/var/lib/rook"),
+	}
+	osdProp := osdProperties{crushHostname: "node1"}
+	getMemoryTarget := func(osd OSDInfo) string {
+		deployment, err := c.makeDeployment(osdProp, osd, dataPathMap)
+		require.NoError(t, err)
+		for _, arg := range deployment.Spec.Template.Spec.Containers[0].Args {
+			if strings.HasPrefix(arg, "--osd-memory-target=") {
+				return strings.TrimPrefix(arg, "--osd-memory-target=")
+			}
+		}
+		return ""
+	}
+
+	// the target is not set without a memory limit
+	assert.Equal(t, "", getMemoryTarget(OSDInfo{ID: 0, CephVolumeInitiated: true}))
+
+	// the target is 80% of the memory limit of the osd
+	osdProp.resources = v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	assert.Equal(t, "3435973888", getMemoryTarget(OSDInfo{ID: 0, CephVolumeInitiated: true}))
+
+	// filestore osds do not use the target
+	assert.Equal(t, "", getMemoryTarget(OSDInfo{ID: 0, IsDirectory: true, IsFileStore: true}))
+}
+
+func TestOSDsPerDevice(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephconfig.ClusterInfo{
+		CephVersion: cephver.Nautilus,
+	}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+	dataPathMap := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.Namespace, "/var/lib/rook"),
+	}
+
+	// the devices without a count get the count of the node
+	osdProp := osdProperties{
+		crushHostname: "node1",
+		devices: []rookalpha.Device{
+			{Name: "nvme0n1"},
+			{Name: "nvme1n1", Config: map[string]string{config.OSDsPerDeviceKey: "1"}},
+		},
+		storeConfig: config.ToStoreConfig(map[string]string{config.OSDsPerDeviceKey: "4"}),
+	}
+	job, err := c.makeJob(osdProp, dataPathMap)
+	require.NoError(t, err)
+	container := job.Spec.Template.Spec.Containers[0]
+	verifyEnvVar(t, container.Env, "ROOK_OSDS_PER_DEVICE", "4", true)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICES", "nvme0n1:4:::,nvme1n1:1:::", true)
+}
+
+func TestActivateOSDInitContainer(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephconfig.ClusterInfo{
+		CephVersion: cephver.Nautilus,
+	}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+	dataPathMap := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.Namespace, "/var/lib/rook"),
+	}
+	osdProp := osdProperties{crushHostname: "node1"}
+	osd := OSDInfo{ID: 3, UUID: "osd-uuid", CephVolumeInitiated: true}
+
+	deployment, err := c.makeDeployment(osdProp, osd, dataPathMap)
+	require.NoError(t, err)
+	var activate *v1.Container
+	for i, container := range deployment.Spec.Template.Spec.InitContainers {
+		if container.Name == "activate-osd" {
+			activate = &deployment.Spec.Template.Spec.InitContainers[i]
+		}
+	}
+	require.NotNil(t, activate)
+
+	// the volumes of the osd are activated by the uuid that ceph-volume tags them with before the osd is activated
+	script := activate.Command[2]
+	lvchange := strings.Index(script, `lvchange --activate y "@ceph.osd_fsid=$OSD_UUID"`)
+	assert.NotEqual(t, -1, lvchange)
+	assert.True(t, lvchange < strings.Index(script, "ceph-volume lvm activate"))
+	assert.Contains(t, script, "OSD_UUID=osd-uuid\n")
+
+	// the loop device of the test mode is attached again from the data dir of the host before its volumes are activated
+	assert.Contains(t, script, "TEST_DEVICE_FILE=/var/lib/rook/osd-test-device.img\n")
+	assert.True(t, strings.Index(script, `losetup --find "$TEST_DEVICE_FILE"`) < lvchange)
+	assert.Contains(t, activate.VolumeMounts, v1.VolumeMount{Name: "rook-data", MountPath: "/var/lib/rook"})
+}
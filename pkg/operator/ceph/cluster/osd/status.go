@@ -20,12 +20,15 @@ package osd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util"
+	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,16 +47,78 @@ const (
 	nodeLabelKey                     = "node"
 	completeProvisionTimeout         = 20
 	completeProvisionSkipOSDTimeout  = 5
+	prepareJobsMaxInFlightEnv        = "ROOK_OSD_PREPARE_MAX_IN_FLIGHT"
 )
 
 type provisionConfig struct {
 	errorMessages []string
 	DataPathMap   *config.DataPathMap // location to store data in container
+
+	// the max number of prepare jobs running at once, or 0 to start all the jobs at once
+	maxJobsInFlight int
+	runningJobs     *util.Set
+	pendingJobs     []pendingJob
+
+	// the existing osd deployments to restart, by failure domain
+	osdUpdates map[string][]osdUpdate
+}
+
+// pendingJob is a prepare job waiting for another prepare job to complete
+type pendingJob struct {
+	job      *batch.Job
+	nodeName string
 }
 
 func (c *Cluster) newProvisionConfig() *provisionConfig {
 	return &provisionConfig{
-		DataPathMap: config.NewDatalessDaemonDataPathMap(c.Namespace, c.dataDirHostPath),
+		DataPathMap:     config.NewDatalessDaemonDataPathMap(c.Namespace, c.dataDirHostPath),
+		maxJobsInFlight: prepareJobsMaxInFlight(),
+		runningJobs:     util.NewSet(),
+		osdUpdates:      map[string][]osdUpdate{},
+	}
+}
+
+// prepareJobsMaxInFlight returns the limit of the prepare jobs running at once set on the operator
+func prepareJobsMaxInFlight() int {
+	value := os.Getenv(prepareJobsMaxInFlightEnv)
+	if value == "" {
+		return 0
+	}
+	max, err := strconv.Atoi(value)
+	if err != nil || max < 0 {
+		logger.Warningf("ignoring invalid %s %q. all the osd prepare jobs are started at once", prepareJobsMaxInFlightEnv, value)
+		return 0
+	}
+	return max
+}
+
+// runProvisionJob starts the prepare job of the node, or queues the job until another prepare job completes if the
+// max number of prepare jobs are already running
+func (c *Cluster) runProvisionJob(job *batch.Job, nodeName string, config *provisionConfig) bool {
+	if config.maxJobsInFlight > 0 && config.runningJobs.Count() >= config.maxJobsInFlight {
+		logger.Infof("osd provision job for node %s is waiting for one of the %d running jobs to complete", nodeName, config.runningJobs.Count())
+		config.pendingJobs = append(config.pendingJobs, pendingJob{job: job, nodeName: nodeName})
+		return true
+	}
+	if !c.runJob(job, nodeName, config, "provision") {
+		return false
+	}
+	config.runningJobs.Add(nodeName)
+	return true
+}
+
+// provisionJobCompleted starts the next pending prepare jobs after the job of the node has completed
+func (c *Cluster) provisionJobCompleted(nodeName string, config *provisionConfig) {
+	if !config.runningJobs.Remove(nodeName) {
+		return
+	}
+	for len(config.pendingJobs) > 0 && config.runningJobs.Count() < config.maxJobsInFlight {
+		next := config.pendingJobs[0]
+		config.pendingJobs = config.pendingJobs[1:]
+		// the failure to start the job is reported in the status of the node
+		if c.runJob(next.job, next.nodeName, config, "provision") {
+			config.runningJobs.Add(next.nodeName)
+		}
 	}
 }
 
@@ -226,6 +291,10 @@ func (c *Cluster) completeOSDsForAllNodes(config *provisionConfig, configOSDs bo
 					}
 					completed := c.handleStatusConfigMapStatus(node, config, configMap, configOSDs)
 					if completed {
+						if config.maxJobsInFlight > 0 {
+							// the nodes are provisioned a few at a time, so only time out when none completes
+							currentTimeoutMinutes = 0
+						}
 						remainingNodes.Remove(node)
 						if remainingNodes.Count() == 0 {
 							logger.Infof("%d/%d node(s) completed osd provisioning", originalNodes, originalNodes)
@@ -262,6 +331,9 @@ func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provision
 	}
 
 	logger.Infof("osd orchestration status for node %s is %s", nodeName, status.Status)
+	if isStatusCompleted(*status) {
+		c.provisionJobCompleted(nodeName, config)
+	}
 	if status.Status == OrchestrationStatusCompleted {
 		if configOSDs {
 			if status.PvcBackedOSD {
@@ -18,6 +18,7 @@ package osd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"testing"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -42,7 +44,7 @@ func TestOrchestrationStatus(t *testing.T) {
 		CephVersion: cephver.Nautilus,
 	}
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false)
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
 	kv := k8sutil.NewConfigMapKVStore(c.Namespace, clientset, metav1.OwnerReference{})
 	nodeName := "mynode"
 	cmName := fmt.Sprintf(orchestrationStatusMapName, nodeName)
@@ -123,3 +125,51 @@ func waitForOrchestrationCompletion(c *Cluster, nodeName string, startCompleted
 		<-time.After(50 * time.Millisecond)
 	}
 }
+
+func TestProvisionJobsMaxInFlight(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephconfig.ClusterInfo{
+		CephVersion: cephver.Nautilus,
+	}
+	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", "my-priority-class", metav1.OwnerReference{}, false, false)
+	job := func(nodeName string) *batch.Job {
+		return &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-prepare-" + nodeName, Namespace: "ns"}}
+	}
+	jobCount := func() int {
+		jobs, err := clientset.BatchV1().Jobs("ns").List(metav1.ListOptions{})
+		assert.NoError(t, err)
+		return len(jobs.Items)
+	}
+
+	// all the jobs are started at once by default
+	config := c.newProvisionConfig()
+	assert.Equal(t, 0, config.maxJobsInFlight)
+
+	// invalid limits are ignored
+	os.Setenv(prepareJobsMaxInFlightEnv, "-1")
+	defer os.Unsetenv(prepareJobsMaxInFlightEnv)
+	assert.Equal(t, 0, c.newProvisionConfig().maxJobsInFlight)
+
+	os.Setenv(prepareJobsMaxInFlightEnv, "2")
+	config = c.newProvisionConfig()
+	for _, node := range []string{"a", "b", "c", "d"} {
+		assert.True(t, c.runProvisionJob(job(node), node, config))
+	}
+	assert.Equal(t, 2, jobCount())
+	assert.Equal(t, 2, len(config.pendingJobs))
+
+	// the completion of a pending job does not start another job
+	c.provisionJobCompleted("c", config)
+	assert.Equal(t, 2, jobCount())
+
+	// the next job starts when a running job completes
+	c.provisionJobCompleted("a", config)
+	assert.Equal(t, 3, jobCount())
+	assert.True(t, config.runningJobs.Contains("c"))
+	c.provisionJobCompleted("b", config)
+	c.provisionJobCompleted("c", config)
+	assert.Equal(t, 4, jobCount())
+	assert.Equal(t, 0, len(config.pendingJobs))
+	assert.Equal(t, 1, config.runningJobs.Count())
+}
@@ -29,9 +29,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+const (
+	// The GA topology labels of Kubernetes 1.17, which take precedence over the beta labels
+	labelZoneStable   = "topology.kubernetes.io/zone"
+	labelRegionStable = "topology.kubernetes.io/region"
+)
+
 var (
 
-	// The labels that can be specified with the K8s labels such as failure-domain.beta.kubernetes.io/zone
+	// The labels that can be specified with the K8s labels such as topology.kubernetes.io/zone or
+	// failure-domain.beta.kubernetes.io/zone
 	// These are all at the top layers of the CRUSH map.
 	KubernetesTopologyLabels = []string{"zone", "region"}
 
@@ -48,13 +55,11 @@ func ExtractRookTopologyFromLabels(labels map[string]string) (map[string]string,
 	topology := make(map[string]string)
 
 	// get zone
-	zone, ok := labels[corev1.LabelZoneFailureDomain]
-	if ok {
+	if zone, ok := getFirstLabel(labels, labelZoneStable, corev1.LabelZoneFailureDomain); ok {
 		topology["zone"] = client.NormalizeCrushName(zone)
 	}
 	// get region
-	region, ok := labels[corev1.LabelZoneRegion]
-	if ok {
+	if region, ok := getFirstLabel(labels, labelRegionStable, corev1.LabelZoneRegion); ok {
 		topology["region"] = client.NormalizeCrushName(region)
 	}
 
@@ -66,19 +71,35 @@ func ExtractRookTopologyFromLabels(labels map[string]string) (map[string]string,
 
 	invalidEncountered := make([]string, 0)
 	for labelKey, labelValue := range labels {
+		if !strings.HasPrefix(labelKey, k8sutil.TopologyLabelPrefix) {
+			continue
+		}
+		s := strings.Split(labelKey, "/")
+		if len(s) != 2 {
+			invalidEncountered = append(invalidEncountered, fmt.Sprintf("%s=%s", labelKey, labelValue))
+			continue
+		}
+		topologyType := s[1]
+		valid := false
 		for _, validTopologyType := range CRUSHTopologyLabels {
-			if strings.HasPrefix(labelKey, k8sutil.TopologyLabelPrefix) {
-				s := strings.Split(labelKey, "/")
-				if len(s) != 2 {
-					invalidEncountered = append(invalidEncountered, fmt.Sprintf("%s=%s", labelKey, labelValue))
-					continue
-				}
-				topologyType := s[1]
-				if topologyType == validTopologyType {
-					topology[validTopologyType] = client.NormalizeCrushName(labelValue)
-				}
+			if topologyType == validTopologyType {
+				topology[validTopologyType] = client.NormalizeCrushName(labelValue)
+				valid = true
 			}
 		}
+		if !valid {
+			invalidEncountered = append(invalidEncountered, fmt.Sprintf("%s=%s", labelKey, labelValue))
+		}
 	}
 	return topology, invalidEncountered
 }
+
+// getFirstLabel returns the value of the first of the labels found
+func getFirstLabel(labels map[string]string, keys ...string) (string, bool) {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
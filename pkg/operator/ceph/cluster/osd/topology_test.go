@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestOrderedCRUSHLabels(t *testing.T) {
@@ -37,3 +38,32 @@ func TestOrderedCRUSHLabels(t *testing.T) {
 	assert.Equal(t, "zone", CRUSHMapLevelsOrdered[8])
 	assert.Equal(t, "region", CRUSHMapLevelsOrdered[9])
 }
+
+func TestExtractRookTopologyFromLabels(t *testing.T) {
+	labels := map[string]string{
+		corev1.LabelHostname:          "node.example.com",
+		corev1.LabelZoneRegion:        "region1",
+		corev1.LabelZoneFailureDomain: "zone1",
+		"topology.rook.io/rack":       "rack1",
+		"topology.rook.io/datacenter": "dc1",
+		"topology.rook.io/foo":        "bar",
+		"topology.rook.io/rack/a":     "b",
+		"other.io/rack":               "rack2",
+	}
+	topology, invalid := ExtractRookTopologyFromLabels(labels)
+	assert.Equal(t, map[string]string{
+		"host":       "node-example-com",
+		"region":     "region1",
+		"zone":       "zone1",
+		"rack":       "rack1",
+		"datacenter": "dc1",
+	}, topology)
+	assert.ElementsMatch(t, []string{"topology.rook.io/foo=bar", "topology.rook.io/rack/a=b"}, invalid)
+
+	// the GA labels take precedence over the beta labels
+	labels["topology.kubernetes.io/region"] = "region2"
+	labels["topology.kubernetes.io/zone"] = "zone2"
+	topology, _ = ExtractRookTopologyFromLabels(labels)
+	assert.Equal(t, "region2", topology["region"])
+	assert.Equal(t, "zone2", topology["zone"])
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+)
+
+const nooutFlag = "noout"
+
+var (
+	// the osds of the next failure domain are restarted when all the pgs are clean again
+	waitForCleanPGsInterval = 10 * time.Second
+	waitForCleanPGsRetries  = 180
+
+	// the osds of a failure domain are only restarted together when ceph reports they are ok to stop
+	waitForOkToStopInterval = 10 * time.Second
+	waitForOkToStopRetries  = 30
+	osdsOkToStop            = client.OSDsOkToStop
+)
+
+// osdUpdate is an existing osd deployment that must be restarted with the desired deployment
+type osdUpdate struct {
+	id         int
+	deployment *apps.Deployment
+}
+
+// queueOSDUpdate queues the restart of an existing osd if its desired deployment changed, for example after the
+// image or the config of the osds changed. The osds are always restarted during an upgrade.
+func (c *Cluster) queueOSDUpdate(config *provisionConfig, osdID int, existing, desired *apps.Deployment) {
+	if !c.isUpgrade && !k8sutil.DeploymentChanged(existing, desired) {
+		logger.Debugf("osd deployment %q is up to date", desired.Name)
+		return
+	}
+	failureDomain := desired.Labels[FailureDomainKey]
+	logger.Infof("osd deployment %q changed. restarting it with the osds of failure domain %q", desired.Name, failureDomain)
+	config.osdUpdates[failureDomain] = append(config.osdUpdates[failureDomain], osdUpdate{id: osdID, deployment: desired})
+}
+
+// updateOSDDeployments restarts the changed osds one failure domain at a time. The noout flag is set for the time of
+// the restarts so the data of the restarted osds is not rebalanced, and the osds of the next failure domain are only
+// restarted when all the pgs are clean again. Before the osds of a failure domain are restarted together, ceph must
+// report with "osd ok-to-stop" that stopping them does not make any pg inactive.
+func (c *Cluster) updateOSDDeployments(config *provisionConfig) {
+	if len(config.osdUpdates) == 0 {
+		return
+	}
+
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo.Name)
+	if err != nil {
+		config.addError("failed to get the osd flags before restarting the osds. %v", err)
+		return
+	}
+	// the flag is left alone if it was already set, for example during a maintenance
+	if !osdDump.IsFlagSet(nooutFlag) {
+		if err := client.SetFlag(c.context, c.clusterInfo.Name, nooutFlag); err != nil {
+			config.addError("failed to set the noout flag before restarting the osds. %v", err)
+			return
+		}
+		defer func() {
+			if err := client.UnsetFlag(c.context, c.clusterInfo.Name, nooutFlag); err != nil {
+				config.addError("failed to unset the noout flag after restarting the osds. %v", err)
+			}
+		}()
+	}
+
+	failureDomains := []string{}
+	for failureDomain := range config.osdUpdates {
+		failureDomains = append(failureDomains, failureDomain)
+	}
+	sort.Strings(failureDomains)
+
+	cephVersion := c.getOSDCephVersion()
+	for _, failureDomain := range failureDomains {
+		updates := config.osdUpdates[failureDomain]
+		if err := c.waitForOSDsOkToStop(updates); err != nil {
+			// the osds of the failure domain are restarted by the next orchestration
+			config.addError("failed to restart the osds in failure domain %q. %v", failureDomain, err)
+			return
+		}
+		logger.Infof("restarting %d osds in failure domain %q", len(updates), failureDomain)
+		for _, update := range updates {
+			if err := updateDeploymentAndWait(c.context, update.deployment, c.Namespace, string(opconfig.OsdType), strconv.Itoa(update.id), cephVersion, c.isUpgrade, c.skipUpgradeChecks); err != nil {
+				logger.Errorf("failed to update osd deployment %d. %v", update.id, err)
+			}
+		}
+
+		if err := c.waitForCleanPGs(); err != nil {
+			// the osds of the remaining failure domains are restarted by the next orchestration
+			config.addError("failed to wait for the pgs to be clean after restarting the osds in failure domain %q. %v", failureDomain, err)
+			return
+		}
+	}
+}
+
+// getOSDCephVersion returns the ceph version of the osds that are not updated yet
+func (c *Cluster) getOSDCephVersion() cephver.CephVersion {
+	// If this is not a Ceph upgrade there is no need to check the ceph version
+	if !c.isUpgrade {
+		return cephver.CephVersion{}
+	}
+
+	// Always invoke ceph version before an upgrade so we are sure to be up-to-date
+	currentCephVersion, err := client.LeastUptodateDaemonVersion(c.context, c.clusterInfo.Name, string(opconfig.OsdType))
+	if err != nil {
+		logger.Warningf("failed to retrieve current ceph %q version. %v", opconfig.OsdType, err)
+		logger.Debug("could not detect ceph version during update, this is likely an initial bootstrap, proceeding with c.clusterInfo.CephVersion")
+		return c.clusterInfo.CephVersion
+	}
+	logger.Debugf("current cluster version for osds before upgrading is: %+v", currentCephVersion)
+	return currentCephVersion
+}
+
+// waitForOSDsOkToStop waits for ceph to report that the osds of the updates can be stopped at the same time
+func (c *Cluster) waitForOSDsOkToStop(updates []osdUpdate) error {
+	if c.skipUpgradeChecks {
+		logger.Warningf("not checking if the osds are ok to stop since the upgrade checks are skipped")
+		return nil
+	}
+	osdIDs := []int{}
+	for _, update := range updates {
+		osdIDs = append(osdIDs, update.id)
+	}
+	var err error
+	for i := 0; i < waitForOkToStopRetries; i++ {
+		if err = osdsOkToStop(c.context, c.clusterInfo.Name, osdIDs); err == nil {
+			return nil
+		}
+		logger.Infof("waiting for osds %v to be ok to stop. %v", osdIDs, err)
+		time.Sleep(waitForOkToStopInterval)
+	}
+	return errors.Wrapf(err, "gave up waiting for osds %v to be ok to stop after %d attempts", osdIDs, waitForOkToStopRetries)
+}
+
+// waitForCleanPGs waits for all the pgs to be active+clean
+func (c *Cluster) waitForCleanPGs() error {
+	for i := 0; i < waitForCleanPGsRetries; i++ {
+		msg, clean, err := client.IsClusterClean(c.context, c.clusterInfo.Name)
+		if err != nil {
+			logger.Warningf("failed to check if the pgs are clean. %v", err)
+		} else if clean {
+			logger.Info(msg)
+			return nil
+		} else {
+			logger.Infof("waiting for the pgs to be clean. %s", msg)
+		}
+		time.Sleep(waitForCleanPGsInterval)
+	}
+	return errors.Errorf("gave up waiting for the pgs to be clean after %d attempts", waitForCleanPGsRetries)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateOSDDeployments(t *testing.T) {
+	flags := ""
+	status := `{"pgmap":{"num_pgs":0}}`
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "osd" && args[1] == "dump":
+			return `{"osds":[],"flags":"` + flags + `"}`, nil
+		case args[0] == "osd" && (args[1] == "set" || args[1] == "unset"):
+			commands = append(commands, strings.Join(args[:3], " "))
+			return "", nil
+		case args[0] == "status":
+			return status, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	c := New(&cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Nautilus}, &clusterd.Context{Clientset: test.New(1), Executor: executor}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.Annotations{}, cephv1.NetworkSpec{}, cephv1.KeyManagementServiceSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", "", metav1.OwnerReference{}, false, false)
+
+	var deploymentsUpdated *[]*apps.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+	waitForCleanPGsInterval = 0
+	waitForCleanPGsRetries = 1
+	waitForOkToStopInterval = 0
+	waitForOkToStopRetries = 1
+	var okToStop [][]int
+	notOkToStop := map[int]bool{}
+	osdsOkToStop = func(context *clusterd.Context, clusterName string, osdIDs []int) error {
+		okToStop = append(okToStop, osdIDs)
+		for _, id := range osdIDs {
+			if notOkToStop[id] {
+				return errors.Errorf("osd %d is not ok to stop", id)
+			}
+		}
+		return nil
+	}
+
+	deployment := func(id, host, image string) *apps.Deployment {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-" + id, Labels: map[string]string{FailureDomainKey: host}}}
+		d.Spec.Template.Spec.Containers = []v1.Container{{Name: "osd", Image: image}}
+		k8sutil.ApplySpecHash(d)
+		return d
+	}
+	queueUpdates := func(config *provisionConfig) {
+		// osd 0 is up to date and the other osds have a new image
+		c.queueOSDUpdate(config, 0, deployment("0", "host-b", "ceph:v14.2.5"), deployment("0", "host-b", "ceph:v14.2.5"))
+		c.queueOSDUpdate(config, 1, deployment("1", "host-b", "ceph:v14.2.5"), deployment("1", "host-b", "ceph:v14.2.6"))
+		c.queueOSDUpdate(config, 2, deployment("2", "host-a", "ceph:v14.2.5"), deployment("2", "host-a", "ceph:v14.2.6"))
+		c.queueOSDUpdate(config, 3, deployment("3", "host-a", "ceph:v14.2.5"), deployment("3", "host-a", "ceph:v14.2.6"))
+	}
+
+	// nothing is restarted if no osd changed
+	config := c.newProvisionConfig()
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 0, len(commands))
+
+	// the osds are restarted one failure domain at a time while noout is set
+	queueUpdates(config)
+	assert.Equal(t, 2, len(config.osdUpdates))
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, []string{"rook-ceph-osd-2", "rook-ceph-osd-3", "rook-ceph-osd-1"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	assert.Equal(t, []string{"osd set noout", "osd unset noout"}, commands)
+	assert.Equal(t, [][]int{{2, 3}, {1}}, okToStop)
+
+	// the noout flag set before the restarts is left alone
+	flags = "noout,sortbitwise"
+	commands = nil
+	*deploymentsUpdated = nil
+	config = c.newProvisionConfig()
+	queueUpdates(config)
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, 3, len(*deploymentsUpdated))
+	assert.Equal(t, 0, len(commands))
+
+	// the next failure domain waits for the pgs to be clean
+	flags = ""
+	status = `{"pgmap":{"num_pgs":2,"pgs_by_state":[{"state_name":"active+clean","count":1},{"state_name":"active+undersized+degraded","count":1}]}}`
+	commands = nil
+	*deploymentsUpdated = nil
+	config = c.newProvisionConfig()
+	queueUpdates(config)
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 1, len(config.errorMessages))
+	assert.Equal(t, []string{"rook-ceph-osd-2", "rook-ceph-osd-3"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	assert.Equal(t, []string{"osd set noout", "osd unset noout"}, commands)
+
+	// the osds of a failure domain are not restarted if they are not ok to stop
+	status = `{"pgmap":{"num_pgs":0}}`
+	notOkToStop[3] = true
+	commands = nil
+	okToStop = nil
+	*deploymentsUpdated = nil
+	config = c.newProvisionConfig()
+	queueUpdates(config)
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 1, len(config.errorMessages))
+	assert.Equal(t, 0, len(*deploymentsUpdated))
+	assert.Equal(t, [][]int{{2, 3}}, okToStop)
+	assert.Equal(t, []string{"osd set noout", "osd unset noout"}, commands)
+
+	// the check is skipped with the upgrade checks
+	c.skipUpgradeChecks = true
+	okToStop = nil
+	*deploymentsUpdated = nil
+	config = c.newProvisionConfig()
+	queueUpdates(config)
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, 3, len(*deploymentsUpdated))
+	assert.Equal(t, 0, len(okToStop))
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+const (
+	// weightInConfigKey is the key of the config-key store of the mons with the target crush weight of each osd
+	// that is weighed in
+	weightInConfigKey = "rook/osd-weight-in"
+	// weightInNewOSDsKey is the key with the ids of the osds started by the operator that are not weighed in yet
+	weightInNewOSDsKey = "rook/osd-weight-in-new"
+	// weightInInitialWeightKey is the key where the operator records that it set the initial crush weight of the osds
+	weightInInitialWeightKey = "rook/osd-weight-in-initial-weight"
+	defaultWeightInStep      = 0.1
+	kbPerTiB                 = 1024 * 1024 * 1024
+)
+
+// weightInLock serializes the updates of the new osds by the orchestration and the osd monitor
+var weightInLock sync.Mutex
+
+// weightInSettings returns the step and the interval of the weight-in spec
+func weightInSettings(spec *cephv1.OSDWeightInSpec) (float64, time.Duration, error) {
+	step := defaultWeightInStep
+	if spec.Step != 0 {
+		step = spec.Step
+	}
+	if step <= 0 || step > 1 {
+		return 0, 0, errors.Errorf("invalid osd weight-in step %v. it must be greater than 0 and at most 1", spec.Step)
+	}
+	var interval time.Duration
+	if spec.Interval != "" {
+		var err error
+		interval, err = time.ParseDuration(spec.Interval)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "invalid osd weight-in interval %q", spec.Interval)
+		}
+		if interval < 0 {
+			return 0, 0, errors.Errorf("invalid osd weight-in interval %q. it must not be negative", spec.Interval)
+		}
+	}
+	return step, interval, nil
+}
+
+// SetWeightInConfig makes the new osds join the crush map with a weight of 0 when they are weighed in. The default
+// initial weight of the osds, which is their size, is restored otherwise if the operator had set it.
+func SetWeightInConfig(context *clusterd.Context, namespace string, spec *cephv1.OSDWeightInSpec) error {
+	store := config.GetMonStore(context, namespace)
+	if spec == nil {
+		val, _, err := client.GetConfigKey(context, namespace, weightInInitialWeightKey)
+		if err != nil {
+			return err
+		}
+		if val != "true" {
+			// the initial weight was not set by the operator
+			return nil
+		}
+		if err := store.Delete("osd", "osd_crush_initial_weight"); err != nil {
+			return errors.Wrapf(err, "failed to restore the initial crush weight of the osds")
+		}
+		return client.SetConfigKey(context, namespace, weightInInitialWeightKey, "false")
+	}
+	if _, _, err := weightInSettings(spec); err != nil {
+		return err
+	}
+	if err := store.Set("osd", "osd_crush_initial_weight", "0"); err != nil {
+		return errors.Wrapf(err, "failed to set the initial crush weight of the osds")
+	}
+	return client.SetConfigKey(context, namespace, weightInInitialWeightKey, "true")
+}
+
+// UpdateWeightIn updates the weight-in settings of the new osds
+func (m *Monitor) UpdateWeightIn(spec *cephv1.OSDWeightInSpec) {
+	m.weightIn = spec
+	m.weightInFinished = false
+}
+
+// addWeightInOSDs records the osds started for the first time by the operator, which are weighed in if they join the
+// crush map with a weight of 0
+func addWeightInOSDs(context *clusterd.Context, namespace string, osdIDs []int) error {
+	if len(osdIDs) == 0 {
+		return nil
+	}
+	weightInLock.Lock()
+	defer weightInLock.Unlock()
+
+	newOSDs, err := getWeightInOSDs(context, namespace)
+	if err != nil {
+		return err
+	}
+	for _, id := range osdIDs {
+		newOSDs[strconv.Itoa(id)] = true
+	}
+	return setWeightInOSDs(context, namespace, newOSDs)
+}
+
+// weighInOSDs increases the crush weight of the new osds by one step once all the pgs are clean. A new osd is an
+// osd started for the first time by the operator that is up and in with a crush weight of 0, and it is weighed in
+// until its weight is its size in TiB. When the weight-in is disabled, the osds are set to their full weight.
+func (m *Monitor) weighInOSDs() error {
+	if m.weightIn == nil {
+		if m.weightInFinished {
+			return nil
+		}
+		if err := m.finishWeightIn(); err != nil {
+			return err
+		}
+		m.weightInFinished = true
+		return nil
+	}
+	step, interval, err := weightInSettings(m.weightIn)
+	if err != nil {
+		return err
+	}
+
+	targets, err := m.getWeightInTargets()
+	if err != nil {
+		return err
+	}
+	usage, err := client.GetOSDUsage(m.context, m.clusterName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the usage of the osds")
+	}
+	osdDump, err := client.GetOSDDump(m.context, m.clusterName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the osd dump")
+	}
+
+	changed, err := m.addWeightInTargets(targets, usage, osdDump)
+	if err != nil {
+		return err
+	}
+	for id := range targets {
+		osdID, err := strconv.Atoi(id)
+		if err != nil || usage.ByID(osdID) == nil {
+			// the osd was removed
+			delete(targets, id)
+			changed = true
+			continue
+		}
+		if _, in, err := osdDump.StatusByID(int64(osdID)); err == nil && in != inStatus {
+			// the osd is not weighed in anymore once it is out, e.g. when it is purged
+			logger.Infof("stopping the weight-in of osd.%d that is out", osdID)
+			delete(targets, id)
+			changed = true
+		}
+	}
+
+	if len(targets) > 0 && time.Since(m.lastWeightInStep) >= interval {
+		msg, clean, err := client.IsClusterClean(m.context, m.clusterName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if the pgs are clean")
+		}
+		if !clean {
+			logger.Infof("waiting for the pgs to be clean before weighing in the osds. %s", msg)
+		} else {
+			for id, target := range targets {
+				osdID, _ := strconv.Atoi(id)
+				weight, _ := usage.ByID(osdID).CrushWeight.Float64()
+				weight = math.Min(weight+step*target, target)
+				logger.Infof("increasing the crush weight of osd.%d to %.5f of %.5f", osdID, weight, target)
+				if o, err := client.CrushReweight(m.context, m.clusterName, osdID, weight); err != nil {
+					return errors.Wrapf(err, "failed to reweight osd.%d: %s", osdID, o)
+				}
+				if weight >= target {
+					logger.Infof("osd.%d is weighed in", osdID)
+					delete(targets, id)
+				}
+			}
+			m.lastWeightInStep = time.Now()
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return m.setWeightInTargets(targets)
+}
+
+// addWeightInTargets adds the new osds that are up and in with a weight of 0 to the osds that are weighed in. The
+// new osds that are not up and in yet are kept for the next check.
+func (m *Monitor) addWeightInTargets(targets map[string]float64, usage *client.OSDUsage, osdDump *client.OSDDump) (bool, error) {
+	weightInLock.Lock()
+	defer weightInLock.Unlock()
+
+	newOSDs, err := getWeightInOSDs(m.context, m.clusterName)
+	if err != nil || len(newOSDs) == 0 {
+		return false, err
+	}
+	pending := len(newOSDs)
+	changed := false
+	for id := range newOSDs {
+		osdID, err := strconv.Atoi(id)
+		if err != nil {
+			delete(newOSDs, id)
+			continue
+		}
+		up, in, err := osdDump.StatusByID(int64(osdID))
+		if err != nil {
+			// the osd was removed
+			delete(newOSDs, id)
+			continue
+		}
+		osd := usage.ByID(osdID)
+		if osd == nil || up != upStatus || in != inStatus {
+			continue
+		}
+		delete(newOSDs, id)
+		weight, err := osd.CrushWeight.Float64()
+		if err != nil || weight != 0 {
+			// the osd did not join the crush map with a weight of 0
+			continue
+		}
+		kb, err := osd.KB.Int64()
+		if err != nil || kb == 0 {
+			continue
+		}
+		targets[id] = float64(kb) / kbPerTiB
+		logger.Infof("weighing in osd.%d up to a crush weight of %.5f", osdID, targets[id])
+		changed = true
+	}
+	if len(newOSDs) != pending {
+		if err := setWeightInOSDs(m.context, m.clusterName, newOSDs); err != nil {
+			return false, err
+		}
+	}
+	return changed, nil
+}
+
+// finishWeightIn sets the osds that are weighed in to their full weight
+func (m *Monitor) finishWeightIn() error {
+	targets, err := m.getWeightInTargets()
+	if err != nil || len(targets) == 0 {
+		return err
+	}
+	usage, err := client.GetOSDUsage(m.context, m.clusterName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the usage of the osds")
+	}
+	for id, target := range targets {
+		osdID, err := strconv.Atoi(id)
+		if err != nil || usage.ByID(osdID) == nil {
+			// the osd was removed
+			continue
+		}
+		logger.Infof("the weight-in is disabled. increasing the crush weight of osd.%d to %.5f", osdID, target)
+		if o, err := client.CrushReweight(m.context, m.clusterName, osdID, target); err != nil {
+			return errors.Wrapf(err, "failed to reweight osd.%d: %s", osdID, o)
+		}
+	}
+	if err := setWeightInOSDs(m.context, m.clusterName, map[string]bool{}); err != nil {
+		return err
+	}
+	return m.setWeightInTargets(map[string]float64{})
+}
+
+// getWeightInTargets returns the target crush weight of the osds that are weighed in by osd id
+func (m *Monitor) getWeightInTargets() (map[string]float64, error) {
+	targets := map[string]float64{}
+	val, found, err := client.GetConfigKey(m.context, m.clusterName, weightInConfigKey)
+	if err != nil || !found {
+		return targets, err
+	}
+	if err := json.Unmarshal([]byte(val), &targets); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the osds that are weighed in")
+	}
+	return targets, nil
+}
+
+func (m *Monitor) setWeightInTargets(targets map[string]float64) error {
+	val, err := json.Marshal(targets)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the osds that are weighed in")
+	}
+	return client.SetConfigKey(m.context, m.clusterName, weightInConfigKey, string(val))
+}
+
+// getWeightInOSDs returns the ids of the new osds that are not weighed in yet
+func getWeightInOSDs(context *clusterd.Context, namespace string) (map[string]bool, error) {
+	newOSDs := map[string]bool{}
+	val, found, err := client.GetConfigKey(context, namespace, weightInNewOSDsKey)
+	if err != nil || !found {
+		return newOSDs, err
+	}
+	if err := json.Unmarshal([]byte(val), &newOSDs); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the new osds")
+	}
+	return newOSDs, nil
+}
+
+func setWeightInOSDs(context *clusterd.Context, namespace string, newOSDs map[string]bool) error {
+	val, err := json.Marshal(newOSDs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the new osds")
+	}
+	return client.SetConfigKey(context, namespace, weightInNewOSDsKey, string(val))
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// mockConfigKeyStore mocks the config-key commands with the given store
+func mockConfigKeyStore(store map[string]string, args []string) (string, bool, error) {
+	switch {
+	case args[0] == "config-key" && args[1] == "dump":
+		keys := map[string]string{}
+		if val, ok := store[args[2]]; ok {
+			keys[args[2]] = val
+		}
+		output, _ := json.Marshal(keys)
+		return string(output), true, nil
+	case args[0] == "config-key" && args[1] == "set" && args[3] == "-i":
+		val, err := ioutil.ReadFile(args[4])
+		if err != nil {
+			return "", true, err
+		}
+		store[args[2]] = string(val)
+		return "", true, nil
+	}
+	return "", false, nil
+}
+
+func TestSetWeightInConfig(t *testing.T) {
+	store := map[string]string{}
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if output, ok, err := mockConfigKeyStore(store, args); ok {
+			return output, err
+		}
+		if args[0] == "config" {
+			commands = append(commands, strings.Join(args[:4], " "))
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the initial weight set by the user is not removed
+	require.NoError(t, SetWeightInConfig(context, "ns", nil))
+	assert.Nil(t, commands)
+
+	require.NoError(t, SetWeightInConfig(context, "ns", &cephv1.OSDWeightInSpec{Step: 0.2, Interval: "10m"}))
+	assert.Equal(t, []string{"config set osd osd_crush_initial_weight"}, commands)
+
+	// the initial weight set by the operator is removed once
+	commands = nil
+	require.NoError(t, SetWeightInConfig(context, "ns", nil))
+	assert.Equal(t, []string{"config rm osd osd_crush_initial_weight"}, commands)
+	commands = nil
+	require.NoError(t, SetWeightInConfig(context, "ns", nil))
+	assert.Nil(t, commands)
+
+	// invalid settings are not applied
+	commands = nil
+	assert.Error(t, SetWeightInConfig(context, "ns", &cephv1.OSDWeightInSpec{Step: 1.5}))
+	assert.Error(t, SetWeightInConfig(context, "ns", &cephv1.OSDWeightInSpec{Interval: "-1m"}))
+	assert.Error(t, SetWeightInConfig(context, "ns", &cephv1.OSDWeightInSpec{Interval: "soon"}))
+	assert.Nil(t, commands)
+}
+
+func TestWeighInOSDs(t *testing.T) {
+	store := map[string]string{}
+	crushWeight := "0"
+	osdIn := 1
+	pgState := "active+clean"
+	var reweights []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "osd" && args[1] == "df":
+			// osd.1 is 2 TiB
+			return `{"nodes":[{"id":1,"crush_weight":` + crushWeight + `,"kb":2147483648}]}`, nil
+		case args[0] == "osd" && args[1] == "dump":
+			return `{"osds":[{"osd":1,"up":1,"in":` + strconv.Itoa(osdIn) + `}]}`, nil
+		case args[0] == "status":
+			return `{"pgmap":{"num_pgs":1,"pgs_by_state":[{"state_name":"` + pgState + `","count":1}]}}`, nil
+		case args[0] == "config-key":
+			output, _, err := mockConfigKeyStore(store, args)
+			return output, err
+		case args[0] == "osd" && args[1] == "crush" && args[2] == "reweight":
+			reweights = append(reweights, args[3]+" "+args[4])
+			crushWeight = args[4]
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	m := NewMonitor(&clusterd.Context{Executor: executor}, "ns", false, cephver.Nautilus, cephv1.KeyManagementServiceSpec{}, metav1.OwnerReference{})
+
+	// nothing is weighed in without the setting
+	require.NoError(t, m.weighInOSDs())
+	assert.Nil(t, reweights)
+
+	// an osd with a weight of 0 that was not started by the operator is not weighed in
+	m.UpdateWeightIn(&cephv1.OSDWeightInSpec{Step: 0.5, Interval: "1h"})
+	require.NoError(t, m.weighInOSDs())
+	assert.Nil(t, reweights)
+
+	// the new osd is weighed in by one step
+	require.NoError(t, addWeightInOSDs(m.context, "ns", []int{1}))
+	require.NoError(t, m.weighInOSDs())
+	assert.Equal(t, []string{"osd.1 1.00000"}, reweights)
+	assert.Equal(t, `{"1":2}`, store[weightInConfigKey])
+	assert.Equal(t, `{}`, store[weightInNewOSDsKey])
+
+	// the next step waits for the interval
+	reweights = nil
+	require.NoError(t, m.weighInOSDs())
+	assert.Nil(t, reweights)
+
+	// the next step waits for the pgs to be clean
+	m.lastWeightInStep = time.Time{}
+	pgState = "active+remapped+backfilling"
+	require.NoError(t, m.weighInOSDs())
+	assert.Nil(t, reweights)
+
+	// the osd reaches its full weight and is not weighed in anymore
+	pgState = "active+clean"
+	require.NoError(t, m.weighInOSDs())
+	assert.Equal(t, []string{"osd.1 2.00000"}, reweights)
+	assert.Equal(t, `{}`, store[weightInConfigKey])
+
+	// an osd that is out is not weighed in until it is in
+	reweights = nil
+	crushWeight = "0"
+	osdIn = 0
+	m.lastWeightInStep = time.Time{}
+	require.NoError(t, addWeightInOSDs(m.context, "ns", []int{1}))
+	require.NoError(t, m.weighInOSDs())
+	assert.Nil(t, reweights)
+	assert.Equal(t, `{"1":true}`, store[weightInNewOSDsKey])
+
+	// the osd is set to its full weight when the weight-in is disabled
+	osdIn = 1
+	require.NoError(t, m.weighInOSDs())
+	assert.Equal(t, []string{"osd.1 1.00000"}, reweights)
+	reweights = nil
+	m.UpdateWeightIn(nil)
+	require.NoError(t, m.weighInOSDs())
+	assert.Equal(t, []string{"osd.1 2.00000"}, reweights)
+	assert.Equal(t, `{}`, store[weightInConfigKey])
+	reweights = nil
+	require.NoError(t, m.weighInOSDs())
+	assert.Nil(t, reweights)
+}
@@ -20,6 +20,8 @@ limitations under the License.
 package keyring
 
 import (
+	"strconv"
+
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
@@ -36,8 +38,32 @@ var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-cfg-keyring")
 const (
 	keyKeyName      = "key"
 	keyringFileName = "keyring"
+	// KeyGenerationAnnotation records the generation of the key stored in a keyring secret
+	KeyGenerationAnnotation = "ceph.rook.io/key-generation"
 )
 
+// CapsMismatchPolicy is the action taken when the caps of an existing Ceph user don't match the desired caps
+type CapsMismatchPolicy string
+
+const (
+	// CapsMismatchUpdate updates the caps of the existing user to the desired caps
+	CapsMismatchUpdate CapsMismatchPolicy = "update"
+	// CapsMismatchWarn only logs a warning when the caps of the existing user don't match
+	CapsMismatchWarn CapsMismatchPolicy = "warn"
+	// CapsMismatchIgnore keeps the caps of the existing user without verifying them
+	CapsMismatchIgnore CapsMismatchPolicy = "ignore"
+)
+
+// ValidateCapsMismatchPolicy returns an error if the policy is not a known policy. An empty policy is valid
+// and means the default policy of the caller is used.
+func ValidateCapsMismatchPolicy(policy string) error {
+	switch CapsMismatchPolicy(policy) {
+	case "", CapsMismatchUpdate, CapsMismatchWarn, CapsMismatchIgnore:
+		return nil
+	}
+	return errors.Errorf("invalid caps mismatch policy %q", policy)
+}
+
 // SecretStore is a helper to store Ceph daemon keyrings as Kubernetes secrets.
 type SecretStore struct {
 	context   *clusterd.Context
@@ -98,13 +124,107 @@ func (k *SecretStore) GenerateKey(user string, access []string) (string, error)
 	return key, nil
 }
 
+// RotateKey replaces the key of a Ceph user with a new key with the given access permissions. It returns
+// the new key on success. The daemons using the previous key can no longer authenticate and must be
+// restarted with the new key.
+func (k *SecretStore) RotateKey(user string, access []string) (string, error) {
+	logger.Infof("rotating the key of %q", user)
+	if err := client.AuthDelete(k.context, k.namespace, user); err != nil {
+		return "", errors.Wrapf(err, "failed to delete the previous key of %q", user)
+	}
+	key, err := client.AuthGetOrCreateKey(k.context, k.namespace, user, access)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create a new key for %q", user)
+	}
+	return key, nil
+}
+
+// KeyGeneration returns the generation of the key stored in the keyring secret for the resource and
+// whether the secret exists. Keys stored before their generation was recorded are at generation 0.
+func (k *SecretStore) KeyGeneration(resourceName string) (int, bool, error) {
+	secretName := keyringSecretName(resourceName)
+	secret, err := k.context.Clientset.CoreV1().Secrets(k.namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return 0, false, nil
+		}
+		return 0, false, errors.Wrapf(err, "failed to get secret for %s", secretName)
+	}
+	value, ok := secret.Annotations[KeyGenerationAnnotation]
+	if !ok {
+		return 0, true, nil
+	}
+	generation, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, true, errors.Wrapf(err, "invalid key generation %q in secret %s", value, secretName)
+	}
+	return generation, true, nil
+}
+
+// VerifyCaps compares the caps of an existing user with the desired access permissions. Get-or-create
+// returns the key of an existing user even if its caps are different, so the caps must be verified to
+// detect a privilege drift. The mismatch is handled according to the policy.
+func (k *SecretStore) VerifyCaps(user string, access []string, policy CapsMismatchPolicy) error {
+	if policy == CapsMismatchIgnore {
+		return nil
+	}
+	if len(access)%2 != 0 {
+		return errors.Errorf("invalid access for %q. expected pairs of entity and caps: %v", user, access)
+	}
+
+	desired := map[string]string{}
+	for i := 0; i < len(access); i += 2 {
+		desired[access[i]] = access[i+1]
+	}
+	current, err := client.AuthGetCaps(k.context, k.namespace, user)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the caps of %q", user)
+	}
+	if capsEqual(desired, current) {
+		return nil
+	}
+
+	if policy == CapsMismatchWarn {
+		logger.Warningf("the caps of %q are %v but %v are expected", user, current, desired)
+		return nil
+	}
+	logger.Infof("updating the caps of %q from %v to %v", user, current, desired)
+	if err := client.AuthUpdateCaps(k.context, k.namespace, user, access); err != nil {
+		return errors.Wrapf(err, "failed to update the caps of %q", user)
+	}
+	return nil
+}
+
+func capsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for entity, caps := range a {
+		if b[entity] != caps {
+			return false
+		}
+	}
+	return true
+}
+
 // CreateOrUpdate creates or updates the keyring secret for the resource with the keyring specified.
 // WARNING: Do not use "rook-ceph-admin" as the resource name; conflicts with the AdminStore.
 func (k *SecretStore) CreateOrUpdate(resourceName string, keyring string) error {
+	return k.createOrUpdate(resourceName, keyring, nil)
+}
+
+// CreateOrUpdateWithGeneration creates or updates the keyring secret for the resource and records the
+// generation of the key in the secret.
+func (k *SecretStore) CreateOrUpdateWithGeneration(resourceName, keyring string, generation int) error {
+	return k.createOrUpdate(resourceName, keyring, map[string]string{KeyGenerationAnnotation: strconv.Itoa(generation)})
+}
+
+func (k *SecretStore) createOrUpdate(resourceName, keyring string, annotations map[string]string) error {
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      keyringSecretName(resourceName),
-			Namespace: k.namespace,
+			Name:        keyringSecretName(resourceName),
+			Namespace:   k.namespace,
+			Annotations: annotations,
 		},
 		StringData: map[string]string{
 			keyringFileName: keyring,
@@ -130,7 +250,7 @@ func (k *SecretStore) Delete(resourceName string) error {
 // CreateSecret creates or update a kubernetes secret
 func (k *SecretStore) CreateSecret(secret *v1.Secret) error {
 	secretName := secret.ObjectMeta.Name
-	_, err := k.context.Clientset.CoreV1().Secrets(k.namespace).Get(secretName, metav1.GetOptions{})
+	existing, err := k.context.Clientset.CoreV1().Secrets(k.namespace).Get(secretName, metav1.GetOptions{})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debugf("creating secret for %s", secretName)
@@ -142,6 +262,16 @@ func (k *SecretStore) CreateSecret(secret *v1.Secret) error {
 		return errors.Wrapf(err, "failed to get secret for %s", secretName)
 	}
 
+	// keep the generation of the key unless a new generation is given
+	if generation, ok := existing.Annotations[KeyGenerationAnnotation]; ok {
+		if _, ok := secret.Annotations[KeyGenerationAnnotation]; !ok {
+			if secret.Annotations == nil {
+				secret.Annotations = map[string]string{}
+			}
+			secret.Annotations[KeyGenerationAnnotation] = generation
+		}
+	}
+
 	logger.Debugf("updating secret for %s", secretName)
 	if _, err := k.context.Clientset.CoreV1().Secrets(k.namespace).Update(secret); err != nil {
 		return errors.Wrapf(err, "failed to update secret for %s", secretName)
@@ -69,6 +69,104 @@ func TestGenerateKey(t *testing.T) {
 	assert.Error(t, e)
 }
 
+func TestVerifyCaps(t *testing.T) {
+	currentCaps := `[{"entity":"mgr.a","key":"mysecurekey","caps":{"mon":"allow *"}}]`
+	capsUpdated := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "get" {
+				return currentCaps, nil
+			}
+			if args[0] == "auth" && args[1] == "caps" {
+				capsUpdated = append(capsUpdated, args[2:7]...)
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected command %v", args)
+		},
+	}
+	ctx := &clusterd.Context{
+		Clientset: testop.New(1),
+		Executor:  executor,
+	}
+	owner := metav1.OwnerReference{}
+	s := GetSecretStore(ctx, "rook-ceph", &owner)
+	access := []string{"mon", "allow profile mgr", "osd", "allow *"}
+
+	// mismatched caps are updated
+	assert.NoError(t, s.VerifyCaps("mgr.a", access, CapsMismatchUpdate))
+	assert.Equal(t, []string{"mgr.a", "mon", "allow profile mgr", "osd", "allow *"}, capsUpdated)
+
+	// mismatched caps are only reported
+	capsUpdated = []string{}
+	assert.NoError(t, s.VerifyCaps("mgr.a", access, CapsMismatchWarn))
+	assert.Equal(t, 0, len(capsUpdated))
+
+	// caps are not verified
+	assert.NoError(t, s.VerifyCaps("mgr.a", []string{"invalid"}, CapsMismatchIgnore))
+
+	// matching caps are not updated
+	currentCaps = `[{"entity":"mgr.a","key":"mysecurekey","caps":{"mon":"allow profile mgr","osd":"allow *"}}]`
+	assert.NoError(t, s.VerifyCaps("mgr.a", access, CapsMismatchUpdate))
+	assert.Equal(t, 0, len(capsUpdated))
+
+	// invalid access
+	assert.Error(t, s.VerifyCaps("mgr.a", []string{"mon"}, CapsMismatchUpdate))
+
+	assert.NoError(t, ValidateCapsMismatchPolicy(""))
+	assert.NoError(t, ValidateCapsMismatchPolicy("warn"))
+	assert.Error(t, ValidateCapsMismatchPolicy("fix"))
+}
+
+func TestKeyRotation(t *testing.T) {
+	commands := [][]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			commands = append(commands, args[0:3])
+			if args[0] == "auth" && args[1] == "get-or-create-key" {
+				return `{"key":"newkey"}`, nil
+			}
+			return "", nil
+		},
+	}
+	ctx := &clusterd.Context{
+		Clientset: testop.New(1),
+		Executor:  executor,
+	}
+	owner := metav1.OwnerReference{}
+	k := GetSecretStore(ctx, "rook-ceph", &owner)
+
+	// the previous key is deleted before a new one is created
+	key, err := k.RotateKey("mgr.a", []string{"mon", "allow profile mgr"})
+	assert.NoError(t, err)
+	assert.Equal(t, "newkey", key)
+	assert.Equal(t, [][]string{{"auth", "del", "mgr.a"}, {"auth", "get-or-create-key", "mgr.a"}}, commands)
+
+	// the secret does not exist
+	generation, exists, err := k.KeyGeneration("rook-ceph-mgr-a")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, 0, generation)
+
+	// the generation of a key stored without a generation is 0
+	assert.NoError(t, k.CreateOrUpdate("rook-ceph-mgr-a", "oldkeyring"))
+	generation, exists, err = k.KeyGeneration("rook-ceph-mgr-a")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 0, generation)
+
+	// the generation is recorded
+	assert.NoError(t, k.CreateOrUpdateWithGeneration("rook-ceph-mgr-a", "newkeyring", 2))
+	generation, _, err = k.KeyGeneration("rook-ceph-mgr-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, generation)
+
+	// the generation is kept when the keyring is updated without a generation
+	assert.NoError(t, k.CreateOrUpdate("rook-ceph-mgr-a", "newkeyring"))
+	generation, _, err = k.KeyGeneration("rook-ceph-mgr-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, generation)
+}
+
 func TestKeyringStore(t *testing.T) {
 	clientset := testop.New(1)
 	ctx := &clusterd.Context{
@@ -62,6 +62,17 @@ func (m *MonStore) Set(who, option, value string) error {
 	return nil
 }
 
+// Delete removes a config from the centralized mon configuration database.
+func (m *MonStore) Delete(who, option string) error {
+	args := []string{"config", "rm", who, normalizeKey(option)}
+	cephCmd := client.NewCephCommand(m.context, m.namespace, args)
+	out, err := cephCmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete ceph config in the centralized mon configuration database. output: %s", string(out))
+	}
+	return nil
+}
+
 // SetAll sets all configs from the overrides in the centralized mon configuration database.
 // See MonStore.Set for more.
 func (m *MonStore) SetAll(options ...Option) error {
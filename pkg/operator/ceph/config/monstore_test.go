@@ -71,6 +71,38 @@ func TestMonStore_Set(t *testing.T) {
 	assert.Contains(t, execedCmd, " config set mon.* unknown_setting 10 ")
 }
 
+func TestMonStore_Delete(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	ctx := &clusterd.Context{
+		Clientset: testop.New(1),
+		Executor:  executor,
+	}
+
+	execedCmd := ""
+	execInjectErr := false
+	executor.MockExecuteCommandWithOutputFile =
+		func(debug bool, actionName string, command string, outfile string, args ...string) (string, error) {
+			execedCmd = command + " " + strings.Join(args, " ")
+			if execInjectErr {
+				return "output from cmd with error", errors.New("mocked error")
+			}
+			return "", nil
+		}
+
+	monStore := GetMonStore(ctx, "ns")
+
+	// deleting with spaces converts to underscores
+	e := monStore.Delete("mgr", "public network")
+	assert.NoError(t, e)
+	assert.Contains(t, execedCmd, " config rm mgr public_network ")
+
+	// errors returned as expected
+	execInjectErr = true
+	e = monStore.Delete("mgr", "cluster_network")
+	assert.Error(t, e)
+	assert.Contains(t, execedCmd, " config rm mgr cluster_network ")
+}
+
 func TestMonStore_SetAll(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	ctx := &clusterd.Context{
@@ -76,8 +76,8 @@ func (s *Store) CreateOrUpdate(clusterInfo *cephconfig.ClusterInfo) error {
 	return nil
 }
 
-// update "mon_host" and "mon_initial_members" in the stored config
-func (s *Store) createOrUpdateMonHostSecrets(clusterInfo *cephconfig.ClusterInfo) error {
+// MonHostAndMembers returns the "mon_host" and "mon_initial_members" config values of the mons in the cluster info
+func MonHostAndMembers(clusterInfo *cephconfig.ClusterInfo) (string, string) {
 	hosts := make([]string, len(clusterInfo.Monitors))
 	members := make([]string, len(clusterInfo.Monitors))
 	i := 0
@@ -107,6 +107,12 @@ func (s *Store) createOrUpdateMonHostSecrets(clusterInfo *cephconfig.ClusterInfo
 		members[i] = m.Name
 		i++
 	}
+	return strings.Join(hosts, ","), strings.Join(members, ",")
+}
+
+// update "mon_host" and "mon_initial_members" in the stored config
+func (s *Store) createOrUpdateMonHostSecrets(clusterInfo *cephconfig.ClusterInfo) error {
+	hosts, members := MonHostAndMembers(clusterInfo)
 
 	// store these in a secret instead of the configmap; secrets are required by CSI drivers
 	secret := &v1.Secret{
@@ -116,8 +122,8 @@ func (s *Store) createOrUpdateMonHostSecrets(clusterInfo *cephconfig.ClusterInfo
 			Namespace: s.namespace,
 		},
 		StringData: map[string]string{
-			monHostKey:           strings.Join(hosts, ","),
-			monInitialMembersKey: strings.Join(members, ","),
+			monHostKey:           hosts,
+			monInitialMembersKey: members,
 		},
 		Type: k8sutil.RookType,
 	}
@@ -17,8 +17,6 @@ limitations under the License.
 package clusterdisruption
 
 import (
-	"math"
-
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
@@ -33,39 +31,49 @@ const (
 	pdbName = "rook-ceph-mon-pdb"
 )
 
-// setting minAvailable for mons at floor((n+1)/2) an logging and error if n is even
-// monCount - minAvailable
-// 1,2      - 0 (no HA)
-// even     - n -1
-// odd      - floor((n+1)/2)
+// at most one mon is disrupted at a time, so the mons keep quorum with one more mon failing during a drain.
+// no budget is created for 1 or 2 mons since the quorum is lost with any mon down.
 func (r *ReconcileClusterDisruption) reconcileMonPDB(cephCluster *cephv1.CephCluster) error {
-	monCount := cephCluster.Spec.Mon.Count
-	minAvailable := int32(math.Floor(float64((monCount + 1) / 2)))
-	if monCount%2 == 0 {
-		logger.Error("mon count should be an odd number, setting effective maxUnvailable to 1")
-		minAvailable = int32(monCount - 1)
+	namespace := cephCluster.ObjectMeta.Namespace
+	pdbRequest := types.NamespacedName{Name: pdbName, Namespace: namespace}
+	pdb := makeMonPDB(cephCluster)
+	if pdb == nil {
+		logger.Warningf("managePodBudgets is set, but mon count %d <= 2. Not creating a disruptionbudget for Mons", cephCluster.Spec.Mon.Count)
+		err := r.deleteStaticPDB(pdbRequest)
+		if err != nil {
+			return errors.Wrapf(err, "could not delete mon pdb")
+		}
+		return nil
+	}
+	err := r.reconcileStaticPDB(pdbRequest, pdb)
+	if err != nil {
+		return errors.Wrapf(err, "could not reconcile mon pdb")
 	}
-	if monCount <= 2 {
-		logger.Error("managePodBudgets is set, but mon-count <= 2. Not creating a disruptionbudget for Mons")
+	return nil
+}
+
+func makeMonPDB(cephCluster *cephv1.CephCluster) *policyv1beta1.PodDisruptionBudget {
+	if cephCluster.Spec.Mon.Count <= 2 {
 		return nil
 	}
-	namespace := cephCluster.ObjectMeta.Namespace
-	pdbRequest := types.NamespacedName{Name: pdbName, Namespace: namespace}
-	pdb := &policyv1beta1.PodDisruptionBudget{
+	return &policyv1beta1.PodDisruptionBudget{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pdbName,
-			Namespace: namespace,
+			Namespace: cephCluster.ObjectMeta.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: cephCluster.APIVersion,
+					Kind:       cephCluster.Kind,
+					Name:       cephCluster.ObjectMeta.GetName(),
+					UID:        cephCluster.GetUID(),
+				},
+			},
 		},
 		Spec: policyv1beta1.PodDisruptionBudgetSpec{
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{k8sutil.AppAttr: mon.AppName},
 			},
-			MinAvailable: &intstr.IntOrString{IntVal: minAvailable},
+			MaxUnavailable: &intstr.IntOrString{IntVal: 1},
 		},
 	}
-	err := r.reconcileStaticPDB(pdbRequest, pdb)
-	if err != nil {
-		return errors.Wrapf(err, "could not reconcile mon pdb")
-	}
-	return nil
 }
@@ -0,0 +1,46 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterdisruption
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMakeMonPDB(t *testing.T) {
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"}}
+
+	// no budget is needed without mon redundancy
+	for _, count := range []int{1, 2} {
+		cephCluster.Spec.Mon.Count = count
+		assert.Nil(t, makeMonPDB(cephCluster))
+	}
+
+	// a single mon can be disrupted whatever the mon count
+	for _, count := range []int{3, 4, 5} {
+		cephCluster.Spec.Mon.Count = count
+		pdb := makeMonPDB(cephCluster)
+		assert.NotNil(t, pdb)
+		assert.Equal(t, int32(1), pdb.Spec.MaxUnavailable.IntVal)
+		assert.Nil(t, pdb.Spec.MinAvailable)
+		assert.Equal(t, map[string]string{"app": "rook-ceph-mon"}, pdb.Spec.Selector.MatchLabels)
+		assert.Equal(t, "rook-ceph", pdb.OwnerReferences[0].Name)
+	}
+}
@@ -194,8 +194,11 @@ func (r *ReconcileClusterDisruption) reconcilePDBsForOSDs(
 	}
 	if shouldChange {
 		if activeDrains {
-			pdbStateMap.Data[disabledPDBKey] = drainingFailureDomains[0]
-			pdbStateMap.Data[disabledPDBTimeKey] = time.Now().Format(time.RFC3339)
+			disabledFailureDomain := getDisabledFailureDomain(pdbStateMap.Data[disabledPDBKey], drainingFailureDomains)
+			if disabledFailureDomain != pdbStateMap.Data[disabledPDBKey] {
+				pdbStateMap.Data[disabledPDBKey] = disabledFailureDomain
+				pdbStateMap.Data[disabledPDBTimeKey] = time.Now().Format(time.RFC3339)
+			}
 		} else {
 			pdbStateMap.Data[disabledPDBKey] = ""
 			delete(pdbStateMap.Data, disabledPDBTimeKey)
@@ -249,6 +252,17 @@ func (r *ReconcileClusterDisruption) reconcilePDBsForOSDs(
 	return nil
 }
 
+// getDisabledFailureDomain returns the failure domain whose OSDs may be drained. A failure domain that is
+// still draining keeps its PDBs disabled so that its drain is not blocked halfway by a drain in another one.
+func getDisabledFailureDomain(disabledFailureDomain string, drainingFailureDomains []string) string {
+	for _, failureDomain := range drainingFailureDomains {
+		if failureDomain == disabledFailureDomain {
+			return disabledFailureDomain
+		}
+	}
+	return drainingFailureDomains[0]
+}
+
 func (r *ReconcileClusterDisruption) updateNoout(pdbStateMap *corev1.ConfigMap, allFailureDomainsMap map[string][]OsdData) error {
 	disabledFailureDomain := pdbStateMap.Data[disabledPDBKey]
 	namespace := pdbStateMap.ObjectMeta.Namespace
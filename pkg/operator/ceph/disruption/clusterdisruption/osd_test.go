@@ -0,0 +1,34 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterdisruption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDisabledFailureDomain(t *testing.T) {
+	// the first draining failure domain is disabled when none is
+	assert.Equal(t, "zone-a", getDisabledFailureDomain("", []string{"zone-a", "zone-b"}))
+
+	// the failure domain that is still draining stays disabled
+	assert.Equal(t, "zone-b", getDisabledFailureDomain("zone-b", []string{"zone-a", "zone-b"}))
+
+	// another failure domain is disabled when the drain of the disabled one is done
+	assert.Equal(t, "zone-a", getDisabledFailureDomain("zone-c", []string{"zone-a", "zone-b"}))
+}
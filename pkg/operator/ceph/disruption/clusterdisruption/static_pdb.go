@@ -18,6 +18,7 @@ package clusterdisruption
 
 import (
 	"context"
+	"reflect"
 
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -48,11 +49,32 @@ func (r *ReconcileClusterDisruption) updateStaticPDB(request types.NamespacedNam
 }
 
 func (r *ReconcileClusterDisruption) reconcileStaticPDB(request types.NamespacedName, pdb *policyv1beta1.PodDisruptionBudget) error {
-	err := r.client.Get(context.TODO(), request, pdb)
+	existing := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(context.TODO(), request, existing)
 	if errors.IsNotFound(err) {
 		return r.createStaticPDB(request, pdb)
 	} else if err != nil {
 		return err
 	}
+	if !reflect.DeepEqual(existing.Spec, pdb.Spec) {
+		logger.Infof("updating pdb %q", request)
+		return r.updateStaticPDB(request, pdb)
+	}
+	return nil
+}
+
+func (r *ReconcileClusterDisruption) deleteStaticPDB(request types.NamespacedName) error {
+	existing := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(context.TODO(), request, existing)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	logger.Infof("deleting pdb %q", request)
+	err = r.client.Delete(context.TODO(), existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
 	return nil
 }
@@ -29,6 +29,14 @@ type Context struct {
 	Context     *clusterd.Context
 	Name        string
 	ClusterName string
+	// The multisite realm, zone group and zone of the admin commands. The realm and zone group are named after the
+	// store when not set.
+	Realm     string
+	ZoneGroup string
+	Zone      string
+	// Whether the admin commands run in the default realm of an external cluster, for the stores served by external
+	// gateways
+	DefaultRealm bool
 }
 
 // NewContext creates a new object store context.
@@ -37,10 +45,16 @@ func NewContext(context *clusterd.Context, name, clusterName string) *Context {
 }
 
 func runAdminCommandNoRealm(c *Context, args ...string) (string, error) {
+	return runAdminCommandWithDebug(c, false, args...)
+}
+
+// runAdminCommandWithDebug runs the radosgw-admin command and only logs it at the debug level if debug is set, for
+// the commands with secrets in their arguments
+func runAdminCommandWithDebug(c *Context, debug bool, args ...string) (string, error) {
 	command, args := client.FinalizeCephCommandArgs("radosgw-admin", args, c.Context.ConfigDir, c.ClusterName)
 
 	// start the rgw admin command
-	output, err := c.Context.Executor.ExecuteCommandWithOutput(false, "", command, args...)
+	output, err := c.Context.Executor.ExecuteCommandWithOutput(debug, "", command, args...)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to run radosgw-admin")
 	}
@@ -49,9 +63,19 @@ func runAdminCommandNoRealm(c *Context, args ...string) (string, error) {
 }
 
 func runAdminCommand(c *Context, args ...string) (string, error) {
+	if c.DefaultRealm {
+		return runAdminCommandNoRealm(c, args...)
+	}
+	realm, zoneGroup := c.Name, c.Name
+	if c.Realm != "" {
+		realm, zoneGroup = c.Realm, c.ZoneGroup
+	}
 	options := []string{
-		fmt.Sprintf("--rgw-realm=%s", c.Name),
-		fmt.Sprintf("--rgw-zonegroup=%s", c.Name),
+		fmt.Sprintf("--rgw-realm=%s", realm),
+		fmt.Sprintf("--rgw-zonegroup=%s", zoneGroup),
+	}
+	if c.Zone != "" {
+		options = append(options, fmt.Sprintf("--rgw-zone=%s", c.Zone))
 	}
 	return runAdminCommandNoRealm(c, append(args, options...)...)
 }
@@ -38,7 +38,10 @@ type Provisioner struct {
 	claimClientset  claimClient.Interface
 	storeDomainName string
 	storePort       int32
-	region          string
+	// whether the gateways are only reached on their secure port, and the CA of their certificate
+	storeTLS    bool
+	storeCACert []byte
+	region      string
 	// the namespace where the rook cluster should respond to events
 	namespace string
 	// access keys for acct for the bucket *owner*
@@ -78,7 +81,7 @@ func (p Provisioner) Provision(options *apibkt.BucketOptions) (*bktv1alpha1.Obje
 		return nil, errors.Wrapf(err, "Provision: can't create ceph user")
 	}
 
-	s3svc, err := NewS3Agent(p.accessKeyID, p.secretAccessKey, p.getObjectStoreEndpoint())
+	s3svc, err := NewS3Agent(p.accessKeyID, p.secretAccessKey, p.getObjectStoreEndpoint(), p.storeTLS, p.storeCACert)
 
 	if err != nil {
 		return nil, err
@@ -139,7 +142,7 @@ func (p Provisioner) Grant(options *apibkt.BucketOptions) (*bktv1alpha1.ObjectBu
 		return nil, errors.Wrapf(err, "could not get user (user: %s)", stats.Owner)
 	}
 
-	s3svc, err := NewS3Agent(*objectUser.AccessKey, *objectUser.SecretKey, p.getObjectStoreEndpoint())
+	s3svc, err := NewS3Agent(*objectUser.AccessKey, *objectUser.SecretKey, p.getObjectStoreEndpoint(), p.storeTLS, p.storeCACert)
 	if err != nil {
 		return nil, err
 	}
@@ -230,7 +233,7 @@ func (p Provisioner) Revoke(ob *bktv1alpha1.ObjectBucket) error {
 		return errors.Errorf("querying user %q returned nil", p.cephUserName)
 	}
 
-	s3svc, err := NewS3Agent(*user.AccessKey, *user.SecretKey, p.getObjectStoreEndpoint())
+	s3svc, err := NewS3Agent(*user.AccessKey, *user.SecretKey, p.getObjectStoreEndpoint(), p.storeTLS, p.storeCACert)
 	if err != nil {
 		return err
 	}
@@ -327,6 +330,9 @@ func (p *Provisioner) initializeDeleteOrRevoke(ob *bktv1alpha1.ObjectBucket) err
 	if err = p.setObjectStoreDomainName(sc); err != nil {
 		return err
 	}
+	if err = p.setObjectStorePort(sc); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -340,7 +346,7 @@ func (p *Provisioner) composeObjectBucket() *bktv1alpha1.ObjectBucket {
 			BucketPort: int(p.storePort),
 			BucketName: p.bucketName,
 			Region:     p.region,
-			SSL:        false,
+			SSL:        p.storeTLS,
 		},
 		Authentication: &bktv1alpha1.Authentication{
 			AccessKeys: &bktv1alpha1.AccessKeys{
@@ -367,7 +373,11 @@ func (p *Provisioner) setObjectContext() error {
 	} else if p.objectStoreNamespace == "" {
 		return errors.Errorf(msg, "namespace")
 	}
-	p.objectContext = cephObject.NewContext(p.context, p.objectStoreName, p.objectStoreNamespace)
+	objectContext, err := cephObject.NewStoreContext(p.context, p.objectStoreName, p.objectStoreNamespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build the context of object store %q", p.objectStoreName)
+	}
+	p.objectContext = objectContext
 	return nil
 }
 
@@ -387,16 +397,13 @@ func (p *Provisioner) setObjectStoreDomainName(sc *storagev1.StorageClass) error
 }
 
 func (p *Provisioner) setObjectStorePort(sc *storagev1.StorageClass) error {
-	name := getObjectStoreName(sc)
-	name = fmt.Sprintf("%s-%s", prefixObjectStoreSvc, name)
-	namespace := getObjectStoreNameSpace(sc)
-	// also ensure the service exists and get the appropriate clusterIP port
-	svc, err := getService(p.context.Clientset, namespace, name)
+	store, err := getObjectStore(p.context.RookClientset.CephV1(), getObjectStoreNameSpace(sc), getObjectStoreName(sc))
 	if err != nil {
 		return err
 	}
-	p.storePort = svc.Spec.Ports[0].Port
-	return nil
+	// also ensure the service exists and get the appropriate clusterIP port
+	p.storePort, p.storeTLS, p.storeCACert, err = cephObject.GetStoreServicePort(p.context, store)
+	return err
 }
 
 func (p *Provisioner) setObjectStoreName(sc *storagev1.StorageClass) {
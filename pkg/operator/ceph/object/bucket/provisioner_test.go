@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephObject "github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectStoreEndpoint(t *testing.T) {
+	clientset := test.New(1)
+	store := &cephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"},
+		Spec:       cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{SSLCertificateRef: "rgw-cert"}},
+	}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(store)}
+	p := NewProvisioner(context, "rook-ceph")
+	sc := &storagev1.StorageClass{Parameters: map[string]string{objectStoreName: "my-store", objectStoreNamespace: "rook-ceph"}}
+
+	// the service of the store must exist
+	assert.Error(t, p.setObjectStorePort(sc))
+
+	// the insecure port is used when the gateways listen on both ports
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-rgw-my-store", Namespace: "rook-ceph"},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}, {Name: "https", Port: 443}}},
+	}
+	_, err := clientset.CoreV1().Services("rook-ceph").Create(svc)
+	require.NoError(t, err)
+	p.setObjectStoreNamespace(sc)
+	p.setObjectStoreName(sc)
+	require.NoError(t, p.setObjectStoreDomainName(sc))
+	require.NoError(t, p.setObjectStorePort(sc))
+	assert.Equal(t, "rook-ceph-rgw-my-store.rook-ceph:80", p.getObjectStoreEndpoint())
+	assert.False(t, p.composeObjectBucket().Spec.Endpoint.SSL)
+
+	// the secure port is used with the CA of the ssl certificate when the gateways only listen on the secure port
+	svc.Spec.Ports = []v1.ServicePort{{Name: "https", Port: 443}}
+	_, err = clientset.CoreV1().Services("rook-ceph").Update(svc)
+	require.NoError(t, err)
+	assert.Error(t, p.setObjectStorePort(sc))
+	_, err = clientset.CoreV1().Secrets("rook-ceph").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rgw-cert", Namespace: "rook-ceph"},
+		Data:       map[string][]byte{"cert": []byte("mycert"), cephObject.CACertKeyName: []byte("myca")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, p.setObjectStorePort(sc))
+	assert.Equal(t, "rook-ceph-rgw-my-store.rook-ceph:443", p.getObjectStoreEndpoint())
+	assert.Equal(t, []byte("myca"), p.storeCACert)
+	assert.True(t, p.composeObjectBucket().Spec.Endpoint.SSL)
+
+	// the CA must be valid to connect to the gateways
+	_, err = NewS3Agent("access", "secret", p.getObjectStoreEndpoint(), p.storeTLS, p.storeCACert)
+	assert.Error(t, err)
+	_, err = NewS3Agent("access", "secret", p.getObjectStoreEndpoint(), p.storeTLS, nil)
+	assert.NoError(t, err)
+
+	// the certificate of the gateways must be trusted by the system when the store has no ssl certificate secret
+	store.Spec.Gateway.SSLCertificateRef = ""
+	_, err = context.RookClientset.CephV1().CephObjectStores("rook-ceph").Update(store)
+	require.NoError(t, err)
+	require.NoError(t, p.setObjectStorePort(sc))
+	assert.True(t, p.storeTLS)
+	assert.Nil(t, p.storeCACert)
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package bucket
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -34,17 +37,8 @@ type S3Agent struct {
 	client *s3.S3
 }
 
-func NewS3Agent(accessKey, secretKey, endpoint string) (*S3Agent, error) {
-	const cephRegion = "us-east-1"
-
-	sess, err := session.NewSession(
-		aws.NewConfig().
-			WithRegion(cephRegion).
-			WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, "")).
-			WithEndpoint(endpoint).
-			WithS3ForcePathStyle(true).
-			WithMaxRetries(20).
-			WithDisableSSL(true))
+func NewS3Agent(accessKey, secretKey, endpoint string, tlsEnabled bool, caCert []byte) (*S3Agent, error) {
+	sess, err := NewSession(accessKey, secretKey, endpoint, tlsEnabled, caCert)
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +48,29 @@ func NewS3Agent(accessKey, secretKey, endpoint string) (*S3Agent, error) {
 	}, nil
 }
 
+// NewSession returns an aws session to the gateways of an object store at the given endpoint
+func NewSession(accessKey, secretKey, endpoint string, tlsEnabled bool, caCert []byte) (*session.Session, error) {
+	const cephRegion = "us-east-1"
+
+	config := aws.NewConfig().
+		WithRegion(cephRegion).
+		WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, "")).
+		WithEndpoint(endpoint).
+		WithS3ForcePathStyle(true).
+		WithMaxRetries(20).
+		WithDisableSSL(!tlsEnabled)
+	if tlsEnabled && len(caCert) > 0 {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to parse the CA certificate of the object store")
+		}
+		config = config.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: caCertPool}},
+		})
+	}
+	return session.NewSession(config)
+}
+
 // CreateBucket creates a bucket with the given name
 func (s S3Agent) CreateBucket(name string) error {
 	logger.Infof("creating bucket %q", name)
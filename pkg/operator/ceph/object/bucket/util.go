@@ -28,7 +28,6 @@ import (
 	storagev1 "k8s.io/api/storage/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -106,18 +105,6 @@ func getObjectStore(c cephclientset.CephV1Interface, namespace, name string) (*c
 	return store, err
 }
 
-func getService(c kubernetes.Interface, namespace, name string) (*v1.Service, error) {
-	// Verify the object store's service actually exists
-	svc, err := c.CoreV1().Services(namespace).Get(name, metav1.GetOptions{})
-	if err != nil {
-		if kerrors.IsNotFound(err) {
-			return nil, errors.Wrapf(err, "cephObjectStore service not found")
-		}
-		return nil, errors.Wrapf(err, "error getting cephObjectStore service")
-	}
-	return svc, nil
-}
-
 func randomString(n int) string {
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -41,6 +41,11 @@ caps osd = "allow rwx"
 	certDir        = "/etc/ceph/private"
 	certKeyName    = "cert"
 	certFilename   = "rgw-cert.pem"
+	// the private key is optional in the secret when the cert also contains the key
+	certPrivateKeyName = "key"
+	certPrivateKeyFile = "rgw-key.pem"
+	// CACertKeyName is the optional key of the ssl secret with the CA that signed the cert of the gateways
+	CACertKeyName = "cacert"
 )
 
 var (
@@ -74,6 +79,9 @@ func (c *clusterConfig) portString(v cephver.CephVersion) string {
 				portString = fmt.Sprintf("ssl_port=%d ssl_certificate=%s",
 					c.store.Spec.Gateway.SecurePort, certPath)
 			}
+			if c.sslPrivateKey {
+				portString = fmt.Sprintf("%s ssl_private_key=%s", portString, path.Join(certDir, certPrivateKeyFile))
+			}
 		} else {
 			// This is civetweb config
 			// Config is http://docs.ceph.com/docs/master/radosgw/frontends/#id5
@@ -93,6 +101,29 @@ func (c *clusterConfig) portString(v cephver.CephVersion) string {
 	return portString
 }
 
+// validateSSLCertificate checks the secret with the ssl certificate of the gateways. The private key can be in the
+// cert or in its own key of the secret, which is only supported by the beast frontend.
+func (c *clusterConfig) validateSSLCertificate() error {
+	if c.store.Spec.Gateway.SecurePort == 0 || c.store.Spec.Gateway.SSLCertificateRef == "" {
+		if c.store.Spec.Gateway.SecurePort != 0 || c.store.Spec.Gateway.SSLCertificateRef != "" {
+			logger.Warningf("both the secure port and the ssl certificate ref are required for the gateways to listen on the secure port")
+		}
+		return nil
+	}
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.store.Namespace).Get(c.store.Spec.Gateway.SSLCertificateRef, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the ssl certificate secret %q", c.store.Spec.Gateway.SSLCertificateRef)
+	}
+	if len(secret.Data[certKeyName]) == 0 {
+		return errors.Errorf("the ssl certificate secret %q has no %q key", secret.Name, certKeyName)
+	}
+	_, c.sslPrivateKey = secret.Data[certPrivateKeyName]
+	if c.sslPrivateKey && !c.clusterInfo.CephVersion.IsAtLeastNautilus() {
+		return errors.Errorf("the private key must be in the %q key of the ssl certificate secret %q with civetweb", certKeyName, secret.Name)
+	}
+	return nil
+}
+
 func generateCephXUser(name string) string {
 	user := strings.TrimPrefix(name, AppName)
 	return "client.rgw" + strings.Replace(user, "-", ".", -1)
@@ -131,6 +162,15 @@ func (c *clusterConfig) setDefaultFlagsMonConfigStore(rgwName string) error {
 	configOptions["rgw_enable_usage_log"] = "true"
 	configOptions["rgw_zone"] = c.store.Name
 	configOptions["rgw_zonegroup"] = c.store.Name
+	if c.store.Spec.Zone.Name != "" {
+		zoneContext, err := GetZoneContext(c.context, c.store.Namespace, c.store.Spec.Zone.Name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the zone of object store %q", c.store.Name)
+		}
+		configOptions["rgw_realm"] = zoneContext.Realm
+		configOptions["rgw_zonegroup"] = zoneContext.ZoneGroup
+		configOptions["rgw_zone"] = zoneContext.Zone
+	}
 
 	for flag, val := range configOptions {
 		err := monStore.Set(who, flag, val)
@@ -20,9 +20,13 @@ import (
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testop "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func newConfig() *clusterConfig {
@@ -98,6 +102,11 @@ func TestPortString(t *testing.T) {
 	result = cfg.portString(cfg.clusterInfo.CephVersion)
 	assert.Equal(t, "port=80 ssl_port=443 ssl_certificate=/etc/ceph/private/rgw-cert.pem", result)
 
+	// Private key of the cert on beast
+	cfg.sslPrivateKey = true
+	result = cfg.portString(cfg.clusterInfo.CephVersion)
+	assert.Equal(t, "port=80 ssl_port=443 ssl_certificate=/etc/ceph/private/rgw-cert.pem ssl_private_key=/etc/ceph/private/rgw-key.pem", result)
+
 	// Secure port requires the cert on civetweb
 	cfg = newConfig()
 	cfg.clusterInfo.CephVersion = cephver.Mimic
@@ -113,6 +122,40 @@ func TestPortString(t *testing.T) {
 	assert.Equal(t, "", result)
 }
 
+func TestValidateSSLCertificate(t *testing.T) {
+	clientset := testop.New(1)
+	cfg := newConfig()
+	cfg.context = &clusterd.Context{Clientset: clientset}
+	cfg.store.Namespace = "ns"
+
+	// nothing to check without the secure port
+	assert.NoError(t, cfg.validateSSLCertificate())
+
+	// the secret must exist with the cert
+	cfg.store.Spec.Gateway.SecurePort = 443
+	cfg.store.Spec.Gateway.SSLCertificateRef = "rgw-cert"
+	assert.Error(t, cfg.validateSSLCertificate())
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "rgw-cert", Namespace: "ns"}, Data: map[string][]byte{"cacert": []byte("ca")}}
+	_, err := clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.NoError(t, err)
+	assert.Error(t, cfg.validateSSLCertificate())
+
+	secret.Data[certKeyName] = []byte("certandkey")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.validateSSLCertificate())
+	assert.False(t, cfg.sslPrivateKey)
+
+	// the private key in its own key of the secret is only supported by beast
+	secret.Data[certPrivateKeyName] = []byte("key")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	assert.Error(t, cfg.validateSSLCertificate())
+	cfg.clusterInfo.CephVersion = cephver.Nautilus
+	assert.NoError(t, cfg.validateSSLCertificate())
+	assert.True(t, cfg.sslPrivateKey)
+}
+
 func TestFrontend(t *testing.T) {
 	cfg := newConfig()
 	cfg.clusterInfo.CephVersion = cephver.Mimic
@@ -98,22 +98,28 @@ func (c *ObjectStoreController) StartWatch(namespace string, stopCh chan struct{
 }
 
 func (c *ObjectStoreController) onAdd(obj interface{}) {
-	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
-		logger.Warningf("Creating object store for an external ceph cluster is disabled because no Ceph image is specified")
-		return
-	}
-
 	objectStore, err := getObjectStoreObject(obj)
 	if err != nil {
 		logger.Errorf("failed to get objectstore object. %v", err)
 		return
 	}
+	if c.deployDisabled(objectStore) {
+		logger.Warningf("Creating object store for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
 
 	c.acquireOrchestrationLock()
 	defer c.releaseOrchestrationLock()
 	updateCephObjectStoreStatus(objectStore.GetName(), objectStore.GetNamespace(), k8sutil.ProcessingStatus, c.context)
 
-	if c.clusterSpec.External.Enable {
+	if err := c.validateExternalAdminKey(objectStore); err != nil {
+		logger.Errorf("failed to create object store %q. %v", objectStore.Name, err)
+		updateCephObjectStoreStatusMessage(objectStore.GetName(), objectStore.GetNamespace(), k8sutil.FailedStatus, err.Error(), c.context)
+		return
+	}
+
+	// the versions can only be compared with the image that runs the gateways
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image != "" {
 		_, err := cephspec.ValidateCephVersionsBetweenLocalAndExternalClusters(c.context, c.namespace, c.clusterInfo.CephVersion)
 		if err != nil {
 			// This handles the case where the operator is running, the external cluster has been upgraded and a CR creation is called
@@ -128,11 +134,6 @@ func (c *ObjectStoreController) onAdd(obj interface{}) {
 }
 
 func (c *ObjectStoreController) onUpdate(oldObj, newObj interface{}) {
-	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
-		logger.Warningf("Updating object store for an external ceph cluster is disabled because no Ceph image is specified")
-		return
-	}
-
 	// if the object store spec is modified, update the object store
 	oldStore, err := getObjectStoreObject(oldObj)
 	if err != nil {
@@ -144,6 +145,10 @@ func (c *ObjectStoreController) onUpdate(oldObj, newObj interface{}) {
 		logger.Errorf("failed to get new objectstore object. %v", err)
 		return
 	}
+	if c.deployDisabled(newStore) {
+		logger.Warningf("Updating object store for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
 
 	if !storeChanged(oldStore.Spec, newStore.Spec) {
 		logger.Debugf("object store %q did not change", newStore.Name)
@@ -154,6 +159,11 @@ func (c *ObjectStoreController) onUpdate(oldObj, newObj interface{}) {
 	defer c.releaseOrchestrationLock()
 
 	updateCephObjectStoreStatus(newStore.GetName(), newStore.GetNamespace(), k8sutil.ProcessingStatus, c.context)
+	if err := c.validateExternalAdminKey(newStore); err != nil {
+		logger.Errorf("failed to update object store %q. %v", newStore.Name, err)
+		updateCephObjectStoreStatusMessage(newStore.GetName(), newStore.GetNamespace(), k8sutil.FailedStatus, err.Error(), c.context)
+		return
+	}
 	c.createOrUpdateStore(newStore)
 	updateCephObjectStoreStatus(newStore.GetName(), newStore.GetNamespace(), k8sutil.ReadyStatus, c.context)
 }
@@ -176,16 +186,15 @@ func (c *ObjectStoreController) createOrUpdateStore(objectstore *cephv1.CephObje
 }
 
 func (c *ObjectStoreController) onDelete(obj interface{}) {
-	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
-		logger.Warningf("Deleting object store for an external ceph cluster is disabled because no Ceph image is specified")
-		return
-	}
-
 	objectstore, err := getObjectStoreObject(obj)
 	if err != nil {
 		logger.Errorf("failed to get objectstore object. %v", err)
 		return
 	}
+	if c.deployDisabled(objectstore) {
+		logger.Warningf("Deleting object store for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
 
 	c.acquireOrchestrationLock()
 	defer c.releaseOrchestrationLock()
@@ -227,6 +236,28 @@ func (c *ObjectStoreController) ParentClusterChanged(cluster cephv1.ClusterSpec,
 	}
 }
 
+// deployDisabled returns whether the gateways of the store cannot be deployed in an external cluster without a Ceph
+// image. The stores served by external gateways don't need an image.
+func (c *ObjectStoreController) deployDisabled(store *cephv1.CephObjectStore) bool {
+	return c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" && !store.Spec.IsExternal()
+}
+
+// validateExternalAdminKey checks that the admin key of the external cluster was imported for a store served by
+// external gateways. The users and the buckets of the store are managed with radosgw-admin in the operator, which
+// cannot run with the restricted user of the external cluster.
+func (c *ObjectStoreController) validateExternalAdminKey(store *cephv1.CephObjectStore) error {
+	if !store.Spec.IsExternal() {
+		return nil
+	}
+	if c.clusterInfo.ExternalCred.Username != "" {
+		return errors.Errorf("the admin key of the external cluster is required for the external rgw endpoints, but the operator connects as %q", c.clusterInfo.ExternalCred.Username)
+	}
+	if c.clusterInfo.AdminSecret == "" {
+		return errors.New("the admin key of the external cluster is required for the external rgw endpoints, but it was not imported")
+	}
+	return nil
+}
+
 func (c *ObjectStoreController) storeOwners(store *cephv1.CephObjectStore) metav1.OwnerReference {
 	// Set the object store CR as the owner
 	return metav1.OwnerReference{
@@ -266,6 +297,14 @@ func storeChanged(oldStore, newStore cephv1.ObjectStoreSpec) bool {
 		logger.Infof("SSLCertificateRef changed from %s to %s", oldStore.Gateway.SSLCertificateRef, newStore.Gateway.SSLCertificateRef)
 		return true
 	}
+	if !reflect.DeepEqual(oldStore.Gateway.ExternalRgwEndpoints, newStore.Gateway.ExternalRgwEndpoints) {
+		logger.Infof("ExternalRgwEndpoints changed from %v to %v", oldStore.Gateway.ExternalRgwEndpoints, newStore.Gateway.ExternalRgwEndpoints)
+		return true
+	}
+	if !reflect.DeepEqual(oldStore.Auth, newStore.Auth) {
+		logger.Infof("Auth changed from %+v to %+v", oldStore.Auth, newStore.Auth)
+		return true
+	}
 	return false
 }
 
@@ -292,6 +331,11 @@ func (c *ObjectStoreController) releaseOrchestrationLock() {
 }
 
 func updateCephObjectStoreStatus(name, namespace, status string, context *clusterd.Context) {
+	updateCephObjectStoreStatusMessage(name, namespace, status, "", context)
+}
+
+// updateCephObjectStoreStatusMessage updates the status of the store with the reason of a failure
+func updateCephObjectStoreStatusMessage(name, namespace, status, message string, context *clusterd.Context) {
 	updatedCephObjectStore, err := context.RookClientset.CephV1().CephObjectStores(namespace).Get(name, metav1.GetOptions{})
 	if err != nil {
 		logger.Errorf("Unable to update the cephObjectStore %s status %v", updatedCephObjectStore.GetName(), err)
@@ -299,10 +343,11 @@ func updateCephObjectStoreStatus(name, namespace, status string, context *cluste
 	}
 	if updatedCephObjectStore.Status == nil {
 		updatedCephObjectStore.Status = &cephv1.Status{}
-	} else if updatedCephObjectStore.Status.Phase == status {
+	} else if updatedCephObjectStore.Status.Phase == status && updatedCephObjectStore.Status.Message == message {
 		return
 	}
 	updatedCephObjectStore.Status.Phase = status
+	updatedCephObjectStore.Status.Message = message
 	_, err = context.RookClientset.CephV1().CephObjectStores(updatedCephObjectStore.Namespace).Update(updatedCephObjectStore)
 	if err != nil {
 		logger.Errorf("Unable to update the cephObjectStore %s status %v", updatedCephObjectStore.GetName(), err)
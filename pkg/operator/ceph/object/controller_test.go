@@ -21,7 +21,9 @@ import (
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	daemonconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 )
 
 func TestObjectStoreChanged(t *testing.T) {
@@ -58,3 +60,21 @@ func TestGetObjectStoreObject(t *testing.T) {
 	assert.Nil(t, objectstore)
 	assert.NotNil(t, err)
 }
+
+func TestValidateExternalAdminKey(t *testing.T) {
+	store := &cephv1.CephObjectStore{}
+	c := &ObjectStoreController{clusterInfo: &daemonconfig.ClusterInfo{ExternalCred: daemonconfig.ExternalCred{Username: "client.healthchecker", Secret: "secret"}}}
+
+	// the stores with their own gateways don't need the admin key
+	assert.NoError(t, c.validateExternalAdminKey(store))
+
+	// the restricted user of the external cluster cannot manage the users
+	store.Spec.Gateway.ExternalRgwEndpoints = []v1.EndpointAddress{{IP: "192.168.39.182"}}
+	assert.Error(t, c.validateExternalAdminKey(store))
+
+	c.clusterInfo = &daemonconfig.ClusterInfo{}
+	assert.Error(t, c.validateExternalAdminKey(store))
+
+	c.clusterInfo.AdminSecret = "adminsecret"
+	assert.NoError(t, c.validateExternalAdminKey(store))
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the keys of the secret with the credentials of the keystone service user of the gateways, which are passed to the
+// gateways in the env vars of the same name
+const (
+	keystoneUserKeyName     = "OS_USERNAME"
+	keystonePasswordKeyName = "OS_PASSWORD"
+	keystoneProjectKeyName  = "OS_PROJECT_NAME"
+	keystoneDomainKeyName   = "OS_USER_DOMAIN_NAME"
+)
+
+var keystoneSecretKeys = []string{keystoneUserKeyName, keystonePasswordKeyName, keystoneProjectKeyName, keystoneDomainKeyName}
+
+const (
+	// the password of the service user is mounted in a file from octopus, so it doesn't show in the args of the gateways
+	keystoneVolumeName   = "rgw-keystone"
+	keystoneDir          = "/etc/ceph/keystone"
+	keystonePasswordFile = "password"
+	// keystoneSecretHashAnnotation is the hash of the secret of the service user, which restarts the gateways when
+	// the secret changes
+	keystoneSecretHashAnnotation = "rook.io/keystone-secret-hash"
+)
+
+// validateKeystone checks the keystone settings of the store and the secret with the credentials of the service user
+func (c *clusterConfig) validateKeystone() error {
+	keystone := c.store.Spec.Auth.Keystone
+	if keystone == nil {
+		return nil
+	}
+	if keystone.URL == "" {
+		return errors.New("missing keystone url")
+	}
+	if len(keystone.AcceptedRoles) == 0 {
+		return errors.New("missing keystone accepted roles")
+	}
+	switch keystone.ImplicitTenants {
+	case "", "true", "false", "s3", "swift":
+	default:
+		return errors.Errorf("invalid keystone implicit tenants %q", keystone.ImplicitTenants)
+	}
+	if keystone.ServiceUserSecretName == "" {
+		return errors.New("missing keystone service user secret name")
+	}
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.store.Namespace).Get(keystone.ServiceUserSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the keystone service user secret %q", keystone.ServiceUserSecretName)
+	}
+	for _, key := range keystoneSecretKeys {
+		if len(secret.Data[key]) == 0 {
+			return errors.Errorf("the keystone service user secret %q has no %q key", secret.Name, key)
+		}
+	}
+	c.keystoneSecretHash = secretHash(secret)
+	return nil
+}
+
+// secretHash returns a hash of the data of a secret
+func secretHash(secret *v1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s=%x;", key, secret.Data[key])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// keystonePasswordInFile returns whether the gateways read the password of the service user from a file
+func (c *clusterConfig) keystonePasswordInFile() bool {
+	return c.clusterInfo.CephVersion.IsAtLeastOctopus()
+}
+
+// keystoneFlags returns the flags of the gateways to authenticate the users of the S3 and Swift APIs with keystone.
+// The credentials of the service user are only referenced by their env vars so they don't show in the pod spec. From
+// octopus, the password is read from its file so it doesn't show in the args of the gateways either.
+func (c *clusterConfig) keystoneFlags() []string {
+	keystone := c.store.Spec.Auth.Keystone
+	if keystone == nil {
+		return []string{}
+	}
+	passwordFlag := cephconfig.NewFlag("rgw keystone admin password", opspec.ContainerEnvVarReference(keystonePasswordKeyName))
+	if c.keystonePasswordInFile() {
+		passwordFlag = cephconfig.NewFlag("rgw keystone admin password path", path.Join(keystoneDir, keystonePasswordFile))
+	}
+	flags := []string{
+		cephconfig.NewFlag("rgw keystone url", keystone.URL),
+		cephconfig.NewFlag("rgw keystone api version", "3"),
+		cephconfig.NewFlag("rgw keystone admin user", opspec.ContainerEnvVarReference(keystoneUserKeyName)),
+		passwordFlag,
+		cephconfig.NewFlag("rgw keystone admin project", opspec.ContainerEnvVarReference(keystoneProjectKeyName)),
+		cephconfig.NewFlag("rgw keystone admin domain", opspec.ContainerEnvVarReference(keystoneDomainKeyName)),
+		cephconfig.NewFlag("rgw keystone accepted roles", strings.Join(keystone.AcceptedRoles, ",")),
+		cephconfig.NewFlag("rgw s3 auth use keystone", "true"),
+	}
+	if keystone.ImplicitTenants != "" {
+		flags = append(flags, cephconfig.NewFlag("rgw keystone implicit tenants", keystone.ImplicitTenants))
+	}
+	if keystone.TokenCacheSize != nil {
+		flags = append(flags, cephconfig.NewFlag("rgw keystone token cache size", strconv.Itoa(*keystone.TokenCacheSize)))
+	}
+	return flags
+}
+
+func (c *clusterConfig) keystoneEnvVars() []v1.EnvVar {
+	keystone := c.store.Spec.Auth.Keystone
+	if keystone == nil {
+		return []v1.EnvVar{}
+	}
+	envVars := []v1.EnvVar{}
+	for _, key := range keystoneSecretKeys {
+		if key == keystonePasswordKeyName && c.keystonePasswordInFile() {
+			continue
+		}
+		ref := &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: keystone.ServiceUserSecretName}, Key: key}
+		envVars = append(envVars, v1.EnvVar{Name: key, ValueFrom: &v1.EnvVarSource{SecretKeyRef: ref}})
+	}
+	return envVars
+}
+
+// keystoneVolumes returns the volume with the password of the service user if the gateways read it from a file
+func (c *clusterConfig) keystoneVolumes() []v1.Volume {
+	keystone := c.store.Spec.Auth.Keystone
+	if keystone == nil || !c.keystonePasswordInFile() {
+		return []v1.Volume{}
+	}
+	userReadOnly := int32(0400)
+	return []v1.Volume{{
+		Name: keystoneVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: keystone.ServiceUserSecretName,
+				Items: []v1.KeyToPath{
+					{Key: keystonePasswordKeyName, Path: keystonePasswordFile, Mode: &userReadOnly},
+				}}}}}
+}
+
+func (c *clusterConfig) keystoneVolumeMounts() []v1.VolumeMount {
+	if len(c.keystoneVolumes()) == 0 {
+		return []v1.VolumeMount{}
+	}
+	return []v1.VolumeMount{{Name: keystoneVolumeName, MountPath: keystoneDir, ReadOnly: true}}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateKeystone(t *testing.T) {
+	clientset := testop.New(1)
+	cfg := newConfig()
+	cfg.context = &clusterd.Context{Clientset: clientset}
+	cfg.store.Namespace = "ns"
+
+	// nothing to check without keystone
+	assert.NoError(t, cfg.validateKeystone())
+
+	cfg.store.Spec.Auth.Keystone = &cephv1.KeystoneSpec{URL: "http://keystone:5000", ServiceUserSecretName: "rgw-keystone"}
+	assert.Error(t, cfg.validateKeystone())
+	cfg.store.Spec.Auth.Keystone.AcceptedRoles = []string{"admin", "member"}
+	cfg.store.Spec.Auth.Keystone.ImplicitTenants = "all"
+	assert.Error(t, cfg.validateKeystone())
+	cfg.store.Spec.Auth.Keystone.ImplicitTenants = "swift"
+
+	// the secret must exist with all the credentials of the service user
+	assert.Error(t, cfg.validateKeystone())
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "rgw-keystone", Namespace: "ns"}, Data: map[string][]byte{
+		keystoneUserKeyName:     []byte("rgw"),
+		keystonePasswordKeyName: []byte("password"),
+		keystoneProjectKeyName:  []byte("service"),
+	}}
+	_, err := clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.NoError(t, err)
+	assert.Error(t, cfg.validateKeystone())
+
+	secret.Data[keystoneDomainKeyName] = []byte("Default")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.validateKeystone())
+	hash := cfg.keystoneSecretHash
+	assert.NotEmpty(t, hash)
+
+	// the hash of the secret changes with the credentials
+	secret.Data[keystonePasswordKeyName] = []byte("new-password")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.validateKeystone())
+	assert.NotEqual(t, hash, cfg.keystoneSecretHash)
+}
+
+func TestKeystonePodSpec(t *testing.T) {
+	store := simpleStore()
+	tokenCacheSize := 1000
+	store.Spec.Auth.Keystone = &cephv1.KeystoneSpec{
+		URL:                   "http://keystone:5000",
+		ServiceUserSecretName: "rgw-keystone",
+		AcceptedRoles:         []string{"admin", "member"},
+		TokenCacheSize:        &tokenCacheSize,
+	}
+	c := &clusterConfig{
+		clusterInfo: testop.CreateConfigDir(1),
+		store:       store,
+		rookVersion: "rook/rook:myversion",
+		clusterSpec: &cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v14.2.5"}},
+		DataPathMap: cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, "default", "rook-ceph", "/var/lib/rook/"),
+	}
+
+	s := c.makeRGWPodSpec(&rgwConfig{ResourceName: c.instanceName()})
+	podTemplate := cephtest.NewPodTemplateSpecTester(t, &s)
+	// the credentials of the service user are sourced from the secret
+	podTemplate.Spec().Containers().AssertArgReferencesMatchEnvVars()
+
+	container := s.Spec.Containers[0]
+	assert.Contains(t, container.Args, "--rgw-keystone-url=http://keystone:5000")
+	assert.Contains(t, container.Args, "--rgw-keystone-accepted-roles=admin,member")
+	assert.Contains(t, container.Args, "--rgw-keystone-admin-password=$(OS_PASSWORD)")
+	assert.Contains(t, container.Args, "--rgw-keystone-token-cache-size=1000")
+	assert.Contains(t, container.Args, "--rgw-s3-auth-use-keystone=true")
+	for _, env := range container.Env {
+		if env.Name == keystonePasswordKeyName {
+			assert.Equal(t, "rgw-keystone", env.ValueFrom.SecretKeyRef.Name)
+			assert.Equal(t, keystonePasswordKeyName, env.ValueFrom.SecretKeyRef.Key)
+		}
+	}
+	assert.Empty(t, s.ObjectMeta.Annotations[keystoneSecretHashAnnotation])
+
+	// from octopus, the password is read from its file instead of the args
+	c.clusterInfo.CephVersion = cephver.Octopus
+	c.keystoneSecretHash = "hash"
+	s = c.makeRGWPodSpec(&rgwConfig{ResourceName: c.instanceName()})
+	podTemplate = cephtest.NewPodTemplateSpecTester(t, &s)
+	podTemplate.Spec().Containers().AssertArgReferencesMatchEnvVars()
+	container = s.Spec.Containers[0]
+	passwordVolume := s.Spec.Volumes[len(s.Spec.Volumes)-1].Secret
+	assert.Equal(t, "rgw-keystone", passwordVolume.SecretName)
+	assert.Equal(t, keystonePasswordKeyName, passwordVolume.Items[0].Key)
+	assert.Equal(t, keystonePasswordFile, passwordVolume.Items[0].Path)
+	assert.Equal(t, v1.VolumeMount{Name: keystoneVolumeName, MountPath: keystoneDir, ReadOnly: true}, container.VolumeMounts[len(container.VolumeMounts)-1])
+	assert.Contains(t, container.Args, "--rgw-keystone-admin-password-path=/etc/ceph/keystone/password")
+	assert.NotContains(t, container.Args, "--rgw-keystone-admin-password=$(OS_PASSWORD)")
+	for _, env := range container.Env {
+		assert.NotEqual(t, keystonePasswordKeyName, env.Name)
+	}
+	assert.Equal(t, "hash", s.ObjectMeta.Annotations[keystoneSecretHashAnnotation])
+}
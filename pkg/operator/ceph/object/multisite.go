@@ -0,0 +1,534 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// SystemAccessKeyName is the key of the access key of the system user of a realm in its secret
+	SystemAccessKeyName = "access-key"
+	// SystemSecretKeyName is the key of the secret key of the system user of a realm in its secret
+	SystemSecretKeyName = "secret-key"
+)
+
+var (
+	// the zone of a multisite store is created by the zone controller, which may not be done when the store is created
+	waitForZoneInterval = 15 * time.Second
+	waitForZoneTimeout  = 5 * time.Minute
+)
+
+// SystemKeys are the keys of the system user of a realm, which the zones of the realm use to sync with each other
+type SystemKeys struct {
+	AccessKey string
+	SecretKey string
+}
+
+type periodType struct {
+	MasterZoneGroup string `json:"master_zonegroup"`
+}
+
+type zoneGroupType struct {
+	MasterZone string         `json:"master_zone"`
+	Endpoints  []string       `json:"endpoints"`
+	Zones      []zoneInfoType `json:"zones"`
+}
+
+type zoneInfoType struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Endpoints []string `json:"endpoints"`
+}
+
+type syncStatusType struct {
+	SyncStatus struct {
+		Info struct {
+			Status string `json:"status"`
+		} `json:"info"`
+	} `json:"sync_status"`
+}
+
+// NewMultisiteContext creates the context of a multisite realm, zone group and zone. The zone group and zone are
+// empty for the commands that only apply to the realm. The pools of the zone are named after the zone.
+func NewMultisiteContext(context *clusterd.Context, clusterName, realm, zoneGroup, zone string) *Context {
+	return &Context{Context: context, Name: zone, ClusterName: clusterName, Realm: realm, ZoneGroup: zoneGroup, Zone: zone}
+}
+
+// GetZoneContext returns the multisite context of the zone with the given name from the custom resources of the
+// zone, its zone group and its realm
+func GetZoneContext(context *clusterd.Context, namespace, zoneName string) (*Context, error) {
+	zone, err := context.RookClientset.CephV1().CephObjectZones(namespace).Get(zoneName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get zone %q", zoneName)
+	}
+	zoneGroup, err := context.RookClientset.CephV1().CephObjectZoneGroups(namespace).Get(zone.Spec.ZoneGroup, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get zone group %q of zone %q", zone.Spec.ZoneGroup, zoneName)
+	}
+	realm, err := context.RookClientset.CephV1().CephObjectRealms(namespace).Get(zoneGroup.Spec.Realm, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get realm %q of zone group %q", zoneGroup.Spec.Realm, zoneGroup.Name)
+	}
+	return NewMultisiteContext(context, namespace, realm.Name, zoneGroup.Name, zone.Name), nil
+}
+
+// NewStoreContext creates the context of the object store with the given name. The admin commands of the context run
+// in the multisite zone of the store if it has one, or in the default realm if the store is served by external gateways.
+func NewStoreContext(context *clusterd.Context, name, namespace string) (*Context, error) {
+	store, err := context.RookClientset.CephV1().CephObjectStores(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get object store %q", name)
+	}
+	return storeContext(context, store)
+}
+
+func storeContext(context *clusterd.Context, store *cephv1.CephObjectStore) (*Context, error) {
+	objContext := NewContext(context, store.Name, store.Namespace)
+	if store.Spec.IsExternal() {
+		// rook didn't create the realm of the external gateways
+		objContext.DefaultRealm = true
+		return objContext, nil
+	}
+	if store.Spec.Zone.Name == "" {
+		return objContext, nil
+	}
+	zoneContext, err := GetZoneContext(context, store.Namespace, store.Spec.Zone.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the zone of object store %q", store.Name)
+	}
+	objContext.Realm = zoneContext.Realm
+	objContext.ZoneGroup = zoneContext.ZoneGroup
+	objContext.Zone = zoneContext.Zone
+	return objContext, nil
+}
+
+// waitForZone waits for the zone of a multisite store to be created in its zone group and returns the context of
+// the store in the zone
+func waitForZone(context *clusterd.Context, store *cephv1.CephObjectStore) (*Context, error) {
+	var objContext *Context
+	var lastErr error
+	zoneCreated := func() bool {
+		objContext, lastErr = storeContext(context, store)
+		if lastErr != nil {
+			return false
+		}
+		zoneGroup, err := getZoneGroup(objContext)
+		if err != nil {
+			lastErr = err
+			return false
+		}
+		for _, zone := range zoneGroup.Zones {
+			if zone.Name == objContext.Zone {
+				return true
+			}
+		}
+		lastErr = errors.Errorf("zone %q does not exist in zone group %q yet", objContext.Zone, objContext.ZoneGroup)
+		return false
+	}
+	if zoneCreated() {
+		return objContext, nil
+	}
+	logger.Infof("waiting for zone %q of object store %q to be created. %v", store.Spec.Zone.Name, store.Name, lastErr)
+	err := wait.Poll(waitForZoneInterval, waitForZoneTimeout, func() (bool, error) {
+		return zoneCreated(), nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(lastErr, "timed out waiting for zone %q of object store %q", store.Spec.Zone.Name, store.Name)
+	}
+	return objContext, nil
+}
+
+func (c *Context) realmArg() string {
+	return fmt.Sprintf("--rgw-realm=%s", c.Realm)
+}
+
+func (c *Context) zoneGroupArg() string {
+	return fmt.Sprintf("--rgw-zonegroup=%s", c.ZoneGroup)
+}
+
+func (c *Context) zoneArg() string {
+	return fmt.Sprintf("--rgw-zone=%s", c.Zone)
+}
+
+// RealmExists returns whether the realm of the context exists
+func RealmExists(c *Context) bool {
+	_, err := runAdminCommandNoRealm(c, "realm", "get", c.realmArg())
+	return err == nil
+}
+
+// ZoneGroupExists returns whether the zone group of the context exists in its realm
+func ZoneGroupExists(c *Context) bool {
+	_, err := getZoneGroup(c)
+	return err == nil
+}
+
+// RealmKeysSecretName returns the name of the secret with the keys of the system user of the realm
+func RealmKeysSecretName(realm string) string {
+	return fmt.Sprintf("%s-keys", realm)
+}
+
+func systemUserID(realm string) string {
+	return fmt.Sprintf("%s-system-user", realm)
+}
+
+// CreateRealm creates the realm of the context if it doesn't exist yet. The first realm is marked as the default.
+func CreateRealm(c *Context) error {
+	if RealmExists(c) {
+		logger.Debugf("realm %q already exists", c.Realm)
+		return nil
+	}
+
+	realms, err := getObjectStores(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list realms")
+	}
+	args := []string{"realm", "create", c.realmArg()}
+	if len(realms) == 0 {
+		args = append(args, "--default")
+	}
+	output, err := runAdminCommandNoRealm(c, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create realm %q", c.Realm)
+	}
+	realmID, err := decodeID(output)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse realm id")
+	}
+	logger.Infof("created realm %q with id %q", c.Realm, realmID)
+	return nil
+}
+
+// PullRealm pulls the realm of the context and its current period from the master zone at the endpoint if the realm
+// doesn't exist yet. The first realm is marked as the default.
+func PullRealm(c *Context, endpoint string, keys *SystemKeys) error {
+	if RealmExists(c) {
+		logger.Debugf("realm %q already exists", c.Realm)
+		return nil
+	}
+
+	realms, err := getObjectStores(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list realms")
+	}
+	output, err := runAdminCommandWithDebug(c, true, "realm", "pull", c.realmArg(), fmt.Sprintf("--url=%s", endpoint),
+		fmt.Sprintf("--access-key=%s", keys.AccessKey), fmt.Sprintf("--secret=%s", keys.SecretKey))
+	if err != nil {
+		return errors.Wrapf(err, "failed to pull realm %q from %q", c.Realm, endpoint)
+	}
+	realmID, err := decodeID(output)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse realm id")
+	}
+	if len(realms) == 0 {
+		if _, err := runAdminCommandNoRealm(c, "realm", "default", c.realmArg()); err != nil {
+			return errors.Wrapf(err, "failed to set realm %q as the default", c.Realm)
+		}
+	}
+	logger.Infof("pulled realm %q with id %q from %q", c.Realm, realmID, endpoint)
+	return nil
+}
+
+// DeleteRealm deletes the realm of the context
+func DeleteRealm(c *Context) error {
+	if _, err := runAdminCommandNoRealm(c, "realm", "delete", c.realmArg()); err != nil {
+		return errors.Wrapf(err, "failed to delete realm %q", c.Realm)
+	}
+	logger.Infof("deleted realm %q", c.Realm)
+	return nil
+}
+
+// CreateZoneGroup creates the zone group of the context in its realm if it doesn't exist yet. The first zone group of
+// the realm is its master zone group.
+func CreateZoneGroup(c *Context) error {
+	if ZoneGroupExists(c) {
+		logger.Debugf("zone group %q already exists in realm %q", c.ZoneGroup, c.Realm)
+		return nil
+	}
+
+	output, err := runAdminCommandNoRealm(c, "period", "get", c.realmArg())
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the period of realm %q", c.Realm)
+	}
+	var period periodType
+	if err := json.Unmarshal([]byte(output), &period); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the period of realm %q", c.Realm)
+	}
+
+	args := []string{"zonegroup", "create", c.realmArg(), c.zoneGroupArg()}
+	if period.MasterZoneGroup == "" {
+		args = append(args, "--master")
+	}
+	output, err = runAdminCommandNoRealm(c, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create zone group %q in realm %q", c.ZoneGroup, c.Realm)
+	}
+	zoneGroupID, err := decodeID(output)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse zone group id")
+	}
+	if err := commitPeriod(c); err != nil {
+		return err
+	}
+	logger.Infof("created zone group %q with id %q in realm %q", c.ZoneGroup, zoneGroupID, c.Realm)
+	return nil
+}
+
+// DeleteZoneGroup deletes the zone group of the context from its realm
+func DeleteZoneGroup(c *Context) error {
+	if _, err := runAdminCommandNoRealm(c, "zonegroup", "delete", c.realmArg(), c.zoneGroupArg()); err != nil {
+		return errors.Wrapf(err, "failed to delete zone group %q", c.ZoneGroup)
+	}
+	if err := commitPeriod(c); err != nil {
+		return err
+	}
+	logger.Infof("deleted zone group %q from realm %q", c.ZoneGroup, c.Realm)
+	return nil
+}
+
+// CreateZone creates the pools and the zone of the context in its zone group if they don't exist yet. The first zone
+// of the zone group is its master zone. The zone syncs with the other zones with the keys of the system user of the
+// realm if they are given.
+func CreateZone(c *Context, metadataSpec, dataSpec model.Pool, keys *SystemKeys) error {
+	if err := createPools(c, metadataSpec, dataSpec); err != nil {
+		return errors.Wrapf(err, "failed to create the pools of zone %q", c.Zone)
+	}
+
+	if _, err := runAdminCommandNoRealm(c, "zone", "get", c.realmArg(), c.zoneGroupArg(), c.zoneArg()); err == nil {
+		logger.Debugf("zone %q already exists in zone group %q", c.Zone, c.ZoneGroup)
+		return nil
+	}
+
+	zoneGroup, err := getZoneGroup(c)
+	if err != nil {
+		return err
+	}
+	args := []string{"zone", "create", c.realmArg(), c.zoneGroupArg(), c.zoneArg()}
+	if zoneGroup.MasterZone == "" {
+		args = append(args, "--master")
+	}
+	if keys != nil {
+		args = append(args, fmt.Sprintf("--access-key=%s", keys.AccessKey), fmt.Sprintf("--secret=%s", keys.SecretKey))
+	}
+	output, err := runAdminCommandWithDebug(c, keys != nil, args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create zone %q in zone group %q", c.Zone, c.ZoneGroup)
+	}
+	zoneID, err := decodeID(output)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse zone id")
+	}
+	if err := commitPeriod(c); err != nil {
+		return err
+	}
+	logger.Infof("created zone %q with id %q in zone group %q", c.Zone, zoneID, c.ZoneGroup)
+	return nil
+}
+
+// CreateSystemUser creates the system user of the realm in the zone of the context if it doesn't exist yet, and sets
+// its keys as the keys the zone syncs with
+func CreateSystemUser(c *Context) (*SystemKeys, error) {
+	userID := systemUserID(c.Realm)
+	user, _, err := GetUser(c, userID)
+	if err != nil {
+		output, err := runAdminCommandWithDebug(c, true, "user", "create", "--uid", userID, "--display-name", userID, "--system",
+			c.realmArg(), c.zoneGroupArg(), c.zoneArg())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the system user of realm %q", c.Realm)
+		}
+		if user, _, err = decodeUser(output); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the system user of realm %q", c.Realm)
+		}
+		logger.Infof("created the system user %q of realm %q", userID, c.Realm)
+	}
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return nil, errors.Errorf("the system user %q of realm %q has no keys", userID, c.Realm)
+	}
+	keys := &SystemKeys{AccessKey: *user.AccessKey, SecretKey: *user.SecretKey}
+
+	if _, err := runAdminCommandWithDebug(c, true, "zone", "modify", c.realmArg(), c.zoneGroupArg(), c.zoneArg(),
+		fmt.Sprintf("--access-key=%s", keys.AccessKey), fmt.Sprintf("--secret=%s", keys.SecretKey)); err != nil {
+		return nil, errors.Wrapf(err, "failed to set the system keys of zone %q", c.Zone)
+	}
+	if err := commitPeriod(c); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetSyncStatus returns the state of the metadata sync of the zone of the context from the master zone, empty in the
+// master zone, and the state of its data sync from each of the other zones of its zone group. The status commands are
+// only logged at the debug level since the status is checked periodically.
+func GetSyncStatus(c *Context) (string, map[string]string, error) {
+	zoneGroup, err := getZoneGroup(c)
+	if err != nil {
+		return "", nil, err
+	}
+	masterZone, err := isMasterZone(c, zoneGroup)
+	if err != nil {
+		return "", nil, err
+	}
+
+	metadataSync := ""
+	if !masterZone {
+		output, err := runAdminCommandWithDebug(c, true, "metadata", "sync", "status", c.realmArg(), c.zoneGroupArg(), c.zoneArg())
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "failed to get the metadata sync status of zone %q", c.Zone)
+		}
+		if metadataSync, err = decodeSyncState(output); err != nil {
+			return "", nil, err
+		}
+	}
+
+	dataSync := map[string]string{}
+	for _, zone := range zoneGroup.Zones {
+		if zone.Name == c.Zone {
+			continue
+		}
+		output, err := runAdminCommandWithDebug(c, true, "data", "sync", "status", fmt.Sprintf("--source-zone=%s", zone.Name),
+			c.realmArg(), c.zoneGroupArg(), c.zoneArg())
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "failed to get the data sync status of zone %q from zone %q", c.Zone, zone.Name)
+		}
+		if dataSync[zone.Name], err = decodeSyncState(output); err != nil {
+			return "", nil, err
+		}
+	}
+	return metadataSync, dataSync, nil
+}
+
+func decodeSyncState(data string) (string, error) {
+	var status syncStatusType
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal the sync status")
+	}
+	return status.SyncStatus.Info.Status, nil
+}
+
+// DeleteZone deletes the zone of the context from its zone group, and its pools unless they are preserved
+func DeleteZone(c *Context, preservePoolsOnDelete bool) error {
+	if _, err := runAdminCommandNoRealm(c, "zone", "delete", c.realmArg(), c.zoneGroupArg(), c.zoneArg()); err != nil {
+		return errors.Wrapf(err, "failed to delete zone %q", c.Zone)
+	}
+	if err := commitPeriod(c); err != nil {
+		return err
+	}
+
+	if preservePoolsOnDelete {
+		logger.Infof("PreservePoolsOnDelete is set in zone %s. Pools not deleted", c.Zone)
+	} else if err := deletePools(c, false); err != nil {
+		return errors.Wrapf(err, "failed to delete the pools of zone %q", c.Zone)
+	}
+	logger.Infof("deleted zone %q from zone group %q", c.Zone, c.ZoneGroup)
+	return nil
+}
+
+// SetZoneEndpoints sets the endpoints of the zone of the context. The endpoints of the master zone are also the
+// endpoints of its zone group.
+func SetZoneEndpoints(c *Context, endpoints []string) error {
+	zoneGroup, err := getZoneGroup(c)
+	if err != nil {
+		return err
+	}
+	var zone *zoneInfoType
+	for i := range zoneGroup.Zones {
+		if zoneGroup.Zones[i].Name == c.Zone {
+			zone = &zoneGroup.Zones[i]
+		}
+	}
+	if zone == nil {
+		return errors.Errorf("zone %q does not exist in zone group %q yet", c.Zone, c.ZoneGroup)
+	}
+
+	sort.Strings(endpoints)
+	endpointsArg := fmt.Sprintf("--endpoints=%s", strings.Join(endpoints, ","))
+	updatePeriod := false
+	if !sameEndpoints(zone.Endpoints, endpoints) {
+		if _, err := runAdminCommandNoRealm(c, "zone", "modify", c.realmArg(), c.zoneGroupArg(), c.zoneArg(), endpointsArg); err != nil {
+			return errors.Wrapf(err, "failed to set the endpoints of zone %q", c.Zone)
+		}
+		updatePeriod = true
+	}
+	masterZone, err := isMasterZone(c, zoneGroup)
+	if err != nil {
+		return err
+	}
+	if masterZone && !sameEndpoints(zoneGroup.Endpoints, endpoints) {
+		if _, err := runAdminCommandNoRealm(c, "zonegroup", "modify", c.realmArg(), c.zoneGroupArg(), endpointsArg); err != nil {
+			return errors.Wrapf(err, "failed to set the endpoints of zone group %q", c.ZoneGroup)
+		}
+		updatePeriod = true
+	}
+
+	if updatePeriod {
+		if err := commitPeriod(c); err != nil {
+			return err
+		}
+		logger.Infof("set the endpoints of zone %q to %v", c.Zone, endpoints)
+	}
+	return nil
+}
+
+func getZoneGroup(c *Context) (*zoneGroupType, error) {
+	output, err := runAdminCommandNoRealm(c, "zonegroup", "get", c.realmArg(), c.zoneGroupArg())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get zone group %q", c.ZoneGroup)
+	}
+	var zoneGroup zoneGroupType
+	if err := json.Unmarshal([]byte(output), &zoneGroup); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal zone group %q", c.ZoneGroup)
+	}
+	return &zoneGroup, nil
+}
+
+func isMasterZone(c *Context, zoneGroup *zoneGroupType) (bool, error) {
+	output, err := runAdminCommandNoRealm(c, "zone", "get", c.realmArg(), c.zoneGroupArg(), c.zoneArg())
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get zone %q", c.Zone)
+	}
+	zoneID, err := decodeID(output)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse zone id")
+	}
+	return zoneGroup.MasterZone == zoneID, nil
+}
+
+func sameEndpoints(current, desired []string) bool {
+	current = append([]string{}, current...)
+	sort.Strings(current)
+	return reflect.DeepEqual(current, desired) || (len(current) == 0 && len(desired) == 0)
+}
+
+// commitPeriod commits the changes of the realm of the context, so they are seen by the other zones
+func commitPeriod(c *Context) error {
+	if _, err := runAdminCommandNoRealm(c, "period", "update", "--commit", c.realmArg()); err != nil {
+		return errors.Wrapf(err, "failed to commit the period of realm %q", c.Realm)
+	}
+	return nil
+}
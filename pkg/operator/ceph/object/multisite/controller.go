@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package multisite to manage the realms, zone groups and zones of the rook object stores.
+package multisite
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-object-multisite")
+
+var (
+	// the interval and timeout to wait for the realm of a zone group or the zone group of a zone to be created
+	waitForParentInterval = 15 * time.Second
+	waitForParentTimeout  = 5 * time.Minute
+
+	// the interval to check the sync status of the zones
+	syncStatusInterval = time.Minute
+)
+
+// ObjectRealmResource represents the object realm custom resource
+var ObjectRealmResource = opkit.CustomResource{
+	Name:    "cephobjectrealm",
+	Plural:  "cephobjectrealms",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephObjectRealm{}).Name(),
+}
+
+// ObjectZoneGroupResource represents the object zone group custom resource
+var ObjectZoneGroupResource = opkit.CustomResource{
+	Name:    "cephobjectzonegroup",
+	Plural:  "cephobjectzonegroups",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephObjectZoneGroup{}).Name(),
+}
+
+// ObjectZoneResource represents the object zone custom resource
+var ObjectZoneResource = opkit.CustomResource{
+	Name:    "cephobjectzone",
+	Plural:  "cephobjectzones",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephObjectZone{}).Name(),
+}
+
+// waitForParent waits until the parent of a multisite resource exists in the cluster
+func waitForParent(exists func() bool) error {
+	if exists() {
+		return nil
+	}
+	return wait.Poll(waitForParentInterval, waitForParentTimeout, func() (bool, error) {
+		return exists(), nil
+	})
+}
+
+// getSystemKeys returns the keys of the system user of a realm from the secret with the given name
+func getSystemKeys(context *clusterd.Context, namespace, secretName string) (*object.SystemKeys, error) {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %q", secretName)
+	}
+	accessKey, ok := secret.Data[object.SystemAccessKeyName]
+	if !ok || len(accessKey) == 0 {
+		return nil, errors.Errorf("secret %q has no %q", secretName, object.SystemAccessKeyName)
+	}
+	secretKey, ok := secret.Data[object.SystemSecretKeyName]
+	if !ok || len(secretKey) == 0 {
+		return nil, errors.Errorf("secret %q has no %q", secretName, object.SystemSecretKeyName)
+	}
+	return &object.SystemKeys{AccessKey: string(accessKey), SecretKey: string(secretKey)}, nil
+}
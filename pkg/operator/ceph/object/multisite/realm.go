@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multisite
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PullCASecretKey is the key of the PEM encoded CA certificates in the CA secret of the pull of a realm
+	PullCASecretKey = "ca.crt"
+)
+
+// caAnchorsDir is where the CAs trusted by the operator are added, which radosgw-admin uses to verify the
+// certificates of the remote gateways
+var caAnchorsDir = "/etc/pki/ca-trust/source/anchors"
+
+// RealmController represents a controller object for object realm custom resources
+type RealmController struct {
+	context     *clusterd.Context
+	clusterSpec *cephv1.ClusterSpec
+	namespace   string
+}
+
+// NewRealmController create controller for watching object realm custom resources created
+func NewRealmController(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, namespace string) *RealmController {
+	return &RealmController{
+		context:     context,
+		clusterSpec: clusterSpec,
+		namespace:   namespace,
+	}
+}
+
+// StartWatch watches for instances of ObjectRealm custom resources and acts on them
+func (c *RealmController) StartWatch(stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching object realm resources in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(ObjectRealmResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephObjectRealm{}, stopCh)
+	return nil
+}
+
+func (c *RealmController) onAdd(obj interface{}) {
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+		logger.Warningf("Creating object realm for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+
+	realm, err := getRealmObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object realm object. %v", err)
+		return
+	}
+	updateCephObjectRealmStatus(realm.Name, realm.Namespace, k8sutil.ProcessingStatus, c.context)
+
+	if err := c.createRealm(realm); err != nil {
+		logger.Errorf("failed to create object realm %q. %v", realm.Name, err)
+		updateCephObjectRealmStatus(realm.Name, realm.Namespace, k8sutil.FailedStatus, c.context)
+		return
+	}
+	updateCephObjectRealmStatus(realm.Name, realm.Namespace, k8sutil.ReadyStatus, c.context)
+}
+
+func (c *RealmController) createRealm(realm *cephv1.CephObjectRealm) error {
+	if err := validateRealm(realm); err != nil {
+		return errors.Wrapf(err, "invalid realm %s arguments", realm.Name)
+	}
+	objContext := object.NewMultisiteContext(c.context, realm.Namespace, realm.Name, "", "")
+	if realm.Spec.Pull == nil {
+		logger.Infof("creating object realm %q", realm.Name)
+		return object.CreateRealm(objContext)
+	}
+
+	// the realm is pulled from the master zone of another cluster with the keys of its system user
+	keys, err := getSystemKeys(c.context, realm.Namespace, realm.Spec.Pull.SecretName)
+	if err != nil {
+		return err
+	}
+	if realm.Spec.Pull.CASecretName != "" {
+		if err := c.trustPullCA(realm); err != nil {
+			return err
+		}
+	}
+	logger.Infof("pulling object realm %q from %q", realm.Name, realm.Spec.Pull.Endpoint)
+	return object.PullRealm(objContext, realm.Spec.Pull.Endpoint, keys)
+}
+
+// trustPullCA adds the CA of the master zone of a realm to the CAs trusted by the operator, so the certificate of the
+// endpoint can be verified when the realm is pulled
+func (c *RealmController) trustPullCA(realm *cephv1.CephObjectRealm) error {
+	secretName := realm.Spec.Pull.CASecretName
+	secret, err := c.context.Clientset.CoreV1().Secrets(realm.Namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the pull CA secret %q", secretName)
+	}
+	ca := secret.Data[PullCASecretKey]
+	if !x509.NewCertPool().AppendCertsFromPEM(ca) {
+		return errors.Errorf("the pull CA secret %q does not have PEM encoded certificates under the %q key", secretName, PullCASecretKey)
+	}
+
+	path := filepath.Join(caAnchorsDir, fmt.Sprintf("rook-%s-%s.crt", realm.Namespace, realm.Name))
+	if current, err := ioutil.ReadFile(path); err == nil && bytes.Equal(current, ca) {
+		return nil
+	}
+	if err := os.MkdirAll(caAnchorsDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create the CA directory %q", caAnchorsDir)
+	}
+	if err := ioutil.WriteFile(path, ca, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write the pull CA of realm %q", realm.Name)
+	}
+	if err := c.context.Executor.ExecuteCommand(false, "", "update-ca-trust", "extract"); err != nil {
+		return errors.Wrapf(err, "failed to trust the pull CA of realm %q", realm.Name)
+	}
+	logger.Infof("trusting the CA of secret %q to pull realm %q", secretName, realm.Name)
+	return nil
+}
+
+func validateRealm(realm *cephv1.CephObjectRealm) error {
+	if realm.Spec.Pull == nil {
+		return nil
+	}
+	if realm.Spec.Pull.Endpoint == "" {
+		return errors.New("missing pull endpoint")
+	}
+	if realm.Spec.Pull.SecretName == "" {
+		return errors.New("missing pull secret name")
+	}
+	return nil
+}
+
+func (c *RealmController) onDelete(obj interface{}) {
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+		logger.Warningf("Deleting object realm for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+
+	realm, err := getRealmObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object realm object. %v", err)
+		return
+	}
+
+	objContext := object.NewMultisiteContext(c.context, realm.Namespace, realm.Name, "", "")
+	if err := object.DeleteRealm(objContext); err != nil {
+		logger.Errorf("failed to delete object realm %q. %v", realm.Name, err)
+	}
+}
+
+func getRealmObject(obj interface{}) (*cephv1.CephObjectRealm, error) {
+	realm, ok := obj.(*cephv1.CephObjectRealm)
+	if ok {
+		return realm.DeepCopy(), nil
+	}
+	return nil, errors.Errorf("not a known object realm object %+v", obj)
+}
+
+func updateCephObjectRealmStatus(name, namespace, status string, context *clusterd.Context) {
+	realm, err := context.RookClientset.CephV1().CephObjectRealms(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("Unable to update the cephObjectRealm %s status %v", name, err)
+		return
+	}
+	if realm.Status == nil {
+		realm.Status = &cephv1.Status{}
+	} else if realm.Status.Phase == status {
+		return
+	}
+	realm.Status.Phase = status
+	if _, err := context.RookClientset.CephV1().CephObjectRealms(namespace).Update(realm); err != nil {
+		logger.Errorf("Unable to update the cephObjectRealm %s status %v", name, err)
+	}
+}
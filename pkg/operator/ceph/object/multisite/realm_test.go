@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multisite
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPullRealm(t *testing.T) {
+	var pullArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		switch strings.Join(args[:2], " ") {
+		case "realm get":
+			return "", errors.New("realm not found")
+		case "realm list":
+			return `{"realms":["realm-b"]}`, nil
+		case "realm pull":
+			pullArgs = args
+			return `{"id":"realm-id"}`, nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+
+	realm := &cephv1.CephObjectRealm{
+		ObjectMeta: metav1.ObjectMeta{Name: "realm-a", Namespace: "ns"},
+		Spec:       cephv1.ObjectRealmSpec{Pull: &cephv1.PullSpec{Endpoint: "http://1.2.3.4:80", SecretName: "realm-a-keys"}},
+	}
+	clientset := test.New(1)
+	c := NewRealmController(&clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(realm)}, &cephv1.ClusterSpec{}, "ns")
+
+	// the keys of the master zone are required to pull the realm
+	assert.Error(t, c.createRealm(realm))
+	assert.Nil(t, pullArgs)
+
+	_, err := clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "realm-a-keys", Namespace: "ns"},
+		Data: map[string][]byte{
+			object.SystemAccessKeyName: []byte("access"),
+			object.SystemSecretKeyName: []byte("secret"),
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.createRealm(realm))
+	assert.Contains(t, pullArgs, "--url=http://1.2.3.4:80")
+	assert.Contains(t, pullArgs, "--access-key=access")
+	assert.Contains(t, pullArgs, "--secret=secret")
+
+	// the CA of the endpoint is trusted before the realm is pulled
+	var trustCommands []string
+	executor.MockExecuteCommand = func(debug bool, actionName, command string, args ...string) error {
+		trustCommands = append(trustCommands, command+" "+strings.Join(args, " "))
+		return nil
+	}
+	caAnchorsDir, err = ioutil.TempDir("", "anchors")
+	require.NoError(t, err)
+	defer os.RemoveAll(caAnchorsDir)
+	realm.Spec.Pull.CASecretName = "realm-a-ca"
+	assert.Error(t, c.createRealm(realm))
+	_, err = clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "realm-a-ca", Namespace: "ns"},
+		Data:       map[string][]byte{PullCASecretKey: []byte("not a certificate")},
+	})
+	require.NoError(t, err)
+	assert.Error(t, c.createRealm(realm))
+	assert.Nil(t, trustCommands)
+
+	ca := generateTestCA(t)
+	_, err = clientset.CoreV1().Secrets("ns").Update(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "realm-a-ca", Namespace: "ns"},
+		Data:       map[string][]byte{PullCASecretKey: ca},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.createRealm(realm))
+	assert.Equal(t, []string{"update-ca-trust extract"}, trustCommands)
+	trusted, err := ioutil.ReadFile(filepath.Join(caAnchorsDir, "rook-ns-realm-a.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, ca, trusted)
+
+	// the trust is not updated again for the same CA
+	require.NoError(t, c.createRealm(realm))
+	assert.Equal(t, []string{"update-ca-trust extract"}, trustCommands)
+
+	// the endpoint and the secret of the pull are required
+	realm.Spec.Pull.Endpoint = ""
+	assert.Error(t, validateRealm(realm))
+}
+
+// generateTestCA returns a PEM encoded self-signed CA certificate
+func generateTestCA(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multisite
+
+import (
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkSyncStatus periodically reports the sync status of the zones in their status until the stop channel is closed
+func (c *ZoneController) checkSyncStatus(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping the sync status checks of the object zones")
+			return
+
+		case <-time.After(syncStatusInterval):
+			c.updateSyncStatus()
+		}
+	}
+}
+
+func (c *ZoneController) updateSyncStatus() {
+	zones, err := c.context.RookClientset.CephV1().CephObjectZones(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Errorf("failed to list the object zones. %v", err)
+		return
+	}
+	for i := range zones.Items {
+		zone := &zones.Items[i]
+		// the sync status is only checked once the zone is created
+		if zone.Status == nil || zone.Status.Phase != k8sutil.ReadyStatus {
+			continue
+		}
+		if err := c.updateZoneSyncStatus(zone); err != nil {
+			logger.Warningf("failed to update the sync status of object zone %q. %v", zone.Name, err)
+		}
+	}
+}
+
+func (c *ZoneController) updateZoneSyncStatus(zone *cephv1.CephObjectZone) error {
+	objContext, err := object.GetZoneContext(c.context, zone.Namespace, zone.Name)
+	if err != nil {
+		return err
+	}
+	metadataSync, dataSync, err := object.GetSyncStatus(objContext)
+	if err != nil {
+		return err
+	}
+
+	zone.Status.SyncStatus = &cephv1.ZoneSyncStatus{
+		MetadataSync: metadataSync,
+		DataSync:     dataSync,
+		LastChecked:  time.Now().UTC().Format(time.RFC3339),
+	}
+	_, err = c.context.RookClientset.CephV1().CephObjectZones(zone.Namespace).Update(zone)
+	return err
+}
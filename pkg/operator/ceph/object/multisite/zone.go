@@ -0,0 +1,258 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multisite
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ZoneController represents a controller object for object zone custom resources
+type ZoneController struct {
+	context     *clusterd.Context
+	clusterSpec *cephv1.ClusterSpec
+	namespace   string
+}
+
+// NewZoneController create controller for watching object zone custom resources created
+func NewZoneController(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, namespace string) *ZoneController {
+	return &ZoneController{
+		context:     context,
+		clusterSpec: clusterSpec,
+		namespace:   namespace,
+	}
+}
+
+// StartWatch watches for instances of ObjectZone custom resources and acts on them
+func (c *ZoneController) StartWatch(stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching object zone resources in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(ObjectZoneResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephObjectZone{}, stopCh)
+	go c.checkSyncStatus(stopCh)
+	return nil
+}
+
+func (c *ZoneController) onAdd(obj interface{}) {
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+		logger.Warningf("Creating object zone for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+
+	zone, err := getZoneObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object zone object. %v", err)
+		return
+	}
+	c.createOrUpdateZone(zone)
+}
+
+func (c *ZoneController) onUpdate(oldObj, newObj interface{}) {
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+		logger.Warningf("Updating object zone for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+
+	oldZone, err := getZoneObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old object zone object. %v", err)
+		return
+	}
+	newZone, err := getZoneObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new object zone object. %v", err)
+		return
+	}
+	if reflect.DeepEqual(oldZone.Spec, newZone.Spec) {
+		logger.Debugf("object zone %q did not change", newZone.Name)
+		return
+	}
+
+	// the missing pools of the zone are created
+	c.createOrUpdateZone(newZone)
+}
+
+func (c *ZoneController) createOrUpdateZone(zone *cephv1.CephObjectZone) {
+	updateCephObjectZoneStatus(zone.Name, zone.Namespace, k8sutil.ProcessingStatus, c.context)
+	if err := c.createZone(zone); err != nil {
+		logger.Errorf("failed to create object zone %q. %v", zone.Name, err)
+		updateCephObjectZoneStatus(zone.Name, zone.Namespace, k8sutil.FailedStatus, c.context)
+		return
+	}
+	updateCephObjectZoneStatus(zone.Name, zone.Namespace, k8sutil.ReadyStatus, c.context)
+}
+
+func (c *ZoneController) createZone(zone *cephv1.CephObjectZone) error {
+	if err := validateZone(c.context, zone); err != nil {
+		return errors.Wrapf(err, "invalid zone %s arguments", zone.Name)
+	}
+	objContext, err := object.GetZoneContext(c.context, zone.Namespace, zone.Name)
+	if err != nil {
+		return err
+	}
+	if err := waitForParent(func() bool { return object.ZoneGroupExists(objContext) }); err != nil {
+		return errors.Wrapf(err, "failed to wait for zone group %q to be created", objContext.ZoneGroup)
+	}
+
+	keys, err := c.getRealmKeys(objContext)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("creating object zone %q in zone group %q", zone.Name, objContext.ZoneGroup)
+	if err := object.CreateZone(objContext, *zone.Spec.MetadataPool.ToModel(""), *zone.Spec.DataPool.ToModel(""), keys); err != nil {
+		return err
+	}
+	if keys != nil {
+		return nil
+	}
+
+	// the first zone of the realm in the cluster creates the system user the other zones sync with
+	keys, err = object.CreateSystemUser(objContext)
+	if err != nil {
+		return err
+	}
+	return c.saveRealmKeys(objContext, keys)
+}
+
+// getRealmKeys returns the keys of the system user of the realm of the zone, from the pull secret of the realm if it is
+// pulled from another cluster, or from the keys secret of the realm if they were already created in this cluster
+func (c *ZoneController) getRealmKeys(objContext *object.Context) (*object.SystemKeys, error) {
+	realm, err := c.context.RookClientset.CephV1().CephObjectRealms(c.namespace).Get(objContext.Realm, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get realm %q", objContext.Realm)
+	}
+	if realm.Spec.Pull != nil {
+		return getSystemKeys(c.context, c.namespace, realm.Spec.Pull.SecretName)
+	}
+
+	secretName := object.RealmKeysSecretName(objContext.Realm)
+	if _, err := c.context.Clientset.CoreV1().Secrets(c.namespace).Get(secretName, metav1.GetOptions{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get secret %q", secretName)
+	}
+	return getSystemKeys(c.context, c.namespace, secretName)
+}
+
+// saveRealmKeys stores the keys of the system user of the realm in a secret, which the realms pulled in other clusters
+// refer to
+func (c *ZoneController) saveRealmKeys(objContext *object.Context, keys *object.SystemKeys) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      object.RealmKeysSecretName(objContext.Realm),
+			Namespace: c.namespace,
+			Labels: map[string]string{
+				"app":          "rook-ceph-rgw",
+				"rook_cluster": c.namespace,
+				"realm":        objContext.Realm,
+			},
+		},
+		StringData: map[string]string{
+			object.SystemAccessKeyName: keys.AccessKey,
+			object.SystemSecretKeyName: keys.SecretKey,
+		},
+		Type: k8sutil.RookType,
+	}
+	if _, err := c.context.Clientset.CoreV1().Secrets(c.namespace).Create(secret); err != nil {
+		return errors.Wrapf(err, "failed to save the keys of realm %q", objContext.Realm)
+	}
+	logger.Infof("saved the keys of the system user of realm %q in secret %q", objContext.Realm, secret.Name)
+	return nil
+}
+
+func (c *ZoneController) onDelete(obj interface{}) {
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+		logger.Warningf("Deleting object zone for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+
+	zone, err := getZoneObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object zone object. %v", err)
+		return
+	}
+
+	objContext, err := object.GetZoneContext(c.context, zone.Namespace, zone.Name)
+	if err != nil {
+		// the zone is deleted even if its zone group or realm were deleted first
+		zoneGroup, zgErr := c.context.RookClientset.CephV1().CephObjectZoneGroups(zone.Namespace).Get(zone.Spec.ZoneGroup, metav1.GetOptions{})
+		if zgErr != nil {
+			logger.Errorf("failed to delete object zone %q. %v", zone.Name, err)
+			return
+		}
+		objContext = object.NewMultisiteContext(c.context, zone.Namespace, zoneGroup.Spec.Realm, zone.Spec.ZoneGroup, zone.Name)
+	}
+	if err := object.DeleteZone(objContext, zone.Spec.PreservePoolsOnDelete); err != nil {
+		logger.Errorf("failed to delete object zone %q. %v", zone.Name, err)
+	}
+}
+
+func validateZone(context *clusterd.Context, zone *cephv1.CephObjectZone) error {
+	if zone.Spec.ZoneGroup == "" {
+		return errors.New("missing zone group")
+	}
+	if err := pool.ValidatePoolSpec(context, zone.Namespace, &zone.Spec.MetadataPool); err != nil {
+		return errors.Wrapf(err, "invalid metadata pool spec")
+	}
+	if err := pool.ValidatePoolSpec(context, zone.Namespace, &zone.Spec.DataPool); err != nil {
+		return errors.Wrapf(err, "invalid data pool spec")
+	}
+	return nil
+}
+
+func getZoneObject(obj interface{}) (*cephv1.CephObjectZone, error) {
+	zone, ok := obj.(*cephv1.CephObjectZone)
+	if ok {
+		return zone.DeepCopy(), nil
+	}
+	return nil, errors.Errorf("not a known object zone object %+v", obj)
+}
+
+func updateCephObjectZoneStatus(name, namespace, status string, context *clusterd.Context) {
+	zone, err := context.RookClientset.CephV1().CephObjectZones(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("Unable to update the cephObjectZone %s status %v", name, err)
+		return
+	}
+	if zone.Status == nil {
+		zone.Status = &cephv1.ObjectZoneStatus{}
+	} else if zone.Status.Phase == status {
+		return
+	}
+	zone.Status.Phase = status
+	if _, err := context.RookClientset.CephV1().CephObjectZones(namespace).Update(zone); err != nil {
+		logger.Errorf("Unable to update the cephObjectZone %s status %v", name, err)
+	}
+}
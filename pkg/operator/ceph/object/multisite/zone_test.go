@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multisite
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateZone(t *testing.T) {
+	waitForParentInterval = time.Millisecond
+	waitForParentTimeout = 10 * time.Millisecond
+	zoneGroupCreated := false
+	var zoneCreated bool
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		switch strings.Join(args[:2], " ") {
+		case "zonegroup get":
+			if !zoneGroupCreated {
+				return "", errors.New("zone group not found")
+			}
+			return `{"master_zone":"","zones":[]}`, nil
+		case "zone get":
+			return "", errors.New("zone not found")
+		case "zone create":
+			zoneCreated = true
+			return `{"id":"zone-id"}`, nil
+		case "user info":
+			return "", errors.New("user not found")
+		case "user create":
+			return `{"user_id":"realm-a-system-user","keys":[{"access_key":"access","secret_key":"secret"}]}`, nil
+		case "zone modify", "period update":
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		return "", nil
+	}
+
+	zone := &cephv1.CephObjectZone{
+		ObjectMeta: metav1.ObjectMeta{Name: "zone-a", Namespace: "ns"},
+		Spec: cephv1.ObjectZoneSpec{
+			ZoneGroup:    "zonegroup-a",
+			MetadataPool: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}},
+			DataPool:     cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}},
+		},
+	}
+	zoneGroup := &cephv1.CephObjectZoneGroup{ObjectMeta: metav1.ObjectMeta{Name: "zonegroup-a", Namespace: "ns"}, Spec: cephv1.ObjectZoneGroupSpec{Realm: "realm-a"}}
+	realm := &cephv1.CephObjectRealm{ObjectMeta: metav1.ObjectMeta{Name: "realm-a", Namespace: "ns"}}
+	rookClientset := rookfake.NewSimpleClientset(zone, zoneGroup, realm)
+	clientset := test.New(1)
+	c := NewZoneController(&clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}, &cephv1.ClusterSpec{}, "ns")
+	getPhase := func() string {
+		z, err := rookClientset.CephV1().CephObjectZones("ns").Get("zone-a", metav1.GetOptions{})
+		require.NoError(t, err)
+		return z.Status.Phase
+	}
+
+	// the zone waits for its zone group to be created
+	c.onAdd(zone)
+	assert.False(t, zoneCreated)
+	assert.Equal(t, k8sutil.FailedStatus, getPhase())
+
+	zoneGroupCreated = true
+	c.onAdd(zone)
+	assert.True(t, zoneCreated)
+	assert.Equal(t, k8sutil.ReadyStatus, getPhase())
+
+	// the keys of the system user of the realm are saved for the realms pulled in other clusters
+	secret, err := clientset.CoreV1().Secrets("ns").Get("realm-a-keys", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "access", secret.StringData[object.SystemAccessKeyName])
+	assert.Equal(t, "secret", secret.StringData[object.SystemSecretKeyName])
+
+	// the zone must belong to a zone group
+	assert.Error(t, validateZone(c.context, &cephv1.CephObjectZone{ObjectMeta: metav1.ObjectMeta{Name: "zone-b", Namespace: "ns"}}))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multisite
+
+import (
+	"github.com/pkg/errors"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ZoneGroupController represents a controller object for object zone group custom resources
+type ZoneGroupController struct {
+	context     *clusterd.Context
+	clusterSpec *cephv1.ClusterSpec
+	namespace   string
+}
+
+// NewZoneGroupController create controller for watching object zone group custom resources created
+func NewZoneGroupController(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, namespace string) *ZoneGroupController {
+	return &ZoneGroupController{
+		context:     context,
+		clusterSpec: clusterSpec,
+		namespace:   namespace,
+	}
+}
+
+// StartWatch watches for instances of ObjectZoneGroup custom resources and acts on them
+func (c *ZoneGroupController) StartWatch(stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching object zone group resources in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(ObjectZoneGroupResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephObjectZoneGroup{}, stopCh)
+	return nil
+}
+
+func (c *ZoneGroupController) onAdd(obj interface{}) {
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+		logger.Warningf("Creating object zone group for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+
+	zoneGroup, err := getZoneGroupObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object zone group object. %v", err)
+		return
+	}
+	updateCephObjectZoneGroupStatus(zoneGroup.Name, zoneGroup.Namespace, k8sutil.ProcessingStatus, c.context)
+
+	if err := c.createZoneGroup(zoneGroup); err != nil {
+		logger.Errorf("failed to create object zone group %q. %v", zoneGroup.Name, err)
+		updateCephObjectZoneGroupStatus(zoneGroup.Name, zoneGroup.Namespace, k8sutil.FailedStatus, c.context)
+		return
+	}
+	updateCephObjectZoneGroupStatus(zoneGroup.Name, zoneGroup.Namespace, k8sutil.ReadyStatus, c.context)
+}
+
+func (c *ZoneGroupController) createZoneGroup(zoneGroup *cephv1.CephObjectZoneGroup) error {
+	if zoneGroup.Spec.Realm == "" {
+		return errors.New("missing realm")
+	}
+	if _, err := c.context.RookClientset.CephV1().CephObjectRealms(zoneGroup.Namespace).Get(zoneGroup.Spec.Realm, metav1.GetOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to get realm %q", zoneGroup.Spec.Realm)
+	}
+
+	objContext := object.NewMultisiteContext(c.context, zoneGroup.Namespace, zoneGroup.Spec.Realm, zoneGroup.Name, "")
+	if err := waitForParent(func() bool { return object.RealmExists(objContext) }); err != nil {
+		return errors.Wrapf(err, "failed to wait for realm %q to be created", zoneGroup.Spec.Realm)
+	}
+
+	logger.Infof("creating object zone group %q in realm %q", zoneGroup.Name, zoneGroup.Spec.Realm)
+	return object.CreateZoneGroup(objContext)
+}
+
+func (c *ZoneGroupController) onDelete(obj interface{}) {
+	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+		logger.Warningf("Deleting object zone group for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+
+	zoneGroup, err := getZoneGroupObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object zone group object. %v", err)
+		return
+	}
+
+	objContext := object.NewMultisiteContext(c.context, zoneGroup.Namespace, zoneGroup.Spec.Realm, zoneGroup.Name, "")
+	if err := object.DeleteZoneGroup(objContext); err != nil {
+		logger.Errorf("failed to delete object zone group %q. %v", zoneGroup.Name, err)
+	}
+}
+
+func getZoneGroupObject(obj interface{}) (*cephv1.CephObjectZoneGroup, error) {
+	zoneGroup, ok := obj.(*cephv1.CephObjectZoneGroup)
+	if ok {
+		return zoneGroup.DeepCopy(), nil
+	}
+	return nil, errors.Errorf("not a known object zone group object %+v", obj)
+}
+
+func updateCephObjectZoneGroupStatus(name, namespace, status string, context *clusterd.Context) {
+	zoneGroup, err := context.RookClientset.CephV1().CephObjectZoneGroups(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("Unable to update the cephObjectZoneGroup %s status %v", name, err)
+		return
+	}
+	if zoneGroup.Status == nil {
+		zoneGroup.Status = &cephv1.Status{}
+	} else if zoneGroup.Status.Phase == status {
+		return
+	}
+	zoneGroup.Status.Phase = status
+	if _, err := context.RookClientset.CephV1().CephObjectZoneGroups(namespace).Update(zoneGroup); err != nil {
+		logger.Errorf("Unable to update the cephObjectZoneGroup %s status %v", name, err)
+	}
+}
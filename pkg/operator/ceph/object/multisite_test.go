@@ -0,0 +1,349 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/model"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// multisiteCommand returns the radosgw-admin command without the flags of the cluster
+func multisiteCommand(args []string) string {
+	var command []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--cluster=") && !strings.HasPrefix(arg, "--conf=") && !strings.HasPrefix(arg, "--keyring=") && !strings.HasPrefix(arg, "--name=") {
+			command = append(command, arg)
+		}
+	}
+	return strings.Join(command, " ")
+}
+
+func TestCreateZoneGroup(t *testing.T) {
+	var commands []string
+	period := `{"master_zonegroup":""}`
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		cmd := strings.Join(args[:2], " ")
+		switch cmd {
+		case "zonegroup get":
+			return "", errors.New("zone group not found")
+		case "period get":
+			return period, nil
+		case "zonegroup create", "period update":
+			commands = append(commands, multisiteCommand(args))
+			return `{"id":"zonegroup-id"}`, nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	c := NewMultisiteContext(&clusterd.Context{Executor: executor}, "ns", "realm-a", "zonegroup-a", "")
+
+	// the first zone group of the realm is its master
+	require.NoError(t, CreateZoneGroup(c))
+	assert.Equal(t, []string{
+		"zonegroup create --rgw-realm=realm-a --rgw-zonegroup=zonegroup-a --master",
+		"period update --commit --rgw-realm=realm-a",
+	}, commands)
+
+	commands = nil
+	period = `{"master_zonegroup":"zonegroup-id"}`
+	require.NoError(t, CreateZoneGroup(c))
+	assert.Equal(t, "zonegroup create --rgw-realm=realm-a --rgw-zonegroup=zonegroup-a", commands[0])
+}
+
+func TestCreateZone(t *testing.T) {
+	var commands []string
+	zoneGroup := `{"master_zone":"master-id","zones":[]}`
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		cmd := strings.Join(args[:2], " ")
+		switch cmd {
+		case "zone get":
+			return "", errors.New("zone not found")
+		case "zonegroup get":
+			return zoneGroup, nil
+		case "zone create", "period update":
+			commands = append(commands, multisiteCommand(args))
+			return `{"id":"zone-id"}`, nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	var pools []string
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+			return "", errors.New("pool not found")
+		}
+		if args[0] == "osd" && args[1] == "pool" && args[2] == "create" {
+			pools = append(pools, args[3])
+		}
+		return "", nil
+	}
+	c := NewMultisiteContext(&clusterd.Context{Executor: executor}, "ns", "realm-a", "zonegroup-a", "zone-a")
+
+	// the pools of the zone are named after the zone
+	poolSpec := model.Pool{ReplicatedConfig: model.ReplicatedPoolConfig{Size: 1}}
+	require.NoError(t, CreateZone(c, poolSpec, poolSpec, nil))
+	assert.Contains(t, pools, "zone-a.rgw.meta")
+	assert.Contains(t, pools, "zone-a.rgw.buckets.data")
+	assert.Contains(t, pools, ".rgw.root")
+	assert.Equal(t, []string{
+		"zone create --rgw-realm=realm-a --rgw-zonegroup=zonegroup-a --rgw-zone=zone-a",
+		"period update --commit --rgw-realm=realm-a",
+	}, commands)
+
+	// the first zone of the zone group is its master
+	zoneGroup = `{"master_zone":"","zones":[]}`
+	commands = nil
+	require.NoError(t, CreateZone(c, poolSpec, poolSpec, nil))
+	assert.Equal(t, "zone create --rgw-realm=realm-a --rgw-zonegroup=zonegroup-a --rgw-zone=zone-a --master", commands[0])
+
+	// the zone syncs with the keys of the system user of the realm
+	commands = nil
+	require.NoError(t, CreateZone(c, poolSpec, poolSpec, &SystemKeys{AccessKey: "access", SecretKey: "secret"}))
+	assert.Equal(t, "zone create --rgw-realm=realm-a --rgw-zonegroup=zonegroup-a --rgw-zone=zone-a --master --access-key=access --secret=secret", commands[0])
+}
+
+func TestPullRealm(t *testing.T) {
+	var commands []string
+	realmExists := false
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		cmd := strings.Join(args[:2], " ")
+		switch cmd {
+		case "realm get":
+			if !realmExists {
+				return "", errors.New("realm not found")
+			}
+			return `{"id":"realm-id"}`, nil
+		case "realm list":
+			return `{"realms":[]}`, nil
+		case "realm pull":
+			// the keys are not logged
+			assert.True(t, debug)
+			commands = append(commands, multisiteCommand(args))
+			return `{"id":"realm-id"}`, nil
+		case "realm default":
+			commands = append(commands, multisiteCommand(args))
+			return `{"id":"realm-id"}`, nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	c := NewMultisiteContext(&clusterd.Context{Executor: executor}, "ns", "realm-a", "", "")
+	keys := &SystemKeys{AccessKey: "access", SecretKey: "secret"}
+
+	// the first realm of the cluster is its default
+	require.NoError(t, PullRealm(c, "http://1.2.3.4:80", keys))
+	assert.Equal(t, []string{
+		"realm pull --rgw-realm=realm-a --url=http://1.2.3.4:80 --access-key=access --secret=secret",
+		"realm default --rgw-realm=realm-a",
+	}, commands)
+
+	// the realm is only pulled once
+	commands = nil
+	realmExists = true
+	require.NoError(t, PullRealm(c, "http://1.2.3.4:80", keys))
+	assert.Empty(t, commands)
+}
+
+func TestCreateSystemUser(t *testing.T) {
+	var commands []string
+	userExists := false
+	user := `{"user_id":"realm-a-system-user","keys":[{"access_key":"access","secret_key":"secret"}]}`
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		cmd := strings.Join(args[:2], " ")
+		switch cmd {
+		case "user info":
+			if !userExists {
+				return "", errors.New("user not found")
+			}
+			return user, nil
+		case "user create":
+			commands = append(commands, cmd)
+			return user, nil
+		case "zone modify", "period update":
+			commands = append(commands, multisiteCommand(args))
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	c := NewMultisiteContext(&clusterd.Context{Executor: executor}, "ns", "realm-a", "zonegroup-a", "zone-a")
+
+	keys, err := CreateSystemUser(c)
+	require.NoError(t, err)
+	assert.Equal(t, &SystemKeys{AccessKey: "access", SecretKey: "secret"}, keys)
+	assert.Equal(t, []string{
+		"user create",
+		"zone modify --rgw-realm=realm-a --rgw-zonegroup=zonegroup-a --rgw-zone=zone-a --access-key=access --secret=secret",
+		"period update --commit --rgw-realm=realm-a",
+	}, commands)
+
+	// the existing system user is not created again
+	commands = nil
+	userExists = true
+	keys, err = CreateSystemUser(c)
+	require.NoError(t, err)
+	assert.Equal(t, "access", keys.AccessKey)
+	assert.NotContains(t, commands, "user create")
+}
+
+func TestGetSyncStatus(t *testing.T) {
+	zoneID := "zone-b-id"
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		cmd := strings.Join(args[:2], " ")
+		switch cmd {
+		case "zonegroup get":
+			return `{"master_zone":"zone-a-id","zones":[{"id":"zone-a-id","name":"zone-a"},{"id":"zone-b-id","name":"zone-b"}]}`, nil
+		case "zone get":
+			return fmt.Sprintf(`{"id":"%s"}`, zoneID), nil
+		case "metadata sync":
+			return `{"sync_status":{"info":{"status":"sync"}}}`, nil
+		case "data sync":
+			if strings.Contains(multisiteCommand(args), "--source-zone=zone-a") {
+				return `{"sync_status":{"info":{"status":"init"}}}`, nil
+			}
+			return `{"sync_status":{"info":{"status":"sync"}}}`, nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	c := NewMultisiteContext(&clusterd.Context{Executor: executor}, "ns", "realm-a", "zonegroup-a", "zone-b")
+
+	metadataSync, dataSync, err := GetSyncStatus(c)
+	require.NoError(t, err)
+	assert.Equal(t, "sync", metadataSync)
+	assert.Equal(t, map[string]string{"zone-a": "init"}, dataSync)
+
+	// the master zone doesn't sync the metadata
+	zoneID = "zone-a-id"
+	c = NewMultisiteContext(&clusterd.Context{Executor: executor}, "ns", "realm-a", "zonegroup-a", "zone-a")
+	metadataSync, dataSync, err = GetSyncStatus(c)
+	require.NoError(t, err)
+	assert.Equal(t, "", metadataSync)
+	assert.Equal(t, map[string]string{"zone-b": "sync"}, dataSync)
+}
+
+func TestSetZoneEndpoints(t *testing.T) {
+	var commands []string
+	zoneGroup := `{"master_zone":"zone-id","endpoints":[],"zones":[{"name":"zone-a","endpoints":[]}]}`
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		cmd := strings.Join(args[:2], " ")
+		switch cmd {
+		case "zone get":
+			return `{"id":"zone-id"}`, nil
+		case "zonegroup get":
+			return zoneGroup, nil
+		case "zone modify", "zonegroup modify", "period update":
+			commands = append(commands, cmd)
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	c := NewMultisiteContext(&clusterd.Context{Executor: executor}, "ns", "realm-a", "zonegroup-a", "zone-a")
+
+	// the endpoints of the master zone are also those of the zone group
+	require.NoError(t, SetZoneEndpoints(c, []string{"http://1.2.3.4:80"}))
+	assert.Equal(t, []string{"zone modify", "zonegroup modify", "period update"}, commands)
+
+	// nothing changes when the endpoints are already set
+	commands = nil
+	zoneGroup = `{"master_zone":"zone-id","endpoints":["http://1.2.3.4:80"],"zones":[{"name":"zone-a","endpoints":["http://1.2.3.4:80"]}]}`
+	require.NoError(t, SetZoneEndpoints(c, []string{"http://1.2.3.4:80"}))
+	assert.Empty(t, commands)
+
+	// the zone must exist in the zone group
+	zoneGroup = `{"master_zone":"zone-id","zones":[]}`
+	assert.Error(t, SetZoneEndpoints(c, []string{"http://1.2.3.4:80"}))
+}
+
+func TestStoreContext(t *testing.T) {
+	store := &cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "ns"}}
+	rookClientset := rookfake.NewSimpleClientset(store)
+	context := &clusterd.Context{RookClientset: rookClientset}
+
+	// a store without zone has its own realm
+	c, err := NewStoreContext(context, "my-store", "ns")
+	require.NoError(t, err)
+	assert.Equal(t, "my-store", c.Name)
+	assert.Equal(t, "", c.Realm)
+
+	// the zone of the store must exist with its zone group and realm
+	store.Spec.Zone.Name = "zone-a"
+	_, err = rookClientset.CephV1().CephObjectStores("ns").Update(store)
+	require.NoError(t, err)
+	_, err = NewStoreContext(context, "my-store", "ns")
+	assert.Error(t, err)
+
+	_, err = rookClientset.CephV1().CephObjectZones("ns").Create(&cephv1.CephObjectZone{
+		ObjectMeta: metav1.ObjectMeta{Name: "zone-a", Namespace: "ns"}, Spec: cephv1.ObjectZoneSpec{ZoneGroup: "zonegroup-a"}})
+	require.NoError(t, err)
+	_, err = rookClientset.CephV1().CephObjectZoneGroups("ns").Create(&cephv1.CephObjectZoneGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "zonegroup-a", Namespace: "ns"}, Spec: cephv1.ObjectZoneGroupSpec{Realm: "realm-a"}})
+	require.NoError(t, err)
+	_, err = NewStoreContext(context, "my-store", "ns")
+	assert.Error(t, err)
+
+	_, err = rookClientset.CephV1().CephObjectRealms("ns").Create(&cephv1.CephObjectRealm{ObjectMeta: metav1.ObjectMeta{Name: "realm-a", Namespace: "ns"}})
+	require.NoError(t, err)
+	c, err = NewStoreContext(context, "my-store", "ns")
+	require.NoError(t, err)
+	assert.Equal(t, "my-store", c.Name)
+	assert.Equal(t, "realm-a", c.Realm)
+	assert.Equal(t, "zonegroup-a", c.ZoneGroup)
+	assert.Equal(t, "zone-a", c.Zone)
+}
+
+func TestWaitForZone(t *testing.T) {
+	waitForZoneInterval = time.Millisecond
+	waitForZoneTimeout = 10 * time.Millisecond
+	zoneGroup := `{"zones":[]}`
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		if strings.Join(args[:2], " ") == "zonegroup get" {
+			return zoneGroup, nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	store := &cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "ns"}}
+	store.Spec.Zone.Name = "zone-a"
+	rookClientset := rookfake.NewSimpleClientset(store,
+		&cephv1.CephObjectZone{ObjectMeta: metav1.ObjectMeta{Name: "zone-a", Namespace: "ns"}, Spec: cephv1.ObjectZoneSpec{ZoneGroup: "zonegroup-a"}},
+		&cephv1.CephObjectZoneGroup{ObjectMeta: metav1.ObjectMeta{Name: "zonegroup-a", Namespace: "ns"}, Spec: cephv1.ObjectZoneGroupSpec{Realm: "realm-a"}},
+		&cephv1.CephObjectRealm{ObjectMeta: metav1.ObjectMeta{Name: "realm-a", Namespace: "ns"}})
+	context := &clusterd.Context{Executor: executor, RookClientset: rookClientset}
+
+	// the wait times out while the zone is not in the zone group
+	_, err := waitForZone(context, store)
+	assert.Error(t, err)
+
+	zoneGroup = `{"zones":[{"name":"zone-a"}]}`
+	c, err := waitForZone(context, store)
+	require.NoError(t, err)
+	assert.Equal(t, "zone-a", c.Zone)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notification to manage the bucket topics and the bucket notifications of the rook object stores.
+package notification
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-object-notification")
+
+// the interval to retry the notifications waiting for their topic or their bucket to be created
+var retryNotificationInterval = time.Minute
+
+// BucketTopicResource represents the bucket topic custom resource
+var BucketTopicResource = opkit.CustomResource{
+	Name:    "cephbuckettopic",
+	Plural:  "cephbuckettopics",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephBucketTopic{}).Name(),
+}
+
+// BucketNotificationResource represents the bucket notification custom resource
+var BucketNotificationResource = opkit.CustomResource{
+	Name:    "cephbucketnotification",
+	Plural:  "cephbucketnotifications",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephBucketNotification{}).Name(),
+}
+
+// storeDisabled returns whether the topics and notifications of a store cannot be managed in an external cluster
+// without a Ceph image, unless the store is served by external gateways
+func storeDisabled(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, namespace, storeName string) bool {
+	if !clusterSpec.External.Enable || clusterSpec.CephVersion.Image != "" {
+		return false
+	}
+	store, err := context.RookClientset.CephV1().CephObjectStores(namespace).Get(storeName, metav1.GetOptions{})
+	if err != nil {
+		logger.Debugf("failed to get object store %q. %v", storeName, err)
+		return true
+	}
+	return !store.Spec.IsExternal()
+}
+
+// newStoreSession returns an aws session to the service of an object store with the keys of an rgw user
+func newStoreSession(context *clusterd.Context, namespace, storeName string, user *object.ObjectUser) (*session.Session, error) {
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return nil, errors.Errorf("user %q has no keys", user.UserID)
+	}
+	store, err := context.RookClientset.CephV1().CephObjectStores(namespace).Get(storeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get object store %q", storeName)
+	}
+	port, tlsEnabled, caCert, err := object.GetStoreServicePort(context, store)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("%s-%s.%s:%d", object.AppName, storeName, namespace, port)
+	return bucket.NewSession(*user.AccessKey, *user.SecretKey, endpoint, tlsEnabled, caCert)
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// the events of the objects of a bucket that the gateways notify
+var supportedEvents = []string{
+	"s3:ObjectCreated:*",
+	"s3:ObjectCreated:Put",
+	"s3:ObjectCreated:Post",
+	"s3:ObjectCreated:Copy",
+	"s3:ObjectCreated:CompleteMultipartUpload",
+	"s3:ObjectRemoved:*",
+	"s3:ObjectRemoved:Delete",
+	"s3:ObjectRemoved:DeleteMarkerCreated",
+}
+
+// notificationLock serializes the updates of the notification configurations of the buckets, which are read and put whole
+var notificationLock sync.Mutex
+
+// NotificationController represents a controller object for bucket notification custom resources
+type NotificationController struct {
+	context     *clusterd.Context
+	clusterSpec *cephv1.ClusterSpec
+	namespace   string
+}
+
+// NewNotificationController create controller for watching bucket notification custom resources created
+func NewNotificationController(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, namespace string) *NotificationController {
+	return &NotificationController{
+		context:     context,
+		clusterSpec: clusterSpec,
+		namespace:   namespace,
+	}
+}
+
+// StartWatch watches for instances of BucketNotification custom resources and acts on them
+func (c *NotificationController) StartWatch(stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching bucket notification resources in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(BucketNotificationResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephBucketNotification{}, stopCh)
+	go c.retryPendingNotifications(stopCh)
+	return nil
+}
+
+func (c *NotificationController) onAdd(obj interface{}) {
+	notification, err := getNotificationObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get bucket notification object. %v", err)
+		return
+	}
+	c.createNotification(notification)
+}
+
+func (c *NotificationController) onUpdate(oldObj, newObj interface{}) {
+	oldNotification, err := getNotificationObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old bucket notification object. %v", err)
+		return
+	}
+	newNotification, err := getNotificationObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new bucket notification object. %v", err)
+		return
+	}
+	if reflect.DeepEqual(oldNotification.Spec, newNotification.Spec) {
+		logger.Debugf("bucket notification %q did not change", newNotification.Name)
+		return
+	}
+
+	// the notification is removed from its previous bucket and topic before it is created again
+	if err := c.deleteNotification(oldNotification); err != nil {
+		logger.Errorf("failed to delete the previous bucket notification %q. %v", oldNotification.Name, err)
+	}
+	c.createNotification(newNotification)
+}
+
+func (c *NotificationController) onDelete(obj interface{}) {
+	notification, err := getNotificationObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get bucket notification object. %v", err)
+		return
+	}
+	if err := c.deleteNotification(notification); err != nil {
+		logger.Errorf("failed to delete bucket notification %q. %v", notification.Name, err)
+	}
+}
+
+func (c *NotificationController) createNotification(notification *cephv1.CephBucketNotification) {
+	notificationLock.Lock()
+	defer notificationLock.Unlock()
+
+	if err := validateNotification(notification); err != nil {
+		logger.Errorf("invalid bucket notification %s arguments. %v", notification.Name, err)
+		updateCephBucketNotificationStatus(notification.Name, notification.Namespace, k8sutil.FailedStatus, "", c.context)
+		return
+	}
+
+	// the notification is created when its topic gets an ARN, or when the pending notifications are retried
+	topic, err := c.context.RookClientset.CephV1().CephBucketTopics(notification.Namespace).Get(notification.Spec.Topic, metav1.GetOptions{})
+	if err != nil || topic.Status == nil || topic.Status.ARN == "" {
+		logger.Infof("waiting for topic %q of bucket notification %q to be created", notification.Spec.Topic, notification.Name)
+		updateCephBucketNotificationStatus(notification.Name, notification.Namespace, k8sutil.ProcessingStatus, "", c.context)
+		return
+	}
+	if storeDisabled(c.context, c.clusterSpec, topic.Namespace, topic.Spec.Store) {
+		logger.Warningf("Creating bucket notification for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+	updateCephBucketNotificationStatus(notification.Name, notification.Namespace, k8sutil.ProcessingStatus, "", c.context)
+
+	client, rgwerr, err := c.bucketClient(topic.Spec.Store, notification)
+	if err != nil {
+		if rgwerr == object.RGWErrorNotFound {
+			logger.Infof("waiting for bucket %q of bucket notification %q to be created", notification.Spec.Bucket, notification.Name)
+			return
+		}
+		logger.Errorf("failed to create bucket notification %q. %v", notification.Name, err)
+		updateCephBucketNotificationStatus(notification.Name, notification.Namespace, k8sutil.FailedStatus, "", c.context)
+		return
+	}
+	if err := putNotification(client, notification, topic.Status.ARN); err != nil {
+		logger.Errorf("failed to create bucket notification %q. %v", notification.Name, err)
+		updateCephBucketNotificationStatus(notification.Name, notification.Namespace, k8sutil.FailedStatus, "", c.context)
+		return
+	}
+	updateCephBucketNotificationStatus(notification.Name, notification.Namespace, k8sutil.ReadyStatus, topic.Spec.Store, c.context)
+}
+
+// createPendingNotifications creates the valid notifications that are not ready, only the ones of the topic if it is set
+func (c *NotificationController) createPendingNotifications(topicName string) {
+	notifications, err := c.context.RookClientset.CephV1().CephBucketNotifications(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Errorf("failed to list the bucket notifications. %v", err)
+		return
+	}
+	for i := range notifications.Items {
+		notification := &notifications.Items[i]
+		if topicName != "" && notification.Spec.Topic != topicName {
+			continue
+		}
+		if notification.Status != nil && notification.Status.Phase == k8sutil.ReadyStatus {
+			continue
+		}
+		if validateNotification(notification) != nil {
+			continue
+		}
+		c.createNotification(notification)
+	}
+}
+
+// retryPendingNotifications periodically creates the notifications waiting for their bucket, or that failed to be created
+func (c *NotificationController) retryPendingNotifications(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("Stopping the retries of the bucket notifications")
+			return
+
+		case <-time.After(retryNotificationInterval):
+			c.createPendingNotifications("")
+		}
+	}
+}
+
+// putNotification adds the notification to the configuration of the bucket, replacing the configuration with the same id
+func putNotification(client *s3.S3, notification *cephv1.CephBucketNotification, topicARN string) error {
+	logger.Infof("creating bucket notification %q of bucket %q to topic %q", notification.Name, notification.Spec.Bucket, notification.Spec.Topic)
+	current, err := client.GetBucketNotificationConfiguration(&s3.GetBucketNotificationConfigurationRequest{Bucket: aws.String(notification.Spec.Bucket)})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the notifications of bucket %q", notification.Spec.Bucket)
+	}
+	config := &s3.NotificationConfiguration{
+		TopicConfigurations: mergeTopicConfigurations(current.TopicConfigurations, topicConfiguration(notification, topicARN)),
+	}
+	if _, err := client.PutBucketNotificationConfiguration(&s3.PutBucketNotificationConfigurationInput{
+		Bucket:                    aws.String(notification.Spec.Bucket),
+		NotificationConfiguration: config,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to put the notifications of bucket %q", notification.Spec.Bucket)
+	}
+	return nil
+}
+
+// deleteNotification removes the notification from its bucket, in the store it was created in, or in the store of its
+// topic if it was created before its store was reported in its status. Nothing is done if the bucket is gone.
+func (c *NotificationController) deleteNotification(notification *cephv1.CephBucketNotification) error {
+	notificationLock.Lock()
+	defer notificationLock.Unlock()
+
+	storeName := ""
+	if notification.Status != nil {
+		storeName = notification.Status.Store
+	}
+	if storeName == "" {
+		topic, err := c.context.RookClientset.CephV1().CephBucketTopics(notification.Namespace).Get(notification.Spec.Topic, metav1.GetOptions{})
+		if err != nil {
+			logger.Infof("bucket notification %q was not created and its topic %q is not found. %v", notification.Name, notification.Spec.Topic, err)
+			return nil
+		}
+		storeName = topic.Spec.Store
+	}
+	if storeDisabled(c.context, c.clusterSpec, notification.Namespace, storeName) {
+		logger.Warningf("Deleting bucket notification for an external ceph cluster is disabled because no Ceph image is specified")
+		return nil
+	}
+	client, rgwerr, err := c.bucketClient(storeName, notification)
+	if err != nil {
+		if rgwerr == object.RGWErrorNotFound {
+			logger.Infof("bucket %q of bucket notification %q is not found", notification.Spec.Bucket, notification.Name)
+			return nil
+		}
+		return err
+	}
+	logger.Infof("deleting bucket notification %q of bucket %q", notification.Name, notification.Spec.Bucket)
+	return deleteBucketNotification(client, notification.Spec.Bucket, notification.Name)
+}
+
+// bucketClient returns an s3 client to the store with the keys of the owner of the bucket of the notification, since
+// only the owner of a bucket can configure its notifications. The rgw error code of getting the bucket is returned.
+func (c *NotificationController) bucketClient(storeName string, notification *cephv1.CephBucketNotification) (*s3.S3, int, error) {
+	objContext, err := object.NewStoreContext(c.context, storeName, notification.Namespace)
+	if err != nil {
+		return nil, object.RGWErrorUnknown, errors.Wrapf(err, "failed to get the context of object store %q", storeName)
+	}
+	bucket, rgwerr, err := object.GetBucket(objContext, notification.Spec.Bucket)
+	if err != nil {
+		return nil, rgwerr, errors.Wrapf(err, "failed to get bucket %q", notification.Spec.Bucket)
+	}
+	owner, _, err := object.GetUser(objContext, bucket.Owner)
+	if err != nil {
+		return nil, object.RGWErrorUnknown, errors.Wrapf(err, "failed to get the owner %q of bucket %q", bucket.Owner, notification.Spec.Bucket)
+	}
+	sess, err := newStoreSession(c.context, notification.Namespace, storeName, owner)
+	if err != nil {
+		return nil, object.RGWErrorUnknown, err
+	}
+	return s3.New(sess), object.RGWErrorNone, nil
+}
+
+// topicConfiguration returns the configuration of the notification of the bucket, with the name of the notification as id
+func topicConfiguration(notification *cephv1.CephBucketNotification, topicARN string) *s3.TopicConfiguration {
+	events := notification.Spec.Events
+	if len(events) == 0 {
+		events = []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}
+	}
+	config := &s3.TopicConfiguration{
+		Id:       aws.String(notification.Name),
+		TopicArn: aws.String(topicARN),
+		Events:   aws.StringSlice(events),
+	}
+	if filter := notification.Spec.Filter; filter != nil {
+		var rules []*s3.FilterRule
+		if filter.Prefix != "" {
+			rules = append(rules, &s3.FilterRule{Name: aws.String(s3.FilterRuleNamePrefix), Value: aws.String(filter.Prefix)})
+		}
+		if filter.Suffix != "" {
+			rules = append(rules, &s3.FilterRule{Name: aws.String(s3.FilterRuleNameSuffix), Value: aws.String(filter.Suffix)})
+		}
+		if len(rules) > 0 {
+			config.Filter = &s3.NotificationConfigurationFilter{Key: &s3.KeyFilter{FilterRules: rules}}
+		}
+	}
+	return config
+}
+
+// mergeTopicConfigurations returns the configurations of a bucket with the given configuration replacing the one with the same id
+func mergeTopicConfigurations(current []*s3.TopicConfiguration, config *s3.TopicConfiguration) []*s3.TopicConfiguration {
+	merged := []*s3.TopicConfiguration{}
+	for _, c := range current {
+		if aws.StringValue(c.Id) != aws.StringValue(config.Id) {
+			merged = append(merged, c)
+		}
+	}
+	return append(merged, config)
+}
+
+type deleteBucketNotificationInput struct {
+	_ struct{} `type:"structure"`
+
+	Bucket *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
+
+	ID *string `location:"querystring" locationName:"notification" type:"string" required:"true"`
+}
+
+type deleteBucketNotificationOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// deleteBucketNotification deletes a notification of a bucket with the notification API of the gateways, since the s3
+// API can only replace all the notifications of a bucket
+func deleteBucketNotification(client *s3.S3, bucket, id string) error {
+	op := &request.Operation{
+		Name:       "DeleteBucketNotification",
+		HTTPMethod: "DELETE",
+		HTTPPath:   "/{Bucket}",
+	}
+	input := &deleteBucketNotificationInput{Bucket: aws.String(bucket), ID: aws.String(id)}
+	if err := client.NewRequest(op, input, &deleteBucketNotificationOutput{}).Send(); err != nil {
+		return errors.Wrapf(err, "failed to delete notification %q of bucket %q", id, bucket)
+	}
+	return nil
+}
+
+func validateNotification(notification *cephv1.CephBucketNotification) error {
+	if notification.Spec.Topic == "" {
+		return errors.New("missing topic")
+	}
+	if notification.Spec.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+	for _, event := range notification.Spec.Events {
+		supported := false
+		for _, s := range supportedEvents {
+			if event == s {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return errors.Errorf("unsupported event %q. must be one of %q", event, supportedEvents)
+		}
+	}
+	return nil
+}
+
+func getNotificationObject(obj interface{}) (*cephv1.CephBucketNotification, error) {
+	notification, ok := obj.(*cephv1.CephBucketNotification)
+	if ok {
+		return notification.DeepCopy(), nil
+	}
+	return nil, errors.Errorf("not a known bucket notification object %+v", obj)
+}
+
+// updateCephBucketNotificationStatus updates the phase of the notification, and its store when it is set
+func updateCephBucketNotificationStatus(name, namespace, status, store string, context *clusterd.Context) {
+	notification, err := context.RookClientset.CephV1().CephBucketNotifications(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("Unable to update the cephBucketNotification %s status %v", name, err)
+		return
+	}
+	if notification.Status == nil {
+		notification.Status = &cephv1.BucketNotificationStatus{}
+	} else if notification.Status.Phase == status && (store == "" || notification.Status.Store == store) {
+		return
+	}
+	notification.Status.Phase = status
+	if store != "" {
+		notification.Status.Store = store
+	}
+	if _, err := context.RookClientset.CephV1().CephBucketNotifications(namespace).Update(notification); err != nil {
+		logger.Errorf("Unable to update the cephBucketNotification %s status %v", name, err)
+	}
+}
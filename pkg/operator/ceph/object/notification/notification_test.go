@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateNotification(t *testing.T) {
+	notification := &cephv1.CephBucketNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "notification-a", Namespace: "ns"},
+		Spec: cephv1.BucketNotificationSpec{
+			Topic:  "topic-a",
+			Bucket: "bucket-a",
+			Events: []string{"s3:ObjectCreated:Put", "s3:ObjectRemoved:*"},
+		},
+	}
+	assert.NoError(t, validateNotification(notification))
+
+	// the events must be supported by the gateways
+	notification.Spec.Events = []string{"s3:ObjectRestore:Post"}
+	assert.Error(t, validateNotification(notification))
+
+	// the topic and the bucket must be set
+	notification.Spec.Events = nil
+	notification.Spec.Topic = ""
+	assert.Error(t, validateNotification(notification))
+	notification.Spec.Topic = "topic-a"
+	notification.Spec.Bucket = ""
+	assert.Error(t, validateNotification(notification))
+}
+
+func TestTopicConfiguration(t *testing.T) {
+	notification := &cephv1.CephBucketNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "notification-a", Namespace: "ns"},
+		Spec:       cephv1.BucketNotificationSpec{Topic: "topic-a", Bucket: "bucket-a"},
+	}
+
+	// all the events are notified by default
+	config := topicConfiguration(notification, "arn:aws:sns:zonegroup-a::topic-a")
+	assert.Equal(t, "notification-a", *config.Id)
+	assert.Equal(t, "arn:aws:sns:zonegroup-a::topic-a", *config.TopicArn)
+	assert.Equal(t, []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}, aws.StringValueSlice(config.Events))
+	assert.Nil(t, config.Filter)
+
+	notification.Spec.Events = []string{"s3:ObjectCreated:Put"}
+	notification.Spec.Filter = &cephv1.NotificationFilterSpec{Prefix: "images/", Suffix: ".png"}
+	config = topicConfiguration(notification, "arn:aws:sns:zonegroup-a::topic-a")
+	assert.Equal(t, []string{"s3:ObjectCreated:Put"}, aws.StringValueSlice(config.Events))
+	rules := config.Filter.Key.FilterRules
+	assert.Equal(t, 2, len(rules))
+	assert.Equal(t, "prefix", *rules[0].Name)
+	assert.Equal(t, "images/", *rules[0].Value)
+	assert.Equal(t, "suffix", *rules[1].Name)
+	assert.Equal(t, ".png", *rules[1].Value)
+}
+
+func TestMergeTopicConfigurations(t *testing.T) {
+	current := []*s3.TopicConfiguration{
+		{Id: aws.String("notification-a"), TopicArn: aws.String("arn-a")},
+		{Id: aws.String("notification-b"), TopicArn: aws.String("arn-b")},
+	}
+
+	// the configuration with the same id is replaced
+	merged := mergeTopicConfigurations(current, &s3.TopicConfiguration{Id: aws.String("notification-a"), TopicArn: aws.String("arn-c")})
+	assert.Equal(t, 2, len(merged))
+	assert.Equal(t, "notification-b", *merged[0].Id)
+	assert.Equal(t, "arn-c", *merged[1].TopicArn)
+
+	// a new configuration is added
+	merged = mergeTopicConfigurations(current, &s3.TopicConfiguration{Id: aws.String("notification-c"), TopicArn: aws.String("arn-c")})
+	assert.Equal(t, 3, len(merged))
+}
+
+func TestCreatePendingNotifications(t *testing.T) {
+	waiting := &cephv1.CephBucketNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "notification-a", Namespace: "ns"},
+		Spec:       cephv1.BucketNotificationSpec{Topic: "topic-a", Bucket: "bucket-a"},
+	}
+	invalid := &cephv1.CephBucketNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "notification-b", Namespace: "ns"},
+		Spec:       cephv1.BucketNotificationSpec{Topic: "topic-a"},
+	}
+	rookClientset := rookfake.NewSimpleClientset(waiting, invalid)
+	context := &clusterd.Context{RookClientset: rookClientset}
+	c := NewNotificationController(context, &cephv1.ClusterSpec{}, "ns")
+	getStatus := func(name string) *cephv1.BucketNotificationStatus {
+		notification, err := rookClientset.CephV1().CephBucketNotifications("ns").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return notification.Status
+	}
+
+	// the notifications of another topic are left alone
+	c.createPendingNotifications("topic-b")
+	assert.Nil(t, getStatus("notification-a"))
+
+	// the notification waits for its topic without blocking, and the invalid notification is not retried
+	c.createPendingNotifications("topic-a")
+	assert.Equal(t, &cephv1.BucketNotificationStatus{Phase: k8sutil.ProcessingStatus}, getStatus("notification-a"))
+	assert.Nil(t, getStatus("notification-b"))
+
+	// a notification that was not created is not deleted from any store when its topic is gone
+	assert.NoError(t, c.deleteNotification(waiting))
+}
+
+func TestUpdateCephBucketNotificationStatus(t *testing.T) {
+	notification := &cephv1.CephBucketNotification{ObjectMeta: metav1.ObjectMeta{Name: "notification-a", Namespace: "ns"}}
+	rookClientset := rookfake.NewSimpleClientset(notification)
+	context := &clusterd.Context{RookClientset: rookClientset}
+	getStatus := func() *cephv1.BucketNotificationStatus {
+		notification, err := rookClientset.CephV1().CephBucketNotifications("ns").Get("notification-a", metav1.GetOptions{})
+		require.NoError(t, err)
+		return notification.Status
+	}
+
+	updateCephBucketNotificationStatus("notification-a", "ns", k8sutil.ReadyStatus, "store-a", context)
+	assert.Equal(t, &cephv1.BucketNotificationStatus{Phase: k8sutil.ReadyStatus, Store: "store-a"}, getStatus())
+
+	// the store is kept to delete the notification when the phase changes
+	updateCephBucketNotificationStatus("notification-a", "ns", k8sutil.FailedStatus, "", context)
+	assert.Equal(t, &cephv1.BucketNotificationStatus{Phase: k8sutil.FailedStatus, Store: "store-a"}, getStatus())
+}
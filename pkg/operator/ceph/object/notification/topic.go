@@ -0,0 +1,310 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/pkg/errors"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// the rgw user of a store that owns the topics of the store
+const topicUserID = "rook-ceph-bucket-topics"
+
+// TopicController represents a controller object for bucket topic custom resources
+type TopicController struct {
+	context     *clusterd.Context
+	clusterSpec *cephv1.ClusterSpec
+	namespace   string
+}
+
+// NewTopicController create controller for watching bucket topic custom resources created
+func NewTopicController(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, namespace string) *TopicController {
+	return &TopicController{
+		context:     context,
+		clusterSpec: clusterSpec,
+		namespace:   namespace,
+	}
+}
+
+// StartWatch watches for instances of BucketTopic custom resources and acts on them
+func (c *TopicController) StartWatch(stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching bucket topic resources in namespace %s", c.namespace)
+	watcher := opkit.NewWatcher(BucketTopicResource, c.namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephBucketTopic{}, stopCh)
+	return nil
+}
+
+func (c *TopicController) onAdd(obj interface{}) {
+	topic, err := getTopicObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get bucket topic object. %v", err)
+		return
+	}
+	c.createTopic(topic)
+}
+
+func (c *TopicController) onUpdate(oldObj, newObj interface{}) {
+	oldTopic, err := getTopicObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old bucket topic object. %v", err)
+		return
+	}
+	newTopic, err := getTopicObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new bucket topic object. %v", err)
+		return
+	}
+	if reflect.DeepEqual(oldTopic.Spec, newTopic.Spec) {
+		logger.Debugf("bucket topic %q did not change", newTopic.Name)
+		return
+	}
+	if oldTopic.Spec.Store != newTopic.Spec.Store {
+		logger.Errorf("the store of bucket topic %q cannot be changed from %q to %q", newTopic.Name, oldTopic.Spec.Store, newTopic.Spec.Store)
+		updateCephBucketTopicStatus(newTopic.Name, newTopic.Namespace, k8sutil.FailedStatus, "", c.context)
+		return
+	}
+
+	// creating the topic again updates its attributes
+	c.createTopic(newTopic)
+}
+
+func (c *TopicController) createTopic(topic *cephv1.CephBucketTopic) {
+	if storeDisabled(c.context, c.clusterSpec, topic.Namespace, topic.Spec.Store) {
+		logger.Warningf("Creating bucket topic for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+	updateCephBucketTopicStatus(topic.Name, topic.Namespace, k8sutil.ProcessingStatus, "", c.context)
+
+	arn, err := c.putTopic(topic)
+	if err != nil {
+		logger.Errorf("failed to create bucket topic %q. %v", topic.Name, err)
+		updateCephBucketTopicStatus(topic.Name, topic.Namespace, k8sutil.FailedStatus, "", c.context)
+		return
+	}
+	updateCephBucketTopicStatus(topic.Name, topic.Namespace, k8sutil.ReadyStatus, arn, c.context)
+
+	// the notifications waiting for the topic can be created now that it has an ARN
+	NewNotificationController(c.context, c.clusterSpec, c.namespace).createPendingNotifications(topic.Name)
+}
+
+// putTopic creates or updates the topic in the store and returns its ARN
+func (c *TopicController) putTopic(topic *cephv1.CephBucketTopic) (string, error) {
+	if err := validateTopic(topic); err != nil {
+		return "", errors.Wrapf(err, "invalid bucket topic %s arguments", topic.Name)
+	}
+	client, err := c.topicClient(topic)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Infof("creating bucket topic %q in object store %q", topic.Name, topic.Spec.Store)
+	out, err := client.CreateTopic(&sns.CreateTopicInput{
+		Name:       aws.String(topic.Name),
+		Attributes: aws.StringMap(topicAttributes(topic.Spec)),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create topic %q", topic.Name)
+	}
+	if out.TopicArn == nil {
+		return "", errors.Errorf("no ARN returned for topic %q", topic.Name)
+	}
+	return *out.TopicArn, nil
+}
+
+func (c *TopicController) onDelete(obj interface{}) {
+	topic, err := getTopicObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get bucket topic object. %v", err)
+		return
+	}
+	if storeDisabled(c.context, c.clusterSpec, topic.Namespace, topic.Spec.Store) {
+		logger.Warningf("Deleting bucket topic for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
+	if topic.Status == nil || topic.Status.ARN == "" {
+		logger.Infof("bucket topic %q was not created", topic.Name)
+		return
+	}
+
+	client, err := c.topicClient(topic)
+	if err != nil {
+		logger.Errorf("failed to delete bucket topic %q. %v", topic.Name, err)
+		return
+	}
+	logger.Infof("deleting bucket topic %q from object store %q", topic.Name, topic.Spec.Store)
+	if _, err := client.DeleteTopic(&sns.DeleteTopicInput{TopicArn: aws.String(topic.Status.ARN)}); err != nil {
+		logger.Errorf("failed to delete bucket topic %q. %v", topic.Name, err)
+	}
+}
+
+// topicClient returns an sns client to the store of the topic with the keys of the user owning the topics of the store
+func (c *TopicController) topicClient(topic *cephv1.CephBucketTopic) (*sns.SNS, error) {
+	objContext, err := object.NewStoreContext(c.context, topic.Spec.Store, topic.Namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the context of object store %q", topic.Spec.Store)
+	}
+	user, rgwerr, err := object.GetUser(objContext, topicUserID)
+	if err != nil {
+		if rgwerr != object.RGWErrorNotFound {
+			return nil, errors.Wrapf(err, "failed to get user %q", topicUserID)
+		}
+		displayName := "Rook bucket topics"
+		user, _, err = object.CreateUser(objContext, object.ObjectUser{UserID: topicUserID, DisplayName: &displayName})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create user %q", topicUserID)
+		}
+	}
+	sess, err := newStoreSession(c.context, topic.Namespace, topic.Spec.Store, user)
+	if err != nil {
+		return nil, err
+	}
+	return sns.New(sess), nil
+}
+
+// topicAttributes returns the attributes of the topic for its endpoint
+func topicAttributes(spec cephv1.BucketTopicSpec) map[string]string {
+	attributes := map[string]string{}
+	if spec.OpaqueData != "" {
+		attributes["OpaqueData"] = spec.OpaqueData
+	}
+	endpoint := spec.Endpoint
+	switch {
+	case endpoint.HTTP != nil:
+		attributes["push-endpoint"] = endpoint.HTTP.URI
+		attributes["verify-ssl"] = strconv.FormatBool(!endpoint.HTTP.DisableVerifySSL)
+	case endpoint.AMQP != nil:
+		attributes["push-endpoint"] = endpoint.AMQP.URI
+		attributes["verify-ssl"] = strconv.FormatBool(!endpoint.AMQP.DisableVerifySSL)
+		attributes["amqp-exchange"] = endpoint.AMQP.Exchange
+		if endpoint.AMQP.AckLevel != "" {
+			attributes["amqp-ack-level"] = endpoint.AMQP.AckLevel
+		}
+	case endpoint.Kafka != nil:
+		attributes["push-endpoint"] = endpoint.Kafka.URI
+		attributes["use-ssl"] = strconv.FormatBool(endpoint.Kafka.UseSSL)
+		if endpoint.Kafka.AckLevel != "" {
+			attributes["kafka-ack-level"] = endpoint.Kafka.AckLevel
+		}
+	}
+	return attributes
+}
+
+func validateTopic(topic *cephv1.CephBucketTopic) error {
+	if topic.Spec.Store == "" {
+		return errors.New("missing store")
+	}
+	endpoint := topic.Spec.Endpoint
+	endpoints := 0
+	if endpoint.HTTP != nil {
+		endpoints++
+		if err := validateURI(endpoint.HTTP.URI, "http", "https"); err != nil {
+			return errors.Wrapf(err, "invalid http endpoint")
+		}
+	}
+	if endpoint.AMQP != nil {
+		endpoints++
+		if err := validateURI(endpoint.AMQP.URI, "amqp", "amqps"); err != nil {
+			return errors.Wrapf(err, "invalid amqp endpoint")
+		}
+		if endpoint.AMQP.Exchange == "" {
+			return errors.New("missing amqp exchange")
+		}
+		switch endpoint.AMQP.AckLevel {
+		case "", "none", "broker", "routable":
+		default:
+			return errors.Errorf("invalid amqp ack level %q. must be one of \"none\", \"broker\" or \"routable\"", endpoint.AMQP.AckLevel)
+		}
+	}
+	if endpoint.Kafka != nil {
+		endpoints++
+		if err := validateURI(endpoint.Kafka.URI, "kafka"); err != nil {
+			return errors.Wrapf(err, "invalid kafka endpoint")
+		}
+		switch endpoint.Kafka.AckLevel {
+		case "", "none", "broker":
+		default:
+			return errors.Errorf("invalid kafka ack level %q. must be one of \"none\" or \"broker\"", endpoint.Kafka.AckLevel)
+		}
+	}
+	if endpoints != 1 {
+		return errors.New("exactly one of the http, amqp or kafka endpoints must be set")
+	}
+	return nil
+}
+
+func validateURI(uri string, schemes ...string) error {
+	if uri == "" {
+		return errors.New("missing uri")
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse uri %q", uri)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid scheme of uri %q. must be one of %q", uri, schemes)
+}
+
+func getTopicObject(obj interface{}) (*cephv1.CephBucketTopic, error) {
+	topic, ok := obj.(*cephv1.CephBucketTopic)
+	if ok {
+		return topic.DeepCopy(), nil
+	}
+	return nil, errors.Errorf("not a known bucket topic object %+v", obj)
+}
+
+// updateCephBucketTopicStatus updates the phase of the topic, and its ARN when it is set
+func updateCephBucketTopicStatus(name, namespace, status, arn string, context *clusterd.Context) {
+	topic, err := context.RookClientset.CephV1().CephBucketTopics(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("Unable to update the cephBucketTopic %s status %v", name, err)
+		return
+	}
+	if topic.Status == nil {
+		topic.Status = &cephv1.BucketTopicStatus{}
+	} else if topic.Status.Phase == status && (arn == "" || topic.Status.ARN == arn) {
+		return
+	}
+	topic.Status.Phase = status
+	if arn != "" {
+		topic.Status.ARN = arn
+	}
+	if _, err := context.RookClientset.CephV1().CephBucketTopics(namespace).Update(topic); err != nil {
+		logger.Errorf("Unable to update the cephBucketTopic %s status %v", name, err)
+	}
+}
@@ -45,6 +45,10 @@ type clusterConfig struct {
 	DataPathMap       *config.DataPathMap
 	isUpgrade         bool
 	skipUpgradeChecks bool
+	// whether the private key of the ssl certificate is in its own key of the secret
+	sslPrivateKey bool
+	// the hash of the secret of the keystone service user
+	keystoneSecretHash string
 }
 
 type rgwConfig struct {
@@ -63,6 +67,28 @@ func (c *clusterConfig) createOrUpdate() error {
 	if err := validateStore(c.context, c.store); err != nil {
 		return errors.Wrapf(err, "invalid object store %s arguments", c.store.Name)
 	}
+	if err := c.validateSSLCertificate(); err != nil {
+		return errors.Wrapf(err, "invalid ssl certificate of object store %s", c.store.Name)
+	}
+	if err := c.validateKeystone(); err != nil {
+		return errors.Wrapf(err, "invalid keystone settings of object store %s", c.store.Name)
+	}
+
+	if c.store.Spec.IsExternal() {
+		if !c.clusterSpec.External.Enable {
+			return errors.Errorf("external rgw endpoints of object store %s are only supported with an external cluster", c.store.Name)
+		}
+		// the pools and the gateways of the store already exist in the external cluster
+		logger.Infof("creating object store %s in namespace %s for the external rgw endpoints", c.store.Name, c.store.Namespace)
+		if _, err := c.startService(); err != nil {
+			return errors.Wrapf(err, "failed to start rgw service")
+		}
+		if err := c.updateExternalEndpoints(); err != nil {
+			return errors.Wrapf(err, "failed to set the external rgw endpoints")
+		}
+		logger.Infof("created object store %s", c.store.Name)
+		return nil
+	}
 
 	logger.Infof("creating object store %s in namespace %s", c.store.Name, c.store.Namespace)
 
@@ -73,10 +99,25 @@ func (c *clusterConfig) createOrUpdate() error {
 	}
 
 	// create the ceph artifacts for the object store
-	objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
-	err = createObjectStore(objContext, *c.store.Spec.MetadataPool.ToModel(""), *c.store.Spec.DataPool.ToModel(""), serviceIP, c.store.Spec.Gateway.Port)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create pools")
+	if c.store.Spec.Zone.Name == "" {
+		objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
+		err = createObjectStore(objContext, *c.store.Spec.MetadataPool.ToModel(""), *c.store.Spec.DataPool.ToModel(""), serviceIP, c.store.Spec.Gateway.Port)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create pools")
+		}
+	} else {
+		// the realm, zone group, zone and pools of a multisite store are created by their own controllers
+		objContext, err := waitForZone(c.context, &c.store)
+		if err != nil {
+			return err
+		}
+		endpoints, err := c.zoneEndpoints(serviceIP)
+		if err != nil {
+			return err
+		}
+		if err := SetZoneEndpoints(objContext, endpoints); err != nil {
+			return errors.Wrapf(err, "failed to join zone %q", c.store.Spec.Zone.Name)
+		}
 	}
 
 	if err := c.startRGWPods(); err != nil {
@@ -288,6 +329,16 @@ func (c *clusterConfig) deleteStore() error {
 		logger.Warningf("failed to delete rgw service. %v", err)
 	}
 
+	// The gateways, keys and pools of an external store are left to the external cluster
+	if c.store.Spec.IsExternal() {
+		err = c.context.Clientset.CoreV1().Endpoints(c.store.Namespace).Delete(c.instanceName(), options)
+		if err != nil && !kerrors.IsNotFound(err) {
+			logger.Warningf("failed to delete rgw endpoints. %v", err)
+		}
+		logger.Infof("Completed deleting object store %s", c.store.Name)
+		return nil
+	}
+
 	// Make a best effort to delete the rgw pods deployments
 	deps, err := k8sutil.GetDeployments(c.context.Clientset, c.store.Namespace, c.storeLabelSelector())
 	if err != nil {
@@ -315,11 +366,15 @@ func (c *clusterConfig) deleteStore() error {
 		}
 	}
 
-	// Delete the realm and pools
-	objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
-	err = deleteRealmAndPools(objContext, c.store.Spec.PreservePoolsOnDelete)
-	if err != nil {
-		return errors.Wrapf(err, "failed to delete the realm and pools")
+	// Delete the realm and pools, unless they belong to the multisite zone of the store
+	if c.store.Spec.Zone.Name == "" {
+		objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
+		err = deleteRealmAndPools(objContext, c.store.Spec.PreservePoolsOnDelete)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete the realm and pools")
+		}
+	} else {
+		logger.Infof("the pools of zone %q are not deleted with object store %s", c.store.Spec.Zone.Name, c.store.Name)
 	}
 
 	logger.Infof("Completed deleting object store %s", c.store.Name)
@@ -330,6 +385,27 @@ func (c *clusterConfig) instanceName() string {
 	return fmt.Sprintf("%s-%s", AppName, c.store.Name)
 }
 
+// endpoint returns the endpoint of the gateways behind the service of the store
+func (c *clusterConfig) endpoint(serviceIP string) string {
+	if c.store.Spec.Gateway.Port == 0 {
+		return fmt.Sprintf("https://%s:%d", serviceIP, c.store.Spec.Gateway.SecurePort)
+	}
+	return fmt.Sprintf("http://%s:%d", serviceIP, c.store.Spec.Gateway.Port)
+}
+
+// zoneEndpoints returns the custom endpoints of the zone of the store if it has any, since the other clusters of the
+// realm may not reach the service of the store, or else the endpoint of the service
+func (c *clusterConfig) zoneEndpoints(serviceIP string) ([]string, error) {
+	zone, err := c.context.RookClientset.CephV1().CephObjectZones(c.store.Namespace).Get(c.store.Spec.Zone.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get zone %q", c.store.Spec.Zone.Name)
+	}
+	if len(zone.Spec.CustomEndpoints) > 0 {
+		return zone.Spec.CustomEndpoints, nil
+	}
+	return []string{c.endpoint(serviceIP)}, nil
+}
+
 func (c *clusterConfig) storeLabelSelector() string {
 	return fmt.Sprintf("rook_object_store=%s", c.store.Name)
 }
@@ -342,6 +418,23 @@ func validateStore(context *clusterd.Context, s cephv1.CephObjectStore) error {
 	if s.Namespace == "" {
 		return errors.New("missing namespace")
 	}
+	if s.Spec.IsExternal() {
+		if s.Spec.Zone.Name != "" {
+			return errors.New("external rgw endpoints cannot serve a zone")
+		}
+		if s.Spec.Gateway.Port == 0 && s.Spec.Gateway.SecurePort == 0 {
+			return errors.New("missing port of the external rgw endpoints")
+		}
+		if s.Spec.Auth.Keystone != nil {
+			return errors.New("keystone must be configured in the external gateways")
+		}
+		// the pools of the store are those of the external cluster
+		return nil
+	}
+	if s.Spec.Zone.Name != "" {
+		// the pools of the store are those of its zone
+		return nil
+	}
 	if err := pool.ValidatePoolSpec(context, s.Namespace, &s.Spec.MetadataPool); err != nil {
 		return errors.Wrapf(err, "invalid metadata pool spec")
 	}
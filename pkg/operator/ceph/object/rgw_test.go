@@ -21,12 +21,15 @@ import (
 	"os"
 	"testing"
 
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -52,7 +55,7 @@ func TestStartRGW(t *testing.T) {
 	data := cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, "my-fs", "rook-ceph", "/var/lib/rook/")
 
 	// start a basic cluster
-	c := &clusterConfig{info, context, store, version, &cephv1.ClusterSpec{}, metav1.OwnerReference{}, data, false, false}
+	c := &clusterConfig{clusterInfo: info, context: context, store: store, rookVersion: version, clusterSpec: &cephv1.ClusterSpec{}, DataPathMap: data}
 	err := c.startRGWPods()
 	assert.Nil(t, err)
 
@@ -94,11 +97,53 @@ func TestCreateObjectStore(t *testing.T) {
 	data := cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, "my-fs", "rook-ceph", "/var/lib/rook/")
 
 	// create the pools
-	c := &clusterConfig{info, context, store, "1.2.3.4", &cephv1.ClusterSpec{}, metav1.OwnerReference{}, data, false, false}
+	c := &clusterConfig{clusterInfo: info, context: context, store: store, rookVersion: "1.2.3.4", clusterSpec: &cephv1.ClusterSpec{}, DataPathMap: data}
 	err := c.createOrUpdate()
 	assert.Nil(t, err)
 }
 
+func TestCreateExternalObjectStore(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			return "", errors.Errorf("unexpected command %q %v", command, args)
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfile string, args ...string) (string, error) {
+			return "", errors.Errorf("unexpected command %q %v", command, args)
+		},
+	}
+	store := simpleStore()
+	store.Spec.Gateway.ExternalRgwEndpoints = []v1.EndpointAddress{{IP: "192.168.39.182"}}
+	clientset := testop.New(3)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	info := testop.CreateConfigDir(1)
+	data := cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, "my-fs", "rook-ceph", "/var/lib/rook/")
+
+	// the external gateways are only supported with an external cluster
+	c := &clusterConfig{clusterInfo: info, context: context, store: store, rookVersion: "1.2.3.4", clusterSpec: &cephv1.ClusterSpec{}, DataPathMap: data}
+	assert.Error(t, c.createOrUpdate())
+
+	// the service points to the external gateways, and neither pools nor gateways are created
+	c.clusterSpec.External.Enable = true
+	require.NoError(t, c.createOrUpdate())
+	svc, err := clientset.CoreV1().Services(store.Namespace).Get(c.instanceName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, svc.Spec.Selector)
+	endpoints, err := clientset.CoreV1().Endpoints(store.Namespace).Get(c.instanceName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []v1.EndpointAddress{{IP: "192.168.39.182"}}, endpoints.Subsets[0].Addresses)
+	assert.Equal(t, int32(123), endpoints.Subsets[0].Ports[0].Port)
+	deps, err := clientset.AppsV1().Deployments(store.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, deps.Items)
+
+	// the endpoints are updated with the store
+	c.store.Spec.Gateway.ExternalRgwEndpoints = []v1.EndpointAddress{{IP: "192.168.39.182"}, {IP: "192.168.39.183"}}
+	require.NoError(t, c.createOrUpdate())
+	endpoints, err = clientset.CoreV1().Endpoints(store.Namespace).Get(c.instanceName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, endpoints.Subsets[0].Addresses, 2)
+}
+
 func simpleStore() cephv1.CephObjectStore {
 	return cephv1.CephObjectStore{
 		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "mycluster"},
@@ -21,7 +21,9 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rook "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
@@ -70,8 +72,11 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) v1.PodTemplateSpec
 		},
 		RestartPolicy: v1.RestartPolicyAlways,
 		Volumes: append(
-			opspec.DaemonVolumes(c.DataPathMap, rgwConfig.ResourceName),
-			c.mimeTypesVolume(),
+			append(
+				opspec.DaemonVolumes(c.DataPathMap, rgwConfig.ResourceName),
+				c.mimeTypesVolume(),
+			),
+			c.keystoneVolumes()...,
 		),
 		HostNetwork:       c.clusterSpec.Network.IsHost(),
 		PriorityClassName: c.store.Spec.Gateway.PriorityClassName,
@@ -95,9 +100,12 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) v1.PodTemplateSpec
 					Items: []v1.KeyToPath{
 						{Key: certKeyName, Path: certFilename, Mode: &userReadOnly},
 					}}}}
+		if c.sslPrivateKey {
+			certVol.Secret.Items = append(certVol.Secret.Items, v1.KeyToPath{Key: certPrivateKeyName, Path: certPrivateKeyFile, Mode: &userReadOnly})
+		}
 		podSpec.Volumes = append(podSpec.Volumes, certVol)
 	}
-	c.setPodPlacement(&podSpec, c.store.Spec.Gateway.Placement)
+	c.setPodPlacement(&podSpec, c.gatewayPlacement())
 
 	podTemplateSpec := v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -107,10 +115,26 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) v1.PodTemplateSpec
 		Spec: podSpec,
 	}
 	c.store.Spec.Gateway.Annotations.ApplyToObjectMeta(&podTemplateSpec.ObjectMeta)
+	if c.keystoneSecretHash != "" {
+		// the gateways are restarted with the new credentials of the keystone service user
+		if podTemplateSpec.ObjectMeta.Annotations == nil {
+			podTemplateSpec.ObjectMeta.Annotations = map[string]string{}
+		}
+		podTemplateSpec.ObjectMeta.Annotations[keystoneSecretHashAnnotation] = c.keystoneSecretHash
+	}
 
 	return podTemplateSpec
 }
 
+// gatewayPlacement returns the placement of the gateways, which is the rgw placement of the cluster
+// overridden by the placement of the object store
+func (c *clusterConfig) gatewayPlacement() rook.Placement {
+	if c.clusterSpec == nil {
+		return c.store.Spec.Gateway.Placement
+	}
+	return cephv1.GetRGWPlacement(c.clusterSpec.Placement).Merge(c.store.Spec.Gateway.Placement)
+}
+
 func (c *clusterConfig) setPodPlacement(pod *v1.PodSpec, p rook.Placement) {
 	p.ApplyToPodSpec(pod)
 
@@ -162,22 +186,18 @@ func (c *clusterConfig) makeDaemonContainer(rgwConfig *rgwConfig) v1.Container {
 				cephconfig.NewFlag("host", opspec.ContainerEnvVarReference("POD_NAME")),
 				cephconfig.NewFlag("rgw-mime-types-file", mimeTypesMountPath()),
 			),
+			c.keystoneFlags()...,
 		),
 		VolumeMounts: append(
-			opspec.DaemonVolumeMounts(c.DataPathMap, rgwConfig.ResourceName),
-			c.mimeTypesVolumeMount(),
+			append(
+				opspec.DaemonVolumeMounts(c.DataPathMap, rgwConfig.ResourceName),
+				c.mimeTypesVolumeMount(),
+			),
+			c.keystoneVolumeMounts()...,
 		),
-		Env:       opspec.DaemonEnvVars(c.clusterSpec.CephVersion.Image),
-		Resources: c.store.Spec.Gateway.Resources,
-		LivenessProbe: &v1.Probe{
-			Handler: v1.Handler{
-				HTTPGet: &v1.HTTPGetAction{
-					Path: "/swift/healthcheck",
-					Port: intstr.FromInt(int(c.store.Spec.Gateway.Port)),
-				},
-			},
-			InitialDelaySeconds: 10,
-		},
+		Env:             append(opspec.DaemonEnvVars(c.clusterSpec.CephVersion.Image), c.keystoneEnvVars()...),
+		Resources:       c.store.Spec.Gateway.Resources,
+		LivenessProbe:   c.makeLivenessProbe(),
 		SecurityContext: mon.PodSecurityContext(),
 	}
 
@@ -190,6 +210,23 @@ func (c *clusterConfig) makeDaemonContainer(rgwConfig *rgwConfig) v1.Container {
 	return container
 }
 
+// makeLivenessProbe checks the health of the gateway on the insecure port, or on the secure port if the gateway only
+// listens on the secure port
+func (c *clusterConfig) makeLivenessProbe() *v1.Probe {
+	action := &v1.HTTPGetAction{
+		Path: "/swift/healthcheck",
+		Port: intstr.FromInt(int(c.store.Spec.Gateway.Port)),
+	}
+	if c.store.Spec.Gateway.Port == 0 && c.store.Spec.Gateway.SecurePort != 0 && c.store.Spec.Gateway.SSLCertificateRef != "" {
+		action.Port = intstr.FromInt(int(c.store.Spec.Gateway.SecurePort))
+		action.Scheme = v1.URISchemeHTTPS
+	}
+	return &v1.Probe{
+		Handler:             v1.Handler{HTTPGet: action},
+		InitialDelaySeconds: 10,
+	}
+}
+
 func (c *clusterConfig) startService() (string, error) {
 	labels := c.getLabels()
 	svc := &v1.Service{
@@ -198,9 +235,10 @@ func (c *clusterConfig) startService() (string, error) {
 			Namespace: c.store.Namespace,
 			Labels:    labels,
 		},
-		Spec: v1.ServiceSpec{
-			Selector: labels,
-		},
+	}
+	// the endpoints of the service are set by rook instead of selecting the pods when the gateways are external
+	if !c.store.Spec.IsExternal() {
+		svc.Spec.Selector = labels
 	}
 	k8sutil.SetOwnerRef(&svc.ObjectMeta, &c.ownerRef)
 	if c.clusterSpec.Network.IsHost() {
@@ -226,6 +264,75 @@ func (c *clusterConfig) startService() (string, error) {
 	return svc.Spec.ClusterIP, nil
 }
 
+// GetStoreServicePort returns the port of the service of the store the clients connect to, whether it is the secure
+// port, and the CA of the ssl certificate of the gateways. The insecure port is used when the gateways listen on both
+// ports. Without a CA the certificate of the gateways must be trusted by the system.
+func GetStoreServicePort(context *clusterd.Context, store *cephv1.CephObjectStore) (int32, bool, []byte, error) {
+	serviceName := fmt.Sprintf("%s-%s", AppName, store.Name)
+	svc, err := context.Clientset.CoreV1().Services(store.Namespace).Get(serviceName, metav1.GetOptions{})
+	if err != nil {
+		return 0, false, nil, errors.Wrapf(err, "failed to get the service of object store %q", store.Name)
+	}
+	var securePort *v1.ServicePort
+	for i, port := range svc.Spec.Ports {
+		switch port.Name {
+		case "http":
+			return port.Port, false, nil, nil
+		case "https":
+			securePort = &svc.Spec.Ports[i]
+		}
+	}
+	if securePort == nil {
+		return 0, false, nil, errors.Errorf("service of object store %q has no http or https port", store.Name)
+	}
+	if store.Spec.Gateway.SSLCertificateRef == "" {
+		return securePort.Port, true, nil, nil
+	}
+	secret, err := context.Clientset.CoreV1().Secrets(store.Namespace).Get(store.Spec.Gateway.SSLCertificateRef, metav1.GetOptions{})
+	if err != nil {
+		return 0, false, nil, errors.Wrapf(err, "failed to get the ssl certificate secret of object store %q", store.Name)
+	}
+	return securePort.Port, true, secret.Data[CACertKeyName], nil
+}
+
+// updateExternalEndpoints points the service of the store to the external gateways
+func (c *clusterConfig) updateExternalEndpoints() error {
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.instanceName(),
+			Namespace: c.store.Namespace,
+			Labels:    c.getLabels(),
+		},
+		Subsets: []v1.EndpointSubset{{Addresses: c.store.Spec.Gateway.ExternalRgwEndpoints}},
+	}
+	k8sutil.SetOwnerRef(&endpoints.ObjectMeta, &c.ownerRef)
+	addEndpointPort(endpoints, "http", c.store.Spec.Gateway.Port)
+	addEndpointPort(endpoints, "https", c.store.Spec.Gateway.SecurePort)
+
+	_, err := c.context.Clientset.CoreV1().Endpoints(c.store.Namespace).Create(endpoints)
+	if err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create rgw endpoints")
+		}
+		if _, err := c.context.Clientset.CoreV1().Endpoints(c.store.Namespace).Update(endpoints); err != nil {
+			return errors.Wrapf(err, "failed to update rgw endpoints")
+		}
+	}
+	logger.Infof("Gateway service points to the external rgw endpoints %v", c.store.Spec.Gateway.ExternalRgwEndpoints)
+	return nil
+}
+
+func addEndpointPort(endpoints *v1.Endpoints, name string, port int32) {
+	if port == 0 {
+		return
+	}
+	endpoints.Subsets[0].Ports = append(endpoints.Subsets[0].Ports, v1.EndpointPort{
+		Name:     name,
+		Port:     port,
+		Protocol: v1.ProtocolTCP,
+	})
+}
+
 func addPort(service *v1.Service, name string, port int32) {
 	if port == 0 {
 		return
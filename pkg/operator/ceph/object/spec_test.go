@@ -130,6 +130,16 @@ func TestSSLPodSpec(t *testing.T) {
 	assert.True(t, s.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, s.Spec.DNSPolicy)
 
+	// the gateways only listen on the secure port with the private key of the cert in its own key
+	c.store.Spec.Gateway.Port = 0
+	c.sslPrivateKey = true
+	s = c.makeRGWPodSpec(rgwConfig)
+	probe := s.Spec.Containers[0].LivenessProbe.HTTPGet
+	assert.Equal(t, v1.URISchemeHTTPS, probe.Scheme)
+	assert.Equal(t, 443, probe.Port.IntValue())
+	certVol := s.Spec.Volumes[len(s.Spec.Volumes)-1]
+	assert.Equal(t, certVolumeName, certVol.Name)
+	assert.Equal(t, 2, len(certVol.Secret.Items))
 }
 
 func TestValidateSpec(t *testing.T) {
@@ -163,6 +173,18 @@ func TestValidateSpec(t *testing.T) {
 	s.Spec.MetadataPool.Replicated.Size = 1
 	err = validateStore(context, s)
 	assert.Nil(t, err)
+
+	// the external gateways need a port and cannot serve a zone
+	s.Spec.Gateway.ExternalRgwEndpoints = []v1.EndpointAddress{{IP: "192.168.39.182"}}
+	err = validateStore(context, s)
+	assert.Nil(t, err)
+	s.Spec.Gateway.Port = 0
+	err = validateStore(context, s)
+	assert.NotNil(t, err)
+	s.Spec.Gateway.Port = 123
+	s.Spec.Zone.Name = "zone-a"
+	err = validateStore(context, s)
+	assert.NotNil(t, err)
 }
 
 func testPodSpecPlacement(t *testing.T, hostNet bool, req int, placement *rook.Placement) {
@@ -214,3 +236,25 @@ func TestPodSpecPlacement(t *testing.T) {
 	p = makePlacement()
 	testPodSpecPlacement(t, false, 1, &p)
 }
+
+func TestGatewayPlacement(t *testing.T) {
+	c := newConfig()
+	tolerations := []v1.Toleration{{Key: "storage", Operator: v1.TolerationOpExists}}
+	nodeAffinity := &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+		NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{
+			{Key: "role", Operator: v1.NodeSelectorOpIn, Values: []string{"rgw"}},
+		}}},
+	}}
+
+	// the placement of the store is used without a cluster spec
+	c.store.Spec.Gateway.Placement = rook.Placement{Tolerations: tolerations}
+	assert.Equal(t, rook.Placement{Tolerations: tolerations}, c.gatewayPlacement())
+
+	// the rgw placement of the cluster is overridden by the placement of the store
+	c.clusterSpec = &cephv1.ClusterSpec{Placement: rook.PlacementSpec{
+		"all": {Tolerations: []v1.Toleration{{Key: "all", Operator: v1.TolerationOpExists}}},
+		"rgw": {NodeAffinity: nodeAffinity},
+		"osd": {PodAffinity: &v1.PodAffinity{}},
+	}}
+	assert.Equal(t, rook.Placement{NodeAffinity: nodeAffinity, Tolerations: tolerations}, c.gatewayPlacement())
+}
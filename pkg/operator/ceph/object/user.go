@@ -18,6 +18,7 @@ package object
 
 import (
 	"encoding/json"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -39,6 +40,8 @@ type ObjectUser struct {
 	Email       *string `json:"email"`
 	AccessKey   *string `json:"accessKey"`
 	SecretKey   *string `json:"secretKey"`
+	// the admin capabilities of the user by type of capability
+	Caps map[string]string `json:"caps,omitempty"`
 }
 
 // ListUsers lists the object pool users.
@@ -64,6 +67,10 @@ type rgwUserInfo struct {
 		AccessKey string `json:"access_key"`
 		SecretKey string `json:"secret_key"`
 	}
+	Caps []struct {
+		Type string `json:"type"`
+		Perm string `json:"perm"`
+	} `json:"caps"`
 }
 
 func decodeUser(data string) (*ObjectUser, int, error) {
@@ -79,6 +86,12 @@ func decodeUser(data string) (*ObjectUser, int, error) {
 		rookUser.AccessKey = &user.Keys[0].AccessKey
 		rookUser.SecretKey = &user.Keys[0].SecretKey
 	}
+	if len(user.Caps) > 0 {
+		rookUser.Caps = map[string]string{}
+		for _, userCap := range user.Caps {
+			rookUser.Caps[userCap.Type] = userCap.Perm
+		}
+	}
 
 	return &rookUser, RGWErrorNone, nil
 }
@@ -190,6 +203,67 @@ func SetQuotaUserBucketMax(c *Context, id string, max int) (string, int, error)
 	return result, errCode, err
 }
 
+// SetUserQuota sets the quota of the user with the scope "user" for all its buckets, or "bucket" for each one of its
+// buckets. A negative limit is unlimited, and the quota is disabled when both limits are unlimited.
+func SetUserQuota(c *Context, id, scope string, maxSize, maxObjects int64) (string, int, error) {
+	logger.Infof("Setting user %q %s quota to max size %d and max objects %d", id, scope, maxSize, maxObjects)
+	args := []string{"--quota-scope", scope, "--max-size", strconv.FormatInt(maxSize, 10), "--max-objects", strconv.FormatInt(maxObjects, 10)}
+	result, errCode, err := setUserQuota(c, id, args)
+	if err != nil {
+		return result, errCode, errors.Wrapf(err, "failed setting %s quota", scope)
+	}
+
+	action := "enable"
+	if maxSize < 0 && maxObjects < 0 {
+		action = "disable"
+	}
+	result, err = runAdminCommand(c, "quota", action, "--quota-scope", scope, "--uid", id)
+	if err != nil {
+		return result, RGWErrorUnknown, errors.Wrapf(err, "failed to %s the %s quota of user %q", action, scope, id)
+	}
+	return result, RGWErrorNone, nil
+}
+
+// SetUserCaps sets the admin capabilities of the user to the given permission by type of capability. The
+// capabilities of the user with other types are removed.
+func SetUserCaps(c *Context, id string, caps map[string]string) (string, int, error) {
+	user, errCode, err := GetUser(c, id)
+	if err != nil {
+		return "", errCode, err
+	}
+
+	var removed, added []string
+	for capType, perm := range user.Caps {
+		if caps[capType] != perm {
+			removed = append(removed, capType+"="+perm)
+		}
+	}
+	for capType, perm := range caps {
+		if perm != "" && user.Caps[capType] != perm {
+			added = append(added, capType+"="+perm)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	var result string
+	if len(removed) > 0 {
+		logger.Infof("Removing caps %v of user %q", removed, id)
+		result, err = runAdminCommand(c, "caps", "rm", "--uid", id, "--caps", strings.Join(removed, ";"))
+		if err != nil {
+			return result, RGWErrorUnknown, errors.Wrapf(err, "failed to remove the caps of user %q", id)
+		}
+	}
+	if len(added) > 0 {
+		logger.Infof("Adding caps %v to user %q", added, id)
+		result, err = runAdminCommand(c, "caps", "add", "--uid", id, "--caps", strings.Join(added, ";"))
+		if err != nil {
+			return result, RGWErrorUnknown, errors.Wrapf(err, "failed to add the caps of user %q", id)
+		}
+	}
+	return result, RGWErrorNone, nil
+}
+
 func setUserQuota(c *Context, id string, args []string) (string, int, error) {
 	args = append([]string{"quota", "set", "--uid", id}, args...)
 	result, err := runAdminCommand(c, args...)
@@ -35,6 +35,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 )
@@ -90,16 +91,15 @@ func (c *ObjectStoreUserController) StartWatch(stopCh chan struct{}) error {
 }
 
 func (c *ObjectStoreUserController) onAdd(obj interface{}) {
-	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
-		logger.Warningf("Creating object store user for an external ceph cluster is disabled because no Ceph image is specified")
-		return
-	}
-
 	user, err := getObjectStoreUserObject(obj)
 	if err != nil {
 		logger.Errorf("failed to get objectstoreuser object. %v", err)
 		return
 	}
+	if c.usersDisabled(user) {
+		logger.Warningf("Creating object store user for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
 	updateCephObjectStoreUserStatus(user.GetName(), user.GetNamespace(), k8sutil.ProcessingStatus, c.context)
 
 	if err = c.createUser(c.context, user); err != nil {
@@ -111,30 +111,76 @@ func (c *ObjectStoreUserController) onAdd(obj interface{}) {
 }
 
 func (c *ObjectStoreUserController) onUpdate(oldObj, newObj interface{}) {
-	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
+	oldUser, err := getObjectStoreUserObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old objectstoreuser object. %v", err)
+		return
+	}
+	newUser, err := getObjectStoreUserObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new objectstoreuser object. %v", err)
+		return
+	}
+	if c.usersDisabled(newUser) {
 		logger.Warningf("Updating object store user for an external ceph cluster is disabled because no Ceph image is specified")
 		return
 	}
-	// TODO: Add update code here after features are added which require updates.
-}
+	if reflect.DeepEqual(oldUser.Spec, newUser.Spec) {
+		logger.Debugf("object store user %q did not change", newUser.Name)
+		return
+	}
 
-func (c *ObjectStoreUserController) onDelete(obj interface{}) {
-	if c.clusterSpec.External.Enable && c.clusterSpec.CephVersion.Image == "" {
-		logger.Warningf("Deleting object store user for an external ceph cluster is disabled because no Ceph image is specified")
+	// only the quotas and the capabilities of the user are updated
+	logger.Infof("updating object store user %q", newUser.Name)
+	if err := ValidateUser(c.context, newUser); err != nil {
+		logger.Errorf("invalid user %s arguments. %v", newUser.Name, err)
+		updateCephObjectStoreUserStatus(newUser.GetName(), newUser.GetNamespace(), k8sutil.FailedStatus, c.context)
 		return
 	}
+	objContext, err := object.NewStoreContext(c.context, newUser.Spec.Store, newUser.Namespace)
+	if err != nil {
+		logger.Errorf("failed to update object store user %q. %v", newUser.Name, err)
+		updateCephObjectStoreUserStatus(newUser.GetName(), newUser.GetNamespace(), k8sutil.FailedStatus, c.context)
+		return
+	}
+	if err := setUserLimits(objContext, newUser); err != nil {
+		logger.Errorf("failed to update object store user %q. %v", newUser.Name, err)
+		updateCephObjectStoreUserStatus(newUser.GetName(), newUser.GetNamespace(), k8sutil.FailedStatus, c.context)
+		return
+	}
+	updateCephObjectStoreUserStatus(newUser.GetName(), newUser.GetNamespace(), k8sutil.ReadyStatus, c.context)
+}
 
+func (c *ObjectStoreUserController) onDelete(obj interface{}) {
 	user, err := getObjectStoreUserObject(obj)
 	if err != nil {
 		logger.Errorf("failed to get objectstoreuser object. %v", err)
 		return
 	}
+	if c.usersDisabled(user) {
+		logger.Warningf("Deleting object store user for an external ceph cluster is disabled because no Ceph image is specified")
+		return
+	}
 
 	if err = deleteUser(c.context, user); err != nil {
 		logger.Errorf("failed to delete object store user %q. %v", user.Name, err)
 	}
 }
 
+// usersDisabled returns whether the users cannot be managed in an external cluster without a Ceph image, unless their
+// store is served by external gateways
+func (c *ObjectStoreUserController) usersDisabled(u *cephv1.CephObjectStoreUser) bool {
+	if !c.clusterSpec.External.Enable || c.clusterSpec.CephVersion.Image != "" {
+		return false
+	}
+	store, err := c.context.RookClientset.CephV1().CephObjectStores(u.Namespace).Get(u.Spec.Store, metav1.GetOptions{})
+	if err != nil {
+		logger.Debugf("failed to get object store %q of user %q. %v", u.Spec.Store, u.Name, err)
+		return true
+	}
+	return !store.Spec.IsExternal()
+}
+
 // ParentClusterChanged determines wether or not a CR update has been sent
 func (c *ObjectStoreUserController) ParentClusterChanged(cluster cephv1.ClusterSpec, clusterInfo *cephconfig.ClusterInfo, isUpgrade bool) {
 	logger.Debugf("No need to update object store users after the parent cluster changed")
@@ -194,6 +240,13 @@ func (c *ObjectStoreUserController) createUser(context *clusterd.Context, u *cep
 		}
 	}
 
+	// the user is created in the multisite zone of the store if it has one
+	if storeContext, err := object.NewStoreContext(context, u.Spec.Store, u.Namespace); err == nil {
+		objContext = storeContext
+	} else {
+		logger.Warningf("failed to get the zone of object store %q. %v", u.Spec.Store, err)
+	}
+
 	user, rgwerr, err := object.CreateUser(objContext, userConfig)
 	if err != nil {
 		pollErr := wait.Poll(time.Second*15, time.Minute*5, func() (ok bool, err error) {
@@ -210,6 +263,9 @@ func (c *ObjectStoreUserController) createUser(context *clusterd.Context, u *cep
 			return errors.Wrapf(pollErr, "errored or timed out while waiting for objectuser %q to be created", u.Name)
 		}
 	}
+	if err := setUserLimits(objContext, u); err != nil {
+		return err
+	}
 
 	// Store the keys in a secret
 	secrets := map[string]string{
@@ -240,9 +296,67 @@ func (c *ObjectStoreUserController) createUser(context *clusterd.Context, u *cep
 	return nil
 }
 
+// setUserLimits applies the quotas and the admin capabilities of the spec to the rgw user. The quotas and the
+// capabilities that are not in the spec are left alone, so the ones set with radosgw-admin are kept.
+func setUserLimits(objContext *object.Context, u *cephv1.CephObjectStoreUser) error {
+	if err := setUserQuotas(objContext, u.Name, u.Spec.Quotas); err != nil {
+		return err
+	}
+	if u.Spec.Capabilities == nil {
+		return nil
+	}
+	caps := map[string]string{
+		"users":    u.Spec.Capabilities.Users,
+		"buckets":  u.Spec.Capabilities.Buckets,
+		"metadata": u.Spec.Capabilities.Metadata,
+		"usage":    u.Spec.Capabilities.Usage,
+		"zone":     u.Spec.Capabilities.Zone,
+	}
+	if _, _, err := object.SetUserCaps(objContext, u.Name, caps); err != nil {
+		return errors.Wrapf(err, "failed to set the caps of user %q", u.Name)
+	}
+	return nil
+}
+
+func setUserQuotas(objContext *object.Context, name string, quotas *cephv1.ObjectUserQuotaSpec) error {
+	if quotas == nil {
+		return nil
+	}
+	// the max buckets of rgw is left alone if not set
+	if quotas.MaxBuckets != nil {
+		if _, _, err := object.SetQuotaUserBucketMax(objContext, name, *quotas.MaxBuckets); err != nil {
+			return errors.Wrapf(err, "failed to set the max buckets of user %q", name)
+		}
+	}
+	maxSize, maxObjects := quotaLimits(quotas.MaxSize, quotas.MaxObjects)
+	if _, _, err := object.SetUserQuota(objContext, name, "user", maxSize, maxObjects); err != nil {
+		return errors.Wrapf(err, "failed to set the quota of user %q", name)
+	}
+	if quotas.Bucket == nil {
+		return nil
+	}
+	maxSize, maxObjects = quotaLimits(quotas.Bucket.MaxSize, quotas.Bucket.MaxObjects)
+	if _, _, err := object.SetUserQuota(objContext, name, "bucket", maxSize, maxObjects); err != nil {
+		return errors.Wrapf(err, "failed to set the bucket quota of user %q", name)
+	}
+	return nil
+}
+
+// quotaLimits returns the limits of a quota for rgw, where -1 is unlimited
+func quotaLimits(maxSize *resource.Quantity, maxObjects *int64) (int64, int64) {
+	size, objects := int64(-1), int64(-1)
+	if maxSize != nil {
+		size = maxSize.Value()
+	}
+	if maxObjects != nil {
+		objects = *maxObjects
+	}
+	return size, objects
+}
+
 func objectStoreInitialized(context *object.Context) (bool, error) {
 	// check if CephObjectStore CR is created
-	_, err := context.Context.RookClientset.CephV1().CephObjectStores(context.ClusterName).Get(context.Name, metav1.GetOptions{})
+	store, err := context.Context.RookClientset.CephV1().CephObjectStores(context.ClusterName).Get(context.Name, metav1.GetOptions{})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Warningf("CephObjectStore %s could not be found. %v", context.Name, err)
@@ -250,6 +364,10 @@ func objectStoreInitialized(context *object.Context) (bool, error) {
 		}
 		return false, err
 	}
+	// the external gateways are already running, but the store fails without the admin key of the external cluster
+	if store.Spec.IsExternal() {
+		return store.Status != nil && store.Status.Phase == k8sutil.ReadyStatus, nil
+	}
 
 	// check if ObjectStore is initialized
 	// rook does this by starting the RGW pod(s)
@@ -275,7 +393,11 @@ func objectStoreInitialized(context *object.Context) (bool, error) {
 
 // Delete the user
 func deleteUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) error {
-	objContext := object.NewContext(context, u.Spec.Store, u.Namespace)
+	objContext, err := object.NewStoreContext(context, u.Spec.Store, u.Namespace)
+	if err != nil {
+		logger.Warningf("failed to get the zone of object store %q. %v", u.Spec.Store, err)
+		objContext = object.NewContext(context, u.Spec.Store, u.Namespace)
+	}
 	_, rgwerr, err := object.DeleteUser(objContext, u.Name)
 	if err != nil {
 		if rgwerr == 3 {
@@ -305,6 +427,38 @@ func ValidateUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) erro
 	if u.Spec.Store == "" {
 		return errors.New("missing store")
 	}
+	if q := u.Spec.Quotas; q != nil {
+		if q.MaxBuckets != nil && *q.MaxBuckets < 0 {
+			return errors.New("max buckets cannot be negative")
+		}
+		if err := validateQuotaLimits(q.MaxSize, q.MaxObjects); err != nil {
+			return errors.Wrapf(err, "invalid user quota")
+		}
+		if q.Bucket != nil {
+			if err := validateQuotaLimits(q.Bucket.MaxSize, q.Bucket.MaxObjects); err != nil {
+				return errors.Wrapf(err, "invalid bucket quota")
+			}
+		}
+	}
+	if caps := u.Spec.Capabilities; caps != nil {
+		for _, perm := range []string{caps.Users, caps.Buckets, caps.Metadata, caps.Usage, caps.Zone} {
+			switch perm {
+			case "", "read", "write", "*":
+			default:
+				return errors.Errorf("invalid capability %q. must be one of \"read\", \"write\" or \"*\"", perm)
+			}
+		}
+	}
+	return nil
+}
+
+func validateQuotaLimits(maxSize *resource.Quantity, maxObjects *int64) error {
+	if maxSize != nil && maxSize.Sign() < 0 {
+		return errors.New("max size cannot be negative")
+	}
+	if maxObjects != nil && *maxObjects < 0 {
+		return errors.New("max objects cannot be negative")
+	}
 	return nil
 }
 
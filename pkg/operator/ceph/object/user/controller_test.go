@@ -22,6 +22,8 @@ import (
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetObjectStoreUserObject(t *testing.T) {
@@ -35,3 +37,37 @@ func TestGetObjectStoreUserObject(t *testing.T) {
 	assert.Nil(t, objectuser)
 	assert.NotNil(t, err)
 }
+
+func TestValidateUser(t *testing.T) {
+	u := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "myuser", Namespace: "rook-ceph"},
+		Spec:       cephv1.ObjectStoreUserSpec{Store: "my-store"},
+	}
+	assert.NoError(t, ValidateUser(nil, u))
+
+	maxBuckets := 10
+	maxObjects := int64(-1)
+	maxSize := resource.MustParse("10Gi")
+	u.Spec.Quotas = &cephv1.ObjectUserQuotaSpec{MaxBuckets: &maxBuckets, MaxSize: &maxSize}
+	assert.NoError(t, ValidateUser(nil, u))
+	u.Spec.Quotas.Bucket = &cephv1.ObjectBucketQuotaSpec{MaxObjects: &maxObjects}
+	assert.Error(t, ValidateUser(nil, u))
+	u.Spec.Quotas.Bucket = nil
+
+	u.Spec.Capabilities = &cephv1.ObjectUserCapSpec{Users: "*", Buckets: "read"}
+	assert.NoError(t, ValidateUser(nil, u))
+	u.Spec.Capabilities.Zone = "all"
+	assert.Error(t, ValidateUser(nil, u))
+}
+
+func TestQuotaLimits(t *testing.T) {
+	size, objects := quotaLimits(nil, nil)
+	assert.Equal(t, int64(-1), size)
+	assert.Equal(t, int64(-1), objects)
+
+	maxSize := resource.MustParse("1Gi")
+	maxObjects := int64(1000)
+	size, objects = quotaLimits(&maxSize, &maxObjects)
+	assert.Equal(t, int64(1073741824), size)
+	assert.Equal(t, int64(1000), objects)
+}
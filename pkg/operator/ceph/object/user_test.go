@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminCommand returns the radosgw-admin command without the flags of the realm and the cluster
+func adminCommand(args []string) string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "--rgw-realm") {
+			return strings.Join(args[:i], " ")
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+func TestSetUserQuota(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		commands = append(commands, adminCommand(args))
+		return "", nil
+	}
+	context := &Context{Context: &clusterd.Context{Executor: executor}, Name: "myobj", ClusterName: "ns"}
+
+	_, _, err := SetUserQuota(context, "myuser", "user", 1024, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"quota set --uid myuser --quota-scope user --max-size 1024 --max-objects -1",
+		"quota enable --quota-scope user --uid myuser",
+	}, commands)
+
+	// the quota is disabled without limits
+	commands = nil
+	_, _, err = SetUserQuota(context, "myuser", "bucket", -1, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "quota disable --quota-scope bucket --uid myuser", commands[1])
+}
+
+func TestSetUserCaps(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		cmd := adminCommand(args)
+		switch {
+		case strings.HasPrefix(cmd, "user info"):
+			return `{"user_id":"myuser","keys":[],"caps":[{"type":"users","perm":"read"},{"type":"usage","perm":"*"}]}`, nil
+		case strings.HasPrefix(cmd, "caps"):
+			commands = append(commands, cmd)
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected radosgw-admin command %q", args)
+	}
+	context := &Context{Context: &clusterd.Context{Executor: executor}, Name: "myobj", ClusterName: "ns"}
+
+	// the caps that changed are removed before the new caps are added
+	_, _, err := SetUserCaps(context, "myuser", map[string]string{"users": "*", "usage": "*", "buckets": "read", "zone": ""})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"caps rm --uid myuser --caps users=read",
+		"caps add --uid myuser --caps buckets=read;users=*",
+	}, commands)
+
+	// nothing changes if the caps are the same
+	commands = nil
+	_, _, err = SetUserCaps(context, "myuser", map[string]string{"users": "read", "usage": "*"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(commands))
+}
@@ -107,11 +107,16 @@ func (o *Operator) Run() error {
 		return errors.Errorf("rook operator namespace is not provided. expose it via downward API in the rook operator manifest file using environment variable %s", k8sutil.PodNamespaceEnvVar)
 	}
 
+	rookDiscover := discover.New(o.context.Clientset)
 	if EnableDiscoveryDaemon {
-		rookDiscover := discover.New(o.context.Clientset)
 		if err := rookDiscover.Start(o.operatorNamespace, o.rookImage, o.securityAccount, true); err != nil {
 			return errors.Wrapf(err, "error starting device discovery daemonset")
 		}
+	} else {
+		logger.Infof("the device discovery daemonset is disabled")
+		if err := rookDiscover.Stop(o.operatorNamespace); err != nil {
+			return errors.Wrapf(err, "error stopping device discovery daemonset")
+		}
 	}
 
 	serverVersion, err := o.context.Clientset.Discovery().ServerVersion()
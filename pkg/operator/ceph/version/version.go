@@ -49,10 +49,12 @@ var (
 	Nautilus = CephVersion{14, 0, 0, 0}
 	// Octopus Ceph version
 	Octopus = CephVersion{15, 0, 0, 0}
+	// Pacific Ceph version
+	Pacific = CephVersion{16, 0, 0, 0}
 
 	// supportedVersions are production-ready versions that rook supports
 	supportedVersions   = []CephVersion{Mimic, Nautilus}
-	unsupportedVersions = []CephVersion{Octopus}
+	unsupportedVersions = []CephVersion{Octopus, Pacific}
 	// allVersions includes all supportedVersions as well as unreleased versions that are being tested with rook
 	allVersions = append(supportedVersions, unsupportedVersions...)
 
@@ -83,6 +85,8 @@ func (v *CephVersion) CephVersionFormatted() string {
 // ReleaseName is the name of the Ceph release
 func (v *CephVersion) ReleaseName() string {
 	switch v.Major {
+	case Pacific.Major:
+		return "pacific"
 	case Octopus.Major:
 		return "octopus"
 	case Nautilus.Major:
@@ -172,6 +176,11 @@ func (v *CephVersion) IsAtLeast(other CephVersion) bool {
 	return true
 }
 
+// IsAtLeastPacific check that the Ceph version is at least Pacific
+func (v *CephVersion) IsAtLeastPacific() bool {
+	return v.IsAtLeast(Pacific)
+}
+
 // IsAtLeastOctopus check that the Ceph version is at least Octopus
 func (v *CephVersion) IsAtLeastOctopus() bool {
 	return v.IsAtLeast(Octopus)
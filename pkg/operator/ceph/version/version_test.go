@@ -37,6 +37,7 @@ func TestCephVersionFormatted(t *testing.T) {
 }
 
 func TestReleaseName(t *testing.T) {
+	assert.Equal(t, "pacific", Pacific.ReleaseName())
 	assert.Equal(t, "nautilus", Nautilus.ReleaseName())
 	assert.Equal(t, "mimic", Mimic.ReleaseName())
 	ver := CephVersion{-1, 0, 0, 0}
@@ -145,6 +146,9 @@ func TestVersionAtLeast(t *testing.T) {
 }
 
 func TestVersionAtLeastX(t *testing.T) {
+	assert.True(t, Pacific.IsAtLeastPacific())
+	assert.True(t, Pacific.IsAtLeastOctopus())
+	assert.False(t, Octopus.IsAtLeastPacific())
 	assert.True(t, Octopus.IsAtLeastOctopus())
 	assert.True(t, Octopus.IsAtLeastNautilus())
 	assert.True(t, Octopus.IsAtLeastMimic())
@@ -77,10 +77,35 @@ func (d *Discover) Start(namespace, discoverImage, securityAccount string, useCe
 	return nil
 }
 
+// Stop removes the discover daemonset if it was started before the discovery daemon was disabled
+func (d *Discover) Stop(namespace string) error {
+	err := d.clientset.AppsV1().DaemonSets(namespace).Delete(discoverDaemonsetName, &metav1.DeleteOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete rook-discover daemon set. %+v", err)
+	}
+	logger.Infof("rook-discover daemonset deleted")
+	return nil
+}
+
+// getDiscoverInterval returns the interval between the discoveries of the devices. An invalid interval would keep
+// the discover pods from starting, so the default interval is used instead.
+func getDiscoverInterval() string {
+	interval := getEnvVar(discoverIntervalEnv, defaultDiscoverInterval)
+	duration, err := time.ParseDuration(interval)
+	if err != nil || duration <= 0 {
+		logger.Warningf("invalid %s %q. using the default interval %s", discoverIntervalEnv, interval, defaultDiscoverInterval)
+		return defaultDiscoverInterval
+	}
+	return interval
+}
+
 func (d *Discover) createDiscoverDaemonSet(namespace, discoverImage, securityAccount string, useCephVolume bool) error {
 	privileged := true
 	discovery_parameters := []string{"discover",
-		"--discover-interval", getEnvVar(discoverIntervalEnv, defaultDiscoverInterval)}
+		"--discover-interval", getDiscoverInterval()}
 	if useCephVolume {
 		discovery_parameters = append(discovery_parameters, "--use-ceph-volume")
 	}
@@ -179,6 +204,14 @@ func (d *Discover) createDiscoverDaemonSet(namespace, discoverImage, securityAcc
 		k8sutil.SetOwnerRefsWithoutBlockOwner(&ds.ObjectMeta, operatorPod.OwnerReferences)
 	}
 
+	// Pass the filters of the devices on to the discover pods
+	for _, name := range []string{discoverDaemon.UdevBlacklistEnvVar, discoverDaemon.DeviceExclusionsEnvVar} {
+		if value := os.Getenv(name); value != "" {
+			container := &ds.Spec.Template.Spec.Containers[0]
+			container.Env = append(container.Env, v1.EnvVar{Name: name, Value: value})
+		}
+	}
+
 	// Add toleration if any
 	tolerationValue := os.Getenv(discoverDaemonsetTolerationEnv)
 	if tolerationValue != "" {
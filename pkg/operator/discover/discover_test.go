@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -86,6 +87,39 @@ func TestStartDiscoveryDaemonset(t *testing.T) {
 	image := agentDS.Spec.Template.Spec.Containers[0].Image
 	assert.Equal(t, "rook/rook:myversion", image)
 	assert.Nil(t, agentDS.Spec.Template.Spec.Tolerations)
+	assert.Equal(t, []string{"discover", "--discover-interval", "60m"}, agentDS.Spec.Template.Spec.Containers[0].Args)
+
+	// the filters of the devices are passed on to the discover pods
+	os.Setenv(discoverDaemon.DeviceExclusionsEnvVar, "^/dev/dm-[0-9]+$")
+	defer os.Unsetenv(discoverDaemon.DeviceExclusionsEnvVar)
+	err = a.Start(namespace, "rook/rook:myversion", "mysa", false)
+	assert.Nil(t, err)
+	agentDS, err = clientset.AppsV1().DaemonSets(namespace).Get("rook-discover", metav1.GetOptions{})
+	assert.Nil(t, err)
+	envs = agentDS.Spec.Template.Spec.Containers[0].Env
+	assert.Equal(t, 4, len(envs))
+	assert.Equal(t, v1.EnvVar{Name: discoverDaemon.DeviceExclusionsEnvVar, Value: "^/dev/dm-[0-9]+$"}, envs[3])
+
+	// the daemonset is removed when discovery is disabled
+	err = a.Stop(namespace)
+	assert.Nil(t, err)
+	_, err = clientset.AppsV1().DaemonSets(namespace).Get("rook-discover", metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	err = a.Stop(namespace)
+	assert.Nil(t, err)
+}
+
+func TestGetDiscoverInterval(t *testing.T) {
+	assert.Equal(t, "60m", getDiscoverInterval())
+
+	os.Setenv(discoverIntervalEnv, "5m")
+	defer os.Unsetenv(discoverIntervalEnv)
+	assert.Equal(t, "5m", getDiscoverInterval())
+
+	for _, interval := range []string{"5", "abc", "-1m", "0s"} {
+		os.Setenv(discoverIntervalEnv, interval)
+		assert.Equal(t, "60m", getDiscoverInterval(), interval)
+	}
 }
 
 func TestGetAvailableDevices(t *testing.T) {
@@ -17,7 +17,9 @@ limitations under the License.
 package k8sutil
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/rook/rook/pkg/clusterd"
@@ -31,6 +33,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// SpecHashAnnotation is the hash of the desired deployment the existing deployment was updated to
+const SpecHashAnnotation = "rook.io/spec-hash"
+
 // GetDeploymentImage returns the version of the image running in the pod spec for the desired container
 func GetDeploymentImage(clientset kubernetes.Interface, namespace, name, container string) (string, error) {
 	d, err := clientset.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
@@ -73,9 +78,20 @@ func UpdateDeploymentAndWait(context *clusterd.Context, deployment *apps.Deploym
 		return nil, fmt.Errorf("failed to check if deployment %s can be updated: %+v", deployment.Name, err)
 	}
 
-	logger.Infof("updating deployment %s", deployment.Name)
-	if _, err := context.Clientset.AppsV1().Deployments(namespace).Update(deployment); err != nil {
-		return nil, fmt.Errorf("failed to update deployment %s. %+v", deployment.Name, err)
+	observedGeneration := original.Status.ObservedGeneration
+	if selectorChanged(original, deployment) {
+		// the selector of an apps/v1 deployment is immutable, the deployment must be recreated with the new selector
+		logger.Infof("selector of deployment %s changed. recreating deployment", deployment.Name)
+		if err := recreateDeployment(context.Clientset, deployment, namespace); err != nil {
+			return nil, err
+		}
+		// the status of the new deployment starts over
+		observedGeneration = 0
+	} else {
+		logger.Infof("updating deployment %s", deployment.Name)
+		if _, err := context.Clientset.AppsV1().Deployments(namespace).Update(deployment); err != nil {
+			return nil, fmt.Errorf("failed to update deployment %s. %+v", deployment.Name, err)
+		}
 	}
 
 	// wait for the deployment to be restarted
@@ -91,7 +107,7 @@ func UpdateDeploymentAndWait(context *clusterd.Context, deployment *apps.Deploym
 		if err != nil {
 			return nil, fmt.Errorf("failed to get deployment %s. %+v", deployment.Name, err)
 		}
-		if d.Status.ObservedGeneration != original.Status.ObservedGeneration && d.Status.UpdatedReplicas > 0 && d.Status.ReadyReplicas > 0 {
+		if d.Status.ObservedGeneration != observedGeneration && d.Status.UpdatedReplicas > 0 && d.Status.ReadyReplicas > 0 {
 			logger.Infof("finished waiting for updated deployment %s", d.Name)
 
 			// Now we check if we can go to the next daemon
@@ -109,6 +125,28 @@ func UpdateDeploymentAndWait(context *clusterd.Context, deployment *apps.Deploym
 	return nil, fmt.Errorf("gave up waiting for deployment %s to update", deployment.Name)
 }
 
+// selectorChanged returns whether the selector of the desired deployment is different from the
+// selector of the existing deployment. Deployments created with the extensions/v1beta1 API may have a
+// selector defaulted from the pod labels that does not match the selector now set by Rook.
+func selectorChanged(existing, desired *apps.Deployment) bool {
+	if desired.Spec.Selector == nil {
+		return false
+	}
+	return !reflect.DeepEqual(existing.Spec.Selector, desired.Spec.Selector)
+}
+
+// recreateDeployment deletes the existing deployment and creates the desired deployment
+func recreateDeployment(clientset kubernetes.Interface, deployment *apps.Deployment, namespace string) error {
+	if err := DeleteDeployment(clientset, namespace, deployment.Name); err != nil {
+		return fmt.Errorf("failed to delete deployment %s. %+v", deployment.Name, err)
+	}
+	deployment.ResourceVersion = ""
+	if _, err := clientset.AppsV1().Deployments(namespace).Create(deployment); err != nil {
+		return fmt.Errorf("failed to recreate deployment %s. %+v", deployment.Name, err)
+	}
+	return nil
+}
+
 // GetDeployments returns a list of deployment names labels matching a given selector
 // example of a label selector might be "app=rook-ceph-mon, mon!=b"
 // more: https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/
@@ -238,3 +276,26 @@ func CreateDeployment(name, namespace string, clientset kubernetes.Interface, de
 	}
 	return err
 }
+
+// ApplySpecHash annotates the deployment with a hash of its labels and spec so that changes to the
+// desired deployment can be detected without comparing with the defaults set by kubernetes
+func ApplySpecHash(d *apps.Deployment) {
+	hash, err := json.Marshal(struct {
+		Labels map[string]string
+		Spec   apps.DeploymentSpec
+	}{d.Labels, d.Spec})
+	if err != nil {
+		logger.Warningf("failed to hash the spec of deployment %q. %v", d.Name, err)
+		return
+	}
+	if d.Annotations == nil {
+		d.Annotations = map[string]string{}
+	}
+	d.Annotations[SpecHashAnnotation] = Hash(string(hash))
+}
+
+// DeploymentChanged returns whether the desired deployment is different from the existing deployment
+func DeploymentChanged(existing, desired *apps.Deployment) bool {
+	hash, ok := desired.Annotations[SpecHashAnnotation]
+	return !ok || existing.Annotations[SpecHashAnnotation] != hash
+}
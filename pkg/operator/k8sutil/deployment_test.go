@@ -0,0 +1,52 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRecreateDeploymentWithNewSelector(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	existing := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mgr-a", Namespace: "ns"},
+		Spec: apps.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "rook-ceph-mgr"}},
+		},
+	}
+	_, err := clientset.AppsV1().Deployments("ns").Create(existing)
+	assert.NoError(t, err)
+
+	desired := existing.DeepCopy()
+	assert.False(t, selectorChanged(existing, desired))
+	desired.Spec.Selector = nil
+	assert.False(t, selectorChanged(existing, desired))
+
+	desired.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "rook-ceph-mgr", "mgr": "a"}}
+	desired.ResourceVersion = "1"
+	assert.True(t, selectorChanged(existing, desired))
+
+	assert.NoError(t, recreateDeployment(clientset, desired, "ns"))
+	d, err := clientset.AppsV1().Deployments("ns").Get("rook-ceph-mgr-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, desired.Spec.Selector, d.Spec.Selector)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// eventSourceComponent is the component reported as the source of the events created by the operator
+	eventSourceComponent = "rook-ceph-operator"
+)
+
+// CreateEvent records an event about the object referenced by the owner reference, for example to report
+// an invalid setting of a CR that prevents a daemon from being deployed
+func CreateEvent(clientset kubernetes.Interface, namespace string, object *metav1.OwnerReference, eventType, reason, message string) error {
+	now := metav1.NewTime(time.Now())
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// the same format as the names of the events created by the kubernetes event recorder
+			Name:      fmt.Sprintf("%s.%x", object.Name, now.UnixNano()),
+			Namespace: namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: object.APIVersion,
+			Kind:       object.Kind,
+			Name:       object.Name,
+			Namespace:  namespace,
+			UID:        object.UID,
+		},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         v1.EventSource{Component: eventSourceComponent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := clientset.CoreV1().Events(namespace).Create(event); err != nil {
+		return fmt.Errorf("failed to create %s event %q for %s %q. %+v", eventType, reason, object.Kind, object.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateEvent(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	owner := &metav1.OwnerReference{APIVersion: "ceph.rook.io/v1", Kind: "CephCluster", Name: "rook-ceph", UID: "uid"}
+
+	err := CreateEvent(clientset, "rook-ceph", owner, v1.EventTypeWarning, "InvalidResources", "the memory limit is too low")
+	assert.NoError(t, err)
+
+	events, err := clientset.CoreV1().Events("rook-ceph").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(events.Items))
+	event := events.Items[0]
+	assert.Equal(t, v1.EventTypeWarning, event.Type)
+	assert.Equal(t, "InvalidResources", event.Reason)
+	assert.Equal(t, "the memory limit is too low", event.Message)
+	assert.Equal(t, v1.ObjectReference{APIVersion: "ceph.rook.io/v1", Kind: "CephCluster", Name: "rook-ceph", Namespace: "rook-ceph", UID: "uid"}, event.InvolvedObject)
+	assert.Equal(t, "rook-ceph-operator", event.Source.Component)
+}
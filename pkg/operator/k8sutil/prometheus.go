@@ -8,6 +8,7 @@ import (
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	monitoringclient "github.com/coreos/prometheus-operator/pkg/client/versioned"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sYAML "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -49,10 +50,14 @@ func CreateOrUpdateServiceMonitor(serviceMonitorDefinition *monitoringv1.Service
 		if !errors.IsAlreadyExists(err) {
 			return nil, fmt.Errorf("failed to create servicemonitor. %+v", err)
 		}
-		sm, err = client.MonitoringV1().ServiceMonitors(namespace).Update(sm)
+		existing, err := client.MonitoringV1().ServiceMonitors(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get servicemonitor. %+v", err)
+		}
+		serviceMonitorDefinition.ResourceVersion = existing.ResourceVersion
+		sm, err = client.MonitoringV1().ServiceMonitors(namespace).Update(serviceMonitorDefinition)
 		if err != nil {
 			return nil, fmt.Errorf("failed to update servicemonitor. %+v", err)
-
 		}
 	}
 	return sm, nil
@@ -86,6 +91,11 @@ func CreateOrUpdatePrometheusRule(prometheusRule *monitoringv1.PrometheusRule) (
 		if !errors.IsAlreadyExists(err) {
 			return nil, fmt.Errorf("failed to create prometheusRules. %+v", err)
 		}
+		existing, err := client.MonitoringV1().PrometheusRules(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get prometheusRule. %+v", err)
+		}
+		prometheusRule.ResourceVersion = existing.ResourceVersion
 		promRule, err = client.MonitoringV1().PrometheusRules(namespace).Update(prometheusRule)
 		if err != nil {
 			return nil, fmt.Errorf("failed to update prometheusRule. %+v", err)
@@ -32,11 +32,16 @@ const (
 	CryptType    = "crypt"
 	LVMType      = "lvm"
 	LinearType   = "linear"
+	MultiPath    = "mpath"
+	LoopType     = "loop"
 	sgdisk       = "sgdisk"
 	mountCmd     = "mount"
 	cephLVPrefix = "ceph--"
 )
 
+// MultipathMemberFS is the filesystem type that udev reports for the paths of a multipath device
+const MultipathMemberFS = "mpath_member"
+
 type Partition struct {
 	Name       string
 	Size       uint64
@@ -392,6 +392,11 @@ func (h *CephInstaller) UninstallRookFromMultipleNS(gatherLogs bool, systemNames
 		"cephblockpools.ceph.rook.io",
 		"cephobjectstores.ceph.rook.io",
 		"cephobjectstoreusers.ceph.rook.io",
+		"cephobjectrealms.ceph.rook.io",
+		"cephobjectzonegroups.ceph.rook.io",
+		"cephobjectzones.ceph.rook.io",
+		"cephbuckettopics.ceph.rook.io",
+		"cephbucketnotifications.ceph.rook.io",
 		"cephfilesystems.ceph.rook.io",
 		"cephnfses.ceph.rook.io",
 		"cephclients.ceph.rook.io",
@@ -639,6 +639,25 @@ rules:
   - create
   - update
   - delete
+- apiGroups:
+  - networking.k8s.io
+  resources:
+  - ingresses
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  # The mgr service account is created if it does not exist
+  - serviceaccounts
+  verbs:
+  - get
+  - create
 ---
 apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRole
@@ -695,6 +714,13 @@ rules:
   - get
   - list
   - watch
+- apiGroups:
+  - scheduling.k8s.io
+  resources:
+  # The priority classes of the daemons are verified before the daemons are started
+  - priorityclasses
+  verbs:
+  - get
 - apiGroups:
   - batch
   resources: